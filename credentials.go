@@ -0,0 +1,32 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mingregister/fers/pkg/config"
+)
+
+// runEncryptCredentials reads an OSS access key id and secret, one per line,
+// from in and prints the storage.oss.credentials_enc value encrypting them with key
+func runEncryptCredentials(key string, in io.Reader, out, errOut io.Writer) int {
+	scanner := bufio.NewScanner(in)
+	var lines []string
+	for len(lines) < 2 && scanner.Scan() {
+		lines = append(lines, strings.TrimSpace(scanner.Text()))
+	}
+	if len(lines) < 2 {
+		fmt.Fprintln(errOut, "expected the access key id and secret on two lines of stdin")
+		return exitUsage
+	}
+
+	blob, err := config.EncryptCredentials(key, lines[0], lines[1])
+	if err != nil {
+		fmt.Fprintln(errOut, err)
+		return exitUsage
+	}
+	fmt.Fprintln(out, blob)
+	return exitOK
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mingregister/fers/pkg/config"
+)
+
+func TestRunEncryptCredentials(t *testing.T) {
+	var out, errOut strings.Builder
+	code := runEncryptCredentials("creds-key", strings.NewReader("LTAI-id\nsecret-value\n"), &out, &errOut)
+	if code != exitOK {
+		t.Fatalf("Expected exit %d, got %d: %s", exitOK, code, errOut.String())
+	}
+
+	cfg := &config.Config{Storage: config.Storage{Oss: config.OSS{CredentialsEnc: strings.TrimSpace(out.String())}}}
+	if err := cfg.ResolveCredentials("creds-key"); err != nil {
+		t.Fatalf("ResolveCredentials failed: %v", err)
+	}
+	if cfg.Storage.Oss.AccessKeyID != "LTAI-id" || cfg.Storage.Oss.AccessKeySecret != "secret-value" {
+		t.Errorf("Unexpected credentials %q / %q", cfg.Storage.Oss.AccessKeyID, cfg.Storage.Oss.AccessKeySecret)
+	}
+}
+
+func TestRunEncryptCredentials_Usage(t *testing.T) {
+	var out, errOut strings.Builder
+	if code := runEncryptCredentials("creds-key", strings.NewReader("only-id\n"), &out, &errOut); code != exitUsage {
+		t.Errorf("Expected exit %d with a missing secret, got %d", exitUsage, code)
+	}
+	if code := runEncryptCredentials("", strings.NewReader("id\nsecret\n"), &out, &errOut); code != exitUsage {
+		t.Errorf("Expected exit %d without a key, got %d", exitUsage, code)
+	}
+	if out.Len() != 0 {
+		t.Errorf("Nothing should be printed on errors, got %q", out.String())
+	}
+}
@@ -5,7 +5,10 @@ go 1.24.6
 require (
 	fyne.io/fyne/v2 v2.6.3
 	github.com/aliyun/alibabacloud-oss-go-sdk-v2 v1.3.0
+	github.com/fsnotify/fsnotify v1.9.0
+	github.com/klauspost/compress v1.17.11
 	github.com/spf13/viper v1.21.0
+	golang.org/x/crypto v0.33.0
 )
 
 require (
@@ -13,7 +16,6 @@ require (
 	github.com/BurntSushi/toml v1.4.0 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/fredbi/uri v1.1.0 // indirect
-	github.com/fsnotify/fsnotify v1.9.0 // indirect
 	github.com/fyne-io/gl-js v0.2.0 // indirect
 	github.com/fyne-io/glfw-js v0.3.0 // indirect
 	github.com/fyne-io/image v0.1.1 // indirect
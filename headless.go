@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/mingregister/fers/pkg/config"
+	"github.com/mingregister/fers/pkg/crypto"
+	"github.com/mingregister/fers/pkg/dir"
+)
+
+// Exit codes of a headless run
+const (
+	exitOK      = 0
+	exitFailure = 1 // 操作失败或有文件失败
+	exitUsage   = 2 // 参数或配置错误
+)
+
+// Operations accepted by a headless run
+const (
+	headlessUpload   = "upload"
+	headlessDownload = "download"
+	headlessSync     = "sync" // 先上传再下载
+)
+
+// headlessOptions selects what a headless run does and how it reports
+type headlessOptions struct {
+	Op   string // upload、download 或 sync，空表示 sync
+	JSON bool   // 以 JSON 对象而不是 FERS-RESULT 行输出汇总
+
+	KeystorePassphrase string // crypto_key_source 为 keystore 时解锁用的口令，来自环境变量
+}
+
+// headlessSummary is the machine-readable result printed at the end of a headless run
+type headlessSummary struct {
+	Operation  string  `json:"operation"`
+	Uploaded   int     `json:"uploaded"`
+	Downloaded int     `json:"downloaded"`
+	Failed     int     `json:"failed"`
+	Elapsed    float64 `json:"elapsed_seconds"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// add counts the files of a batch result; upload reports which counter succeeded files go to
+func (s *headlessSummary) add(result *dir.BatchResult, upload bool) {
+	if result == nil {
+		return
+	}
+	if upload {
+		s.Uploaded += len(result.Succeeded)
+	} else {
+		s.Downloaded += len(result.Succeeded)
+	}
+	s.Failed += len(result.Failed)
+}
+
+// exitCode returns exitOK only when nothing failed
+func (s *headlessSummary) exitCode() int {
+	if s.Failed > 0 || s.Error != "" {
+		return exitFailure
+	}
+	return exitOK
+}
+
+// write prints the summary as a single FERS-RESULT line, or as a JSON object
+func (s *headlessSummary) write(w io.Writer, asJSON bool) error {
+	if asJSON {
+		return json.NewEncoder(w).Encode(s)
+	}
+	line := fmt.Sprintf("FERS-RESULT operation=%s uploaded=%d downloaded=%d failed=%d elapsed=%.1fs",
+		s.Operation, s.Uploaded, s.Downloaded, s.Failed, s.Elapsed)
+	if s.Error != "" {
+		line += " error=" + strconv.Quote(s.Error)
+	}
+	_, err := fmt.Fprintln(w, line)
+	return err
+}
+
+// runHeadless runs a sync operation without building any window, logging to
+// out and ending with a summary, and returns the process exit code
+func runHeadless(cfg *config.Config, opts headlessOptions, out io.Writer) int {
+	logOptions := &slog.HandlerOptions{Level: slog.Level(cfg.LogLevel)}
+	fileLogHandler, closeFileLog, err := openFileLog(cfg, logOptions)
+	if err != nil {
+		fmt.Fprintln(out, err)
+		return exitUsage
+	}
+	defer closeFileLog()
+	logger := slog.New(newFanoutHandler(slog.NewTextHandler(out, logOptions), fileLogHandler))
+
+	op := opts.Op
+	if op == "" {
+		op = headlessSync
+	}
+	if op != headlessUpload && op != headlessDownload && op != headlessSync {
+		logger.Error("Unknown headless operation", slog.String("operation", op))
+		return exitUsage
+	}
+	if cfg.CryptoKeySource == "prompt" {
+		// 无界面时无法弹窗输入密钥
+		logger.Error("crypto_key_source prompt is not supported in headless mode")
+		return exitUsage
+	}
+
+	cipherOptions, err := cfg.CipherOptions()
+	if err != nil {
+		logger.Error("Invalid cipher settings", slog.String("error", err.Error()))
+		return exitUsage
+	}
+	var cipher crypto.Cipher
+	if path, ok := keystorePath(cfg.CryptoKeySource); ok {
+		passphrase := opts.KeystorePassphrase
+		if passphrase == "" {
+			logger.Error("crypto_key_source keystore needs the passphrase in $" + keystorePassphraseEnv + " in headless mode")
+			return exitUsage
+		}
+		assessKey(logger, passphrase)
+		if cipher, err = openKeystore(path, passphrase, logger, cipherOptions...); err != nil {
+			logger.Error("Failed to open keystore", slog.String("error", err.Error()))
+			return exitUsage
+		}
+	} else {
+		assessKey(logger, cfg.CryptoKey)
+		cipher = crypto.NewAESGCM(cfg.CryptoKey, cipherOptions...)
+	}
+	if closer, ok := cipher.(io.Closer); ok {
+		defer closer.Close()
+	}
+	sources, closeSources, err := newSources(cfg, logger, cipher)
+	if err != nil {
+		logger.Error("Failed to create storage client", slog.String("error", err.Error()))
+		return exitUsage
+	}
+	defer closeSources()
+
+	start := time.Now()
+	summary := &headlessSummary{Operation: op}
+	defer func() {
+		summary.Elapsed = time.Since(start).Seconds()
+		if err := summary.write(out, opts.JSON); err != nil {
+			logger.Error("Failed to write summary", slog.String("error", err.Error()))
+		}
+	}()
+
+	ctx := context.Background()
+	if timeout := sources[0].fileManager.OperationTimeout(); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	// 依次处理每个备份集，一个失败后不再继续
+	for _, src := range sources {
+		sourceLogger := logger
+		if src.name != "" {
+			sourceLogger = logger.With(slog.String("source", src.name))
+		}
+		if err := runHeadlessSource(ctx, src.fileManager, op, summary, sourceLogger); err != nil {
+			summary.Error = err.Error()
+			break
+		}
+	}
+	return summary.exitCode()
+}
+
+// runHeadlessSource prepares the working directory and key of one source and runs op on it
+func runHeadlessSource(ctx context.Context, fileManager *dir.FileManager, op string, summary *headlessSummary, logger *slog.Logger) error {
+	if err := fileManager.EnsureWorkingDir(); err != nil {
+		logger.Error("Failed to prepare working directory", slog.String("error", err.Error()))
+		return err
+	}
+	// 密钥与远程不符时不能继续，否则会混用两个密钥
+	if err := fileManager.CheckKey(); err != nil {
+		logger.Error("Failed to check crypto key", slog.String("error", err.Error()))
+		return err
+	}
+
+	failedBefore := summary.Failed
+	if err := runHeadlessOperation(ctx, fileManager, op, summary); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = dir.ErrTimeout
+		}
+		logger.Error("Operation failed", slog.String("operation", op), slog.String("error", err.Error()))
+		return err
+	}
+	if failed := summary.Failed - failedBefore; failed > 0 {
+		logger.Warn("Operation completed with errors", slog.String("operation", op), slog.Int("failed", failed))
+	} else {
+		logger.Info("Operation completed successfully", slog.String("operation", op))
+	}
+	return nil
+}
+
+// runHeadlessOperation runs op and adds the files processed to summary
+func runHeadlessOperation(ctx context.Context, fileManager *dir.FileManager, op string, summary *headlessSummary) error {
+	if op == headlessUpload || op == headlessSync {
+		result, err := fileManager.SyncUpload(ctx)
+		summary.add(result, true)
+		if err != nil {
+			return fmt.Errorf("sync upload: %w", err)
+		}
+	}
+	if op == headlessDownload || op == headlessSync {
+		result, err := fileManager.SyncDownload(ctx)
+		summary.add(result, false)
+		if err != nil {
+			return fmt.Errorf("sync download: %w", err)
+		}
+	}
+	return nil
+}
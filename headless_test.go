@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mingregister/fers/pkg/config"
+)
+
+// newHeadlessConfig returns a config using the local mock storage in remoteDir
+func newHeadlessConfig(workingDir, remoteDir string) *config.Config {
+	return &config.Config{
+		CryptoKey: "headless-key",
+		TargetDir: workingDir,
+		Storage: config.Storage{
+			RemoteType: "localhost",
+			Localhost:  config.Localhost{Workdir: remoteDir},
+		},
+	}
+}
+
+func TestRunHeadless_UploadThenDownload(t *testing.T) {
+	remoteDir := t.TempDir()
+	srcDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(srcDir, "docs"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	files := map[string]string{"a.txt": "alpha", filepath.Join("docs", "b.txt"): "beta"}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(srcDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	var out bytes.Buffer
+	if code := runHeadless(newHeadlessConfig(srcDir, remoteDir), headlessOptions{Op: "upload"}, &out); code != exitOK {
+		t.Fatalf("Upload exited with %d:\n%s", code, out.String())
+	}
+
+	// A fresh, not yet existing working directory receives the files
+	dstDir := filepath.Join(t.TempDir(), "restore")
+	out.Reset()
+	if code := runHeadless(newHeadlessConfig(dstDir, remoteDir), headlessOptions{Op: "download"}, &out); code != exitOK {
+		t.Fatalf("Download exited with %d:\n%s", code, out.String())
+	}
+	for name, content := range files {
+		data, err := os.ReadFile(filepath.Join(dstDir, name))
+		if err != nil {
+			t.Errorf("Expected %s to be downloaded: %v", name, err)
+			continue
+		}
+		if string(data) != content {
+			t.Errorf("Unexpected content of %s: %q", name, data)
+		}
+	}
+	if !strings.Contains(out.String(), "Operation completed successfully") {
+		t.Errorf("Expected the run to be logged, got:\n%s", out.String())
+	}
+}
+
+func TestRunHeadless_WrongKeyFails(t *testing.T) {
+	remoteDir := t.TempDir()
+	srcDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(srcDir, "a.txt"), []byte("alpha"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	var out bytes.Buffer
+	if code := runHeadless(newHeadlessConfig(srcDir, remoteDir), headlessOptions{Op: "sync"}, &out); code != exitOK {
+		t.Fatalf("Sync exited with %d:\n%s", code, out.String())
+	}
+
+	cfg := newHeadlessConfig(t.TempDir(), remoteDir)
+	cfg.CryptoKey = "wrong-key"
+	if code := runHeadless(cfg, headlessOptions{Op: "download"}, &out); code != exitFailure {
+		t.Errorf("Expected exit code %d when files fail to decrypt, got %d", exitFailure, code)
+	}
+}
+
+func TestRunHeadless_UnknownOperation(t *testing.T) {
+	var out bytes.Buffer
+	cfg := newHeadlessConfig(t.TempDir(), t.TempDir())
+	if code := runHeadless(cfg, headlessOptions{Op: "mirror"}, &out); code != exitUsage {
+		t.Errorf("Expected exit code %d for an unknown operation, got %d", exitUsage, code)
+	}
+}
+
+// uploadHeadlessFixture uploads a.txt and b.txt to the mock storage in remoteDir
+func uploadHeadlessFixture(t *testing.T, remoteDir string) {
+	t.Helper()
+	srcDir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if err := os.WriteFile(filepath.Join(srcDir, name), []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+	var out bytes.Buffer
+	if code := runHeadless(newHeadlessConfig(srcDir, remoteDir), headlessOptions{Op: "upload"}, &out); code != exitOK {
+		t.Fatalf("Upload exited with %d:\n%s", code, out.String())
+	}
+}
+
+// parseSummaryLine returns the fields of the FERS-RESULT line in out
+func parseSummaryLine(t *testing.T, out string) map[string]string {
+	t.Helper()
+	for _, line := range strings.Split(out, "\n") {
+		rest, ok := strings.CutPrefix(line, "FERS-RESULT ")
+		if !ok {
+			continue
+		}
+		fields := map[string]string{}
+		for _, field := range strings.Fields(rest) {
+			key, value, _ := strings.Cut(field, "=")
+			fields[key] = value
+		}
+		return fields
+	}
+	t.Fatalf("No FERS-RESULT line in output:\n%s", out)
+	return nil
+}
+
+// parseSummaryJSON decodes the JSON summary, the last line of out
+func parseSummaryJSON(t *testing.T, out string) headlessSummary {
+	t.Helper()
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	var summary headlessSummary
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &summary); err != nil {
+		t.Fatalf("Last line is not a JSON summary: %v\n%s", err, out)
+	}
+	return summary
+}
+
+func TestRunHeadless_SummaryLine(t *testing.T) {
+	remoteDir := t.TempDir()
+	uploadHeadlessFixture(t, remoteDir)
+
+	var out bytes.Buffer
+	if code := runHeadless(newHeadlessConfig(t.TempDir(), remoteDir), headlessOptions{Op: "download"}, &out); code != exitOK {
+		t.Fatalf("Download exited with %d:\n%s", code, out.String())
+	}
+	fields := parseSummaryLine(t, out.String())
+	if fields["operation"] != "download" || fields["uploaded"] != "0" || fields["downloaded"] != "2" || fields["failed"] != "0" {
+		t.Errorf("Unexpected summary %v", fields)
+	}
+	if !strings.HasSuffix(fields["elapsed"], "s") {
+		t.Errorf("Expected elapsed in seconds, got %q", fields["elapsed"])
+	}
+
+	// 损坏一个远程文件，另一个仍能下载
+	if err := os.WriteFile(filepath.Join(remoteDir, "b.txt"), []byte("corrupted"), 0644); err != nil {
+		t.Fatalf("Failed to corrupt remote file: %v", err)
+	}
+	out.Reset()
+	if code := runHeadless(newHeadlessConfig(t.TempDir(), remoteDir), headlessOptions{Op: "download"}, &out); code != exitFailure {
+		t.Errorf("Expected exit code %d for a partial failure, got %d", exitFailure, code)
+	}
+	fields = parseSummaryLine(t, out.String())
+	if fields["downloaded"] != "1" || fields["failed"] != "1" {
+		t.Errorf("Unexpected partial failure summary %v", fields)
+	}
+}
+
+func TestRunHeadless_SummaryJSON(t *testing.T) {
+	remoteDir := t.TempDir()
+	uploadHeadlessFixture(t, remoteDir)
+
+	var out bytes.Buffer
+	opts := headlessOptions{Op: "download", JSON: true}
+	if code := runHeadless(newHeadlessConfig(t.TempDir(), remoteDir), opts, &out); code != exitOK {
+		t.Fatalf("Download exited with %d:\n%s", code, out.String())
+	}
+	summary := parseSummaryJSON(t, out.String())
+	if summary.Operation != "download" || summary.Downloaded != 2 || summary.Failed != 0 || summary.Error != "" {
+		t.Errorf("Unexpected summary %+v", summary)
+	}
+	if strings.Contains(out.String(), "FERS-RESULT") {
+		t.Error("JSON output should not include the text summary line")
+	}
+
+	if err := os.WriteFile(filepath.Join(remoteDir, "a.txt"), []byte("corrupted"), 0644); err != nil {
+		t.Fatalf("Failed to corrupt remote file: %v", err)
+	}
+	out.Reset()
+	if code := runHeadless(newHeadlessConfig(t.TempDir(), remoteDir), opts, &out); code != exitFailure {
+		t.Errorf("Expected exit code %d for a partial failure, got %d", exitFailure, code)
+	}
+	summary = parseSummaryJSON(t, out.String())
+	if summary.Downloaded != 1 || summary.Failed != 1 {
+		t.Errorf("Unexpected partial failure summary %+v", summary)
+	}
+}
+
+func TestRunHeadless_Keystore(t *testing.T) {
+	remoteDir := t.TempDir()
+	srcDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(srcDir, "a.txt"), []byte("alpha"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	cfg := newHeadlessConfig(srcDir, remoteDir)
+	cfg.CryptoKey = ""
+	cfg.CryptoKeySource = keystoreSourcePrefix + filepath.Join(t.TempDir(), "fers.keystore")
+
+	var out bytes.Buffer
+	if code := runHeadless(cfg, headlessOptions{Op: "upload"}, &out); code != exitUsage {
+		t.Fatalf("Expected exit code %d without a passphrase, got %d", exitUsage, code)
+	}
+	opts := headlessOptions{Op: "upload", KeystorePassphrase: "headless passphrase"}
+	if code := runHeadless(cfg, opts, &out); code != exitOK {
+		t.Fatalf("Upload exited with %d:\n%s", code, out.String())
+	}
+
+	cfg.TargetDir = t.TempDir()
+	opts.Op = "download"
+	if code := runHeadless(cfg, opts, &out); code != exitOK {
+		t.Fatalf("Download exited with %d:\n%s", code, out.String())
+	}
+	if data, err := os.ReadFile(filepath.Join(cfg.TargetDir, "a.txt")); err != nil || string(data) != "alpha" {
+		t.Errorf("Expected a.txt to be restored with the keystore key, got %q, %v", data, err)
+	}
+}
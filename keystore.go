@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/mingregister/fers/pkg/crypto"
+	"github.com/mingregister/fers/pkg/crypto/keystore"
+)
+
+// keystoreSourcePrefix selects a keystore file as the crypto key source: keystore:/path/to/file
+const keystoreSourcePrefix = "keystore:"
+
+// keystorePassphraseEnv holds the keystore passphrase in headless mode, where it cannot be prompted for
+const keystorePassphraseEnv = "FERS_KEYSTORE_PASSPHRASE"
+
+// keystorePath returns the keystore file of a crypto_key_source, ok is false for other sources
+func keystorePath(source string) (path string, ok bool) {
+	path, ok = strings.CutPrefix(source, keystoreSourcePrefix)
+	return path, ok
+}
+
+// openKeystore unlocks the keystore at path with passphrase and returns a cipher
+// using its master key. A keystore that does not exist yet is created with
+// passphrase and a new random master key.
+func openKeystore(path, passphrase string, logger *slog.Logger, options ...crypto.AESGCMOption) (crypto.Cipher, error) {
+	if path == "" {
+		return nil, errors.New("crypto_key_source keystore: needs a file path")
+	}
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		if err := keystore.Create(path, passphrase); err != nil {
+			return nil, err
+		}
+		logger.Info("Created keystore with a new master key, keep a backup of it", slog.String("path", path))
+	}
+
+	master, err := keystore.Open(path, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unlock keystore %s: %w", path, err)
+	}
+	defer clear(master)
+	return crypto.NewAESGCMWithKey(master, options...)
+}
+
+// startWithKeystore asks for the keystore passphrase and calls start with a
+// cipher using the master key it unlocks; an empty passphrase or a keystore
+// that cannot be unlocked calls fail instead
+func startWithKeystore(prompt passwordPrompt, path string, logger *slog.Logger, start func(crypto.Cipher), fail func(error), options ...crypto.AESGCMOption) {
+	prompt(func(passphrase string) {
+		if passphrase == "" {
+			fail(errEmptyPassword)
+			return
+		}
+		c, err := openKeystore(path, passphrase, logger, options...)
+		if err != nil {
+			fail(err)
+			return
+		}
+		start(c)
+	})
+}
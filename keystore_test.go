@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mingregister/fers/pkg/crypto"
+	"github.com/mingregister/fers/pkg/crypto/keystore"
+)
+
+func TestKeystorePath(t *testing.T) {
+	if path, ok := keystorePath("keystore:/home/me/.fers/keystore"); !ok || path != "/home/me/.fers/keystore" {
+		t.Errorf("Expected the keystore path, got %q, %v", path, ok)
+	}
+	for _, source := range []string{"", "config", "prompt"} {
+		if _, ok := keystorePath(source); ok {
+			t.Errorf("%q should not select a keystore", source)
+		}
+	}
+}
+
+func TestStartWithKeystore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fers.keystore")
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	prompt := func(submit func(string)) { submit("my passphrase") }
+
+	// 第一次启动创建 keystore
+	var first crypto.Cipher
+	startWithKeystore(prompt, path, logger, func(c crypto.Cipher) { first = c }, func(err error) {
+		t.Fatalf("Unexpected startup failure: %v", err)
+	})
+	if first == nil {
+		t.Fatal("start should be called with the cipher")
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("Expected the keystore to be created: %v", err)
+	}
+	encrypted, err := first.Encrypt([]byte("secret data"))
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+
+	// 之后的启动解锁同一个主密钥
+	var second crypto.Cipher
+	startWithKeystore(prompt, path, logger, func(c crypto.Cipher) { second = c }, func(err error) {
+		t.Fatalf("Unexpected startup failure: %v", err)
+	})
+	if plain, err := second.Decrypt(encrypted); err != nil || string(plain) != "secret data" {
+		t.Errorf("Expected the reopened keystore to decrypt the data, got %q, %v", plain, err)
+	}
+}
+
+func TestStartWithKeystore_WrongPassphrase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fers.keystore")
+	if err := keystore.Create(path, "right"); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+
+	var failErr error
+	startWithKeystore(func(submit func(string)) { submit("wrong") }, path, logger, func(crypto.Cipher) {
+		t.Error("start should not be called with the wrong passphrase")
+	}, func(err error) { failErr = err })
+	if !errors.Is(failErr, keystore.ErrWrongPassphrase) {
+		t.Errorf("Expected ErrWrongPassphrase, got %v", failErr)
+	}
+
+	failErr = nil
+	startWithKeystore(func(submit func(string)) { submit("") }, path, logger, func(crypto.Cipher) {
+		t.Error("start should not be called without a passphrase")
+	}, func(err error) { failErr = err })
+	if !errors.Is(failErr, errEmptyPassword) {
+		t.Errorf("Expected errEmptyPassword, got %v", failErr)
+	}
+}
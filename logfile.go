@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/mingregister/fers/pkg/config"
+)
+
+// newFileLogHandler returns the handler that writes the log file in format,
+// text (the default) or json
+func newFileLogHandler(w io.Writer, format string, opts *slog.HandlerOptions) (slog.Handler, error) {
+	switch format {
+	case "", "text":
+		return slog.NewTextHandler(w, opts), nil
+	case "json":
+		return slog.NewJSONHandler(w, opts), nil
+	default:
+		return nil, fmt.Errorf("unsupported log_format %q", format)
+	}
+}
+
+// defaultLogMaxBackups is the number of rotated log files kept when log_max_backups is not set
+const defaultLogMaxBackups = 3
+
+// openFileLog opens the log file of cfg for appending, rotating it by size when
+// log_max_size_mb is set, and returns its handler and the function that closes
+// it. An empty log path disables the file log.
+func openFileLog(cfg *config.Config, opts *slog.HandlerOptions) (slog.Handler, func(), error) {
+	if cfg.Log == "" {
+		return nil, func() {}, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(cfg.Log), 0755); err != nil {
+		return nil, nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+	maxBackups := cfg.LogMaxBackups
+	if maxBackups <= 0 {
+		maxBackups = defaultLogMaxBackups
+	}
+	w, err := newRotatingWriter(cfg.Log, int64(cfg.LogMaxSizeMB)<<20, maxBackups)
+	if err != nil {
+		return nil, nil, err
+	}
+	handler, err := newFileLogHandler(w, cfg.LogFormat, opts)
+	if err != nil {
+		w.Close()
+		return nil, nil, err
+	}
+	return handler, func() { w.Close() }, nil
+}
+
+// rotatingWriter appends to a log file and, once a write would take it past
+// maxSize bytes, renames it to path.1 (shifting older backups up to
+// path.<maxBackups> and deleting the rest) and starts a new file. Writes are
+// serialized, so a record is never split across two files or interleaved
+// with a rotation.
+type rotatingWriter struct {
+	mu         sync.Mutex
+	path       string
+	file       *os.File
+	size       int64
+	maxSize    int64 // 0 表示不轮转
+	maxBackups int
+}
+
+// newRotatingWriter opens path for appending
+func newRotatingWriter(path string, maxSize int64, maxBackups int) (*rotatingWriter, error) {
+	w := &rotatingWriter{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// open opens the log file and records its current size
+func (w *rotatingWriter) open() error {
+	f, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	w.file, w.size = f, info.Size()
+	return nil
+}
+
+func (w *rotatingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return 0, os.ErrClosed
+	}
+	// 单条记录超过上限时仍整条写入，不拆分
+	var rotateErr error
+	if w.maxSize > 0 && w.size > 0 && w.size+int64(len(p)) > w.maxSize {
+		rotateErr = w.rotate()
+		if w.file == nil {
+			return 0, rotateErr
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	if err == nil {
+		err = rotateErr
+	}
+	return n, err
+}
+
+// rotate moves the current file to the first backup and reopens path. When
+// the move fails, e.g. because the file is locked on Windows, path is reopened
+// anyway so logging continues in the current file; the next write retries.
+func (w *rotatingWriter) rotate() error {
+	err := w.file.Close()
+	w.file = nil
+	if err != nil {
+		err = fmt.Errorf("failed to close log file: %w", err)
+	} else {
+		err = w.shiftBackups()
+	}
+	if openErr := w.open(); openErr != nil {
+		return errors.Join(err, openErr)
+	}
+	return err
+}
+
+// shiftBackups renames path to the first backup, moving the older ones up
+func (w *rotatingWriter) shiftBackups() error {
+	if err := w.prune(); err != nil {
+		return err
+	}
+	for i := w.maxBackups - 1; i >= 1; i-- {
+		if err := os.Rename(w.backup(i), w.backup(i+1)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to rotate log file: %w", err)
+		}
+	}
+	if err := os.Rename(w.path, w.backup(1)); err != nil {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+	return nil
+}
+
+// prune removes the backups numbered maxBackups and above, including those
+// left by a larger log_max_backups, so the shift in rotate stays within the limit
+func (w *rotatingWriter) prune() error {
+	entries, err := os.ReadDir(filepath.Dir(w.path))
+	if err != nil {
+		return fmt.Errorf("failed to list log directory: %w", err)
+	}
+	prefix := filepath.Base(w.path) + "."
+	for _, entry := range entries {
+		suffix, ok := strings.CutPrefix(entry.Name(), prefix)
+		if !ok {
+			continue
+		}
+		if i, err := strconv.Atoi(suffix); err != nil || i < w.maxBackups {
+			continue
+		}
+		if err := os.Remove(filepath.Join(filepath.Dir(w.path), entry.Name())); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove old log file: %w", err)
+		}
+	}
+	return nil
+}
+
+// backup returns the path of the i-th most recent rotated file
+func (w *rotatingWriter) backup(i int) string {
+	return fmt.Sprintf("%s.%d", w.path, i)
+}
+
+// Close closes the log file; later writes fail
+func (w *rotatingWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// fanoutHandler passes each record to every handler enabled for its level
+type fanoutHandler []slog.Handler
+
+// newFanoutHandler combines the non-nil handlers, returning a single one unwrapped
+func newFanoutHandler(handlers ...slog.Handler) slog.Handler {
+	var fanout fanoutHandler
+	for _, h := range handlers {
+		if h != nil {
+			fanout = append(fanout, h)
+		}
+	}
+	if len(fanout) == 1 {
+		return fanout[0]
+	}
+	return fanout
+}
+
+func (f fanoutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range f {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f fanoutHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, h := range f {
+		if h.Enabled(ctx, r.Level) {
+			errs = append(errs, h.Handle(ctx, r.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (f fanoutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make(fanoutHandler, len(f))
+	for i, h := range f {
+		handlers[i] = h.WithAttrs(attrs)
+	}
+	return handlers
+}
+
+func (f fanoutHandler) WithGroup(name string) slog.Handler {
+	handlers := make(fanoutHandler, len(f))
+	for i, h := range f {
+		handlers[i] = h.WithGroup(name)
+	}
+	return handlers
+}
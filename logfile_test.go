@@ -0,0 +1,255 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mingregister/fers/pkg/config"
+)
+
+func TestNewFileLogHandler_JSON(t *testing.T) {
+	var buf bytes.Buffer
+	handler, err := newFileLogHandler(&buf, "json", &slog.HandlerOptions{Level: slog.LevelInfo, AddSource: true})
+	if err != nil {
+		t.Fatalf("newFileLogHandler failed: %v", err)
+	}
+	logger := slog.New(handler)
+	logger.Info("File uploaded", slog.String("path", "docs/a.txt"), slog.Int("bytes", 42))
+	logger.Debug("Filtered out by level")
+	logger.Warn("Second line")
+
+	var records []map[string]any
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var record map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("Line is not valid JSON: %q: %v", scanner.Text(), err)
+		}
+		records = append(records, record)
+	}
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 records, got %d", len(records))
+	}
+	first := records[0]
+	if first["level"] != "INFO" || first["msg"] != "File uploaded" || first["path"] != "docs/a.txt" || first["bytes"] != 42.0 {
+		t.Errorf("Unexpected record %v", first)
+	}
+	if _, ok := first["source"].(map[string]any); !ok {
+		t.Errorf("Expected the source to be recorded, got %v", first["source"])
+	}
+}
+
+func TestNewFileLogHandler_Formats(t *testing.T) {
+	for _, format := range []string{"", "text"} {
+		var buf bytes.Buffer
+		handler, err := newFileLogHandler(&buf, format, nil)
+		if err != nil {
+			t.Fatalf("newFileLogHandler(%q) failed: %v", format, err)
+		}
+		slog.New(handler).Info("hello")
+		if !strings.Contains(buf.String(), "level=INFO msg=hello") {
+			t.Errorf("Expected a text record for %q, got %q", format, buf.String())
+		}
+	}
+	if _, err := newFileLogHandler(&bytes.Buffer{}, "xml", nil); err == nil {
+		t.Error("Expected an error for an unsupported format")
+	}
+}
+
+func TestOpenFileLog_AppendsToFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "app.log")
+	for _, msg := range []string{"first", "second"} {
+		handler, closeLog, err := openFileLog(&config.Config{Log: path, LogFormat: "json"}, nil)
+		if err != nil {
+			t.Fatalf("openFileLog failed: %v", err)
+		}
+		slog.New(handler).Info(msg)
+		closeLog()
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 2 {
+		t.Errorf("Expected both runs to be appended, got %q", data)
+	}
+
+	handler, closeLog, err := openFileLog(&config.Config{LogFormat: "json"}, nil)
+	if err != nil || handler != nil {
+		t.Errorf("Expected no file log without a path, got %v (%v)", handler, err)
+	}
+	closeLog()
+}
+
+func TestRotatingWriter_RollsAndPrunes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	// 之前用更大的 log_max_backups 留下的旧文件
+	if err := os.WriteFile(path+".4", []byte("stale\n"), 0644); err != nil {
+		t.Fatalf("Failed to create stale backup: %v", err)
+	}
+	w, err := newRotatingWriter(path, 20, 2)
+	if err != nil {
+		t.Fatalf("newRotatingWriter failed: %v", err)
+	}
+	defer w.Close()
+
+	// 每条 10 字节，每个文件放两条
+	for i := range 7 {
+		if _, err := fmt.Fprintf(w, "record %02d\n", i); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+
+	want := map[string]string{
+		path:        "record 06\n",
+		path + ".1": "record 04\nrecord 05\n",
+		path + ".2": "record 02\nrecord 03\n",
+	}
+	for file, content := range want {
+		data, err := os.ReadFile(file)
+		if err != nil || string(data) != content {
+			t.Errorf("Expected %s to contain %q, got %q (%v)", filepath.Base(file), content, data, err)
+		}
+	}
+	for _, pruned := range []string{path + ".3", path + ".4"} {
+		if _, err := os.Stat(pruned); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be pruned, got %v", filepath.Base(pruned), err)
+		}
+	}
+}
+
+func TestRotatingWriter_ResumesSizeAndNeverSplitsRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(path, []byte("0123456789"), 0644); err != nil {
+		t.Fatalf("Failed to create log: %v", err)
+	}
+	w, err := newRotatingWriter(path, 15, 1)
+	if err != nil {
+		t.Fatalf("newRotatingWriter failed: %v", err)
+	}
+	defer w.Close()
+
+	// 已有 10 字节，再写 10 字节会超过上限，先轮转；超长的单条记录完整写入
+	long := strings.Repeat("x", 30)
+	for _, record := range []string{"abcdefghij", long} {
+		if _, err := w.Write([]byte(record)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	if data, _ := os.ReadFile(path + ".1"); string(data) != "abcdefghij" {
+		t.Errorf("Expected the previous record in the backup, got %q", data)
+	}
+	if data, _ := os.ReadFile(path); string(data) != long {
+		t.Errorf("Expected the long record whole in the new file, got %q", data)
+	}
+}
+
+func TestRotatingWriter_KeepsWritingWhenRotationFails(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	// 备份位置是一个非空目录，无法删除也无法覆盖
+	if err := os.MkdirAll(filepath.Join(path+".1", "locked"), 0755); err != nil {
+		t.Fatalf("Failed to create blocking directory: %v", err)
+	}
+	w, err := newRotatingWriter(path, 25, 1)
+	if err != nil {
+		t.Fatalf("newRotatingWriter failed: %v", err)
+	}
+	defer w.Close()
+
+	for _, record := range []string{"abcdefghij", "klmnopqrst"} {
+		if _, err := w.Write([]byte(record)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	n, err := w.Write([]byte("uvwxyz0123"))
+	if err == nil || n != 10 {
+		t.Errorf("Expected the record written and the rotation error reported, got %d, %v", n, err)
+	}
+	if _, err := w.Write([]byte("456789")); err == nil {
+		t.Error("Expected the rotation to be retried and fail again")
+	}
+
+	if data, _ := os.ReadFile(path); string(data) != "abcdefghijklmnopqrstuvwxyz0123456789" {
+		t.Errorf("Expected logging to continue in the current file, got %q", data)
+	}
+}
+
+func TestRotatingWriter_Concurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	w, err := newRotatingWriter(path, 2048, 50)
+	if err != nil {
+		t.Fatalf("newRotatingWriter failed: %v", err)
+	}
+	handler, err := newFileLogHandler(w, "json", nil)
+	if err != nil {
+		t.Fatalf("newFileLogHandler failed: %v", err)
+	}
+	logger := slog.New(handler)
+
+	const writers, records = 8, 50
+	var wg sync.WaitGroup
+	for g := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range records {
+				logger.Info("record", slog.Int("writer", g), slog.Int("i", i))
+			}
+		}()
+	}
+	wg.Wait()
+	w.Close()
+
+	// 每行都是完整的 JSON 记录，总数不变
+	files, _ := filepath.Glob(path + "*")
+	count := 0
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", file, err)
+		}
+		for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+			if line == "" {
+				continue
+			}
+			var record map[string]any
+			if err := json.Unmarshal([]byte(line), &record); err != nil {
+				t.Fatalf("Interleaved record in %s: %q", filepath.Base(file), line)
+			}
+			count++
+		}
+	}
+	if len(files) < 2 {
+		t.Errorf("Expected the log to rotate, got %v", files)
+	}
+	if count != writers*records {
+		t.Errorf("Expected %d records, got %d", writers*records, count)
+	}
+}
+
+func TestFanoutHandler(t *testing.T) {
+	var debug, warn bytes.Buffer
+	logger := slog.New(newFanoutHandler(
+		slog.NewTextHandler(&debug, &slog.HandlerOptions{Level: slog.LevelDebug}),
+		nil,
+		slog.NewJSONHandler(&warn, &slog.HandlerOptions{Level: slog.LevelWarn}),
+	)).With(slog.String("source", "docs"))
+
+	logger.Debug("details")
+	logger.Warn("careful")
+
+	if !strings.Contains(debug.String(), "msg=details") || !strings.Contains(debug.String(), "msg=careful") {
+		t.Errorf("Expected both records in the debug handler, got %q", debug.String())
+	}
+	if strings.Contains(warn.String(), "details") || !strings.Contains(warn.String(), `"source":"docs"`) {
+		t.Errorf("Expected only the warning, with its attributes, in the warn handler, got %q", warn.String())
+	}
+}
@@ -3,20 +3,28 @@ package main
 
 import (
 	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log/slog"
+	"os"
+	"strings"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
+	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/widget"
 	"github.com/mingregister/fers/pkg/appui"
+	"github.com/mingregister/fers/pkg/buildinfo"
 	"github.com/mingregister/fers/pkg/config"
 	"github.com/mingregister/fers/pkg/crypto"
 	"github.com/mingregister/fers/pkg/dir"
 	"github.com/mingregister/fers/pkg/storage"
 )
 
+// showFatalError shows msg in its own app, for errors before the main app is
+// created; use showStartupError once it exists
 func showFatalError(msg string) {
 	a := app.New()
 	w := a.NewWindow("启动失败")
@@ -29,8 +37,7 @@ func showFatalError(msg string) {
 func NewStorageClient(cfg *config.Storage) (storage.Client, error) {
 	switch cfg.RemoteType {
 	case "localhost":
-		storageClient := storage.NewOSSMock(cfg.Localhost.Workdir)
-		return storageClient, nil
+		return storage.NewOSSMockWithNamespace(cfg.Localhost.Workdir, cfg.Localhost.Namespace)
 	case "oss":
 		storageClient, err := storage.NewOSSClient(
 			cfg.Oss.Endpoint,
@@ -39,50 +46,244 @@ func NewStorageClient(cfg *config.Storage) (storage.Client, error) {
 			cfg.Oss.BucketName,
 			cfg.Oss.Region,
 			cfg.Oss.WorkDir,
+			storage.OSSOptions{
+				ConnectTimeout: cfg.Oss.ConnectTimeout,
+				ReadTimeout:    cfg.Oss.ReadTimeout,
+				WriteTimeout:   cfg.Oss.WriteTimeout,
+				RequestTimeout: cfg.Oss.RequestTimeout,
+				Proxy:          cfg.Oss.Proxy,
+				MaxUploadBps:   cfg.Oss.MaxUploadBps,
+				MaxDownloadBps: cfg.Oss.MaxDownloadBps,
+
+				MultipartThreshold: cfg.Oss.MultipartThreshold,
+				PartSize:           cfg.Oss.PartSize,
+				PartConcurrency:    cfg.Oss.PartConcurrency,
+
+				Namespace: cfg.Oss.Namespace,
+			},
 		)
 		return storageClient, err
+	case "memory":
+		// 数据只保存在内存中，退出后丢失，仅用于演示
+		return storage.NewMemoryClient(), nil
 	default:
 		return nil, fmt.Errorf("unsupport storage %s", cfg.RemoteType)
 	}
 }
 
 func main() {
+	headless := flag.Bool("headless", false, "run without the GUI: fers -headless [upload|download|sync]")
+	jsonSummary := flag.Bool("json", false, "print the headless summary as a JSON object")
+	encryptCredentials := flag.Bool("encrypt-credentials", false,
+		"read an OSS access key id and secret from stdin and print storage.oss.credentials_enc, encrypted with $"+config.CredentialsKeyEnv)
+	flag.Parse()
+
+	if *encryptCredentials {
+		os.Exit(runEncryptCredentials(os.Getenv(config.CredentialsKeyEnv), os.Stdin, os.Stdout, os.Stderr))
+	}
+
 	// Initialize configuration
 	cfg, err := config.NewConfig()
 	if err != nil {
+		if *headless {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(exitUsage)
+		}
 		showFatalError(err.Error())
 		return
 	}
 
+	if *headless {
+		os.Exit(runHeadless(cfg, headlessOptions{
+			Op:                 flag.Arg(0),
+			JSON:               *jsonSummary,
+			KeystorePassphrase: os.Getenv(keystorePassphraseEnv),
+		}, os.Stdout))
+	}
+
+	// 日志控件在主线程刷新，需要先创建 app
+	a := app.NewWithID(appui.AppID)
+
 	// Create log widget first.
 	// NOTE: logWidget需要先绑定到window才能使用.
-	logWidget := widget.NewTextGrid()
+	logSink := appui.NewLogSink(cfg.LogView)
 
-	// Set up UI logger
-	uiLogHandler := appui.NewUILogHandler(logWidget, &slog.HandlerOptions{
+	// Set up UI logger, and the file log in log_format when configured
+	logOptions := &slog.HandlerOptions{
 		Level:     slog.Level(cfg.LogLevel),
 		AddSource: true,
-	})
-	logger := slog.New(uiLogHandler)
+	}
+	fileLogHandler, closeFileLog, err := openFileLog(cfg, logOptions)
+	if err != nil {
+		showStartupError(a, err.Error())
+		a.Run()
+		return
+	}
+	defer closeFileLog()
+	uiLogHandler := appui.NewUILogHandler(logSink, logOptions, appui.WithMaxLines(cfg.LogMaxLines))
+	logger := slog.New(newFanoutHandler(uiLogHandler, fileLogHandler))
 	slog.SetDefault(logger)
 
-	storageClient, err := NewStorageClient(&cfg.Storage)
+	cipherOptions, err := cfg.CipherOptions()
 	if err != nil {
-		showFatalError(err.Error())
+		showStartupError(a, err.Error())
+		a.Run()
 		return
 	}
+	var cipherClient crypto.Cipher
+	keyScore, keyReasons := crypto.ScoreStrong, []string(nil)
+	checkKey := func(password string) {
+		keyScore, keyReasons = assessKey(logger, password)
+	}
+	closeSources := func() {}
+	var fileManagers []*dir.FileManager
+	start := func(c crypto.Cipher) {
+		cipherClient = c
 
-	cipherClient := crypto.NewAESGCM(cfg.CryptoKey)
+		// Initialize one file manager per source with UI logger
+		sources, closeFn, err := newSources(cfg, logger, cipherClient)
+		if err != nil {
+			showStartupError(a, err.Error())
+			return
+		}
+		closeSources = closeFn
+		for _, src := range sources {
+			fileManagers = append(fileManagers, src.fileManager)
+			if err := src.fileManager.EnsureWorkingDir(); err != nil {
+				showStartupError(a, err.Error())
+				return
+			}
+		}
 
-	// Initialize file manager with UI logger
-	fileManager := dir.NewFileManager(cfg, storageClient, logger, cipherClient)
+		// Initialize UI with log handler
+		ui := appui.NewAppUIWithApp(a, sources[0].fileManager, logger, uiLogHandler)
+		if len(sources) > 1 {
+			uiSources := make([]appui.Source, 0, len(sources))
+			for _, src := range sources {
+				uiSources = append(uiSources, appui.Source{Name: src.name, FileManager: src.fileManager})
+			}
+			ui.SetSources(uiSources)
+		}
 
-	// Initialize UI with log widget
-	ui := appui.NewAppUIWithLogWidget(fileManager, logger, logWidget)
+		// Log startup message
+		logger.Info("Application started successfully", slog.String("version", buildinfo.Get().Version))
+		ui.Show()
+		ui.WarnKeyStrength(keyScore, keyReasons)
+		ui.CheckStorage()
+		ui.CheckKey()
+	}
 
-	// Log startup message
-	logger.Info("Application started successfully", slog.String("version", "1.0"))
+	keystoreFile, useKeystore := keystorePath(cfg.CryptoKeySource)
+	switch {
+	case cfg.CryptoKeySource == "" || cfg.CryptoKeySource == "config":
+		checkKey(cfg.CryptoKey)
+		start(crypto.NewAESGCM(cfg.CryptoKey, cipherOptions...))
+	case cfg.CryptoKeySource == "prompt":
+		startWithPrompt(checkedPrompt(showPasswordPrompt(a), checkKey), start, func(err error) {
+			showStartupError(a, err.Error())
+		}, cipherOptions...)
+	case useKeystore:
+		startWithKeystore(checkedPrompt(showPasswordPrompt(a), checkKey), keystoreFile, logger, start, func(err error) {
+			showStartupError(a, err.Error())
+		}, cipherOptions...)
+	default:
+		showStartupError(a, fmt.Sprintf("unsupported crypto_key_source %s", cfg.CryptoKeySource))
+	}
 
 	// Run UI
-	ui.Run()
+	a.Run()
+
+	// 退出前清除内存中的密钥，包括更换密钥后正在使用的新密钥
+	for _, fm := range fileManagers {
+		fm.Close()
+	}
+	if closer, ok := cipherClient.(io.Closer); ok {
+		closer.Close()
+	}
+	closeSources()
+
+	// Render anything still batched in the log handler
+	uiLogHandler.Flush()
+}
+
+// errEmptyPassword aborts startup when no crypto password was entered
+var errEmptyPassword = errors.New("no crypto password entered, fers will exit")
+
+// passwordPrompt asks for the crypto password and calls submit with the entered
+// value, or with "" when the user cancels
+type passwordPrompt func(submit func(password string))
+
+// startWithPrompt asks for the crypto password and calls start with a cipher
+// built from it and options; an empty password calls fail instead
+func startWithPrompt(prompt passwordPrompt, start func(crypto.Cipher), fail func(error), options ...crypto.AESGCMOption) {
+	prompt(func(password string) {
+		if password == "" {
+			fail(errEmptyPassword)
+			return
+		}
+		start(crypto.NewAESGCM(password, options...))
+	})
+}
+
+// checkedPrompt returns a prompt that calls check with each entered password before submitting it
+func checkedPrompt(prompt passwordPrompt, check func(password string)) passwordPrompt {
+	return func(submit func(string)) {
+		prompt(func(password string) {
+			if password != "" {
+				check(password)
+			}
+			submit(password)
+		})
+	}
+}
+
+// assessKey logs a warning when the crypto password is easy to guess; the
+// check is advisory only and never stops fers from starting
+func assessKey(logger *slog.Logger, password string) (crypto.Score, []string) {
+	score, reasons := crypto.AssessKeyStrength(password)
+	if score == crypto.ScoreWeak {
+		logger.Warn("The crypto key is weak, consider a longer random key",
+			slog.String("score", score.String()), slog.String("reasons", strings.Join(reasons, "; ")))
+	}
+	return score, reasons
+}
+
+// showPasswordPrompt returns a prompt that asks for the password in a masked entry window of a
+func showPasswordPrompt(a fyne.App) passwordPrompt {
+	return func(submit func(string)) {
+		w := a.NewWindow("fers")
+		entry := widget.NewPasswordEntry()
+
+		submitted := false
+		finish := func(password string) {
+			if submitted {
+				return
+			}
+			submitted = true
+			// 先打开下一个窗口再关闭输入框，否则最后一个窗口关闭会退出程序
+			submit(password)
+			w.Close()
+		}
+
+		form := widget.NewForm(widget.NewFormItem("Crypto key", entry))
+		form.SubmitText = "Unlock"
+		form.OnSubmit = func() { finish(entry.Text) }
+		form.OnCancel = func() { finish("") }
+		entry.OnSubmitted = finish
+		w.SetCloseIntercept(func() { finish("") })
+
+		w.SetContent(container.NewVBox(widget.NewLabel("Enter the crypto key to unlock fers:"), form))
+		w.Resize(fyne.NewSize(400, 150))
+		w.CenterOnScreen()
+		w.Show()
+		w.Canvas().Focus(entry)
+	}
+}
+
+// showStartupError shows msg in a window of the running app a; closing it exits
+func showStartupError(a fyne.App, msg string) {
+	w := a.NewWindow("启动失败")
+	w.SetContent(widget.NewLabel(msg))
+	w.Resize(fyne.NewSize(400, 200))
+	w.Show()
 }
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mingregister/fers/pkg/crypto"
+)
+
+func TestStartWithPrompt(t *testing.T) {
+	encrypted, err := crypto.NewAESGCM("known-password").Encrypt([]byte("secret data"))
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+
+	fakePrompt := func(submit func(string)) { submit("known-password") }
+
+	var started crypto.Cipher
+	startWithPrompt(fakePrompt, func(c crypto.Cipher) { started = c }, func(err error) {
+		t.Fatalf("Unexpected startup failure: %v", err)
+	})
+	if started == nil {
+		t.Fatal("start should be called with the cipher")
+	}
+
+	plain, err := started.Decrypt(encrypted)
+	if err != nil {
+		t.Fatalf("Cipher from the prompt should decrypt the data: %v", err)
+	}
+	if string(plain) != "secret data" {
+		t.Errorf("Unexpected plaintext %q", plain)
+	}
+}
+
+func TestStartWithPrompt_EmptyPassword(t *testing.T) {
+	emptyPrompt := func(submit func(string)) { submit("") }
+
+	var failErr error
+	startWithPrompt(emptyPrompt, func(c crypto.Cipher) {
+		t.Error("start should not be called without a password")
+	}, func(err error) { failErr = err })
+
+	if !errors.Is(failErr, errEmptyPassword) {
+		t.Errorf("Expected errEmptyPassword, got %v", failErr)
+	}
+}
+
+func TestCheckedPrompt_ChecksEnteredPassword(t *testing.T) {
+	var checked []string
+	check := func(password string) { checked = append(checked, password) }
+
+	var submitted []string
+	for _, password := range []string{"1234", ""} {
+		prompt := func(submit func(string)) { submit(password) }
+		checkedPrompt(prompt, check)(func(p string) { submitted = append(submitted, p) })
+	}
+
+	if len(checked) != 1 || checked[0] != "1234" {
+		t.Errorf("Expected only the entered password to be checked, got %q", checked)
+	}
+	if len(submitted) != 2 || submitted[0] != "1234" || submitted[1] != "" {
+		t.Errorf("Expected every password to be submitted unchanged, got %q", submitted)
+	}
+}
@@ -0,0 +1,43 @@
+package appui
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/mingregister/fers/pkg/buildinfo"
+)
+
+// aboutText returns the text of the About dialog for info
+func (ui *AppUI) aboutText(info buildinfo.Info) string {
+	orUnknown := func(s string) string {
+		if s == "" {
+			return "unknown"
+		}
+		return s
+	}
+	commit := orUnknown(info.ShortCommit())
+	if info.Modified {
+		commit += " (modified)"
+	}
+
+	lines := []string{
+		"Version: " + info.Version,
+		"Commit: " + commit,
+		"Built: " + orUnknown(info.BuildDate),
+		"Go: " + info.GoVersion,
+		fmt.Sprintf("OS/Arch: %s/%s", info.OS, info.Arch),
+		"Storage: " + orUnknown(ui.fileManager.RemoteType()),
+	}
+	if source := ui.activeSource(); source != "" {
+		lines = append(lines, "Source: "+source)
+	}
+	lines = append(lines, "Working dir: "+ui.fileManager.GetWorkingDir())
+	return strings.Join(lines, "\n")
+}
+
+// ShowAbout shows the version and build of fers and the storage backend in use
+func (ui *AppUI) ShowAbout() {
+	dialog.ShowCustom("About fers", "Close", widget.NewLabel(ui.aboutText(buildinfo.Get())), ui.window)
+}
@@ -0,0 +1,49 @@
+package appui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mingregister/fers/pkg/buildinfo"
+	"github.com/mingregister/fers/pkg/config"
+	"github.com/mingregister/fers/pkg/storage"
+)
+
+func TestAppUI_AboutText(t *testing.T) {
+	ui := newTestAppUIWithStorage(t, storage.NewMemoryClient(), func(cfg *config.Config) {
+		cfg.Storage.RemoteType = "oss"
+	})
+
+	text := ui.aboutText(buildinfo.Info{
+		Version:   "v0.3.0",
+		Commit:    "0123456789abcdef",
+		Modified:  true,
+		GoVersion: "go1.24.1",
+		OS:        "linux",
+		Arch:      "amd64",
+	})
+
+	for _, want := range []string{
+		"Version: v0.3.0",
+		"Commit: 0123456789ab (modified)",
+		"Built: unknown",
+		"Go: go1.24.1",
+		"OS/Arch: linux/amd64",
+		"Storage: oss",
+		"Working dir: " + ui.fileManager.GetWorkingDir(),
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("About text is missing %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestAppUI_ShowAbout(t *testing.T) {
+	ui := newTestAppUI(t)
+
+	ui.ShowAbout()
+
+	if findLabel(ui.window.Canvas().Overlays().Top(), "Version: ") == nil {
+		t.Error("Expected the About dialog to show the version")
+	}
+}
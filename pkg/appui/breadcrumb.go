@@ -0,0 +1,56 @@
+package appui
+
+import (
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// breadcrumbSegment 是面包屑导航中的一段
+type breadcrumbSegment struct {
+	Label string
+	Path  string // 点击后跳转到的目录
+}
+
+// breadcrumbSegments 返回从 workingDir 到 currentDir 的各级目录，
+// currentDir 不在 workingDir 内时只返回 workingDir
+func breadcrumbSegments(workingDir, currentDir string) []breadcrumbSegment {
+	root := filepath.Clean(workingDir)
+	segments := []breadcrumbSegment{{Label: filepath.Base(root), Path: root}}
+
+	relPath, err := filepath.Rel(root, filepath.Clean(currentDir))
+	if err != nil || relPath == "." || strings.HasPrefix(relPath, "..") {
+		return segments
+	}
+
+	path := root
+	for _, part := range strings.Split(relPath, string(filepath.Separator)) {
+		path = filepath.Join(path, part)
+		segments = append(segments, breadcrumbSegment{Label: part, Path: path})
+	}
+	return segments
+}
+
+// newBreadcrumb 创建可点击的面包屑，最后一段（当前目录）不可点击
+func newBreadcrumb(segments []breadcrumbSegment, onTapped func(path string)) *fyne.Container {
+	box := container.NewHBox()
+	for i, segment := range segments {
+		if i > 0 {
+			box.Add(widget.NewLabel(">"))
+		}
+		if i == len(segments)-1 {
+			box.Add(widget.NewLabelWithStyle(segment.Label, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+			continue
+		}
+		path := segment.Path
+		button := widget.NewButton(segment.Label, func() {
+			onTapped(path)
+		})
+		button.Importance = widget.LowImportance
+		box.Add(button)
+	}
+	return box
+}
@@ -0,0 +1,108 @@
+package appui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/widget"
+)
+
+func TestBreadcrumbSegments_Nested(t *testing.T) {
+	workingDir := filepath.Join("home", "user", "work")
+	currentDir := filepath.Join(workingDir, "a", "b", "c")
+
+	segments := breadcrumbSegments(workingDir, currentDir)
+
+	expected := []breadcrumbSegment{
+		{Label: "work", Path: workingDir},
+		{Label: "a", Path: filepath.Join(workingDir, "a")},
+		{Label: "b", Path: filepath.Join(workingDir, "a", "b")},
+		{Label: "c", Path: currentDir},
+	}
+	if len(segments) != len(expected) {
+		t.Fatalf("Expected %d segments, got %d: %v", len(expected), len(segments), segments)
+	}
+	for i := range expected {
+		if segments[i] != expected[i] {
+			t.Errorf("Segment %d: expected %+v, got %+v", i, expected[i], segments[i])
+		}
+	}
+}
+
+func TestBreadcrumbSegments_AtRoot(t *testing.T) {
+	workingDir := filepath.Join("home", "user", "work")
+
+	segments := breadcrumbSegments(workingDir, workingDir)
+	if len(segments) != 1 || segments[0].Path != workingDir {
+		t.Errorf("Expected only the root segment, got %v", segments)
+	}
+}
+
+func TestBreadcrumbSegments_OutsideWorkingDir(t *testing.T) {
+	workingDir := filepath.Join("home", "user", "work")
+
+	segments := breadcrumbSegments(workingDir, filepath.Join("home", "user"))
+	if len(segments) != 1 || segments[0].Path != workingDir {
+		t.Errorf("Expected only the root segment for a dir outside workingDir, got %v", segments)
+	}
+}
+
+// breadcrumbButtons returns the tappable segments of the UI breadcrumb
+func breadcrumbButtons(ui *AppUI) []*widget.Button {
+	var buttons []*widget.Button
+	for _, o := range ui.breadcrumb.Objects {
+		if box, ok := o.(*fyne.Container); ok {
+			for _, item := range box.Objects {
+				if button, ok := item.(*widget.Button); ok {
+					buttons = append(buttons, button)
+				}
+			}
+		}
+	}
+	return buttons
+}
+
+func TestAppUI_BreadcrumbNavigatesToAncestor(t *testing.T) {
+	ui := newTestAppUI(t)
+	workingDir := ui.fileManager.GetWorkingDir()
+
+	nested := filepath.Join(workingDir, "a", "b", "c")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatalf("Failed to create nested directories: %v", err)
+	}
+
+	ui.breadcrumb = container.NewHBox()
+	ui.currentDir = nested
+	ui.refreshList()
+
+	// Root, a and b are tappable; the current directory c is not
+	buttons := breadcrumbButtons(ui)
+	if len(buttons) != 3 {
+		t.Fatalf("Expected 3 breadcrumb buttons, got %d", len(buttons))
+	}
+
+	test.Tap(buttons[1])
+	if ui.currentDir != filepath.Join(workingDir, "a") {
+		t.Errorf("Expected currentDir %s, got %s", filepath.Join(workingDir, "a"), ui.currentDir)
+	}
+
+	buttons = breadcrumbButtons(ui)
+	test.Tap(buttons[0])
+	if ui.currentDir != filepath.Clean(workingDir) {
+		t.Errorf("Expected currentDir %s, got %s", workingDir, ui.currentDir)
+	}
+}
+
+func TestAppUI_NavigateToOutsideWorkingDir(t *testing.T) {
+	ui := newTestAppUI(t)
+	workingDir := ui.fileManager.GetWorkingDir()
+
+	ui.navigateTo(filepath.Dir(workingDir))
+	if ui.currentDir != workingDir {
+		t.Errorf("currentDir should stay %s, got %s", workingDir, ui.currentDir)
+	}
+}
@@ -0,0 +1,38 @@
+package appui
+
+import (
+	"log/slog"
+	"path/filepath"
+
+	"fyne.io/fyne/v2/dialog"
+)
+
+// selectedPaths returns the absolute path of the selected item and its path
+// relative to the working directory
+func (ui *AppUI) selectedPaths() (absolute, relative string, ok bool) {
+	if !ui.validateSelection() {
+		return "", "", false
+	}
+	absolute = filepath.Join(ui.currentDir, ui.selectedName)
+	relative, err := filepath.Rel(ui.fileManager.GetWorkingDir(), absolute)
+	if err != nil {
+		relative = absolute
+	}
+	return absolute, relative, true
+}
+
+// copySelectedPath puts the path of the selected item on the clipboard,
+// relative to the working directory when relative is true
+func (ui *AppUI) copySelectedPath(relative bool) {
+	absolutePath, relativePath, ok := ui.selectedPaths()
+	if !ok {
+		dialog.ShowInformation("Info", "Please select a file or directory first", ui.window)
+		return
+	}
+	path := absolutePath
+	if relative {
+		path = relativePath
+	}
+	ui.app.Clipboard().SetContent(path)
+	ui.logger.Info("Path copied to clipboard", slog.String("path", path))
+}
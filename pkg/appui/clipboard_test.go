@@ -0,0 +1,57 @@
+package appui
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestAppUI_CopySelectedPath(t *testing.T) {
+	ui := newTestAppUI(t)
+	docs := filepath.Join(ui.currentDir, "docs")
+	if err := os.MkdirAll(docs, 0755); err != nil {
+		t.Fatalf("Failed to create docs: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(docs, "report.pdf"), []byte("pdf"), 0644); err != nil {
+		t.Fatalf("Failed to create report.pdf: %v", err)
+	}
+	ui.setupUI()
+	ui.changeDir(docs)
+	ui.selectItem(slices.Index(ui.items, "report.pdf"))
+
+	absolute, relative, ok := ui.selectedPaths()
+	if !ok {
+		t.Fatal("Expected a selection")
+	}
+	if want := filepath.Join(docs, "report.pdf"); absolute != want {
+		t.Errorf("Expected absolute path %q, got %q", want, absolute)
+	}
+	if want := filepath.Join("docs", "report.pdf"); relative != want {
+		t.Errorf("Expected relative path %q, got %q", want, relative)
+	}
+
+	ui.copySelectedPath(false)
+	if got := ui.app.Clipboard().Content(); got != absolute {
+		t.Errorf("Expected %q in clipboard, got %q", absolute, got)
+	}
+	ui.copySelectedPath(true)
+	if got := ui.app.Clipboard().Content(); got != relative {
+		t.Errorf("Expected %q in clipboard, got %q", relative, got)
+	}
+}
+
+func TestAppUI_CopySelectedPathWithoutSelection(t *testing.T) {
+	ui := newTestAppUI(t)
+	ui.setupUI()
+	ui.app.Clipboard().SetContent("unchanged")
+
+	ui.copySelectedPath(false)
+
+	if got := ui.app.Clipboard().Content(); got != "unchanged" {
+		t.Errorf("Clipboard should be unchanged without a selection, got %q", got)
+	}
+	if len(ui.window.Canvas().Overlays().List()) == 0 {
+		t.Error("Expected an info dialog when nothing is selected")
+	}
+}
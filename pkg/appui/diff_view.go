@@ -0,0 +1,105 @@
+package appui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+	"github.com/mingregister/fers/pkg/dir"
+)
+
+// diffSection is one category of the diff report
+type diffSection struct {
+	title    string
+	files    []string
+	expanded bool // 是否默认展开
+}
+
+// diffSections returns the categories of report in display order
+func diffSections(report *dir.DiffReport) []diffSection {
+	return []diffSection{
+		{"Only local", report.LocalOnly, true},
+		{"Only remote", report.RemoteOnly, true},
+		{"Modified", report.Modified, true},
+		{"Identical", report.Identical, false},
+	}
+}
+
+// diffSummary returns a one-line count of every category
+func diffSummary(report *dir.DiffReport) string {
+	var parts []string
+	for _, section := range diffSections(report) {
+		parts = append(parts, fmt.Sprintf("%d %s", len(section.files), strings.ToLower(section.title)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// diffTree holds the nodes of the diff view: every category is a branch
+// labelled with its count, its files are the leaves
+type diffTree struct {
+	children map[widget.TreeNodeID][]widget.TreeNodeID
+	labels   map[widget.TreeNodeID]string
+	expanded []widget.TreeNodeID
+}
+
+// newDiffTree builds the tree nodes of report
+func newDiffTree(report *dir.DiffReport) *diffTree {
+	tree := &diffTree{
+		children: map[widget.TreeNodeID][]widget.TreeNodeID{},
+		labels:   map[widget.TreeNodeID]string{},
+	}
+	for i, section := range diffSections(report) {
+		branch := fmt.Sprintf("%d", i)
+		tree.children[""] = append(tree.children[""], branch)
+		tree.labels[branch] = fmt.Sprintf("%s (%d)", section.title, len(section.files))
+		if section.expanded {
+			tree.expanded = append(tree.expanded, branch)
+		}
+		for _, file := range section.files {
+			leaf := branch + "/" + file
+			tree.children[branch] = append(tree.children[branch], leaf)
+			tree.labels[leaf] = file
+		}
+	}
+	return tree
+}
+
+// widget returns a tree widget showing the nodes
+func (d *diffTree) widget() *widget.Tree {
+	tree := widget.NewTree(
+		func(id widget.TreeNodeID) []widget.TreeNodeID { return d.children[id] },
+		func(id widget.TreeNodeID) bool { return !strings.Contains(id, "/") },
+		func(bool) fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.TreeNodeID, _ bool, o fyne.CanvasObject) { o.(*widget.Label).SetText(d.labels[id]) },
+	)
+	for _, branch := range d.expanded {
+		tree.OpenBranch(branch)
+	}
+	return tree
+}
+
+// CompareWithRemote compares the working directory with the remote and shows the result
+func (ui *AppUI) CompareWithRemote() {
+	ui.runOperation("Compare", func(ctx context.Context) error {
+		ui.setProgress(ctx, "Comparing local and remote files...")
+		report, err := ui.fileManager.Diff(ctx)
+		if err != nil {
+			return err
+		}
+		fyne.Do(func() { ui.showDiffReport(report) })
+		return nil
+	})
+}
+
+// showDiffReport opens a window listing the files of report by category
+func (ui *AppUI) showDiffReport(report *dir.DiffReport) {
+	w := ui.app.NewWindow("Compare Local and Remote")
+	w.Resize(fyne.NewSize(RemoteWindowWidth, RemoteWindowHeight))
+
+	summary := widget.NewLabel(diffSummary(report))
+	w.SetContent(container.NewBorder(summary, nil, nil, nil, newDiffTree(report).widget()))
+	w.Show()
+}
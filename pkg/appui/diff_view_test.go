@@ -0,0 +1,69 @@
+package appui
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"github.com/mingregister/fers/pkg/dir"
+)
+
+func TestNewDiffTree(t *testing.T) {
+	report := &dir.DiffReport{
+		LocalOnly: []string{"new.txt"},
+		Modified:  []string{"docs/a.txt", "docs/b.txt"},
+		Identical: []string{"same.txt"},
+	}
+	tree := newDiffTree(report)
+
+	roots := tree.children[""]
+	var labels []string
+	for _, root := range roots {
+		labels = append(labels, tree.labels[root])
+	}
+	expected := []string{"Only local (1)", "Only remote (0)", "Modified (2)", "Identical (1)"}
+	if !slices.Equal(labels, expected) {
+		t.Errorf("Expected categories %v, got %v", expected, labels)
+	}
+
+	var modified []string
+	for _, leaf := range tree.children[roots[2]] {
+		modified = append(modified, tree.labels[leaf])
+	}
+	if !slices.Equal(modified, report.Modified) {
+		t.Errorf("Expected modified files %v, got %v", report.Modified, modified)
+	}
+	if slices.Contains(tree.expanded, roots[3]) {
+		t.Error("Identical files should be collapsed by default")
+	}
+
+	if got := diffSummary(report); got != "1 only local, 0 only remote, 2 modified, 1 identical" {
+		t.Errorf("Unexpected summary %q", got)
+	}
+}
+
+func TestAppUI_CompareWithRemote(t *testing.T) {
+	ui := newTestAppUI(t)
+	localPath := filepath.Join(ui.currentDir, "local.txt")
+	if err := os.WriteFile(localPath, []byte("data"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	ui.CompareWithRemote()
+	var diffWindow fyne.Window
+	found := waitFor(t, func() bool {
+		for _, w := range ui.app.Driver().AllWindows() {
+			if w.Title() == "Compare Local and Remote" {
+				diffWindow = w
+				return true
+			}
+		}
+		return false
+	})
+	if !found {
+		t.Fatal("Expected the compare window to be shown")
+	}
+	diffWindow.Close()
+}
@@ -0,0 +1,46 @@
+package appui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+)
+
+// droppedPaths splits dropped URIs into the local paths inside workingDir and
+// the rejected ones, which are outside it or not local files
+func droppedPaths(workingDir string, uris []fyne.URI) (paths, rejected []string) {
+	cleanWorkingDir := filepath.Clean(workingDir)
+	for _, uri := range uris {
+		if uri.Scheme() != "file" {
+			rejected = append(rejected, uri.String())
+			continue
+		}
+
+		path := filepath.Clean(uri.Path())
+		rel, err := filepath.Rel(cleanWorkingDir, path)
+		if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			rejected = append(rejected, path)
+			continue
+		}
+		paths = append(paths, path)
+	}
+	return paths, rejected
+}
+
+// handleDrop encrypts and uploads files and directories dropped onto the window
+func (ui *AppUI) handleDrop(_ fyne.Position, uris []fyne.URI) {
+	workingDir := ui.fileManager.GetWorkingDir()
+	paths, rejected := droppedPaths(workingDir, uris)
+
+	if len(rejected) > 0 {
+		dialog.ShowError(fmt.Errorf("only files inside the working directory %s can be uploaded:\n%s",
+			workingDir, strings.Join(rejected, "\n")), ui.window)
+	}
+	if len(paths) == 0 {
+		return
+	}
+	ui.uploadPaths(paths)
+}
@@ -0,0 +1,82 @@
+package appui
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	fynestorage "fyne.io/fyne/v2/storage"
+)
+
+func TestDroppedPaths(t *testing.T) {
+	workingDir := filepath.Join(t.TempDir(), "work")
+	outside := filepath.Join(filepath.Dir(workingDir), "outside.txt")
+	sibling := workingDir + "-other"
+	webURI, err := fynestorage.ParseURI("https://example.com/file.txt")
+	if err != nil {
+		t.Fatalf("ParseURI failed: %v", err)
+	}
+
+	uris := []fyne.URI{
+		fynestorage.NewFileURI(filepath.Join(workingDir, "a.txt")),
+		fynestorage.NewFileURI(filepath.Join(workingDir, "sub", "dir")),
+		fynestorage.NewFileURI(outside),
+		fynestorage.NewFileURI(filepath.Join(sibling, "b.txt")),
+		fynestorage.NewFileURI(workingDir),
+		webURI,
+	}
+
+	paths, rejected := droppedPaths(workingDir, uris)
+
+	wantPaths := []string{filepath.Join(workingDir, "a.txt"), filepath.Join(workingDir, "sub", "dir")}
+	if !reflect.DeepEqual(paths, wantPaths) {
+		t.Errorf("Expected paths %v, got %v", wantPaths, paths)
+	}
+	wantRejected := []string{outside, filepath.Join(sibling, "b.txt"), workingDir, "https://example.com/file.txt"}
+	if !reflect.DeepEqual(rejected, wantRejected) {
+		t.Errorf("Expected rejected %v, got %v", wantRejected, rejected)
+	}
+}
+
+func TestAppUI_HandleDropUploads(t *testing.T) {
+	ui := newTestAppUI(t)
+	subDir := filepath.Join(ui.currentDir, "sub")
+	if err := os.MkdirAll(subDir, 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(subDir, "a.txt"), []byte("a"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	ui.handleDrop(fyne.NewPos(0, 0), []fyne.URI{fynestorage.NewFileURI(subDir)})
+
+	if !waitFor(t, func() bool {
+		_, err := ui.fileManager.StatRemoteFile("sub/a.txt")
+		return err == nil
+	}) {
+		t.Error("Dropped directory should be uploaded recursively")
+	}
+}
+
+func TestAppUI_HandleDropRejectsOutside(t *testing.T) {
+	ui := newTestAppUI(t)
+	outside := filepath.Join(t.TempDir(), "outside.txt")
+	if err := os.WriteFile(outside, []byte("x"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	ui.handleDrop(fyne.NewPos(0, 0), []fyne.URI{fynestorage.NewFileURI(outside)})
+
+	if ui.window.Canvas().Overlays().Top() == nil {
+		t.Error("Expected an error dialog for a file outside the working directory")
+	}
+	files, err := ui.fileManager.ListRemoteFiles("")
+	if err != nil {
+		t.Fatalf("ListRemoteFiles failed: %v", err)
+	}
+	if len(files) != 0 {
+		t.Errorf("Nothing should be uploaded, got %v", files)
+	}
+}
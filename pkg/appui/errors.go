@@ -0,0 +1,56 @@
+package appui
+
+import (
+	"errors"
+	"slices"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/mingregister/fers/pkg/crypto"
+	"github.com/mingregister/fers/pkg/dir"
+)
+
+// errorHint returns a dialog title and what the user should check for err,
+// or an empty hint when the cause is unknown
+func errorHint(err error) (title, hint string) {
+	switch {
+	// 解密失败要先判断，远程下载的文件解密失败并不是网络问题
+	case errors.Is(err, crypto.ErrDecrypt), errors.Is(err, dir.ErrKeyMismatch):
+		return "Decryption failed", "The data could not be decrypted. Check your crypto_key in the config file."
+	case errors.Is(err, dir.ErrStorage):
+		return "Remote storage error", "The remote storage could not be reached. Check your connection and storage settings."
+	case errors.Is(err, dir.ErrLocalIO):
+		return "Local file error", "A local file could not be read or written. Check free disk space and permissions."
+	}
+	return "Error", ""
+}
+
+// failureHints returns the distinct hints for the errors of failed files
+func failureHints(failed []dir.FileError) []string {
+	var hints []string
+	for _, f := range failed {
+		if _, hint := errorHint(f.Err); hint != "" && !slices.Contains(hints, hint) {
+			hints = append(hints, hint)
+		}
+	}
+	return hints
+}
+
+// showError is dialog.ShowError with a hint on what to check when the cause
+// of err is known
+func showError(err error, parent fyne.Window) {
+	title, hint := errorHint(err)
+	if hint == "" {
+		dialog.ShowError(err, parent)
+		return
+	}
+	message := widget.NewLabel(err.Error())
+	message.Wrapping = fyne.TextWrapWord
+	hintLabel := widget.NewLabelWithStyle(hint, fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	hintLabel.Wrapping = fyne.TextWrapWord
+	d := dialog.NewCustom(title, "OK", container.NewVBox(message, hintLabel), parent)
+	d.Resize(fyne.NewSize(480, 0))
+	d.Show()
+}
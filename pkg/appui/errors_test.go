@@ -0,0 +1,71 @@
+package appui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mingregister/fers/pkg/crypto"
+	"github.com/mingregister/fers/pkg/dir"
+)
+
+func TestErrorHint(t *testing.T) {
+	testCases := []struct {
+		name  string
+		err   error
+		title string
+		hint  string
+	}{
+		{name: "wrong key", err: fmt.Errorf("failed to decrypt file a.txt: %w", crypto.ErrDecrypt), title: "Decryption failed", hint: "crypto_key"},
+		{name: "key mismatch", err: dir.ErrKeyMismatch, title: "Decryption failed", hint: "crypto_key"},
+		{name: "network", err: fmt.Errorf("failed to download file a.txt: %w: %w", dir.ErrStorage, errors.New("connection reset")), title: "Remote storage error", hint: "connection"},
+		{name: "disk", err: fmt.Errorf("failed to write file a.txt: %w: %w", dir.ErrLocalIO, os.ErrPermission), title: "Local file error", hint: "free disk space and permissions"},
+		{name: "unknown", err: errors.New("something else"), title: "Error", hint: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			title, hint := errorHint(tc.err)
+			if title != tc.title {
+				t.Errorf("Expected title %q, got %q", tc.title, title)
+			}
+			if tc.hint == "" && hint != "" || !strings.Contains(hint, tc.hint) {
+				t.Errorf("Expected hint containing %q, got %q", tc.hint, hint)
+			}
+		})
+	}
+}
+
+func TestFailureHints(t *testing.T) {
+	failed := []dir.FileError{
+		{Path: "a.txt", Err: fmt.Errorf("%w: timeout", dir.ErrStorage)},
+		{Path: "b.txt", Err: fmt.Errorf("%w: reset", dir.ErrStorage)},
+		{Path: "c.txt", Err: crypto.ErrDecrypt},
+		{Path: "d.txt", Err: errors.New("unknown")},
+	}
+
+	hints := failureHints(failed)
+	if len(hints) != 2 || !strings.Contains(hints[0], "connection") || !strings.Contains(hints[1], "crypto_key") {
+		t.Errorf("Expected one connection and one crypto_key hint, got %v", hints)
+	}
+}
+
+func TestShowErrorWithHint(t *testing.T) {
+	ui := newTestAppUI(t)
+	ui.setupUI()
+
+	showError(fmt.Errorf("failed to decrypt file a.txt: %w", crypto.ErrDecrypt), ui.window)
+
+	top := ui.window.Canvas().Overlays().Top()
+	if top == nil {
+		t.Fatal("Expected an error dialog")
+	}
+	if findLabel(top, "failed to decrypt file a.txt") == nil {
+		t.Error("Dialog should show the error")
+	}
+	if findLabel(top, "Check your crypto_key") == nil {
+		t.Error("Dialog should suggest checking the crypto key")
+	}
+}
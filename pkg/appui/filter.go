@@ -0,0 +1,53 @@
+package appui
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/mingregister/fers/pkg/dir"
+)
+
+// matchesFilter 判断名称是否包含查询字符串（不区分大小写），query 需已转为小写
+func matchesFilter(name, lowerQuery string) bool {
+	return lowerQuery == "" || strings.Contains(strings.ToLower(name), lowerQuery)
+}
+
+// filterEntries 返回名称匹配 query 的目录项在 entries 中的下标
+func filterEntries(entries []dir.Entry, query string) []int {
+	lowerQuery := strings.ToLower(strings.TrimSpace(query))
+	visible := make([]int, 0, len(entries))
+	for i, entry := range entries {
+		if matchesFilter(entry.Name, lowerQuery) {
+			visible = append(visible, i)
+		}
+	}
+	return visible
+}
+
+// filterNames 返回匹配 query 的名称在 names 中的下标
+func filterNames(names []string, query string) []int {
+	lowerQuery := strings.ToLower(strings.TrimSpace(query))
+	visible := make([]int, 0, len(names))
+	for i, name := range names {
+		if matchesFilter(name, lowerQuery) {
+			visible = append(visible, i)
+		}
+	}
+	return visible
+}
+
+// globNames 返回 candidates 中完整路径（以 / 分隔）匹配 glob 模式 pattern 的下标
+func globNames(names []string, candidates []int, pattern string) ([]int, error) {
+	pattern = strings.TrimSpace(pattern)
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	var matched []int
+	for _, i := range candidates {
+		if ok, _ := path.Match(pattern, names[i]); ok {
+			matched = append(matched, i)
+		}
+	}
+	return matched, nil
+}
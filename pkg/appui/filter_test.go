@@ -0,0 +1,111 @@
+package appui
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mingregister/fers/pkg/dir"
+)
+
+func TestMatchesFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected bool
+	}{
+		{"Report.PDF", "", true},
+		{"Report.PDF", "report", true},
+		{"Report.PDF", "pdf", true},
+		{"Report.PDF", "port.p", true},
+		{"Report.PDF", "docx", false},
+		{"测试文件.txt", "测试", true},
+	}
+
+	for _, test := range tests {
+		if got := matchesFilter(test.name, test.query); got != test.expected {
+			t.Errorf("matchesFilter(%q, %q) = %v, expected %v", test.name, test.query, got, test.expected)
+		}
+	}
+}
+
+func TestFilterEntries_IndexRemapping(t *testing.T) {
+	entries := []dir.Entry{
+		{Name: "alpha.txt"},
+		{Name: "Beta.log"},
+		{Name: "gamma.TXT"},
+		{Name: "delta", IsDir: true},
+	}
+
+	visible := filterEntries(entries, " TXT ")
+	if len(visible) != 2 {
+		t.Fatalf("Expected 2 matches, got %d: %v", len(visible), visible)
+	}
+
+	// Indices in the filtered view must map back to the right entries
+	if entries[visible[0]].Name != "alpha.txt" {
+		t.Errorf("Filtered index 0 should map to alpha.txt, got %s", entries[visible[0]].Name)
+	}
+	if entries[visible[1]].Name != "gamma.TXT" {
+		t.Errorf("Filtered index 1 should map to gamma.TXT, got %s", entries[visible[1]].Name)
+	}
+}
+
+func TestFilterEntries_EmptyQueryKeepsAll(t *testing.T) {
+	entries := []dir.Entry{{Name: "a"}, {Name: "b"}, {Name: "c"}}
+
+	visible := filterEntries(entries, "")
+	if len(visible) != len(entries) {
+		t.Fatalf("Expected all %d entries, got %d", len(entries), len(visible))
+	}
+	for i, index := range visible {
+		if index != i {
+			t.Errorf("Expected index %d at position %d, got %d", i, i, index)
+		}
+	}
+}
+
+func TestFilterEntries_NoMatch(t *testing.T) {
+	entries := []dir.Entry{{Name: "a.txt"}, {Name: "b.txt"}}
+
+	if visible := filterEntries(entries, "zzz"); len(visible) != 0 {
+		t.Errorf("Expected no matches, got %v", visible)
+	}
+}
+
+func TestGlobNames(t *testing.T) {
+	names := []string{
+		"logs/2023-01-01.log",
+		"logs/2023-01-02.log",
+		"logs/2023-02-01.log",
+		"logs/archive/2023-01-03.log",
+		"2023-01-04.log",
+	}
+	all := []int{0, 1, 2, 3, 4}
+
+	tests := []struct {
+		pattern    string
+		candidates []int
+		expected   []int
+	}{
+		{"logs/2023-01-*", all, []int{0, 1}},
+		{"logs/*/2023-01-*", all, []int{3}},
+		{"*.log", all, []int{4}},
+		{"logs/2023-0?-01.log", all, []int{0, 2}},
+		{"logs/2023-01-*", []int{1, 2}, []int{1}},
+		{"missing/*", all, nil},
+	}
+
+	for _, test := range tests {
+		got, err := globNames(names, test.candidates, test.pattern)
+		if err != nil {
+			t.Fatalf("globNames(%q) failed: %v", test.pattern, err)
+		}
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("globNames(%q, %v) = %v, expected %v", test.pattern, test.candidates, got, test.expected)
+		}
+	}
+
+	if _, err := globNames(names, all, "logs/[2023"); err == nil {
+		t.Error("Expected an error for an invalid pattern")
+	}
+}
@@ -0,0 +1,45 @@
+package appui
+
+import (
+	"log/slog"
+	"time"
+)
+
+// handleFocus reloads the current directory when the window comes back to the
+// foreground, so files changed in other programs show up without Refresh.
+// Focus changes within FocusRefreshDebounce of the last refresh are ignored.
+func (ui *AppUI) handleFocus() {
+	now := time.Now()
+	if now.Sub(ui.lastFocusRefresh) < FocusRefreshDebounce {
+		return
+	}
+	ui.lastFocusRefresh = now
+
+	ui.logger.Debug("Window focused, refreshing list", slog.String("dir", ui.currentDir))
+	ui.refreshPreservingSelection()
+}
+
+// refreshPreservingSelection reloads the file list and selects again the
+// items that are still present by name
+func (ui *AppUI) refreshPreservingSelection() {
+	selected := make(map[string]bool)
+	for _, entry := range ui.selectedEntries() {
+		selected[entry.Name] = true
+	}
+	current := ui.selectedName
+
+	ui.reloadList()
+
+	for i, name := range ui.items {
+		if selected[name] {
+			ui.selection[i] = true
+		}
+		if name == current {
+			ui.selectedIndex = i
+			ui.selectedName = name
+		}
+	}
+	if ui.rightClickableList != nil {
+		ui.rightClickableList.Refresh()
+	}
+}
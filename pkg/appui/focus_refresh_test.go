@@ -0,0 +1,156 @@
+package appui
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/mingregister/fers/pkg/config"
+	"github.com/mingregister/fers/pkg/storage"
+)
+
+func TestAppUI_HandleFocusRefreshesAndKeepsSelection(t *testing.T) {
+	ui := newTestAppUI(t)
+	for _, name := range []string{"b.txt", "c.txt"} {
+		if err := os.WriteFile(filepath.Join(ui.currentDir, name), []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+	ui.setupUI()
+	ui.selectItem(slices.Index(ui.items, "b.txt"))
+
+	// 外部程序新增一个排在前面的文件
+	if err := os.WriteFile(filepath.Join(ui.currentDir, "a.txt"), []byte("a"), 0644); err != nil {
+		t.Fatalf("Failed to create a.txt: %v", err)
+	}
+	ui.handleFocus()
+
+	if !slices.Equal(ui.items, []string{"a.txt", "b.txt", "c.txt"}) {
+		t.Fatalf("Expected focus to refresh the list, got %v", ui.items)
+	}
+	if ui.selectedName != "b.txt" || ui.selectedIndex != 1 || !ui.selection[1] || len(ui.selection) != 1 {
+		t.Errorf("Expected b.txt to stay selected at index 1, got %q at %d, %v", ui.selectedName, ui.selectedIndex, ui.selection)
+	}
+}
+
+func TestAppUI_HandleFocusDebounced(t *testing.T) {
+	ui := newTestAppUI(t)
+	ui.setupUI()
+
+	ui.handleFocus()
+	if err := os.WriteFile(filepath.Join(ui.currentDir, "a.txt"), []byte("a"), 0644); err != nil {
+		t.Fatalf("Failed to create a.txt: %v", err)
+	}
+	ui.handleFocus()
+	if len(ui.items) != 0 {
+		t.Errorf("A second focus right away should not refresh, got %v", ui.items)
+	}
+
+	ui.lastFocusRefresh = time.Now().Add(-FocusRefreshDebounce)
+	ui.handleFocus()
+	if !slices.Equal(ui.items, []string{"a.txt"}) {
+		t.Errorf("Expected refresh after the debounce interval, got %v", ui.items)
+	}
+}
+
+func TestAppUI_HandleFocusDropsRemovedSelection(t *testing.T) {
+	ui := newTestAppUI(t)
+	path := filepath.Join(ui.currentDir, "gone.txt")
+	if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+		t.Fatalf("Failed to create gone.txt: %v", err)
+	}
+	ui.setupUI()
+	ui.selectItem(0)
+
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("Failed to remove gone.txt: %v", err)
+	}
+	ui.handleFocus()
+
+	if ui.validateSelection() || len(ui.selection) != 0 {
+		t.Errorf("Expected no selection after the selected file disappeared, got %q, %v", ui.selectedName, ui.selection)
+	}
+}
+
+// newKeepSelectionAppUI returns an AppUI with keep_selection_after_op set and
+// the given files in its working directory
+func newKeepSelectionAppUI(t *testing.T, names ...string) *AppUI {
+	t.Helper()
+	ui := newTestAppUIWithStorage(t, storage.NewMemoryClient(), func(cfg *config.Config) {
+		cfg.KeepSelection = true
+	})
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(ui.currentDir, name), []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+	ui.setupUI()
+	return ui
+}
+
+func TestAppUI_RefreshListKeepsSelectionWhenConfigured(t *testing.T) {
+	ui := newKeepSelectionAppUI(t, "b.txt", "c.txt")
+	ui.selectItem(slices.Index(ui.items, "c.txt"))
+
+	// 操作产生了一个排在前面的新文件
+	if err := os.WriteFile(filepath.Join(ui.currentDir, "a.txt"), []byte("a"), 0644); err != nil {
+		t.Fatalf("Failed to create a.txt: %v", err)
+	}
+	ui.refreshList()
+
+	if ui.selectedName != "c.txt" || ui.selectedIndex != 2 || !ui.selection[2] || len(ui.selection) != 1 {
+		t.Errorf("Expected c.txt to stay selected at index 2, got %q at %d, %v", ui.selectedName, ui.selectedIndex, ui.selection)
+	}
+	if entry, ok := ui.selectedEntry(); !ok || entry.Name != "c.txt" {
+		t.Errorf("Expected the selected entry to resolve to c.txt, got %+v, %v", entry, ok)
+	}
+}
+
+func TestAppUI_RefreshListClearsRemovedSelection(t *testing.T) {
+	ui := newKeepSelectionAppUI(t, "a.txt", "b.txt")
+	ui.selectItem(slices.Index(ui.items, "b.txt"))
+
+	if err := ui.fileManager.DeleteLocalFile("b.txt"); err != nil {
+		t.Fatalf("DeleteLocalFile failed: %v", err)
+	}
+	ui.refreshList()
+
+	if ui.validateSelection() || ui.selectedIndex != -1 || len(ui.selection) != 0 {
+		t.Errorf("Expected no selection after deleting b.txt, got %q at %d, %v", ui.selectedName, ui.selectedIndex, ui.selection)
+	}
+}
+
+func TestAppUI_RefreshListClearsSelectionByDefault(t *testing.T) {
+	ui := newTestAppUI(t)
+	if err := os.WriteFile(filepath.Join(ui.currentDir, "a.txt"), []byte("a"), 0644); err != nil {
+		t.Fatalf("Failed to create a.txt: %v", err)
+	}
+	ui.setupUI()
+	ui.selectItem(0)
+
+	ui.refreshList()
+	if ui.validateSelection() || len(ui.selection) != 0 {
+		t.Errorf("Expected refresh to clear the selection, got %q, %v", ui.selectedName, ui.selection)
+	}
+}
+
+func TestAppUI_ChangeDirClearsKeptSelection(t *testing.T) {
+	ui := newKeepSelectionAppUI(t, "a.txt")
+	sub := filepath.Join(ui.currentDir, "sub")
+	if err := os.Mkdir(sub, 0755); err != nil {
+		t.Fatalf("Failed to create sub: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(sub, "a.txt"), []byte("a"), 0644); err != nil {
+		t.Fatalf("Failed to create sub/a.txt: %v", err)
+	}
+	ui.refreshList()
+	ui.selectItem(slices.Index(ui.items, "a.txt"))
+
+	// 另一个目录中的同名文件不是同一个选择
+	ui.changeDir(sub)
+	if ui.validateSelection() || len(ui.selection) != 0 {
+		t.Errorf("Expected changing directory to clear the selection, got %q, %v", ui.selectedName, ui.selection)
+	}
+}
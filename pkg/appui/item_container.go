@@ -2,12 +2,15 @@ package appui
 
 import (
 	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
 	"fyne.io/fyne/v2/widget"
 )
 
 var _ fyne.Widget = (*ItemContainer)(nil)
 var _ fyne.Tappable = (*ItemContainer)(nil)
 var _ fyne.SecondaryTappable = (*ItemContainer)(nil)
+var _ desktop.Mouseable = (*ItemContainer)(nil)
+var _ fyne.Draggable = (*ItemContainer)(nil)
 
 // ItemContainer 是单个列表项，只负责显示文字和点击回调
 type ItemContainer struct {
@@ -16,6 +19,13 @@ type ItemContainer struct {
 	index          int
 	onTapped       func(index int)
 	onRightClicked func(index int, pos fyne.Position)
+	onToggled      func(index int)  // Ctrl/Cmd+左键点击
+	modifier       fyne.KeyModifier // 最近一次按下鼠标时的修饰键
+
+	onDropped func(index int, pos fyne.Position) // 拖动结束，pos 为松开鼠标处的绝对位置
+	dragging  bool
+	dragIndex int // 开始拖动时的索引，拖动中列表可能重新绑定该控件
+	dragPos   fyne.Position
 }
 
 // NewItemContainer 创建新ItemContainer
@@ -39,21 +49,77 @@ func (ic *ItemContainer) SetText(text string) {
 	ic.label.SetText(text)
 }
 
+// SetOnToggled 设置 Ctrl/Cmd+左键点击的回调，用于多选
+func (ic *ItemContainer) SetOnToggled(onToggled func(index int)) {
+	ic.onToggled = onToggled
+}
+
+// SetOnDropped 设置拖动该项后松开鼠标时的回调
+func (ic *ItemContainer) SetOnDropped(onDropped func(index int, pos fyne.Position)) {
+	ic.onDropped = onDropped
+}
+
+// SetSelected 设置是否显示为选中状态
+func (ic *ItemContainer) SetSelected(selected bool) {
+	importance := widget.MediumImportance
+	if selected {
+		importance = widget.HighImportance
+	}
+	if ic.label.Importance != importance {
+		ic.label.Importance = importance
+		ic.label.Refresh()
+	}
+}
+
 // SetIndex 设置当前索引
 func (ic *ItemContainer) SetIndex(i int) {
 	ic.index = i
 }
 
-// Tapped 左键点击
+// Tapped 左键点击，按住 Ctrl/Cmd 时切换选中状态
 func (ic *ItemContainer) Tapped(pe *fyne.PointEvent) {
+	toggle := ic.modifier&(fyne.KeyModifierControl|fyne.KeyModifierSuper) != 0
+	ic.modifier = 0
+	if toggle && ic.onToggled != nil {
+		ic.onToggled(ic.index)
+		return
+	}
 	if ic.onTapped != nil {
 		ic.onTapped(ic.index)
 	}
 }
 
+// MouseDown 记录修饰键，Tapped 中据此区分普通点击与多选点击
+func (ic *ItemContainer) MouseDown(me *desktop.MouseEvent) {
+	ic.modifier = me.Modifier
+}
+
+// MouseUp 实现 desktop.Mouseable 接口
+func (ic *ItemContainer) MouseUp(me *desktop.MouseEvent) {}
+
 // TappedSecondary 右键点击
 func (ic *ItemContainer) TappedSecondary(pe *fyne.PointEvent) {
 	if ic.onRightClicked != nil {
 		ic.onRightClicked(ic.index, pe.AbsolutePosition)
 	}
 }
+
+// Dragged 记录拖动的项和当前位置，松开时在 DragEnd 中回调
+func (ic *ItemContainer) Dragged(ev *fyne.DragEvent) {
+	if !ic.dragging {
+		ic.dragging = true
+		ic.dragIndex = ic.index
+	}
+	ic.dragPos = ev.AbsolutePosition
+}
+
+// DragEnd 拖动结束，报告拖动的项和松开的位置
+func (ic *ItemContainer) DragEnd() {
+	if !ic.dragging {
+		return
+	}
+	ic.dragging = false
+	if ic.onDropped != nil {
+		ic.onDropped(ic.dragIndex, ic.dragPos)
+	}
+}
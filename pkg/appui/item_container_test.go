@@ -4,7 +4,9 @@ import (
 	"testing"
 
 	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
 	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/widget"
 )
 
 func TestNewItemContainer(t *testing.T) {
@@ -180,3 +182,47 @@ func TestItemContainer_WithTestApp(t *testing.T) {
 		t.Errorf("Expected tapped index 7, got %d", tappedIndex)
 	}
 }
+
+func TestItemContainer_CtrlTappedToggles(t *testing.T) {
+	var tappedCalled, toggledCalled bool
+	var toggledIndex int
+
+	ic := NewItemContainer(func(index int) { tappedCalled = true }, nil)
+	ic.SetOnToggled(func(index int) {
+		toggledCalled = true
+		toggledIndex = index
+	})
+	ic.SetIndex(3)
+
+	ic.MouseDown(&desktop.MouseEvent{Modifier: fyne.KeyModifierControl})
+	ic.Tapped(&fyne.PointEvent{})
+
+	if !toggledCalled || toggledIndex != 3 {
+		t.Error("Ctrl+click should call the toggled callback with the item index")
+	}
+	if tappedCalled {
+		t.Error("Ctrl+click should not call the tapped callback")
+	}
+
+	// A plain click afterwards is a normal tap again
+	toggledCalled = false
+	ic.MouseDown(&desktop.MouseEvent{})
+	ic.Tapped(&fyne.PointEvent{})
+	if !tappedCalled || toggledCalled {
+		t.Error("Plain click should call the tapped callback only")
+	}
+}
+
+func TestItemContainer_SetSelected(t *testing.T) {
+	ic := NewItemContainer(nil, nil)
+
+	ic.SetSelected(true)
+	if ic.label.Importance != widget.HighImportance {
+		t.Error("Selected item should be highlighted")
+	}
+
+	ic.SetSelected(false)
+	if ic.label.Importance != widget.MediumImportance {
+		t.Error("Deselected item should use the default importance")
+	}
+}
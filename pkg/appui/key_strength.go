@@ -0,0 +1,51 @@
+package appui
+
+import (
+	"log/slog"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/mingregister/fers/pkg/crypto"
+)
+
+// prefKeyWarningDismissed records that the user dismissed the weak key banner
+const prefKeyWarningDismissed = "key_strength.warning_dismissed"
+
+// WarnKeyStrength shows a dismissible banner recommending a stronger crypto
+// key when score is weak. The banner is advisory only and is not shown again
+// once dismissed.
+func (ui *AppUI) WarnKeyStrength(score crypto.Score, reasons []string) {
+	if score != crypto.ScoreWeak || ui.banner == nil {
+		return
+	}
+	prefs := ui.app.Preferences()
+	if prefs.Bool(prefKeyWarningDismissed) {
+		return
+	}
+
+	text := "The crypto key is weak, so your backup could be decrypted by guessing it"
+	if len(reasons) > 0 {
+		text += ": " + strings.Join(reasons, "; ")
+	}
+	text += ". Consider re-keying with a longer, random key (File > Change Crypto Key...)."
+	message := widget.NewLabel(text)
+	message.Wrapping = fyne.TextWrapWord
+	message.Importance = widget.WarningImportance
+
+	var row *fyne.Container
+	dismiss := widget.NewButton("Dismiss", func() {
+		prefs.SetBool(prefKeyWarningDismissed, true)
+		ui.banner.Remove(row)
+		if len(ui.banner.Objects) == 0 {
+			ui.banner.Hide()
+		}
+		ui.logger.Info("Weak key warning dismissed")
+	})
+	row = container.NewBorder(nil, nil, widget.NewIcon(theme.WarningIcon()), dismiss, message)
+	ui.banner.Add(row)
+	ui.banner.Show()
+	ui.logger.Debug("Showing weak key warning", slog.String("score", score.String()))
+}
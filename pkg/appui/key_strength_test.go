@@ -0,0 +1,42 @@
+package appui
+
+import (
+	"testing"
+
+	"fyne.io/fyne/v2/test"
+	"github.com/mingregister/fers/pkg/crypto"
+)
+
+func TestAppUI_WarnKeyStrength(t *testing.T) {
+	ui := newTestAppUI(t)
+	ui.setupUI()
+
+	ui.WarnKeyStrength(crypto.ScoreStrong, nil)
+	ui.WarnKeyStrength(crypto.ScoreMedium, []string{"it is shorter than 12 characters"})
+	if ui.banner.Visible() {
+		t.Fatal("Banner should only be shown for a weak key")
+	}
+
+	ui.WarnKeyStrength(crypto.AssessKeyStrength("1234"))
+	if !ui.banner.Visible() || len(ui.banner.Objects) != 1 {
+		t.Fatalf("Expected one banner row for a weak key, got %d", len(ui.banner.Objects))
+	}
+	if findLabel(ui.banner, "crypto key is weak") == nil {
+		t.Error("Banner should explain the weak key")
+	}
+
+	dismiss := findButton(ui.banner, "Dismiss")
+	if dismiss == nil {
+		t.Fatal("Banner should have a Dismiss button")
+	}
+	test.Tap(dismiss)
+	if ui.banner.Visible() || len(ui.banner.Objects) != 0 {
+		t.Error("Dismiss should hide the banner")
+	}
+
+	// 关闭后不再提示
+	ui.WarnKeyStrength(crypto.AssessKeyStrength("1234"))
+	if ui.banner.Visible() {
+		t.Error("Banner should not be shown again once dismissed")
+	}
+}
@@ -0,0 +1,74 @@
+package appui
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+)
+
+// EncryptToFile asks for a file and where to save it, and encrypts it locally without uploading
+func (ui *AppUI) EncryptToFile() {
+	ui.pickLocalCryptPaths(func(src string) string {
+		return filepath.Base(src) + ".enc"
+	}, func(src, dst string) {
+		ui.runLocalCrypt("Encrypt to File", src, dst, ui.fileManager.EncryptLocalFile)
+	})
+}
+
+// DecryptFromFile asks for an encrypted file and where to save it, and decrypts it locally
+func (ui *AppUI) DecryptFromFile() {
+	ui.pickLocalCryptPaths(func(src string) string {
+		return strings.TrimSuffix(filepath.Base(src), ".enc")
+	}, func(src, dst string) {
+		ui.runLocalCrypt("Decrypt from File", src, dst, ui.fileManager.DecryptLocalFile)
+	})
+}
+
+// pickLocalCryptPaths shows an open dialog for the source file and then a
+// save dialog, suggesting dstName(src), before calling run
+func (ui *AppUI) pickLocalCryptPaths(dstName func(src string) string, run func(src, dst string)) {
+	openDialog := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("failed to open file: %w", err), ui.window)
+			return
+		}
+		if reader == nil {
+			// 用户取消
+			return
+		}
+		src := reader.URI().Path()
+		reader.Close()
+
+		saveDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil {
+				dialog.ShowError(fmt.Errorf("failed to open file: %w", err), ui.window)
+				return
+			}
+			if writer == nil {
+				return
+			}
+			// 只需要路径，内容由 FileManager 原子写入
+			dst := writer.URI().Path()
+			writer.Close()
+			run(src, dst)
+		}, ui.window)
+		saveDialog.SetFileName(dstName(src))
+		saveDialog.Show()
+	}, ui.window)
+	openDialog.Show()
+}
+
+// runLocalCrypt runs transform from src to dst as an operation and refreshes the list
+func (ui *AppUI) runLocalCrypt(operationName, src, dst string, transform func(src, dst string) error) {
+	ui.runOperation(operationName, func(context.Context) error {
+		if err := transform(src, dst); err != nil {
+			return err
+		}
+		fyne.Do(ui.refreshList)
+		return nil
+	})
+}
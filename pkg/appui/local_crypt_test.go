@@ -0,0 +1,31 @@
+package appui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAppUI_RunLocalCryptRoundTrip(t *testing.T) {
+	ui := newTestAppUI(t)
+	src := filepath.Join(ui.currentDir, "notes.txt")
+	if err := os.WriteFile(src, []byte("local secret"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	encrypted := filepath.Join(t.TempDir(), "notes.txt.enc")
+	ui.runLocalCrypt("Encrypt to File", src, encrypted, ui.fileManager.EncryptLocalFile)
+	if !waitFor(t, func() bool { _, err := os.Stat(encrypted); return err == nil && !ui.progressBar.Visible() }) {
+		t.Fatal("Expected the encrypted file to be written")
+	}
+
+	decrypted := filepath.Join(ui.currentDir, "restored.txt")
+	ui.runLocalCrypt("Decrypt from File", encrypted, decrypted, ui.fileManager.DecryptLocalFile)
+	if !waitFor(t, func() bool { _, err := os.Stat(decrypted); return err == nil && !ui.progressBar.Visible() }) {
+		t.Fatal("Expected the decrypted file to be written")
+	}
+	data, err := os.ReadFile(decrypted)
+	if err != nil || string(data) != "local secret" {
+		t.Errorf("Unexpected decrypted content %q, %v", data, err)
+	}
+}
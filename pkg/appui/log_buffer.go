@@ -0,0 +1,48 @@
+package appui
+
+import "log/slog"
+
+// logEntry is a formatted log line together with the level it was logged at
+type logEntry struct {
+	level slog.Level
+	text  string
+}
+
+// logBuffer is a ring buffer of log entries. When max is 0 it grows without bound.
+type logBuffer struct {
+	entries []logEntry
+	start   int
+	max     int
+}
+
+// newLogBuffer creates a new logBuffer holding at most max entries
+func newLogBuffer(max int) *logBuffer {
+	if max < 0 {
+		max = 0
+	}
+	return &logBuffer{max: max}
+}
+
+// push appends an entry, overwriting the oldest one once the buffer is full;
+// it reports whether an entry was overwritten
+func (b *logBuffer) push(entry logEntry) bool {
+	if b.max == 0 || len(b.entries) < b.max {
+		b.entries = append(b.entries, entry)
+		return false
+	}
+	b.entries[b.start] = entry
+	b.start = (b.start + 1) % b.max
+	return true
+}
+
+// len returns the number of buffered entries
+func (b *logBuffer) len() int {
+	return len(b.entries)
+}
+
+// snapshot returns the buffered entries from oldest to newest
+func (b *logBuffer) snapshot() []logEntry {
+	out := make([]logEntry, 0, len(b.entries))
+	out = append(out, b.entries[b.start:]...)
+	return append(out, b.entries[:b.start]...)
+}
@@ -3,24 +3,87 @@ package appui
 import (
 	"context"
 	"fmt"
+	"image/color"
+	"io"
 	"log/slog"
 	"runtime"
 	"strings"
 	"sync"
+	"time"
 
 	"fyne.io/fyne/v2/widget"
 )
 
+const (
+	// DefaultLogMaxLines is the number of log lines kept in memory by default
+	DefaultLogMaxLines = 1000
+	// DefaultLogFlushInterval is how long records are batched before the widget is redrawn
+	DefaultLogFlushInterval = 100 * time.Millisecond
+)
+
+var _ io.WriterTo = (*UILogHandler)(nil)
+
+// Row styles used to highlight log lines by severity
+var (
+	logWarnStyle  = &widget.CustomTextGridStyle{FGColor: color.NRGBA{R: 0xff, G: 0x98, B: 0x00, A: 0xff}}
+	logErrorStyle = &widget.CustomTextGridStyle{FGColor: color.NRGBA{R: 0xf4, G: 0x43, B: 0x36, A: 0xff}}
+)
+
+// logLevelStyle returns the row style for a level, or nil for the default style
+func logLevelStyle(level slog.Level) widget.TextGridStyle {
+	switch {
+	case level >= slog.LevelError:
+		return logErrorStyle
+	case level >= slog.LevelWarn:
+		return logWarnStyle
+	default:
+		return nil
+	}
+}
+
 // UILogHandler is a custom slog handler that outputs to a UI widget
 type UILogHandler struct {
-	logWidget *widget.TextGrid
-	mutex     sync.Mutex
-	opts      slog.HandlerOptions
-	logs      []string
+	sink     LogSink
+	mutex    sync.Mutex
+	opts     slog.HandlerOptions
+	maxLines int
+	logs     *logBuffer
+
+	// level is the minimum level currently displayed; it may differ from
+	// opts.Level, which decides what is captured into the buffer
+	level slog.LevelVar
+
+	// Batched rendering: a pending timer means there are lines not yet shown
+	flushInterval time.Duration
+	flushTimer    *time.Timer
+
+	// Lines shown since the last render are appended to the sink; once the
+	// buffer drops a line or the level changes the sink is redrawn instead
+	pending []LogLine
+	redraw  bool
+}
+
+// UILogHandlerOption configures optional behaviour of a UILogHandler
+type UILogHandlerOption func(*UILogHandler)
+
+// WithMaxLines sets how many log lines are kept; 0 keeps every line
+func WithMaxLines(maxLines int) UILogHandlerOption {
+	return func(h *UILogHandler) {
+		h.maxLines = maxLines
+	}
+}
+
+// WithFlushInterval sets how long records are batched before the widget is
+// redrawn; 0 redraws on every record
+func WithFlushInterval(interval time.Duration) UILogHandlerOption {
+	return func(h *UILogHandler) {
+		h.flushInterval = interval
+	}
 }
 
-// NewUILogHandler creates a new UI log handler
-func NewUILogHandler(logWidget *widget.TextGrid, opts *slog.HandlerOptions) *UILogHandler {
+// NewUILogHandler creates a new UI log handler that renders into sink; use
+// NewTextGridSink to render into a TextGrid
+func NewUILogHandler(sink LogSink, opts *slog.HandlerOptions, options ...UILogHandlerOption) *UILogHandler {
 	if opts == nil {
 		opts = &slog.HandlerOptions{
 			Level:     slog.LevelInfo,
@@ -28,16 +91,55 @@ func NewUILogHandler(logWidget *widget.TextGrid, opts *slog.HandlerOptions) *UIL
 		}
 	}
 
-	return &UILogHandler{
-		logWidget: logWidget,
-		opts:      *opts,
-		logs:      make([]string, 0),
+	h := &UILogHandler{
+		sink:     sink,
+		opts:     *opts,
+		maxLines: DefaultLogMaxLines,
+
+		flushInterval: DefaultLogFlushInterval,
+	}
+	for _, option := range options {
+		option(h)
+	}
+	h.logs = newLogBuffer(h.maxLines)
+	h.level.Set(h.captureLevel())
+
+	return h
+}
+
+// captureLevel returns the minimum level retained in the buffer
+func (h *UILogHandler) captureLevel() slog.Level {
+	if h.opts.Level == nil {
+		return slog.LevelInfo
 	}
+	return h.opts.Level.Level()
 }
 
-// Enabled reports whether the handler handles records at the given level
+// Enabled reports whether the handler handles records at the given level.
+// Records at the configured level are kept even while hidden by SetLevel,
+// so lowering the display level again can reveal them.
 func (h *UILogHandler) Enabled(ctx context.Context, level slog.Level) bool {
-	return level >= h.opts.Level.Level()
+	return level >= min(h.captureLevel(), h.level.Level())
+}
+
+// Level returns the minimum level currently displayed
+func (h *UILogHandler) Level() slog.Level {
+	return h.level.Level()
+}
+
+// SetLevel changes the minimum level displayed and re-renders the buffered logs
+func (h *UILogHandler) SetLevel(level slog.Level) {
+	h.level.Set(level)
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.flushTimer != nil {
+		h.flushTimer.Stop()
+		h.flushTimer = nil
+	}
+	h.redraw = true
+	h.render()
 }
 
 // Handle handles the Record
@@ -85,22 +187,94 @@ func (h *UILogHandler) Handle(ctx context.Context, r slog.Record) error {
 		}
 	}
 
-	// Add to logs slice
-	h.logs = append(h.logs, logLine)
+	// Add to the ring buffer, which drops the oldest line once maxLines is reached
+	if h.logs.push(logEntry{level: r.Level, text: logLine}) {
+		h.redraw = true
+	}
+	if !h.redraw && r.Level >= h.level.Level() {
+		h.pending = append(h.pending, LogLine{Level: r.Level, Text: logLine})
+	}
 
-	// Keep only the last 1000 lines to prevent memory issues
-	if len(h.logs) > 1000 {
-		h.logs = h.logs[len(h.logs)-1000:]
+	// Redrawing the whole text is O(n), so batch records and render at most
+	// once per flush interval instead of on every line
+	if h.flushInterval <= 0 {
+		h.render()
+		return nil
 	}
+	if h.flushTimer == nil {
+		h.flushTimer = time.AfterFunc(h.flushInterval, h.Flush)
+	}
+
+	return nil
+}
 
-	// Update the widget - TextGrid performs better with SetText than incremental updates
-	allText := strings.Join(h.logs, "\n")
-	h.logWidget.SetText(allText)
+// Flush renders any pending log lines to the widget immediately
+func (h *UILogHandler) Flush() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
 
-	// Refresh the widget to ensure UI updates
-	h.logWidget.Refresh()
+	if h.flushTimer == nil {
+		return
+	}
+	h.flushTimer.Stop()
+	h.flushTimer = nil
 
-	return nil
+	h.render()
+}
+
+// Snapshot returns every buffered log line from oldest to newest,
+// including lines hidden by the current display level
+func (h *UILogHandler) Snapshot() []string {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	entries := h.logs.snapshot()
+	lines := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		lines = append(lines, entry.text)
+	}
+	return lines
+}
+
+// WriteTo writes every buffered log line to w, one per line
+func (h *UILogHandler) WriteTo(w io.Writer) (int64, error) {
+	var written int64
+	for _, line := range h.Snapshot() {
+		n, err := io.WriteString(w, line+"\n")
+		written += int64(n)
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// Sink returns the sink the handler renders into
+func (h *UILogHandler) Sink() LogSink {
+	return h.sink
+}
+
+// render appends the pending lines to the sink, or redraws it from the
+// buffer when lines were dropped; the caller must hold h.mutex
+func (h *UILogHandler) render() {
+	if !h.redraw {
+		for _, line := range h.pending {
+			h.sink.Append(line.Text, line.Level)
+		}
+		h.pending = nil
+		return
+	}
+	h.redraw = false
+	h.pending = nil
+
+	level := h.level.Level()
+	var lines []LogLine
+	for _, entry := range h.logs.snapshot() {
+		if entry.level >= level {
+			lines = append(lines, LogLine{Level: entry.level, Text: entry.text})
+		}
+	}
+	h.sink.SetLines(lines)
 }
 
 // WithAttrs returns a new Handler whose attributes consist of
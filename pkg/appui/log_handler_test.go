@@ -2,24 +2,26 @@ package appui
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"strings"
 	"testing"
 	"time"
 
+	"fyne.io/fyne/v2/test"
 	"fyne.io/fyne/v2/widget"
 )
 
 func TestNewUILogHandler(t *testing.T) {
 	logWidget := widget.NewTextGrid()
 	opts := &slog.HandlerOptions{Level: slog.LevelInfo}
-	handler := NewUILogHandler(logWidget, opts)
+	handler := NewUILogHandler(NewTextGridSink(logWidget), opts)
 
 	if handler == nil {
 		t.Fatal("NewUILogHandler returned nil")
 	}
 
-	if handler.logWidget != logWidget {
+	if sink, ok := handler.Sink().(WidgetSink); !ok || sink.CanvasObject() != logWidget {
 		t.Error("UILogHandler widget not set correctly")
 	}
 
@@ -29,8 +31,8 @@ func TestNewUILogHandler(t *testing.T) {
 }
 
 func TestNewUILogHandler_WithNilOpts(t *testing.T) {
-	logWidget := widget.NewTextGrid()
-	handler := NewUILogHandler(logWidget, nil)
+	sink := &recordingSink{}
+	handler := NewUILogHandler(sink, nil)
 
 	if handler == nil {
 		t.Fatal("NewUILogHandler returned nil")
@@ -42,9 +44,9 @@ func TestNewUILogHandler_WithNilOpts(t *testing.T) {
 }
 
 func TestUILogHandler_Enabled(t *testing.T) {
-	logWidget := widget.NewTextGrid()
+	sink := &recordingSink{}
 	opts := &slog.HandlerOptions{Level: slog.LevelWarn}
-	handler := NewUILogHandler(logWidget, opts)
+	handler := NewUILogHandler(sink, opts)
 
 	ctx := context.Background()
 
@@ -68,9 +70,9 @@ func TestUILogHandler_Enabled(t *testing.T) {
 }
 
 func TestUILogHandler_Handle(t *testing.T) {
-	logWidget := widget.NewTextGrid()
+	sink := &recordingSink{}
 	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
-	handler := NewUILogHandler(logWidget, opts)
+	handler := NewUILogHandler(sink, opts)
 
 	ctx := context.Background()
 
@@ -87,21 +89,22 @@ func TestUILogHandler_Handle(t *testing.T) {
 		t.Fatalf("Handle returned error: %v", err)
 	}
 
-	// Check if the message was added to the widget
-	text := logWidget.Text()
+	// Check if the message was added to the sink
+	handler.Flush()
+	text := sink.text()
 	if !strings.Contains(text, "Test log message") {
-		t.Error("Log message not found in widget text")
+		t.Error("Log message not found in sink text")
 	}
 
 	if !strings.Contains(text, "INFO") {
-		t.Error("Log level not found in widget text")
+		t.Error("Log level not found in sink text")
 	}
 }
 
 func TestUILogHandler_HandleMultipleMessages(t *testing.T) {
-	logWidget := widget.NewTextGrid()
+	sink := &recordingSink{}
 	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
-	handler := NewUILogHandler(logWidget, opts)
+	handler := NewUILogHandler(sink, opts)
 
 	ctx := context.Background()
 
@@ -121,18 +124,19 @@ func TestUILogHandler_HandleMultipleMessages(t *testing.T) {
 		}
 	}
 
-	text := logWidget.Text()
+	handler.Flush()
+	text := sink.text()
 	for _, msg := range messages {
 		if !strings.Contains(text, msg) {
-			t.Errorf("Message '%s' not found in widget text", msg)
+			t.Errorf("Message '%s' not found in sink text", msg)
 		}
 	}
 }
 
 func TestUILogHandler_HandleDifferentLevels(t *testing.T) {
-	logWidget := widget.NewTextGrid()
+	sink := &recordingSink{}
 	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
-	handler := NewUILogHandler(logWidget, opts)
+	handler := NewUILogHandler(sink, opts)
 
 	ctx := context.Background()
 
@@ -159,18 +163,19 @@ func TestUILogHandler_HandleDifferentLevels(t *testing.T) {
 		}
 	}
 
-	text := logWidget.Text()
+	handler.Flush()
+	text := sink.text()
 	for _, levelTest := range levels {
 		if !strings.Contains(text, levelTest.name) {
-			t.Errorf("Level '%s' not found in widget text", levelTest.name)
+			t.Errorf("Level '%s' not found in sink text", levelTest.name)
 		}
 	}
 }
 
 func TestUILogHandler_HandleWithAttributes(t *testing.T) {
-	logWidget := widget.NewTextGrid()
+	sink := &recordingSink{}
 	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
-	handler := NewUILogHandler(logWidget, opts)
+	handler := NewUILogHandler(sink, opts)
 
 	ctx := context.Background()
 
@@ -190,22 +195,23 @@ func TestUILogHandler_HandleWithAttributes(t *testing.T) {
 		t.Fatalf("Handle returned error: %v", err)
 	}
 
-	text := logWidget.Text()
+	handler.Flush()
+	text := sink.text()
 	if !strings.Contains(text, "operation=test") {
-		t.Error("String attribute not found in widget text")
+		t.Error("String attribute not found in sink text")
 	}
 	if !strings.Contains(text, "count=42") {
-		t.Error("Int attribute not found in widget text")
+		t.Error("Int attribute not found in sink text")
 	}
 	if !strings.Contains(text, "success=true") {
-		t.Error("Bool attribute not found in widget text")
+		t.Error("Bool attribute not found in sink text")
 	}
 }
 
 func TestUILogHandler_WithAttrs(t *testing.T) {
-	logWidget := widget.NewTextGrid()
+	sink := &recordingSink{}
 	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
-	handler := NewUILogHandler(logWidget, opts)
+	handler := NewUILogHandler(sink, opts)
 
 	attrs := []slog.Attr{
 		slog.String("component", "test"),
@@ -229,9 +235,9 @@ func TestUILogHandler_WithAttrs(t *testing.T) {
 }
 
 func TestUILogHandler_WithGroup(t *testing.T) {
-	logWidget := widget.NewTextGrid()
+	sink := &recordingSink{}
 	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
-	handler := NewUILogHandler(logWidget, opts)
+	handler := NewUILogHandler(sink, opts)
 
 	newHandler := handler.WithGroup("testgroup")
 	if newHandler == nil {
@@ -250,9 +256,9 @@ func TestUILogHandler_WithGroup(t *testing.T) {
 }
 
 func TestUILogHandler_HandleEmptyMessage(t *testing.T) {
-	logWidget := widget.NewTextGrid()
+	sink := &recordingSink{}
 	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
-	handler := NewUILogHandler(logWidget, opts)
+	handler := NewUILogHandler(sink, opts)
 
 	ctx := context.Background()
 
@@ -267,16 +273,17 @@ func TestUILogHandler_HandleEmptyMessage(t *testing.T) {
 		t.Fatalf("Handle returned error for empty message: %v", err)
 	}
 
-	text := logWidget.Text()
+	handler.Flush()
+	text := sink.text()
 	if !strings.Contains(text, "INFO") {
-		t.Error("Log level not found in widget text for empty message")
+		t.Error("Log level not found in sink text for empty message")
 	}
 }
 
 func TestUILogHandler_HandleLongMessage(t *testing.T) {
-	logWidget := widget.NewTextGrid()
+	sink := &recordingSink{}
 	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
-	handler := NewUILogHandler(logWidget, opts)
+	handler := NewUILogHandler(sink, opts)
 
 	ctx := context.Background()
 
@@ -292,16 +299,17 @@ func TestUILogHandler_HandleLongMessage(t *testing.T) {
 		t.Fatalf("Handle returned error for long message: %v", err)
 	}
 
-	text := logWidget.Text()
+	handler.Flush()
+	text := sink.text()
 	if !strings.Contains(text, "This is a very long message.") {
-		t.Error("Long message not found in widget text")
+		t.Error("Long message not found in sink text")
 	}
 }
 
 func TestUILogHandler_HandleUnicodeMessage(t *testing.T) {
-	logWidget := widget.NewTextGrid()
+	sink := &recordingSink{}
 	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
-	handler := NewUILogHandler(logWidget, opts)
+	handler := NewUILogHandler(sink, opts)
 
 	ctx := context.Background()
 
@@ -317,25 +325,26 @@ func TestUILogHandler_HandleUnicodeMessage(t *testing.T) {
 		t.Fatalf("Handle returned error for unicode message: %v", err)
 	}
 
-	text := logWidget.Text()
+	handler.Flush()
+	text := sink.text()
 	if !strings.Contains(text, unicodeMessage) {
-		t.Error("Unicode message not found in widget text")
+		t.Error("Unicode message not found in sink text")
 	}
 }
 
 func TestUILogHandler_InterfaceCompliance(t *testing.T) {
-	logWidget := widget.NewTextGrid()
+	sink := &recordingSink{}
 	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
-	handler := NewUILogHandler(logWidget, opts)
+	handler := NewUILogHandler(sink, opts)
 
 	// Test that UILogHandler implements slog.Handler
 	var _ slog.Handler = handler
 }
 
 func TestUILogHandler_ConcurrentAccess(t *testing.T) {
-	logWidget := widget.NewTextGrid()
+	sink := &recordingSink{}
 	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
-	handler := NewUILogHandler(logWidget, opts)
+	handler := NewUILogHandler(sink, opts)
 
 	ctx := context.Background()
 
@@ -364,16 +373,17 @@ func TestUILogHandler_ConcurrentAccess(t *testing.T) {
 		<-done
 	}
 
-	text := logWidget.Text()
+	handler.Flush()
+	text := sink.text()
 	if !strings.Contains(text, "Concurrent message") {
-		t.Error("Concurrent messages not found in widget text")
+		t.Error("Concurrent messages not found in sink text")
 	}
 }
 
 func TestUILogHandler_TimeFormatting(t *testing.T) {
-	logWidget := widget.NewTextGrid()
+	sink := &recordingSink{}
 	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
-	handler := NewUILogHandler(logWidget, opts)
+	handler := NewUILogHandler(sink, opts)
 
 	ctx := context.Background()
 
@@ -389,17 +399,18 @@ func TestUILogHandler_TimeFormatting(t *testing.T) {
 		t.Fatalf("Handle returned error: %v", err)
 	}
 
-	text := logWidget.Text()
+	handler.Flush()
+	text := sink.text()
 	// Check if time is formatted (should contain some time components)
 	if !strings.Contains(text, "15:30:45") {
-		t.Error("Time formatting not found in widget text")
+		t.Error("Time formatting not found in sink text")
 	}
 }
 
 func TestUILogHandler_LogsLimit(t *testing.T) {
-	logWidget := widget.NewTextGrid()
+	sink := &recordingSink{}
 	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
-	handler := NewUILogHandler(logWidget, opts)
+	handler := NewUILogHandler(sink, opts)
 
 	ctx := context.Background()
 
@@ -419,18 +430,84 @@ func TestUILogHandler_LogsLimit(t *testing.T) {
 	}
 
 	// Check that logs are limited to 1000
-	if len(handler.logs) > 1000 {
-		t.Errorf("Expected logs to be limited to 1000, got %d", len(handler.logs))
+	if handler.logs.len() > 1000 {
+		t.Errorf("Expected logs to be limited to 1000, got %d", handler.logs.len())
+	}
+}
+
+func TestUILogHandler_MaxLines(t *testing.T) {
+	sink := &recordingSink{}
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
+	handler := NewUILogHandler(sink, opts, WithMaxLines(50))
+
+	ctx := context.Background()
+
+	for i := 0; i < 200; i++ {
+		record := slog.Record{
+			Time:    time.Now(),
+			Level:   slog.LevelInfo,
+			Message: "Test message",
+		}
+		record.AddAttrs(slog.Int("index", i))
+
+		if err := handler.Handle(ctx, record); err != nil {
+			t.Fatalf("Handle returned error for message %d: %v", i, err)
+		}
+	}
+
+	entries := handler.logs.snapshot()
+	if len(entries) != 50 {
+		t.Fatalf("Expected 50 buffered lines, got %d", len(entries))
+	}
+
+	// The remaining lines must be the last 50 messages, oldest first
+	for i, entry := range entries {
+		want := fmt.Sprintf("index=%d)", 150+i)
+		if !strings.Contains(entry.text, want) {
+			t.Errorf("Line %d: expected %q, got %q", i, want, entry.text)
+		}
+	}
+
+	handler.Flush()
+	text := sink.text()
+	if strings.Contains(text, "index=149)") {
+		t.Error("Trimmed message still present in sink text")
+	}
+	if !strings.Contains(text, "index=199)") {
+		t.Error("Latest message not found in sink text")
+	}
+}
+
+func TestUILogHandler_MaxLinesUnbounded(t *testing.T) {
+	sink := &recordingSink{}
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
+	handler := NewUILogHandler(sink, opts, WithMaxLines(0))
+
+	ctx := context.Background()
+
+	for i := 0; i < 1100; i++ {
+		record := slog.Record{
+			Time:    time.Now(),
+			Level:   slog.LevelInfo,
+			Message: "Test message",
+		}
+		if err := handler.Handle(ctx, record); err != nil {
+			t.Fatalf("Handle returned error for message %d: %v", i, err)
+		}
+	}
+
+	if handler.logs.len() != 1100 {
+		t.Errorf("Expected all 1100 lines to be kept, got %d", handler.logs.len())
 	}
 }
 
 func TestUILogHandler_AddSource(t *testing.T) {
-	logWidget := widget.NewTextGrid()
+	sink := &recordingSink{}
 	opts := &slog.HandlerOptions{
 		Level:     slog.LevelDebug,
 		AddSource: true,
 	}
-	handler := NewUILogHandler(logWidget, opts)
+	handler := NewUILogHandler(sink, opts)
 
 	ctx := context.Background()
 
@@ -448,8 +525,274 @@ func TestUILogHandler_AddSource(t *testing.T) {
 
 	// Note: Since we set PC to 1, the source info might not be meaningful,
 	// but we can test that the handler doesn't crash
-	text := logWidget.Text()
+	handler.Flush()
+	text := sink.text()
 	if !strings.Contains(text, "Test with source") {
-		t.Error("Message not found in widget text")
+		t.Error("Message not found in sink text")
 	}
 }
+
+func TestUILogHandler_BatchedFlush(t *testing.T) {
+	sink := &recordingSink{}
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
+	handler := NewUILogHandler(sink, opts, WithFlushInterval(10*time.Millisecond))
+
+	ctx := context.Background()
+
+	for i := 0; i < 100; i++ {
+		record := slog.Record{
+			Time:    time.Now(),
+			Level:   slog.LevelInfo,
+			Message: "Batched message",
+		}
+		record.AddAttrs(slog.Int("index", i))
+
+		if err := handler.Handle(ctx, record); err != nil {
+			t.Fatalf("Handle returned error for message %d: %v", i, err)
+		}
+	}
+
+	// The timer should render every message without an explicit Flush
+	deadline := time.Now().Add(time.Second)
+	for !strings.Contains(sink.text(), "index=99)") {
+		if time.Now().After(deadline) {
+			t.Fatal("Batched messages were not flushed to the sink")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	text := sink.text()
+	for i := 0; i < 100; i++ {
+		if !strings.Contains(text, fmt.Sprintf("index=%d)", i)) {
+			t.Errorf("Message %d not found in sink text", i)
+		}
+	}
+}
+
+func TestUILogHandler_FlushRendersPending(t *testing.T) {
+	sink := &recordingSink{}
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
+	handler := NewUILogHandler(sink, opts, WithFlushInterval(time.Hour))
+
+	record := slog.Record{
+		Time:    time.Now(),
+		Level:   slog.LevelInfo,
+		Message: "Pending message",
+	}
+	if err := handler.Handle(context.Background(), record); err != nil {
+		t.Fatalf("Handle returned error: %v", err)
+	}
+
+	if strings.Contains(sink.text(), "Pending message") {
+		t.Error("Message should not be rendered before the flush interval")
+	}
+
+	handler.Flush()
+	if !strings.Contains(sink.text(), "Pending message") {
+		t.Error("Flush did not render the pending message")
+	}
+}
+
+func TestUILogHandler_SetLevelEnabled(t *testing.T) {
+	sink := &recordingSink{}
+	opts := &slog.HandlerOptions{Level: slog.LevelInfo}
+	handler := NewUILogHandler(sink, opts)
+
+	ctx := context.Background()
+
+	if handler.Enabled(ctx, slog.LevelDebug) {
+		t.Error("Debug should be disabled at the configured Info level")
+	}
+
+	handler.SetLevel(slog.LevelDebug)
+	if handler.Level() != slog.LevelDebug {
+		t.Errorf("Expected Level() Debug, got %v", handler.Level())
+	}
+	if !handler.Enabled(ctx, slog.LevelDebug) {
+		t.Error("Debug should be enabled after SetLevel(Debug)")
+	}
+
+	// Raising the display level keeps capturing at the configured level
+	handler.SetLevel(slog.LevelError)
+	if handler.Enabled(ctx, slog.LevelDebug) {
+		t.Error("Debug should be disabled after SetLevel(Error)")
+	}
+	if !handler.Enabled(ctx, slog.LevelInfo) {
+		t.Error("Info should still be captured after SetLevel(Error)")
+	}
+}
+
+func TestUILogHandler_SetLevelFiltersOutput(t *testing.T) {
+	sink := &recordingSink{}
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
+	handler := NewUILogHandler(sink, opts)
+
+	ctx := context.Background()
+
+	levels := []struct {
+		level   slog.Level
+		message string
+	}{
+		{slog.LevelDebug, "Debug line"},
+		{slog.LevelInfo, "Info line"},
+		{slog.LevelWarn, "Warn line"},
+		{slog.LevelError, "Error line"},
+	}
+	for _, l := range levels {
+		record := slog.Record{Time: time.Now(), Level: l.level, Message: l.message}
+		if err := handler.Handle(ctx, record); err != nil {
+			t.Fatalf("Handle returned error: %v", err)
+		}
+	}
+
+	handler.SetLevel(slog.LevelWarn)
+	text := sink.text()
+	for _, hidden := range []string{"Debug line", "Info line"} {
+		if strings.Contains(text, hidden) {
+			t.Errorf("%q should be hidden at Warn level", hidden)
+		}
+	}
+	for _, shown := range []string{"Warn line", "Error line"} {
+		if !strings.Contains(text, shown) {
+			t.Errorf("%q should be shown at Warn level", shown)
+		}
+	}
+
+	// Lowering the threshold reveals the retained lines again
+	handler.SetLevel(slog.LevelDebug)
+	text = sink.text()
+	for _, l := range levels {
+		if !strings.Contains(text, l.message) {
+			t.Errorf("%q should be shown at Debug level", l.message)
+		}
+	}
+}
+
+func TestUILogHandler_WriteTo(t *testing.T) {
+	sink := &recordingSink{}
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
+	handler := NewUILogHandler(sink, opts)
+
+	ctx := context.Background()
+
+	testTime := time.Date(2023, 12, 25, 15, 30, 45, 0, time.UTC)
+	for _, msg := range []string{"First message", "Second message"} {
+		record := slog.Record{Time: testTime, Level: slog.LevelInfo, Message: msg}
+		if err := handler.Handle(ctx, record); err != nil {
+			t.Fatalf("Handle returned error: %v", err)
+		}
+	}
+
+	var buf strings.Builder
+	n, err := handler.WriteTo(&buf)
+	if err != nil {
+		t.Fatalf("WriteTo returned error: %v", err)
+	}
+
+	expected := "[2023-12-25 15:30:45] INFO: First message\n" +
+		"[2023-12-25 15:30:45] INFO: Second message\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+	if n != int64(len(expected)) {
+		t.Errorf("Expected %d bytes written, got %d", len(expected), n)
+	}
+}
+
+func TestUILogHandler_WriteToEmpty(t *testing.T) {
+	handler := NewUILogHandler(&recordingSink{}, nil)
+
+	if len(handler.Snapshot()) != 0 {
+		t.Error("Snapshot of a new handler should be empty")
+	}
+
+	var buf strings.Builder
+	n, err := handler.WriteTo(&buf)
+	if err != nil {
+		t.Fatalf("WriteTo returned error: %v", err)
+	}
+	if n != 0 || buf.Len() != 0 {
+		t.Errorf("Expected nothing written, got %d bytes", n)
+	}
+}
+
+func TestUILogHandler_SeverityStyles(t *testing.T) {
+	test.NewTempApp(t)
+	logWidget := widget.NewTextGrid()
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
+	handler := NewUILogHandler(NewTextGridSink(logWidget), opts, WithMaxLines(3), WithFlushInterval(0))
+
+	ctx := context.Background()
+
+	records := []struct {
+		level   slog.Level
+		message string
+	}{
+		{slog.LevelInfo, "Dropped by trim"},
+		{slog.LevelError, "Error line"},
+		{slog.LevelInfo, "Info line"},
+		{slog.LevelWarn, "Warn line"},
+	}
+	for _, r := range records {
+		record := slog.Record{Time: time.Now(), Level: r.level, Message: r.message}
+		if err := handler.Handle(ctx, record); err != nil {
+			t.Fatalf("Handle returned error: %v", err)
+		}
+	}
+
+	// After trimming the error line is the first row
+	expected := []struct {
+		message string
+		style   widget.TextGridStyle
+	}{
+		{"Error line", logErrorStyle},
+		{"Info line", nil},
+		{"Warn line", logWarnStyle},
+	}
+	if len(logWidget.Rows) != len(expected) {
+		t.Fatalf("Expected %d rows, got %d", len(expected), len(logWidget.Rows))
+	}
+	for i, e := range expected {
+		if !strings.Contains(logWidget.RowText(i), e.message) {
+			t.Errorf("Row %d: expected %q, got %q", i, e.message, logWidget.RowText(i))
+		}
+		if logWidget.Rows[i].Style != e.style {
+			t.Errorf("Row %d (%s): unexpected style %v", i, e.message, logWidget.Rows[i].Style)
+		}
+	}
+}
+
+func benchmarkUILogHandlerHandle(b *testing.B, flushInterval time.Duration) {
+	test.NewTempApp(b)
+	logWidget := widget.NewTextGrid()
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
+	handler := NewUILogHandler(NewTextGridSink(logWidget), opts, WithFlushInterval(flushInterval))
+
+	ctx := context.Background()
+
+	// Fill the buffer so every iteration pays the full rendering cost
+	for i := 0; i < DefaultLogMaxLines; i++ {
+		handler.Handle(ctx, slog.Record{Time: time.Now(), Level: slog.LevelInfo, Message: "Warm up"})
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		record := slog.Record{
+			Time:    time.Now(),
+			Level:   slog.LevelInfo,
+			Message: "Benchmark message",
+		}
+		handler.Handle(ctx, record)
+	}
+	b.StopTimer()
+	handler.Flush()
+}
+
+// BenchmarkUILogHandler_HandleImmediate measures the old behaviour of rendering on every record
+func BenchmarkUILogHandler_HandleImmediate(b *testing.B) {
+	benchmarkUILogHandlerHandle(b, 0)
+}
+
+func BenchmarkUILogHandler_HandleBatched(b *testing.B) {
+	benchmarkUILogHandlerHandle(b, DefaultLogFlushInterval)
+}
@@ -0,0 +1,162 @@
+package appui
+
+import (
+	"log/slog"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Log view names accepted by NewLogSink
+const (
+	LogViewGrid = "grid"
+	LogViewRich = "rich"
+)
+
+// LogLine is a formatted log line together with the level it was logged at
+type LogLine struct {
+	Level slog.Level
+	Text  string
+}
+
+// LogSink displays the log lines rendered by a UILogHandler. Its methods may
+// be called from any goroutine, but never concurrently.
+type LogSink interface {
+	// Append adds a line after the displayed ones
+	Append(line string, level slog.Level)
+	// SetLines replaces the displayed lines, oldest first
+	SetLines(lines []LogLine)
+}
+
+// WidgetSink is a LogSink shown by a widget in the log pane
+type WidgetSink interface {
+	LogSink
+	// CanvasObject returns the widget placed in the log pane
+	CanvasObject() fyne.CanvasObject
+}
+
+// NewLogSink creates the sink for a log_view setting; unknown values use the grid
+func NewLogSink(view string) WidgetSink {
+	if view == LogViewRich {
+		return NewRichTextSink(widget.NewRichText())
+	}
+	return NewTextGridSink(widget.NewTextGrid())
+}
+
+// textGridSink renders into a monospaced TextGrid, colouring rows by level
+type textGridSink struct {
+	grid *widget.TextGrid
+}
+
+// NewTextGridSink creates a sink that renders into grid
+func NewTextGridSink(grid *widget.TextGrid) WidgetSink {
+	return &textGridSink{grid: grid}
+}
+
+func (s *textGridSink) Append(line string, level slog.Level) {
+	fyne.Do(func() {
+		row := len(s.grid.Rows)
+		s.grid.Append(line)
+		if style := logLevelStyle(level); style != nil {
+			for ; row < len(s.grid.Rows); row++ {
+				s.grid.SetRowStyle(row, style)
+			}
+		}
+	})
+}
+
+func (s *textGridSink) SetLines(lines []LogLine) {
+	// 日志可能来自任意 goroutine，控件只能在主线程更新
+	fyne.Do(func() { s.setLines(lines) })
+}
+
+func (s *textGridSink) setLines(lines []LogLine) {
+	if len(lines) == 0 {
+		// SetText("") 会留下一个空行，之后追加的行会排在它后面
+		s.grid.Rows = nil
+		s.grid.Refresh()
+		return
+	}
+	texts := make([]string, 0, len(lines))
+	for _, line := range lines {
+		texts = append(texts, line.Text)
+	}
+
+	// TextGrid performs better with SetText than incremental updates
+	s.grid.SetText(strings.Join(texts, "\n"))
+
+	// Color the rows by severity. A message may span several grid rows.
+	row := 0
+	for _, line := range lines {
+		rows := strings.Count(line.Text, "\n") + 1
+		if style := logLevelStyle(line.Level); style != nil {
+			for i := 0; i < rows; i++ {
+				s.grid.SetRowStyle(row+i, style)
+			}
+		}
+		row += rows
+	}
+
+	s.grid.Refresh()
+}
+
+func (s *textGridSink) CanvasObject() fyne.CanvasObject {
+	return s.grid
+}
+
+// richTextSink renders into a RichText that wraps long lines
+type richTextSink struct {
+	rich *widget.RichText
+}
+
+// NewRichTextSink creates a sink that renders into rich with word wrapping
+func NewRichTextSink(rich *widget.RichText) WidgetSink {
+	rich.Wrapping = fyne.TextWrapWord
+	return &richTextSink{rich: rich}
+}
+
+func (s *richTextSink) Append(line string, level slog.Level) {
+	fyne.Do(func() {
+		s.rich.Segments = append(s.rich.Segments, logSegment(LogLine{Level: level, Text: line}))
+		s.rich.Refresh()
+	})
+}
+
+func (s *richTextSink) SetLines(lines []LogLine) {
+	fyne.Do(func() { s.setLines(lines) })
+}
+
+func (s *richTextSink) setLines(lines []LogLine) {
+	segments := make([]widget.RichTextSegment, 0, len(lines))
+	for _, line := range lines {
+		segments = append(segments, logSegment(line))
+	}
+	s.rich.Segments = segments
+	s.rich.Refresh()
+}
+
+func (s *richTextSink) CanvasObject() fyne.CanvasObject {
+	return s.rich
+}
+
+// logSegment returns the rich text segment of a log line, coloured by level
+func logSegment(line LogLine) widget.RichTextSegment {
+	return &widget.TextSegment{
+		Text:  line.Text,
+		Style: widget.RichTextStyle{ColorName: logLevelColorName(line.Level)},
+	}
+}
+
+// logLevelColorName returns the theme colour for a level in the rich text view
+func logLevelColorName(level slog.Level) fyne.ThemeColorName {
+	switch {
+	case level >= slog.LevelError:
+		return theme.ColorNameError
+	case level >= slog.LevelWarn:
+		return theme.ColorNameWarning
+	default:
+		return theme.ColorNameForeground
+	}
+}
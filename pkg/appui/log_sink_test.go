@@ -0,0 +1,158 @@
+package appui
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// recordingSink records the displayed lines without a widget; batched
+// renders call it from a timer goroutine
+type recordingSink struct {
+	mu      sync.Mutex
+	lines   []LogLine
+	calls   int
+	appends int
+}
+
+func (s *recordingSink) Append(line string, level slog.Level) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lines = append(s.lines, LogLine{Level: level, Text: line})
+	s.calls++
+	s.appends++
+}
+
+func (s *recordingSink) SetLines(lines []LogLine) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lines = append([]LogLine(nil), lines...)
+	s.calls++
+}
+
+// text returns the recorded lines joined like the grid displays them
+func (s *recordingSink) text() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	texts := make([]string, 0, len(s.lines))
+	for _, line := range s.lines {
+		texts = append(texts, line.Text)
+	}
+	return strings.Join(texts, "\n")
+}
+
+func TestUILogHandler_SinkReceivesFormattedLines(t *testing.T) {
+	sink := &recordingSink{}
+	handler := NewUILogHandler(sink, &slog.HandlerOptions{Level: slog.LevelDebug}, WithFlushInterval(0))
+	logger := slog.New(handler)
+
+	logger.Info("Uploaded file", slog.String("path", "a.txt"))
+	logger.Error("Upload failed")
+
+	if sink.calls != 2 {
+		t.Errorf("Expected 2 renders, got %d", sink.calls)
+	}
+	if len(sink.lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %v", sink.lines)
+	}
+	if sink.lines[0].Level != slog.LevelInfo || !strings.Contains(sink.lines[0].Text, "INFO: Uploaded file (path=a.txt)") {
+		t.Errorf("Unexpected first line %+v", sink.lines[0])
+	}
+	if sink.lines[1].Level != slog.LevelError || !strings.Contains(sink.lines[1].Text, "ERROR: Upload failed") {
+		t.Errorf("Unexpected second line %+v", sink.lines[1])
+	}
+
+	// Lines below the display level are not passed to the sink
+	handler.SetLevel(slog.LevelError)
+	if len(sink.lines) != 1 || sink.lines[0].Level != slog.LevelError {
+		t.Errorf("Expected only the error line after SetLevel, got %v", sink.lines)
+	}
+}
+
+func TestRichTextSink_SetLines(t *testing.T) {
+	test.NewTempApp(t)
+	rich := widget.NewRichText()
+	sink := NewRichTextSink(rich)
+	handler := NewUILogHandler(sink, nil, WithFlushInterval(0))
+
+	ctx := context.Background()
+	handler.Handle(ctx, slog.NewRecord(time.Now(), slog.LevelInfo, "Hello", 0))
+	handler.Handle(ctx, slog.NewRecord(time.Now(), slog.LevelWarn, "Careful", 0))
+
+	if rich.Wrapping != fyne.TextWrapWord {
+		t.Error("Rich text log view should wrap long lines")
+	}
+	if len(rich.Segments) != 2 {
+		t.Fatalf("Expected 2 segments, got %d", len(rich.Segments))
+	}
+	warn := rich.Segments[1].(*widget.TextSegment)
+	if !strings.Contains(warn.Text, "WARN: Careful") {
+		t.Errorf("Unexpected segment text %q", warn.Text)
+	}
+	if warn.Style.ColorName != theme.ColorNameWarning {
+		t.Errorf("Expected warning colour, got %q", warn.Style.ColorName)
+	}
+	if sink.CanvasObject() != rich {
+		t.Error("CanvasObject should return the rich text widget")
+	}
+}
+
+func TestNewLogSink(t *testing.T) {
+	if _, ok := NewLogSink(LogViewRich).CanvasObject().(*widget.RichText); !ok {
+		t.Error("log_view rich should use a RichText")
+	}
+	for _, view := range []string{LogViewGrid, "", "unknown"} {
+		if _, ok := NewLogSink(view).CanvasObject().(*widget.TextGrid); !ok {
+			t.Errorf("log_view %q should use a TextGrid", view)
+		}
+	}
+}
+
+func TestUILogHandler_AppendsUntilLinesAreDropped(t *testing.T) {
+	sink := &recordingSink{}
+	handler := NewUILogHandler(sink, nil, WithMaxLines(2), WithFlushInterval(0))
+	logger := slog.New(handler)
+
+	logger.Info("first")
+	logger.Info("second")
+	if sink.appends != 2 || sink.calls != 2 {
+		t.Errorf("Expected new lines to be appended, got %d appends of %d calls", sink.appends, sink.calls)
+	}
+
+	// 缓冲区丢弃最旧的一行后整体重绘
+	logger.Info("third")
+	if sink.appends != 2 || sink.calls != 3 {
+		t.Errorf("Expected a redraw once a line is dropped, got %d appends of %d calls", sink.appends, sink.calls)
+	}
+	if text := sink.text(); strings.Contains(text, "first") || !strings.Contains(text, "third") {
+		t.Errorf("Expected the last two lines, got %q", text)
+	}
+}
+
+func TestTextGridSink_Append(t *testing.T) {
+	test.NewTempApp(t)
+	grid := widget.NewTextGrid()
+	sink := NewTextGridSink(grid)
+
+	sink.SetLines(nil)
+	sink.Append("hello", slog.LevelInfo)
+	sink.Append("bad\nthings", slog.LevelError)
+
+	if got := grid.Text(); got != "hello\nbad\nthings" {
+		t.Errorf("Unexpected grid text %q", got)
+	}
+	if grid.Rows[0].Style != nil {
+		t.Error("Info rows should use the default style")
+	}
+	if grid.Rows[1].Style != logErrorStyle || grid.Rows[2].Style != logErrorStyle {
+		t.Error("Every row of an error line should use the error style")
+	}
+}
@@ -0,0 +1,96 @@
+package appui
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// mirrorConfirmText must be typed before Sync Upload Mirror deletes remote files
+const mirrorConfirmText = "DELETE"
+
+// SyncUploadMirror uploads like Sync Upload and then deletes the remote files
+// that no longer exist locally, once the user has reviewed the list and typed
+// DELETE. Declining still uploads.
+func (ui *AppUI) SyncUploadMirror() {
+	ui.runOperation("Sync Upload Mirror", func(ctx context.Context) error {
+		result, err := ui.fileManager.SyncUploadMirror(ctx, func(toDelete []string) bool {
+			return ui.confirmMirrorDeletes(ctx, toDelete)
+		})
+		if err != nil {
+			return err
+		}
+		if len(result.Failed) > 0 || len(result.Deleted) > 0 {
+			ui.showBatchSummary("Sync Upload Mirror", result.Total(), result, nil)
+		}
+		return batchError(result)
+	})
+}
+
+// confirmMirrorDeletes asks from a background operation whether toDelete may
+// be deleted and waits for the answer; cancelling the operation counts as no
+func (ui *AppUI) confirmMirrorDeletes(ctx context.Context, toDelete []string) bool {
+	answer := make(chan bool, 1)
+	fyne.Do(func() {
+		ui.showMirrorConfirm(toDelete, func(confirmed bool) { answer <- confirmed })
+	})
+	select {
+	case confirmed := <-answer:
+		return confirmed
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// showMirrorConfirm lists the remote files to be deleted and then asks for
+// DELETE to be typed; answer is called exactly once
+func (ui *AppUI) showMirrorConfirm(toDelete []string, answer func(bool)) {
+	list := widget.NewList(
+		func() int { return len(toDelete) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, obj fyne.CanvasObject) { obj.(*widget.Label).SetText(toDelete[id]) },
+	)
+	content := container.NewBorder(
+		widget.NewLabel(fmt.Sprintf("These %d remote file(s) no longer exist locally and will be deleted:", len(toDelete))),
+		nil, nil, nil, list)
+
+	d := dialog.NewCustomConfirm("Sync Upload Mirror", "Continue", "Upload Only", content, func(confirmed bool) {
+		if !confirmed {
+			ui.logger.Info("Mirror deletions declined by user", slog.Int("files", len(toDelete)))
+			answer(false)
+			return
+		}
+		ui.showMirrorTypeConfirm(len(toDelete), answer)
+	}, ui.window)
+	d.Resize(fyne.NewSize(560, 420))
+	d.Show()
+}
+
+// showMirrorTypeConfirm is the second step of showMirrorConfirm; the Delete
+// button stays disabled until DELETE is typed
+func (ui *AppUI) showMirrorTypeConfirm(count int, answer func(bool)) {
+	entry := widget.NewEntry()
+	entry.SetPlaceHolder(mirrorConfirmText)
+	entry.Validator = func(text string) error {
+		if text != mirrorConfirmText {
+			return fmt.Errorf("type %s to confirm", mirrorConfirmText)
+		}
+		return nil
+	}
+	dialog.ShowForm("Confirm Deletion", "Delete", "Upload Only",
+		[]*widget.FormItem{
+			widget.NewFormItem(fmt.Sprintf("Type %s to delete %d remote file(s)", mirrorConfirmText, count), entry),
+		},
+		func(confirmed bool) {
+			confirmed = confirmed && entry.Text == mirrorConfirmText
+			if !confirmed {
+				ui.logger.Info("Mirror deletions declined by user", slog.Int("files", count))
+			}
+			answer(confirmed)
+		}, ui.window)
+}
@@ -0,0 +1,110 @@
+package appui
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/widget"
+)
+
+// findEntry returns the first entry inside obj
+func findEntry(obj fyne.CanvasObject) *widget.Entry {
+	if entry, ok := obj.(*widget.Entry); ok {
+		return entry
+	}
+
+	var children []fyne.CanvasObject
+	switch o := obj.(type) {
+	case *fyne.Container:
+		children = o.Objects
+	case fyne.Widget:
+		children = test.WidgetRenderer(o).Objects()
+	}
+	for _, child := range children {
+		if entry := findEntry(child); entry != nil {
+			return entry
+		}
+	}
+	return nil
+}
+
+// setupMirrorRemote uploads gone.txt and removes it locally
+func setupMirrorRemote(t *testing.T, ui *AppUI) {
+	t.Helper()
+	path := filepath.Join(ui.currentDir, "gone.txt")
+	if err := os.WriteFile(path, []byte("data"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if _, err := ui.fileManager.SyncUpload(context.Background()); err != nil {
+		t.Fatalf("SyncUpload failed: %v", err)
+	}
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("Failed to remove test file: %v", err)
+	}
+}
+
+func remoteHas(t *testing.T, ui *AppUI, key string) bool {
+	t.Helper()
+	files, err := ui.fileManager.ListRemoteFiles("")
+	if err != nil {
+		t.Fatalf("ListRemoteFiles failed: %v", err)
+	}
+	return slices.Contains(files, key)
+}
+
+func TestAppUI_SyncUploadMirrorRequiresTypedConfirmation(t *testing.T) {
+	ui := newTestAppUI(t)
+	ui.setupUI()
+	setupMirrorRemote(t, ui)
+
+	ui.SyncUploadMirror()
+	if !waitFor(t, func() bool { return ui.window.Canvas().Overlays().Top() != nil }) {
+		t.Fatal("Expected the list of files to delete")
+	}
+	if findLabel(ui.window.Canvas().Overlays().Top(), "1 remote file(s)") == nil {
+		t.Error("Expected the dialog to count the files to delete")
+	}
+	tapDialogButton(t, ui, "Continue")
+
+	if !waitFor(t, func() bool { return findEntry(ui.window.Canvas().Overlays().Top()) != nil }) {
+		t.Fatal("Expected the typed confirmation")
+	}
+	top := ui.window.Canvas().Overlays().Top()
+	entry := findEntry(top)
+	test.Type(entry, "delete")
+	if !findButton(top, "Delete").Disabled() {
+		t.Error("Delete should stay disabled until DELETE is typed exactly")
+	}
+	entry.SetText("")
+	test.Type(entry, mirrorConfirmText)
+	tapDialogButton(t, ui, "Delete")
+
+	if !waitFor(t, func() bool { return !remoteHas(t, ui, "gone.txt") }) {
+		t.Error("Expected gone.txt to be deleted after confirming")
+	}
+}
+
+func TestAppUI_SyncUploadMirrorUploadOnly(t *testing.T) {
+	ui := newTestAppUI(t)
+	ui.setupUI()
+	setupMirrorRemote(t, ui)
+	if err := os.WriteFile(filepath.Join(ui.currentDir, "new.txt"), []byte("new"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	ui.SyncUploadMirror()
+	tapDialogButton(t, ui, "Upload Only")
+
+	if !waitFor(t, func() bool { return remoteHas(t, ui, "new.txt") }) {
+		t.Error("Expected new.txt to be uploaded")
+	}
+	waitFor(t, func() bool { return !ui.progressBar.Visible() })
+	if !remoteHas(t, ui, "gone.txt") {
+		t.Error("Declining should keep gone.txt")
+	}
+}
@@ -0,0 +1,243 @@
+package appui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// runningOperation is an operation started by runOperation that has not finished yet
+type runningOperation struct {
+	id       uint64
+	name     string
+	cancel   context.CancelFunc
+	done     chan struct{} // 操作结束或被取消后由队列协程关闭
+	queued   bool          // 仍在等待前面的操作结束
+	progress string        // 最近一次的进度消息
+}
+
+// queuedOperation is an operation waiting in the operation queue
+type queuedOperation struct {
+	op  *runningOperation
+	ctx context.Context
+	run func(context.Context)
+}
+
+// operationQueueSize is how many operations can wait for the running one
+const operationQueueSize = 64
+
+// errOperationQueueFull is shown when an operation is requested while the queue is full
+var errOperationQueueFull = errors.New("too many operations are waiting, try again later")
+
+// operationKey is the context key under which runOperation stores the operation ID
+type operationKey struct{}
+
+// enqueueOperation registers a queued operation named name and hands run to
+// the operation worker, which calls it after the operations queued before it
+func (ui *AppUI) enqueueOperation(name string, run func(context.Context)) {
+	ui.operationWorker.Do(func() {
+		ui.operationQueue = make(chan queuedOperation, operationQueueSize)
+		go ui.runOperationQueue(ui.operationQueue)
+	})
+
+	op, ctx := ui.startOperation(name)
+	select {
+	case ui.operationQueue <- queuedOperation{op: op, ctx: ctx, run: run}:
+		if len(ui.runningOperations()) > 1 {
+			ui.logger.Info("Operation queued", slog.String("operation", name))
+		}
+	default:
+		ui.logger.Error("Operation queue is full", slog.String("operation", name))
+		ui.finishOperation(op)
+		close(op.done)
+		fyne.Do(func() {
+			dialog.ShowError(fmt.Errorf("%s: %w", name, errOperationQueueFull), ui.window)
+		})
+	}
+}
+
+// runOperationQueue runs the queued operations one at a time, skipping the
+// ones cancelled while they waited
+func (ui *AppUI) runOperationQueue(queue <-chan queuedOperation) {
+	for job := range queue {
+		ui.runQueuedOperation(job)
+	}
+}
+
+// runQueuedOperation runs one operation from the queue; the operation timeout
+// counts from here rather than from when it was queued
+func (ui *AppUI) runQueuedOperation(job queuedOperation) {
+	defer close(job.op.done)
+	defer ui.finishOperation(job.op)
+
+	if !ui.markOperationRunning(job.op) {
+		ui.logger.Info("Queued operation cancelled", slog.String("operation", job.op.name))
+		return
+	}
+	ctx := job.ctx
+	if timeout := ui.fileManager.OperationTimeout(); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	job.run(ctx)
+}
+
+// startOperation registers a new queued operation and returns it with its context
+func (ui *AppUI) startOperation(name string) (*runningOperation, context.Context) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	ui.operationMutex.Lock()
+	if ui.operations == nil {
+		ui.operations = make(map[uint64]*runningOperation)
+	}
+	ui.nextOperationID++
+	op := &runningOperation{id: ui.nextOperationID, name: name, cancel: cancel, done: make(chan struct{}), queued: true}
+	ui.operations[op.id] = op
+	ui.operationMutex.Unlock()
+
+	ui.operationsChanged()
+	return op, context.WithValue(ctx, operationKey{}, op.id)
+}
+
+// markOperationRunning moves op out of the queued state; it reports false
+// when op was cancelled while it waited
+func (ui *AppUI) markOperationRunning(op *runningOperation) bool {
+	ui.operationMutex.Lock()
+	_, ok := ui.operations[op.id]
+	if ok {
+		op.queued = false
+	}
+	ui.operationMutex.Unlock()
+	if ok {
+		ui.operationsChanged()
+	}
+	return ok
+}
+
+// finishOperation removes op from the running operations
+func (ui *AppUI) finishOperation(op *runningOperation) {
+	ui.operationMutex.Lock()
+	delete(ui.operations, op.id)
+	ui.operationMutex.Unlock()
+	op.cancel()
+	ui.operationsChanged()
+}
+
+// runningOperations returns a copy of the running operations in the order they were started
+func (ui *AppUI) runningOperations() []runningOperation {
+	ui.operationMutex.Lock()
+	defer ui.operationMutex.Unlock()
+
+	ops := make([]runningOperation, 0, len(ui.operations))
+	for _, op := range ui.operations {
+		ops = append(ops, *op)
+	}
+	sort.Slice(ops, func(i, j int) bool { return ops[i].id < ops[j].id })
+	return ops
+}
+
+// operationRunning reports whether any operation is running
+func (ui *AppUI) operationRunning() bool {
+	ui.operationMutex.Lock()
+	defer ui.operationMutex.Unlock()
+	return len(ui.operations) > 0
+}
+
+// cancelOperationByID cancels one running or queued operation and reports
+// whether it was still listed. A queued operation is removed from the list at
+// once and skipped when its turn comes.
+func (ui *AppUI) cancelOperationByID(id uint64) bool {
+	ui.operationMutex.Lock()
+	op, ok := ui.operations[id]
+	queued := ok && op.queued
+	if queued {
+		delete(ui.operations, id)
+	}
+	ui.operationMutex.Unlock()
+	if !ok {
+		return false
+	}
+	op.cancel()
+	ui.logger.Info("Operation cancelled by user", slog.String("operation", op.name))
+	if queued {
+		ui.operationsChanged()
+	}
+	return true
+}
+
+// cancelOperation cancels the running operation and clears the queue
+func (ui *AppUI) cancelOperation() {
+	for _, op := range ui.runningOperations() {
+		ui.cancelOperationByID(op.id)
+	}
+}
+
+// setProgress shows a progress message for the operation running with ctx
+func (ui *AppUI) setProgress(ctx context.Context, text string) {
+	id, _ := ctx.Value(operationKey{}).(uint64)
+	ui.operationMutex.Lock()
+	op, ok := ui.operations[id]
+	if ok {
+		op.progress = text
+	}
+	ui.operationMutex.Unlock()
+	if ok {
+		ui.operationsChanged()
+	}
+}
+
+// operationsChanged shows the progress indicator while operations are running
+// and redraws the running operations list on the main goroutine
+func (ui *AppUI) operationsChanged() {
+	if ui.progressBar == nil {
+		return
+	}
+	ops := ui.runningOperations()
+	fyne.Do(func() {
+		if len(ops) > 0 {
+			ui.progressBar.Show()
+			ui.progressBar.Start()
+		} else {
+			ui.progressBar.Stop()
+			ui.progressBar.Hide()
+		}
+		ui.refreshOperationList(ops)
+	})
+}
+
+// refreshOperationList rebuilds the operations list, one row with a Cancel
+// button per running or queued operation; it must run on the main goroutine
+func (ui *AppUI) refreshOperationList(ops []runningOperation) {
+	if ui.operationList == nil {
+		return
+	}
+	rows := make([]fyne.CanvasObject, 0, len(ops))
+	for _, op := range ops {
+		text := op.name
+		if op.queued {
+			text += " (queued)"
+		} else if op.progress != "" {
+			text += ": " + op.progress
+		}
+		label := widget.NewLabel(text)
+		label.Truncation = fyne.TextTruncateEllipsis
+		id := op.id
+		cancel := widget.NewButton("Cancel", func() { ui.cancelOperationByID(id) })
+		rows = append(rows, container.NewBorder(nil, nil, nil, cancel, label))
+	}
+	ui.operationList.Objects = rows
+	if len(rows) == 0 {
+		ui.operationList.Hide()
+	} else {
+		ui.operationList.Show()
+	}
+	ui.operationList.Refresh()
+}
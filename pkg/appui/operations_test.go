@@ -0,0 +1,168 @@
+package appui
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// blockingOperation starts an operation named name that runs until its
+// context is done and returns that context
+func blockingOperation(t *testing.T, ui *AppUI, name string) context.Context {
+	t.Helper()
+	started := make(chan context.Context)
+	ui.runOperation(name, func(ctx context.Context) error {
+		started <- ctx
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	return <-started
+}
+
+// recordingOperation queues an operation named name that appends name to
+// *ran when it runs
+func recordingOperation(ui *AppUI, name string, mu *sync.Mutex, ran *[]string) {
+	ui.runOperation(name, func(ctx context.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+		*ran = append(*ran, name)
+		return nil
+	})
+}
+
+func TestAppUI_QueuedOperationsRunInOrder(t *testing.T) {
+	ui := newTestAppUI(t)
+	var mu sync.Mutex
+	var ran []string
+
+	release := make(chan struct{})
+	started := make(chan context.Context)
+	ui.runOperation("Sync Upload", func(ctx context.Context) error {
+		started <- ctx
+		<-release
+		mu.Lock()
+		defer mu.Unlock()
+		ran = append(ran, "Sync Upload")
+		return nil
+	})
+	upload := <-started
+	recordingOperation(ui, "Sync Download", &mu, &ran)
+	recordingOperation(ui, "Verify Backup", &mu, &ran)
+
+	ops := ui.runningOperations()
+	if len(ops) != 3 || ops[0].queued || !ops[1].queued || !ops[2].queued {
+		t.Fatalf("Expected one running and two queued operations, got %+v", ops)
+	}
+	if upload.Err() != nil {
+		t.Fatalf("Queuing operations should not cancel the running one, got %v", upload.Err())
+	}
+
+	close(release)
+	if !waitFor(t, func() bool { return !ui.operationRunning() }) {
+		t.Fatalf("Expected every operation to finish, still running %+v", ui.runningOperations())
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if want := []string{"Sync Upload", "Sync Download", "Verify Backup"}; !slices.Equal(ran, want) {
+		t.Errorf("Expected operations to run in order %v, got %v", want, ran)
+	}
+}
+
+func TestAppUI_CancelQueuedOperation(t *testing.T) {
+	ui := newTestAppUI(t)
+	var mu sync.Mutex
+	var ran []string
+	upload := blockingOperation(t, ui, "Encrypt & Upload")
+	recordingOperation(ui, "Download Multiple Files", &mu, &ran)
+	recordingOperation(ui, "Verify Backup", &mu, &ran)
+
+	ops := ui.runningOperations()
+	if !ui.cancelOperationByID(ops[1].id) {
+		t.Fatal("cancelOperationByID should find the queued download")
+	}
+	if remaining := ui.runningOperations(); len(remaining) != 2 || remaining[1].name != "Verify Backup" {
+		t.Errorf("Expected the cancelled download to leave the list at once, got %+v", remaining)
+	}
+	if upload.Err() != nil {
+		t.Errorf("Cancelling a queued operation should leave the running one alone, got %v", upload.Err())
+	}
+
+	ui.cancelOperationByID(ops[0].id)
+	if !waitFor(t, func() bool { return !ui.operationRunning() }) {
+		t.Fatalf("Expected the queue to drain, got %+v", ui.runningOperations())
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !slices.Equal(ran, []string{"Verify Backup"}) {
+		t.Errorf("Only the operation that was not cancelled should run, got %v", ran)
+	}
+	if ui.cancelOperationByID(ops[1].id) {
+		t.Error("A cancelled operation should no longer be cancellable")
+	}
+}
+
+func TestAppUI_CancelAllOperations(t *testing.T) {
+	ui := newTestAppUI(t)
+	var mu sync.Mutex
+	var ran []string
+	upload := blockingOperation(t, ui, "Sync Upload")
+	recordingOperation(ui, "Sync Download", &mu, &ran)
+
+	ui.cancelOperation()
+	if upload.Err() == nil {
+		t.Error("The running operation should be cancelled")
+	}
+	if !waitFor(t, func() bool { return !ui.operationRunning() && !ui.progressBar.Visible() }) {
+		t.Error("Progress indicator should be hidden once every operation finished")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(ran) != 0 {
+		t.Errorf("Cancel All should clear the queue, but %v ran", ran)
+	}
+}
+
+func TestAppUI_OperationListCancelButton(t *testing.T) {
+	ui := newTestAppUI(t)
+	ui.setupUI()
+	started := make(chan struct{})
+	ui.runOperation("Encrypt & Upload", func(ctx context.Context) error {
+		ui.setProgress(ctx, "3 of 10")
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	<-started
+	ui.runOperation("Verify Backup", func(ctx context.Context) error {
+		t.Error("The cancelled operation should never run")
+		return nil
+	})
+	t.Cleanup(ui.cancelOperation)
+
+	if !waitFor(t, func() bool { return len(ui.operationList.Objects) == 2 }) {
+		t.Fatalf("Expected a row per operation, got %d rows", len(ui.operationList.Objects))
+	}
+	if !ui.operationList.Visible() {
+		t.Error("The operation list should be visible")
+	}
+	if label := findLabel(ui.operationList.Objects[0], "Encrypt & Upload: 3 of 10"); label == nil {
+		t.Error("Expected the upload row to show its progress")
+	}
+	if label := findLabel(ui.operationList.Objects[1], "Verify Backup (queued)"); label == nil {
+		t.Error("Expected the verify row to show it is queued")
+	}
+
+	cancel := findButton(ui.operationList.Objects[1], "Cancel")
+	if cancel == nil {
+		t.Fatal("Expected a Cancel button on the verify row")
+	}
+	cancel.OnTapped()
+	if !waitFor(t, func() bool { return len(ui.operationList.Objects) == 1 }) {
+		t.Fatalf("Expected the verify row to disappear, got %d rows", len(ui.operationList.Objects))
+	}
+	if label := findLabel(ui.operationList.Objects[0], "Encrypt & Upload"); label == nil || strings.Contains(label.Text, "Verify") {
+		t.Error("Expected the remaining row to be the upload")
+	}
+}
@@ -0,0 +1,77 @@
+package appui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// CanChangeKey reports whether Change Crypto Key can re-key the current
+// source; it cannot with obfuscate_keys, a keystore or a shared crypto_key
+func (ui *AppUI) CanChangeKey() bool {
+	return ui.fileManager.CanRekey() == nil
+}
+
+// ChangeKey asks for a new crypto key and re-encrypts the whole remote with it
+func (ui *AppUI) ChangeKey() {
+	if err := ui.fileManager.CanRekey(); err != nil {
+		dialog.ShowError(err, ui.window)
+		return
+	}
+	newKey := widget.NewPasswordEntry()
+	confirmKey := widget.NewPasswordEntry()
+	dialog.ShowForm("Change Crypto Key", "Re-encrypt", "Cancel",
+		[]*widget.FormItem{
+			widget.NewFormItem("New key", newKey),
+			widget.NewFormItem("Confirm", confirmKey),
+		},
+		func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			if err := validateNewKey(newKey.Text, confirmKey.Text); err != nil {
+				dialog.ShowError(err, ui.window)
+				return
+			}
+			ui.runRekey(newKey.Text)
+		}, ui.window)
+}
+
+// validateNewKey checks the new key entered twice in the Change Crypto Key dialog
+func validateNewKey(newKey, confirmKey string) error {
+	if newKey == "" {
+		return errors.New("the new key is empty")
+	}
+	if newKey != confirmKey {
+		return errors.New("the new keys do not match")
+	}
+	return nil
+}
+
+// runRekey re-encrypts every remote object with newKey
+func (ui *AppUI) runRekey(newKey string) {
+	ui.runOperation("Change Key", func(ctx context.Context) error {
+		result, err := ui.fileManager.Rekey(ctx, newKey, func(done, total int) {
+			ui.setProgress(ctx, fmt.Sprintf("Re-encrypting %d/%d", done, total))
+		})
+		if err != nil {
+			return err
+		}
+		if len(result.Failed) > 0 {
+			// 失败后用同一个新密钥再次执行即可继续
+			ui.showBatchSummary("Change Key", result.Total(), result, nil)
+			return batchError(result)
+		}
+		setting := ui.fileManager.KeySetting()
+		fyne.Do(func() {
+			dialog.ShowInformation("Change Key", fmt.Sprintf(
+				"%d file(s) re-encrypted with the new key.\nUpdate %s in your config before restarting fers.",
+				len(result.Succeeded), setting), ui.window)
+		})
+		return nil
+	})
+}
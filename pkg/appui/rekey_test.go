@@ -0,0 +1,75 @@
+package appui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mingregister/fers/pkg/crypto"
+	"github.com/mingregister/fers/pkg/storage"
+)
+
+func TestValidateNewKey(t *testing.T) {
+	if err := validateNewKey("", ""); err == nil {
+		t.Error("Expected an error for an empty key")
+	}
+	if err := validateNewKey("new", "other"); err == nil {
+		t.Error("Expected an error when the confirmation differs")
+	}
+	if err := validateNewKey("new", "new"); err != nil {
+		t.Errorf("Expected matching keys to be accepted, got %v", err)
+	}
+}
+
+func TestAppUI_RunRekey(t *testing.T) {
+	store := storage.NewMemoryClient()
+	ui := newTestAppUIWithStorage(t, store)
+	if err := os.WriteFile(filepath.Join(ui.currentDir, "a.txt"), []byte("a"), 0644); err != nil {
+		t.Fatalf("Failed to create a.txt: %v", err)
+	}
+	if err := ui.fileManager.EncryptAndUploadFile(filepath.Join(ui.currentDir, "a.txt"), "a.txt"); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	ui.setupUI()
+
+	ui.runRekey("new-password")
+	if !waitFor(t, func() bool { return ui.window.Canvas().Overlays().Top() != nil }) {
+		t.Fatal("Expected a dialog after changing the key")
+	}
+	if findLabel(ui.window.Canvas().Overlays().Top(), "Update crypto_key") == nil {
+		t.Error("Dialog should remind to update crypto_key")
+	}
+
+	encrypted, err := store.Download("a.txt")
+	if err != nil {
+		t.Fatalf("Download failed: %v", err)
+	}
+	if _, err := crypto.NewAESGCM("new-password").Decrypt(encrypted); err != nil {
+		t.Errorf("a.txt should decrypt with the new key: %v", err)
+	}
+}
+
+func TestAppUI_ChangeKeyKeystore(t *testing.T) {
+	ui := newTestAppUI(t)
+	ui.fileManager.SetKeystoreKey(true)
+	ui.setupUI()
+
+	if ui.CanChangeKey() {
+		t.Error("The key of a keystore should not be changeable")
+	}
+	for _, m := range ui.window.MainMenu().Items {
+		for _, item := range m.Items {
+			if item.Label == "Change Crypto Key..." && !item.Disabled {
+				t.Error("Change Crypto Key should be disabled with a keystore")
+			}
+		}
+	}
+
+	ui.ChangeKey()
+	if !waitFor(t, func() bool { return ui.window.Canvas().Overlays().Top() != nil }) {
+		t.Fatal("Expected an error dialog")
+	}
+	if findLabel(ui.window.Canvas().Overlays().Top(), "keystore") == nil {
+		t.Error("Dialog should explain that the keystore key cannot be changed")
+	}
+}
@@ -0,0 +1,261 @@
+package appui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/mingregister/fers/pkg/dir"
+)
+
+// parentPrefix returns the prefix one folder above prefix ("a/b/" => "a/", "a/" => "")
+func parentPrefix(prefix string) string {
+	trimmed := strings.TrimSuffix(prefix, "/")
+	idx := strings.LastIndex(trimmed, "/")
+	if idx < 0 {
+		return ""
+	}
+	return trimmed[:idx+1]
+}
+
+// remoteBrowser shows the remote storage as folders and files
+type remoteBrowser struct {
+	ui        *AppUI
+	window    fyne.Window
+	prefix    string   // 当前浏览的前缀，为空或以 "/" 结尾
+	folders   []string // 当前前缀下的子目录（完整前缀）
+	files     []string // 当前前缀下的文件（完整 key）
+	selected  int      // 选中项的下标，-1 表示未选中
+	query     string   // 非空时列表显示搜索结果而不是当前目录
+	allKeys   []string // 搜索使用的全部远程文件，首次搜索时加载
+	list      *RightClickableList
+	pathLabel *widget.Label
+	searchBox *widget.Entry
+}
+
+// newRemoteBrowser creates a browser positioned at the remote root
+func newRemoteBrowser(ui *AppUI) *remoteBrowser {
+	return &remoteBrowser{ui: ui, selected: -1}
+}
+
+// items returns the list labels: folders first, then files, relative to the
+// current prefix; search results are shown with their full key
+func (b *remoteBrowser) items() []string {
+	if b.query != "" {
+		return append([]string(nil), b.files...)
+	}
+	items := make([]string, 0, len(b.folders)+len(b.files))
+	for _, folder := range b.folders {
+		items = append(items, strings.TrimPrefix(folder, b.prefix))
+	}
+	for _, file := range b.files {
+		items = append(items, strings.TrimPrefix(file, b.prefix))
+	}
+	return items
+}
+
+// navigate lists prefix and makes it the current folder
+func (b *remoteBrowser) navigate(prefix string) error {
+	files, folders, err := b.ui.fileManager.ListRemoteDir(prefix)
+	if err != nil {
+		return fmt.Errorf("failed to list remote folder: %w", err)
+	}
+	b.prefix, b.files, b.folders = prefix, files, folders
+	b.query = ""
+	if b.searchBox != nil {
+		b.searchBox.SetText("")
+	}
+	b.showItems("Remote: /" + b.prefix)
+	return nil
+}
+
+// showItems resets the selection and shows the current items under label
+func (b *remoteBrowser) showItems(label string) {
+	b.selected = -1
+	if b.list != nil {
+		b.list.SetItems(b.items())
+		b.list.UnselectAll()
+	}
+	if b.pathLabel != nil {
+		b.pathLabel.SetText(label)
+	}
+}
+
+// search shows the remote files matching query, see dir.MatchKeys; an empty
+// query shows the current folder again. The remote keys are loaded once and
+// searched in memory, so typing does not list the remote again.
+func (b *remoteBrowser) search(query string) error {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		if b.query == "" {
+			return nil
+		}
+		return b.navigate(b.prefix)
+	}
+
+	if b.allKeys == nil {
+		keys, err := b.ui.fileManager.RemoteFileKeys(context.Background())
+		if err != nil {
+			return fmt.Errorf("failed to list remote files: %w", err)
+		}
+		b.allKeys = append([]string{}, keys...)
+	}
+	matches, err := dir.MatchKeys(b.allKeys, query)
+	if err != nil {
+		return err
+	}
+
+	b.query, b.files, b.folders = query, matches, nil
+	b.showItems(fmt.Sprintf("Search: %s (%d results)", query, len(matches)))
+	return nil
+}
+
+// up navigates to the parent folder, or back to the current folder from search results
+func (b *remoteBrowser) up() error {
+	if b.query != "" {
+		return b.navigate(b.prefix)
+	}
+	if b.prefix == "" {
+		return nil
+	}
+	return b.navigate(parentPrefix(b.prefix))
+}
+
+// itemTapped enters a tapped folder or selects a tapped file
+func (b *remoteBrowser) itemTapped(i int) error {
+	if i < 0 || i >= len(b.folders)+len(b.files) {
+		return nil
+	}
+	if i < len(b.folders) {
+		return b.navigate(b.folders[i])
+	}
+	b.selected = i
+	if b.list != nil {
+		b.list.Refresh()
+	}
+	return nil
+}
+
+// itemAt returns the full key of item i and whether it is a folder
+func (b *remoteBrowser) itemAt(i int) (key string, isFolder, ok bool) {
+	switch {
+	case i < 0 || i >= len(b.folders)+len(b.files):
+		return "", false, false
+	case i < len(b.folders):
+		return b.folders[i], true, true
+	default:
+		return b.files[i-len(b.folders)], false, true
+	}
+}
+
+// itemMenu returns the context menu of item i: folders can be opened, files
+// downloaded or deleted
+func (b *remoteBrowser) itemMenu(i int) *fyne.Menu {
+	key, isFolder, ok := b.itemAt(i)
+	if !ok {
+		return nil
+	}
+	if isFolder {
+		return fyne.NewMenu("", fyne.NewMenuItem("open", func() { b.showError(b.navigate(key)) }))
+	}
+	return fyne.NewMenu("",
+		fyne.NewMenuItem("download", func() { b.ui.downloadRemoteFiles([]string{key}, true) }),
+		fyne.NewMenuItem("delete", func() { b.confirmDelete(key) }),
+	)
+}
+
+// confirmDelete asks before deleting the remote file key
+func (b *remoteBrowser) confirmDelete(key string) {
+	dialog.ShowConfirm("Delete Remote File", fmt.Sprintf("Delete %s from remote storage?", key),
+		func(confirmed bool) {
+			if confirmed {
+				b.showError(b.deleteFile(key))
+			}
+		}, b.window)
+}
+
+// deleteFile deletes the remote file key and reloads the current folder or search
+func (b *remoteBrowser) deleteFile(key string) error {
+	if err := b.ui.fileManager.DeleteRemoteFile(key); err != nil {
+		return err
+	}
+	b.allKeys = nil
+	if b.query != "" {
+		return b.search(b.query)
+	}
+	return b.navigate(b.prefix)
+}
+
+// showError shows err in the browser window, if any
+func (b *remoteBrowser) showError(err error) {
+	if err != nil && b.window != nil {
+		showError(err, b.window)
+	}
+}
+
+// selectedFile returns the key of the selected file, if any
+func (b *remoteBrowser) selectedFile() (string, bool) {
+	i := b.selected - len(b.folders)
+	if b.selected < 0 || i < 0 || i >= len(b.files) {
+		return "", false
+	}
+	return b.files[i], true
+}
+
+// show opens the browser in its own window
+func (b *remoteBrowser) show() {
+	b.window = b.ui.app.NewWindow("Browse Remote")
+	b.window.Resize(fyne.NewSize(RemoteWindowWidth, RemoteWindowHeight))
+	b.window.CenterOnScreen()
+
+	b.pathLabel = widget.NewLabel("Remote: /" + b.prefix)
+	b.list = NewRightClickableList()
+	b.list.OnItemTapped = func(i int) { b.showError(b.itemTapped(i)) }
+	b.list.OnItemRightClick = func(i int, pos fyne.Position) {
+		if menu := b.itemMenu(i); menu != nil {
+			widget.ShowPopUpMenuAtPosition(menu, b.window.Canvas(), pos)
+		}
+	}
+	b.list.IsItemSelected = func(i int) bool { return i == b.selected }
+	b.list.SetItems(b.items())
+	b.list.Build()
+
+	b.searchBox = widget.NewEntry()
+	b.searchBox.SetPlaceHolder("Search all remote files (e.g. invoice or *.pdf)...")
+	b.searchBox.OnChanged = func(query string) { b.showError(b.search(query)) }
+
+	upBtn := widget.NewButton("Up", func() { b.showError(b.up()) })
+	downloadBtn := widget.NewButton("Download", func() {
+		file, ok := b.selectedFile()
+		if !ok {
+			dialog.ShowInformation("Info", "Please select a file first", b.window)
+			return
+		}
+		b.ui.downloadRemoteFiles([]string{file}, true)
+	})
+	closeBtn := widget.NewButton("Close", b.window.Close)
+
+	content := container.NewBorder(
+		container.NewVBox(container.NewHBox(upBtn, b.pathLabel), b.searchBox),
+		container.NewHBox(downloadBtn, closeBtn),
+		nil,
+		nil,
+		b.list,
+	)
+	b.window.SetContent(content)
+	b.window.Show()
+}
+
+// showRemoteBrowser opens the remote browser at the remote root
+func (ui *AppUI) showRemoteBrowser() {
+	browser := newRemoteBrowser(ui)
+	if err := browser.navigate(""); err != nil {
+		showError(err, ui.window)
+		return
+	}
+	browser.show()
+}
@@ -0,0 +1,208 @@
+package appui
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestParentPrefix(t *testing.T) {
+	tests := []struct {
+		prefix string
+		want   string
+	}{
+		{"", ""},
+		{"docs/", ""},
+		{"docs/2021/", "docs/"},
+		{"docs/2021/q1/", "docs/2021/"},
+	}
+
+	for _, tt := range tests {
+		if got := parentPrefix(tt.prefix); got != tt.want {
+			t.Errorf("parentPrefix(%q) = %q, want %q", tt.prefix, got, tt.want)
+		}
+	}
+}
+
+func TestRemoteBrowser_Navigate(t *testing.T) {
+	ui := newTestAppUI(t)
+	localPath := filepath.Join(ui.currentDir, "file.txt")
+	if err := os.WriteFile(localPath, []byte("data"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	for _, key := range []string{"root.txt", "docs/a.txt", "docs/2021/report.pdf"} {
+		if err := ui.fileManager.EncryptAndUploadFile(localPath, key); err != nil {
+			t.Fatalf("Failed to upload %s: %v", key, err)
+		}
+	}
+
+	b := newRemoteBrowser(ui)
+	if err := b.navigate(""); err != nil {
+		t.Fatalf("navigate failed: %v", err)
+	}
+	if !slices.Equal(b.items(), []string{"docs/", "root.txt"}) {
+		t.Errorf("Unexpected root items %v", b.items())
+	}
+
+	// Tapping a folder enters it
+	if err := b.itemTapped(0); err != nil {
+		t.Fatalf("itemTapped failed: %v", err)
+	}
+	if b.prefix != "docs/" || !slices.Equal(b.items(), []string{"2021/", "a.txt"}) {
+		t.Errorf("Unexpected items in docs/: prefix %q, items %v", b.prefix, b.items())
+	}
+
+	// Tapping a file selects it
+	if err := b.itemTapped(1); err != nil {
+		t.Fatalf("itemTapped failed: %v", err)
+	}
+	if file, ok := b.selectedFile(); !ok || file != "docs/a.txt" {
+		t.Errorf("Expected docs/a.txt to be selected, got %q", file)
+	}
+
+	if err := b.up(); err != nil {
+		t.Fatalf("up failed: %v", err)
+	}
+	if b.prefix != "" {
+		t.Errorf("Expected to be back at the root, got %q", b.prefix)
+	}
+	if _, ok := b.selectedFile(); ok {
+		t.Error("Navigating should clear the selection")
+	}
+}
+
+func TestRemoteBrowser_NestedNavigation(t *testing.T) {
+	ui := newTestAppUI(t)
+	localPath := filepath.Join(ui.currentDir, "file.txt")
+	if err := os.WriteFile(localPath, []byte("data"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := ui.fileManager.EncryptAndUploadFile(localPath, "a/b/c.txt"); err != nil {
+		t.Fatalf("Failed to upload: %v", err)
+	}
+
+	b := newRemoteBrowser(ui)
+	if err := b.navigate(""); err != nil {
+		t.Fatalf("navigate failed: %v", err)
+	}
+	for _, want := range []string{"a/", "a/b/"} {
+		if err := b.itemTapped(0); err != nil {
+			t.Fatalf("itemTapped failed: %v", err)
+		}
+		if b.prefix != want {
+			t.Fatalf("Expected prefix %q, got %q", want, b.prefix)
+		}
+	}
+	if !slices.Equal(b.items(), []string{"c.txt"}) {
+		t.Errorf("Unexpected items in a/b/: %v", b.items())
+	}
+
+	for _, want := range []string{"a/", ""} {
+		if err := b.up(); err != nil {
+			t.Fatalf("up failed: %v", err)
+		}
+		if b.prefix != want {
+			t.Fatalf("Expected prefix %q after up, got %q", want, b.prefix)
+		}
+	}
+	// Up at the root stays there
+	if err := b.up(); err != nil || b.prefix != "" {
+		t.Errorf("Up at the root should be a no-op, got prefix %q (%v)", b.prefix, err)
+	}
+}
+
+func TestRemoteBrowser_ItemMenuAndDelete(t *testing.T) {
+	ui := newTestAppUI(t)
+	localPath := filepath.Join(ui.currentDir, "file.txt")
+	if err := os.WriteFile(localPath, []byte("data"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	for _, key := range []string{"docs/a.txt", "docs/b.txt", "docs/sub/c.txt"} {
+		if err := ui.fileManager.EncryptAndUploadFile(localPath, key); err != nil {
+			t.Fatalf("Failed to upload %s: %v", key, err)
+		}
+	}
+
+	b := newRemoteBrowser(ui)
+	if err := b.navigate("docs/"); err != nil {
+		t.Fatalf("navigate failed: %v", err)
+	}
+
+	menuLabels := func(i int) []string {
+		var labels []string
+		for _, item := range b.itemMenu(i).Items {
+			labels = append(labels, item.Label)
+		}
+		return labels
+	}
+	if labels := menuLabels(0); !slices.Equal(labels, []string{"open"}) {
+		t.Errorf("Folder menu should only open, got %v", labels)
+	}
+	if labels := menuLabels(1); !slices.Equal(labels, []string{"download", "delete"}) {
+		t.Errorf("File menu should offer download and delete, got %v", labels)
+	}
+	if b.itemMenu(10) != nil {
+		t.Error("Out of range items should have no menu")
+	}
+
+	if err := b.deleteFile("docs/a.txt"); err != nil {
+		t.Fatalf("deleteFile failed: %v", err)
+	}
+	if b.prefix != "docs/" || !slices.Equal(b.items(), []string{"sub/", "b.txt"}) {
+		t.Errorf("Expected docs/ to be reloaded without a.txt, got prefix %q items %v", b.prefix, b.items())
+	}
+}
+
+func TestRemoteBrowser_SearchAndDownload(t *testing.T) {
+	ui := newTestAppUI(t)
+	localPath := filepath.Join(ui.currentDir, "file.txt")
+	if err := os.WriteFile(localPath, []byte("data"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	for _, key := range []string{"notes.txt", "finance/2021/invoice-2021.pdf", "finance/invoice-2022.pdf"} {
+		if err := ui.fileManager.EncryptAndUploadFile(localPath, key); err != nil {
+			t.Fatalf("Failed to upload %s: %v", key, err)
+		}
+	}
+
+	b := newRemoteBrowser(ui)
+	if err := b.navigate("finance/"); err != nil {
+		t.Fatalf("navigate failed: %v", err)
+	}
+	if err := b.search("Invoice-2021"); err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if !slices.Equal(b.items(), []string{"finance/2021/invoice-2021.pdf"}) {
+		t.Fatalf("Expected the search result with its full key, got %v", b.items())
+	}
+
+	// 搜索结果直接可以下载
+	if err := b.itemTapped(0); err != nil {
+		t.Fatalf("itemTapped failed: %v", err)
+	}
+	file, ok := b.selectedFile()
+	if !ok || file != "finance/2021/invoice-2021.pdf" {
+		t.Fatalf("Expected the result to be selected, got %q", file)
+	}
+	ui.downloadRemoteFiles([]string{file}, true)
+	downloaded := filepath.Join(ui.currentDir, "finance", "2021", "invoice-2021.pdf")
+	if !waitFor(t, func() bool { _, err := os.Stat(downloaded); return err == nil }) {
+		t.Error("Expected the search result to be downloaded")
+	}
+
+	if err := b.search("*.pdf"); err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if len(b.items()) != 2 {
+		t.Errorf("Expected 2 glob results, got %v", b.items())
+	}
+
+	// 清空搜索回到原来的目录
+	if err := b.search(""); err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if b.prefix != "finance/" || !slices.Equal(b.items(), []string{"2021/", "invoice-2022.pdf"}) {
+		t.Errorf("Expected the finance folder again, got %q %v", b.prefix, b.items())
+	}
+}
@@ -0,0 +1,346 @@
+package appui
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	// remoteStatWorkers 同时查询远程文件大小的协程数
+	remoteStatWorkers = 8
+	// PresignExpiry 分享链接的有效期
+	PresignExpiry = time.Hour
+)
+
+// formatSize formats a byte count as a human-readable size
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit && exp < 4; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTP"[exp])
+}
+
+// remoteFileLabel returns the check label of a remote file, with its size once known
+func remoteFileLabel(name, size string) string {
+	if size == "" {
+		return name
+	}
+	return fmt.Sprintf("%s (%s)", name, size)
+}
+
+// remoteFileCheck is a check box that also reports right clicks
+type remoteFileCheck struct {
+	widget.Check
+	onTappedSecondary func(*fyne.PointEvent)
+}
+
+// newRemoteFileCheck creates a remoteFileCheck
+func newRemoteFileCheck(label string, changed func(bool), tappedSecondary func(*fyne.PointEvent)) *remoteFileCheck {
+	c := &remoteFileCheck{onTappedSecondary: tappedSecondary}
+	c.Text = label
+	c.OnChanged = changed
+	c.ExtendBaseWidget(c)
+	return c
+}
+
+// TappedSecondary handles right clicks
+func (c *remoteFileCheck) TappedSecondary(pe *fyne.PointEvent) {
+	if c.onTappedSecondary != nil {
+		c.onTappedSecondary(pe)
+	}
+}
+
+// remoteFileDialog lets the user pick remote files to download
+type remoteFileDialog struct {
+	ui       *AppUI
+	window   fyne.Window
+	files    []string
+	checks   []*remoteFileCheck // 与 files 一一对应
+	selected map[string]bool
+	visible  []int // 匹配过滤条件的 files 下标
+	list     *fyne.Container
+	query    string
+
+	overwriteCheck *widget.Check // 下载时是否覆盖本地已存在的文件
+	flattenCheck   *widget.Check // 下载到当前目录而不保留远程目录结构
+
+	// 分页加载
+	prefix      string
+	pageSize    int
+	nextToken   string
+	loaded      bool // 是否已加载过第一页
+	loadMoreBtn *widget.Button
+
+	ctx context.Context // 窗口关闭时取消
+}
+
+// newRemoteFileDialog creates an empty dialog for the remote files under prefix
+func newRemoteFileDialog(ui *AppUI, prefix string) *remoteFileDialog {
+	return &remoteFileDialog{
+		ui:       ui,
+		prefix:   prefix,
+		pageSize: RemotePageSize,
+		selected: make(map[string]bool),
+		list:     container.NewVBox(),
+		ctx:      context.Background(),
+
+		overwriteCheck: widget.NewCheck("Overwrite existing", nil),
+		flattenCheck:   widget.NewCheck("Flatten into current folder", nil),
+	}
+}
+
+// addFiles appends files to the list and starts loading their sizes once the dialog is shown
+func (d *remoteFileDialog) addFiles(files []string) {
+	var checks []*remoteFileCheck
+	for _, name := range files {
+		key := name
+		check := newRemoteFileCheck(remoteFileLabel(name, ""),
+			func(checked bool) { d.selected[key] = checked },
+			func(pe *fyne.PointEvent) { d.showFileMenu(key, pe.AbsolutePosition) })
+		checks = append(checks, check)
+		d.list.Add(check)
+	}
+	d.files = append(d.files, files...)
+	d.checks = append(d.checks, checks...)
+	d.setFilter(d.query)
+
+	if d.window != nil {
+		go d.loadSizes(d.ctx, files, checks)
+	}
+}
+
+// loadMore fetches the next page of remote files
+func (d *remoteFileDialog) loadMore() error {
+	files, nextToken, err := d.ui.fileManager.ListRemoteFilesPage(d.prefix, d.nextToken, d.pageSize)
+	if err != nil {
+		return fmt.Errorf("failed to list remote files: %w", err)
+	}
+	d.nextToken = nextToken
+	d.loaded = true
+	d.addFiles(files)
+
+	if d.loadMoreBtn != nil && !d.hasMore() {
+		d.loadMoreBtn.Hide()
+	}
+	return nil
+}
+
+// hasMore reports whether more pages of remote files are available
+func (d *remoteFileDialog) hasMore() bool {
+	return !d.loaded || d.nextToken != ""
+}
+
+// setFilter shows only the files whose name contains query
+func (d *remoteFileDialog) setFilter(query string) {
+	d.query = query
+	d.visible = filterNames(d.files, query)
+	shown := make(map[int]bool, len(d.visible))
+	for _, i := range d.visible {
+		shown[i] = true
+	}
+	for i, check := range d.checks {
+		if shown[i] {
+			check.Show()
+		} else {
+			check.Hide()
+		}
+	}
+	d.list.Refresh()
+}
+
+// setVisibleChecked checks or unchecks the currently visible files only
+func (d *remoteFileDialog) setVisibleChecked(checked bool) {
+	for _, i := range d.visible {
+		d.checks[i].SetChecked(checked)
+	}
+}
+
+// setMatchingChecked checks or unchecks the visible files whose key matches the
+// glob pattern and returns how many matched
+func (d *remoteFileDialog) setMatchingChecked(pattern string, checked bool) (int, error) {
+	matched, err := globNames(d.files, d.visible, pattern)
+	if err != nil {
+		return 0, err
+	}
+	for _, i := range matched {
+		d.checks[i].SetChecked(checked)
+	}
+	return len(matched), nil
+}
+
+// showFileMenu shows the context menu of a remote file
+func (d *remoteFileDialog) showFileMenu(file string, pos fyne.Position) {
+	if d.window == nil {
+		return
+	}
+	fileMenu := fyne.NewMenu("", fyne.NewMenuItem("copy share link", func() {
+		if err := d.copyShareLink(file); err != nil {
+			showError(err, d.window)
+		}
+	}))
+	widget.ShowPopUpMenuAtPosition(fileMenu, d.window.Canvas(), pos)
+}
+
+// copyShareLink copies a presigned download URL of file to the clipboard
+func (d *remoteFileDialog) copyShareLink(file string) error {
+	url, err := d.ui.fileManager.PresignRemoteFile(file, PresignExpiry)
+	if err != nil {
+		return fmt.Errorf("failed to create share link: %w", err)
+	}
+	d.ui.app.Clipboard().SetContent(url)
+	d.ui.logger.Info("Share link copied to clipboard", slog.String("file", file), slog.Duration("expiry", PresignExpiry))
+	return nil
+}
+
+// selectedFiles returns the checked files in list order
+func (d *remoteFileDialog) selectedFiles() []string {
+	var files []string
+	for _, name := range d.files {
+		if d.selected[name] {
+			files = append(files, name)
+		}
+	}
+	return files
+}
+
+// loadSizes queries the size of the given files concurrently and updates their
+// labels as results arrive, so the dialog opens without waiting for them
+func (d *remoteFileDialog) loadSizes(ctx context.Context, files []string, checks []*remoteFileCheck) {
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < remoteStatWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				size := "?"
+				info, err := d.ui.fileManager.StatRemoteFile(files[i])
+				if err != nil {
+					d.ui.logger.Debug("Failed to stat remote file", slog.String("file", files[i]), slog.String("error", err.Error()))
+				} else {
+					size = formatSize(info.Size)
+				}
+				check, label := checks[i], remoteFileLabel(files[i], size)
+				fyne.Do(func() { check.SetText(label) })
+			}
+		}()
+	}
+
+	go func() {
+		defer close(indices)
+		for i := range files {
+			select {
+			case <-ctx.Done():
+				return
+			case indices <- i:
+			}
+		}
+	}()
+	wg.Wait()
+}
+
+// show opens the dialog in its own window
+func (d *remoteFileDialog) show() {
+	d.window = d.ui.app.NewWindow("Remote Files")
+	d.window.Resize(fyne.NewSize(RemoteWindowWidth, RemoteWindowHeight))
+	d.window.CenterOnScreen()
+
+	// 关闭窗口时停止查询剩余文件的大小
+	ctx, cancel := context.WithCancel(context.Background())
+	d.ctx = ctx
+	d.window.SetOnClosed(cancel)
+	go d.loadSizes(ctx, d.files, d.checks)
+
+	scroll := container.NewScroll(d.list)
+	scroll.SetMinSize(fyne.NewSize(RemoteScrollMinWidth, RemoteScrollMinHeight))
+
+	filterEntry := widget.NewEntry()
+	filterEntry.SetPlaceHolder("Filter remote files...")
+	filterEntry.OnChanged = d.setFilter
+
+	// 全选/全不选只作用于当前过滤后可见的文件
+	selectAllBtn := widget.NewButton("Select All", func() { d.setVisibleChecked(true) })
+	deselectAllBtn := widget.NewButton("Deselect All", func() { d.setVisibleChecked(false) })
+
+	downloadBtn := widget.NewButton("Download Selected", func() {
+		files := d.selectedFiles()
+		if len(files) == 0 {
+			dialog.ShowInformation("Info", "Please select at least one file", d.window)
+			return
+		}
+		d.window.Close()
+		if d.flattenCheck.Checked {
+			d.ui.downloadRemoteFilesFlat(files, d.overwriteCheck.Checked)
+			return
+		}
+		d.ui.downloadRemoteFiles(files, d.overwriteCheck.Checked)
+	})
+	downloadOpenBtn := widget.NewButton("Download & Open", func() {
+		files := d.selectedFiles()
+		if len(files) == 0 {
+			dialog.ShowInformation("Info", "Please select at least one file", d.window)
+			return
+		}
+		d.window.Close()
+		d.ui.downloadAndOpenRemoteFiles(files, d.overwriteCheck.Checked, d.flattenCheck.Checked)
+	})
+	cancelBtn := widget.NewButton("Cancel", d.window.Close)
+
+	d.loadMoreBtn = widget.NewButton("Load More", func() {
+		if err := d.loadMore(); err != nil {
+			showError(err, d.window)
+		}
+	})
+	if !d.hasMore() {
+		d.loadMoreBtn.Hide()
+	}
+
+	// 按 glob 模式勾选，例如 logs/2023-01-*，同样只作用于可见的文件
+	globEntry := widget.NewEntry()
+	globEntry.SetPlaceHolder("Glob pattern, e.g. logs/2023-01-*")
+	selectMatching := func(checked bool) {
+		count, err := d.setMatchingChecked(globEntry.Text, checked)
+		if err != nil {
+			showError(err, d.window)
+			return
+		}
+		d.ui.logger.Debug("Glob selection applied", slog.String("pattern", globEntry.Text),
+			slog.Bool("checked", checked), slog.Int("matched", count))
+	}
+	selectMatchingBtn := widget.NewButton("Select matching", func() { selectMatching(true) })
+	deselectMatchingBtn := widget.NewButton("Deselect matching", func() { selectMatching(false) })
+
+	topButtons := container.NewHBox(selectAllBtn, deselectAllBtn)
+	globRow := container.NewBorder(nil, nil, nil, container.NewHBox(selectMatchingBtn, deselectMatchingBtn), globEntry)
+	bottomButtons := container.NewHBox(d.overwriteCheck, d.flattenCheck, downloadBtn, downloadOpenBtn, cancelBtn)
+
+	content := container.NewBorder(
+		container.NewVBox(
+			widget.NewLabel("Select remote files to download:"),
+			filterEntry,
+			topButtons,
+			globRow,
+		),
+		bottomButtons,
+		nil,
+		nil,
+		container.NewBorder(nil, d.loadMoreBtn, nil, nil, scroll),
+	)
+
+	d.window.SetContent(content)
+	d.window.Show()
+}
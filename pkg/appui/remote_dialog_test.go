@@ -0,0 +1,372 @@
+package appui
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mingregister/fers/pkg/config"
+	"github.com/mingregister/fers/pkg/crypto"
+	"github.com/mingregister/fers/pkg/dir"
+	"github.com/mingregister/fers/pkg/storage"
+)
+
+func TestFormatSize(t *testing.T) {
+	tests := []struct {
+		size int64
+		want string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KB"},
+		{1536, "1.5 KB"},
+		{5 * 1024 * 1024, "5.0 MB"},
+		{2 * 1024 * 1024 * 1024, "2.0 GB"},
+		{3 * 1024 * 1024 * 1024 * 1024, "3.0 TB"},
+	}
+
+	for _, tt := range tests {
+		if got := formatSize(tt.size); got != tt.want {
+			t.Errorf("formatSize(%d) = %q, want %q", tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestRemoteFileDialog_Filter(t *testing.T) {
+	ui := newTestAppUI(t)
+	d := newRemoteFileDialog(ui, "")
+	d.addFiles([]string{"docs/report.pdf", "docs/notes.txt", "photos/cat.jpg"})
+
+	d.setFilter("DOCS")
+	if len(d.visible) != 2 {
+		t.Fatalf("Expected 2 visible files, got %v", d.visible)
+	}
+	if d.checks[2].Visible() {
+		t.Error("Non-matching file should be hidden")
+	}
+
+	// Select All only selects the filtered files
+	d.setVisibleChecked(true)
+	selected := d.selectedFiles()
+	if len(selected) != 2 || selected[0] != "docs/report.pdf" || selected[1] != "docs/notes.txt" {
+		t.Errorf("Expected only filtered files to be selected, got %v", selected)
+	}
+
+	d.setFilter("")
+	if len(d.visible) != 3 || !d.checks[2].Visible() {
+		t.Error("Clearing the filter should show all files again")
+	}
+	if len(d.selectedFiles()) != 2 {
+		t.Error("Clearing the filter should keep the selection")
+	}
+}
+
+func TestRemoteFileDialog_SelectMatching(t *testing.T) {
+	ui := newTestAppUI(t)
+	d := newRemoteFileDialog(ui, "")
+	d.addFiles([]string{"logs/2023-01-01.log", "logs/2023-01-02.txt", "logs/2023-02-01.log", "logs/old/2023-01-05.log"})
+
+	count, err := d.setMatchingChecked("logs/2023-01-*", true)
+	if err != nil {
+		t.Fatalf("setMatchingChecked failed: %v", err)
+	}
+	if selected := d.selectedFiles(); count != 2 || len(selected) != 2 || selected[0] != "logs/2023-01-01.log" || selected[1] != "logs/2023-01-02.txt" {
+		t.Errorf("Expected the two January files in logs/ to be selected, got %d: %v", count, selected)
+	}
+
+	// 只作用于过滤后可见的文件
+	d.setFilter(".log")
+	if _, err := d.setMatchingChecked("logs/*", false); err != nil {
+		t.Fatalf("setMatchingChecked failed: %v", err)
+	}
+	if selected := d.selectedFiles(); len(selected) != 1 || selected[0] != "logs/2023-01-02.txt" {
+		t.Errorf("Hidden files should keep their selection, got %v", selected)
+	}
+
+	d.setFilter("")
+	d.setVisibleChecked(true)
+	if _, err := d.setMatchingChecked("logs/*/*", false); err != nil {
+		t.Fatalf("setMatchingChecked failed: %v", err)
+	}
+	if len(d.selectedFiles()) != 3 || d.selected["logs/old/2023-01-05.log"] {
+		t.Errorf("Expected only the nested file to be deselected, got %v", d.selectedFiles())
+	}
+
+	if _, err := d.setMatchingChecked("[", true); err == nil {
+		t.Error("Expected an error for an invalid pattern")
+	}
+}
+
+func TestRemoteFileDialog_LoadSizes(t *testing.T) {
+	ui := newTestAppUI(t)
+	localPath := filepath.Join(ui.currentDir, "a.txt")
+	if err := os.WriteFile(localPath, []byte("data"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := ui.fileManager.EncryptAndUploadFile(localPath, "a.txt"); err != nil {
+		t.Fatalf("Failed to upload test file: %v", err)
+	}
+
+	d := newRemoteFileDialog(ui, "")
+	d.addFiles([]string{"a.txt", "missing.txt"})
+	d.loadSizes(context.Background(), d.files, d.checks)
+
+	if label := d.checks[0].Text; !strings.HasPrefix(label, "a.txt (") || !strings.HasSuffix(label, " B)") {
+		t.Errorf("Expected label with size, got %q", label)
+	}
+	if label := d.checks[1].Text; label != "missing.txt (?)" {
+		t.Errorf("Expected unknown size marker, got %q", label)
+	}
+}
+
+func TestRemoteFileDialog_LoadMore(t *testing.T) {
+	ui := newTestAppUI(t)
+	localPath := filepath.Join(ui.currentDir, "file.txt")
+	if err := os.WriteFile(localPath, []byte("data"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	for _, key := range []string{"a.txt", "b.txt", "c.txt", "d.txt", "e.txt"} {
+		if err := ui.fileManager.EncryptAndUploadFile(localPath, key); err != nil {
+			t.Fatalf("Failed to upload %s: %v", key, err)
+		}
+	}
+
+	d := newRemoteFileDialog(ui, "")
+	d.pageSize = 2
+	if !d.hasMore() {
+		t.Fatal("A new dialog should have a first page to load")
+	}
+
+	if err := d.loadMore(); err != nil {
+		t.Fatalf("loadMore failed: %v", err)
+	}
+	if len(d.files) != 2 || !d.hasMore() {
+		t.Fatalf("Expected first page of 2 files with more available, got %v", d.files)
+	}
+
+	// Newly loaded files respect the active filter
+	d.setFilter("c.txt")
+	for d.hasMore() {
+		if err := d.loadMore(); err != nil {
+			t.Fatalf("loadMore failed: %v", err)
+		}
+	}
+
+	want := []string{"a.txt", "b.txt", "c.txt", "d.txt", "e.txt"}
+	if len(d.files) != len(want) {
+		t.Fatalf("Expected %v after loading all pages, got %v", want, d.files)
+	}
+	for i, name := range want {
+		if d.files[i] != name {
+			t.Errorf("Expected file %d to be %s, got %s", i, name, d.files[i])
+		}
+	}
+	if len(d.visible) != 1 || d.files[d.visible[0]] != "c.txt" {
+		t.Errorf("Expected only c.txt visible, got %v", d.visible)
+	}
+}
+
+// presignStorage is a storage client that can presign URLs
+type presignStorage struct {
+	storage.Client
+}
+
+func (p presignStorage) Presign(key string, expiry time.Duration) (string, error) {
+	return "https://example.com/" + key, nil
+}
+
+func TestRemoteFileDialog_CopyShareLink(t *testing.T) {
+	ui := newTestAppUI(t)
+	d := newRemoteFileDialog(ui, "")
+	d.addFiles([]string{"a.txt"})
+
+	if err := d.copyShareLink("a.txt"); err == nil {
+		t.Error("Expected an error when the storage backend cannot presign")
+	}
+
+	cfg := &config.Config{TargetDir: ui.currentDir}
+	ui.fileManager = dir.NewFileManager(cfg, presignStorage{storage.NewMemoryClient()}, ui.logger, crypto.NewAESGCM("test-password"))
+	if err := d.copyShareLink("a.txt"); err != nil {
+		t.Fatalf("copyShareLink failed: %v", err)
+	}
+	if got := ui.app.Clipboard().Content(); got != "https://example.com/a.txt" {
+		t.Errorf("Expected share link in clipboard, got %q", got)
+	}
+}
+
+func TestAppUI_DownloadRemoteFilesFlat(t *testing.T) {
+	ui := newTestAppUI(t)
+	localPath := filepath.Join(ui.currentDir, "file.txt")
+	if err := os.WriteFile(localPath, []byte("data"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	keys := []string{"logs/2023/app.log", "logs/2024/app.log"}
+	for _, key := range keys {
+		if err := ui.fileManager.EncryptAndUploadFile(localPath, key); err != nil {
+			t.Fatalf("Failed to upload %s: %v", key, err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(ui.currentDir, "inbox"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	ui.setupUI()
+	ui.changeDir(filepath.Join(ui.currentDir, "inbox"))
+
+	ui.downloadRemoteFilesFlat(keys, false)
+	if !waitFor(t, func() bool { return len(ui.items) == 2 }) {
+		t.Fatalf("Expected both files in the current folder, got %v", ui.items)
+	}
+	if ui.items[0] != "app (2).log" || ui.items[1] != "app.log" {
+		t.Errorf("Expected the colliding name to be renamed, got %v", ui.items)
+	}
+	if _, err := os.Stat(filepath.Join(ui.fileManager.GetWorkingDir(), "logs")); !os.IsNotExist(err) {
+		t.Errorf("Flattened download should not recreate remote folders, got %v", err)
+	}
+	tapDialogButton(t, ui, "OK")
+}
+
+func TestFileManagerCommand(t *testing.T) {
+	tests := []struct {
+		goos  string
+		path  string
+		isDir bool
+		want  []string
+	}{
+		{"windows", `C:\backup\docs\a.txt`, false, []string{"explorer", `/select,C:\backup\docs\a.txt`}},
+		{"darwin", "/backup/docs/a.txt", false, []string{"open", "-R", "/backup/docs/a.txt"}},
+		{"linux", "/backup/docs/a.txt", false, []string{"xdg-open", "/backup/docs"}},
+		{"linux", "/backup/docs", true, []string{"xdg-open", "/backup/docs"}},
+	}
+	for _, tt := range tests {
+		cmd, err := fileManagerCommand(tt.goos, tt.path, tt.isDir)
+		if err != nil {
+			t.Fatalf("fileManagerCommand(%s) failed: %v", tt.goos, err)
+		}
+		if !slices.Equal(cmd.Args, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.goos, tt.want, cmd.Args)
+		}
+	}
+	if _, err := fileManagerCommand("plan9", "/backup", true); err == nil {
+		t.Error("Expected an unsupported OS to fail")
+	}
+}
+
+func TestOpenFileCommand(t *testing.T) {
+	tests := []struct {
+		goos string
+		path string
+		want []string
+	}{
+		{"windows", `C:\backup\a.txt`, []string{"cmd", "/c", "start", "", `C:\backup\a.txt`}},
+		{"darwin", "/backup/a.txt", []string{"open", "/backup/a.txt"}},
+		{"linux", "/backup/a.txt", []string{"xdg-open", "/backup/a.txt"}},
+	}
+	for _, tt := range tests {
+		cmd, err := openFileCommand(tt.goos, tt.path)
+		if err != nil {
+			t.Fatalf("openFileCommand(%s) failed: %v", tt.goos, err)
+		}
+		if !slices.Equal(cmd.Args, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.goos, tt.want, cmd.Args)
+		}
+	}
+	if _, err := openFileCommand("plan9", "/backup/a.txt"); err == nil {
+		t.Error("Expected an unsupported OS to fail")
+	}
+}
+
+// uploadRemoteKeys uploads a file under each key and removes it locally
+func uploadRemoteKeys(t *testing.T, ui *AppUI, keys ...string) {
+	t.Helper()
+	localPath := filepath.Join(ui.currentDir, "upload.tmp.txt")
+	if err := os.WriteFile(localPath, []byte("data"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	for _, key := range keys {
+		if err := ui.fileManager.EncryptAndUploadFile(localPath, key); err != nil {
+			t.Fatalf("Failed to upload %s: %v", key, err)
+		}
+	}
+	if err := os.Remove(localPath); err != nil {
+		t.Fatalf("Failed to remove test file: %v", err)
+	}
+}
+
+// recordCommands replaces the commands run by ui and returns their arguments
+func recordCommands(ui *AppUI, err error) func() [][]string {
+	var mu sync.Mutex
+	var commands [][]string
+	ui.commandRunner = func(cmd *exec.Cmd) error {
+		mu.Lock()
+		defer mu.Unlock()
+		commands = append(commands, cmd.Args)
+		return err
+	}
+	return func() [][]string {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(commands)
+	}
+}
+
+func TestAppUI_DownloadAndOpenSingleFile(t *testing.T) {
+	ui := newTestAppUI(t)
+	uploadRemoteKeys(t, ui, "docs/a.txt")
+	commands := recordCommands(ui, nil)
+	ui.setupUI()
+
+	ui.downloadAndOpenRemoteFiles([]string{"docs/a.txt"}, false, false)
+	if !waitFor(t, func() bool { return len(commands()) == 1 }) {
+		t.Fatal("Expected the downloaded file to be opened")
+	}
+	target := filepath.Join(ui.fileManager.GetWorkingDir(), "docs", "a.txt")
+	want, _ := openFileCommand(runtime.GOOS, target)
+	if !slices.Equal(commands()[0], want.Args) {
+		t.Errorf("Expected %v, got %v", want.Args, commands()[0])
+	}
+	if _, err := os.Stat(target); err != nil {
+		t.Errorf("Expected the file to be downloaded: %v", err)
+	}
+}
+
+func TestAppUI_DownloadAndOpenMultipleFiles(t *testing.T) {
+	ui := newTestAppUI(t)
+	uploadRemoteKeys(t, ui, "docs/a.txt", "docs/b.txt")
+	commands := recordCommands(ui, nil)
+	ui.setupUI()
+
+	ui.downloadAndOpenRemoteFiles([]string{"docs/b.txt", "docs/a.txt"}, false, true)
+	if !waitFor(t, func() bool { return len(commands()) == 1 }) {
+		t.Fatal("Expected the folder of the first file to be opened")
+	}
+	want, _ := fileManagerCommand(runtime.GOOS, filepath.Join(ui.currentDir, "b.txt"), false)
+	if !slices.Equal(commands()[0], want.Args) {
+		t.Errorf("Expected %v, got %v", want.Args, commands()[0])
+	}
+}
+
+func TestAppUI_DownloadAndOpenFailsToOpen(t *testing.T) {
+	ui := newTestAppUI(t)
+	uploadRemoteKeys(t, ui, "a.txt")
+	recordCommands(ui, errors.New("no default application"))
+	ui.setupUI()
+
+	ui.downloadAndOpenRemoteFiles([]string{"a.txt"}, false, false)
+	if !waitFor(t, func() bool {
+		top := ui.window.Canvas().Overlays().Top()
+		return top != nil && findLabel(top, "failed to open it") != nil
+	}) {
+		t.Fatal("Expected an error saying the file could not be opened")
+	}
+	if _, err := os.Stat(filepath.Join(ui.currentDir, "a.txt")); err != nil {
+		t.Errorf("The download should be kept when opening fails: %v", err)
+	}
+}
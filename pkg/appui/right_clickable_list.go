@@ -14,6 +14,11 @@ type RightClickableList struct {
 	items            []string
 	OnItemTapped     func(index int)
 	OnItemRightClick func(index int, pos fyne.Position)
+	OnItemToggled    func(index int)      // Ctrl/Cmd+左键点击
+	IsItemSelected   func(index int) bool // 用于显示多选状态
+	OnItemDropped    func(from, to int)   // 把第 from 项拖到第 to 项上松开
+
+	containers []*ItemContainer // 列表创建的所有项控件，用于查找松开位置下的项
 }
 
 // NewRightClickableList 创建新RightClickableList
@@ -36,7 +41,7 @@ func (rcl *RightClickableList) Build() {
 	rcl.list = widget.NewList(
 		func() int { return len(rcl.items) },
 		func() fyne.CanvasObject {
-			return NewItemContainer(
+			ic := NewItemContainer(
 				func(i int) {
 					if rcl.OnItemTapped != nil {
 						rcl.OnItemTapped(i)
@@ -48,15 +53,55 @@ func (rcl *RightClickableList) Build() {
 					}
 				},
 			)
+			ic.SetOnToggled(func(i int) {
+				if rcl.OnItemToggled != nil {
+					rcl.OnItemToggled(i)
+				}
+			})
+			ic.SetOnDropped(func(i int, pos fyne.Position) {
+				if to, ok := rcl.itemAt(pos); ok && to != i && rcl.OnItemDropped != nil {
+					rcl.OnItemDropped(i, to)
+				}
+			})
+			rcl.containers = append(rcl.containers, ic)
+			return ic
 		},
 		func(i int, o fyne.CanvasObject) {
 			itemContainer := o.(*ItemContainer)
 			itemContainer.SetText(rcl.items[i])
 			itemContainer.SetIndex(i)
+			itemContainer.SetSelected(rcl.IsItemSelected != nil && rcl.IsItemSelected(i))
 		},
 	)
 }
 
+// itemAt returns the index of the displayed item at the absolute position pos
+func (rcl *RightClickableList) itemAt(pos fyne.Position) (int, bool) {
+	if rcl.list == nil || fyne.CurrentApp() == nil {
+		return 0, false
+	}
+	driver := fyne.CurrentApp().Driver()
+	if !rectContains(driver.AbsolutePositionForObject(rcl.list), rcl.list.Size(), pos) {
+		return 0, false
+	}
+	for _, ic := range rcl.containers {
+		// 列表回收但未显示的项不在画布中，其位置无意义
+		if !ic.Visible() || ic.index >= len(rcl.items) {
+			continue
+		}
+		if rectContains(driver.AbsolutePositionForObject(ic), ic.Size(), pos) {
+			return ic.index, true
+		}
+	}
+	return 0, false
+}
+
+// rectContains reports whether pos lies in the rectangle at origin with size
+func rectContains(origin fyne.Position, size fyne.Size, pos fyne.Position) bool {
+	return pos.X >= origin.X && pos.X < origin.X+size.Width &&
+		pos.Y >= origin.Y && pos.Y < origin.Y+size.Height
+}
+
 // CreateRenderer 实现 fyne.Widget 接口
 func (rcl *RightClickableList) CreateRenderer() fyne.WidgetRenderer {
 	return widget.NewSimpleRenderer(rcl.list)
@@ -0,0 +1,39 @@
+package appui
+
+import "sort"
+
+// selectionSet 记录文件列表中被选中的下标（对应过滤后的列表）
+type selectionSet map[int]bool
+
+// selectOnly 清除其他选中项，只选中 i
+func (s selectionSet) selectOnly(i int) {
+	s.clear()
+	s[i] = true
+}
+
+// toggle 切换 i 的选中状态，返回切换后是否选中
+func (s selectionSet) toggle(i int) bool {
+	if s[i] {
+		delete(s, i)
+		return false
+	}
+	s[i] = true
+	return true
+}
+
+// clear 清除全部选中项
+func (s selectionSet) clear() {
+	for i := range s {
+		delete(s, i)
+	}
+}
+
+// indices 按升序返回选中的下标
+func (s selectionSet) indices() []int {
+	out := make([]int, 0, len(s))
+	for i := range s {
+		out = append(out, i)
+	}
+	sort.Ints(out)
+	return out
+}
@@ -0,0 +1,65 @@
+package appui
+
+import "testing"
+
+func TestSelectionSet_SelectOnly(t *testing.T) {
+	s := selectionSet{}
+	s.toggle(1)
+	s.toggle(3)
+
+	s.selectOnly(2)
+
+	indices := s.indices()
+	if len(indices) != 1 || indices[0] != 2 {
+		t.Errorf("Expected only index 2 selected, got %v", indices)
+	}
+}
+
+func TestSelectionSet_Toggle(t *testing.T) {
+	s := selectionSet{}
+
+	if !s.toggle(4) {
+		t.Error("Toggling an unselected index should select it")
+	}
+	if !s.toggle(1) {
+		t.Error("Toggling an unselected index should select it")
+	}
+	if s.toggle(4) {
+		t.Error("Toggling a selected index should deselect it")
+	}
+
+	indices := s.indices()
+	if len(indices) != 1 || indices[0] != 1 {
+		t.Errorf("Expected only index 1 selected, got %v", indices)
+	}
+}
+
+func TestSelectionSet_IndicesSorted(t *testing.T) {
+	s := selectionSet{}
+	for _, i := range []int{7, 2, 5, 0} {
+		s.toggle(i)
+	}
+
+	indices := s.indices()
+	expected := []int{0, 2, 5, 7}
+	if len(indices) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, indices)
+	}
+	for i := range expected {
+		if indices[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected, indices)
+			break
+		}
+	}
+}
+
+func TestSelectionSet_Clear(t *testing.T) {
+	s := selectionSet{}
+	s.toggle(1)
+	s.toggle(2)
+
+	s.clear()
+	if len(s) != 0 {
+		t.Errorf("Expected empty selection after clear, got %v", s.indices())
+	}
+}
@@ -0,0 +1,52 @@
+package appui
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+)
+
+// registerShortcuts wires the keyboard shortcuts for the main operations to the window canvas
+func (ui *AppUI) registerShortcuts() {
+	canvas := ui.window.Canvas()
+
+	shortcuts := []struct {
+		key     fyne.KeyName
+		handler func()
+	}{
+		{fyne.KeyU, ui.SyncUpload},
+		{fyne.KeyD, ui.SyncDownload},
+	}
+	for _, s := range shortcuts {
+		handler := s.handler
+		canvas.AddShortcut(&desktop.CustomShortcut{KeyName: s.key, Modifier: fyne.KeyModifierShortcutDefault},
+			func(fyne.Shortcut) {
+				if !ui.shortcutsBlocked() {
+					handler()
+				}
+			})
+	}
+
+	canvas.SetOnTypedKey(ui.handleTypedKey)
+}
+
+// handleTypedKey handles the single-key shortcuts typed on the canvas
+func (ui *AppUI) handleTypedKey(ev *fyne.KeyEvent) {
+	if ui.shortcutsBlocked() {
+		return
+	}
+
+	switch ev.Name {
+	case fyne.KeyF5:
+		ui.Refresh()
+	case fyne.KeyDelete:
+		ui.deleteSelectedLocalFiles()
+	case fyne.KeyEscape:
+		ui.cancelOperation()
+	}
+}
+
+// shortcutsBlocked reports whether the search box has focus, in which case keys belong to it
+func (ui *AppUI) shortcutsBlocked() bool {
+	focused := ui.window.Canvas().Focused()
+	return focused != nil && focused == fyne.Focusable(ui.searchEntry)
+}
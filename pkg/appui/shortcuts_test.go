@@ -0,0 +1,141 @@
+package appui
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+)
+
+// newShortcutTestAppUI creates a fully set up AppUI so the shortcuts are registered
+func newShortcutTestAppUI(t *testing.T) *AppUI {
+	t.Helper()
+	ui := newTestAppUI(t)
+	ui.setupUI()
+	return ui
+}
+
+// typeShortcut sends a Ctrl/Cmd shortcut to the window canvas
+func typeShortcut(ui *AppUI, key fyne.KeyName) {
+	shortcut := &desktop.CustomShortcut{KeyName: key, Modifier: fyne.KeyModifierShortcutDefault}
+	ui.window.Canvas().(fyne.Shortcutable).TypedShortcut(shortcut)
+}
+
+// typeKey sends a single key to the window canvas
+func typeKey(ui *AppUI, key fyne.KeyName) {
+	ui.window.Canvas().OnTypedKey()(&fyne.KeyEvent{Name: key})
+}
+
+func TestShortcuts_SyncUpload(t *testing.T) {
+	ui := newShortcutTestAppUI(t)
+	ui.skipSyncConfirm = true
+	if err := os.WriteFile(filepath.Join(ui.currentDir, "file.txt"), []byte("data"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	typeShortcut(ui, fyne.KeyU)
+
+	uploaded := waitFor(t, func() bool {
+		files, err := ui.fileManager.ListRemoteFiles("")
+		return err == nil && len(files) == 1
+	})
+	if !uploaded {
+		t.Error("Ctrl+U should run Sync Upload")
+	}
+}
+
+func TestShortcuts_SyncDownload(t *testing.T) {
+	ui := newShortcutTestAppUI(t)
+	ui.skipSyncConfirm = true
+	localPath := filepath.Join(ui.currentDir, "file.txt")
+	if err := os.WriteFile(localPath, []byte("data"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := ui.fileManager.EncryptAndUploadFile(localPath, "file.txt"); err != nil {
+		t.Fatalf("Failed to upload test file: %v", err)
+	}
+	if err := os.Remove(localPath); err != nil {
+		t.Fatalf("Failed to remove test file: %v", err)
+	}
+
+	typeShortcut(ui, fyne.KeyD)
+
+	downloaded := waitFor(t, func() bool {
+		_, err := os.Stat(localPath)
+		return err == nil
+	})
+	if !downloaded {
+		t.Error("Ctrl+D should run Sync Download")
+	}
+}
+
+func TestShortcuts_RefreshAndDelete(t *testing.T) {
+	ui := newShortcutTestAppUI(t)
+	if err := os.WriteFile(filepath.Join(ui.currentDir, "file.txt"), []byte("data"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	typeKey(ui, fyne.KeyF5)
+	if len(ui.items) != 1 {
+		t.Fatalf("F5 should refresh the list, got %v", ui.items)
+	}
+
+	ui.selectItem(0)
+	typeKey(ui, fyne.KeyDelete)
+	if ui.window.Canvas().Overlays().Top() == nil {
+		t.Error("Delete should ask to confirm deleting the selected file")
+	}
+}
+
+func TestShortcuts_EscapeCancels(t *testing.T) {
+	ui := newShortcutTestAppUI(t)
+
+	started := make(chan struct{})
+	cancelled := make(chan struct{})
+	ui.runOperation("test", func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		close(cancelled)
+		return ctx.Err()
+	})
+	<-started
+
+	typeKey(ui, fyne.KeyEscape)
+
+	if !waitFor(t, func() bool {
+		select {
+		case <-cancelled:
+			return true
+		default:
+			return false
+		}
+	}) {
+		t.Error("Escape should cancel the running operation")
+	}
+}
+
+func TestShortcuts_IgnoredWhileSearchFocused(t *testing.T) {
+	ui := newShortcutTestAppUI(t)
+	ui.window.Canvas().Focus(ui.searchEntry)
+
+	if err := os.WriteFile(filepath.Join(ui.currentDir, "file.txt"), []byte("data"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	typeKey(ui, fyne.KeyF5)
+	if len(ui.items) != 0 {
+		t.Errorf("F5 should not refresh while the search box has focus, got %v", ui.items)
+	}
+
+	typeShortcut(ui, fyne.KeyU)
+	files, err := ui.fileManager.ListRemoteFiles("")
+	if err != nil {
+		t.Fatalf("ListRemoteFiles failed: %v", err)
+	}
+	if len(files) != 0 || ui.progressBar.Visible() {
+		t.Error("Ctrl+U should not run while the search box has focus")
+	}
+}
@@ -0,0 +1,67 @@
+package appui
+
+import (
+	"log/slog"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+)
+
+// requestClose handles the user closing the main window: with operations
+// running it asks for confirmation, cancels them and waits briefly for them
+// to unwind before closing
+func (ui *AppUI) requestClose() {
+	if !ui.operationRunning() {
+		ui.shutdown()
+		ui.window.Close()
+		return
+	}
+
+	dialog.ShowConfirm("Quit", "Operations are running, quit anyway?", func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		done := ui.cancelOperationForShutdown()
+		go func() {
+			select {
+			case <-done:
+			case <-time.After(ShutdownTimeout):
+				ui.logger.Warn("Operation did not stop in time, closing anyway")
+			}
+			fyne.Do(func() {
+				ui.shutdown()
+				ui.window.Close()
+			})
+		}()
+	}, ui.window)
+}
+
+// cancelOperationForShutdown cancels the running operations and returns a
+// channel that is closed once all of them have finished
+func (ui *AppUI) cancelOperationForShutdown() <-chan struct{} {
+	ops := ui.runningOperations()
+	done := make(chan struct{})
+	if len(ops) == 0 {
+		close(done)
+		return done
+	}
+	ui.logger.Info("Cancelling running operations before exit", slog.Int("count", len(ops)))
+	for _, op := range ops {
+		op.cancel()
+	}
+	go func() {
+		for _, op := range ops {
+			<-op.done
+		}
+		close(done)
+	}()
+	return done
+}
+
+// shutdown stops the background syncs before the application exits
+func (ui *AppUI) shutdown() {
+	// 关闭时这两个调用不会失败
+	_ = ui.setAutoSync(false)
+	_ = ui.setScheduledSync(false)
+}
@@ -0,0 +1,74 @@
+package appui
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+func TestAppUI_RequestCloseCancelsOperation(t *testing.T) {
+	ui := newTestAppUI(t)
+
+	var mu sync.Mutex
+	var events []string
+	record := func(event string) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	}
+	ui.window.SetOnClosed(func() { record("closed") })
+
+	ui.runOperation("Sync Upload", func(ctx context.Context) error {
+		<-ctx.Done()
+		record("cancelled")
+		return ctx.Err()
+	})
+
+	ui.requestClose()
+	tapDialogButton(t, ui, "Yes")
+
+	if !waitFor(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(events) == 2
+	}) {
+		t.Fatalf("Expected the operation to be cancelled and the window closed, got %v", events)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if events[0] != "cancelled" || events[1] != "closed" {
+		t.Errorf("Expected cancellation before close, got %v", events)
+	}
+}
+
+func TestAppUI_RequestCloseDeclined(t *testing.T) {
+	ui := newTestAppUI(t)
+	closed := false
+	ui.window.SetOnClosed(func() { closed = true })
+
+	release := make(chan struct{})
+	defer close(release)
+	ui.runOperation("Sync Upload", func(ctx context.Context) error {
+		<-release
+		return nil
+	})
+
+	ui.requestClose()
+	tapDialogButton(t, ui, "No")
+
+	if closed {
+		t.Error("Window should stay open when quitting is declined")
+	}
+}
+
+func TestAppUI_RequestCloseIdle(t *testing.T) {
+	ui := newTestAppUI(t)
+	closed := false
+	ui.window.SetOnClosed(func() { closed = true })
+
+	ui.requestClose()
+
+	if !closed {
+		t.Error("Window should close immediately when no operation is running")
+	}
+}
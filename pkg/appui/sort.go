@@ -0,0 +1,119 @@
+package appui
+
+import (
+	"slices"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"github.com/mingregister/fers/pkg/dir"
+)
+
+// SortField 文件列表的排序字段
+type SortField int
+
+const (
+	SortByName SortField = iota
+	SortByModTime
+	SortBySize
+)
+
+// sortFieldNames 排序字段在界面上显示的名称，下标与 SortField 对应
+var sortFieldNames = []string{"Name", "Modified", "Size"}
+
+// String 返回排序字段在界面上显示的名称
+func (f SortField) String() string {
+	if f < 0 || int(f) >= len(sortFieldNames) {
+		return sortFieldNames[SortByName]
+	}
+	return sortFieldNames[f]
+}
+
+// SortMode 文件列表的排序方式
+type SortMode struct {
+	Field      SortField
+	Descending bool
+	DirsFirst  bool // 目录排在文件前面，不受 Descending 影响
+}
+
+// DefaultSortMode 默认按名称升序，目录在前
+var DefaultSortMode = SortMode{Field: SortByName, DirsFirst: true}
+
+// Preference keys for the persisted sort mode
+const (
+	prefSortField      = "sort.field"
+	prefSortDescending = "sort.descending"
+	prefSortDirsFirst  = "sort.dirs_first"
+)
+
+// loadSortMode reads the sort mode from preferences, falling back to DefaultSortMode
+func loadSortMode(prefs fyne.Preferences) SortMode {
+	field := SortField(prefs.IntWithFallback(prefSortField, int(DefaultSortMode.Field)))
+	if field < SortByName || field > SortBySize {
+		field = DefaultSortMode.Field
+	}
+	return SortMode{
+		Field:      field,
+		Descending: prefs.BoolWithFallback(prefSortDescending, DefaultSortMode.Descending),
+		DirsFirst:  prefs.BoolWithFallback(prefSortDirsFirst, DefaultSortMode.DirsFirst),
+	}
+}
+
+// saveSortMode persists the sort mode to preferences
+func saveSortMode(prefs fyne.Preferences, mode SortMode) {
+	prefs.SetInt(prefSortField, int(mode.Field))
+	prefs.SetBool(prefSortDescending, mode.Descending)
+	prefs.SetBool(prefSortDirsFirst, mode.DirsFirst)
+}
+
+// compareEntries 按排序方式比较两个目录项，名称相同时按名称升序保证顺序稳定
+func compareEntries(a, b dir.Entry, mode SortMode) int {
+	if mode.DirsFirst && a.IsDir != b.IsDir {
+		if a.IsDir {
+			return -1
+		}
+		return 1
+	}
+
+	var c int
+	switch mode.Field {
+	case SortByModTime:
+		c = a.ModTime.Compare(b.ModTime)
+	case SortBySize:
+		c = compareInt64(a.Size, b.Size)
+	default:
+		c = compareNames(a.Name, b.Name)
+	}
+	if mode.Descending {
+		c = -c
+	}
+	if c == 0 {
+		c = compareNames(a.Name, b.Name)
+	}
+	return c
+}
+
+// compareNames 不区分大小写比较名称，仅大小写不同时按原始字符串比较
+func compareNames(a, b string) int {
+	if c := strings.Compare(strings.ToLower(a), strings.ToLower(b)); c != 0 {
+		return c
+	}
+	return strings.Compare(a, b)
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// sortEntries 按排序方式就地排序目录项
+func sortEntries(entries []dir.Entry, mode SortMode) {
+	slices.SortStableFunc(entries, func(a, b dir.Entry) int {
+		return compareEntries(a, b, mode)
+	})
+}
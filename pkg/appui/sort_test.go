@@ -0,0 +1,86 @@
+package appui
+
+import (
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2/test"
+	"github.com/mingregister/fers/pkg/dir"
+)
+
+func newSortTestEntries() []dir.Entry {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	return []dir.Entry{
+		{Name: "b.txt", Size: 300, ModTime: base.Add(2 * time.Hour)},
+		{Name: "zdir", IsDir: true, Size: 4096, ModTime: base},
+		{Name: "A.txt", Size: 100, ModTime: base.Add(3 * time.Hour)},
+		{Name: "adir", IsDir: true, Size: 4096, ModTime: base.Add(time.Hour)},
+		{Name: "c.txt", Size: 200, ModTime: base.Add(4 * time.Hour)},
+	}
+}
+
+func entryNames(entries []dir.Entry) []string {
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name)
+	}
+	return names
+}
+
+func TestSortEntries(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     SortMode
+		expected []string
+	}{
+		{"name ascending", SortMode{Field: SortByName}, []string{"A.txt", "adir", "b.txt", "c.txt", "zdir"}},
+		{"name descending", SortMode{Field: SortByName, Descending: true}, []string{"zdir", "c.txt", "b.txt", "adir", "A.txt"}},
+		{"modtime ascending", SortMode{Field: SortByModTime}, []string{"zdir", "adir", "b.txt", "A.txt", "c.txt"}},
+		{"modtime descending", SortMode{Field: SortByModTime, Descending: true}, []string{"c.txt", "A.txt", "b.txt", "adir", "zdir"}},
+		{"size ascending", SortMode{Field: SortBySize}, []string{"A.txt", "c.txt", "b.txt", "adir", "zdir"}},
+		{"size descending", SortMode{Field: SortBySize, Descending: true}, []string{"adir", "zdir", "b.txt", "c.txt", "A.txt"}},
+		{"name dirs first", SortMode{Field: SortByName, DirsFirst: true}, []string{"adir", "zdir", "A.txt", "b.txt", "c.txt"}},
+		{"name descending dirs first", SortMode{Field: SortByName, Descending: true, DirsFirst: true}, []string{"zdir", "adir", "c.txt", "b.txt", "A.txt"}},
+		{"size descending dirs first", SortMode{Field: SortBySize, Descending: true, DirsFirst: true}, []string{"adir", "zdir", "b.txt", "c.txt", "A.txt"}},
+	}
+
+	for _, test := range tests {
+		entries := newSortTestEntries()
+		sortEntries(entries, test.mode)
+
+		got := entryNames(entries)
+		for i := range test.expected {
+			if got[i] != test.expected[i] {
+				t.Errorf("%s: expected %v, got %v", test.name, test.expected, got)
+				break
+			}
+		}
+	}
+}
+
+func TestCompareEntries_EqualKeysFallBackToName(t *testing.T) {
+	a := dir.Entry{Name: "a.txt", Size: 10}
+	b := dir.Entry{Name: "b.txt", Size: 10}
+
+	mode := SortMode{Field: SortBySize, Descending: true}
+	if compareEntries(a, b, mode) >= 0 {
+		t.Error("Entries with equal size should be ordered by name ascending")
+	}
+}
+
+func TestSortMode_Preferences(t *testing.T) {
+	a := test.NewApp()
+	defer a.Quit()
+	prefs := a.Preferences()
+
+	if mode := loadSortMode(prefs); mode != DefaultSortMode {
+		t.Errorf("Expected default sort mode %+v, got %+v", DefaultSortMode, mode)
+	}
+
+	saved := SortMode{Field: SortBySize, Descending: true, DirsFirst: false}
+	saveSortMode(prefs, saved)
+
+	if mode := loadSortMode(prefs); mode != saved {
+		t.Errorf("Expected saved sort mode %+v, got %+v", saved, mode)
+	}
+}
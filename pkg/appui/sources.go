@@ -0,0 +1,107 @@
+package appui
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"fyne.io/fyne/v2/dialog"
+	"github.com/mingregister/fers/pkg/dir"
+	"github.com/mingregister/fers/pkg/menu"
+)
+
+// errOperationRunning is returned when switching sources while an operation uses the current one
+var errOperationRunning = errors.New("wait for the current operation to finish or cancel it first")
+
+// Source is a backup set that can be selected in the UI
+type Source struct {
+	Name        string
+	FileManager *dir.FileManager
+}
+
+// SetSources makes sources selectable; the selector is shown when there is
+// more than one. The source whose file manager is active is selected.
+func (ui *AppUI) SetSources(sources []Source) {
+	ui.sources = sources
+	names := make([]string, 0, len(sources))
+	active := ""
+	for _, src := range sources {
+		names = append(names, src.Name)
+		if src.FileManager == ui.fileManager {
+			active = src.Name
+		}
+	}
+
+	ui.sourceSelect.SetOptions(names)
+	ui.sourceSelect.SetSelected(active)
+	if len(sources) > 1 {
+		ui.sourceSelect.Show()
+	} else {
+		ui.sourceSelect.Hide()
+	}
+}
+
+// activeSource returns the name of the source being shown
+func (ui *AppUI) activeSource() string {
+	for _, src := range ui.sources {
+		if src.FileManager == ui.fileManager {
+			return src.Name
+		}
+	}
+	return ""
+}
+
+// selectSource switches to the source chosen in the selector, restoring the selection on failure
+func (ui *AppUI) selectSource(name string) {
+	if err := ui.switchSource(name); err != nil {
+		ui.logger.Error("Failed to switch source", slog.String("source", name), slog.String("error", err.Error()))
+		dialog.ShowError(err, ui.window)
+		ui.sourceSelect.SetSelected(ui.activeSource())
+	}
+}
+
+// switchSource makes the named source active: background syncs of the old
+// source stop and the file list shows the new working directory
+func (ui *AppUI) switchSource(name string) error {
+	var next *dir.FileManager
+	for _, src := range ui.sources {
+		if src.Name == name {
+			next = src.FileManager
+		}
+	}
+	if next == nil {
+		return fmt.Errorf("unknown source %q", name)
+	}
+	if next == ui.fileManager {
+		return nil
+	}
+
+	// 正在运行的操作使用的是当前备份集
+	if ui.operationRunning() {
+		return errOperationRunning
+	}
+	if err := next.EnsureWorkingDir(); err != nil {
+		return err
+	}
+
+	ui.autoSyncCheck.SetChecked(false)
+	ui.scheduleCheck.SetChecked(false)
+
+	ui.fileManager = next
+	ui.currentDir = loadLastDir(ui.app.Preferences(), next.GetWorkingDir())
+	ui.showHidden = next.IncludeHidden()
+	ui.workingDirLabel.SetText("Working dir: " + next.GetWorkingDir())
+	ui.reloadList()
+	// 不同备份集能否更换密钥不同
+	ui.window.SetMainMenu(menu.CreateMainMenu(ui))
+
+	interval, mode, _ := next.SyncSchedule()
+	ui.scheduleCheck.SetText(fmt.Sprintf("Sync every %s (%s)", interval, mode))
+	if next.ScheduledSyncConfigured() {
+		ui.scheduleCheck.SetChecked(true)
+	}
+
+	ui.logger.Info("Switched source", slog.String("source", name), slog.String("working_dir", next.GetWorkingDir()))
+	ui.CheckKey()
+	return nil
+}
@@ -0,0 +1,93 @@
+package appui
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/mingregister/fers/pkg/config"
+	"github.com/mingregister/fers/pkg/crypto"
+	"github.com/mingregister/fers/pkg/dir"
+	"github.com/mingregister/fers/pkg/storage"
+)
+
+// newSourcesTestAppUI creates a set up AppUI with two sources sharing one storage
+func newSourcesTestAppUI(t *testing.T) (*AppUI, []Source) {
+	t.Helper()
+	shared := storage.NewMemoryClient()
+	ui := newTestAppUIWithStorage(t, storage.NewPrefixedClient(shared, "docs"))
+	ui.setupUI()
+
+	photosDir := t.TempDir()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	photos := dir.NewFileManager(&config.Config{TargetDir: photosDir},
+		storage.NewPrefixedClient(shared, "photos"), logger, crypto.NewAESGCM("test-password"))
+
+	sources := []Source{{Name: "docs", FileManager: ui.fileManager}, {Name: "photos", FileManager: photos}}
+	ui.SetSources(sources)
+	return ui, sources
+}
+
+func TestAppUI_SetSources(t *testing.T) {
+	ui, _ := newSourcesTestAppUI(t)
+	if !ui.sourceSelect.Visible() {
+		t.Error("The source selector should be shown for several sources")
+	}
+	if ui.sourceSelect.Selected != "docs" {
+		t.Errorf("Expected the active source to be selected, got %q", ui.sourceSelect.Selected)
+	}
+
+	ui.SetSources(ui.sources[:1])
+	if ui.sourceSelect.Visible() {
+		t.Error("The source selector should be hidden for a single source")
+	}
+}
+
+func TestAppUI_SwitchSource(t *testing.T) {
+	ui, sources := newSourcesTestAppUI(t)
+	photosDir := sources[1].FileManager.GetWorkingDir()
+	if err := os.WriteFile(filepath.Join(photosDir, "beach.jpg"), []byte("jpg"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	ui.sourceSelect.SetSelected("photos")
+	if ui.fileManager != sources[1].FileManager {
+		t.Fatal("Selecting a source should make its file manager active")
+	}
+	if ui.currentDir != photosDir {
+		t.Errorf("Expected the list to show %s, got %s", photosDir, ui.currentDir)
+	}
+	if !slices.Contains(ui.items, "beach.jpg") {
+		t.Errorf("Expected the photos files to be listed, got %v", ui.items)
+	}
+	if ui.workingDirLabel.Text != "Working dir: "+photosDir {
+		t.Errorf("Unexpected working dir label %q", ui.workingDirLabel.Text)
+	}
+
+	if err := ui.switchSource("missing"); err == nil {
+		t.Error("Switching to an unknown source should fail")
+	}
+}
+
+func TestAppUI_SwitchSourceWhileRunning(t *testing.T) {
+	ui, sources := newSourcesTestAppUI(t)
+
+	release := make(chan struct{})
+	ui.runOperation("test", func(ctx context.Context) error {
+		<-release
+		return nil
+	})
+	defer close(release)
+
+	if err := ui.switchSource("photos"); !errors.Is(err, errOperationRunning) {
+		t.Errorf("Expected errOperationRunning, got %v", err)
+	}
+	if ui.fileManager != sources[0].FileManager {
+		t.Error("The active source should not change while an operation runs")
+	}
+}
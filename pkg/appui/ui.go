@@ -2,6 +2,7 @@ package appui
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
@@ -10,15 +11,21 @@ import (
 	"runtime"
 	"strings"
 	"sync"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 	"github.com/mingregister/fers/pkg/dir"
+	"github.com/mingregister/fers/pkg/menu"
 )
 
+// AppID is the application ID, matching FyneApp.toml; preferences are stored under it
+const AppID = "io.github.mingregister.fers"
+
 // UI Constants
 const (
 	DefaultWindowWidth    = 1000
@@ -30,6 +37,11 @@ const (
 	RemoteWindowHeight    = 500
 	RemoteScrollMinWidth  = 650
 	RemoteScrollMinHeight = 300
+	RemotePageSize        = 500              // 远程文件对话框每页加载的文件数
+	ShutdownTimeout       = 3 * time.Second  // 退出时等待正在运行的操作结束的最长时间
+	AutoSyncDebounce      = 2 * time.Second  // 自动同步在文件停止变化多久后上传
+	FocusRefreshDebounce  = time.Second      // 窗口重新获得焦点时两次刷新列表的最短间隔
+	StoragePingTimeout    = 15 * time.Second // 启动时检查存储是否可达的最长时间
 )
 
 // AppUI manages the user interface
@@ -41,60 +53,153 @@ type AppUI struct {
 
 	// UI components
 	rightClickableList *RightClickableList
-	items              []string
+	items              []string    // 列表中显示的名称（已过滤）
+	entries            []dir.Entry // 当前目录的全部目录项（已排序）
+	visible            []int       // items 中每一项在 entries 中的下标
+	filterQuery        string
+	searchEntry        *widget.Entry
 	selectedIndex      int
 	selectedName       string
+	selection          selectionSet // 多选的下标，selectedIndex 为最近点击的一项
 	logWidget          *widget.TextGrid
+	logView            fyne.CanvasObject // 日志面板中的控件，来自 logHandler 的 LogSink
+	logHandler         *UILogHandler
 
 	// Directory navigation
-	currentDir string // 当前显示的目录
-	dirLabel   *widget.Label
+	currentDir       string // 当前显示的目录
+	breadcrumb       *fyne.Container
+	sortMode         SortMode
+	showHidden       bool      // 文件列表是否显示隐藏文件
+	lastFocusRefresh time.Time // 上次因窗口获得焦点而刷新列表的时间
+
+	workingDirUnavailable bool               // 工作目录已无法访问（如外接硬盘被拔出）
+	operationWidgets      []fyne.Disableable // 工作目录无法访问时禁用的按钮
 
 	// Operation management
-	operationMutex sync.Mutex
-	cancelFunc     context.CancelFunc
+	operationMutex  sync.Mutex
+	operations      map[uint64]*runningOperation // 正在运行和排队的操作，按 ID
+	nextOperationID uint64                       // 每次启动操作递增
+	operationQueue  chan queuedOperation         // 按顺序逐个执行的操作
+	operationWorker sync.Once                    // 第一次排队时启动消费 operationQueue 的协程
+	progressBar     *widget.ProgressBarInfinite
+	operationList   *fyne.Container // 正在运行的操作，每项可单独取消
+
+	skipSyncConfirm bool               // 本次会话中不再确认同步
+	autoSyncCancel  context.CancelFunc // 非 nil 表示自动同步正在运行
+	scheduleCancel  context.CancelFunc // 非 nil 表示定时同步正在运行
+
+	notifier      func(*fyne.Notification) // 发送系统通知，为 nil 时使用 app.SendNotification，测试时可替换
+	commandRunner func(*exec.Cmd) error    // 打开文件或文件管理器的命令，为 nil 时直接执行，测试时可替换
+
+	// Backup sets
+	sources         []Source // 配置了多个备份集时可切换，fileManager 为当前备份集的
+	sourceSelect    *widget.Select
+	workingDirLabel *widget.Label
+	autoSyncCheck   *widget.Check
+	scheduleCheck   *widget.Check
+
+	banner *fyne.Container // 窗口顶部的提示横幅，如弱密钥警告
 }
 
+var _ menu.MenuActions = (*AppUI)(nil)
+
 // validateSelection checks if a valid item is selected
 func (ui *AppUI) validateSelection() bool {
 	return ui.selectedIndex >= 0 && ui.selectedIndex < len(ui.items) && ui.selectedName != ""
 }
 
-// NewAppUI creates a new AppUI instance
-func NewAppUI(fileManager *dir.FileManager, logger *slog.Logger) *AppUI {
-	app := app.New()
-	window := app.NewWindow("File Encrypt & Remote Storage")
-	window.Resize(fyne.NewSize(DefaultWindowWidth, DefaultWindowHeight))
-	window.CenterOnScreen()
+// selectedEntries returns the directory entries of every selected item in list order
+func (ui *AppUI) selectedEntries() []dir.Entry {
+	var entries []dir.Entry
+	for _, i := range ui.selection.indices() {
+		if i < len(ui.visible) {
+			entries = append(entries, ui.entries[ui.visible[i]])
+		}
+	}
+	return entries
+}
 
-	ui := &AppUI{
-		app:           app,
-		window:        window,
-		fileManager:   fileManager,
-		logger:        logger,
-		selectedIndex: -1,
-		currentDir:    fileManager.GetWorkingDir(), // 初始化为workingDir
+// clearSelection deselects every item
+func (ui *AppUI) clearSelection() {
+	ui.selectedIndex = -1
+	ui.selectedName = ""
+	ui.selection.clear()
+	if ui.rightClickableList != nil {
+		ui.rightClickableList.UnselectAll()
+		ui.rightClickableList.Refresh()
 	}
+}
 
-	ui.setupUI()
-	return ui
+// selectItem makes i the only selected item
+func (ui *AppUI) selectItem(i int) {
+	ui.selectedIndex = i
+	ui.selectedName = ui.items[i]
+	ui.selection.selectOnly(i)
+	ui.rightClickableList.Refresh()
+}
+
+// toggleItem adds i to or removes it from the selection
+func (ui *AppUI) toggleItem(i int) {
+	if ui.selection.toggle(i) {
+		ui.selectedIndex = i
+		ui.selectedName = ui.items[i]
+	} else if ui.selectedIndex == i {
+		ui.selectedIndex = -1
+		ui.selectedName = ""
+	}
+	ui.rightClickableList.Refresh()
+}
+
+// selectedEntry returns the directory entry of the selected item
+func (ui *AppUI) selectedEntry() (dir.Entry, bool) {
+	if !ui.validateSelection() || ui.selectedIndex >= len(ui.visible) {
+		return dir.Entry{}, false
+	}
+	return ui.entries[ui.visible[ui.selectedIndex]], true
+}
+
+// NewAppUI creates a new AppUI instance
+func NewAppUI(fileManager *dir.FileManager, logger *slog.Logger) *AppUI {
+	return newAppUI(app.NewWithID(AppID), fileManager, logger, nil, nil)
 }
 
 // NewAppUIWithLogWidget creates a new AppUI instance with a pre-created log widget
 func NewAppUIWithLogWidget(fileManager *dir.FileManager, logger *slog.Logger, logWidget *widget.TextGrid) *AppUI {
-	app := app.New()
-	window := app.NewWindow("File Encrypt & Remote Storage")
-	window.Resize(fyne.NewSize(DefaultWindowWidth, DefaultWindowHeight))
-	window.CenterOnScreen()
+	return newAppUI(app.NewWithID(AppID), fileManager, logger, logWidget, nil)
+}
+
+// NewAppUIWithLogHandler creates a new AppUI instance that displays the logs
+// of the given handler and lets the user change its display level
+func NewAppUIWithLogHandler(fileManager *dir.FileManager, logger *slog.Logger, logHandler *UILogHandler) *AppUI {
+	return NewAppUIWithApp(app.NewWithID(AppID), fileManager, logger, logHandler)
+}
 
+// NewAppUIWithApp is like NewAppUIWithLogHandler but uses an existing app, so
+// windows shown before the main UI (such as the password prompt) share its event loop
+func NewAppUIWithApp(app fyne.App, fileManager *dir.FileManager, logger *slog.Logger, logHandler *UILogHandler) *AppUI {
+	return newAppUI(app, fileManager, logger, nil, logHandler)
+}
+
+// newAppUI creates the AppUI for every constructor. The log pane shows the
+// widget sink of logHandler, else logWidget, else a new TextGrid; both may be nil.
+func newAppUI(app fyne.App, fileManager *dir.FileManager, logger *slog.Logger, logWidget *widget.TextGrid, logHandler *UILogHandler) *AppUI {
 	ui := &AppUI{
 		app:           app,
-		window:        window,
+		window:        newMainWindow(app),
 		fileManager:   fileManager,
 		logger:        logger,
 		selectedIndex: -1,
-		currentDir:    fileManager.GetWorkingDir(), // 初始化为workingDir
+		selection:     selectionSet{},
+		currentDir:    loadLastDir(app.Preferences(), fileManager.GetWorkingDir()),
+		showHidden:    fileManager.IncludeHidden(),
 		logWidget:     logWidget,
+		logHandler:    logHandler,
+	}
+	if logHandler != nil {
+		// 不是控件的 LogSink（例如写入文件）时日志面板使用默认的 TextGrid
+		if sink, ok := logHandler.Sink().(WidgetSink); ok {
+			ui.logView = sink.CanvasObject()
+		}
 	}
 
 	ui.setupUI()
@@ -104,89 +209,341 @@ func NewAppUIWithLogWidget(fileManager *dir.FileManager, logger *slog.Logger, lo
 // setupUI initializes the user interface
 func (ui *AppUI) setupUI() {
 	// Directory labels
-	workingDirLabel := widget.NewLabel("Working dir: " + ui.fileManager.GetWorkingDir())
-	ui.dirLabel = widget.NewLabel("Current dir: " + ui.currentDir)
+	ui.workingDirLabel = widget.NewLabel("Working dir: " + ui.fileManager.GetWorkingDir())
+	ui.sourceSelect = widget.NewSelect(nil, ui.selectSource)
+	ui.sourceSelect.Hide()
+	ui.breadcrumb = container.NewHBox()
+	ui.refreshBreadcrumb()
 
 	// File list with right-click support
+	ui.sortMode = loadSortMode(ui.app.Preferences())
 	ui.refreshItems()
 	ui.rightClickableList = NewRightClickableList()
 	ui.rightClickableList.OnItemTapped = func(i int) {
-		ui.selectedIndex = i
-		ui.selectedName = ui.items[i]
+		ui.selectItem(i)
 		ui.logger.Debug("left click", slog.String("item", ui.selectedName))
 	}
+	ui.rightClickableList.OnItemToggled = func(i int) {
+		ui.toggleItem(i)
+		ui.logger.Debug("toggle selection", slog.String("item", ui.items[i]), slog.Int("selected", len(ui.selection)))
+	}
 	ui.rightClickableList.OnItemRightClick = func(i int, pos fyne.Position) {
-		ui.selectedIndex = i
-		ui.selectedName = ui.items[i]
+		// 右键点击未选中的项时只选中该项，否则保留多选
+		if !ui.selection[i] {
+			ui.selectItem(i)
+		} else {
+			ui.selectedIndex = i
+			ui.selectedName = ui.items[i]
+		}
 		ui.logger.Debug("right click", slog.String("item", ui.selectedName))
 		ui.showContextMenu(pos)
 	}
+	ui.rightClickableList.IsItemSelected = func(i int) bool {
+		return ui.selection[i]
+	}
+	ui.rightClickableList.OnItemDropped = func(from, to int) {
+		if err := ui.moveEntryInto(from, to); err != nil {
+			dialog.ShowError(err, ui.window)
+		}
+	}
 	ui.rightClickableList.SetItems(ui.items)
 	ui.rightClickableList.Build()
 
 	// Log widget - create only if not already provided
-	if ui.logWidget == nil {
-		ui.logWidget = widget.NewTextGrid()
-		ui.logWidget.SetText("Application Logs\n\nLogs will appear here...\n")
+	if ui.logView == nil {
+		if ui.logWidget == nil {
+			ui.logWidget = widget.NewTextGrid()
+			ui.logWidget.SetText("Application Logs\n\nLogs will appear here...\n")
+		}
+		ui.logView = ui.logWidget
+	}
+	if grid, ok := ui.logView.(*widget.TextGrid); ok {
+		ui.logWidget = grid
 	}
-	logScroll := container.NewScroll(ui.logWidget)
+	logScroll := container.NewScroll(ui.logView)
 	logScroll.SetMinSize(fyne.NewSize(LogPaneMinWidth, LogPaneMinHeight))
+	var logPane fyne.CanvasObject = logScroll
+	if ui.logHandler != nil {
+		logToolbar := container.NewHBox(ui.createLogLevelSelect(), ui.createSaveLogsButton())
+		logPane = container.NewBorder(logToolbar, nil, nil, nil, logScroll)
+	}
+
+	// 工作目录无法访问时禁用的按钮，Refresh 和 Cancel 除外
+	ui.operationWidgets = nil
+	operation := func(w interface {
+		fyne.CanvasObject
+		fyne.Disableable
+	}) fyne.CanvasObject {
+		ui.operationWidgets = append(ui.operationWidgets, w)
+		return w
+	}
 
 	// Navigation buttons
 	navButtons := container.NewHBox(
-		widget.NewButton("Up", ui.goUpDirectory),
-		widget.NewButton("Enter", ui.enterSelectedDirectory),
+		operation(widget.NewButton("Up", ui.goUpDirectory)),
+		operation(widget.NewButton("Enter", ui.enterSelectedDirectory)),
 	)
 
 	// Operation buttons
+	ui.autoSyncCheck = ui.createAutoSyncCheck()
+	ui.scheduleCheck = ui.createScheduledSyncCheck()
 	buttons := container.NewVBox(
 		navButtons,
 		widget.NewSeparator(),
-		ui.createEncryptUploadButton(),
-		ui.createSyncDownloadButton(),
-		ui.createDownloadSpecificButton(),
-		ui.createSyncUploadButton(),
-		ui.createDeleteLocalFileButton(),
-		widget.NewButton("Refresh", ui.refreshList),
+		operation(ui.createEncryptUploadButton()),
+		operation(ui.createSyncDownloadButton()),
+		operation(ui.createDownloadSpecificButton()),
+		operation(widget.NewButton("Browse Remote", ui.showRemoteBrowser)),
+		operation(widget.NewButton("Verify Backup", ui.VerifyBackup)),
+		operation(widget.NewButton("Compare", ui.CompareWithRemote)),
+		operation(ui.createSyncUploadButton()),
+		operation(ui.autoSyncCheck),
+		operation(ui.scheduleCheck),
+		operation(ui.createDeleteLocalFileButton()),
+		operation(widget.NewButton("New Folder", ui.showNewFolderDialog)),
+		widget.NewButton("Refresh", ui.Refresh),
 		ui.createCancelButton(),
 	)
+	ui.setOperationsEnabled(!ui.workingDirUnavailable)
 
 	// Layout - directly use the custom widget
-	dirLabels := container.NewVBox(workingDirLabel, ui.dirLabel)
-	ListPane := container.NewBorder(dirLabels, nil, nil, nil, ui.rightClickableList)
+	ui.searchEntry = widget.NewEntry()
+	ui.searchEntry.SetPlaceHolder("Filter files...")
+	ui.searchEntry.OnChanged = ui.setFilterQuery
+
+	dirLabels := container.NewVBox(ui.sourceSelect, ui.workingDirLabel, ui.breadcrumb, ui.createSortToolbar(), ui.searchEntry)
+	ui.progressBar = widget.NewProgressBarInfinite()
+	ui.progressBar.Stop()
+	ui.progressBar.Hide()
+	ui.operationList = container.NewVBox()
+	ui.operationList.Hide()
+	progressPane := container.NewVBox(ui.operationList, ui.progressBar)
+	ListPane := container.NewBorder(dirLabels, progressPane, nil, nil, ui.rightClickableList)
 
 	// Create main content with file list on left and log on right
-	mainContent := container.NewVSplit(ListPane, logScroll)
+	mainContent := container.NewVSplit(ListPane, logPane)
 	mainContent.SetOffset(ListPaneRatio)
 
-	content := container.NewBorder(nil, nil, buttons, nil, mainContent)
+	ui.banner = container.NewVBox()
+	ui.banner.Hide()
+	content := container.NewBorder(ui.banner, nil, buttons, nil, mainContent)
 	ui.window.SetContent(content)
+	ui.window.SetMainMenu(menu.CreateMainMenu(ui))
+	ui.window.SetOnDropped(ui.handleDrop)
+	ui.window.SetCloseIntercept(ui.requestClose)
+	ui.app.Lifecycle().SetOnEnteredForeground(ui.handleFocus)
+	ui.registerShortcuts()
+}
+
+// logLevelOptions maps the log level select options to slog levels
+var logLevelOptions = []struct {
+	name  string
+	level slog.Level
+}{
+	{"Debug", slog.LevelDebug},
+	{"Info", slog.LevelInfo},
+	{"Warn", slog.LevelWarn},
+	{"Error", slog.LevelError},
+}
+
+// createLogLevelSelect creates the select that filters the log pane by level
+func (ui *AppUI) createLogLevelSelect() fyne.CanvasObject {
+	var names []string
+	for _, option := range logLevelOptions {
+		names = append(names, option.name)
+	}
+
+	levelSelect := widget.NewSelect(names, func(name string) {
+		for _, option := range logLevelOptions {
+			if option.name == name {
+				ui.logHandler.SetLevel(option.level)
+				return
+			}
+		}
+	})
+
+	// 选中与当前显示级别对应的选项（不触发重新渲染）
+	current := ui.logHandler.Level()
+	for _, option := range logLevelOptions {
+		if option.level == current {
+			levelSelect.Selected = option.name
+			break
+		}
+	}
+
+	return container.NewHBox(widget.NewLabel("Log level:"), levelSelect)
+}
+
+// createSaveLogsButton creates the button that writes the buffered logs to a file
+func (ui *AppUI) createSaveLogsButton() *widget.Button {
+	return widget.NewButton("Save Logs", ui.SaveLogs)
+}
+
+// SaveLogs asks for a file and writes the buffered logs to it
+func (ui *AppUI) SaveLogs() {
+	if ui.logHandler == nil || len(ui.logHandler.Snapshot()) == 0 {
+		dialog.ShowInformation("Info", "There are no logs to save", ui.window)
+		return
+	}
+
+	saveDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("failed to open log file: %w", err), ui.window)
+			return
+		}
+		if writer == nil {
+			// 用户取消
+			return
+		}
+
+		_, writeErr := ui.logHandler.WriteTo(writer)
+		closeErr := writer.Close()
+		if writeErr == nil {
+			writeErr = closeErr
+		}
+		if writeErr != nil {
+			dialog.ShowError(fmt.Errorf("failed to save logs: %w", writeErr), ui.window)
+			return
+		}
+
+		ui.logger.Info("Logs saved", slog.String("path", writer.URI().Path()))
+	}, ui.window)
+	saveDialog.SetFileName("fers.log")
+	saveDialog.Show()
+}
+
+// createSortToolbar creates the controls that choose how the file list is sorted
+func (ui *AppUI) createSortToolbar() fyne.CanvasObject {
+	fieldSelect := widget.NewSelect(sortFieldNames, func(name string) {
+		for i, fieldName := range sortFieldNames {
+			if fieldName == name {
+				ui.setSortMode(SortMode{Field: SortField(i), Descending: ui.sortMode.Descending, DirsFirst: ui.sortMode.DirsFirst})
+				return
+			}
+		}
+	})
+	// 直接设置初始值，避免构建界面时触发刷新
+	fieldSelect.Selected = ui.sortMode.Field.String()
+
+	descendingCheck := widget.NewCheck("Descending", func(checked bool) {
+		ui.setSortMode(SortMode{Field: ui.sortMode.Field, Descending: checked, DirsFirst: ui.sortMode.DirsFirst})
+	})
+	descendingCheck.Checked = ui.sortMode.Descending
+
+	dirsFirstCheck := widget.NewCheck("Dirs first", func(checked bool) {
+		ui.setSortMode(SortMode{Field: ui.sortMode.Field, Descending: ui.sortMode.Descending, DirsFirst: checked})
+	})
+	dirsFirstCheck.Checked = ui.sortMode.DirsFirst
+
+	showHiddenCheck := widget.NewCheck("Show hidden", ui.setShowHidden)
+	showHiddenCheck.Checked = ui.showHidden
+
+	return container.NewHBox(widget.NewLabel("Sort by:"), fieldSelect, descendingCheck, dirsFirstCheck, showHiddenCheck)
+}
+
+// setShowHidden toggles listing hidden files and refreshes the file list
+func (ui *AppUI) setShowHidden(show bool) {
+	if show == ui.showHidden {
+		return
+	}
+	ui.showHidden = show
+	ui.refreshList()
+}
+
+// setSortMode changes and persists the sort mode, then re-sorts the file list
+func (ui *AppUI) setSortMode(mode SortMode) {
+	if mode == ui.sortMode {
+		return
+	}
+	ui.sortMode = mode
+	saveSortMode(ui.app.Preferences(), mode)
+	ui.refreshList()
 }
 
 // refreshItems updates the items list
 func (ui *AppUI) refreshItems() {
-	ui.items = dir.List(ui.currentDir)
+	if !ui.checkWorkingDir() {
+		// 已提示过工作目录无法访问，不再逐个目录报错
+		ui.entries = nil
+		ui.applyFilter()
+		return
+	}
+	entries, err := dir.ListEntriesWith(ui.currentDir, dir.ListOptions{IncludeHidden: ui.showHidden})
+	if err != nil {
+		// 例如当前目录被外部删除或没有读取权限，显示为空列表并提示用户
+		ui.logger.Error("Failed to list directory", slog.String("dir", ui.currentDir), slog.String("error", err.Error()))
+		dialog.ShowError(fmt.Errorf("failed to list %s: %w", ui.currentDir, err), ui.window)
+	}
+	sortEntries(entries, ui.sortMode)
+	ui.entries = entries
+	ui.applyFilter()
+}
+
+// applyFilter rebuilds the displayed items from entries using the filter query
+func (ui *AppUI) applyFilter() {
+	ui.visible = filterEntries(ui.entries, ui.filterQuery)
+	ui.items = make([]string, 0, len(ui.visible))
+	for _, i := range ui.visible {
+		ui.items = append(ui.items, ui.entries[i].Name)
+	}
+}
+
+// setFilterQuery filters the file list without re-reading the directory
+func (ui *AppUI) setFilterQuery(query string) {
+	ui.filterQuery = query
+	ui.applyFilter()
+	if ui.rightClickableList != nil {
+		ui.rightClickableList.SetItems(ui.items)
+	}
+	ui.clearSelection()
+}
+
+// Refresh reloads the file list of the current directory
+func (ui *AppUI) Refresh() {
+	ui.refreshList()
+}
+
+// changeDir switches the list to path and remembers it for the next launch
+func (ui *AppUI) changeDir(path string) {
+	ui.currentDir = path
+	saveLastDir(ui.app.Preferences(), path)
+	ui.reloadList()
 }
 
-// refreshList refreshes the UI list
+// refreshList refreshes the UI list; the selection is cleared, or with
+// keep_selection_after_op kept for the items that are still present
 func (ui *AppUI) refreshList() {
+	if ui.fileManager.KeepSelectionAfterOp() {
+		ui.refreshPreservingSelection()
+		return
+	}
+	ui.reloadList()
+}
+
+// reloadList re-reads the current directory into the list and clears the selection
+func (ui *AppUI) reloadList() {
 	ui.refreshItems()
+	ui.refreshBreadcrumb()
 	if ui.rightClickableList != nil {
 		ui.rightClickableList.SetItems(ui.items)
-		ui.rightClickableList.Refresh()
-		// 清除选择状态
-		ui.rightClickableList.UnselectAll()
 	}
-	ui.selectedIndex = -1
-	ui.selectedName = ""
+	// 清除选择状态
+	ui.clearSelection()
 }
 
 func (ui *AppUI) showContextMenu(pos fyne.Position) {
 	if ui.selectedIndex < 0 || ui.selectedIndex >= len(ui.items) {
 		return
 	}
-	menu := fyne.NewMenu("", fyne.NewMenuItem("open in files", ui.openSelectedInFileManager))
-	popup := widget.NewPopUpMenu(menu, ui.window.Canvas())
+	contextMenu := fyne.NewMenu("",
+		fyne.NewMenuItem("open in files", ui.openSelectedInFileManager),
+		fyne.NewMenuItem("copy path", func() { ui.copySelectedPath(false) }),
+		fyne.NewMenuItem("copy relative path", func() { ui.copySelectedPath(true) }),
+		fyne.NewMenuItem("rename", ui.showRenameDialog),
+		fyne.NewMenuItem("new folder", ui.showNewFolderDialog),
+		fyne.NewMenuItem("delete folder", ui.showDeleteFolderDialog),
+	)
+	popup := widget.NewPopUpMenu(contextMenu, ui.window.Canvas())
 	popup.ShowAtPosition(pos)
 }
 
@@ -210,33 +567,54 @@ func (ui *AppUI) goUpDirectory() {
 		parentDir = cleanWorkingDir
 	}
 
-	ui.currentDir = parentDir
-	ui.dirLabel.SetText(fmt.Sprintf("Current dir: %s", ui.currentDir))
-	ui.refreshList()
+	ui.changeDir(parentDir)
+}
+
+// navigateTo jumps directly to an ancestor or descendant directory inside workingDir
+func (ui *AppUI) navigateTo(path string) {
+	cleanPath := filepath.Clean(path)
+	cleanWorkingDir := filepath.Clean(ui.fileManager.GetWorkingDir())
+
+	// 使用相对路径检查是否在workingDir范围内
+	relPath, err := filepath.Rel(cleanWorkingDir, cleanPath)
+	if err != nil || strings.HasPrefix(relPath, "..") {
+		dialog.ShowInformation("Info", "Cannot navigate outside working directory", ui.window)
+		return
+	}
+
+	ui.changeDir(cleanPath)
+}
+
+// refreshBreadcrumb rebuilds the breadcrumb for the current directory
+func (ui *AppUI) refreshBreadcrumb() {
+	if ui.breadcrumb == nil {
+		return
+	}
+	segments := breadcrumbSegments(ui.fileManager.GetWorkingDir(), ui.currentDir)
+	ui.breadcrumb.Objects = []fyne.CanvasObject{
+		widget.NewLabel("Current dir:"),
+		newBreadcrumb(segments, ui.navigateTo),
+	}
+	ui.breadcrumb.Refresh()
 }
 
 // enterSelectedDirectory enters the selected directory
 func (ui *AppUI) enterSelectedDirectory() {
-	if ui.selectedIndex < 0 || ui.selectedIndex >= len(ui.items) {
+	entry, ok := ui.selectedEntry()
+	if !ok {
 		dialog.ShowInformation("Info", "Please select a directory first", ui.window)
 		return
 	}
 
-	ui.enterDirectory(ui.selectedName)
+	ui.enterDirectory(entry)
 }
 
 // enterDirectory enters the specified directory
-func (ui *AppUI) enterDirectory(dirName string) {
-	fullPath := filepath.Join(ui.currentDir, dirName)
+func (ui *AppUI) enterDirectory(entry dir.Entry) {
+	fullPath := filepath.Join(ui.currentDir, entry.Name)
 
 	// 检查是否是目录
-	info, err := os.Stat(fullPath)
-	if err != nil {
-		dialog.ShowError(fmt.Errorf("failed to access %s: %w", dirName, err), ui.window)
-		return
-	}
-
-	if !info.IsDir() {
+	if !entry.IsDir {
 		dialog.ShowInformation("Info", "Selected item is not a directory", ui.window)
 		return
 	}
@@ -252,172 +630,795 @@ func (ui *AppUI) enterDirectory(dirName string) {
 		return
 	}
 
-	ui.currentDir = cleanFullPath
-	ui.dirLabel.SetText("Current dir: " + ui.currentDir)
-	ui.refreshList()
-	ui.selectedIndex = -1
-	ui.selectedName = ""
+	ui.changeDir(cleanFullPath)
 }
 
-// createEncryptUploadButton creates the encrypt and upload button
-func (ui *AppUI) createEncryptUploadButton() *widget.Button {
-	return widget.NewButton("Encrypt & Upload", func() {
-		// 检查是否有选中的项目
-		if !ui.validateSelection() {
-			dialog.ShowInformation("Info", "Please select a file or directory first", ui.window)
-			return
+// validateEntryName checks that name can be used for a new entry in the current directory
+func (ui *AppUI) validateEntryName(name string) error {
+	if name == "" {
+		return fmt.Errorf("name cannot be empty")
+	}
+	if name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid name: %s", name)
+	}
+	for _, entry := range ui.entries {
+		if entry.Name == name {
+			return fmt.Errorf("%s already exists", name)
 		}
+	}
+	return nil
+}
 
-		ui.runOperation("Encrypt & Upload", func(ctx context.Context) error {
-			name := ui.selectedName
-			// 使用当前目录的完整路径
-			fullPath := filepath.Join(ui.currentDir, name)
+// showNewFolderDialog prompts for a name and creates the folder in the current directory
+func (ui *AppUI) showNewFolderDialog() {
+	dialog.ShowEntryDialog("New Folder", "Folder name:", func(name string) {
+		if err := ui.createFolder(strings.TrimSpace(name)); err != nil {
+			dialog.ShowError(err, ui.window)
+		}
+	}, ui.window)
+}
 
-			info, err := os.Stat(fullPath)
-			if err != nil {
-				return fmt.Errorf("failed to stat file %s: %w", fullPath, err)
-			}
+// createFolder creates a folder named name in the current directory
+func (ui *AppUI) createFolder(name string) error {
+	if err := ui.validateEntryName(name); err != nil {
+		return err
+	}
 
-			// 计算相对于workingDir的路径
-			relativePath, err := filepath.Rel(ui.fileManager.GetWorkingDir(), fullPath)
-			if err != nil {
-				return fmt.Errorf("failed to get relative path for %s: %w", fullPath, err)
-			}
+	// 清理路径并确保不会超出workingDir的范围
+	cleanFullPath := filepath.Clean(filepath.Join(ui.currentDir, name))
+	cleanWorkingDir := filepath.Clean(ui.fileManager.GetWorkingDir())
 
-			if info.IsDir() {
-				return ui.fileManager.EncryptAndUploadDirectory(ctx, fullPath)
-			} else {
-				return ui.fileManager.EncryptAndUploadFile(fullPath, relativePath)
-			}
-		})
-	})
+	relPath, err := filepath.Rel(cleanWorkingDir, cleanFullPath)
+	if err != nil || strings.HasPrefix(relPath, "..") {
+		return fmt.Errorf("cannot create folder outside working directory")
+	}
+
+	if err := ui.fileManager.CreateLocalDirectory(relPath); err != nil {
+		return err
+	}
+	ui.refreshList()
+	return nil
 }
 
-// createSyncDownloadButton creates the sync download button
-func (ui *AppUI) createSyncDownloadButton() *widget.Button {
-	return widget.NewButton("Sync Download", func() {
-		ui.runOperation("Sync Download", func(ctx context.Context) error {
-			err := ui.fileManager.SyncDownload(ctx)
-			if err == nil {
-				ui.refreshList()
+// showRenameDialog prompts for a new name for the selected entry
+func (ui *AppUI) showRenameDialog() {
+	entry, ok := ui.selectedEntry()
+	if !ok {
+		dialog.ShowInformation("Info", "Please select a file or directory first", ui.window)
+		return
+	}
+
+	nameEntry := widget.NewEntry()
+	nameEntry.SetText(entry.Name)
+	dialog.ShowForm("Rename", "Rename", "Cancel",
+		[]*widget.FormItem{widget.NewFormItem("New name", nameEntry)},
+		func(confirmed bool) {
+			if !confirmed {
+				return
 			}
-			return err
-		})
-	})
+			if err := ui.renameEntry(entry, strings.TrimSpace(nameEntry.Text)); err != nil {
+				dialog.ShowError(err, ui.window)
+			}
+		}, ui.window)
 }
 
-// createDownloadSpecificButton creates the download specific file button
-func (ui *AppUI) createDownloadSpecificButton() *widget.Button {
-	return widget.NewButton("Download Specific", func() {
-		ui.showRemoteFileDialog()
-	})
-}
+// renameEntry renames entry in the current directory to newName
+func (ui *AppUI) renameEntry(entry dir.Entry, newName string) error {
+	if newName == entry.Name {
+		return nil
+	}
+	if err := ui.validateEntryName(newName); err != nil {
+		return err
+	}
 
-// createDeleteLocalFileButton creates the delete local file button
-func (ui *AppUI) createDeleteLocalFileButton() *widget.Button {
-	return widget.NewButton("Delete Local File", func() {
-		// 检查是否有选中的项目
-		if !ui.validateSelection() {
-			dialog.ShowInformation("Info", "Please select a file first", ui.window)
-			return
-		}
+	workingDir := ui.fileManager.GetWorkingDir()
+	oldRelative, err := filepath.Rel(workingDir, filepath.Join(ui.currentDir, entry.Name))
+	if err != nil {
+		return fmt.Errorf("failed to get relative path: %w", err)
+	}
+	newPath := filepath.Join(ui.currentDir, newName)
+	newRelative, err := filepath.Rel(workingDir, newPath)
+	if err != nil {
+		return fmt.Errorf("failed to get relative path: %w", err)
+	}
 
-		name := ui.selectedName
-		fullPath := filepath.Join(ui.currentDir, name)
+	if err := ui.fileManager.RenameLocalEntry(oldRelative, newRelative); err != nil {
+		return err
+	}
+	ui.refreshList()
+	ui.uploadRenamed(newPath)
+	return nil
+}
 
-		// 检查是否是文件
-		info, err := os.Stat(fullPath)
+// uploadRenamed uploads a renamed or moved entry under its new path when
+// upload_on_rename is set; the remote files under the old path are kept
+func (ui *AppUI) uploadRenamed(newPath string) {
+	if !ui.fileManager.UploadOnRename() {
+		return
+	}
+	ui.runOperation("Upload Renamed", func(ctx context.Context) error {
+		result, err := ui.fileManager.EncryptAndUploadPaths(ctx, []string{newPath}, nil)
 		if err != nil {
-			dialog.ShowError(fmt.Errorf("failed to access %s: %w", name, err), ui.window)
-			return
-		}
-
-		if info.IsDir() {
-			dialog.ShowInformation("Info", "Please select a file, not a directory", ui.window)
-			return
+			return err
 		}
-
-		// 计算相对路径
-		relativePath, err := filepath.Rel(ui.fileManager.GetWorkingDir(), fullPath)
-		if err != nil {
-			dialog.ShowError(fmt.Errorf("failed to get relative path: %w", err), ui.window)
-			return
+		if len(result.Failed) > 0 {
+			return result.Failed[0].Err
 		}
-
-		// 确认删除
-		dialog.ShowConfirm("Confirm Delete",
-			fmt.Sprintf("Are you sure you want to delete the local file: %s?", relativePath),
-			func(confirmed bool) {
-				if confirmed {
-					if err := ui.fileManager.DeleteLocalFile(relativePath); err != nil {
-						dialog.ShowError(err, ui.window)
-					} else {
-						ui.refreshList()
-						dialog.ShowInformation("Success", "File deleted successfully", ui.window)
-					}
-				}
-			}, ui.window)
+		return nil
 	})
 }
 
-// createSyncUploadButton creates the sync upload button
-func (ui *AppUI) createSyncUploadButton() *widget.Button {
-	return widget.NewButton("Sync Upload", func() {
-		ui.runOperation("Sync Upload", func(ctx context.Context) error {
-			return ui.fileManager.SyncUpload(ctx)
-		})
-	})
-}
+// errMoveOntoFile rejects dropping an item onto a file instead of a folder
+var errMoveOntoFile = errors.New("items can only be moved into a folder")
 
-// createCancelButton creates the cancel operation button
-func (ui *AppUI) createCancelButton() *widget.Button {
-	return widget.NewButton("Cancel Operation", func() {
-		ui.operationMutex.Lock()
-		defer ui.operationMutex.Unlock()
+// movePaths returns the working-dir relative paths for moving entry from
+// currentDir into its sibling folder target
+func movePaths(workingDir, currentDir string, entry, target dir.Entry) (oldRelative, newRelative string, err error) {
+	if !target.IsDir {
+		return "", "", errMoveOntoFile
+	}
+	oldPath := filepath.Join(currentDir, entry.Name)
+	newPath := filepath.Join(currentDir, target.Name, entry.Name)
+	// 不能把文件夹移动到它自己或它的子目录中
+	if inside, err := filepath.Rel(oldPath, filepath.Dir(newPath)); err == nil && !strings.HasPrefix(inside, "..") {
+		return "", "", fmt.Errorf("cannot move %s into itself", entry.Name)
+	}
 
-		if ui.cancelFunc != nil {
-			ui.cancelFunc()
-			ui.logger.Info("Operation cancelled by user")
-		}
-	})
+	oldRelative, err = filepath.Rel(workingDir, oldPath)
+	if err != nil || strings.HasPrefix(oldRelative, "..") {
+		return "", "", fmt.Errorf("%s is outside the working directory", entry.Name)
+	}
+	newRelative, err = filepath.Rel(workingDir, newPath)
+	if err != nil || strings.HasPrefix(newRelative, "..") {
+		return "", "", fmt.Errorf("%s is outside the working directory", target.Name)
+	}
+	return oldRelative, newRelative, nil
 }
 
-// runOperation runs a long-running operation with proper error handling and cancellation
-func (ui *AppUI) runOperation(operationName string, operation func(context.Context) error) {
-	ui.operationMutex.Lock()
-	defer ui.operationMutex.Unlock()
-
-	// Cancel any existing operation
-	if ui.cancelFunc != nil {
-		ui.cancelFunc()
+// moveEntryInto moves the item at index from into the folder at index to,
+// as when one list item is dragged onto another
+func (ui *AppUI) moveEntryInto(from, to int) error {
+	if from < 0 || from >= len(ui.visible) || to < 0 || to >= len(ui.visible) {
+		return nil
+	}
+	entry, target := ui.entries[ui.visible[from]], ui.entries[ui.visible[to]]
+	oldRelative, newRelative, err := movePaths(ui.fileManager.GetWorkingDir(), ui.currentDir, entry, target)
+	if err != nil {
+		return err
+	}
+	if err := ui.fileManager.RenameLocalEntry(oldRelative, newRelative); err != nil {
+		return err
 	}
+	ui.reloadList()
+	ui.uploadRenamed(filepath.Join(ui.fileManager.GetWorkingDir(), newRelative))
+	return nil
+}
 
-	ctx, cancel := context.WithCancel(context.Background())
-	ui.cancelFunc = cancel
+// createEncryptUploadButton creates the encrypt and upload button
+func (ui *AppUI) createEncryptUploadButton() *widget.Button {
+	return widget.NewButton("Encrypt & Upload", ui.encryptAndUploadSelected)
+}
 
-	go func() {
-		defer func() {
-			ui.operationMutex.Lock()
-			ui.cancelFunc = nil
-			ui.operationMutex.Unlock()
-		}()
+// encryptAndUploadSelected uploads the selected items. A single file is
+// uploaded directly; selections containing a directory first confirm how
+// many files the upload will include.
+func (ui *AppUI) encryptAndUploadSelected() {
+	// 检查是否有选中的项目
+	entries := ui.selectedEntries()
+	if len(entries) == 0 {
+		dialog.ShowInformation("Info", "Please select a file or directory first", ui.window)
+		return
+	}
 
-		ui.logger.Info("Starting operation", slog.String("operation", operationName))
+	// 使用当前目录的完整路径
+	var paths []string
+	hasDir := false
+	for _, entry := range entries {
+		paths = append(paths, filepath.Join(ui.currentDir, entry.Name))
+		hasDir = hasDir || entry.IsDir
+	}
+
+	if !hasDir {
+		if len(paths) == 1 {
+			ui.uploadPaths(paths)
+			return
+		}
+		ui.confirmUpload(fmt.Sprintf("Encrypt and upload %d selected items?", len(paths)), paths)
+		return
+	}
+
+	// 目录会被递归上传，先统计实际会上传的文件数
+	count, err := ui.countUploadableFiles(paths)
+	if err != nil {
+		ui.logger.Error("Failed to count files to upload", slog.String("error", err.Error()))
+		dialog.ShowError(err, ui.window)
+		return
+	}
+	ui.confirmUpload(fmt.Sprintf("Encrypt and upload %d file(s) from %d selected item(s)?", count, len(paths)), paths)
+}
+
+// countUploadableFiles returns how many files uploading paths would upload
+func (ui *AppUI) countUploadableFiles(paths []string) (int, error) {
+	total := 0
+	for _, path := range paths {
+		relativePath, err := filepath.Rel(ui.fileManager.GetWorkingDir(), path)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get relative path for %s: %w", path, err)
+		}
+		count, err := ui.fileManager.CountUploadableFiles(relativePath)
+		if err != nil {
+			return 0, err
+		}
+		total += count
+	}
+	return total, nil
+}
+
+// confirmUpload uploads paths once the user confirms message
+func (ui *AppUI) confirmUpload(message string, paths []string) {
+	dialog.ShowConfirm("Confirm Upload", message, func(confirmed bool) {
+		if confirmed {
+			ui.uploadPaths(paths)
+		}
+	}, ui.window)
+}
+
+// createSyncDownloadButton creates the sync download button
+func (ui *AppUI) createSyncDownloadButton() *widget.Button {
+	return widget.NewButton("Sync Download", ui.SyncDownload)
+}
+
+// SyncDownload downloads the remote files missing locally after confirmation
+func (ui *AppUI) SyncDownload() {
+	ui.confirmSync("Sync Download", "download %d file(s) from remote storage",
+		ui.fileManager.PlanSyncDownload, ui.runSyncDownload)
+}
+
+// runSyncDownload runs Sync Download without asking
+func (ui *AppUI) runSyncDownload() {
+	ui.runOperation("Sync Download", func(ctx context.Context) error {
+		result, err := ui.fileManager.SyncDownload(ctx)
+		if err != nil {
+			return err
+		}
+		fyne.Do(ui.refreshList)
+		if len(result.Failed) > 0 {
+			ui.showBatchSummary("Sync Download", result.Total(), result, nil)
+		}
+		return batchError(result)
+	})
+}
+
+// Heal restores local files that no longer match the manifest hash from
+// their remote copies, after asking
+func (ui *AppUI) Heal() {
+	ui.confirmSync("Heal", "restore %d corrupted file(s) from remote storage",
+		ui.fileManager.PlanHeal, ui.runHeal)
+}
+
+// runHeal runs Heal without asking
+func (ui *AppUI) runHeal() {
+	ui.runOperation("Heal", func(ctx context.Context) error {
+		ui.setProgress(ctx, "Checking local files against the manifest...")
+		result, err := ui.fileManager.Heal(ctx)
+		if err != nil {
+			return err
+		}
+		fyne.Do(ui.refreshList)
+		ui.showBatchSummary("Heal", result.Total(), result, nil)
+		return batchError(result)
+	})
+}
+
+// RetryQuarantined transfers the files quarantined after repeated sync failures again after confirmation
+func (ui *AppUI) RetryQuarantined() {
+	ui.confirmSync("Retry Quarantined", "retry %d quarantined file(s)",
+		ui.quarantinedPaths, ui.runRetryQuarantined)
+}
+
+// quarantinedPaths returns the paths of the quarantined files
+func (ui *AppUI) quarantinedPaths(context.Context) ([]string, error) {
+	entries, err := ui.fileManager.Quarantine()
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, entry := range entries {
+		if entry.Quarantined {
+			paths = append(paths, entry.Path)
+		}
+	}
+	return paths, nil
+}
+
+// runRetryQuarantined runs Retry Quarantined without asking
+func (ui *AppUI) runRetryQuarantined() {
+	ui.runOperation("Retry Quarantined", func(ctx context.Context) error {
+		result, err := ui.fileManager.RetryQuarantined(ctx)
+		if err != nil {
+			return err
+		}
+		fyne.Do(ui.refreshList)
+		ui.showBatchSummary("Retry Quarantined", result.Total(), result, nil)
+		return batchError(result)
+	})
+}
+
+// uploadPaths encrypts and uploads local files and directories; a single
+// path reports its error directly, several paths get a batch summary
+func (ui *AppUI) uploadPaths(paths []string) {
+	ui.runOperation("Encrypt & Upload", func(ctx context.Context) error {
+		result, err := ui.fileManager.EncryptAndUploadPaths(ctx, paths, func(done, total int, current string) {
+			if current != "" {
+				ui.setProgress(ctx, fmt.Sprintf("Uploading %d/%d: %s", done+1, total, filepath.Base(current)))
+			}
+		})
+		if err != nil {
+			return err
+		}
+		if len(paths) == 1 {
+			// 单个文件或目录时直接报告错误
+			if len(result.Failed) > 0 {
+				return result.Failed[0].Err
+			}
+			return nil
+		}
+		ui.showBatchSummary("Upload", len(paths), result, nil)
+		return batchError(result)
+	})
+}
+
+// createDownloadSpecificButton creates the download specific file button
+func (ui *AppUI) createDownloadSpecificButton() *widget.Button {
+	return widget.NewButton("Download Specific", func() {
+		ui.showRemoteFileDialog()
+	})
+}
+
+// createDeleteLocalFileButton creates the delete local file button
+func (ui *AppUI) createDeleteLocalFileButton() *widget.Button {
+	return widget.NewButton("Delete Local File", ui.deleteSelectedLocalFiles)
+}
+
+// deleteSelectedLocalFiles asks for confirmation and deletes the selected local files
+func (ui *AppUI) deleteSelectedLocalFiles() {
+	// 检查是否有选中的项目
+	entries := ui.selectedEntries()
+	if len(entries) == 0 {
+		dialog.ShowInformation("Info", "Please select a file first", ui.window)
+		return
+	}
+
+	var relativePaths []string
+	for _, entry := range entries {
+		// 检查是否是文件
+		if entry.IsDir {
+			dialog.ShowInformation("Info", "Please select files only, not directories", ui.window)
+			return
+		}
+
+		// 计算相对路径
+		fullPath := filepath.Join(ui.currentDir, entry.Name)
+		relativePath, err := filepath.Rel(ui.fileManager.GetWorkingDir(), fullPath)
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("failed to get relative path: %w", err), ui.window)
+			return
+		}
+		relativePaths = append(relativePaths, relativePath)
+	}
+
+	message := fmt.Sprintf("Are you sure you want to delete the local file: %s?", relativePaths[0])
+	if len(relativePaths) > 1 {
+		message = fmt.Sprintf("Are you sure you want to delete %d local files?\n\n%s",
+			len(relativePaths), strings.Join(relativePaths, "\n"))
+	}
+
+	// 确认删除
+	dialog.ShowConfirm("Confirm Delete", message,
+		func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			var failed []string
+			for _, relativePath := range relativePaths {
+				if err := ui.fileManager.DeleteLocalFile(relativePath); err != nil {
+					ui.logger.Error("Failed to delete file", slog.String("path", relativePath), slog.String("error", err.Error()))
+					failed = append(failed, relativePath)
+				}
+			}
+			ui.refreshList()
+			if len(failed) > 0 {
+				dialog.ShowError(fmt.Errorf("failed to delete %d of %d files:\n%s",
+					len(failed), len(relativePaths), strings.Join(failed, "\n")), ui.window)
+				return
+			}
+			if len(relativePaths) == 1 {
+				dialog.ShowInformation("Success", "File deleted successfully", ui.window)
+			} else {
+				dialog.ShowInformation("Success", fmt.Sprintf("%d files deleted successfully", len(relativePaths)), ui.window)
+			}
+		}, ui.window)
+}
+
+// showDeleteFolderDialog asks to delete the selected directory and everything under it
+func (ui *AppUI) showDeleteFolderDialog() {
+	entry, ok := ui.selectedEntry()
+	if !ok || !entry.IsDir {
+		dialog.ShowInformation("Info", "Please select a directory first", ui.window)
+		return
+	}
+	relativePath, err := filepath.Rel(ui.fileManager.GetWorkingDir(), filepath.Join(ui.currentDir, entry.Name))
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("failed to get relative path: %w", err), ui.window)
+		return
+	}
+	files, size, err := ui.fileManager.DirectoryUsage(relativePath)
+	if err != nil {
+		dialog.ShowError(err, ui.window)
+		return
+	}
+
+	message := fmt.Sprintf("Are you sure you want to delete the folder %s and everything in it?\n\n%d files, %s will be removed.",
+		relativePath, files, formatSize(size))
+	dialog.ShowConfirm("Confirm Delete Folder", message, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		if err := ui.deleteFolder(relativePath); err != nil {
+			dialog.ShowError(err, ui.window)
+		}
+	}, ui.window)
+}
+
+// deleteFolder recursively deletes relativePath, then clears the selection and reloads the list
+func (ui *AppUI) deleteFolder(relativePath string) error {
+	if err := ui.fileManager.DeleteLocalDirectory(relativePath); err != nil {
+		ui.logger.Error("Failed to delete directory", slog.String("path", relativePath), slog.String("error", err.Error()))
+		return err
+	}
+	ui.clearSelection()
+	ui.reloadList()
+	return nil
+}
+
+// createSyncUploadButton creates the sync upload button
+func (ui *AppUI) createSyncUploadButton() *widget.Button {
+	return widget.NewButton("Sync Upload", ui.SyncUpload)
+}
+
+// createAutoSyncCheck creates the toggle that uploads local changes in the background
+func (ui *AppUI) createAutoSyncCheck() *widget.Check {
+	check := widget.NewCheck("Auto Sync", nil)
+	check.OnChanged = func(checked bool) {
+		if err := ui.setAutoSync(checked); err != nil {
+			dialog.ShowError(err, ui.window)
+			check.SetChecked(false)
+		}
+	}
+	return check
+}
+
+// setAutoSync starts or stops watching the working directory for changes
+func (ui *AppUI) setAutoSync(enabled bool) error {
+	if ui.autoSyncCancel != nil {
+		ui.autoSyncCancel()
+		ui.autoSyncCancel = nil
+	}
+	if !enabled {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := ui.fileManager.StartAutoSync(ctx, AutoSyncDebounce); err != nil {
+		cancel()
+		ui.logger.Error("Failed to start auto sync", slog.String("error", err.Error()))
+		return err
+	}
+	ui.autoSyncCancel = cancel
+	return nil
+}
+
+// createScheduledSyncCheck creates the toggle for periodic syncing; it starts
+// enabled when an interval is configured
+func (ui *AppUI) createScheduledSyncCheck() *widget.Check {
+	interval, mode, _ := ui.fileManager.SyncSchedule()
+	check := widget.NewCheck(fmt.Sprintf("Sync every %s (%s)", interval, mode), nil)
+	check.OnChanged = func(checked bool) {
+		if err := ui.setScheduledSync(checked); err != nil {
+			dialog.ShowError(err, ui.window)
+			check.SetChecked(false)
+		}
+	}
+	if ui.fileManager.ScheduledSyncConfigured() {
+		check.SetChecked(true)
+	}
+	return check
+}
+
+// setScheduledSync starts or stops the periodic sync
+func (ui *AppUI) setScheduledSync(enabled bool) error {
+	if ui.scheduleCancel != nil {
+		ui.scheduleCancel()
+		ui.scheduleCancel = nil
+	}
+	if !enabled {
+		return nil
+	}
+
+	interval, mode, err := ui.fileManager.SyncSchedule()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := ui.fileManager.StartScheduledSync(ctx, interval, mode); err != nil {
+		cancel()
+		ui.logger.Error("Failed to start scheduled sync", slog.String("error", err.Error()))
+		return err
+	}
+	ui.scheduleCancel = cancel
+	return nil
+}
+
+// SyncUpload uploads the local files missing remotely after confirmation; the
+// confirmed plan is uploaded without checking the files again
+func (ui *AppUI) SyncUpload() {
+	var plan *dir.SyncUploadPlan
+	ui.confirmSync("Sync Upload", "upload %d file(s) to remote storage",
+		func(ctx context.Context) ([]string, error) {
+			var err error
+			if plan, err = ui.fileManager.PlanSyncUpload(ctx); err != nil {
+				return nil, err
+			}
+			return plan.Files, nil
+		},
+		func() { ui.runSyncUpload(plan) })
+}
+
+// runSyncUpload runs Sync Upload without asking, uploading plan when it is
+// not nil and planning first otherwise
+func (ui *AppUI) runSyncUpload(plan *dir.SyncUploadPlan) {
+	ui.runOperation("Sync Upload", func(ctx context.Context) error {
+		var result *dir.BatchResult
+		var err error
+		if plan != nil {
+			result, err = ui.fileManager.SyncUploadPlanned(ctx, plan)
+		} else {
+			result, err = ui.fileManager.SyncUpload(ctx)
+		}
+		if err != nil {
+			return err
+		}
+		if len(result.Failed) > 0 {
+			ui.showBatchSummary("Sync Upload", result.Total(), result, nil)
+		}
+		return batchError(result)
+	})
+}
+
+// confirmSync plans a sync in the background and asks the user to confirm the
+// number of files it will transfer before calling run. The prompt is skipped
+// when disabled in the config or for the rest of the session.
+func (ui *AppUI) confirmSync(operationName, actionFormat string, plan func(context.Context) ([]string, error), run func()) {
+	if ui.fileManager.SkipSyncConfirm() || ui.skipSyncConfirm {
+		run()
+		return
+	}
+
+	ui.runOperation("Plan "+operationName, func(ctx context.Context) error {
+		planned, err := plan(ctx)
+		if err != nil {
+			return err
+		}
+		fyne.Do(func() {
+			ui.showSyncConfirm(operationName, fmt.Sprintf(actionFormat, len(planned)), len(planned), run)
+		})
+		return nil
+	})
+}
+
+// showSyncConfirm shows the confirmation dialog for a planned sync
+func (ui *AppUI) showSyncConfirm(operationName, action string, count int, run func()) {
+	if count == 0 {
+		dialog.ShowInformation(operationName, "Nothing to do, everything is in sync", ui.window)
+		return
+	}
+
+	dontAsk := widget.NewCheck("Don't ask again this session", nil)
+	content := container.NewVBox(
+		widget.NewLabel(fmt.Sprintf("%s will %s. Continue?", operationName, action)),
+		dontAsk,
+	)
+	dialog.ShowCustomConfirm("Confirm "+operationName, operationName, "Cancel", content,
+		func(confirmed bool) {
+			if !confirmed {
+				ui.logger.Info("Operation declined by user", slog.String("operation", operationName))
+				return
+			}
+			if dontAsk.Checked {
+				ui.skipSyncConfirm = true
+			}
+			run()
+		}, ui.window)
+}
+
+// createCancelButton creates the button cancelling every running operation
+func (ui *AppUI) createCancelButton() *widget.Button {
+	return widget.NewButton("Cancel All", ui.cancelOperation)
+}
+
+// runOperation runs a long-running operation in the background with proper
+// error handling. Operations run one at a time in the order they were
+// requested: a new one waits in the queue instead of cancelling the running
+// one, and is listed with its own Cancel button while it waits and runs.
+func (ui *AppUI) runOperation(operationName string, operation func(context.Context) error) {
+	ui.enqueueOperation(operationName, func(ctx context.Context) {
+		ui.logger.Info("Starting operation", slog.String("operation", operationName))
+		before := ui.fileManager.Stats()
 
 		if err := operation(ctx); err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				// 操作超时与普通错误区分显示
+				err = dir.ErrTimeout
+			}
+			var partial *partialFailureError
+			if errors.As(err, &partial) {
+				// 失败的文件已在汇总对话框中列出，不再弹出错误框
+				ui.logger.Warn("Operation completed with errors",
+					slog.String("operation", operationName),
+					slog.Int("failed", partial.failed))
+				ui.notify(operationName+" finished", fmt.Sprintf("Completed with %d errors", partial.failed))
+				return
+			}
 			if err == context.Canceled {
 				ui.logger.Info("Operation cancelled", slog.String("operation", operationName))
+			} else if errors.Is(err, dir.ErrTimeout) {
+				ui.logger.Error("Operation timed out",
+					slog.String("operation", operationName),
+					slog.String("error", err.Error()))
+				ui.notify(operationName+" timed out", err.Error())
+				fyne.Do(func() {
+					dialog.ShowError(fmt.Errorf("%s: %w", operationName, err), ui.window)
+				})
 			} else {
 				ui.logger.Error("Operation failed",
 					slog.String("operation", operationName),
 					slog.String("error", err.Error()))
-				dialog.ShowError(err, ui.window)
+				ui.notify(operationName+" failed", err.Error())
+				fyne.Do(func() { showError(err, ui.window) })
 			}
 			return
 		}
 
-		ui.logger.Info("Operation completed successfully", slog.String("operation", operationName))
-	}()
+		attrs := []any{slog.String("operation", operationName)}
+		summary := "Completed successfully"
+		if stats := ui.fileManager.Stats().Sub(before); stats.Files > 0 {
+			// 有文件上传时附带吞吐量，便于判断瓶颈在网络还是 CPU
+			attrs = append(attrs,
+				slog.Int64("files", stats.Files),
+				slog.Int64("bytes", stats.Bytes),
+				slog.String("rate", dir.FormatRate(stats)))
+			summary = fmt.Sprintf("Uploaded %d file(s) at %s", stats.Files, dir.FormatRate(stats))
+		}
+		ui.logger.Info("Operation completed successfully", attrs...)
+		ui.notify(operationName+" finished", summary)
+	})
+}
+
+// notify sends a best-effort OS notification when notifications are enabled;
+// it never blocks the caller
+func (ui *AppUI) notify(title, content string) {
+	if !ui.fileManager.Notifications() {
+		return
+	}
+	send := ui.notifier
+	if send == nil {
+		send = ui.app.SendNotification
+	}
+	go send(fyne.NewNotification(title, content))
+}
+
+// VerifyBackup checks that every remote object decrypts with the current key
+func (ui *AppUI) VerifyBackup() {
+	ui.runOperation("Verify Backup", func(ctx context.Context) error {
+		var badKeys []string
+		checked := 0
+		good, bad, err := ui.fileManager.VerifyRemote(ctx, func(key string, ok bool, err error) {
+			checked++
+			ui.setProgress(ctx, fmt.Sprintf("Verified %d: %s", checked, key))
+			if !ok {
+				badKeys = append(badKeys, key)
+			}
+		})
+		if err != nil {
+			return err
+		}
+
+		message := fmt.Sprintf("%d objects verified: %d good, %d bad.", good+bad, good, bad)
+		if bad > 0 {
+			message += "\n\nObjects that failed to decrypt:\n" + strings.Join(badKeys, "\n")
+		}
+		fyne.Do(func() {
+			dialog.ShowInformation("Verify Backup", message, ui.window)
+		})
+		return nil
+	})
+}
+
+// showBatchSummary shows how many files of a batch succeeded and lists the failed ones;
+// when retry is not nil the dialog offers to run it again for only the failed paths
+func (ui *AppUI) showBatchSummary(operationName string, total int, result *dir.BatchResult, retry func([]string)) {
+	ui.logger.Info("Batch finished",
+		slog.String("operation", operationName),
+		slog.Int("succeeded", len(result.Succeeded)),
+		slog.Int("failed", len(result.Failed)),
+		slog.Int("skipped", len(result.Skipped)),
+		slog.Int("overwritten", len(result.Overwritten)),
+		slog.Int("deleted", len(result.Deleted)))
+
+	message := fmt.Sprintf("%s finished: %d of %d files succeeded.",
+		operationName, len(result.Succeeded), total)
+	if len(result.Failed) > 0 {
+		message = fmt.Sprintf("%s completed with %d errors: %d of %d files succeeded.",
+			operationName, len(result.Failed), len(result.Succeeded), total)
+	}
+	if len(result.Skipped) > 0 || len(result.Overwritten) > 0 {
+		message += fmt.Sprintf("\n%d skipped (already exist locally), %d overwritten.",
+			len(result.Skipped), len(result.Overwritten))
+	}
+	if len(result.Deleted) > 0 {
+		message += fmt.Sprintf("\n%d remote file(s) deleted.", len(result.Deleted))
+	}
+
+	failed := result.FailedPaths()
+	var details []string
+	for _, f := range result.Failed {
+		details = append(details, fmt.Sprintf("%s: %v", f.Path, f.Err))
+	}
+
+	hints := failureHints(result.Failed)
+
+	fyne.Do(func() {
+		content := container.NewVBox(widget.NewLabel(message))
+		for _, hint := range hints {
+			content.Add(widget.NewLabelWithStyle(hint, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+		}
+		if len(details) > 0 {
+			// 错误详情默认折叠，展开后查看每个文件的错误
+			detailsLabel := widget.NewLabel(strings.Join(details, "\n"))
+			detailsLabel.Wrapping = fyne.TextWrapWord
+			content.Add(widget.NewAccordion(widget.NewAccordionItem(
+				fmt.Sprintf("Failed files (%d)", len(details)), detailsLabel)))
+		}
+
+		if retry == nil || len(failed) == 0 {
+			dialog.ShowCustom(operationName+" Summary", "OK", content, ui.window)
+			return
+		}
+		dialog.ShowCustomConfirm(operationName+" Summary", "Retry Failed", "Close", content,
+			func(confirmed bool) {
+				if confirmed {
+					retry(failed)
+				}
+			}, ui.window)
+	})
+}
+
+// partialFailureError is returned by operations that finished but had failed
+// files; the failures are already shown in a batch summary
+type partialFailureError struct {
+	failed int
+}
+
+func (e *partialFailureError) Error() string {
+	return fmt.Sprintf("completed with %d errors", e.failed)
+}
+
+// batchError returns a partialFailureError when some files of result failed
+func batchError(result *dir.BatchResult) error {
+	if len(result.Failed) == 0 {
+		return nil
+	}
+	return &partialFailureError{failed: len(result.Failed)}
 }
 
 // showRemoteFileDialog shows a dialog to select and download remote files
@@ -431,114 +1432,124 @@ func (ui *AppUI) showRemoteFileDialog() {
 	if rel == "." {
 		rel = ""
 	}
-	remoteFiles, err := ui.fileManager.ListRemoteFiles(rel)
-	if err != nil {
-		dialog.ShowError(fmt.Errorf("failed to list remote files: %w", err), ui.window)
+	// 只加载第一页，其余的由 "Load More" 按需加载
+	remoteDialog := newRemoteFileDialog(ui, rel)
+	if err := remoteDialog.loadMore(); err != nil {
+		showError(err, ui.window)
 		return
 	}
 
-	if len(remoteFiles) == 0 {
+	if len(remoteDialog.files) == 0 {
 		dialog.ShowInformation("Info", "No remote files found", ui.window)
 		return
 	}
 
-	// 创建新窗口显示远程文件
-	remoteWindow := ui.app.NewWindow("Remote Files")
-	remoteWindow.Resize(fyne.NewSize(RemoteWindowWidth, RemoteWindowHeight))
-	remoteWindow.CenterOnScreen()
-
-	// 创建多选文件列表
-	selectedFiles := make(map[int]bool)
-	var checkBoxes []*widget.Check
-
-	// 创建滚动容器来容纳复选框列表
-	content := container.NewVBox()
+	remoteDialog.show()
+}
 
-	for i, fileName := range remoteFiles {
-		index := i // 捕获循环变量
-		check := widget.NewCheck(fileName, func(checked bool) {
-			selectedFiles[index] = checked
+// downloadRemoteFiles downloads the given remote files and summarises the result;
+// files that exist locally are skipped unless overwrite is true
+func (ui *AppUI) downloadRemoteFiles(files []string, overwrite bool) {
+	ui.runOperation("Download Multiple Files", func(ctx context.Context) error {
+		// 失败的文件不会中断整个过程，结束后统一汇总
+		result, err := ui.fileManager.DownloadFiles(ctx, files, overwrite, func(done, total int, current string) {
+			if current != "" {
+				ui.setProgress(ctx, fmt.Sprintf("Downloading %d/%d: %s", done+1, total, current))
+			}
 		})
-		checkBoxes = append(checkBoxes, check)
-		content.Add(check)
-	}
-
-	scroll := container.NewScroll(content)
-	scroll.SetMinSize(fyne.NewSize(RemoteScrollMinWidth, RemoteScrollMinHeight))
-
-	// 创建全选/全不选按钮
-	selectAllBtn := widget.NewButton("Select All", func() {
-		for i, check := range checkBoxes {
-			check.SetChecked(true)
-			selectedFiles[i] = true
+		fyne.Do(ui.refreshList)
+		if err != nil {
+			return err
 		}
+		ui.showBatchSummary("Download", len(files), result, func(failed []string) {
+			ui.downloadRemoteFiles(failed, overwrite)
+		})
+		return batchError(result)
 	})
+}
 
-	deselectAllBtn := widget.NewButton("Deselect All", func() {
-		for i, check := range checkBoxes {
-			check.SetChecked(false)
-			selectedFiles[i] = false
+// downloadRemoteFilesFlat downloads the given remote files straight into the
+// current directory without their folders, renaming files whose names collide
+func (ui *AppUI) downloadRemoteFilesFlat(files []string, overwrite bool) {
+	dest, err := filepath.Rel(ui.fileManager.GetWorkingDir(), ui.currentDir)
+	if err != nil {
+		showError(fmt.Errorf("failed to get relative path: %w", err), ui.window)
+		return
+	}
+	ui.runOperation("Download Multiple Files", func(ctx context.Context) error {
+		result, err := ui.fileManager.DownloadFilesFlat(ctx, files, dest, overwrite, func(done, total int, current string) {
+			if current != "" {
+				ui.setProgress(ctx, fmt.Sprintf("Downloading %d/%d: %s", done+1, total, current))
+			}
+		})
+		fyne.Do(ui.refreshList)
+		if err != nil {
+			return err
 		}
+		ui.showBatchSummary("Download", len(files), result, func(failed []string) {
+			ui.downloadRemoteFilesFlat(failed, overwrite)
+		})
+		return batchError(result)
 	})
+}
 
-	// 创建下载按钮
-	downloadBtn := widget.NewButton("Download Selected", func() {
-		// 收集选中的文件
-		var filesToDownload []string
-		for i, selected := range selectedFiles {
-			if selected && i < len(remoteFiles) {
-				filesToDownload = append(filesToDownload, remoteFiles[i])
+// downloadAndOpenRemoteFiles downloads the given remote files like the
+// Download button, then opens the file with its default application when only
+// one was selected, or the folder of the first one in the file manager
+func (ui *AppUI) downloadAndOpenRemoteFiles(files []string, overwrite, flatten bool) {
+	dest, err := filepath.Rel(ui.fileManager.GetWorkingDir(), ui.currentDir)
+	if err != nil {
+		showError(fmt.Errorf("failed to get relative path: %w", err), ui.window)
+		return
+	}
+	ui.runOperation("Download & Open", func(ctx context.Context) error {
+		progress := func(done, total int, current string) {
+			if current != "" {
+				ui.setProgress(ctx, fmt.Sprintf("Downloading %d/%d: %s", done+1, total, current))
 			}
 		}
-
-		if len(filesToDownload) == 0 {
-			dialog.ShowInformation("Info", "Please select at least one file", remoteWindow)
-			return
+		var result *dir.BatchResult
+		var err error
+		if flatten {
+			result, err = ui.fileManager.DownloadFilesFlat(ctx, files, dest, overwrite, progress)
+		} else {
+			result, err = ui.fileManager.DownloadFiles(ctx, files, overwrite, progress)
+		}
+		fyne.Do(ui.refreshList)
+		if err != nil {
+			return err
+		}
+		if len(result.Failed) > 0 {
+			ui.showBatchSummary("Download", len(files), result, func(failed []string) {
+				ui.downloadAndOpenRemoteFiles(failed, overwrite, flatten)
+			})
 		}
 
-		remoteWindow.Close()
-		ui.runOperation("Download Multiple Files", func(ctx context.Context) error {
-			for _, fileName := range filesToDownload {
-				select {
-				case <-ctx.Done():
-					return ctx.Err()
-				default:
-				}
-
-				if err := ui.fileManager.DownloadSpecificFile(ctx, fileName); err != nil {
-					ui.logger.Error("Failed to download file", slog.String("file", fileName), slog.String("error", err.Error()))
-					// 继续下载其他文件，不中断整个过程
-				}
+		// 打开第一个在本地存在的文件，已存在而跳过的文件同样可以打开
+		var target string
+		for _, file := range files {
+			if path, ok := result.LocalPaths[file]; ok {
+				target = path
+				break
 			}
-			ui.refreshList()
-			return nil
-		})
-	})
-
-	cancelBtn := widget.NewButton("Cancel", func() {
-		remoteWindow.Close()
+		}
+		if target == "" {
+			return batchError(result)
+		}
+		open := ui.openInFileManager
+		if len(files) == 1 {
+			open = ui.openFile
+		}
+		if err := open(target); err != nil {
+			// 下载已经完成，只是打开失败
+			return fmt.Errorf("downloaded to %s, but failed to open it: %w", target, err)
+		}
+		return batchError(result)
 	})
-
-	// 布局
-	topButtons := container.NewHBox(selectAllBtn, deselectAllBtn)
-	bottomButtons := container.NewHBox(downloadBtn, cancelBtn)
-
-	finalContent := container.NewBorder(
-		container.NewVBox(
-			widget.NewLabel("Select remote files to download:"),
-			topButtons,
-		),
-		bottomButtons,
-		nil,
-		nil,
-		scroll,
-	)
-
-	remoteWindow.SetContent(finalContent)
-	remoteWindow.Show()
 }
 
-// GetLogWidget returns the log widget for setting up log handler
+// GetLogWidget returns the log widget for setting up log handler, or nil
+// when the log pane uses the rich text view
 func (ui *AppUI) GetLogWidget() *widget.TextGrid {
 	return ui.logWidget
 }
@@ -554,44 +1565,60 @@ func (ui *AppUI) openSelectedInFileManager() {
 		ui.logger.Error("Failed to open file manager", slog.String("error", err.Error()))
 		dialog.ShowError(fmt.Errorf("failed to open file manager: %w", err), ui.window)
 	}
-	ui.selectedIndex = -1
-	ui.selectedName = ""
-	ui.rightClickableList.UnselectAll()
+	if !ui.fileManager.KeepSelectionAfterOp() {
+		ui.clearSelection()
+	}
+}
+
+// OpenInFileManager opens the selected item, or the current directory when
+// nothing is selected, in the system file manager
+func (ui *AppUI) OpenInFileManager() {
+	if ui.validateSelection() {
+		ui.openSelectedInFileManager()
+		return
+	}
+	if err := ui.openInFileManager(ui.currentDir); err != nil {
+		ui.logger.Error("Failed to open file manager", slog.String("error", err.Error()))
+		dialog.ShowError(fmt.Errorf("failed to open file manager: %w", err), ui.window)
+	}
 }
 
 // openInFileManager opens the system file manager at the specified path
 func (ui *AppUI) openInFileManager(path string) error {
-	var cmd *exec.Cmd
-
-	switch runtime.GOOS {
-	case "windows":
-		// Use explorer with /select to highlight the file/folder
-		windowsPath := filepath.Clean(path)
-		cmd = exec.Command("explorer", "/select,"+windowsPath)
-	case "darwin":
-		// Use open with -R to reveal in Finder
-		cmd = exec.Command("open", "-R", path)
-	case "linux":
-		// Check if the path is a directory or file
+	isDir := false
+	if runtime.GOOS == "linux" {
+		// xdg-open 不能选中文件，文件时打开其所在目录
 		info, err := os.Stat(path)
 		if err != nil {
 			return fmt.Errorf("failed to stat path %s: %w", path, err)
 		}
-
-		if info.IsDir() {
-			// Open the directory directly
-			cmd = exec.Command("xdg-open", path)
-		} else {
-			// Open the parent directory
-			parentDir := filepath.Dir(path)
-			cmd = exec.Command("xdg-open", parentDir)
-		}
-	default:
-		return fmt.Errorf("unsupported operating system: %s", runtime.GOOS)
+		isDir = info.IsDir()
+	}
+	cmd, err := fileManagerCommand(runtime.GOOS, path, isDir)
+	if err != nil {
+		return err
 	}
 
 	ui.logger.Info("Opening file manager", slog.String("path", path), slog.String("os", runtime.GOOS))
+	return ui.startCommand(cmd)
+}
+
+// openFile opens the file at path with the default application of the OS
+func (ui *AppUI) openFile(path string) error {
+	cmd, err := openFileCommand(runtime.GOOS, path)
+	if err != nil {
+		return err
+	}
+
+	ui.logger.Info("Opening file", slog.String("path", path), slog.String("os", runtime.GOOS))
+	return ui.startCommand(cmd)
+}
 
+// startCommand runs cmd, or hands it to ui.commandRunner when set
+func (ui *AppUI) startCommand(cmd *exec.Cmd) error {
+	if ui.commandRunner != nil {
+		return ui.commandRunner(cmd)
+	}
 	// Windows 用 Start()，其他系统用 Run()
 	if runtime.GOOS == "windows" {
 		return cmd.Start()
@@ -599,7 +1626,107 @@ func (ui *AppUI) openInFileManager(path string) error {
 	return cmd.Run()
 }
 
+// fileManagerCommand returns the command revealing path in the file manager
+// of goos; on Linux a file's parent directory is opened instead
+func fileManagerCommand(goos, path string, isDir bool) (*exec.Cmd, error) {
+	switch goos {
+	case "windows":
+		// Use explorer with /select to highlight the file/folder
+		return exec.Command("explorer", "/select,"+filepath.Clean(path)), nil
+	case "darwin":
+		// Use open with -R to reveal in Finder
+		return exec.Command("open", "-R", path), nil
+	case "linux":
+		if isDir {
+			return exec.Command("xdg-open", path), nil
+		}
+		return exec.Command("xdg-open", filepath.Dir(path)), nil
+	default:
+		return nil, fmt.Errorf("unsupported operating system: %s", goos)
+	}
+}
+
+// openFileCommand returns the command opening path with the default
+// application of goos
+func openFileCommand(goos, path string) (*exec.Cmd, error) {
+	switch goos {
+	case "windows":
+		// start 的第一个带引号的参数是窗口标题，留空避免把路径当成标题
+		return exec.Command("cmd", "/c", "start", "", filepath.Clean(path)), nil
+	case "darwin":
+		return exec.Command("open", path), nil
+	case "linux":
+		return exec.Command("xdg-open", path), nil
+	default:
+		return nil, fmt.Errorf("unsupported operating system: %s", goos)
+	}
+}
+
 // Run starts the application
 func (ui *AppUI) Run() {
 	ui.window.ShowAndRun()
 }
+
+// Show shows the main window without starting the event loop, for apps that are already running
+func (ui *AppUI) Show() {
+	ui.window.Show()
+}
+
+// CheckKey checks in the background that the crypto key matches the remote and
+// warns prominently when it does not, before anything is uploaded with the wrong key
+func (ui *AppUI) CheckKey() {
+	fileManager := ui.fileManager
+	go func() {
+		err := fileManager.CheckKey()
+		if err == nil {
+			return
+		}
+		if !errors.Is(err, dir.ErrKeyMismatch) {
+			// 网络等问题不代表密钥错误，只记录日志
+			ui.logger.Warn("Failed to check crypto key", slog.String("error", err.Error()))
+			return
+		}
+		fyne.Do(ui.showKeyMismatch)
+	}()
+}
+
+// CheckStorage checks in the background that the storage backend is reachable
+// and warns when it is not. The warning is not fatal: local files can still be
+// browsed and the operations are retried once the backend is back.
+func (ui *AppUI) CheckStorage() {
+	fileManager := ui.fileManager
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), StoragePingTimeout)
+		defer cancel()
+		err := fileManager.Ping(ctx)
+		if err == nil {
+			return
+		}
+		ui.logger.Warn("Storage is not reachable", slog.String("error", err.Error()))
+		fyne.Do(func() { ui.showStorageUnreachable(err) })
+	}()
+}
+
+// showStorageUnreachable warns that the storage backend failed the startup check
+func (ui *AppUI) showStorageUnreachable(err error) {
+	message := widget.NewLabel("The storage backend could not be reached:\n" + err.Error() + "\n\n" +
+		"Check the endpoint, bucket and credentials in your config and your network connection.\n" +
+		"Local files can still be browsed; uploads and downloads will fail until the storage is reachable.")
+	message.Wrapping = fyne.TextWrapWord
+	content := container.NewVBox(container.NewHBox(widget.NewIcon(theme.WarningIcon()),
+		widget.NewLabelWithStyle("Storage unreachable", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})), message)
+	d := dialog.NewCustom("Storage unreachable", "OK", content, ui.window)
+	d.Resize(fyne.NewSize(500, 0))
+	d.Show()
+}
+
+// showKeyMismatch explains that the configured key cannot decrypt this remote
+func (ui *AppUI) showKeyMismatch() {
+	title := widget.NewLabelWithStyle("Crypto key does not match this remote", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	title.Importance = widget.DangerImportance
+	message := widget.NewLabel("The configured crypto key cannot decrypt the key canary (" + dir.CanaryKey + ") stored on this remote.\n" +
+		"Files uploaded now would be encrypted with a different key than the existing ones,\n" +
+		"and downloads will fail. Check crypto_key in your config and restart fers.")
+	content := container.NewVBox(container.NewHBox(widget.NewIcon(theme.ErrorIcon()), title), message)
+	dialog.ShowCustom("Wrong crypto key", "OK", content, ui.window)
+}
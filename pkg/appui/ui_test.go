@@ -0,0 +1,797 @@
+package appui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/widget"
+	"github.com/mingregister/fers/pkg/config"
+	"github.com/mingregister/fers/pkg/crypto"
+	"github.com/mingregister/fers/pkg/dir"
+	"github.com/mingregister/fers/pkg/storage"
+)
+
+// newTestAppUI creates an AppUI backed by the Fyne test app and a file
+// manager working in a temporary directory with in-memory storage
+func newTestAppUI(t *testing.T) *AppUI {
+	t.Helper()
+	return newTestAppUIWithStorage(t, storage.NewMemoryClient())
+}
+
+// newTestAppUIWithStorage creates a headless AppUI backed by store; configure
+// may adjust the config before the file manager is created
+func newTestAppUIWithStorage(t *testing.T, store storage.Client, configure ...func(*config.Config)) *AppUI {
+	t.Helper()
+	a := test.NewApp()
+	t.Cleanup(a.Quit)
+
+	workingDir := t.TempDir()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cfg := &config.Config{TargetDir: workingDir}
+	for _, fn := range configure {
+		fn(cfg)
+	}
+	fileManager := dir.NewFileManager(cfg, store, logger, crypto.NewAESGCM("test-password"))
+
+	ui := &AppUI{
+		app:           a,
+		window:        a.NewWindow("test"),
+		fileManager:   fileManager,
+		logger:        logger,
+		selectedIndex: -1,
+		selection:     selectionSet{},
+		currentDir:    workingDir,
+		progressBar:   widget.NewProgressBarInfinite(),
+	}
+	ui.progressBar.Hide()
+	return ui
+}
+
+// waitFor polls cond until it holds or the timeout expires
+func waitFor(t *testing.T, cond func() bool) bool {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return true
+}
+
+func TestAppUI_RunOperationShowsProgress(t *testing.T) {
+	ui := newTestAppUI(t)
+
+	release := make(chan struct{})
+	ui.runOperation("test", func(ctx context.Context) error {
+		<-release
+		return nil
+	})
+
+	if !ui.progressBar.Visible() {
+		t.Error("Progress indicator should be visible while the operation runs")
+	}
+
+	close(release)
+	if !waitFor(t, func() bool { return !ui.progressBar.Visible() }) {
+		t.Error("Progress indicator should be hidden after the operation finishes")
+	}
+}
+
+func TestAppUI_RunOperationHidesProgressOnCancel(t *testing.T) {
+	ui := newTestAppUI(t)
+
+	ui.runOperation("test", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	if !ui.progressBar.Visible() {
+		t.Error("Progress indicator should be visible while the operation runs")
+	}
+
+	ui.cancelOperation()
+
+	if !waitFor(t, func() bool { return !ui.progressBar.Visible() }) {
+		t.Error("Progress indicator should be hidden after the operation is cancelled")
+	}
+}
+
+func TestAppUI_QueuedOperationKeepsProgress(t *testing.T) {
+	ui := newTestAppUI(t)
+
+	firstDone := make(chan struct{})
+	ui.runOperation("Sync Upload", func(ctx context.Context) error {
+		close(firstDone)
+		return nil
+	})
+
+	// The second operation waits in the queue until the first finishes
+	release := make(chan struct{})
+	ui.runOperation("Sync Upload", func(ctx context.Context) error {
+		<-release
+		return nil
+	})
+
+	// The finished first operation must not hide the indicator of the second
+	<-firstDone
+	time.Sleep(20 * time.Millisecond)
+	if !ui.progressBar.Visible() {
+		t.Error("Progress indicator should stay visible while the second operation runs")
+	}
+
+	close(release)
+	if !waitFor(t, func() bool { return !ui.progressBar.Visible() }) {
+		t.Error("Progress indicator should be hidden after the second operation finishes")
+	}
+}
+
+func TestAppUI_CreateFolder(t *testing.T) {
+	ui := newTestAppUI(t)
+	ui.refreshItems()
+
+	if err := ui.createFolder("docs"); err != nil {
+		t.Fatalf("createFolder failed: %v", err)
+	}
+	if len(ui.items) != 1 || ui.items[0] != "docs" {
+		t.Errorf("Expected list to show the new folder, got %v", ui.items)
+	}
+	info, err := os.Stat(filepath.Join(ui.currentDir, "docs"))
+	if err != nil || !info.IsDir() {
+		t.Fatalf("Folder was not created: %v", err)
+	}
+
+	invalidNames := []string{"", ".", "..", "a/b", `a\b`, "docs"}
+	for _, name := range invalidNames {
+		if err := ui.createFolder(name); err == nil {
+			t.Errorf("Expected folder name %q to be rejected", name)
+		}
+	}
+}
+
+func TestAppUI_RenameEntry(t *testing.T) {
+	ui := newTestAppUI(t)
+	if err := os.WriteFile(filepath.Join(ui.currentDir, "old.txt"), []byte("data"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(ui.currentDir, "taken.txt"), []byte("data"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	ui.refreshItems()
+
+	entry := dir.Entry{Name: "old.txt"}
+	if err := ui.renameEntry(entry, "taken.txt"); err == nil {
+		t.Error("Expected rename onto an existing name to be rejected")
+	}
+	if err := ui.renameEntry(entry, "../escape.txt"); err == nil {
+		t.Error("Expected rename with a path separator to be rejected")
+	}
+
+	if err := ui.renameEntry(entry, "new.txt"); err != nil {
+		t.Fatalf("renameEntry failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(ui.currentDir, "new.txt")); err != nil {
+		t.Errorf("Renamed file not found: %v", err)
+	}
+	if len(ui.items) != 2 || ui.items[0] != "new.txt" {
+		t.Errorf("Expected list to show the renamed file, got %v", ui.items)
+	}
+}
+
+func TestMovePaths(t *testing.T) {
+	workingDir := filepath.Join("/data", "work")
+	currentDir := filepath.Join(workingDir, "docs")
+	file := dir.Entry{Name: "a.txt"}
+	folder := dir.Entry{Name: "photos", IsDir: true}
+	archive := dir.Entry{Name: "archive", IsDir: true}
+
+	oldRelative, newRelative, err := movePaths(workingDir, currentDir, file, archive)
+	if err != nil {
+		t.Fatalf("movePaths failed: %v", err)
+	}
+	if oldRelative != filepath.Join("docs", "a.txt") || newRelative != filepath.Join("docs", "archive", "a.txt") {
+		t.Errorf("Unexpected move %s -> %s", oldRelative, newRelative)
+	}
+	if _, newRelative, err := movePaths(workingDir, currentDir, folder, archive); err != nil || newRelative != filepath.Join("docs", "archive", "photos") {
+		t.Errorf("Expected the folder to move into archive, got %s, %v", newRelative, err)
+	}
+
+	if _, _, err := movePaths(workingDir, currentDir, file, dir.Entry{Name: "b.txt"}); !errors.Is(err, errMoveOntoFile) {
+		t.Errorf("Expected dropping onto a file to be rejected, got %v", err)
+	}
+	if _, _, err := movePaths(workingDir, currentDir, folder, folder); err == nil {
+		t.Error("Expected moving a folder into itself to be rejected")
+	}
+	if _, _, err := movePaths(workingDir, workingDir, file, dir.Entry{Name: "..", IsDir: true}); err == nil {
+		t.Error("Expected a target outside the working directory to be rejected")
+	}
+}
+
+func TestAppUI_MoveEntryInto(t *testing.T) {
+	ui := newTestAppUI(t)
+	if err := os.Mkdir(filepath.Join(ui.currentDir, "archive"), 0755); err != nil {
+		t.Fatalf("Failed to create test directory: %v", err)
+	}
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if err := os.WriteFile(filepath.Join(ui.currentDir, name), []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+	ui.setupUI()
+	index := func(name string) int { return slices.Index(ui.items, name) }
+
+	if err := ui.moveEntryInto(index("a.txt"), index("b.txt")); !errors.Is(err, errMoveOntoFile) {
+		t.Errorf("Expected dropping onto a file to be rejected, got %v", err)
+	}
+	if err := ui.moveEntryInto(index("a.txt"), index("archive")); err != nil {
+		t.Fatalf("moveEntryInto failed: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(ui.currentDir, "archive", "a.txt")); err != nil || string(data) != "a.txt" {
+		t.Errorf("Expected a.txt inside archive, got %q, %v", data, err)
+	}
+	if !slices.Equal(ui.items, []string{"archive", "b.txt"}) {
+		t.Errorf("Expected the list to be refreshed, got %v", ui.items)
+	}
+}
+
+func TestAppUI_DeleteFolder(t *testing.T) {
+	ui := newTestAppUI(t)
+	if err := os.MkdirAll(filepath.Join(ui.currentDir, "old", "nested"), 0755); err != nil {
+		t.Fatalf("Failed to create test directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(ui.currentDir, "old", "nested", "a.txt"), []byte("data"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	ui.setupUI()
+	ui.selectItem(0)
+
+	if err := ui.deleteFolder("."); !errors.Is(err, dir.ErrDeleteWorkingDir) {
+		t.Errorf("Expected deleting the working directory to be refused, got %v", err)
+	}
+	if err := ui.deleteFolder("old"); err != nil {
+		t.Fatalf("deleteFolder failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(ui.currentDir, "old")); !os.IsNotExist(err) {
+		t.Errorf("Folder was not deleted: %v", err)
+	}
+	if len(ui.items) != 0 {
+		t.Errorf("Expected an empty list, got %v", ui.items)
+	}
+	if ui.validateSelection() || len(ui.selection) != 0 {
+		t.Errorf("Expected the selection to be cleared, got %v", ui.selection)
+	}
+}
+
+// findButton returns the first button labelled text inside obj
+func findButton(obj fyne.CanvasObject, text string) *widget.Button {
+	if button, ok := obj.(*widget.Button); ok && button.Text == text {
+		return button
+	}
+
+	var children []fyne.CanvasObject
+	switch o := obj.(type) {
+	case *fyne.Container:
+		children = o.Objects
+	case fyne.Widget:
+		children = test.WidgetRenderer(o).Objects()
+	}
+	for _, child := range children {
+		if button := findButton(child, text); button != nil {
+			return button
+		}
+	}
+	return nil
+}
+
+// tapDialogButton taps the button labelled text on the topmost dialog
+func tapDialogButton(t *testing.T, ui *AppUI, text string) {
+	t.Helper()
+	if !waitFor(t, func() bool { return ui.window.Canvas().Overlays().Top() != nil }) {
+		t.Fatal("Expected a dialog to be shown")
+	}
+	button := findButton(ui.window.Canvas().Overlays().Top(), text)
+	if button == nil {
+		t.Fatalf("Dialog has no %q button", text)
+	}
+	test.Tap(button)
+}
+
+func TestAppUI_SyncUploadDeclined(t *testing.T) {
+	ui := newTestAppUI(t)
+	if err := os.WriteFile(filepath.Join(ui.currentDir, "file.txt"), []byte("data"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	ui.SyncUpload()
+	tapDialogButton(t, ui, "Cancel")
+
+	waitFor(t, func() bool { return !ui.progressBar.Visible() })
+	files, err := ui.fileManager.ListRemoteFiles("")
+	if err != nil {
+		t.Fatalf("ListRemoteFiles failed: %v", err)
+	}
+	if len(files) != 0 {
+		t.Errorf("Sync Upload should not run when declined, got remote files %v", files)
+	}
+}
+
+func TestAppUI_SyncDownloadDeclined(t *testing.T) {
+	ui := newTestAppUI(t)
+	localPath := filepath.Join(ui.currentDir, "file.txt")
+	if err := os.WriteFile(localPath, []byte("data"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := ui.fileManager.EncryptAndUploadFile(localPath, "file.txt"); err != nil {
+		t.Fatalf("Failed to upload test file: %v", err)
+	}
+	if err := os.Remove(localPath); err != nil {
+		t.Fatalf("Failed to remove test file: %v", err)
+	}
+
+	ui.SyncDownload()
+	tapDialogButton(t, ui, "Cancel")
+
+	waitFor(t, func() bool { return !ui.progressBar.Visible() })
+	if _, err := os.Stat(localPath); !os.IsNotExist(err) {
+		t.Error("Sync Download should not run when declined")
+	}
+}
+
+func TestAppUI_SyncUploadConfirmed(t *testing.T) {
+	ui := newTestAppUI(t)
+	if err := os.WriteFile(filepath.Join(ui.currentDir, "file.txt"), []byte("data"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	ui.SyncUpload()
+	tapDialogButton(t, ui, "Sync Upload")
+
+	uploaded := waitFor(t, func() bool {
+		files, err := ui.fileManager.ListRemoteFiles("")
+		return err == nil && len(files) == 1
+	})
+	if !uploaded {
+		t.Error("Sync Upload should run when confirmed")
+	}
+}
+
+func TestAppUI_SetAutoSync(t *testing.T) {
+	ui := newTestAppUI(t)
+
+	if err := ui.setAutoSync(true); err != nil {
+		t.Fatalf("setAutoSync(true) failed: %v", err)
+	}
+	if ui.autoSyncCancel == nil {
+		t.Fatal("Auto sync should be running after enabling it")
+	}
+
+	if err := ui.setAutoSync(false); err != nil {
+		t.Fatalf("setAutoSync(false) failed: %v", err)
+	}
+	if ui.autoSyncCancel != nil {
+		t.Error("Auto sync should be stopped after disabling it")
+	}
+}
+
+func TestAppUI_SetScheduledSync(t *testing.T) {
+	ui := newTestAppUI(t)
+
+	if err := ui.setScheduledSync(true); err != nil {
+		t.Fatalf("setScheduledSync(true) failed: %v", err)
+	}
+	if ui.scheduleCancel == nil {
+		t.Fatal("Scheduled sync should be running after enabling it")
+	}
+
+	if err := ui.setScheduledSync(false); err != nil {
+		t.Fatalf("setScheduledSync(false) failed: %v", err)
+	}
+	if ui.scheduleCancel != nil {
+		t.Error("Scheduled sync should be stopped after disabling it")
+	}
+}
+
+func TestAppUI_ShowHidden(t *testing.T) {
+	ui := newTestAppUI(t)
+	for _, name := range []string{".hidden", "visible.txt"} {
+		if err := os.WriteFile(filepath.Join(ui.currentDir, name), []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	ui.refreshList()
+	if len(ui.items) != 1 {
+		t.Fatalf("Expected hidden file to be excluded, got %v", ui.items)
+	}
+
+	ui.setShowHidden(true)
+	if len(ui.items) != 2 {
+		t.Errorf("Expected hidden file to be listed after enabling Show hidden, got %v", ui.items)
+	}
+}
+
+// failingStorage fails downloads of the keys in failing and records every downloaded key
+type failingStorage struct {
+	storage.Client
+	mu         sync.Mutex
+	failing    map[string]bool
+	downloaded []string
+}
+
+func (f *failingStorage) Download(key string) ([]byte, error) {
+	f.mu.Lock()
+	f.downloaded = append(f.downloaded, key)
+	fail := f.failing[key]
+	f.mu.Unlock()
+	if fail {
+		return nil, fmt.Errorf("download %s: simulated failure", key)
+	}
+	return f.Client.Download(key)
+}
+
+func (f *failingStorage) takeDownloaded() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	keys := f.downloaded
+	f.downloaded = nil
+	return keys
+}
+
+func TestAppUI_RetryFailedDownloads(t *testing.T) {
+	store := &failingStorage{
+		Client:  storage.NewMemoryClient(),
+		failing: map[string]bool{"b.txt": true, "c.txt": true},
+	}
+	ui := newTestAppUIWithStorage(t, store)
+
+	cipher := crypto.NewAESGCM("test-password")
+	for _, key := range []string{"a.txt", "b.txt", "c.txt"} {
+		encrypted, err := cipher.Encrypt([]byte(key))
+		if err != nil {
+			t.Fatalf("Failed to encrypt: %v", err)
+		}
+		if err := store.Upload(key, encrypted); err != nil {
+			t.Fatalf("Failed to upload %s: %v", key, err)
+		}
+	}
+
+	ui.downloadRemoteFiles([]string{"a.txt", "b.txt", "c.txt"}, false)
+	waitFor(t, func() bool { return !ui.progressBar.Visible() })
+	store.takeDownloaded()
+
+	store.mu.Lock()
+	delete(store.failing, "b.txt")
+	store.mu.Unlock()
+	tapDialogButton(t, ui, "Retry Failed")
+	waitFor(t, func() bool { return !ui.progressBar.Visible() })
+
+	retried := store.takeDownloaded()
+	if !reflect.DeepEqual(retried, []string{"b.txt", "c.txt"}) {
+		t.Errorf("Expected only the failed keys to be retried, got %v", retried)
+	}
+	if _, err := os.Stat(filepath.Join(ui.currentDir, "b.txt")); err != nil {
+		t.Errorf("Retried file should be downloaded: %v", err)
+	}
+}
+
+func TestAppUI_SyncDownloadPartialFailure(t *testing.T) {
+	store := &failingStorage{
+		Client:  storage.NewMemoryClient(),
+		failing: map[string]bool{"b.txt": true},
+	}
+	ui := newTestAppUIWithStorage(t, store, func(cfg *config.Config) {
+		cfg.SkipSyncConfirm = true
+		cfg.Notifications = true
+	})
+	notifier := &recordingNotifier{}
+	ui.notifier = notifier.send
+
+	cipher := crypto.NewAESGCM("test-password")
+	for _, key := range []string{"a.txt", "b.txt"} {
+		encrypted, err := cipher.Encrypt([]byte(key))
+		if err != nil {
+			t.Fatalf("Failed to encrypt: %v", err)
+		}
+		if err := store.Upload(key, encrypted); err != nil {
+			t.Fatalf("Failed to upload %s: %v", key, err)
+		}
+	}
+
+	ui.SyncDownload()
+	if !waitFor(t, func() bool { return len(notifier.notifications()) == 1 }) {
+		t.Fatal("Expected a notification after Sync Download")
+	}
+	if n := notifier.notifications()[0]; n.Title != "Sync Download finished" || n.Content != "Completed with 1 errors" {
+		t.Errorf("Unexpected notification %q: %q", n.Title, n.Content)
+	}
+
+	// 汇总对话框带可展开的错误详情
+	if !waitFor(t, func() bool { return ui.window.Canvas().Overlays().Top() != nil }) {
+		t.Fatal("Expected the sync summary to be shown")
+	}
+	if findButton(ui.window.Canvas().Overlays().Top(), "Failed files (1)") == nil {
+		t.Error("Summary should offer the failed file details")
+	}
+	if findLabel(ui.window.Canvas().Overlays().Top(), "Check your connection") == nil {
+		t.Error("Summary should suggest checking the connection after a download error")
+	}
+	tapDialogButton(t, ui, "OK")
+}
+
+func TestAppUI_CheckKeyMismatch(t *testing.T) {
+	store := storage.NewMemoryClient()
+	encrypted, err := crypto.NewAESGCM("other-password").Encrypt([]byte("canary"))
+	if err != nil {
+		t.Fatalf("Failed to encrypt: %v", err)
+	}
+	if err := store.Upload(dir.CanaryKey, encrypted); err != nil {
+		t.Fatalf("Failed to upload canary: %v", err)
+	}
+	ui := newTestAppUIWithStorage(t, store)
+
+	ui.CheckKey()
+	if !waitFor(t, func() bool { return ui.window.Canvas().Overlays().Top() != nil }) {
+		t.Fatal("Expected the key mismatch dialog to be shown")
+	}
+	tapDialogButton(t, ui, "OK")
+}
+
+func TestAppUI_CheckStorageUnreachable(t *testing.T) {
+	base := filepath.Join(t.TempDir(), "remote")
+	store := storage.NewOSSMock(base)
+	if err := os.RemoveAll(base); err != nil {
+		t.Fatalf("Failed to remove mock storage: %v", err)
+	}
+	ui := newTestAppUIWithStorage(t, store)
+
+	ui.CheckStorage()
+	if !waitFor(t, func() bool { return ui.window.Canvas().Overlays().Top() != nil }) {
+		t.Fatal("Expected the storage warning to be shown")
+	}
+	tapDialogButton(t, ui, "OK")
+}
+
+func TestAppUI_VerifyBackup(t *testing.T) {
+	store := storage.NewMemoryClient()
+	ui := newTestAppUIWithStorage(t, store)
+	if err := store.Upload("bad.txt", []byte("not encrypted")); err != nil {
+		t.Fatalf("Failed to upload: %v", err)
+	}
+
+	ui.VerifyBackup()
+	if !waitFor(t, func() bool { return ui.window.Canvas().Overlays().Top() != nil }) {
+		t.Fatal("Expected the verification summary to be shown")
+	}
+	tapDialogButton(t, ui, "OK")
+}
+
+// recordingNotifier collects the notifications sent by the UI
+type recordingNotifier struct {
+	mu   sync.Mutex
+	sent []*fyne.Notification
+}
+
+func (r *recordingNotifier) send(n *fyne.Notification) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sent = append(r.sent, n)
+}
+
+func (r *recordingNotifier) notifications() []*fyne.Notification {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*fyne.Notification(nil), r.sent...)
+}
+
+func TestAppUI_RunOperationTimeout(t *testing.T) {
+	ui := newTestAppUIWithStorage(t, storage.NewMemoryClient(), func(cfg *config.Config) {
+		cfg.Notifications = true
+		cfg.OperationTimeout = 20 * time.Millisecond
+	})
+	notifier := &recordingNotifier{}
+	ui.notifier = notifier.send
+
+	ui.runOperation("Sync Upload", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if !waitFor(t, func() bool { return len(notifier.notifications()) == 1 }) {
+		t.Fatal("Expected a notification after the operation timed out")
+	}
+	if n := notifier.notifications()[0]; n.Title != "Sync Upload timed out" || n.Content != dir.ErrTimeout.Error() {
+		t.Errorf("Unexpected notification %q: %q", n.Title, n.Content)
+	}
+}
+
+func TestAppUI_RunOperationNotifies(t *testing.T) {
+	ui := newTestAppUIWithStorage(t, storage.NewMemoryClient(), func(cfg *config.Config) {
+		cfg.Notifications = true
+	})
+	notifier := &recordingNotifier{}
+	ui.notifier = notifier.send
+
+	ui.runOperation("Sync Upload", func(ctx context.Context) error { return nil })
+	if !waitFor(t, func() bool { return len(notifier.notifications()) == 1 }) {
+		t.Fatal("Expected a notification after the operation completed")
+	}
+	if n := notifier.notifications()[0]; n.Title != "Sync Upload finished" {
+		t.Errorf("Unexpected notification title %q", n.Title)
+	}
+
+	ui.runOperation("Sync Download", func(ctx context.Context) error { return fmt.Errorf("network down") })
+	if !waitFor(t, func() bool { return len(notifier.notifications()) == 2 }) {
+		t.Fatal("Expected a notification after the operation failed")
+	}
+	if n := notifier.notifications()[1]; n.Title != "Sync Download failed" || n.Content != "network down" {
+		t.Errorf("Unexpected failure notification %q: %q", n.Title, n.Content)
+	}
+}
+
+func TestAppUI_RunOperationNotificationsDisabled(t *testing.T) {
+	ui := newTestAppUI(t)
+	notifier := &recordingNotifier{}
+	ui.notifier = notifier.send
+
+	ui.runOperation("Sync Upload", func(ctx context.Context) error { return nil })
+	waitFor(t, func() bool { return !ui.progressBar.Visible() })
+	time.Sleep(20 * time.Millisecond)
+
+	if len(notifier.notifications()) != 0 {
+		t.Error("No notification should be sent when notifications are disabled")
+	}
+}
+
+// findLabel returns the first label inside obj whose text contains text
+func findLabel(obj fyne.CanvasObject, text string) *widget.Label {
+	if label, ok := obj.(*widget.Label); ok && strings.Contains(label.Text, text) {
+		return label
+	}
+
+	var children []fyne.CanvasObject
+	switch o := obj.(type) {
+	case *fyne.Container:
+		children = o.Objects
+	case fyne.Widget:
+		children = test.WidgetRenderer(o).Objects()
+	}
+	for _, child := range children {
+		if label := findLabel(child, text); label != nil {
+			return label
+		}
+	}
+	return nil
+}
+
+func TestAppUI_UploadDirectoryConfirmsFileCount(t *testing.T) {
+	ui := newTestAppUI(t)
+	for _, name := range []string{"docs/a.txt", "docs/sub/b.txt", "docs/sub/c.txt", "docs/.hidden"} {
+		path := filepath.Join(ui.currentDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+	ui.setupUI()
+	ui.selectItem(slices.Index(ui.items, "docs"))
+
+	ui.encryptAndUploadSelected()
+	if !waitFor(t, func() bool { return ui.window.Canvas().Overlays().Top() != nil }) {
+		t.Fatal("Expected a confirmation before uploading a directory")
+	}
+	if findLabel(ui.window.Canvas().Overlays().Top(), "3 file(s)") == nil {
+		t.Error("Expected the confirmation to state that 3 files will be uploaded")
+	}
+	tapDialogButton(t, ui, "Yes")
+
+	var files []string
+	waitFor(t, func() bool {
+		files, _ = ui.fileManager.ListRemoteFiles("")
+		return len(files) == 3
+	})
+	if len(files) != 3 {
+		t.Errorf("Expected 3 uploaded files after confirming, got %v", files)
+	}
+}
+
+func TestAppUI_UploadSingleFileWithoutConfirm(t *testing.T) {
+	ui := newTestAppUI(t)
+	if err := os.WriteFile(filepath.Join(ui.currentDir, "a.txt"), []byte("a"), 0644); err != nil {
+		t.Fatalf("Failed to create a.txt: %v", err)
+	}
+	ui.setupUI()
+	ui.selectItem(0)
+
+	ui.encryptAndUploadSelected()
+
+	var files []string
+	waitFor(t, func() bool {
+		files, _ = ui.fileManager.ListRemoteFiles("")
+		return len(files) == 1
+	})
+	if !slices.Equal(files, []string{"a.txt"}) {
+		t.Errorf("Expected a.txt uploaded without confirmation, got %v", files)
+	}
+}
+
+func TestAppUI_HealRestoresCorruptedFile(t *testing.T) {
+	ui := newTestAppUIWithStorage(t, storage.NewMemoryClient(), func(cfg *config.Config) {
+		cfg.SkipSyncConfirm = true
+	})
+	path := filepath.Join(ui.currentDir, "a.txt")
+	if err := os.WriteFile(path, []byte("original"), 0644); err != nil {
+		t.Fatalf("Failed to create a.txt: %v", err)
+	}
+	if _, err := ui.fileManager.SyncUpload(context.Background()); err != nil {
+		t.Fatalf("SyncUpload failed: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("corrupted"), 0644); err != nil {
+		t.Fatalf("Failed to corrupt a.txt: %v", err)
+	}
+	ui.setupUI()
+
+	ui.Heal()
+	if !waitFor(t, func() bool { return ui.window.Canvas().Overlays().Top() != nil }) {
+		t.Fatal("Expected the heal summary to be shown")
+	}
+	if findLabel(ui.window.Canvas().Overlays().Top(), "1 of 1 files succeeded") == nil {
+		t.Error("Summary should report the healed file")
+	}
+	if data, _ := os.ReadFile(path); string(data) != "original" {
+		t.Errorf("Expected a.txt to be restored, got %q", data)
+	}
+}
+
+func TestAppUI_RetryQuarantined(t *testing.T) {
+	store := &failingStorage{Client: storage.NewMemoryClient(), failing: map[string]bool{"b.txt": true}}
+	ui := newTestAppUIWithStorage(t, store, func(cfg *config.Config) {
+		cfg.SkipSyncConfirm = true
+		cfg.QuarantineAfter = 1
+	})
+	encrypted, err := crypto.NewAESGCM("test-password").Encrypt([]byte("b"))
+	if err != nil {
+		t.Fatalf("Failed to encrypt: %v", err)
+	}
+	if err := store.Upload("b.txt", encrypted); err != nil {
+		t.Fatalf("Failed to upload b.txt: %v", err)
+	}
+	if _, err := ui.fileManager.SyncDownload(context.Background()); err != nil {
+		t.Fatalf("SyncDownload failed: %v", err)
+	}
+	if paths, _ := ui.quarantinedPaths(context.Background()); !reflect.DeepEqual(paths, []string{"b.txt"}) {
+		t.Fatalf("Expected b.txt to be quarantined, got %v", paths)
+	}
+
+	store.mu.Lock()
+	store.failing = nil
+	store.mu.Unlock()
+	ui.setupUI()
+	ui.RetryQuarantined()
+	if !waitFor(t, func() bool { return ui.window.Canvas().Overlays().Top() != nil }) {
+		t.Fatal("Expected the retry summary to be shown")
+	}
+	if findLabel(ui.window.Canvas().Overlays().Top(), "1 of 1 files succeeded") == nil {
+		t.Error("Summary should report the retried file")
+	}
+	if data, _ := os.ReadFile(filepath.Join(ui.currentDir, "b.txt")); string(data) != "b" {
+		t.Errorf("Expected b.txt to be downloaded, got %q", data)
+	}
+	if paths, _ := ui.quarantinedPaths(context.Background()); len(paths) != 0 {
+		t.Errorf("Expected the quarantine to be empty, got %v", paths)
+	}
+}
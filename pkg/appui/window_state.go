@@ -0,0 +1,74 @@
+package appui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+)
+
+// Preference keys for the persisted window state
+const (
+	prefWindowWidth  = "window.width"
+	prefWindowHeight = "window.height"
+	prefLastDir      = "window.last_dir"
+)
+
+// loadWindowSize reads the window size from preferences, falling back to the default size
+func loadWindowSize(prefs fyne.Preferences) fyne.Size {
+	width := prefs.FloatWithFallback(prefWindowWidth, DefaultWindowWidth)
+	height := prefs.FloatWithFallback(prefWindowHeight, DefaultWindowHeight)
+	if width <= 0 || height <= 0 {
+		return fyne.NewSize(DefaultWindowWidth, DefaultWindowHeight)
+	}
+	return fyne.NewSize(float32(width), float32(height))
+}
+
+// saveWindowSize persists the window size to preferences
+func saveWindowSize(prefs fyne.Preferences, size fyne.Size) {
+	if size.Width <= 0 || size.Height <= 0 {
+		return
+	}
+	prefs.SetFloat(prefWindowWidth, float64(size.Width))
+	prefs.SetFloat(prefWindowHeight, float64(size.Height))
+}
+
+// loadLastDir returns the last visited directory if it still exists inside
+// workingDir, otherwise workingDir itself
+func loadLastDir(prefs fyne.Preferences, workingDir string) string {
+	lastDir := prefs.String(prefLastDir)
+	if lastDir == "" {
+		return workingDir
+	}
+
+	// 目录可能已被删除，或工作目录已更改
+	cleanLastDir := filepath.Clean(lastDir)
+	relPath, err := filepath.Rel(filepath.Clean(workingDir), cleanLastDir)
+	if err != nil || strings.HasPrefix(relPath, "..") {
+		return workingDir
+	}
+	info, err := os.Stat(cleanLastDir)
+	if err != nil || !info.IsDir() {
+		return workingDir
+	}
+	return cleanLastDir
+}
+
+// saveLastDir persists the current directory to preferences
+func saveLastDir(prefs fyne.Preferences, dir string) {
+	prefs.SetString(prefLastDir, dir)
+}
+
+// newMainWindow creates the main window with the persisted size, saving it again on close
+func newMainWindow(a fyne.App) fyne.Window {
+	window := a.NewWindow("File Encrypt & Remote Storage")
+	window.Resize(loadWindowSize(a.Preferences()))
+	window.CenterOnScreen()
+
+	// Fyne 没有窗口大小变化的回调，关闭时保存
+	window.SetOnClosed(func() {
+		saveWindowSize(a.Preferences(), window.Canvas().Size())
+	})
+	return window
+}
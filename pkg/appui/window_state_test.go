@@ -0,0 +1,83 @@
+package appui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+)
+
+func TestWindowSize_RoundTrip(t *testing.T) {
+	a := test.NewApp()
+	defer a.Quit()
+	prefs := a.Preferences()
+
+	if got := loadWindowSize(prefs); got != fyne.NewSize(DefaultWindowWidth, DefaultWindowHeight) {
+		t.Errorf("Expected default size without preferences, got %v", got)
+	}
+
+	saveWindowSize(prefs, fyne.NewSize(1280, 720))
+	if got := loadWindowSize(prefs); got != fyne.NewSize(1280, 720) {
+		t.Errorf("Expected saved size 1280x720, got %v", got)
+	}
+
+	// An invalid size is not saved
+	saveWindowSize(prefs, fyne.NewSize(0, 0))
+	if got := loadWindowSize(prefs); got != fyne.NewSize(1280, 720) {
+		t.Errorf("Expected zero size to be ignored, got %v", got)
+	}
+}
+
+func TestLoadLastDir(t *testing.T) {
+	a := test.NewApp()
+	defer a.Quit()
+	prefs := a.Preferences()
+
+	workingDir := t.TempDir()
+	subDir := filepath.Join(workingDir, "sub")
+	if err := os.Mkdir(subDir, 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	filePath := filepath.Join(workingDir, "file.txt")
+	if err := os.WriteFile(filePath, []byte("data"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		saved string
+		want  string
+	}{
+		{"nothing saved", "", workingDir},
+		{"valid subdirectory", subDir, subDir},
+		{"missing directory", filepath.Join(workingDir, "gone"), workingDir},
+		{"not a directory", filePath, workingDir},
+		{"outside working dir", filepath.Dir(workingDir), workingDir},
+		{"escapes via dot-dot", filepath.Join(workingDir, "..", "other"), workingDir},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saveLastDir(prefs, tt.saved)
+			if got := loadLastDir(prefs, workingDir); got != tt.want {
+				t.Errorf("loadLastDir() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAppUI_ChangeDirSavesLastDir(t *testing.T) {
+	ui := newTestAppUI(t)
+	subDir := filepath.Join(ui.currentDir, "sub")
+	if err := os.Mkdir(subDir, 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+
+	ui.changeDir(subDir)
+
+	if got := loadLastDir(ui.app.Preferences(), ui.fileManager.GetWorkingDir()); got != subDir {
+		t.Errorf("Expected last dir %q to be restored, got %q", subDir, got)
+	}
+}
@@ -0,0 +1,45 @@
+package appui
+
+import (
+	"log/slog"
+
+	"fyne.io/fyne/v2/dialog"
+)
+
+// checkWorkingDir records whether the working directory is accessible and
+// reports whether it is. When it disappears, e.g. because the external drive
+// was unmounted, the operation buttons are disabled and the user is told once;
+// they are enabled again by the first refresh after it comes back.
+func (ui *AppUI) checkWorkingDir() bool {
+	available := ui.fileManager.WorkingDirAvailable()
+	if available == !ui.workingDirUnavailable {
+		return available
+	}
+	ui.workingDirUnavailable = !available
+	ui.setOperationsEnabled(available)
+
+	workingDir := ui.fileManager.GetWorkingDir()
+	if available {
+		ui.logger.Info("Working directory is accessible again", slog.String("dir", workingDir))
+		return true
+	}
+	ui.logger.Error("Working directory is no longer accessible", slog.String("dir", workingDir))
+	if ui.window != nil {
+		dialog.ShowInformation("Working directory unavailable",
+			"The working directory "+workingDir+" is no longer accessible.\n"+
+				"If it is on an external drive, reconnect the drive and click Refresh.\n"+
+				"Operations are disabled until then.", ui.window)
+	}
+	return false
+}
+
+// setOperationsEnabled enables or disables the buttons that read or write the working directory
+func (ui *AppUI) setOperationsEnabled(enabled bool) {
+	for _, w := range ui.operationWidgets {
+		if enabled {
+			w.Enable()
+		} else {
+			w.Disable()
+		}
+	}
+}
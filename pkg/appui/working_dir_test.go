@@ -0,0 +1,60 @@
+package appui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAppUI_WorkingDirDisappears(t *testing.T) {
+	ui := newTestAppUI(t)
+	workingDir := ui.fileManager.GetWorkingDir()
+	if err := os.WriteFile(filepath.Join(workingDir, "a.txt"), []byte("a"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	ui.setupUI()
+	if len(ui.operationWidgets) == 0 {
+		t.Fatal("Expected the operation buttons to be tracked")
+	}
+	assertEnabled := func(want bool) {
+		t.Helper()
+		for i, w := range ui.operationWidgets {
+			if w.Disabled() == want {
+				t.Fatalf("Expected operation widget %d enabled=%v", i, want)
+			}
+		}
+	}
+	assertEnabled(true)
+
+	// 工作目录被移走，如外接硬盘被拔出
+	moved := workingDir + "-unmounted"
+	if err := os.Rename(workingDir, moved); err != nil {
+		t.Fatalf("Failed to move working directory: %v", err)
+	}
+	t.Cleanup(func() { os.Rename(moved, workingDir) })
+	ui.Refresh()
+
+	if !ui.workingDirUnavailable || len(ui.items) != 0 {
+		t.Fatalf("Expected the working directory to be reported unavailable, got items %v", ui.items)
+	}
+	assertEnabled(false)
+	if ui.window.Canvas().Overlays().Top() == nil {
+		t.Fatal("Expected the unavailable dialog to be shown")
+	}
+	tapDialogButton(t, ui, "OK")
+
+	// 再次刷新不会重复弹窗
+	ui.Refresh()
+	if ui.window.Canvas().Overlays().Top() != nil {
+		t.Error("Expected the dialog to be shown only once")
+	}
+
+	if err := os.Rename(moved, workingDir); err != nil {
+		t.Fatalf("Failed to restore working directory: %v", err)
+	}
+	ui.Refresh()
+	if ui.workingDirUnavailable || len(ui.items) != 1 || ui.items[0] != "a.txt" {
+		t.Fatalf("Expected the list to come back, got %v", ui.items)
+	}
+	assertEnabled(true)
+}
@@ -0,0 +1,74 @@
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// 构建时可通过 -ldflags "-X github.com/mingregister/fers/pkg/buildinfo.Version=v1.2.3" 注入，
+// 未注入时从 debug.ReadBuildInfo 读取
+var (
+	Version   string
+	Commit    string
+	BuildDate string
+)
+
+// readBuildInfo reads the build information embedded by the Go toolchain, replaced in tests
+var readBuildInfo = debug.ReadBuildInfo
+
+// Info describes the running binary; fields are empty when unknown
+type Info struct {
+	Version   string
+	Commit    string
+	BuildDate string
+	Modified  bool // 构建时工作区有未提交的修改
+	GoVersion string
+	OS        string
+	Arch      string
+}
+
+// Get returns the build information, preferring the values injected with
+// -ldflags over those recorded by the Go toolchain
+func Get() Info {
+	info := Info{GoVersion: runtime.Version(), OS: runtime.GOOS, Arch: runtime.GOARCH}
+	if bi, ok := readBuildInfo(); ok {
+		if bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+			info.Version = bi.Main.Version
+		}
+		if bi.GoVersion != "" {
+			info.GoVersion = bi.GoVersion
+		}
+		for _, setting := range bi.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				info.Commit = setting.Value
+			case "vcs.time":
+				info.BuildDate = setting.Value
+			case "vcs.modified":
+				info.Modified = setting.Value == "true"
+			}
+		}
+	}
+
+	if Version != "" {
+		info.Version = Version
+	}
+	if Commit != "" {
+		info.Commit = Commit
+	}
+	if BuildDate != "" {
+		info.BuildDate = BuildDate
+	}
+	if info.Version == "" {
+		info.Version = "dev"
+	}
+	return info
+}
+
+// ShortCommit returns the first 12 characters of the commit
+func (i Info) ShortCommit() string {
+	if len(i.Commit) > 12 {
+		return i.Commit[:12]
+	}
+	return i.Commit
+}
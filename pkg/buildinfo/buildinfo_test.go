@@ -0,0 +1,67 @@
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+	"testing"
+)
+
+// stubBuildInfo makes Get read bi and clears the injected values for the test
+func stubBuildInfo(t *testing.T, bi *debug.BuildInfo) {
+	t.Helper()
+	oldRead, oldVersion, oldCommit, oldDate := readBuildInfo, Version, Commit, BuildDate
+	t.Cleanup(func() { readBuildInfo, Version, Commit, BuildDate = oldRead, oldVersion, oldCommit, oldDate })
+	readBuildInfo = func() (*debug.BuildInfo, bool) { return bi, bi != nil }
+	Version, Commit, BuildDate = "", "", ""
+}
+
+func TestGet_FromReadBuildInfo(t *testing.T) {
+	stubBuildInfo(t, &debug.BuildInfo{
+		GoVersion: "go1.24.1",
+		Main:      debug.Module{Path: "github.com/mingregister/fers", Version: "v0.3.0"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "0123456789abcdef0123"},
+			{Key: "vcs.time", Value: "2025-01-02T03:04:05Z"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	})
+
+	info := Get()
+	want := Info{
+		Version:   "v0.3.0",
+		Commit:    "0123456789abcdef0123",
+		BuildDate: "2025-01-02T03:04:05Z",
+		Modified:  true,
+		GoVersion: "go1.24.1",
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+	}
+	if info != want {
+		t.Errorf("Get() = %+v, want %+v", info, want)
+	}
+	if got := info.ShortCommit(); got != "0123456789ab" {
+		t.Errorf("ShortCommit() = %q", got)
+	}
+}
+
+func TestGet_LdflagsOverride(t *testing.T) {
+	stubBuildInfo(t, &debug.BuildInfo{
+		Main:     debug.Module{Version: "(devel)"},
+		Settings: []debug.BuildSetting{{Key: "vcs.revision", Value: "fromvcs"}},
+	})
+	Version, Commit = "v1.2.3", "fromldflags"
+
+	info := Get()
+	if info.Version != "v1.2.3" || info.Commit != "fromldflags" {
+		t.Errorf("Expected the injected version and commit, got %+v", info)
+	}
+}
+
+func TestGet_WithoutBuildInfo(t *testing.T) {
+	stubBuildInfo(t, nil)
+
+	info := Get()
+	if info.Version != "dev" || info.GoVersion != runtime.Version() || info.OS != runtime.GOOS {
+		t.Errorf("Expected fallbacks without build info, got %+v", info)
+	}
+}
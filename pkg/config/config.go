@@ -4,16 +4,109 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/mingregister/fers/pkg/crypto"
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	CryptoKey string  `mapstructure:"crypto_key"`
-	Log       string  `mapstructure:"log"`
-	TargetDir string  `mapstructure:"target_dir"`
-	Storage   Storage `mapstructure:"storage"`
-	LogLevel  int     `mapstructure:"log_level"`
+	CryptoKey           string        `mapstructure:"crypto_key"`
+	CryptoKeySource     string        `mapstructure:"crypto_key_source"` // config（默认，使用 crypto_key）、prompt（启动时输入）或 keystore:<文件路径>（启动时输入口令解锁 keystore 中的主密钥）
+	Compression         string        `mapstructure:"compression"`       // 加密前的压缩算法：none（默认）、gzip 或 zstd；解密时按对象头识别，修改后旧对象仍可读取
+	Log                 string        `mapstructure:"log"`               // 日志文件路径，为空时不写日志文件
+	LogFormat           string        `mapstructure:"log_format"`        // 日志文件的格式：text（默认）或 json，界面日志不受影响
+	LogMaxSizeMB        int           `mapstructure:"log_max_size_mb"`   // 日志文件超过该大小（MB）时轮转，0 表示不轮转
+	LogMaxBackups       int           `mapstructure:"log_max_backups"`   // 轮转时保留的旧日志文件数，0 表示默认 3 个
+	TargetDir           string        `mapstructure:"target_dir"`
+	Storage             Storage       `mapstructure:"storage"`
+	LogLevel            int           `mapstructure:"log_level"`
+	LogMaxLines         int           `mapstructure:"log_max_lines"`           // 界面日志保留的最大行数，0 表示不限制
+	LogView             string        `mapstructure:"log_view"`                // 界面日志的显示方式：grid（等宽表格）或 rich（自动换行）
+	UploadOnRename      bool          `mapstructure:"upload_on_rename"`        // 重命名后是否以新名称重新上传
+	SkipSyncConfirm     bool          `mapstructure:"skip_sync_confirm"`       // 同步前不再弹出确认框，用于脚本或无界面运行
+	KeepSelection       bool          `mapstructure:"keep_selection_after_op"` // 操作后刷新列表时保留仍存在的选中项，而不是清除选择
+	AutoSyncIgnore      []string      `mapstructure:"auto_sync_ignore"`        // 自动同步忽略的文件名模式，在内置的编辑器临时文件模式之外
+	IncludeHidden       bool          `mapstructure:"include_hidden"`          // 目录上传和同步上传是否包含以 . 开头的隐藏文件
+	PreserveEmptyDirs   bool          `mapstructure:"preserve_empty_dirs"`     // 目录上传时为空目录上传 .fers-keep 占位文件，下载时还原为空目录
+	UseTrash            bool          `mapstructure:"use_trash"`               // 删除本地文件时移入工作目录下的 .fers-trash 而不是永久删除
+	ObfuscateKeys       bool          `mapstructure:"obfuscate_keys"`          // 远程对象以路径的 HMAC 为键保存，真实路径只记录在加密清单中
+	Notifications       bool          `mapstructure:"notifications"`           // 操作完成或失败时发送系统通知
+	MinFileSize         int64         `mapstructure:"min_file_size"`           // 目录上传和同步上传跳过小于该字节数的文件，0 表示不限制
+	MaxFileSize         int64         `mapstructure:"max_file_size"`           // 目录上传和同步上传跳过大于该字节数的文件，0 表示不限制
+	IncludeExtensions   []string      `mapstructure:"include_extensions"`      // 设置后目录上传和同步上传只包含这些扩展名，如 ".txt"，不区分大小写
+	ExcludeExtensions   []string      `mapstructure:"exclude_extensions"`      // 目录上传和同步上传跳过这些扩展名，在 include_extensions 之后应用
+	OperationTimeout    time.Duration `mapstructure:"operation_timeout"`       // 界面操作的总超时，如 "30m"；0 表示不限制
+	PerFileTimeout      time.Duration `mapstructure:"per_file_timeout"`        // 单个文件上传或下载的超时，如 "2m"；0 表示不限制
+	DownloadConcurrency int           `mapstructure:"download_concurrency"`    // 同步下载同时下载的文件数，0 表示默认值 4
+	SymlinkMode         string        `mapstructure:"symlink_mode"`            // 符号链接的处理方式：skip（默认）、follow 或 store
+	QuarantineAfter     int           `mapstructure:"quarantine_after"`        // 同步中失败多少次后隔离该文件，之后的同步跳过它；0 表示默认值 3，负数表示不隔离
+	Sync                Sync          `mapstructure:"sync"`
+	Sources             []Source      `mapstructure:"sources"` // 多个备份集，为空时只使用 target_dir
+}
+
+// Source is one backup set: a working directory whose remote keys are stored
+// under the source name, optionally with its own crypto key and storage
+type Source struct {
+	Name      string   `mapstructure:"name"`
+	TargetDir string   `mapstructure:"target_dir"`
+	CryptoKey string   `mapstructure:"crypto_key"` // 为空时使用顶层的 crypto_key
+	Storage   *Storage `mapstructure:"storage"`    // 为空时使用顶层的 storage
+}
+
+// ForSource returns a copy of c for source s, with its target_dir, and its
+// crypto_key and storage when set
+func (c *Config) ForSource(s Source) *Config {
+	sc := *c
+	sc.TargetDir = s.TargetDir
+	if s.CryptoKey != "" {
+		sc.CryptoKey = s.CryptoKey
+	}
+	if s.Storage != nil {
+		sc.Storage = *s.Storage
+	}
+	sc.Sources = nil
+	return &sc
+}
+
+// CipherOptions returns the options of the cipher selected by the config,
+// failing for an unknown compression
+func (c *Config) CipherOptions() ([]crypto.AESGCMOption, error) {
+	compressor, err := crypto.CompressorByName(c.Compression)
+	if err != nil {
+		return nil, fmt.Errorf("invalid compression: %w", err)
+	}
+	if compressor == nil {
+		return nil, nil
+	}
+	return []crypto.AESGCMOption{crypto.WithCompressor(compressor)}, nil
+}
+
+// ValidateSources checks that every source has a target_dir and a unique
+// name that can be used as a remote folder
+func (c *Config) ValidateSources() error {
+	seen := make(map[string]bool, len(c.Sources))
+	for i, s := range c.Sources {
+		if s.Name == "" || strings.ContainsAny(s.Name, `/\`) || s.Name == "." || s.Name == ".." {
+			return fmt.Errorf("source %d: invalid name %q", i+1, s.Name)
+		}
+		if seen[s.Name] {
+			return fmt.Errorf("source %q is defined twice", s.Name)
+		}
+		seen[s.Name] = true
+		if s.TargetDir == "" {
+			return fmt.Errorf("source %q: target_dir is required", s.Name)
+		}
+	}
+	return nil
+}
+
+// Sync contains the scheduled sync configuration
+type Sync struct {
+	Interval time.Duration `mapstructure:"interval"` // 定时同步间隔，如 "15m"；0 表示启动时不开启定时同步
+	Mode     string        `mapstructure:"mode"`     // upload、download 或 both，默认 upload
 }
 
 type Storage struct {
@@ -23,7 +116,8 @@ type Storage struct {
 }
 
 type Localhost struct {
-	Workdir string `mapstructure:"work_dir"`
+	Workdir   string `mapstructure:"work_dir"`
+	Namespace string `mapstructure:"namespace"` // work_dir 下的子目录，多台机器共用同一目录时各用一个
 }
 
 // OSS contains the configuration for OSS client
@@ -32,9 +126,27 @@ type OSS struct {
 	Endpoint        string `mapstructure:"endpoint"`
 	AccessKeyID     string `mapstructure:"access_key_id"`
 	AccessKeySecret string `mapstructure:"access_key_secret"`
+	CredentialsEnc  string `mapstructure:"credentials_enc"` // 加密的 access key，用 FERS_CREDENTIALS_KEY 解密后覆盖上面两项
 	BucketName      string `mapstructure:"bucket_name"`
 	Region          string `mapstructure:"region"`
 	WorkDir         string `mapstructure:"workDir"`
+	Namespace       string `mapstructure:"namespace"` // 加在 workDir 之后，多台机器共用一个 bucket 时各用一个，为空表示不使用
+
+	// HTTP 传输设置，未设置时使用 SDK 默认值
+	ConnectTimeout time.Duration `mapstructure:"connect_timeout"`
+	ReadTimeout    time.Duration `mapstructure:"read_timeout"`
+	WriteTimeout   time.Duration `mapstructure:"write_timeout"`
+	RequestTimeout time.Duration `mapstructure:"request_timeout"` // 0 表示不限制整个请求的时长
+	Proxy          string        `mapstructure:"proxy"`
+
+	// 带宽限制（字节/秒），0 表示不限制
+	MaxUploadBps   int64 `mapstructure:"max_upload_bps"`
+	MaxDownloadBps int64 `mapstructure:"max_download_bps"`
+
+	// 分片上传，0 使用默认值（超过 64 MiB 分片，每片 16 MiB，4 片并行）
+	MultipartThreshold int64 `mapstructure:"multipart_threshold"` // 超过该字节数的对象分片上传，负数表示不分片
+	PartSize           int64 `mapstructure:"part_size"`           // 每片字节数，至少 100 KiB
+	PartConcurrency    int   `mapstructure:"part_concurrency"`    // 同时上传的分片数
 }
 
 func NewConfig() (*Config, error) {
@@ -46,41 +158,100 @@ func NewConfig() (*Config, error) {
 	return config, nil
 }
 
-// LoadFromFile 使用Viper从配置文件加载配置
+// SupportedConfigTypes lists the config file extensions LoadFromFile accepts, in search order
+var SupportedConfigTypes = []string{"yaml", "yml", "json", "toml"}
+
+// LoadFromFile 使用Viper从配置文件加载配置。configName 可以是不带扩展名的名称，
+// 此时在搜索路径中依次尝试 SupportedConfigTypes 中的扩展名；也可以是带扩展名的文件名或路径，
+// 格式由扩展名决定
 func LoadFromFile(configName string) (*Config, error) {
 	v := viper.New()
 
-	v.SetDefault("log_level", 0) // 默认日志级别为INFO
+	v.SetDefault("log_level", 0)        // 默认日志级别为INFO
+	v.SetDefault("log_max_lines", 1000) // 默认界面日志保留1000行
+	v.SetDefault("log_view", "grid")    // 默认使用等宽表格显示界面日志
 
-	// 设置配置文件名（不包含扩展名）
-	v.SetConfigName(configName)
-	v.SetConfigType("yaml")
+	configFile, err := findConfigFile(configName, configSearchPaths())
+	if err != nil {
+		return nil, fmt.Errorf("read config failed, %w", err)
+	}
+	// Viper 根据扩展名选择解析器
+	v.SetConfigFile(configFile)
 
-	// 添加配置文件搜索路径
+	// 读取配置文件
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("read config failed, %w", err)
+	}
+
+	// 将配置解析到结构体
+	var config Config
+	if err := v.Unmarshal(&config); err != nil {
+		return nil, fmt.Errorf("unable to decode config into struct: %w", err)
+	}
+	if err := config.ValidateSources(); err != nil {
+		return nil, err
+	}
+	if _, err := config.CipherOptions(); err != nil {
+		return nil, err
+	}
+	// 在创建存储客户端之前解密凭据
+	if err := config.ResolveCredentials(os.Getenv(CredentialsKeyEnv)); err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+// configSearchPaths returns the directories searched for the config file
+func configSearchPaths() []string {
 	// 1. 当前工作目录
-	v.AddConfigPath(".")
+	paths := []string{"."}
 
 	// 2. 可执行文件所在目录
 	if execPath, err := os.Executable(); err == nil {
-		execDir := filepath.Dir(execPath)
-		v.AddConfigPath(execDir)
+		paths = append(paths, filepath.Dir(execPath))
 	}
 
 	// 3. 用户主目录
 	if homeDir, err := os.UserHomeDir(); err == nil {
-		v.AddConfigPath(fmt.Sprintf("%s/.fers", homeDir))
+		paths = append(paths, filepath.Join(homeDir, ".fers"))
 	}
+	return paths
+}
 
-	// 读取配置文件
-	if err := v.ReadInConfig(); err != nil {
-		return nil, fmt.Errorf("read config failed, %w", err)
+// configTypeOf returns the config type of name's extension, or "" when it is not supported
+func configTypeOf(name string) string {
+	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(name)), ".")
+	if slices.Contains(SupportedConfigTypes, ext) {
+		return ext
 	}
+	return ""
+}
 
-	// 将配置解析到结构体
-	var config Config
-	if err := v.Unmarshal(&config); err != nil {
-		return nil, fmt.Errorf("unable to decode config into struct: %w", err)
+// findConfigFile returns the config file for configName; the error wraps
+// os.ErrNotExist when no supported file is found
+func findConfigFile(configName string, searchPaths []string) (string, error) {
+	var candidates []string
+	if configTypeOf(configName) != "" {
+		if filepath.IsAbs(configName) || strings.ContainsAny(configName, "/"+string(filepath.Separator)) {
+			candidates = []string{configName}
+		} else {
+			for _, dir := range searchPaths {
+				candidates = append(candidates, filepath.Join(dir, configName))
+			}
+		}
+	} else {
+		for _, dir := range searchPaths {
+			for _, ext := range SupportedConfigTypes {
+				candidates = append(candidates, filepath.Join(dir, configName+"."+ext))
+			}
+		}
 	}
 
-	return &config, nil
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("config file %q not found in %v: %w", configName, searchPaths, os.ErrNotExist)
 }
@@ -1,9 +1,16 @@
 package config
 
 import (
+	"bytes"
+	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/mingregister/fers/pkg/crypto"
 )
 
 func TestLoadFromFile_ValidConfig(t *testing.T) {
@@ -157,6 +164,15 @@ storage:
 		t.Errorf("Expected default LogLevel 0, got %d", config.LogLevel)
 	}
 
+	// Test default log max lines
+	if config.LogMaxLines != 1000 {
+		t.Errorf("Expected default LogMaxLines 1000, got %d", config.LogMaxLines)
+	}
+
+	if config.LogView != "grid" {
+		t.Errorf("Expected default LogView 'grid', got '%s'", config.LogView)
+	}
+
 	if config.Storage.RemoteType != "localhost" {
 		t.Errorf("Expected RemoteType 'localhost', got '%s'", config.Storage.RemoteType)
 	}
@@ -370,6 +386,69 @@ storage:
 	if config.LogLevel != 0 {
 		t.Errorf("Expected default LogLevel 0, got %d", config.LogLevel)
 	}
+
+	// Test default log max lines
+	if config.LogMaxLines != 1000 {
+		t.Errorf("Expected default LogMaxLines 1000, got %d", config.LogMaxLines)
+	}
+
+	// Renamed entries are not re-uploaded by default
+	if config.UploadOnRename {
+		t.Error("Expected default UploadOnRename false")
+	}
+
+	// Syncs ask for confirmation by default
+	if config.SkipSyncConfirm {
+		t.Error("Expected default SkipSyncConfirm false")
+	}
+
+	// Scheduled sync is off by default
+	if config.Sync.Interval != 0 || config.Sync.Mode != "" {
+		t.Errorf("Expected empty Sync section, got %+v", config.Sync)
+	}
+}
+
+func TestLoadFromFile_SyncSection(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "config.yaml")
+
+	configContent := `
+crypto_key: "test-key"
+target_dir: "/tmp/test"
+operation_timeout: "30m"
+per_file_timeout: "2m"
+sync:
+  interval: "15m"
+  mode: "both"
+`
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create test config file: %v", err)
+	}
+
+	originalDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current directory: %v", err)
+	}
+	defer os.Chdir(originalDir)
+
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("Failed to change to temp directory: %v", err)
+	}
+
+	config, err := LoadFromFile("config")
+	if err != nil {
+		t.Fatalf("LoadFromFile failed: %v", err)
+	}
+
+	if config.Sync.Interval != 15*time.Minute {
+		t.Errorf("Expected Sync.Interval 15m, got %s", config.Sync.Interval)
+	}
+	if config.Sync.Mode != "both" {
+		t.Errorf("Expected Sync.Mode 'both', got '%s'", config.Sync.Mode)
+	}
+	if config.OperationTimeout != 30*time.Minute || config.PerFileTimeout != 2*time.Minute {
+		t.Errorf("Expected timeouts 30m and 2m, got %s and %s", config.OperationTimeout, config.PerFileTimeout)
+	}
 }
 
 func TestConfig_StructTags(t *testing.T) {
@@ -451,3 +530,237 @@ storage:
 		t.Errorf("workDir mapping failed: expected '/tag/work', got '%s'", config.Storage.Oss.WorkDir)
 	}
 }
+
+// sameConfigInFormats is one logical config written as YAML, JSON and TOML
+var sameConfigInFormats = map[string]string{
+	"yaml": `
+crypto_key: "format-key"
+target_dir: "/tmp/format"
+log_level: -4
+auto_sync_ignore: ["*.tmp", "*.bak"]
+per_file_timeout: "2m"
+sync:
+  interval: "15m"
+  mode: "both"
+storage:
+  remote_type: "oss"
+  oss:
+    bucket_name: "format-bucket"
+    workDir: "/oss/work"
+    max_upload_bps: 1048576
+`,
+	"json": `{
+  "crypto_key": "format-key",
+  "target_dir": "/tmp/format",
+  "log_level": -4,
+  "auto_sync_ignore": ["*.tmp", "*.bak"],
+  "per_file_timeout": "2m",
+  "sync": {"interval": "15m", "mode": "both"},
+  "storage": {
+    "remote_type": "oss",
+    "oss": {"bucket_name": "format-bucket", "workDir": "/oss/work", "max_upload_bps": 1048576}
+  }
+}`,
+	"toml": `
+crypto_key = "format-key"
+target_dir = "/tmp/format"
+log_level = -4
+auto_sync_ignore = ["*.tmp", "*.bak"]
+per_file_timeout = "2m"
+
+[sync]
+interval = "15m"
+mode = "both"
+
+[storage]
+remote_type = "oss"
+
+[storage.oss]
+bucket_name = "format-bucket"
+workDir = "/oss/work"
+max_upload_bps = 1048576
+`,
+}
+
+func TestLoadFromFile_Formats(t *testing.T) {
+	var loaded []*Config
+	for _, ext := range []string{"yaml", "json", "toml"} {
+		tempDir := t.TempDir()
+		path := filepath.Join(tempDir, "config."+ext)
+		if err := os.WriteFile(path, []byte(sameConfigInFormats[ext]), 0644); err != nil {
+			t.Fatalf("Failed to create %s config: %v", ext, err)
+		}
+		t.Chdir(tempDir)
+
+		// 只给名称时按扩展名查找
+		byName, err := LoadFromFile("config")
+		if err != nil {
+			t.Fatalf("LoadFromFile(config) with a %s file failed: %v", ext, err)
+		}
+		byPath, err := LoadFromFile(path)
+		if err != nil {
+			t.Fatalf("LoadFromFile(%s) failed: %v", path, err)
+		}
+		if !reflect.DeepEqual(byName, byPath) {
+			t.Errorf("%s: loading by name and by path differ:\n%+v\n%+v", ext, byName, byPath)
+		}
+		loaded = append(loaded, byName)
+	}
+
+	for i, cfg := range loaded[1:] {
+		if !reflect.DeepEqual(loaded[0], cfg) {
+			t.Errorf("Config %d differs from the YAML one:\n got %+v\nwant %+v", i+1, cfg, loaded[0])
+		}
+	}
+	if loaded[0].Sync.Interval != 15*time.Minute || loaded[0].Storage.Oss.MaxUploadBps != 1048576 ||
+		len(loaded[0].AutoSyncIgnore) != 2 || loaded[0].LogMaxLines != 1000 {
+		t.Errorf("Unexpected config %+v", loaded[0])
+	}
+}
+
+func TestLoadFromFile_SearchOrder(t *testing.T) {
+	tempDir := t.TempDir()
+	for ext, content := range map[string]string{
+		"yaml": `crypto_key: "from-yaml"`,
+		"toml": `crypto_key = "from-toml"`,
+		"ini":  "crypto_key = from-ini",
+	} {
+		if err := os.WriteFile(filepath.Join(tempDir, "config."+ext), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create config.%s: %v", ext, err)
+		}
+	}
+	t.Chdir(tempDir)
+
+	config, err := LoadFromFile("config")
+	if err != nil {
+		t.Fatalf("LoadFromFile failed: %v", err)
+	}
+	if config.CryptoKey != "from-yaml" {
+		t.Errorf("YAML should be preferred, got %q", config.CryptoKey)
+	}
+	config, err = LoadFromFile("config.toml")
+	if err != nil {
+		t.Fatalf("LoadFromFile(config.toml) failed: %v", err)
+	}
+	if config.CryptoKey != "from-toml" {
+		t.Errorf("An explicit extension should select that file, got %q", config.CryptoKey)
+	}
+
+	if err := os.Remove(filepath.Join(tempDir, "config.yaml")); err != nil {
+		t.Fatalf("Failed to remove config.yaml: %v", err)
+	}
+	if err := os.Remove(filepath.Join(tempDir, "config.toml")); err != nil {
+		t.Fatalf("Failed to remove config.toml: %v", err)
+	}
+	if _, err := LoadFromFile("config"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Unsupported formats should not be loaded, got %v", err)
+	}
+}
+
+func TestLoadFromFile_Sources(t *testing.T) {
+	tempDir := t.TempDir()
+	configContent := `
+crypto_key: "shared-key"
+target_dir: "/tmp/default"
+storage:
+  remote_type: "oss"
+  oss:
+    bucket_name: "shared-bucket"
+sources:
+  - name: documents
+    target_dir: "/home/me/Documents"
+  - name: photos
+    target_dir: "/home/me/Photos"
+    crypto_key: "photos-key"
+    storage:
+      remote_type: "localhost"
+      localhost:
+        work_dir: "/mnt/backup"
+`
+	if err := os.WriteFile(filepath.Join(tempDir, "config.yaml"), []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to create test config file: %v", err)
+	}
+	t.Chdir(tempDir)
+
+	config, err := LoadFromFile("config")
+	if err != nil {
+		t.Fatalf("LoadFromFile failed: %v", err)
+	}
+	if len(config.Sources) != 2 {
+		t.Fatalf("Expected 2 sources, got %+v", config.Sources)
+	}
+
+	docs := config.ForSource(config.Sources[0])
+	if docs.TargetDir != "/home/me/Documents" || docs.CryptoKey != "shared-key" || docs.Storage.Oss.BucketName != "shared-bucket" {
+		t.Errorf("documents should share crypto and storage, got %+v", docs)
+	}
+	photos := config.ForSource(config.Sources[1])
+	if photos.TargetDir != "/home/me/Photos" || photos.CryptoKey != "photos-key" ||
+		photos.Storage.RemoteType != "localhost" || photos.Storage.Localhost.Workdir != "/mnt/backup" {
+		t.Errorf("photos should override crypto and storage, got %+v", photos)
+	}
+	if config.TargetDir != "/tmp/default" || config.CryptoKey != "shared-key" {
+		t.Error("ForSource should not modify the shared config")
+	}
+}
+
+func TestConfig_ValidateSources(t *testing.T) {
+	tests := []struct {
+		name    string
+		sources []Source
+		valid   bool
+	}{
+		{"none", nil, true},
+		{"two", []Source{{Name: "docs", TargetDir: "/a"}, {Name: "photos", TargetDir: "/b"}}, true},
+		{"missing name", []Source{{TargetDir: "/a"}}, false},
+		{"slash in name", []Source{{Name: "a/b", TargetDir: "/a"}}, false},
+		{"dot dot", []Source{{Name: "..", TargetDir: "/a"}}, false},
+		{"duplicate", []Source{{Name: "docs", TargetDir: "/a"}, {Name: "docs", TargetDir: "/b"}}, false},
+		{"missing target_dir", []Source{{Name: "docs"}}, false},
+	}
+	for _, tt := range tests {
+		err := (&Config{Sources: tt.sources}).ValidateSources()
+		if (err == nil) != tt.valid {
+			t.Errorf("%s: expected valid=%v, got %v", tt.name, tt.valid, err)
+		}
+	}
+}
+
+func TestConfig_CipherOptions(t *testing.T) {
+	data := []byte(strings.Repeat("compressible ", 1000))
+	for _, compression := range []string{"", "none", "gzip", "zstd"} {
+		cfg := &Config{Compression: compression}
+		options, err := cfg.CipherOptions()
+		if err != nil {
+			t.Fatalf("CipherOptions(%q) failed: %v", compression, err)
+		}
+		encrypted, err := crypto.NewAESGCM("key", options...).Encrypt(data)
+		if err != nil {
+			t.Fatalf("Encrypt failed: %v", err)
+		}
+		compressed := len(encrypted) < len(data)
+		if want := compression == "gzip" || compression == "zstd"; compressed != want {
+			t.Errorf("compression %q: expected compressed %v, got %d bytes from %d", compression, want, len(encrypted), len(data))
+		}
+		// 不论写入时是否压缩，默认的 cipher 都能解密
+		if plain, err := crypto.NewAESGCM("key").Decrypt(encrypted); err != nil || !bytes.Equal(plain, data) {
+			t.Errorf("compression %q: decrypt failed: %v", compression, err)
+		}
+	}
+
+	if _, err := (&Config{Compression: "lz4"}).CipherOptions(); !errors.Is(err, crypto.ErrUnknownCompressor) {
+		t.Errorf("Expected an unknown compression to fail, got %v", err)
+	}
+}
+
+func TestLoadFromFile_InvalidCompression(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, "config.yaml"), []byte("compression: \"lz4\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test config file: %v", err)
+	}
+	t.Chdir(tempDir)
+
+	if _, err := LoadFromFile("config"); err == nil || !strings.Contains(err.Error(), "compression") {
+		t.Errorf("Expected the unknown compression to be rejected, got %v", err)
+	}
+}
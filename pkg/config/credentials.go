@@ -0,0 +1,98 @@
+package config
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mingregister/fers/pkg/crypto"
+)
+
+// CredentialsKeyEnv is the environment variable holding the key that decrypts storage.oss.credentials_enc
+const CredentialsKeyEnv = "FERS_CREDENTIALS_KEY"
+
+// ErrCredentials is returned when storage.oss.credentials_enc cannot be decrypted
+var ErrCredentials = errors.New("cannot decrypt storage.oss.credentials_enc")
+
+// ossCredentials is the plaintext of credentials_enc
+type ossCredentials struct {
+	AccessKeyID     string `json:"access_key_id"`
+	AccessKeySecret string `json:"access_key_secret"`
+}
+
+// EncryptCredentials returns the credentials_enc value holding an OSS access
+// key encrypted with key
+func EncryptCredentials(key, accessKeyID, accessKeySecret string) (string, error) {
+	if key == "" {
+		return "", fmt.Errorf("%s is not set", CredentialsKeyEnv)
+	}
+	if accessKeyID == "" || accessKeySecret == "" {
+		return "", errors.New("access key id and secret are required")
+	}
+	plain, err := json.Marshal(ossCredentials{AccessKeyID: accessKeyID, AccessKeySecret: accessKeySecret})
+	if err != nil {
+		return "", err
+	}
+	cipher := crypto.NewAESGCM(key)
+	defer closeCipher(cipher)
+	encrypted, err := cipher.Encrypt(plain)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(encrypted), nil
+}
+
+// ResolveCredentials decrypts the credentials_enc of the storage and of every
+// source storage with key into their access key fields, which it overrides
+func (c *Config) ResolveCredentials(key string) error {
+	if err := c.Storage.Oss.resolveCredentials(key); err != nil {
+		return err
+	}
+	for i := range c.Sources {
+		if s := c.Sources[i].Storage; s != nil {
+			if err := s.Oss.resolveCredentials(key); err != nil {
+				return fmt.Errorf("source %q: %w", c.Sources[i].Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// resolveCredentials decrypts CredentialsEnc, if set, into AccessKeyID and AccessKeySecret
+func (o *OSS) resolveCredentials(key string) error {
+	if o.CredentialsEnc == "" {
+		return nil
+	}
+	if key == "" {
+		return fmt.Errorf("%w: %s is not set", ErrCredentials, CredentialsKeyEnv)
+	}
+	encrypted, err := base64.StdEncoding.DecodeString(strings.TrimSpace(o.CredentialsEnc))
+	if err != nil {
+		return fmt.Errorf("%w: not valid base64: %w", ErrCredentials, err)
+	}
+
+	cipher := crypto.NewAESGCM(key)
+	defer closeCipher(cipher)
+	plain, err := cipher.Decrypt(encrypted)
+	if err != nil {
+		// 不能用解密失败的结果去连接存储
+		return fmt.Errorf("%w: wrong %s or corrupted value: %w", ErrCredentials, CredentialsKeyEnv, err)
+	}
+	var creds ossCredentials
+	if err := json.Unmarshal(plain, &creds); err != nil || creds.AccessKeyID == "" || creds.AccessKeySecret == "" {
+		return fmt.Errorf("%w: the decrypted value holds no access key", ErrCredentials)
+	}
+	o.AccessKeyID = creds.AccessKeyID
+	o.AccessKeySecret = creds.AccessKeySecret
+	return nil
+}
+
+// closeCipher wipes the key of cipher when it supports it
+func closeCipher(cipher crypto.Cipher) {
+	if closer, ok := cipher.(io.Closer); ok {
+		closer.Close()
+	}
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeCredentialsConfig writes a config with credentials_enc set to blob and returns its path
+func writeCredentialsConfig(t *testing.T, blob string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := `
+crypto_key: "data-key"
+target_dir: "/tmp/creds-test"
+storage:
+  remote_type: "oss"
+  oss:
+    endpoint: "oss-cn-beijing.aliyuncs.com"
+    bucket_name: "my-bucket"
+    credentials_enc: "` + blob + `"
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	return path
+}
+
+func TestLoadFromFile_ResolvesEncryptedCredentials(t *testing.T) {
+	blob, err := EncryptCredentials("creds-key", "LTAI-id", "top-secret")
+	if err != nil {
+		t.Fatalf("EncryptCredentials failed: %v", err)
+	}
+	path := writeCredentialsConfig(t, blob)
+	t.Setenv(CredentialsKeyEnv, "creds-key")
+
+	cfg, err := LoadFromFile(path)
+	if err != nil {
+		t.Fatalf("LoadFromFile failed: %v", err)
+	}
+	if cfg.Storage.Oss.AccessKeyID != "LTAI-id" || cfg.Storage.Oss.AccessKeySecret != "top-secret" {
+		t.Errorf("Expected the decrypted credentials, got %q / %q", cfg.Storage.Oss.AccessKeyID, cfg.Storage.Oss.AccessKeySecret)
+	}
+}
+
+func TestLoadFromFile_EncryptedCredentialsWrongKey(t *testing.T) {
+	blob, err := EncryptCredentials("creds-key", "LTAI-id", "top-secret")
+	if err != nil {
+		t.Fatalf("EncryptCredentials failed: %v", err)
+	}
+	path := writeCredentialsConfig(t, blob)
+
+	t.Setenv(CredentialsKeyEnv, "wrong-key")
+	if _, err := LoadFromFile(path); !errors.Is(err, ErrCredentials) {
+		t.Errorf("Expected ErrCredentials with the wrong key, got %v", err)
+	}
+
+	t.Setenv(CredentialsKeyEnv, "")
+	if _, err := LoadFromFile(path); !errors.Is(err, ErrCredentials) {
+		t.Errorf("Expected ErrCredentials without a key, got %v", err)
+	}
+}
+
+func TestConfig_ResolveCredentials(t *testing.T) {
+	blob, err := EncryptCredentials("creds-key", "source-id", "source-secret")
+	if err != nil {
+		t.Fatalf("EncryptCredentials failed: %v", err)
+	}
+
+	cfg := &Config{
+		Storage: Storage{Oss: OSS{AccessKeyID: "plain-id", AccessKeySecret: "plain-secret"}},
+		Sources: []Source{{Name: "photos", Storage: &Storage{Oss: OSS{CredentialsEnc: blob}}}},
+	}
+	if err := cfg.ResolveCredentials("creds-key"); err != nil {
+		t.Fatalf("ResolveCredentials failed: %v", err)
+	}
+	if cfg.Storage.Oss.AccessKeyID != "plain-id" {
+		t.Errorf("Plain credentials without credentials_enc should be kept, got %q", cfg.Storage.Oss.AccessKeyID)
+	}
+	if got := cfg.Sources[0].Storage.Oss; got.AccessKeyID != "source-id" || got.AccessKeySecret != "source-secret" {
+		t.Errorf("Expected the source credentials to be decrypted, got %q / %q", got.AccessKeyID, got.AccessKeySecret)
+	}
+
+	garbage := &Config{Storage: Storage{Oss: OSS{CredentialsEnc: "not base64!"}}}
+	if err := garbage.ResolveCredentials("creds-key"); !errors.Is(err, ErrCredentials) {
+		t.Errorf("Expected ErrCredentials for an invalid value, got %v", err)
+	}
+}
@@ -0,0 +1,171 @@
+package crypto
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compressor compresses plaintext before it is encrypted. ID is written to the
+// ciphertext header, so Decrypt finds the matching codec in the registry
+// without the caller naming it. Decompress must fail with ErrTooLarge instead
+// of producing more than limit bytes.
+type Compressor interface {
+	Compress(data []byte) ([]byte, error)
+	Decompress(data []byte, limit int64) ([]byte, error)
+	ID() byte
+}
+
+// IDs of the built-in codecs
+const (
+	CompressionNone byte = 0
+	CompressionGzip byte = 1
+	CompressionZstd byte = 2
+)
+
+// Built-in codecs, registered by default
+var (
+	NoCompression   Compressor = noneCompressor{}
+	GzipCompression Compressor = gzipCompressor{}
+	ZstdCompression Compressor = &zstdCompressor{}
+)
+
+// ErrUnknownCompressor is returned by Decrypt when the ciphertext names a codec
+// that is not registered
+var ErrUnknownCompressor = errors.New("unknown compression codec")
+
+var (
+	compressorsMu sync.RWMutex
+	compressors   = map[byte]Compressor{
+		CompressionNone: NoCompression,
+		CompressionGzip: GzipCompression,
+		CompressionZstd: ZstdCompression,
+	}
+)
+
+// RegisterCompressor makes c available to Decrypt under c.ID(). Registering an
+// ID that is already taken fails, since existing ciphertexts depend on it.
+func RegisterCompressor(c Compressor) error {
+	compressorsMu.Lock()
+	defer compressorsMu.Unlock()
+	if existing, ok := compressors[c.ID()]; ok {
+		return fmt.Errorf("compression codec id %d already registered by %T", c.ID(), existing)
+	}
+	compressors[c.ID()] = c
+	return nil
+}
+
+// CompressorByName returns the built-in codec configured as name: "gzip" or
+// "zstd". "" and "none" return nil, which keeps writing the format without
+// codec byte.
+func CompressorByName(name string) (Compressor, error) {
+	switch strings.ToLower(name) {
+	case "", "none":
+		return nil, nil
+	case "gzip":
+		return GzipCompression, nil
+	case "zstd":
+		return ZstdCompression, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownCompressor, name)
+	}
+}
+
+// CompressorByID returns the registered codec with id
+func CompressorByID(id byte) (Compressor, bool) {
+	compressorsMu.RLock()
+	defer compressorsMu.RUnlock()
+	c, ok := compressors[id]
+	return c, ok
+}
+
+type noneCompressor struct{}
+
+func (noneCompressor) Compress(data []byte) ([]byte, error) { return data, nil }
+func (noneCompressor) Decompress(data []byte, limit int64) ([]byte, error) {
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%w: %d bytes, limit %d", ErrTooLarge, len(data), limit)
+	}
+	return data, nil
+}
+func (noneCompressor) ID() byte { return CompressionNone }
+
+type gzipCompressor struct{}
+
+func (gzipCompressor) Compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gzipCompressor) Decompress(data []byte, limit int64) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return readLimited(r, limit)
+}
+
+func (gzipCompressor) ID() byte { return CompressionGzip }
+
+// zstdCompressor shares one encoder, which is safe for concurrent EncodeAll
+// calls; each Decompress streams through its own decoder so that the limit
+// bounds the memory used
+type zstdCompressor struct {
+	once    sync.Once
+	encoder *zstd.Encoder
+	err     error
+}
+
+func (z *zstdCompressor) Compress(data []byte) ([]byte, error) {
+	z.once.Do(func() {
+		z.encoder, z.err = zstd.NewWriter(nil)
+	})
+	if z.err != nil {
+		return nil, z.err
+	}
+	return z.encoder.EncodeAll(data, nil), nil
+}
+
+func (z *zstdCompressor) Decompress(data []byte, limit int64) ([]byte, error) {
+	d, err := zstd.NewReader(bytes.NewReader(data),
+		zstd.WithDecoderConcurrency(1),
+		zstd.WithDecoderMaxMemory(uint64(max(limit, 1))))
+	if err != nil {
+		return nil, err
+	}
+	defer d.Close()
+	plain, err := readLimited(d, limit)
+	if errors.Is(err, zstd.ErrDecoderSizeExceeded) {
+		return nil, fmt.Errorf("%w: decompressed data exceeds %d bytes", ErrTooLarge, limit)
+	}
+	return plain, err
+}
+
+func (z *zstdCompressor) ID() byte { return CompressionZstd }
+
+// readLimited reads r to the end, failing once it has more than limit bytes
+// so that a small compressed object cannot make it allocate more (压缩炸弹)
+func readLimited(r io.Reader, limit int64) ([]byte, error) {
+	plain, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(plain)) > limit {
+		return nil, fmt.Errorf("%w: decompressed data exceeds %d bytes", ErrTooLarge, limit)
+	}
+	return plain, nil
+}
@@ -0,0 +1,155 @@
+package crypto
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCompressors_RoundTrip(t *testing.T) {
+	data := []byte(strings.Repeat("compressible text ", 1000))
+	for _, c := range []Compressor{NoCompression, GzipCompression, ZstdCompression} {
+		compressed, err := c.Compress(data)
+		if err != nil {
+			t.Fatalf("%T: Compress failed: %v", c, err)
+		}
+		if c.ID() != CompressionNone && len(compressed) >= len(data) {
+			t.Errorf("%T: expected repetitive data to shrink, got %d bytes from %d", c, len(compressed), len(data))
+		}
+		plain, err := c.Decompress(compressed, DefaultMaxPlaintextSize)
+		if err != nil || !bytes.Equal(plain, data) {
+			t.Errorf("%T: round trip failed: %v", c, err)
+		}
+		if registered, ok := CompressorByID(c.ID()); !ok || registered != c {
+			t.Errorf("%T: expected to be registered under id %d", c, c.ID())
+		}
+	}
+}
+
+func TestAESGCM_DecryptPicksCompressor(t *testing.T) {
+	data := []byte(strings.Repeat("hello zstd ", 500))
+	encrypted, err := NewAESGCMWithOptions("password", ZstdCompression).Encrypt(data)
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	if !bytes.HasPrefix(encrypted, append(bytes.Clone(formatV3Header), CompressionZstd)) {
+		t.Fatalf("Expected the v3 header with the zstd id, got %x", encrypted[:len(formatV3Header)+1])
+	}
+	if len(encrypted) >= len(data) {
+		t.Errorf("Expected the ciphertext to be compressed, got %d bytes from %d", len(encrypted), len(data))
+	}
+
+	// 解密方不需要知道压缩算法
+	plain, err := NewAESGCM("password").Decrypt(encrypted)
+	if err != nil {
+		t.Fatalf("Decrypt failed: %v", err)
+	}
+	if !bytes.Equal(plain, data) {
+		t.Error("Decrypted data does not match")
+	}
+
+	// 没有压缩器时仍写 v2 格式，旧版本可以读取
+	v2, _ := NewAESGCM("password").Encrypt(data)
+	if !bytes.HasPrefix(v2, formatV2Header) {
+		t.Errorf("Expected the v2 header without a compressor, got %x", v2[:len(formatV2Header)])
+	}
+}
+
+func TestAESGCM_CompressedHeaderAuthenticated(t *testing.T) {
+	c := NewAESGCMWithOptions("password", GzipCompression)
+	encrypted, err := c.Encrypt([]byte("data"))
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	// 篡改压缩算法 ID 必须导致认证失败
+	encrypted[len(formatV3Header)] = CompressionNone
+	if _, err := c.Decrypt(encrypted); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("Expected ErrDecrypt for a tampered codec id, got %v", err)
+	}
+
+	encrypted[len(formatV3Header)] = 200
+	if _, err := c.Decrypt(encrypted); !errors.Is(err, ErrUnknownCompressor) {
+		t.Errorf("Expected ErrUnknownCompressor, got %v", err)
+	}
+}
+
+type reverseCompressor struct{}
+
+func (reverseCompressor) Compress(data []byte) ([]byte, error) {
+	out := bytes.Clone(data)
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return out, nil
+}
+func (r reverseCompressor) Decompress(data []byte, limit int64) ([]byte, error) {
+	return r.Compress(data)
+}
+func (reverseCompressor) ID() byte { return 100 }
+
+func TestRegisterCompressor(t *testing.T) {
+	if err := RegisterCompressor(GzipCompression); err == nil {
+		t.Error("Expected registering a taken id to fail")
+	}
+	if err := RegisterCompressor(reverseCompressor{}); err != nil {
+		t.Fatalf("RegisterCompressor failed: %v", err)
+	}
+	t.Cleanup(func() {
+		compressorsMu.Lock()
+		delete(compressors, reverseCompressor{}.ID())
+		compressorsMu.Unlock()
+	})
+
+	key := bytes.Repeat([]byte{1}, MasterKeySize)
+	enc, _ := NewAESGCMWithKey(key, WithCompressor(reverseCompressor{}))
+	encrypted, err := enc.Encrypt([]byte("abc"))
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	dec, _ := NewAESGCMWithKey(key)
+	if plain, err := dec.Decrypt(encrypted); err != nil || string(plain) != "abc" {
+		t.Errorf("Expected the registered codec to be used, got %q, %v", plain, err)
+	}
+}
+
+func TestAESGCM_CompressedMaxPlaintextSize(t *testing.T) {
+	data := make([]byte, 4096)
+	for _, c := range []Compressor{GzipCompression, ZstdCompression} {
+		encrypted, err := NewAESGCMWithOptions("password", c).Encrypt(data)
+		if err != nil {
+			t.Fatalf("%T: Encrypt failed: %v", c, err)
+		}
+		// 压缩后的密文很小，但解压后的明文仍受限制
+		small := NewAESGCM("password", WithMaxPlaintextSize(1024))
+		if _, err := small.Decrypt(encrypted); !errors.Is(err, ErrTooLarge) {
+			t.Errorf("%T: expected ErrTooLarge for a large decompressed plaintext, got %v", c, err)
+		}
+	}
+}
+
+func TestCompressors_DecompressStopsAtLimit(t *testing.T) {
+	data := make([]byte, 1<<20)
+	for _, c := range []Compressor{NoCompression, GzipCompression, ZstdCompression} {
+		compressed, _ := c.Compress(data)
+		// 解压时就按上限停止，而不是解压完整的明文后再检查
+		if _, err := c.Decompress(compressed, 1024); !errors.Is(err, ErrTooLarge) {
+			t.Errorf("%T: expected ErrTooLarge, got %v", c, err)
+		}
+		if plain, err := c.Decompress(compressed, int64(len(data))); err != nil || len(plain) != len(data) {
+			t.Errorf("%T: expected data of exactly the limit to decompress, got %d bytes, %v", c, len(plain), err)
+		}
+	}
+}
+
+func TestCompressorByName(t *testing.T) {
+	for name, want := range map[string]Compressor{"": nil, "none": nil, "gzip": GzipCompression, "ZSTD": ZstdCompression} {
+		got, err := CompressorByName(name)
+		if err != nil || got != want {
+			t.Errorf("CompressorByName(%q) = %v, %v; want %v", name, got, err, want)
+		}
+	}
+	if _, err := CompressorByName("lz4"); !errors.Is(err, ErrUnknownCompressor) {
+		t.Errorf("Expected ErrUnknownCompressor, got %v", err)
+	}
+}
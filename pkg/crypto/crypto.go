@@ -1,12 +1,17 @@
 package crypto
 
 import (
+	"bytes"
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/hmac"
 	"crypto/rand"
 	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"sync"
 )
 
 // 加密行为
@@ -20,28 +25,181 @@ type Decrypter interface {
 }
 
 // 加解密一体
+// 实现可以同时实现 io.Closer 以便在不再需要时清除密钥, Close 之后不能再使用该 Cipher.
 type Cipher interface {
 	Encrypter
 	Decrypter
 }
 
-var _ Cipher = (*aesGCM)(nil)
+// KeyHasher is implemented by ciphers that can derive deterministic, keyed
+// names, e.g. to store objects without revealing their paths
+type KeyHasher interface {
+	// HashKey returns the hex HMAC of name under a subkey of the master key
+	HashKey(name string) (string, error)
+}
+
+// ErrCipherClosed is returned by Encrypt and Decrypt after Close.
+var ErrCipherClosed = errors.New("cipher is closed")
+
+// ErrTooLarge is returned by Encrypt and Decrypt when the plaintext would exceed
+// the maximum plaintext size of the cipher.
+var ErrTooLarge = errors.New("plaintext exceeds the maximum size")
+
+// DefaultMaxPlaintextSize is the largest plaintext a cipher accepts unless
+// configured with WithMaxPlaintextSize
+const DefaultMaxPlaintextSize int64 = 2 << 30
+
+// ErrDecrypt is returned by Decrypt when the data cannot be authenticated,
+// usually because it was encrypted with a different key.
+var ErrDecrypt = errors.New("decryption failed: wrong key or corrupted data")
+
+var (
+	_ Cipher    = (*aesGCM)(nil)
+	_ io.Closer = (*aesGCM)(nil)
+	_ KeyHasher = (*aesGCM)(nil)
+)
 
 type aesGCM struct {
-	key []byte
+	mu           sync.RWMutex
+	key          []byte
+	closed       bool
+	maxPlaintext int64      // 可加解密的最大明文字节数
+	compressor   Compressor // 加密前压缩，nil 时写入不带压缩信息的 v2 格式
 }
 
-func NewAESGCM(password string) Cipher {
+// AESGCMOption configures optional behaviour of the cipher returned by NewAESGCM
+type AESGCMOption func(*aesGCM)
+
+// WithMaxPlaintextSize sets the largest plaintext Encrypt accepts and Decrypt returns
+func WithMaxPlaintextSize(size int64) AESGCMOption {
+	return func(ag *aesGCM) {
+		if size > 0 {
+			ag.maxPlaintext = size
+		}
+	}
+}
+
+// WithCompressor compresses plaintexts with c before encrypting them. Decrypt
+// does not need it: the codec is recorded in each ciphertext.
+func WithCompressor(c Compressor) AESGCMOption {
+	return func(ag *aesGCM) {
+		ag.compressor = c
+	}
+}
+
+func NewAESGCM(password string, options ...AESGCMOption) Cipher {
 	h := sha256.Sum256([]byte(password))
-	return &aesGCM{key: h[:]}
+	ag := &aesGCM{key: h[:], maxPlaintext: DefaultMaxPlaintextSize}
+	for _, option := range options {
+		option(ag)
+	}
+	return ag
 }
 
-func (ag *aesGCM) Encrypt(plain []byte) ([]byte, error) {
-	block, err := aes.NewCipher(ag.key)
+// NewAESGCMWithOptions returns a cipher like NewAESGCM that compresses
+// plaintexts with compressor before encrypting them
+func NewAESGCMWithOptions(password string, compressor Compressor, options ...AESGCMOption) Cipher {
+	return NewAESGCM(password, append(options, WithCompressor(compressor))...)
+}
+
+// MasterKeySize is the length of the raw master keys accepted by NewAESGCMWithKey
+const MasterKeySize = 32
+
+// NewAESGCMWithKey returns a cipher using key, e.g. from a keystore, as the
+// master key instead of deriving it from a password. key is copied.
+func NewAESGCMWithKey(key []byte, options ...AESGCMOption) (Cipher, error) {
+	if len(key) != MasterKeySize {
+		return nil, fmt.Errorf("master key must be %d bytes, got %d", MasterKeySize, len(key))
+	}
+	ag := &aesGCM{key: bytes.Clone(key), maxPlaintext: DefaultMaxPlaintextSize}
+	for _, option := range options {
+		option(ag)
+	}
+	return ag, nil
+}
+
+// Close overwrites the key with zeros. The cipher must not be used afterwards.
+func (ag *aesGCM) Close() error {
+	ag.mu.Lock()
+	defer ag.mu.Unlock()
+	clear(ag.key)
+	ag.closed = true
+	return nil
+}
+
+// HashKey returns the HMAC-SHA256 of name under the key name subkey; the same
+// password always gives the same result
+func (ag *aesGCM) HashKey(name string) (string, error) {
+	ag.mu.RLock()
+	defer ag.mu.RUnlock()
+	if ag.closed {
+		return "", ErrCipherClosed
+	}
+
+	subkey, err := deriveKey(ag.key, nil, keyNameInfo)
+	if err != nil {
+		return "", err
+	}
+	defer clear(subkey)
+	mac := hmac.New(sha256.New, subkey)
+	mac.Write([]byte(name))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// formatV2Header marks ciphertexts whose key is derived with HKDF; the legacy
+// format is just nonce + ciphertext under the SHA-256 key
+var formatV2Header = []byte{'F', 'E', 'R', 'S', 2}
+
+// formatV3Header is followed by the compression codec ID and then the v2
+// layout; the header and ID are authenticated as additional data
+var formatV3Header = []byte{'F', 'E', 'R', 'S', 3}
+
+// newGCM returns the AEAD for salt: with a salt the key is the HKDF encryption
+// subkey of the master key, without one the master key itself (legacy format)
+func (ag *aesGCM) newGCM(salt []byte) (cipher.AEAD, error) {
+	ag.mu.RLock()
+	defer ag.mu.RUnlock()
+	if ag.closed {
+		return nil, ErrCipherClosed
+	}
+
+	key := ag.key
+	if salt != nil {
+		subkey, err := deriveKey(ag.key, salt, encryptionInfo)
+		if err != nil {
+			return nil, err
+		}
+		defer clear(subkey)
+		key = subkey
+	}
+
+	block, err := aes.NewCipher(key)
 	if err != nil {
 		return nil, err
 	}
-	gcm, err := cipher.NewGCM(block)
+	return cipher.NewGCM(block)
+}
+
+// Encrypt encrypts plain in the versioned format: header + salt + nonce + ciphertext
+func (ag *aesGCM) Encrypt(plain []byte) ([]byte, error) {
+	if int64(len(plain)) > ag.maxPlaintext {
+		return nil, fmt.Errorf("%w: %d bytes, limit %d", ErrTooLarge, len(plain), ag.maxPlaintext)
+	}
+	header := formatV2Header
+	if ag.compressor != nil {
+		compressed, err := ag.compressor.Compress(plain)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compress: %w", err)
+		}
+		plain = compressed
+		header = append(bytes.Clone(formatV3Header), ag.compressor.ID())
+	}
+
+	salt := make([]byte, saltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	gcm, err := ag.newGCM(salt)
 	if err != nil {
 		return nil, err
 	}
@@ -49,26 +207,102 @@ func (ag *aesGCM) Encrypt(plain []byte) ([]byte, error) {
 	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
 		return nil, err
 	}
-	ciphertext := gcm.Seal(nil, nonce, plain, nil)
-	// store nonce + ciphertext
-	out := append(nonce, ciphertext...)
-	return out, nil
+
+	out := make([]byte, 0, len(header)+len(salt)+len(nonce)+len(plain)+gcm.Overhead())
+	out = append(out, header...)
+	out = append(out, salt...)
+	out = append(out, nonce...)
+	var additionalData []byte
+	if ag.compressor != nil {
+		additionalData = header
+	}
+	return gcm.Seal(out, nonce, plain, additionalData), nil
 }
 
+// Decrypt decrypts the compressed, the versioned and the legacy format
 func (ag *aesGCM) Decrypt(cipherData []byte) (plain []byte, err error) {
-	block, err := aes.NewCipher(ag.key)
+	switch {
+	case bytes.HasPrefix(cipherData, formatV3Header):
+		plain, err = ag.decryptV3(cipherData)
+	case bytes.HasPrefix(cipherData, formatV2Header):
+		plain, err = ag.decryptV2(cipherData[len(formatV2Header):])
+	default:
+		return ag.decryptLegacy(cipherData)
+	}
+	if err == nil || errors.Is(err, ErrCipherClosed) || errors.Is(err, ErrTooLarge) {
+		return plain, err
+	}
+	// 旧格式的随机 nonce 恰好以版本头开头时，按旧格式再试一次
+	if legacy, legacyErr := ag.decryptLegacy(cipherData); legacyErr == nil {
+		return legacy, nil
+	}
+	return nil, err
+}
+
+// decryptV3 decrypts header + codec ID + salt + nonce + ciphertext and
+// decompresses the result with the registered codec
+func (ag *aesGCM) decryptV3(data []byte) ([]byte, error) {
+	headerSize := len(formatV3Header) + 1
+	if len(data) < headerSize+saltSize {
+		return nil, fmt.Errorf("%w: ciphertext too short", ErrDecrypt)
+	}
+	header := data[:headerSize]
+	compressor, ok := CompressorByID(header[len(formatV3Header)])
+	if !ok {
+		return nil, fmt.Errorf("%w: id %d", ErrUnknownCompressor, header[len(formatV3Header)])
+	}
+	gcm, err := ag.newGCM(data[headerSize : headerSize+saltSize])
 	if err != nil {
 		return nil, err
 	}
-	gcm, err := cipher.NewGCM(block)
+	compressed, err := ag.openNonceCiphertext(gcm, data[headerSize+saltSize:], header)
 	if err != nil {
 		return nil, err
 	}
+	plain, err := compressor.Decompress(compressed, ag.maxPlaintext)
+	if errors.Is(err, ErrTooLarge) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: decompress: %w", ErrDecrypt, err)
+	}
+	return plain, nil
+}
+
+// decryptV2 decrypts salt + nonce + ciphertext
+func (ag *aesGCM) decryptV2(data []byte) ([]byte, error) {
+	if len(data) < saltSize {
+		return nil, fmt.Errorf("%w: ciphertext too short", ErrDecrypt)
+	}
+	gcm, err := ag.newGCM(data[:saltSize])
+	if err != nil {
+		return nil, err
+	}
+	return ag.openNonceCiphertext(gcm, data[saltSize:], nil)
+}
+
+// decryptLegacy decrypts nonce + ciphertext under the master key
+func (ag *aesGCM) decryptLegacy(data []byte) ([]byte, error) {
+	gcm, err := ag.newGCM(nil)
+	if err != nil {
+		return nil, err
+	}
+	return ag.openNonceCiphertext(gcm, data, nil)
+}
+
+// openNonceCiphertext opens nonce + ciphertext with gcm, rejecting data whose
+// plaintext would exceed the maximum size before anything is allocated
+func (ag *aesGCM) openNonceCiphertext(gcm cipher.AEAD, data, additionalData []byte) ([]byte, error) {
 	nonceSize := gcm.NonceSize()
-	if len(cipherData) < nonceSize {
-		return nil, fmt.Errorf("ciphertext too short")
+	if len(data) < nonceSize+gcm.Overhead() {
+		return nil, fmt.Errorf("%w: ciphertext too short", ErrDecrypt)
+	}
+	if size := int64(len(data) - nonceSize - gcm.Overhead()); size > ag.maxPlaintext {
+		return nil, fmt.Errorf("%w: %d bytes, limit %d", ErrTooLarge, size, ag.maxPlaintext)
+	}
+	plain, err := gcm.Open(nil, data[:nonceSize], data[nonceSize:], additionalData)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDecrypt, err)
 	}
-	nonce := cipherData[:nonceSize]
-	ct := cipherData[nonceSize:]
-	return gcm.Open(nil, nonce, ct, nil)
+	return plain, nil
 }
@@ -2,6 +2,9 @@ package crypto
 
 import (
 	"bytes"
+	"errors"
+	"io"
+	"strings"
 	"testing"
 )
 
@@ -20,6 +23,28 @@ func TestNewAESGCM(t *testing.T) {
 	}
 }
 
+func TestNewAESGCMWithKey(t *testing.T) {
+	if _, err := NewAESGCMWithKey(make([]byte, 16)); err == nil {
+		t.Error("Expected a short master key to be rejected")
+	}
+
+	key := bytes.Repeat([]byte{7}, MasterKeySize)
+	c, err := NewAESGCMWithKey(key)
+	if err != nil {
+		t.Fatalf("NewAESGCMWithKey failed: %v", err)
+	}
+	// 调用方清除自己的副本不影响 cipher
+	clear(key)
+	encrypted, err := c.Encrypt([]byte("data"))
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	other, _ := NewAESGCMWithKey(bytes.Repeat([]byte{7}, MasterKeySize))
+	if plain, err := other.Decrypt(encrypted); err != nil || string(plain) != "data" {
+		t.Errorf("Expected the same key to decrypt, got %q, %v", plain, err)
+	}
+}
+
 func TestAESGCM_EncryptDecrypt(t *testing.T) {
 	password := "test-password-123"
 	cipher := NewAESGCM(password)
@@ -139,8 +164,8 @@ func TestAESGCM_DecryptInvalidData(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			_, err := cipher.Decrypt(tc.invalidData)
-			if err == nil {
-				t.Error("Decrypt should fail with invalid data")
+			if !errors.Is(err, ErrDecrypt) {
+				t.Errorf("Expected ErrDecrypt with invalid data, got %v", err)
 			}
 		})
 	}
@@ -160,8 +185,8 @@ func TestAESGCM_DifferentPasswords(t *testing.T) {
 
 	// Try to decrypt with second cipher (different password)
 	_, err = cipher2.Decrypt(encrypted)
-	if err == nil {
-		t.Error("Decryption should fail when using different password")
+	if !errors.Is(err, ErrDecrypt) {
+		t.Errorf("Expected ErrDecrypt when using different password, got %v", err)
 	}
 
 	// Verify first cipher can still decrypt correctly
@@ -184,6 +209,100 @@ func TestAESGCM_InterfaceCompliance(t *testing.T) {
 	var _ Decrypter = cipher
 }
 
+func TestAESGCM_Close(t *testing.T) {
+	c := NewAESGCM("test-password")
+	encrypted, err := c.Encrypt([]byte("secret"))
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+
+	closer, ok := c.(io.Closer)
+	if !ok {
+		t.Fatal("aesGCM should implement io.Closer")
+	}
+	if err := closer.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	key := c.(*aesGCM).key
+	if !bytes.Equal(key, make([]byte, len(key))) {
+		t.Errorf("Key should be zeroed after Close, got %x", key)
+	}
+
+	if _, err := c.Encrypt([]byte("again")); !errors.Is(err, ErrCipherClosed) {
+		t.Errorf("Expected ErrCipherClosed from Encrypt, got %v", err)
+	}
+	if _, err := c.Decrypt(encrypted); !errors.Is(err, ErrCipherClosed) {
+		t.Errorf("Expected ErrCipherClosed from Decrypt, got %v", err)
+	}
+}
+
+func TestAESGCM_HashKey(t *testing.T) {
+	hasher := NewAESGCM("password1").(KeyHasher)
+
+	first, err := hasher.HashKey("docs/report.pdf")
+	if err != nil {
+		t.Fatalf("HashKey failed: %v", err)
+	}
+	again, _ := NewAESGCM("password1").(KeyHasher).HashKey("docs/report.pdf")
+	if first != again {
+		t.Errorf("HashKey should be deterministic, got %s and %s", first, again)
+	}
+	if len(first) != 64 || strings.Contains(first, "report") {
+		t.Errorf("Expected 64 hex characters, got %q", first)
+	}
+
+	other, _ := hasher.HashKey("docs/report2.pdf")
+	otherPassword, _ := NewAESGCM("password2").(KeyHasher).HashKey("docs/report.pdf")
+	if other == first || otherPassword == first {
+		t.Error("HashKey should differ for other names and other passwords")
+	}
+
+	hasher.(io.Closer).Close()
+	if _, err := hasher.HashKey("docs/report.pdf"); !errors.Is(err, ErrCipherClosed) {
+		t.Errorf("Expected ErrCipherClosed after Close, got %v", err)
+	}
+}
+
+func TestAESGCM_MaxPlaintextSize(t *testing.T) {
+	const limit = 64
+	c := NewAESGCM("password1", WithMaxPlaintextSize(limit))
+
+	atLimit, err := c.Encrypt(make([]byte, limit))
+	if err != nil {
+		t.Fatalf("Encrypt at the limit failed: %v", err)
+	}
+	if plain, err := c.Decrypt(atLimit); err != nil || len(plain) != limit {
+		t.Errorf("Decrypt at the limit failed: %d bytes, %v", len(plain), err)
+	}
+	if _, err := c.Encrypt(make([]byte, limit+1)); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("Expected ErrTooLarge from Encrypt over the limit, got %v", err)
+	}
+
+	// 更大限制的 cipher 加密的数据在解密前即被拒绝
+	overLimit, err := NewAESGCM("password1").Encrypt(make([]byte, limit+1))
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	if _, err := c.Decrypt(overLimit); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("Expected ErrTooLarge from Decrypt over the limit, got %v", err)
+	}
+	legacy := NewAESGCM("password1").(*aesGCM)
+	gcm, err := legacy.newGCM(nil)
+	if err != nil {
+		t.Fatalf("newGCM failed: %v", err)
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	legacyData := gcm.Seal(nonce, nonce, make([]byte, limit+1), nil)
+	if _, err := c.Decrypt(legacyData); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("Expected ErrTooLarge from Decrypt of the legacy format, got %v", err)
+	}
+
+	if got := NewAESGCM("password1").(*aesGCM).maxPlaintext; got != DefaultMaxPlaintextSize {
+		t.Errorf("Expected the default limit %d, got %d", DefaultMaxPlaintextSize, got)
+	}
+}
+
 func BenchmarkAESGCM_Encrypt(b *testing.B) {
 	cipher := NewAESGCM("benchmark-password")
 	data := bytes.Repeat([]byte("benchmark data "), 100) // ~1.5KB
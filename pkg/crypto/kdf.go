@@ -0,0 +1,22 @@
+package crypto
+
+import (
+	"crypto/hkdf"
+	"crypto/sha256"
+)
+
+const (
+	// saltSize 每条消息随机生成的 HKDF salt 长度
+	saltSize = 16
+	// subkeySize AES-256 和 HMAC-SHA256 使用的子密钥长度
+	subkeySize = 32
+
+	// 不同用途使用不同的 info 标签，保证派生出的子密钥互不相同
+	encryptionInfo = "fers/v2 encryption"
+	keyNameInfo    = "fers/v2 key names"
+)
+
+// deriveKey derives the subkey for info from the master key and salt using HKDF-SHA256
+func deriveKey(master, salt []byte, info string) ([]byte, error) {
+	return hkdf.Key(sha256.New, master, salt, info, subkeySize)
+}
@@ -0,0 +1,118 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"testing"
+)
+
+func TestDeriveKey_Differs(t *testing.T) {
+	master := sha256.Sum256([]byte("test-password"))
+	salt := bytes.Repeat([]byte{1}, saltSize)
+
+	enc, err := deriveKey(master[:], salt, encryptionInfo)
+	if err != nil {
+		t.Fatalf("deriveKey failed: %v", err)
+	}
+	if len(enc) != subkeySize {
+		t.Fatalf("Unexpected subkey size %d", len(enc))
+	}
+	if bytes.Equal(enc, master[:]) {
+		t.Error("Encryption subkey should differ from the master key")
+	}
+
+	names, err := deriveKey(master[:], salt, keyNameInfo)
+	if err != nil {
+		t.Fatalf("deriveKey failed: %v", err)
+	}
+	if bytes.Equal(enc, names) {
+		t.Error("Encryption and key name subkeys should differ")
+	}
+
+	other, err := deriveKey(master[:], bytes.Repeat([]byte{2}, saltSize), encryptionInfo)
+	if err != nil {
+		t.Fatalf("deriveKey failed: %v", err)
+	}
+	if bytes.Equal(enc, other) {
+		t.Error("Different salts should derive different subkeys")
+	}
+}
+
+func TestAESGCM_EncryptUsesDerivedSubkey(t *testing.T) {
+	encrypted, err := NewAESGCM("test-password").Encrypt([]byte("hello"))
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	if !bytes.HasPrefix(encrypted, formatV2Header) {
+		t.Fatal("Encrypt should produce the versioned format")
+	}
+
+	// 按格式手动解出 salt，用派生的加密子密钥解密
+	data := encrypted[len(formatV2Header):]
+	salt := data[:saltSize]
+	master := sha256.Sum256([]byte("test-password"))
+	subkey, err := deriveKey(master[:], salt, encryptionInfo)
+	if err != nil {
+		t.Fatalf("deriveKey failed: %v", err)
+	}
+
+	block, err := aes.NewCipher(subkey)
+	if err != nil {
+		t.Fatalf("NewCipher failed: %v", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatalf("NewGCM failed: %v", err)
+	}
+	nonce := data[saltSize : saltSize+gcm.NonceSize()]
+	plain, err := gcm.Open(nil, nonce, data[saltSize+gcm.NonceSize():], nil)
+	if err != nil {
+		t.Fatalf("Decryption with the derived encryption subkey failed: %v", err)
+	}
+	if string(plain) != "hello" {
+		t.Errorf("Unexpected plaintext %q", plain)
+	}
+
+	// 主密钥本身不能解密新格式
+	block, _ = aes.NewCipher(master[:])
+	gcm, _ = cipher.NewGCM(block)
+	if _, err := gcm.Open(nil, nonce, data[saltSize+gcm.NonceSize():], nil); err == nil {
+		t.Error("The master key should not decrypt the data directly")
+	}
+}
+
+func TestAESGCM_DecryptLegacyFormat(t *testing.T) {
+	master := sha256.Sum256([]byte("test-password"))
+	block, err := aes.NewCipher(master[:])
+	if err != nil {
+		t.Fatalf("NewCipher failed: %v", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatalf("NewGCM failed: %v", err)
+	}
+
+	// 旧格式：nonce + 密文，直接使用 SHA-256 密钥；包括 nonce 恰好以版本头开头的情况
+	randomNonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(randomNonce); err != nil {
+		t.Fatalf("Failed to generate nonce: %v", err)
+	}
+	headerNonce := append(append([]byte{}, formatV2Header...), randomNonce[len(formatV2Header):]...)
+
+	for name, nonce := range map[string][]byte{"random nonce": randomNonce, "nonce with header": headerNonce} {
+		t.Run(name, func(t *testing.T) {
+			legacy := gcm.Seal(append([]byte{}, nonce...), nonce, []byte("legacy data"), nil)
+
+			plain, err := NewAESGCM("test-password").Decrypt(legacy)
+			if err != nil {
+				t.Fatalf("Decrypting the legacy format failed: %v", err)
+			}
+			if string(plain) != "legacy data" {
+				t.Errorf("Unexpected plaintext %q", plain)
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+// Package keystore keeps the random master key of fers in a local file,
+// wrapped with a key derived from a user passphrase by Argon2id.
+package keystore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/argon2"
+
+	"github.com/mingregister/fers/pkg/crypto"
+)
+
+// ErrWrongPassphrase is returned when the passphrase cannot unwrap the master key
+var ErrWrongPassphrase = errors.New("wrong keystore passphrase or corrupted keystore")
+
+// ErrExists is returned by Create when the keystore file already exists
+var ErrExists = errors.New("keystore already exists")
+
+const (
+	formatVersion = 1
+	kdfArgon2id   = "argon2id"
+	saltSize      = 16
+
+	// Argon2id 参数，参考 RFC 9106 推荐的低内存配置
+	defaultTime    = 3
+	defaultMemory  = 64 * 1024 // KiB
+	defaultThreads = 4
+)
+
+// file is the JSON layout of a keystore; []byte fields are stored as base64
+type file struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Salt       []byte `json:"salt"`
+	Time       uint32 `json:"time"`
+	Memory     uint32 `json:"memory"` // KiB
+	Threads    uint8  `json:"threads"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"` // AES-GCM 加密的主密钥
+}
+
+// Create generates a random master key, wraps it with passphrase and writes it
+// to path. An existing keystore is never overwritten, since the files
+// encrypted with its master key could not be decrypted any more.
+func Create(path, passphrase string) error {
+	if passphrase == "" {
+		return errors.New("keystore passphrase must not be empty")
+	}
+	master := make([]byte, crypto.MasterKeySize)
+	if _, err := rand.Read(master); err != nil {
+		return fmt.Errorf("failed to generate master key: %w", err)
+	}
+	defer clear(master)
+
+	data, err := wrap(master, passphrase)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create keystore directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if errors.Is(err, os.ErrExist) {
+		return fmt.Errorf("%w: %s", ErrExists, path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create keystore: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(path)
+		return fmt.Errorf("failed to write keystore: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(path)
+		return fmt.Errorf("failed to write keystore: %w", err)
+	}
+	return nil
+}
+
+// Open reads the keystore at path and returns its master key unwrapped with
+// passphrase. The caller should clear the key once it is no longer needed.
+func Open(path, passphrase string) (masterKey []byte, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keystore: %w", err)
+	}
+	return unwrap(data, passphrase)
+}
+
+// ChangePassphrase rewraps the master key in the keystore at path with
+// newPassphrase. The master key itself, and so every encrypted file, is unchanged.
+func ChangePassphrase(path, oldPassphrase, newPassphrase string) error {
+	if newPassphrase == "" {
+		return errors.New("keystore passphrase must not be empty")
+	}
+	master, err := Open(path, oldPassphrase)
+	if err != nil {
+		return err
+	}
+	defer clear(master)
+
+	data, err := wrap(master, newPassphrase)
+	if err != nil {
+		return err
+	}
+	// 先写临时文件再替换，中途失败不会损坏原有的 keystore
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write keystore: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to replace keystore: %w", err)
+	}
+	return nil
+}
+
+// wrap encrypts master with a key derived from passphrase and a fresh salt
+func wrap(master []byte, passphrase string) ([]byte, error) {
+	f := file{
+		Version: formatVersion,
+		KDF:     kdfArgon2id,
+		Salt:    make([]byte, saltSize),
+		Time:    defaultTime,
+		Memory:  defaultMemory,
+		Threads: defaultThreads,
+	}
+	if _, err := rand.Read(f.Salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	gcm, err := f.aead(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	f.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(f.Nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	f.Ciphertext = gcm.Seal(nil, f.Nonce, master, f.additionalData())
+	return json.MarshalIndent(f, "", "  ")
+}
+
+// unwrap decrypts the master key in the keystore data with passphrase
+func unwrap(data []byte, passphrase string) ([]byte, error) {
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse keystore: %w", err)
+	}
+	if f.Version != formatVersion || f.KDF != kdfArgon2id {
+		return nil, fmt.Errorf("unsupported keystore version %d (kdf %q)", f.Version, f.KDF)
+	}
+	if len(f.Salt) == 0 || f.Time == 0 || f.Memory == 0 || f.Threads == 0 {
+		return nil, errors.New("invalid keystore key derivation parameters")
+	}
+	gcm, err := f.aead(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	if len(f.Nonce) != gcm.NonceSize() {
+		return nil, ErrWrongPassphrase
+	}
+	master, err := gcm.Open(nil, f.Nonce, f.Ciphertext, f.additionalData())
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+	if len(master) != crypto.MasterKeySize {
+		clear(master)
+		return nil, ErrWrongPassphrase
+	}
+	return master, nil
+}
+
+// aead returns the AES-GCM cipher keyed by Argon2id of passphrase under the parameters of f
+func (f *file) aead(passphrase string) (cipher.AEAD, error) {
+	key := argon2.IDKey([]byte(passphrase), f.Salt, f.Time, f.Memory, f.Threads, crypto.MasterKeySize)
+	defer clear(key)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// additionalData binds the ciphertext to the key derivation parameters, so
+// they cannot be weakened without failing authentication
+func (f *file) additionalData() []byte {
+	return fmt.Appendf(nil, "fers keystore v%d %s %x %d %d %d", f.Version, f.KDF, f.Salt, f.Time, f.Memory, f.Threads)
+}
@@ -0,0 +1,148 @@
+package keystore
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mingregister/fers/pkg/crypto"
+)
+
+func TestCreateOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys", "fers.keystore")
+	if err := Create(path, "correct horse"); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Keystore not written: %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected keystore mode 0600, got %v", info.Mode().Perm())
+	}
+
+	master, err := Open(path, "correct horse")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if len(master) != crypto.MasterKeySize {
+		t.Fatalf("Expected a %d byte master key, got %d", crypto.MasterKeySize, len(master))
+	}
+	again, err := Open(path, "correct horse")
+	if err != nil || !bytes.Equal(master, again) {
+		t.Errorf("Expected the same master key on every open, got %x and %x (%v)", master, again, err)
+	}
+
+	// 主密钥可以直接用于加解密
+	c, err := crypto.NewAESGCMWithKey(master)
+	if err != nil {
+		t.Fatalf("NewAESGCMWithKey failed: %v", err)
+	}
+	encrypted, err := c.Encrypt([]byte("hello"))
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	other, _ := crypto.NewAESGCMWithKey(again)
+	if plain, err := other.Decrypt(encrypted); err != nil || string(plain) != "hello" {
+		t.Errorf("Expected to decrypt with the reopened key, got %q, %v", plain, err)
+	}
+}
+
+func TestCreate_RandomMasterKey(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+	if err := Create(a, "same passphrase"); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := Create(b, "same passphrase"); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	keyA, _ := Open(a, "same passphrase")
+	keyB, _ := Open(b, "same passphrase")
+	if bytes.Equal(keyA, keyB) {
+		t.Error("Expected independent master keys for the same passphrase")
+	}
+}
+
+func TestCreate_RefusesOverwrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fers.keystore")
+	if err := Create(path, "first"); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	before, _ := Open(path, "first")
+	if err := Create(path, "second"); !errors.Is(err, ErrExists) {
+		t.Fatalf("Expected ErrExists, got %v", err)
+	}
+	after, err := Open(path, "first")
+	if err != nil || !bytes.Equal(before, after) {
+		t.Errorf("Existing keystore should be untouched, got %v", err)
+	}
+	if err := Create(filepath.Join(t.TempDir(), "empty"), ""); err == nil {
+		t.Error("Expected an empty passphrase to be rejected")
+	}
+}
+
+func TestOpen_WrongPassphrase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fers.keystore")
+	if err := Create(path, "right"); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := Open(path, "wrong"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Expected ErrWrongPassphrase, got %v", err)
+	}
+	if _, err := Open(filepath.Join(t.TempDir(), "missing"), "right"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected a not-exist error for a missing keystore, got %v", err)
+	}
+}
+
+func TestOpen_TamperedParameters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fers.keystore")
+	if err := Create(path, "right"); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		t.Fatalf("Failed to parse keystore: %v", err)
+	}
+	// 降低 Argon2 参数会改变派生的密钥和附加数据，认证必然失败
+	f.Time = 1
+	data, _ = json.Marshal(f)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("Failed to write keystore: %v", err)
+	}
+	if _, err := Open(path, "right"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Expected tampered parameters to fail, got %v", err)
+	}
+}
+
+func TestChangePassphrase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fers.keystore")
+	if err := Create(path, "old"); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	before, _ := Open(path, "old")
+
+	if err := ChangePassphrase(path, "wrong", "new"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Fatalf("Expected ErrWrongPassphrase for the wrong old passphrase, got %v", err)
+	}
+	if err := ChangePassphrase(path, "old", "new"); err != nil {
+		t.Fatalf("ChangePassphrase failed: %v", err)
+	}
+	if _, err := Open(path, "old"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Old passphrase should no longer open the keystore, got %v", err)
+	}
+	after, err := Open(path, "new")
+	if err != nil {
+		t.Fatalf("Open with the new passphrase failed: %v", err)
+	}
+	if !bytes.Equal(before, after) {
+		t.Error("Changing the passphrase should keep the master key")
+	}
+	if _, err := os.Stat(path + ".tmp"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Temporary keystore should be removed, got %v", err)
+	}
+}
@@ -0,0 +1,117 @@
+package crypto
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Score is the advisory strength of a crypto password
+type Score int
+
+const (
+	ScoreWeak Score = iota
+	ScoreMedium
+	ScoreStrong
+)
+
+func (s Score) String() string {
+	switch s {
+	case ScoreWeak:
+		return "weak"
+	case ScoreMedium:
+		return "medium"
+	case ScoreStrong:
+		return "strong"
+	default:
+		return fmt.Sprintf("Score(%d)", int(s))
+	}
+}
+
+const (
+	// recommendedKeyLength 建议的最短密码长度
+	recommendedKeyLength = 12
+	// minKeyLength 短于此长度的密码一律视为弱密码
+	minKeyLength = 8
+
+	// 估算熵（bit）的分级阈值
+	mediumKeyBits = 50
+	strongKeyBits = 80
+)
+
+// commonPasswords are passwords that guessing attacks try first
+var commonPasswords = map[string]bool{
+	"123456": true, "12345678": true, "123456789": true, "1234567890": true,
+	"password": true, "password1": true, "passw0rd": true, "qwerty": true,
+	"qwertyuiop": true, "abc123": true, "111111": true, "iloveyou": true,
+	"admin": true, "letmein": true, "welcome": true, "secret": true,
+	"changeme": true, "fers": true,
+}
+
+// AssessKeyStrength estimates how hard password is to guess from its length,
+// the kinds of characters it mixes and how predictable its characters are.
+// It returns the score and the reasons the password falls short, which may be
+// empty for a strong password. The result is advisory only.
+func AssessKeyStrength(password string) (Score, []string) {
+	runes := []rune(password)
+	var reasons []string
+
+	if commonPasswords[strings.ToLower(password)] {
+		return ScoreWeak, []string{"it is a commonly used password"}
+	}
+	if len(runes) < recommendedKeyLength {
+		reasons = append(reasons, fmt.Sprintf("it is shorter than %d characters", recommendedKeyLength))
+	}
+
+	var lower, upper, digit, other bool
+	for _, r := range runes {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			other = true
+		}
+	}
+	pool, classes := 0, 0
+	for _, c := range []struct {
+		used bool
+		size int
+	}{{lower, 26}, {upper, 26}, {digit, 10}, {other, 33}} {
+		if c.used {
+			pool += c.size
+			classes++
+		}
+	}
+	if classes < 3 {
+		reasons = append(reasons, "it mixes fewer than 3 of lowercase letters, uppercase letters, digits and symbols")
+	}
+
+	// 重复字符（aaaa）和连续字符（abcd、1234）几乎不增加猜测难度
+	predictable := 0
+	for i := 1; i < len(runes); i++ {
+		if d := runes[i] - runes[i-1]; d >= -1 && d <= 1 {
+			predictable++
+		}
+	}
+	if len(runes) > 1 && predictable*2 >= len(runes) {
+		reasons = append(reasons, "it is mostly repeated or sequential characters")
+	}
+
+	bits := 0.0
+	if pool > 0 {
+		bits = float64(len(runes)-predictable) * math.Log2(float64(pool))
+	}
+	switch {
+	case len(runes) < minKeyLength || bits < mediumKeyBits:
+		return ScoreWeak, reasons
+	case bits < strongKeyBits:
+		return ScoreMedium, reasons
+	default:
+		return ScoreStrong, reasons
+	}
+}
@@ -0,0 +1,45 @@
+package crypto
+
+import "testing"
+
+func TestAssessKeyStrength(t *testing.T) {
+	tests := []struct {
+		password string
+		want     Score
+	}{
+		{"", ScoreWeak},
+		{"1234", ScoreWeak},
+		{"password", ScoreWeak},
+		{"Password1", ScoreWeak},
+		{"aaaaaaaaaaaaaaaaaaaa", ScoreWeak},
+		{"abcdefghijklmnopqrst", ScoreWeak},
+		{"correcthorse", ScoreWeak},
+		{"Tr0ub4dor&3", ScoreMedium},
+		{"Summer2024!", ScoreMedium},
+		{"xK9#mP2$vL7@qR4!", ScoreStrong},
+		{"correct horse battery staple", ScoreStrong},
+	}
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			got, reasons := AssessKeyStrength(tt.password)
+			if got != tt.want {
+				t.Errorf("AssessKeyStrength(%q) = %v %v, want %v", tt.password, got, reasons, tt.want)
+			}
+			if got != ScoreStrong && len(reasons) == 0 {
+				t.Errorf("AssessKeyStrength(%q) should explain a %v score", tt.password, got)
+			}
+		})
+	}
+}
+
+func TestAssessKeyStrength_Reasons(t *testing.T) {
+	if _, reasons := AssessKeyStrength("1234"); len(reasons) != 3 {
+		t.Errorf("Expected short, single-class and sequential reasons, got %v", reasons)
+	}
+	if _, reasons := AssessKeyStrength("qwerty"); len(reasons) != 1 || reasons[0] != "it is a commonly used password" {
+		t.Errorf("Expected the common password reason, got %v", reasons)
+	}
+	if _, reasons := AssessKeyStrength("xK9#mP2$vL7@qR4!"); len(reasons) != 0 {
+		t.Errorf("Expected no reasons for a strong key, got %v", reasons)
+	}
+}
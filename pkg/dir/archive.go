@@ -0,0 +1,344 @@
+package dir
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mingregister/fers/pkg/crypto"
+)
+
+// ErrInvalidArchive is returned by ImportArchive for data that is not an
+// intact archive written by ExportArchive with the current key, or that has
+// an entry escaping the working directory
+var ErrInvalidArchive = errors.New("invalid or corrupted archive")
+
+// archiveMagic starts every archive; the tar stream follows as a sequence of
+// chunks, each a 4-byte big-endian length and the encrypted chunk
+var archiveMagic = []byte("FERSARC\x01")
+
+const (
+	// archiveChunkSize is the plaintext size of each encrypted chunk
+	archiveChunkSize = 1 << 20
+	// 每块明文前是 8 字节序号和 1 字节的结束标记，防止块被重排或截断
+	archiveChunkHeader = 9
+	// maxArchiveFrame bounds the length read for one encrypted chunk
+	maxArchiveFrame = 2 * archiveChunkSize
+)
+
+// archiveWriter encrypts what is written to it in numbered chunks
+type archiveWriter struct {
+	w      io.Writer
+	cipher crypto.Encrypter
+	buf    []byte
+	index  uint64
+}
+
+func (a *archiveWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		n := min(len(p), archiveChunkSize-len(a.buf))
+		a.buf = append(a.buf, p[:n]...)
+		p = p[n:]
+		written += n
+		if len(a.buf) == archiveChunkSize {
+			if err := a.flush(false); err != nil {
+				return written, err
+			}
+		}
+	}
+	return written, nil
+}
+
+// Close writes the remaining data as the last chunk
+func (a *archiveWriter) Close() error {
+	return a.flush(true)
+}
+
+func (a *archiveWriter) flush(last bool) error {
+	plain := make([]byte, archiveChunkHeader, archiveChunkHeader+len(a.buf))
+	binary.BigEndian.PutUint64(plain, a.index)
+	if last {
+		plain[8] = 1
+	}
+	plain = append(plain, a.buf...)
+	encrypted, err := a.cipher.Encrypt(plain)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt archive: %w", err)
+	}
+
+	var size [4]byte
+	binary.BigEndian.PutUint32(size[:], uint32(len(encrypted)))
+	if _, err := a.w.Write(size[:]); err != nil {
+		return err
+	}
+	if _, err := a.w.Write(encrypted); err != nil {
+		return err
+	}
+	a.index++
+	a.buf = a.buf[:0]
+	return nil
+}
+
+// archiveReader decrypts the chunks written by archiveWriter, failing on
+// chunks that are out of order, missing or follow the last one
+type archiveReader struct {
+	r      io.Reader
+	cipher crypto.Decrypter
+	buf    []byte
+	index  uint64
+	last   bool
+}
+
+func (a *archiveReader) Read(p []byte) (int, error) {
+	for len(a.buf) == 0 {
+		if a.last {
+			if n, _ := a.r.Read(make([]byte, 1)); n > 0 {
+				return 0, fmt.Errorf("%w: data after the last chunk", ErrInvalidArchive)
+			}
+			return 0, io.EOF
+		}
+		if err := a.next(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, a.buf)
+	a.buf = a.buf[n:]
+	return n, nil
+}
+
+func (a *archiveReader) next() error {
+	var size [4]byte
+	if _, err := io.ReadFull(a.r, size[:]); err != nil {
+		return archiveReadError(err)
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n > maxArchiveFrame {
+		return fmt.Errorf("%w: chunk of %d bytes", ErrInvalidArchive, n)
+	}
+	encrypted := make([]byte, n)
+	if _, err := io.ReadFull(a.r, encrypted); err != nil {
+		return archiveReadError(err)
+	}
+
+	plain, err := a.cipher.Decrypt(encrypted)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidArchive, err)
+	}
+	if len(plain) < archiveChunkHeader || binary.BigEndian.Uint64(plain) != a.index {
+		return fmt.Errorf("%w: chunk %d out of order", ErrInvalidArchive, a.index)
+	}
+	a.index++
+	a.last = plain[8] == 1
+	a.buf = plain[archiveChunkHeader:]
+	return nil
+}
+
+// archiveReadError reports a short read as a truncated archive
+func archiveReadError(err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("%w: truncated", ErrInvalidArchive)
+	}
+	return err
+}
+
+// ExportArchive writes the whole working directory as one encrypted tar
+// archive to dst, which must be outside the working directory. Entries
+// skipped by sync and files matching the auto sync ignore patterns are left
+// out; symlinks are included only when followed.
+func (fm *FileManager) ExportArchive(ctx context.Context, dst string) error {
+	absDst, err := filepath.Abs(dst)
+	if err != nil {
+		return fmt.Errorf("invalid path %q: %w", dst, err)
+	}
+	workingDir, err := filepath.Abs(fm.workingDir)
+	if err != nil {
+		return fmt.Errorf("invalid working directory: %w", err)
+	}
+	if isWithin(workingDir, absDst) {
+		return fmt.Errorf("archive %s must be outside the working directory", dst)
+	}
+
+	files := 0
+	err = writeAtomic(absDst, 0o600, func(f *os.File) error {
+		if _, err := f.Write(archiveMagic); err != nil {
+			return err
+		}
+		aw := &archiveWriter{w: f, cipher: fm.currentCipher()}
+		tw := tar.NewWriter(aw)
+		err := fm.walk(fm.workingDir, func(path string, info os.FileInfo, err error) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err != nil {
+				return fmt.Errorf("walk error at %s: %w", path, err)
+			}
+			if path == fm.workingDir {
+				return nil
+			}
+			if fm.skipEntry(info) || fm.ignored(path) {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !info.IsDir() && !info.Mode().IsRegular() {
+				fm.logger.Debug("Skipping non-regular file in archive", slog.String("path", path))
+				return nil
+			}
+
+			if err := fm.addToArchive(tw, path, info); err != nil {
+				return err
+			}
+			if !info.IsDir() {
+				files++
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if err := tw.Close(); err != nil {
+			return err
+		}
+		return aw.Close()
+	})
+	if err != nil {
+		return fmt.Errorf("failed to export archive %s: %w", dst, err)
+	}
+
+	fm.logger.Info("Archive exported", slog.String("path", dst), slog.Int("files", files))
+	return nil
+}
+
+// addToArchive writes the directory or regular file at path to tw
+func (fm *FileManager) addToArchive(tw *tar.Writer, path string, info os.FileInfo) error {
+	relativePath, err := filepath.Rel(fm.workingDir, path)
+	if err != nil {
+		return fmt.Errorf("failed to get relative path for %s: %w", path, err)
+	}
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	header.Name = filepath.ToSlash(relativePath)
+	if info.IsDir() {
+		header.Name += "/"
+	}
+	// 不保存本机的用户信息
+	header.Uid, header.Gid, header.Uname, header.Gname = 0, 0, "", ""
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrLocalIO, err)
+	}
+	defer f.Close()
+	if _, err := io.Copy(tw, f); err != nil {
+		return fmt.Errorf("failed to archive %s: %w", relativePath, err)
+	}
+	return nil
+}
+
+// ImportArchive extracts an archive written by ExportArchive into the working
+// directory, replacing files with the same path. Every entry must be a
+// directory or regular file within the working directory; the import stops
+// at the first entry that is not, or when the archive turns out truncated.
+func (fm *FileManager) ImportArchive(ctx context.Context, src string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	magic := make([]byte, len(archiveMagic))
+	if _, err := io.ReadFull(r, magic); err != nil || !bytes.Equal(magic, archiveMagic) {
+		return fmt.Errorf("%w: %s is not a fers archive", ErrInvalidArchive, src)
+	}
+	ar := &archiveReader{r: r, cipher: fm.currentCipher()}
+	tr := tar.NewReader(ar)
+
+	files := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read archive: %w", err)
+		}
+		if err := fm.extractArchiveEntry(src, header, tr); err != nil {
+			return err
+		}
+		if header.Typeflag == tar.TypeReg {
+			files++
+		}
+	}
+	// 读到最后一块为止，确认归档没有被截断
+	if _, err := io.Copy(io.Discard, ar); err != nil {
+		return fmt.Errorf("failed to read archive: %w", err)
+	}
+
+	fm.logger.Info("Archive imported", slog.String("path", src), slog.Int("files", files))
+	return nil
+}
+
+// extractArchiveEntry writes one archive entry below the working directory
+func (fm *FileManager) extractArchiveEntry(src string, header *tar.Header, content io.Reader) error {
+	name := filepath.FromSlash(strings.TrimSuffix(header.Name, "/"))
+	if !filepath.IsLocal(name) {
+		return fmt.Errorf("%w: entry %q escapes the working directory", ErrInvalidArchive, header.Name)
+	}
+	path, err := fm.resolveLocalPath(name)
+	if err != nil {
+		return fmt.Errorf("%w: entry %q: %w", ErrInvalidArchive, header.Name, err)
+	}
+
+	switch header.Typeflag {
+	case tar.TypeDir:
+		if err := os.MkdirAll(path, defaultDirMode); err != nil {
+			return fmt.Errorf("%w: %w", ErrLocalIO, err)
+		}
+		return nil
+	case tar.TypeReg:
+		if err := os.MkdirAll(filepath.Dir(path), defaultDirMode); err != nil {
+			return fmt.Errorf("%w: %w", ErrLocalIO, err)
+		}
+		// 已有的链接目录可能指向工作目录之外
+		if err := fm.checkLocalDestination(src, path); err != nil {
+			return fmt.Errorf("%w: entry %q: %w", ErrInvalidArchive, header.Name, err)
+		}
+		perm := header.FileInfo().Mode().Perm()
+		err := writeAtomic(path, perm, func(f *os.File) error {
+			_, err := io.Copy(f, content)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to extract %s: %w", header.Name, err)
+		}
+		if err := os.Chtimes(path, header.ModTime, header.ModTime); err != nil {
+			fm.logger.Warn("Failed to restore modification time", slog.String("path", name), slog.String("error", err.Error()))
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: entry %q has unsupported type %q", ErrInvalidArchive, header.Name, header.Typeflag)
+	}
+}
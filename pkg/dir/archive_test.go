@@ -0,0 +1,150 @@
+package dir
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mingregister/fers/pkg/crypto"
+)
+
+func TestFileManager_ArchiveRoundTrip(t *testing.T) {
+	fm, tempDir, _ := createTestFileManager(t)
+	files := map[string]string{
+		"a.txt":          "alpha",
+		"docs/b.txt":     "bravo",
+		"docs/sub/c.bin": string(bytes.Repeat([]byte{0, 1, 2, 255}, archiveChunkSize/2)), // 跨越多个块
+	}
+	writeFileContents(t, tempDir, files)
+	writeFileContents(t, tempDir, map[string]string{"notes.tmp": "ignored", ".hidden": "hidden"})
+	if err := os.Mkdir(filepath.Join(tempDir, "empty"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+
+	archive := filepath.Join(t.TempDir(), "backup.enc")
+	if err := fm.ExportArchive(context.Background(), archive); err != nil {
+		t.Fatalf("ExportArchive failed: %v", err)
+	}
+	data, err := os.ReadFile(archive)
+	if err != nil {
+		t.Fatalf("Archive not written: %v", err)
+	}
+	if bytes.Contains(data, []byte("bravo")) || bytes.Contains(data, []byte("docs/b.txt")) {
+		t.Error("The archive should not contain plaintext contents or names")
+	}
+
+	// 导入到另一个使用同一密钥的工作目录
+	restore, restoreDir, _ := createTestFileManager(t)
+	if err := restore.ImportArchive(context.Background(), archive); err != nil {
+		t.Fatalf("ImportArchive failed: %v", err)
+	}
+	for name, content := range files {
+		got, err := os.ReadFile(filepath.Join(restoreDir, filepath.FromSlash(name)))
+		if err != nil || string(got) != content {
+			t.Errorf("Expected %s to be restored, got %d bytes, %v", name, len(got), err)
+		}
+	}
+	if info, err := os.Stat(filepath.Join(restoreDir, "empty")); err != nil || !info.IsDir() {
+		t.Errorf("Expected the empty directory to be restored, got %v", err)
+	}
+	for _, name := range []string{"notes.tmp", ".hidden"} {
+		if _, err := os.Stat(filepath.Join(restoreDir, name)); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("Expected %s to be left out of the archive, got %v", name, err)
+		}
+	}
+}
+
+func TestFileManager_ExportArchiveRejectsWorkingDir(t *testing.T) {
+	fm, tempDir, _ := createTestFileManager(t)
+	if err := fm.ExportArchive(context.Background(), filepath.Join(tempDir, "backup.enc")); err == nil {
+		t.Error("Expected an archive inside the working directory to be rejected")
+	}
+}
+
+// writeRawArchive encrypts a tar with the given entries like ExportArchive does
+func writeRawArchive(t *testing.T, cipher crypto.Cipher, entries []tar.Header) string {
+	t.Helper()
+	var buf bytes.Buffer
+	buf.Write(archiveMagic)
+	aw := &archiveWriter{w: &buf, cipher: cipher}
+	tw := tar.NewWriter(aw)
+	for _, header := range entries {
+		content := []byte("payload")
+		if header.Typeflag == tar.TypeReg {
+			header.Size = int64(len(content))
+		}
+		if err := tw.WriteHeader(&header); err != nil {
+			t.Fatalf("WriteHeader failed: %v", err)
+		}
+		if header.Typeflag == tar.TypeReg {
+			tw.Write(content)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("tar Close failed: %v", err)
+	}
+	if err := aw.Close(); err != nil {
+		t.Fatalf("archive Close failed: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "raw.enc")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("Failed to write archive: %v", err)
+	}
+	return path
+}
+
+func TestFileManager_ImportArchiveRejectsEscapingEntries(t *testing.T) {
+	for _, name := range []string{"../evil.txt", "docs/../../evil.txt", "/tmp/evil.txt"} {
+		t.Run(name, func(t *testing.T) {
+			fm, tempDir, _ := createTestFileManager(t)
+			archive := writeRawArchive(t, fm.cipher, []tar.Header{
+				{Name: "ok.txt", Typeflag: tar.TypeReg, Mode: 0644},
+				{Name: name, Typeflag: tar.TypeReg, Mode: 0644},
+			})
+
+			err := fm.ImportArchive(context.Background(), archive)
+			if !errors.Is(err, ErrInvalidArchive) {
+				t.Fatalf("Expected ErrInvalidArchive, got %v", err)
+			}
+			if _, err := os.Stat(filepath.Join(filepath.Dir(tempDir), "evil.txt")); !errors.Is(err, os.ErrNotExist) {
+				t.Error("Nothing should be written outside the working directory")
+			}
+		})
+	}
+
+	fm, _, _ := createTestFileManager(t)
+	archive := writeRawArchive(t, fm.cipher, []tar.Header{
+		{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "/etc"},
+	})
+	if err := fm.ImportArchive(context.Background(), archive); !errors.Is(err, ErrInvalidArchive) {
+		t.Errorf("Expected a symlink entry to be rejected, got %v", err)
+	}
+}
+
+func TestFileManager_ImportArchiveDetectsTampering(t *testing.T) {
+	fm, tempDir, _ := createTestFileManager(t)
+	writeFileContents(t, tempDir, map[string]string{"a.txt": string(bytes.Repeat([]byte("x"), archiveChunkSize+10))})
+	archive := filepath.Join(t.TempDir(), "backup.enc")
+	if err := fm.ExportArchive(context.Background(), archive); err != nil {
+		t.Fatalf("ExportArchive failed: %v", err)
+	}
+	data, _ := os.ReadFile(archive)
+
+	// 去掉最后一块后仍是合法的块序列，但缺少结束标记
+	first := len(archiveMagic)
+	firstSize := int(data[first])<<24 | int(data[first+1])<<16 | int(data[first+2])<<8 | int(data[first+3])
+	truncated := filepath.Join(t.TempDir(), "truncated.enc")
+	os.WriteFile(truncated, data[:first+4+firstSize], 0644)
+	if err := fm.ImportArchive(context.Background(), truncated); !errors.Is(err, ErrInvalidArchive) {
+		t.Errorf("Expected a truncated archive to be rejected, got %v", err)
+	}
+
+	other := NewFileManager(fm.config, fm.storage, fm.logger, crypto.NewAESGCM("another-password"))
+	if err := other.ImportArchive(context.Background(), archive); !errors.Is(err, ErrInvalidArchive) {
+		t.Errorf("Expected the wrong key to be rejected, got %v", err)
+	}
+}
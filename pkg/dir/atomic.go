@@ -0,0 +1,49 @@
+package dir
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// writeTemp writes data to the temporary file, replaced in tests to simulate failures
+var writeTemp = func(f *os.File, data []byte) error {
+	_, err := f.Write(data)
+	return err
+}
+
+// writeFileAtomic writes data to a hidden temporary file next to path and
+// renames it into place, so a failed write never leaves a partial file or
+// replaces an existing one
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	return writeAtomic(path, perm, func(f *os.File) error { return writeTemp(f, data) })
+}
+
+// writeAtomic is writeFileAtomic for content produced by write, e.g. streamed
+func writeAtomic(path string, perm os.FileMode, write func(*os.File) error) (err error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmpPath)
+		}
+	}()
+
+	if err := write(tmp); err != nil {
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
@@ -0,0 +1,85 @@
+package dir
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mingregister/fers/pkg/crypto"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.txt")
+
+	if err := writeFileAtomic(path, []byte("first"), 0o644); err != nil {
+		t.Fatalf("writeFileAtomic failed: %v", err)
+	}
+	if err := writeFileAtomic(path, []byte("second"), 0o644); err != nil {
+		t.Fatalf("writeFileAtomic overwrite failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	if string(data) != "second" {
+		t.Errorf("Expected overwritten content, got %q", data)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Failed to stat file: %v", err)
+	}
+	if info.Mode().Perm() != 0o644 {
+		t.Errorf("Expected mode 0644, got %v", info.Mode().Perm())
+	}
+}
+
+func TestDownloadAndDecryptFile_WriteFailureKeepsOriginal(t *testing.T) {
+	fm, tempDir, mockStore := createTestFileManager(t)
+
+	encrypted, err := crypto.NewAESGCM("test-password").Encrypt([]byte("new remote content"))
+	if err != nil {
+		t.Fatalf("Failed to encrypt: %v", err)
+	}
+	mustUpload(t, mockStore, "file.txt", encrypted)
+
+	localPath := filepath.Join(tempDir, "file.txt")
+	if err := os.WriteFile(localPath, []byte("original content"), 0644); err != nil {
+		t.Fatalf("Failed to create local file: %v", err)
+	}
+
+	// 写入一半后失败
+	writeErr := errors.New("disk full")
+	original := writeTemp
+	writeTemp = func(f *os.File, data []byte) error {
+		f.Write(data[:len(data)/2])
+		return writeErr
+	}
+	defer func() { writeTemp = original }()
+
+	err = fm.DownloadAndDecryptFile("file.txt", localPath)
+	if !errors.Is(err, writeErr) {
+		t.Fatalf("Expected write error, got %v", err)
+	}
+
+	data, err := os.ReadFile(localPath)
+	if err != nil {
+		t.Fatalf("Failed to read local file: %v", err)
+	}
+	if string(data) != "original content" {
+		t.Errorf("Original file should be intact, got %q", data)
+	}
+
+	entries, err := os.ReadDir(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to read directory: %v", err)
+	}
+	if len(entries) != 1 {
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		t.Errorf("Expected no temporary files left behind, got %v", names)
+	}
+}
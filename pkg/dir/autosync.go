@@ -0,0 +1,217 @@
+package dir
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// defaultIgnorePatterns 编辑器临时文件等不需要自动上传的文件名模式
+var defaultIgnorePatterns = []string{"*.swp", "*.swx", "*~", "*.tmp", ".#*", "#*#", "4913"}
+
+// Watcher delivers file system change events for auto sync
+type Watcher interface {
+	Add(path string) error
+	Events() <-chan fsnotify.Event
+	Errors() <-chan error
+	Close() error
+}
+
+// fsWatcher is the fsnotify based Watcher
+type fsWatcher struct {
+	w *fsnotify.Watcher
+}
+
+func newFSWatcher() (Watcher, error) {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	return &fsWatcher{w: w}, nil
+}
+
+func (f *fsWatcher) Add(path string) error         { return f.w.Add(path) }
+func (f *fsWatcher) Events() <-chan fsnotify.Event { return f.w.Events }
+func (f *fsWatcher) Errors() <-chan error          { return f.w.Errors }
+func (f *fsWatcher) Close() error                  { return f.w.Close() }
+
+// StartAutoSync watches the working directory recursively and encrypts and
+// uploads changed files once no further change arrived for debounce. It
+// returns once the watcher is set up; syncing stops when ctx is cancelled.
+func (fm *FileManager) StartAutoSync(ctx context.Context, debounce time.Duration) error {
+	newWatcher := fm.newWatcher
+	if newWatcher == nil {
+		newWatcher = newFSWatcher
+	}
+	w, err := newWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create file watcher: %w", err)
+	}
+	if err := fm.watchRecursive(w, fm.workingDir); err != nil {
+		w.Close()
+		return err
+	}
+
+	fm.logger.Info("Auto sync started", slog.String("dir", fm.workingDir))
+	go fm.runAutoSync(ctx, w, debounce)
+	return nil
+}
+
+// runAutoSync collects change events and uploads the changed paths after the debounce delay
+func (fm *FileManager) runAutoSync(ctx context.Context, w Watcher, debounce time.Duration) {
+	defer w.Close()
+
+	pending := make(map[string]bool)
+	var timer *time.Timer
+	var timerC <-chan time.Time
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			fm.logger.Info("Auto sync stopped")
+			return
+		case event, ok := <-w.Events():
+			if !ok {
+				return
+			}
+			// 仅权限变化不需要上传
+			if event.Op == fsnotify.Chmod || fm.ignored(event.Name) {
+				continue
+			}
+			// 编辑器原子保存会先写临时文件再重命名，统一在防抖结束后按路径当前状态处理
+			pending[event.Name] = true
+			if timer == nil {
+				timer = time.NewTimer(debounce)
+			} else {
+				timer.Reset(debounce)
+			}
+			timerC = timer.C
+		case err, ok := <-w.Errors():
+			if !ok {
+				return
+			}
+			fm.logger.Warn("File watcher error", slog.String("error", err.Error()))
+		case <-timerC:
+			timer, timerC = nil, nil
+			fm.flushAutoSync(ctx, w, pending)
+			pending = make(map[string]bool)
+		}
+	}
+}
+
+// flushAutoSync uploads the changed paths that still exist, watching and
+// uploading the contents of newly created directories
+func (fm *FileManager) flushAutoSync(ctx context.Context, w Watcher, pending map[string]bool) {
+	paths := make([]string, 0, len(pending))
+	for path := range pending {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		if ctx.Err() != nil {
+			return
+		}
+
+		info, err := os.Lstat(path)
+		if err != nil {
+			// 已删除或被重命名走的文件
+			continue
+		}
+
+		if info.IsDir() {
+			if err := fm.watchRecursive(w, path); err != nil {
+				fm.logger.Error("Failed to watch directory", slog.String("path", path), slog.String("error", err.Error()))
+			}
+			fm.autoUploadDirectory(ctx, path)
+			continue
+		}
+		if info.Mode().IsRegular() {
+			fm.autoUploadFile(path)
+		}
+	}
+}
+
+// autoUploadDirectory uploads every non-ignored file below dirPath
+func (fm *FileManager) autoUploadDirectory(ctx context.Context, dirPath string) {
+	err := filepath.WalkDir(dirPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if fm.ignored(path) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Type().IsRegular() {
+			fm.autoUploadFile(path)
+		}
+		return nil
+	})
+	if err != nil && ctx.Err() == nil {
+		fm.logger.Error("Failed to scan directory", slog.String("path", dirPath), slog.String("error", err.Error()))
+	}
+}
+
+// autoUploadFile encrypts and uploads a single changed file
+func (fm *FileManager) autoUploadFile(path string) {
+	relativePath, err := filepath.Rel(fm.workingDir, path)
+	if err != nil {
+		fm.logger.Error("Failed to get relative path", slog.String("path", path), slog.String("error", err.Error()))
+		return
+	}
+	if err := fm.EncryptAndUploadFile(path, relativePath); err != nil {
+		fm.logger.Error("Auto sync upload failed", slog.String("path", relativePath), slog.String("error", err.Error()))
+	}
+}
+
+// watchRecursive adds root and all its non-ignored subdirectories to the watcher
+func (fm *FileManager) watchRecursive(w Watcher, root string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != root && fm.ignored(path) {
+			return filepath.SkipDir
+		}
+		if err := w.Add(path); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", path, err)
+		}
+		return nil
+	})
+}
+
+// ignored reports whether the base name of path is the trash, an upload checkpoint, the quarantine, an excluded hidden name or matches an ignore pattern
+func (fm *FileManager) ignored(path string) bool {
+	name := filepath.Base(path)
+	if name == TrashDirName || name == UploadStateFile || name == QuarantineFile || (!fm.includeHidden && IsHidden(name)) {
+		return true
+	}
+	for _, patterns := range [][]string{defaultIgnorePatterns, fm.config.AutoSyncIgnore} {
+		for _, pattern := range patterns {
+			if matched, _ := filepath.Match(pattern, name); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
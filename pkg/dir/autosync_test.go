@@ -0,0 +1,154 @@
+package dir
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/mingregister/fers/pkg/storage"
+)
+
+// fakeWatcher is a Watcher whose events are sent by the test
+type fakeWatcher struct {
+	mu     sync.Mutex
+	added  []string
+	events chan fsnotify.Event
+	errors chan error
+}
+
+func newFakeWatcher() *fakeWatcher {
+	return &fakeWatcher{
+		events: make(chan fsnotify.Event),
+		errors: make(chan error),
+	}
+}
+
+func (f *fakeWatcher) Add(path string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.added = append(f.added, path)
+	return nil
+}
+
+func (f *fakeWatcher) Events() <-chan fsnotify.Event { return f.events }
+func (f *fakeWatcher) Errors() <-chan error          { return f.errors }
+func (f *fakeWatcher) Close() error                  { return nil }
+
+func (f *fakeWatcher) watching(path string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, p := range f.added {
+		if p == path {
+			return true
+		}
+	}
+	return false
+}
+
+func startFakeAutoSync(t *testing.T, fm *FileManager) *fakeWatcher {
+	t.Helper()
+	watcher := newFakeWatcher()
+	fm.newWatcher = func() (Watcher, error) { return watcher, nil }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	if err := fm.StartAutoSync(ctx, 10*time.Millisecond); err != nil {
+		t.Fatalf("StartAutoSync failed: %v", err)
+	}
+	return watcher
+}
+
+func waitForRemote(t *testing.T, store storage.Client, key string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, err := store.Stat(key); err == nil {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("Expected %s to be uploaded", key)
+}
+
+func TestFileManager_AutoSyncUploadsChangedFile(t *testing.T) {
+	fm, tempDir, store := createTestFileManager(t)
+	watcher := startFakeAutoSync(t, fm)
+
+	if !watcher.watching(tempDir) {
+		t.Fatal("Working directory should be watched")
+	}
+
+	path := filepath.Join(tempDir, "notes.txt")
+	if err := os.WriteFile(path, []byte("hello"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	watcher.events <- fsnotify.Event{Name: path, Op: fsnotify.Create}
+	watcher.events <- fsnotify.Event{Name: path, Op: fsnotify.Write}
+
+	waitForRemote(t, store, "notes.txt")
+}
+
+func TestFileManager_AutoSyncAtomicSave(t *testing.T) {
+	fm, tempDir, store := createTestFileManager(t)
+	watcher := startFakeAutoSync(t, fm)
+
+	// 编辑器先写临时文件，再重命名覆盖目标文件
+	tmpPath := filepath.Join(tempDir, "doc.txt.tmp")
+	target := filepath.Join(tempDir, "doc.txt")
+	if err := os.WriteFile(tmpPath, []byte("saved"), 0644); err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	watcher.events <- fsnotify.Event{Name: tmpPath, Op: fsnotify.Create}
+	if err := os.Rename(tmpPath, target); err != nil {
+		t.Fatalf("Failed to rename: %v", err)
+	}
+	watcher.events <- fsnotify.Event{Name: tmpPath, Op: fsnotify.Rename}
+	watcher.events <- fsnotify.Event{Name: target, Op: fsnotify.Create}
+
+	waitForRemote(t, store, "doc.txt")
+	if _, err := store.Stat("doc.txt.tmp"); err == nil {
+		t.Error("Editor temp file should not be uploaded")
+	}
+}
+
+func TestFileManager_AutoSyncNewDirectory(t *testing.T) {
+	fm, tempDir, store := createTestFileManager(t)
+	watcher := startFakeAutoSync(t, fm)
+
+	subDir := filepath.Join(tempDir, "sub")
+	if err := os.Mkdir(subDir, 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(subDir, "a.txt"), []byte("a"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	watcher.events <- fsnotify.Event{Name: subDir, Op: fsnotify.Create}
+
+	waitForRemote(t, store, "sub/a.txt")
+	if !watcher.watching(subDir) {
+		t.Error("Newly created directory should be watched")
+	}
+}
+
+func TestFileManager_AutoSyncIgnorePatterns(t *testing.T) {
+	fm, tempDir, store := createTestFileManager(t)
+	fm.config.AutoSyncIgnore = []string{"*.log"}
+	watcher := startFakeAutoSync(t, fm)
+
+	for _, name := range []string{"app.log", ".main.go.swp", "keep.txt"} {
+		path := filepath.Join(tempDir, name)
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+		watcher.events <- fsnotify.Event{Name: path, Op: fsnotify.Write}
+	}
+
+	waitForRemote(t, store, "keep.txt")
+	if count := remoteFileCount(t, store); count != 1 {
+		t.Errorf("Expected only keep.txt to be uploaded, got %d files", count)
+	}
+}
@@ -0,0 +1,64 @@
+package dir
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// CanaryKey is the remote object used to check that the crypto key matches the remote
+const CanaryKey = ".fers-canary"
+
+// canaryContent is the plaintext of the canary object
+var canaryContent = []byte("fers key canary")
+
+// ErrKeyMismatch is returned by CheckKey when the canary cannot be decrypted
+// with the configured key
+var ErrKeyMismatch = errors.New("the crypto key does not match this remote")
+
+// CheckKey decrypts the canary object to confirm that the configured key is the
+// one the remote was written with. A missing canary is created with the current
+// key, so the first run always passes.
+func (fm *FileManager) CheckKey() error {
+	encrypted, err := fm.storage.Download(CanaryKey)
+	if errors.Is(err, os.ErrNotExist) {
+		return fm.writeCanary()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to download key canary: %w", err)
+	}
+
+	plain, err := fm.currentCipher().Decrypt(encrypted)
+	if err != nil || !bytes.Equal(plain, canaryContent) {
+		fm.logger.Error("Crypto key does not match the remote canary")
+		return ErrKeyMismatch
+	}
+	fm.logger.Debug("Crypto key matches the remote canary")
+	return nil
+}
+
+// writeCanary uploads the canary encrypted with the current key
+func (fm *FileManager) writeCanary() error {
+	encrypted, err := fm.currentCipher().Encrypt(canaryContent)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt key canary: %w", err)
+	}
+	if err := fm.storage.Upload(CanaryKey, encrypted); err != nil {
+		return fmt.Errorf("failed to upload key canary: %w", err)
+	}
+	fm.logger.Info("Key canary created", slog.String("key", CanaryKey))
+	return nil
+}
+
+// withoutInternalKeys returns keys without the objects fers keeps for itself
+func withoutInternalKeys(keys []string) []string {
+	filtered := keys[:0:0]
+	for _, key := range keys {
+		if key != CanaryKey && key != ManifestKey {
+			filtered = append(filtered, key)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,64 @@
+package dir
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mingregister/fers/pkg/crypto"
+)
+
+func TestFileManager_CheckKey_MissingCanary(t *testing.T) {
+	fm, _, mockStore := createTestFileManager(t)
+
+	if err := fm.CheckKey(); err != nil {
+		t.Fatalf("CheckKey should pass on first use: %v", err)
+	}
+	if _, err := mockStore.Stat(CanaryKey); err != nil {
+		t.Errorf("CheckKey should create the canary: %v", err)
+	}
+}
+
+func TestFileManager_CheckKey_Match(t *testing.T) {
+	fm, _, _ := createTestFileManager(t)
+
+	if err := fm.CheckKey(); err != nil {
+		t.Fatalf("CheckKey failed creating the canary: %v", err)
+	}
+	if err := fm.CheckKey(); err != nil {
+		t.Errorf("CheckKey should pass with the same key: %v", err)
+	}
+}
+
+func TestFileManager_CheckKey_Mismatch(t *testing.T) {
+	fm, _, _ := createTestFileManager(t)
+	if err := fm.CheckKey(); err != nil {
+		t.Fatalf("CheckKey failed creating the canary: %v", err)
+	}
+
+	fm.cipher = crypto.NewAESGCM("another-password")
+	if err := fm.CheckKey(); !errors.Is(err, ErrKeyMismatch) {
+		t.Errorf("Expected ErrKeyMismatch, got %v", err)
+	}
+}
+
+func TestFileManager_CanaryHiddenFromSync(t *testing.T) {
+	fm, _, _ := createTestFileManager(t)
+	if err := fm.CheckKey(); err != nil {
+		t.Fatalf("CheckKey failed: %v", err)
+	}
+
+	files, err := fm.ListRemoteFiles("")
+	if err != nil {
+		t.Fatalf("ListRemoteFiles failed: %v", err)
+	}
+	if len(files) != 0 {
+		t.Errorf("The canary should not be listed, got %v", files)
+	}
+	planned, err := fm.PlanSyncDownload(t.Context())
+	if err != nil {
+		t.Fatalf("PlanSyncDownload failed: %v", err)
+	}
+	if len(planned) != 0 {
+		t.Errorf("The canary should not be downloaded, got %v", planned)
+	}
+}
@@ -0,0 +1,82 @@
+package dir
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// UploadStateFile is the checkpoint an interrupted directory upload leaves in
+// the uploaded directory; the next upload of that directory skips the files it lists
+const UploadStateFile = ".fers-upload-state"
+
+// uploadCheckpoint records the files of a directory upload as they complete.
+// The file holds one remote key per line and is only appended to, so a crash
+// loses at most the line being written.
+type uploadCheckpoint struct {
+	path string
+	done map[string]bool
+	file *os.File
+}
+
+// openUploadCheckpoint loads the checkpoint of dirPath, if any, and opens it for appending
+func openUploadCheckpoint(dirPath string) (*uploadCheckpoint, error) {
+	c := &uploadCheckpoint{path: filepath.Join(dirPath, UploadStateFile), done: map[string]bool{}}
+
+	data, err := os.ReadFile(c.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read upload checkpoint: %w", err)
+	}
+	// 只接受以换行结尾的行，写到一半的最后一行可能是另一个路径的前缀
+	for len(data) > 0 {
+		line, rest, complete := strings.Cut(string(data), "\n")
+		if !complete {
+			break
+		}
+		if line != "" {
+			c.done[line] = true
+		}
+		data = []byte(rest)
+	}
+
+	c.file, err = os.OpenFile(c.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, defaultFileMode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open upload checkpoint: %w", err)
+	}
+	return c, nil
+}
+
+// completed reports whether key was uploaded by an earlier, interrupted run
+func (c *uploadCheckpoint) completed(key string) bool {
+	return c.done[key]
+}
+
+// record durably marks key as uploaded
+func (c *uploadCheckpoint) record(key string) error {
+	w := bufio.NewWriter(c.file)
+	w.WriteString(key)
+	w.WriteByte('\n')
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to update upload checkpoint: %w", err)
+	}
+	if err := c.file.Sync(); err != nil {
+		return fmt.Errorf("failed to update upload checkpoint: %w", err)
+	}
+	c.done[key] = true
+	return nil
+}
+
+// close closes the checkpoint, removing it when the upload finished
+func (c *uploadCheckpoint) close(finished bool) error {
+	err := c.file.Close()
+	if finished {
+		if removeErr := os.Remove(c.path); removeErr != nil && !errors.Is(removeErr, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove upload checkpoint: %w", removeErr)
+		}
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,129 @@
+package dir
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mingregister/fers/pkg/storage"
+)
+
+// interruptingStorage records uploaded files and interrupts the upload after limit of them
+type interruptingStorage struct {
+	storage.Client
+	uploaded []string
+	limit    int
+	cancel   context.CancelFunc
+}
+
+func (s *interruptingStorage) record(key string) error {
+	if key == ManifestKey {
+		return nil
+	}
+	if s.limit >= 0 && len(s.uploaded) == s.limit {
+		s.cancel()
+		return context.Canceled
+	}
+	s.uploaded = append(s.uploaded, key)
+	return nil
+}
+
+func (s *interruptingStorage) Upload(key string, data []byte) error {
+	if err := s.record(key); err != nil {
+		return err
+	}
+	return s.Client.Upload(key, data)
+}
+
+func (s *interruptingStorage) UploadWithMeta(key string, data []byte, meta map[string]string) error {
+	if err := s.record(key); err != nil {
+		return err
+	}
+	return s.Client.(storage.MetadataUploader).UploadWithMeta(key, data, meta)
+}
+
+func TestEncryptAndUploadDirectory_ResumesFromCheckpoint(t *testing.T) {
+	fm, tempDir, mockStore := createTestFileManager(t)
+	writeLocalFiles(t, tempDir, "docs/a.txt", "docs/b.txt", "docs/sub/c.txt", "docs/sub/d.txt", "docs/e.txt")
+	docs := filepath.Join(tempDir, "docs")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	interrupting := &interruptingStorage{Client: mockStore, limit: 2, cancel: cancel}
+	fm.storage = interrupting
+
+	if err := fm.EncryptAndUploadDirectory(ctx, docs); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected interrupted upload to return context.Canceled, got %v", err)
+	}
+	first := []string{"docs/a.txt", "docs/b.txt"}
+	if !reflect.DeepEqual(interrupting.uploaded, first) {
+		t.Fatalf("Expected lexical upload order %v, got %v", first, interrupting.uploaded)
+	}
+	state, err := os.ReadFile(filepath.Join(docs, UploadStateFile))
+	if err != nil {
+		t.Fatalf("Expected checkpoint after interruption: %v", err)
+	}
+	if got := strings.Fields(string(state)); !reflect.DeepEqual(got, first) {
+		t.Errorf("Expected checkpoint %v, got %v", first, got)
+	}
+
+	resumed := &interruptingStorage{Client: mockStore, limit: -1, cancel: func() {}}
+	fm.storage = resumed
+	if err := fm.EncryptAndUploadDirectory(context.Background(), docs); err != nil {
+		t.Fatalf("Resumed upload failed: %v", err)
+	}
+	rest := []string{"docs/e.txt", "docs/sub/c.txt", "docs/sub/d.txt"}
+	if !reflect.DeepEqual(resumed.uploaded, rest) {
+		t.Errorf("Expected resumed upload of only %v, got %v", rest, resumed.uploaded)
+	}
+	if _, err := os.Stat(filepath.Join(docs, UploadStateFile)); !os.IsNotExist(err) {
+		t.Errorf("Expected checkpoint removed after full upload, got %v", err)
+	}
+	files, err := fm.ListRemoteFiles("")
+	if err != nil {
+		t.Fatalf("ListRemoteFiles failed: %v", err)
+	}
+	if len(files) != 5 {
+		t.Errorf("Expected 5 remote files, got %v", files)
+	}
+}
+
+func TestUploadCheckpoint_IgnoresPartialLine(t *testing.T) {
+	dirPath := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dirPath, UploadStateFile), []byte("a.txt\nb.t"), 0644); err != nil {
+		t.Fatalf("Failed to write checkpoint: %v", err)
+	}
+
+	c, err := openUploadCheckpoint(dirPath)
+	if err != nil {
+		t.Fatalf("openUploadCheckpoint failed: %v", err)
+	}
+	defer c.close(false)
+	if !c.completed("a.txt") {
+		t.Error("Expected a.txt completed")
+	}
+	if c.completed("b.t") {
+		t.Error("Expected unterminated line to be ignored")
+	}
+}
+
+func TestEncryptAndUploadDirectory_CheckpointNotUploaded(t *testing.T) {
+	fm, tempDir, _ := createTestFileManager(t)
+	fm.includeHidden = true
+	writeLocalFiles(t, tempDir, "a.txt", UploadStateFile)
+
+	if err := fm.EncryptAndUploadDirectory(context.Background(), tempDir); err != nil {
+		t.Fatalf("EncryptAndUploadDirectory failed: %v", err)
+	}
+	files, err := fm.ListRemoteFiles("")
+	if err != nil {
+		t.Fatalf("ListRemoteFiles failed: %v", err)
+	}
+	if !reflect.DeepEqual(files, []string{"a.txt"}) {
+		t.Errorf("Expected only a.txt uploaded, got %v", files)
+	}
+}
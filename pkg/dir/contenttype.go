@@ -0,0 +1,23 @@
+package dir
+
+import (
+	"mime"
+	"net/http"
+	"path/filepath"
+)
+
+// sniffLen is how much of a file http.DetectContentType looks at
+const sniffLen = 512
+
+// detectContentType returns the MIME type of a file named name starting with
+// head: by extension when it is known, otherwise by sniffing head, which
+// falls back to application/octet-stream
+func detectContentType(name string, head []byte) string {
+	if t := mime.TypeByExtension(filepath.Ext(name)); t != "" {
+		return t
+	}
+	if len(head) > sniffLen {
+		head = head[:sniffLen]
+	}
+	return http.DetectContentType(head)
+}
@@ -0,0 +1,70 @@
+package dir
+
+import (
+	"mime"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mingregister/fers/pkg/storage"
+)
+
+func TestDetectContentType(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	tests := []struct {
+		name string
+		head []byte
+		want string
+	}{
+		{"photo.png", png, "image/png"},
+		{"photo.JPG", nil, "image/jpeg"},
+		{"docs/report.pdf", []byte("%PDF-1.7"), "application/pdf"},
+		{"data.json", []byte(`{"a":1}`), "application/json"},
+		{"index.html", []byte("<html></html>"), "text/html"},
+		{"logo.svg", nil, "image/svg+xml"},
+		// 扩展名未知时按内容判断
+		{"notes", []byte("plain text notes\n"), "text/plain"},
+		{"image.fersunknown", png, "image/png"},
+		{"blob.fersunknown", []byte{0x00, 0x01, 0xfe, 0xff, 0x10}, "application/octet-stream"},
+		{"empty.fersunknown", nil, "text/plain"},
+	}
+	for _, tt := range tests {
+		got := detectContentType(tt.name, tt.head)
+		mediaType, _, err := mime.ParseMediaType(got)
+		if err != nil || mediaType != tt.want {
+			t.Errorf("detectContentType(%q) = %q, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDetectContentType_SniffsOnlyPrefix(t *testing.T) {
+	head := make([]byte, 4096)
+	copy(head, "plain text")
+	for i := len("plain text"); i < len(head); i++ {
+		head[i] = ' '
+	}
+	head[len(head)-1] = 0x00 // 超出嗅探范围的二进制字节不影响结果
+
+	if mediaType, _, _ := mime.ParseMediaType(detectContentType("big.fersunknown", head)); mediaType != "text/plain" {
+		t.Errorf("Expected text/plain from the first %d bytes, got %q", sniffLen, mediaType)
+	}
+}
+
+func TestEncryptAndUploadFile_StoresContentType(t *testing.T) {
+	fm, tempDir, store := createTestFileManager(t)
+	path := filepath.Join(tempDir, "page.html")
+	if err := os.WriteFile(path, []byte("<html><body>hi</body></html>"), 0644); err != nil {
+		t.Fatalf("Failed to write page.html: %v", err)
+	}
+	if err := fm.EncryptAndUploadFile(path, "page.html"); err != nil {
+		t.Fatalf("EncryptAndUploadFile failed: %v", err)
+	}
+
+	info, err := store.Stat("page.html")
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if mediaType, _, _ := mime.ParseMediaType(info.Metadata[storage.MetaContentType]); mediaType != "text/html" {
+		t.Errorf("Expected text/html content type metadata, got %q", info.Metadata[storage.MetaContentType])
+	}
+}
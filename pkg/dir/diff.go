@@ -0,0 +1,190 @@
+package dir
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/mingregister/fers/pkg/storage"
+)
+
+// DiffReport groups files, as slash-separated paths relative to the working
+// directory, by how the local copy compares with the remote one
+type DiffReport struct {
+	LocalOnly  []string // 只在本地，SyncUpload 会上传
+	RemoteOnly []string // 只在远程，SyncDownload 会下载
+	Modified   []string // 两边都有但内容不同
+	Identical  []string // 两边内容相同
+}
+
+// Total returns the number of files in the report
+func (r *DiffReport) Total() int {
+	return len(r.LocalOnly) + len(r.RemoteOnly) + len(r.Modified) + len(r.Identical)
+}
+
+// Diff compares the working directory with the remote without changing either.
+// Files are compared by the SHA-256 stored with each remote object; objects
+// uploaded without it are downloaded and decrypted to compare their content.
+func (fm *FileManager) Diff(ctx context.Context) (*DiffReport, error) {
+	remote, err := fm.remoteEntries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	local := make(map[string]string) // 远程键 => 本地路径
+	err = fm.walk(fm.workingDir, func(path string, info os.FileInfo, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil {
+			return fmt.Errorf("walk error at %s: %w", path, err)
+		}
+
+		if path != fm.workingDir && fm.skipEntry(info) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() || fm.filteredOut(path, info) {
+			return nil
+		}
+
+		relativePath, err := filepath.Rel(fm.workingDir, path)
+		if err != nil {
+			return fmt.Errorf("failed to get relative path for %s: %w", path, err)
+		}
+		local[storage.NormalizeKey(relativePath)] = path
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report := &DiffReport{}
+	for key, path := range local {
+		entry, ok := remote[key]
+		if !ok {
+			report.LocalOnly = append(report.LocalOnly, key)
+			continue
+		}
+		same, err := fm.sameContent(ctx, key, path, entry)
+		if err != nil {
+			return nil, err
+		}
+		if same {
+			report.Identical = append(report.Identical, key)
+		} else {
+			report.Modified = append(report.Modified, key)
+		}
+	}
+	for key := range remote {
+		if _, ok := local[key]; !ok {
+			report.RemoteOnly = append(report.RemoteOnly, key)
+		}
+	}
+
+	sort.Strings(report.LocalOnly)
+	sort.Strings(report.RemoteOnly)
+	sort.Strings(report.Modified)
+	sort.Strings(report.Identical)
+	return report, nil
+}
+
+// remoteEntries returns every remote file with what is known about it, from
+// the manifest when it is fresh and by listing and stating otherwise. Unlike
+// a sync it never writes the manifest.
+func (fm *FileManager) remoteEntries(ctx context.Context) (map[string]ManifestEntry, error) {
+	entries := make(map[string]ManifestEntry)
+	if m, err := fm.LoadManifest(); err == nil && fm.manifestUsable(m) {
+		for key, entry := range m.Files {
+			if !isEmptyDirPlaceholder(key) {
+				entries[key] = entry
+			}
+		}
+		return entries, nil
+	}
+
+	keys, err := fm.storage.List("")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list remote files: %w", err)
+	}
+	for _, key := range withoutInternalKeys(keys) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if isEmptyDirPlaceholder(key) {
+			continue
+		}
+		info, err := fm.storage.Stat(key)
+		if err != nil {
+			// 没有元数据时按内容比较
+			entries[key] = ManifestEntry{}
+			continue
+		}
+		entries[key] = manifestEntryFromInfo(info)
+	}
+	return entries, nil
+}
+
+// sameContent reports whether the local file at path has the content of the remote object key
+func (fm *FileManager) sameContent(ctx context.Context, key, path string, entry ManifestEntry) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	if info, err := os.Lstat(path); err == nil && isSymlink(info) && fm.symlinkMode == SymlinkStore {
+		// 保存为链接的文件比较链接目标
+		target, err := os.Readlink(path)
+		return err == nil && target == entry.Link, nil
+	}
+	if entry.SHA256 != "" {
+		// 有哈希时 Size 也来自元数据，是明文大小，大小不同就不必再计算哈希
+		if info, err := os.Stat(path); err == nil && info.Size() != entry.Size {
+			return false, nil
+		}
+		sum, err := fileSHA256(path)
+		if err != nil {
+			return false, fmt.Errorf("failed to hash %s: %w", key, err)
+		}
+		return sum == entry.SHA256, nil
+	}
+
+	encrypted, err := fm.storage.Download(key)
+	if err != nil {
+		return false, fmt.Errorf("failed to download %s: %w", key, err)
+	}
+	plain, err := fm.currentCipher().Decrypt(encrypted)
+	if err != nil {
+		// 无法解密的远程文件与本地文件视为不同
+		fm.logger.Warn("Failed to decrypt remote file for comparison", slog.String("path", key), slog.String("error", err.Error()))
+		return false, nil
+	}
+	sum, err := fileSHA256(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to hash %s: %w", key, err)
+	}
+	remoteSum := sha256.Sum256(plain)
+	return sum == hex.EncodeToString(remoteSum[:]), nil
+}
+
+// fileSHA256 returns the hex SHA-256 of the file at path
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
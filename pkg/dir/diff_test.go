@@ -0,0 +1,112 @@
+package dir
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// uploadWithoutMetadata stores content encrypted under key without any object metadata
+func uploadWithoutMetadata(t *testing.T, fm *FileManager, key, content string) {
+	t.Helper()
+	encrypted, err := fm.cipher.Encrypt([]byte(content))
+	if err != nil {
+		t.Fatalf("Failed to encrypt %s: %v", key, err)
+	}
+	mustUpload(t, fm.storage, key, encrypted)
+}
+
+func TestFileManager_Diff(t *testing.T) {
+	fm, tempDir, mockStore := createTestFileManager(t)
+
+	writeLocalFiles(t, tempDir, "same.txt", "docs/changed.txt", "gone.txt")
+	for _, name := range []string{"same.txt", "docs/changed.txt", "gone.txt"} {
+		if err := fm.EncryptAndUploadFile(filepath.Join(tempDir, filepath.FromSlash(name)), name); err != nil {
+			t.Fatalf("Failed to upload %s: %v", name, err)
+		}
+	}
+	// 修改后大小不变，只能靠哈希发现差异
+	if err := os.WriteFile(filepath.Join(tempDir, "docs", "changed.txt"), []byte("docs/CHANGED.txt"), 0644); err != nil {
+		t.Fatalf("Failed to modify file: %v", err)
+	}
+	if err := os.Remove(filepath.Join(tempDir, "gone.txt")); err != nil {
+		t.Fatalf("Failed to remove file: %v", err)
+	}
+	writeLocalFiles(t, tempDir, "new.txt", "legacy-same.txt", "legacy-changed.txt")
+	uploadWithoutMetadata(t, fm, "legacy-same.txt", "legacy-same.txt")
+	uploadWithoutMetadata(t, fm, "legacy-changed.txt", "something else")
+	mustUpload(t, mockStore, "broken.txt", []byte("not encrypted"))
+	writeLocalFiles(t, tempDir, "broken.txt")
+
+	remoteBefore, err := mockStore.List("")
+	if err != nil {
+		t.Fatalf("Failed to list remote files: %v", err)
+	}
+
+	report, err := fm.Diff(t.Context())
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+
+	expected := &DiffReport{
+		LocalOnly:  []string{"new.txt"},
+		RemoteOnly: []string{"gone.txt"},
+		Modified:   []string{"broken.txt", "docs/changed.txt", "legacy-changed.txt"},
+		Identical:  []string{"legacy-same.txt", "same.txt"},
+	}
+	if !reflect.DeepEqual(report, expected) {
+		t.Errorf("Unexpected report:\n got %+v\nwant %+v", report, expected)
+	}
+	if report.Total() != 7 {
+		t.Errorf("Expected 7 files in total, got %d", report.Total())
+	}
+
+	// 比较不能修改远程，也不能建立清单
+	remoteAfter, err := mockStore.List("")
+	if err != nil {
+		t.Fatalf("Failed to list remote files: %v", err)
+	}
+	if !reflect.DeepEqual(remoteBefore, remoteAfter) {
+		t.Errorf("Diff changed the remote: before %v, after %v", remoteBefore, remoteAfter)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "gone.txt")); !os.IsNotExist(err) {
+		t.Error("Diff should not download remote-only files")
+	}
+}
+
+func TestFileManager_DiffUsesManifest(t *testing.T) {
+	fm, tempDir, store := newManifestTestFileManager(t)
+	writeLocalFiles(t, tempDir, "a.txt", "b.txt")
+	if _, err := fm.SyncUpload(t.Context()); err != nil {
+		t.Fatalf("SyncUpload failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "b.txt"), []byte("changed"), 0644); err != nil {
+		t.Fatalf("Failed to modify file: %v", err)
+	}
+
+	lists := store.lists.Load()
+	report, err := fm.Diff(t.Context())
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	if !reflect.DeepEqual(report.Identical, []string{"a.txt"}) || !reflect.DeepEqual(report.Modified, []string{"b.txt"}) {
+		t.Errorf("Unexpected report %+v", report)
+	}
+	if store.lists.Load() != lists {
+		t.Error("Diff should use a fresh manifest instead of listing the remote")
+	}
+}
+
+func TestFileManager_DiffCancelled(t *testing.T) {
+	fm, tempDir, _ := createTestFileManager(t)
+	writeLocalFiles(t, tempDir, "a.txt")
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	if _, err := fm.Diff(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
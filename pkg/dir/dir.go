@@ -3,21 +3,85 @@ package dir
 import (
 	"os"
 	"strings"
+	"time"
 )
 
-// List 返回给定目录的一层文件/目录名称（不含隐藏 .git 等）
-func List(dir string) []string {
+// Entry 描述目录中的一项
+type Entry struct {
+	Name    string
+	IsDir   bool
+	Size    int64
+	ModTime time.Time
+}
+
+// ListOptions 控制目录列表包含哪些目录项
+type ListOptions struct {
+	IncludeHidden bool // 是否包含以 . 开头的隐藏文件/目录
+}
+
+// IsHidden reports whether name is a hidden start-with-dot entry
+func IsHidden(name string) bool {
+	return strings.HasPrefix(name, ".")
+}
+
+// ListEntries 返回给定目录的一层文件/目录信息（不含隐藏 .git 等）
+func ListEntries(dir string) ([]Entry, error) {
+	return ListEntriesWith(dir, ListOptions{})
+}
+
+// ListEntriesWith 按 opts 返回给定目录的一层文件/目录信息
+func ListEntriesWith(dir string, opts ListOptions) ([]Entry, error) {
 	fis, err := os.ReadDir(dir)
 	if err != nil {
-		return []string{}
+		return nil, err
 	}
-	var out []string
+	var out []Entry
 	for _, fi := range fis {
-		// skip hidden start-with-dot entries (可根据需要修改)
-		if strings.HasPrefix(fi.Name(), ".") {
+		// 回收站目录始终不显示
+		if fi.Name() == TrashDirName || (!opts.IncludeHidden && IsHidden(fi.Name())) {
 			continue
 		}
-		out = append(out, fi.Name())
+		info, err := fi.Info()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, Entry{
+			Name:    fi.Name(),
+			IsDir:   fi.IsDir(),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		})
+	}
+	return out, nil
+}
+
+// ListErr 返回给定目录的一层文件/目录名称（不含隐藏 .git 等），读取失败时返回错误
+func ListErr(dir string) ([]string, error) {
+	return listNames(dir, ListOptions{})
+}
+
+func listNames(dir string, opts ListOptions) ([]string, error) {
+	entries, err := ListEntriesWith(dir, opts)
+	if err != nil {
+		return nil, err
+	}
+	var out []string
+	for _, entry := range entries {
+		out = append(out, entry.Name)
+	}
+	return out, nil
+}
+
+// List 返回给定目录的一层文件/目录名称（不含隐藏 .git 等），读取失败时返回空列表
+func List(dir string) []string {
+	return ListWith(dir, ListOptions{})
+}
+
+// ListWith 按 opts 返回给定目录的一层文件/目录名称，读取失败时返回空列表
+func ListWith(dir string, opts ListOptions) []string {
+	out, err := listNames(dir, opts)
+	if err != nil {
+		return []string{}
 	}
 	return out
 }
@@ -2,11 +2,19 @@ package dir
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io/fs"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/mingregister/fers/pkg/config"
 	"github.com/mingregister/fers/pkg/crypto"
@@ -18,53 +26,205 @@ const (
 	defaultDirMode  = 0o755
 )
 
+// DefaultDownloadConcurrency is the number of files SyncDownload downloads at
+// once when download_concurrency is not set
+const DefaultDownloadConcurrency = 4
+
+// EmptyDirPlaceholder is the zero-byte remote key uploaded for an empty
+// directory when PreserveEmptyDirs is enabled
+const EmptyDirPlaceholder = ".fers-keep"
+
+// ErrStorage and ErrLocalIO wrap remote storage and local file system failures,
+// so callers can tell them apart from decryption errors with errors.Is
+var (
+	ErrStorage = errors.New("remote storage error")
+	ErrLocalIO = errors.New("local file error")
+)
+
 // FileManager handles file operations with encryption and remote storage
 type FileManager struct {
-	config     *config.Config
-	storage    storage.Client
-	workingDir string
-	cipher     crypto.Cipher
-	logger     *slog.Logger
+	config           *config.Config
+	storage          storage.Client
+	workingDir       string
+	cipher           crypto.Cipher
+	cipherMu         sync.RWMutex // 保护 cipher，Rekey 完成后会替换它
+	keySetting       string       // 保存当前密钥的配置项，Rekey 之后需要更新
+	sharedCipher     bool         // 其他备份集使用同一个 cipher，不能单独更换密钥
+	keystoreKey      bool         // 密钥来自密钥库，Rekey 无法更新密钥库
+	logger           *slog.Logger
+	includeHidden    bool                       // 上传时是否包含隐藏文件
+	minFileSize      int64                      // 上传时跳过更小的文件，0 表示不限制
+	maxFileSize      int64                      // 上传时跳过更大的文件，0 表示不限制
+	includeExts      map[string]bool            // 非空时上传只包含这些扩展名（小写，带 .）
+	excludeExts      map[string]bool            // 上传时跳过的扩展名（小写，带 .）
+	metrics          *metrics                   // 加密上传吞吐量统计
+	operationTimeout time.Duration              // 界面操作的总超时，0 表示不限制
+	perFileTimeout   time.Duration              // 单个文件上传或下载的超时，0 表示不限制
+	newWatcher       func() (Watcher, error)    // 自动同步使用的文件监视器，测试时可替换
+	newTicker        func(time.Duration) Ticker // 定时同步使用的计时器，测试时可替换
+	manifest         manifestState              // 远程清单的更新状态
+	obfuscateKeys    bool                       // 远程键为路径的 HMAC，路径只保存在清单中
+	downloadWorkers  int                        // 同步下载同时下载的文件数
+	symlinkMode      SymlinkMode                // 上传和同步时如何处理符号链接
+	quarantineMu     sync.Mutex                 // 保护工作目录中的 QuarantineFile
+}
+
+// storageError wraps a non-nil error of the storage backend with ErrStorage
+func storageError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("%w: %w", ErrStorage, err)
 }
 
 // NewFileManager creates a new FileManager instance
-func NewFileManager(cfg *config.Config, storage storage.Client, logger *slog.Logger, cipher crypto.Cipher) *FileManager {
-	return &FileManager{
-		config:     cfg,
-		storage:    storage,
-		workingDir: cfg.TargetDir,
-		cipher:     cipher,
-		logger:     logger,
+func NewFileManager(cfg *config.Config, client storage.Client, logger *slog.Logger, cipher crypto.Cipher) *FileManager {
+	fm := &FileManager{
+		config:        cfg,
+		storage:       client,
+		workingDir:    cfg.TargetDir,
+		cipher:        cipher,
+		keySetting:    "crypto_key",
+		logger:        logger,
+		includeHidden: cfg.IncludeHidden,
+		minFileSize:   cfg.MinFileSize,
+		maxFileSize:   cfg.MaxFileSize,
+		includeExts:   extensionSet(cfg.IncludeExtensions),
+		excludeExts:   extensionSet(cfg.ExcludeExtensions),
+		metrics:       &metrics{},
+
+		operationTimeout: cfg.OperationTimeout,
+		perFileTimeout:   cfg.PerFileTimeout,
+		obfuscateKeys:    cfg.ObfuscateKeys,
+		downloadWorkers:  cfg.DownloadConcurrency,
+	}
+	if fm.downloadWorkers <= 0 {
+		fm.downloadWorkers = DefaultDownloadConcurrency
+	}
+	mode, err := ParseSymlinkMode(cfg.SymlinkMode)
+	if err != nil {
+		logger.Warn("Invalid symlink_mode, skipping symlinks", slog.String("error", err.Error()))
+		mode = SymlinkSkip
 	}
+	fm.symlinkMode = mode
+	if fm.obfuscateKeys {
+		fm.storage = storage.NewObfuscatedClient(client, fm.hashKey, fm.manifestKeys, CanaryKey, ManifestKey)
+	}
+	return fm
 }
 
 func (fm *FileManager) GetWorkingDir() string {
 	return fm.workingDir
 }
 
+// EnsureWorkingDir creates the working directory if it does not exist yet
+func (fm *FileManager) EnsureWorkingDir() error {
+	info, err := os.Stat(fm.workingDir)
+	if err == nil {
+		if !info.IsDir() {
+			return fmt.Errorf("working directory %s is not a directory", fm.workingDir)
+		}
+		return nil
+	}
+	if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to access working directory %s: %w", fm.workingDir, err)
+	}
+
+	if err := os.MkdirAll(fm.workingDir, 0755); err != nil {
+		return fmt.Errorf("failed to create working directory %s: %w", fm.workingDir, err)
+	}
+	fm.logger.Info("Created working directory", slog.String("path", fm.workingDir))
+	return nil
+}
+
+// Ping checks that the storage backend is reachable and accepts the credentials
+func (fm *FileManager) Ping(ctx context.Context) error {
+	if err := storage.Ping(ctx, fm.storage); err != nil {
+		return fmt.Errorf("%w: %w", ErrStorage, err)
+	}
+	fm.logger.Debug("Storage is reachable")
+	return nil
+}
+
+// WorkingDirAvailable reports whether the working directory still exists and
+// is a directory, e.g. that the drive it is on has not been unmounted
+func (fm *FileManager) WorkingDirAvailable() bool {
+	info, err := os.Stat(fm.workingDir)
+	return err == nil && info.IsDir()
+}
+
 // EncryptAndUploadFile encrypts and uploads a single file
 func (fm *FileManager) EncryptAndUploadFile(filePath, relativePath string) error {
+	if fm.symlinkMode == SymlinkStore {
+		if info, err := os.Lstat(filePath); err == nil && isSymlink(info) {
+			return fm.uploadSymlink(filePath, relativePath)
+		}
+	}
+	start := time.Now()
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to read file %s: %w: %w", filePath, ErrLocalIO, err)
+	}
 	data, err := os.ReadFile(filePath)
 	if err != nil {
-		return fmt.Errorf("failed to read file %s: %w", filePath, err)
+		return fmt.Errorf("failed to read file %s: %w: %w", filePath, ErrLocalIO, err)
 	}
 
-	encrypted, err := fm.cipher.Encrypt(data)
+	encrypted, err := fm.currentCipher().Encrypt(data)
 	if err != nil {
 		return fmt.Errorf("failed to encrypt file %s: %w", filePath, err)
 	}
 
-	if err := fm.storage.Upload(filepath.ToSlash(relativePath), encrypted); err != nil {
-		return fmt.Errorf("failed to upload file %s: %w", relativePath, err)
+	key := storage.NormalizeKey(relativePath)
+	meta := uploadMetadata(relativePath, data, info)
+	if uploader, ok := fm.storage.(storage.MetadataUploader); ok {
+		// 附带明文大小等元数据，不解密也能查看
+		err = uploader.UploadWithMeta(key, encrypted, meta)
+	} else {
+		err = fm.storage.Upload(key, encrypted)
 	}
+	if err != nil {
+		return fmt.Errorf("failed to upload file %s: %w: %w", relativePath, ErrStorage, err)
+	}
+	fm.metrics.record(int64(len(data)), time.Since(start))
+	fm.changeManifest(manifestPut(key, ManifestEntry{
+		Size:    int64(len(data)),
+		SHA256:  meta[storage.MetaSHA256],
+		ModTime: info.ModTime().UTC(),
+	}))
 
 	fm.logger.Info("File uploaded successfully", slog.String("path", relativePath))
 	return nil
 }
 
-// EncryptAndUploadDirectory recursively encrypts and uploads a directory
+// uploadMetadata returns the metadata stored with the uploaded file name
+func uploadMetadata(name string, plain []byte, info os.FileInfo) map[string]string {
+	sum := sha256.Sum256(plain)
+	return map[string]string{
+		storage.MetaOrigSize:    strconv.Itoa(len(plain)),
+		storage.MetaMtime:       info.ModTime().UTC().Format(time.RFC3339),
+		storage.MetaSHA256:      hex.EncodeToString(sum[:]),
+		storage.MetaContentType: detectContentType(name, plain),
+	}
+}
+
+// EncryptAndUploadDirectory recursively encrypts and uploads a directory.
+// Files are walked in lexical order and recorded in an UploadStateFile in
+// dirPath as they complete; an interrupted upload resumes after the recorded
+// files and the state file is removed once every file is uploaded.
 func (fm *FileManager) EncryptAndUploadDirectory(ctx context.Context, dirPath string) error {
-	return filepath.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
+	checkpoint, err := openUploadCheckpoint(dirPath)
+	if err != nil {
+		return err
+	}
+	if n := len(checkpoint.done); n > 0 {
+		fm.logger.Info("Resuming directory upload", slog.String("dir", dirPath), slog.Int("completed", n))
+	}
+
+	fm.beginManifestBatch()
+	defer fm.endManifestBatch()
+
+	err = fm.walk(dirPath, func(path string, info os.FileInfo, err error) error {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
@@ -75,7 +235,21 @@ func (fm *FileManager) EncryptAndUploadDirectory(ctx context.Context, dirPath st
 			return fmt.Errorf("walk error at %s: %w", path, err)
 		}
 
+		if path != dirPath && fm.skipEntry(info) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
 		if info.IsDir() {
+			if fm.config.PreserveEmptyDirs {
+				return fm.uploadEmptyDirPlaceholder(path)
+			}
+			return nil
+		}
+
+		if fm.filteredOut(path, info) {
 			return nil
 		}
 
@@ -84,45 +258,166 @@ func (fm *FileManager) EncryptAndUploadDirectory(ctx context.Context, dirPath st
 			return fmt.Errorf("failed to get relative path for %s: %w", path, err)
 		}
 
-		return fm.EncryptAndUploadFile(path, relativePath)
+		key := storage.NormalizeKey(relativePath)
+		if checkpoint.completed(key) {
+			return nil
+		}
+		if err := fm.uploadFile(ctx, path, relativePath); err != nil {
+			return err
+		}
+		return checkpoint.record(key)
 	})
+	if closeErr := checkpoint.close(err == nil); err == nil {
+		err = closeErr
+	}
+	return err
 }
 
-// DownloadAndDecryptFile downloads and decrypts a single file
+// CountUploadableFiles returns how many files uploading relativePath would
+// upload: 1 for a file, and for a directory every file that is not skipped as
+// hidden or by the size and extension filters
+func (fm *FileManager) CountUploadableFiles(relativePath string) (int, error) {
+	root, err := fm.resolveLocalPath(relativePath)
+	if err != nil {
+		return 0, err
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat %s: %w", relativePath, err)
+	}
+	if !info.IsDir() {
+		return 1, nil
+	}
+
+	count := 0
+	err = fm.walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return fmt.Errorf("walk error at %s: %w", path, err)
+		}
+		if path != root && fm.skipEntry(info) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.IsDir() && !fm.filteredOut(path, info) {
+			count++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// uploadFile is EncryptAndUploadFile limited by the per-file timeout
+func (fm *FileManager) uploadFile(ctx context.Context, filePath, relativePath string) error {
+	return fm.withFileTimeout(ctx, func(context.Context) error {
+		return fm.EncryptAndUploadFile(filePath, relativePath)
+	})
+}
+
+// downloadFile is DownloadResumable limited by the per-file timeout
+func (fm *FileManager) downloadFile(ctx context.Context, remotePath, localPath string) error {
+	return fm.withFileTimeout(ctx, func(ctx context.Context) error {
+		return fm.DownloadResumable(ctx, remotePath, localPath, nil)
+	})
+}
+
+// uploadEmptyDirPlaceholder uploads the placeholder key for dirPath if it has no uploadable entries
+func (fm *FileManager) uploadEmptyDirPlaceholder(dirPath string) error {
+	relativePath, err := filepath.Rel(fm.workingDir, dirPath)
+	if err != nil {
+		return fmt.Errorf("failed to get relative path for %s: %w", dirPath, err)
+	}
+	if relativePath == "." {
+		return nil
+	}
+
+	entries, err := os.ReadDir(dirPath)
+	if err != nil {
+		return fmt.Errorf("failed to read directory %s: %w", dirPath, err)
+	}
+	for _, entry := range entries {
+		if fm.includeHidden || !IsHidden(entry.Name()) {
+			return nil
+		}
+	}
+
+	key := storage.NormalizeKey(filepath.Join(relativePath, EmptyDirPlaceholder))
+	if err := fm.storage.Upload(key, []byte{}); err != nil {
+		return fmt.Errorf("failed to upload empty directory %s: %w", relativePath, err)
+	}
+	fm.changeManifest(manifestPut(key, ManifestEntry{}))
+
+	fm.logger.Info("Empty directory uploaded", slog.String("path", relativePath))
+	return nil
+}
+
+// isEmptyDirPlaceholder reports whether remotePath is an empty directory placeholder key
+func isEmptyDirPlaceholder(remotePath string) bool {
+	return remotePath == EmptyDirPlaceholder || strings.HasSuffix(remotePath, "/"+EmptyDirPlaceholder)
+}
+
+// DownloadAndDecryptFile downloads and decrypts a single file; an empty
+// directory placeholder recreates its directory instead
 func (fm *FileManager) DownloadAndDecryptFile(remotePath, localPath string) error {
+	if isEmptyDirPlaceholder(remotePath) {
+		dir := filepath.Dir(localPath)
+		if err := os.MkdirAll(dir, defaultDirMode); err != nil {
+			return fmt.Errorf("failed to create directory %s: %w: %w", dir, ErrLocalIO, err)
+		}
+		fm.logger.Info("Empty directory restored", slog.String("path", dir))
+		return nil
+	}
+
 	encrypted, err := fm.storage.Download(remotePath)
 	if err != nil {
-		return fmt.Errorf("failed to download file %s: %w", remotePath, err)
+		return fmt.Errorf("failed to download file %s: %w: %w", remotePath, ErrStorage, err)
 	}
 
-	decrypted, err := fm.cipher.Decrypt(encrypted)
+	decrypted, err := fm.currentCipher().Decrypt(encrypted)
 	if err != nil {
 		return fmt.Errorf("failed to decrypt file %s: %w", remotePath, err)
 	}
 
 	dir := filepath.Dir(localPath)
 	if err := os.MkdirAll(dir, defaultDirMode); err != nil {
-		return fmt.Errorf("failed to create directory %s: %w", dir, err)
+		return fmt.Errorf("failed to create directory %s: %w: %w", dir, ErrLocalIO, err)
 	}
 
-	if err := os.WriteFile(localPath, decrypted, defaultFileMode); err != nil {
-		return fmt.Errorf("failed to write file %s: %w", localPath, err)
+	if err := writeFileAtomic(localPath, decrypted, defaultFileMode); err != nil {
+		return fmt.Errorf("failed to write file %s: %w: %w", localPath, ErrLocalIO, err)
 	}
 
 	fm.logger.Info("File downloaded and decrypted successfully", slog.String("path", localPath))
 	return nil
 }
 
-// SyncDownload downloads missing files from remote storage
-func (fm *FileManager) SyncDownload(ctx context.Context) error {
-	remoteFiles, err := fm.storage.List("")
+// PlanSyncDownload returns the remote files that SyncDownload would download
+func (fm *FileManager) PlanSyncDownload(ctx context.Context) ([]string, error) {
+	missing, _, _, err := fm.planSyncDownload(ctx)
+	return missing, err
+}
+
+// planSyncDownload is PlanSyncDownload that also returns the remote keys and
+// whether they come from a listing rather than the manifest
+func (fm *FileManager) planSyncDownload(ctx context.Context) (missing, remoteFiles []string, listed bool, err error) {
+	remoteFiles, listed, err = fm.remoteKeys()
 	if err != nil {
-		return fmt.Errorf("failed to list remote files: %w", err)
+		return nil, nil, false, err
 	}
 
 	// 构建本地文件的完整路径集合
 	localFileSet := make(map[string]bool)
-	err = filepath.Walk(fm.workingDir, func(path string, info os.FileInfo, err error) error {
+	err = fm.walk(fm.workingDir, func(path string, info os.FileInfo, err error) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
 		if err != nil {
 			return err
 		}
@@ -132,39 +427,190 @@ func (fm *FileManager) SyncDownload(ctx context.Context) error {
 				return err
 			}
 			// 使用斜杠路径以匹配远程路径格式
-			localFileSet[filepath.ToSlash(relativePath)] = true
+			localFileSet[storage.NormalizeKey(relativePath)] = true
 		}
 		return nil
 	})
 	if err != nil {
-		return fmt.Errorf("failed to scan local files: %w", err)
+		if ctx.Err() != nil {
+			return nil, nil, false, ctx.Err()
+		}
+		return nil, nil, false, fmt.Errorf("failed to scan local files: %w", err)
 	}
 
+	quarantined := fm.quarantined()
 	for _, remotePath := range remoteFiles {
+		if quarantined[remotePath] {
+			fm.logger.Debug("Skipping quarantined file", slog.String("path", remotePath))
+			continue
+		}
+		// 空目录占位符只需本地目录存在
+		if isEmptyDirPlaceholder(remotePath) {
+			dir := filepath.Join(fm.workingDir, filepath.Dir(filepath.FromSlash(remotePath)))
+			if info, err := os.Stat(dir); err == nil && info.IsDir() {
+				continue
+			}
+		}
+		// 检查远程文件是否在本地存在
+		if !localFileSet[remotePath] {
+			missing = append(missing, remotePath)
+		}
+	}
+	return missing, remoteFiles, listed, nil
+}
+
+// SyncDownload downloads missing files from remote storage. Failed files do
+// not stop the sync; they are reported in the result.
+func (fm *FileManager) SyncDownload(ctx context.Context) (*BatchResult, error) {
+	missing, remoteFiles, listed, err := fm.planSyncDownload(ctx)
+	if err != nil {
+		return &BatchResult{}, err
+	}
+
+	result, err := fm.downloadMissing(ctx, missing)
+	if err == nil && listed {
+		// 已经列出了整个远程，顺便建立清单，下次同步不必再列出
+		fm.saveListedManifest(ctx, remoteFiles)
+	}
+	return result, err
+}
+
+// downloadMissing downloads the planned remote files with up to
+// downloadWorkers at once, continuing past failures. A file that appeared
+// locally since planning is skipped rather than overwritten. The result lists
+// the files in planned order.
+func (fm *FileManager) downloadMissing(ctx context.Context, missing []string) (*BatchResult, error) {
+	errs := make([]error, len(missing))
+	skipped := make([]bool, len(missing))
+	var links map[string]string
+	if fm.symlinkMode == SymlinkStore {
+		links = fm.manifestLinks()
+	}
+
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(fm.downloadWorkers, len(missing)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				remotePath := missing[i]
+				localPath, err := fm.resolveLocalPath(filepath.FromSlash(remotePath))
+				if err != nil {
+					errs[i] = err
+				} else if localFileExists(localPath) {
+					skipped[i] = true
+					continue
+				} else if target, ok := links[remotePath]; ok {
+					errs[i] = fm.restoreSymlink(target, localPath)
+				} else {
+					errs[i] = fm.downloadFile(ctx, remotePath, localPath)
+				}
+				if errs[i] != nil {
+					fm.logger.Error("Failed to download file", slog.String("path", remotePath), slog.String("error", errs[i].Error()))
+				}
+			}
+		}()
+	}
+
+	dispatched := 0
+dispatch:
+	for ; dispatched < len(missing) && ctx.Err() == nil; dispatched++ {
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			break dispatch
+		case indices <- dispatched:
+		}
+	}
+	close(indices)
+	wg.Wait()
+
+	result := &BatchResult{}
+	for i, remotePath := range missing[:dispatched] {
+		switch {
+		case skipped[i]:
+			result.Skipped = append(result.Skipped, remotePath)
+		case errs[i] != nil:
+			result.Failed = append(result.Failed, FileError{Path: remotePath, Err: errs[i]})
 		default:
+			result.Succeeded = append(result.Succeeded, remotePath)
 		}
+	}
+	fm.recordSyncResult(result)
+	if dispatched < len(missing) {
+		return result, ctx.Err()
+	}
+	return result, nil
+}
 
-		// 检查远程文件是否在本地存在
-		if !localFileSet[remotePath] {
-			localPath := filepath.Join(fm.workingDir, remotePath)
-			if err := fm.DownloadAndDecryptFile(remotePath, localPath); err != nil {
-				fm.logger.Error("Failed to download file", slog.String("path", remotePath), slog.String("error", err.Error()))
-				continue
-			}
+// remoteUnchanged reports whether the remote object at key holds the same
+// content as the local file at path. The manifest entry is checked first: a
+// matching size and modification time count as unchanged without reading the
+// file, and a matching SHA-256 without asking the remote. Without an entry,
+// or when it disagrees, the plaintext size and SHA-256 stored with the object
+// decide; objects without them, or that cannot be checked, count as changed
+// so they are uploaded again.
+func (fm *FileManager) remoteUnchanged(path, key string, info os.FileInfo, entry ManifestEntry, known bool) bool {
+	if isSymlink(info) {
+		// 链接只保存加密的目标路径，按名称判断
+		return true
+	}
+
+	var sum string
+	hash := func() (string, error) {
+		if sum != "" {
+			return sum, nil
+		}
+		var err error
+		sum, err = fileSHA256(path)
+		return sum, err
+	}
+	if known && entry.SHA256 != "" && entry.Size == info.Size() {
+		// 上传时记录的修改时间是精确的；重建清单得到的只精确到秒，通常不相等而改为比较哈希
+		if entry.ModTime.Equal(info.ModTime()) {
+			return true
+		}
+		if local, err := hash(); err == nil && local == entry.SHA256 {
+			return true
 		}
 	}
 
-	return nil
+	// 清单中没有记录或与本地不一致时，以远程对象的元数据为准
+	remote, err := fm.storage.Stat(key)
+	if err != nil {
+		fm.logger.Debug("Failed to stat remote file, uploading it", slog.String("path", key), slog.String("error", err.Error()))
+		return false
+	}
+	want := remote.Metadata[storage.MetaSHA256]
+	if want == "" {
+		return false
+	}
+	if size, err := strconv.ParseInt(remote.Metadata[storage.MetaOrigSize], 10, 64); err == nil && size != info.Size() {
+		return false
+	}
+	local, err := hash()
+	if err != nil {
+		return false
+	}
+	return local == want
+}
+
+// SyncUploadPlan is a planned Sync Upload. SyncUploadPlanned uploads it
+// without walking and hashing the working directory again, e.g. after the
+// user confirmed the number of files.
+type SyncUploadPlan struct {
+	Files []string // 需要上传的本地文件，相对工作目录
+
+	remoteFiles []string
+	listed      bool // remoteFiles 来自完整列表，上传后可以重建清单
 }
 
-// SyncUpload uploads missing local files to remote storage
-func (fm *FileManager) SyncUpload(ctx context.Context) error {
-	remoteFiles, err := fm.storage.List("")
+// PlanSyncUpload returns the local files, relative to the working directory,
+// that SyncUpload would upload
+func (fm *FileManager) PlanSyncUpload(ctx context.Context) (*SyncUploadPlan, error) {
+	remoteFiles, manifest, listed, err := fm.remoteState()
 	if err != nil {
-		return fmt.Errorf("failed to list remote files: %w", err)
+		return nil, err
 	}
 
 	remoteSet := make(map[string]bool, len(remoteFiles))
@@ -172,8 +618,10 @@ func (fm *FileManager) SyncUpload(ctx context.Context) error {
 		remoteSet[file] = true
 		remoteSet[strings.Split(file, "/")[0]] = true
 	}
+	quarantined := fm.quarantined()
 
-	return filepath.Walk(fm.workingDir, func(path string, info os.FileInfo, err error) error {
+	plan := &SyncUploadPlan{remoteFiles: remoteFiles, listed: listed}
+	err = fm.walk(fm.workingDir, func(path string, info os.FileInfo, err error) error {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
@@ -184,7 +632,14 @@ func (fm *FileManager) SyncUpload(ctx context.Context) error {
 			return fmt.Errorf("walk error at %s: %w", path, err)
 		}
 
-		if info.IsDir() {
+		if path != fm.workingDir && fm.skipEntry(info) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if info.IsDir() || fm.filteredOut(path, info) {
 			return nil
 		}
 
@@ -193,45 +648,322 @@ func (fm *FileManager) SyncUpload(ctx context.Context) error {
 			return fmt.Errorf("failed to get relative path for %s: %w", path, err)
 		}
 
-		relativeSlash := filepath.ToSlash(relativePath)
-		if !remoteSet[relativeSlash] {
-			if err := fm.EncryptAndUploadFile(path, relativePath); err != nil {
-				fm.logger.Error("Failed to upload file", slog.String("path", relativePath), slog.String("error", err.Error()))
-			}
+		key := storage.NormalizeKey(relativePath)
+		if quarantined[key] {
+			fm.logger.Debug("Skipping quarantined file", slog.String("path", key))
+			return nil
+		}
+		var entry ManifestEntry
+		known := false
+		if manifest != nil {
+			entry, known = manifest.Files[key]
+		}
+		if !remoteSet[key] || !fm.remoteUnchanged(path, key, info, entry, known) {
+			plan.Files = append(plan.Files, relativePath)
 		}
-
 		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// SyncUpload uploads local files that are missing from remote storage or
+// whose content differs from the remote object. Files whose stored size and
+// SHA-256 match are skipped without being encrypted again, so running it on
+// an unchanged tree uploads nothing. Failed files do not stop the sync; they
+// are reported in the result.
+func (fm *FileManager) SyncUpload(ctx context.Context) (*BatchResult, error) {
+	plan, err := fm.PlanSyncUpload(ctx)
+	if err != nil {
+		return &BatchResult{}, err
+	}
+	return fm.SyncUploadPlanned(ctx, plan)
+}
+
+// SyncUploadPlanned uploads the files of plan like SyncUpload
+func (fm *FileManager) SyncUploadPlanned(ctx context.Context, plan *SyncUploadPlan) (*BatchResult, error) {
+	before := fm.Stats()
+	result, err := fm.uploadMissing(ctx, plan.Files)
+	if err != nil {
+		return result, err
+	}
+	if plan.listed {
+		// 已经列出了整个远程，加上刚上传的文件建立清单
+		remoteFiles := slices.Clone(plan.remoteFiles)
+		for _, relativePath := range result.Succeeded {
+			remoteFiles = append(remoteFiles, storage.NormalizeKey(relativePath))
+		}
+		fm.saveListedManifest(ctx, remoteFiles)
+	}
+
+	stats := fm.Stats().Sub(before)
+	fm.logger.Info("Sync upload finished",
+		slog.Int64("files", stats.Files),
+		slog.Int64("bytes", stats.Bytes),
+		slog.Int("failed", len(result.Failed)),
+		slog.String("rate", FormatRate(stats)))
+	return result, nil
+}
+
+// uploadMissing uploads the planned local files, continuing past failures
+func (fm *FileManager) uploadMissing(ctx context.Context, missing []string) (*BatchResult, error) {
+	fm.beginManifestBatch()
+	defer fm.endManifestBatch()
+
+	result := &BatchResult{}
+	defer fm.recordSyncResult(result)
+	for _, relativePath := range missing {
+		select {
+		case <-ctx.Done():
+			return result, ctx.Err()
+		default:
+		}
+
+		path := filepath.Join(fm.workingDir, relativePath)
+		if err := fm.uploadFile(ctx, path, relativePath); err != nil {
+			fm.logger.Error("Failed to upload file", slog.String("path", relativePath), slog.String("error", err.Error()))
+			result.Failed = append(result.Failed, FileError{Path: relativePath, Err: err})
+			continue
+		}
+		result.Succeeded = append(result.Succeeded, relativePath)
+	}
+
+	return result, nil
 }
 
 // ListRemoteFiles returns a list of all remote files
 func (fm *FileManager) ListRemoteFiles(prefix string) ([]string, error) {
-	return fm.storage.List(prefix)
+	keys, err := fm.storage.List(prefix)
+	return withoutInternalKeys(keys), storageError(err)
+}
+
+// ListRemoteFilesPage returns one page of remote files and the token of the next page
+func (fm *FileManager) ListRemoteFilesPage(prefix, continuationToken string, max int) ([]string, string, error) {
+	keys, nextToken, err := fm.storage.ListPage(prefix, continuationToken, max)
+	return withoutInternalKeys(keys), nextToken, storageError(err)
+}
+
+// ListRemoteDir returns the remote files directly under prefix and its sub-folders,
+// treating "/" in keys as the folder separator
+func (fm *FileManager) ListRemoteDir(prefix string) (files, folders []string, err error) {
+	files, folders, err = fm.storage.ListWithDelimiter(prefix, "/")
+	return withoutInternalKeys(files), folders, storageError(err)
+}
+
+// StatRemoteFile returns the size and modification time of a remote file
+func (fm *FileManager) StatRemoteFile(remotePath string) (storage.ObjectInfo, error) {
+	info, err := fm.storage.Stat(remotePath)
+	return info, storageError(err)
 }
 
-// DownloadSpecificFile downloads a specific file from remote storage
-func (fm *FileManager) DownloadSpecificFile(ctx context.Context, remotePath string) error {
+// ErrPresignNotSupported is returned when the storage backend cannot generate presigned URLs
+var ErrPresignNotSupported = errors.New("storage backend does not support presigned URLs")
+
+// PresignRemoteFile returns a time-limited download URL for a remote file
+func (fm *FileManager) PresignRemoteFile(remotePath string, expiry time.Duration) (string, error) {
+	presigner, ok := fm.storage.(storage.Presigner)
+	if !ok {
+		return "", ErrPresignNotSupported
+	}
+	return presigner.Presign(remotePath, expiry)
+}
+
+// ErrMoveNotSupported is returned by RenameRemoteFile when the storage backend cannot move objects
+var ErrMoveNotSupported = errors.New("storage backend does not support moving objects")
+
+// RenameRemoteFile moves a remote object to a new key on the server side,
+// refusing to overwrite an existing object
+func (fm *FileManager) RenameRemoteFile(ctx context.Context, src, dst string) error {
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
 	default:
 	}
 
-	localPath := filepath.Join(fm.workingDir, remotePath)
-	return fm.DownloadAndDecryptFile(remotePath, localPath)
+	mover, ok := fm.storage.(storage.Mover)
+	if !ok {
+		return ErrMoveNotSupported
+	}
+
+	src, dst = storage.NormalizeKey(src), storage.NormalizeKey(dst)
+	if src == "" || dst == "" || src == dst {
+		return fmt.Errorf("invalid rename from %q to %q", src, dst)
+	}
+	if _, err := fm.storage.Stat(dst); err == nil {
+		return fmt.Errorf("failed to rename %s: %s already exists", src, dst)
+	}
+
+	if err := mover.Move(src, dst); err != nil {
+		return fmt.Errorf("failed to rename %s to %s: %w", src, dst, err)
+	}
+	fm.changeManifest(manifestMove(src, dst))
+
+	fm.logger.Info("Remote file renamed successfully", slog.String("from", src), slog.String("to", dst))
+	return nil
 }
 
-// DeleteLocalFile deletes a local file
-func (fm *FileManager) DeleteLocalFile(relativePath string) error {
-	localPath := filepath.Join(fm.workingDir, relativePath)
+// ErrLocalFileExists is returned by DownloadSpecificFile when the local file
+// already exists and overwrite is false
+var ErrLocalFileExists = errors.New("local file already exists")
+
+// DownloadSpecificFile downloads a specific file from remote storage; an
+// existing local file is only replaced when overwrite is true
+func (fm *FileManager) DownloadSpecificFile(ctx context.Context, remotePath string, overwrite bool) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	localPath, err := fm.resolveLocalPath(filepath.FromSlash(remotePath))
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", remotePath, err)
+	}
+	if !overwrite && localFileExists(localPath) {
+		return fmt.Errorf("failed to download %s: %w", remotePath, ErrLocalFileExists)
+	}
+	return fm.downloadFile(ctx, remotePath, localPath)
+}
+
+// localFileExists reports whether path exists locally
+func localFileExists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+// ProgressFunc is called before each file of a batch is processed with the
+// number of files already done, and once more with done == total when finished
+type ProgressFunc func(done, total int, current string)
+
+// FileError records a file that failed during a batch operation
+type FileError struct {
+	Path string
+	Err  error
+}
+
+// BatchResult summarises a batch operation over several files
+type BatchResult struct {
+	Succeeded   []string
+	Failed      []FileError
+	Skipped     []string          // 本地已存在而未下载的文件
+	Overwritten []string          // Succeeded 中覆盖了本地已有文件的部分
+	Deleted     []string          // 镜像同步时删除的远程文件
+	LocalPaths  map[string]string // 下载时每个文件在本地的路径，包括因已存在而跳过的
+}
+
+// Total returns the number of files processed, including skipped ones
+func (r *BatchResult) Total() int {
+	return len(r.Succeeded) + len(r.Failed) + len(r.Skipped)
+}
+
+// FailedPaths returns the paths of the files that failed
+func (r *BatchResult) FailedPaths() []string {
+	paths := make([]string, 0, len(r.Failed))
+	for _, f := range r.Failed {
+		paths = append(paths, f.Path)
+	}
+	return paths
+}
+
+// DownloadFiles downloads the given remote files one by one, continuing past
+// failures; files that exist locally are skipped unless overwrite is true.
+// It only returns an error when ctx is cancelled; the result still describes
+// the files processed so far.
+func (fm *FileManager) DownloadFiles(ctx context.Context, remotePaths []string, overwrite bool, progress ProgressFunc) (*BatchResult, error) {
+	return fm.downloadFilesTo(ctx, remotePaths, overwrite, progress, func(remotePath string) (string, error) {
+		return fm.resolveLocalPath(resolveDownloadPath(remotePath, false, nil))
+	})
+}
+
+// EncryptAndUploadPaths encrypts and uploads each of the given local files or
+// directories, continuing past failures. It only returns an error when ctx is
+// cancelled; the result still describes the paths processed so far.
+func (fm *FileManager) EncryptAndUploadPaths(ctx context.Context, paths []string, progress ProgressFunc) (*BatchResult, error) {
+	fm.beginManifestBatch()
+	defer fm.endManifestBatch()
+
+	result := &BatchResult{}
+	total := len(paths)
+
+	for i, path := range paths {
+		select {
+		case <-ctx.Done():
+			return result, ctx.Err()
+		default:
+		}
 
-	// 确保文件在工作目录范围内
-	cleanLocalPath := filepath.Clean(localPath)
+		if progress != nil {
+			progress(i, total, path)
+		}
+
+		if err := fm.encryptAndUploadPath(ctx, path); err != nil {
+			if ctx.Err() != nil {
+				return result, ctx.Err()
+			}
+			fm.logger.Error("Failed to upload", slog.String("path", path), slog.String("error", err.Error()))
+			result.Failed = append(result.Failed, FileError{Path: path, Err: err})
+			continue
+		}
+		result.Succeeded = append(result.Succeeded, path)
+	}
+
+	if progress != nil {
+		progress(total, total, "")
+	}
+	return result, nil
+}
+
+// encryptAndUploadPath uploads a single local file or a whole directory
+func (fm *FileManager) encryptAndUploadPath(ctx context.Context, path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to stat file %s: %w", path, err)
+	}
+	if info.IsDir() {
+		return fm.EncryptAndUploadDirectory(ctx, path)
+	}
+
+	relativePath, err := filepath.Rel(fm.workingDir, path)
+	if err != nil {
+		return fmt.Errorf("failed to get relative path for %s: %w", path, err)
+	}
+	return fm.uploadFile(ctx, path, relativePath)
+}
+
+// ErrOutsideWorkingDir is returned when a path, such as a remote key being
+// downloaded, would resolve outside the working directory
+var ErrOutsideWorkingDir = errors.New("path is outside the working directory")
+
+// resolveLocalPath converts a path relative to the working directory into a
+// local path, rejecting absolute paths and paths that escape the working directory
+func (fm *FileManager) resolveLocalPath(relativePath string) (string, error) {
+	if relativePath == "" || filepath.IsAbs(relativePath) {
+		return "", fmt.Errorf("invalid path: %q", relativePath)
+	}
+
+	// 确保路径在工作目录范围内
+	cleanLocalPath := filepath.Clean(filepath.Join(fm.workingDir, relativePath))
 	cleanWorkingDir := filepath.Clean(fm.workingDir)
 
 	relPath, err := filepath.Rel(cleanWorkingDir, cleanLocalPath)
-	if err != nil || strings.HasPrefix(relPath, "..") {
-		return fmt.Errorf("file is outside working directory")
+	if err != nil || relPath == "." || strings.HasPrefix(relPath, "..") {
+		return "", fmt.Errorf("%s: %w", relativePath, ErrOutsideWorkingDir)
+	}
+	return cleanLocalPath, nil
+}
+
+// DeleteLocalFile deletes a local file, or moves it to the trash when use_trash is enabled
+func (fm *FileManager) DeleteLocalFile(relativePath string) error {
+	localPath, err := fm.resolveLocalPath(relativePath)
+	if err != nil {
+		return err
+	}
+
+	// 回收站内的文件直接永久删除
+	if fm.config.UseTrash && !fm.inTrash(localPath) {
+		return fm.moveToTrash(localPath, relativePath)
 	}
 
 	if err := os.Remove(localPath); err != nil {
@@ -241,3 +973,253 @@ func (fm *FileManager) DeleteLocalFile(relativePath string) error {
 	fm.logger.Info("File deleted successfully", slog.String("path", relativePath))
 	return nil
 }
+
+// ErrDeleteWorkingDir is returned when asked to delete the working directory itself
+var ErrDeleteWorkingDir = errors.New("cannot delete the working directory")
+
+// DirectoryUsage returns the number of files under a local directory and their total size
+func (fm *FileManager) DirectoryUsage(relativePath string) (files int, size int64, err error) {
+	localPath, err := fm.resolveLocalPath(relativePath)
+	if err != nil {
+		return 0, 0, err
+	}
+	err = filepath.WalkDir(localPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files++
+		size += info.Size()
+		return nil
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to scan directory %s: %w: %w", relativePath, ErrLocalIO, err)
+	}
+	return files, size, nil
+}
+
+// DeleteLocalDirectory deletes a local directory and everything under it, or
+// moves it to the trash when use_trash is enabled. The working directory root
+// itself is never deleted.
+func (fm *FileManager) DeleteLocalDirectory(relativePath string) error {
+	if clean := filepath.Clean(relativePath); relativePath == "" || clean == "." {
+		return ErrDeleteWorkingDir
+	}
+	localPath, err := fm.resolveLocalPath(relativePath)
+	if err != nil {
+		return err
+	}
+	info, err := os.Lstat(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to delete directory %s: %w: %w", relativePath, ErrLocalIO, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("failed to delete directory %s: not a directory", relativePath)
+	}
+
+	if fm.config.UseTrash && !fm.inTrash(localPath) {
+		return fm.moveToTrash(localPath, relativePath)
+	}
+
+	if err := os.RemoveAll(localPath); err != nil {
+		return fmt.Errorf("failed to delete directory %s: %w: %w", relativePath, ErrLocalIO, err)
+	}
+
+	fm.logger.Info("Directory deleted successfully", slog.String("path", relativePath))
+	return nil
+}
+
+// DeleteRemoteFile deletes a file from remote storage
+func (fm *FileManager) DeleteRemoteFile(remotePath string) error {
+	key := storage.NormalizeKey(remotePath)
+	if key == "" {
+		return fmt.Errorf("remote path is empty")
+	}
+	if err := fm.storage.Delete(key); err != nil {
+		return fmt.Errorf("failed to delete remote file %s: %w: %w", key, ErrStorage, err)
+	}
+	fm.changeManifest(manifestRemove(key))
+
+	fm.logger.Info("Remote file deleted successfully", slog.String("path", key))
+	return nil
+}
+
+// CreateLocalDirectory creates a new directory under the working directory
+func (fm *FileManager) CreateLocalDirectory(relativePath string) error {
+	localPath, err := fm.resolveLocalPath(relativePath)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Mkdir(localPath, defaultDirMode); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", relativePath, err)
+	}
+
+	fm.logger.Info("Directory created successfully", slog.String("path", relativePath))
+	return nil
+}
+
+// RenameLocalEntry renames a local file or directory, refusing to overwrite an existing entry
+func (fm *FileManager) RenameLocalEntry(oldRelative, newRelative string) error {
+	oldPath, err := fm.resolveLocalPath(oldRelative)
+	if err != nil {
+		return err
+	}
+	newPath, err := fm.resolveLocalPath(newRelative)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Lstat(oldPath); err != nil {
+		return fmt.Errorf("failed to rename %s: %w", oldRelative, err)
+	}
+	// os.Rename 会静默覆盖已存在的文件，需先检查
+	if _, err := os.Lstat(newPath); err == nil {
+		return fmt.Errorf("failed to rename %s: %s already exists", oldRelative, newRelative)
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to rename %s: %w", oldRelative, err)
+	}
+
+	if err := os.Rename(oldPath, newPath); err != nil {
+		return fmt.Errorf("failed to rename %s to %s: %w", oldRelative, newRelative, err)
+	}
+
+	fm.logger.Info("Entry renamed successfully", slog.String("from", oldRelative), slog.String("to", newRelative))
+	return nil
+}
+
+// RemoteType returns the configured storage backend, e.g. "oss" or "localhost"
+func (fm *FileManager) RemoteType() string {
+	return fm.config.Storage.RemoteType
+}
+
+// IncludeHidden reports whether directory and sync uploads include hidden files
+func (fm *FileManager) IncludeHidden() bool {
+	return fm.includeHidden
+}
+
+// SetIncludeHidden sets whether directory and sync uploads include hidden files
+func (fm *FileManager) SetIncludeHidden(include bool) {
+	fm.includeHidden = include
+}
+
+// SetFileSizeLimits sets the size range for directory and sync uploads, 0 means no limit
+func (fm *FileManager) SetFileSizeLimits(min, max int64) {
+	fm.minFileSize = min
+	fm.maxFileSize = max
+}
+
+// SetExtensionFilter sets the extensions directory and sync uploads include and exclude.
+// A non-empty include list limits uploads to those extensions, exclude then filters the rest.
+func (fm *FileManager) SetExtensionFilter(include, exclude []string) {
+	fm.includeExts = extensionSet(include)
+	fm.excludeExts = extensionSet(exclude)
+}
+
+// extensionSet normalizes extensions to lower case with a leading dot
+func extensionSet(exts []string) map[string]bool {
+	if len(exts) == 0 {
+		return nil
+	}
+	set := make(map[string]bool, len(exts))
+	for _, ext := range exts {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if ext == "" {
+			continue
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		set[ext] = true
+	}
+	return set
+}
+
+// fileExtension returns the lower-case extension of name, dotfiles like .bashrc have none
+func fileExtension(name string) string {
+	return strings.ToLower(filepath.Ext(strings.TrimLeft(name, ".")))
+}
+
+// filteredOut reports whether a walked file is excluded by the size or extension filters
+func (fm *FileManager) filteredOut(path string, info os.FileInfo) bool {
+	if isSymlink(info) {
+		// 保存为链接时大小是目标路径的长度，不按大小过滤
+		return fm.excludedExtension(path, info)
+	}
+	return fm.outsideSizeRange(path, info) || fm.excludedExtension(path, info)
+}
+
+// excludedExtension reports whether a walked file is excluded by the extension filters
+func (fm *FileManager) excludedExtension(path string, info os.FileInfo) bool {
+	ext := fileExtension(info.Name())
+	if (len(fm.includeExts) > 0 && !fm.includeExts[ext]) || fm.excludeExts[ext] {
+		fm.logger.Debug("Skipping file by extension", slog.String("path", path), slog.String("extension", ext))
+		return true
+	}
+	return false
+}
+
+// outsideSizeRange reports whether a walked file is outside the configured size range
+func (fm *FileManager) outsideSizeRange(path string, info os.FileInfo) bool {
+	size := info.Size()
+	if (fm.minFileSize > 0 && size < fm.minFileSize) || (fm.maxFileSize > 0 && size > fm.maxFileSize) {
+		fm.logger.Debug("Skipping file outside size range",
+			slog.String("path", path),
+			slog.Int64("size", size),
+			slog.Int64("min", fm.minFileSize),
+			slog.Int64("max", fm.maxFileSize))
+		return true
+	}
+	return false
+}
+
+// skipEntry reports whether a walked entry is the trash, an upload checkpoint
+// or a partial download, or hidden while hidden files are excluded
+func (fm *FileManager) skipEntry(info os.FileInfo) bool {
+	return info.Name() == TrashDirName || info.Name() == UploadStateFile || info.Name() == QuarantineFile ||
+		strings.HasSuffix(info.Name(), partSuffix) ||
+		(!fm.includeHidden && IsHidden(info.Name()))
+}
+
+// Notifications reports whether finished operations send an OS notification
+func (fm *FileManager) Notifications() bool {
+	return fm.config.Notifications
+}
+
+// SkipSyncConfirm reports whether Sync Upload and Sync Download run without asking for confirmation
+func (fm *FileManager) SkipSyncConfirm() bool {
+	return fm.config.SkipSyncConfirm
+}
+
+// SyncSchedule returns the configured scheduled sync interval and mode,
+// falling back to DefaultSyncInterval and SyncModeUpload
+func (fm *FileManager) SyncSchedule() (time.Duration, SyncMode, error) {
+	interval := fm.config.Sync.Interval
+	if interval <= 0 {
+		interval = DefaultSyncInterval
+	}
+	mode, err := ParseSyncMode(fm.config.Sync.Mode)
+	return interval, mode, err
+}
+
+// ScheduledSyncConfigured reports whether a scheduled sync interval is set in the config
+func (fm *FileManager) ScheduledSyncConfigured() bool {
+	return fm.config.Sync.Interval > 0
+}
+
+// KeepSelectionAfterOp reports whether refreshing the file list after an
+// operation keeps the selected items that still exist
+func (fm *FileManager) KeepSelectionAfterOp() bool {
+	return fm.config.KeepSelection
+}
+
+// UploadOnRename reports whether renamed entries should be re-uploaded under their new remote key
+func (fm *FileManager) UploadOnRename() bool {
+	return fm.config.UploadOnRename
+}
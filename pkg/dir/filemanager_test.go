@@ -1,58 +1,46 @@
 package dir
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"log/slog"
 	"os"
 	"path/filepath"
-	"strings"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/mingregister/fers/pkg/config"
 	"github.com/mingregister/fers/pkg/crypto"
+	"github.com/mingregister/fers/pkg/storage"
 )
 
-// Mock implementations for testing
-type mockStorage struct {
-	files map[string][]byte
-}
-
-func newMockStorage() *mockStorage {
-	return &mockStorage{
-		files: make(map[string][]byte),
-	}
-}
-
-func (m *mockStorage) List(prefix string) ([]string, error) {
-	var result []string
-	for key := range m.files {
-		if prefix == "" || strings.HasPrefix(key, prefix) {
-			result = append(result, key)
-		}
+// mustUpload stores data under key in the test storage
+func mustUpload(t *testing.T, store storage.Client, key string, data []byte) {
+	t.Helper()
+	if err := store.Upload(key, data); err != nil {
+		t.Fatalf("Failed to upload %s: %v", key, err)
 	}
-	return result, nil
-}
-
-func (m *mockStorage) Upload(key string, data []byte) error {
-	m.files[key] = data
-	return nil
 }
 
-func (m *mockStorage) Download(key string) ([]byte, error) {
-	data, exists := m.files[key]
-	if !exists {
-		return nil, os.ErrNotExist
+// remoteFileCount returns the number of objects in the test storage
+func remoteFileCount(t *testing.T, store storage.Client) int {
+	t.Helper()
+	files, err := store.List("")
+	if err != nil {
+		t.Fatalf("Failed to list remote files: %v", err)
 	}
-	return data, nil
-}
-
-func (m *mockStorage) Delete(key string) error {
-	delete(m.files, key)
-	return nil
+	return len(files)
 }
 
-func createTestFileManager(t *testing.T) (*FileManager, string, *mockStorage) {
+func createTestFileManager(t *testing.T) (*FileManager, string, storage.Client) {
 	tempDir := t.TempDir()
 
 	cfg := &config.Config{
@@ -60,7 +48,7 @@ func createTestFileManager(t *testing.T) (*FileManager, string, *mockStorage) {
 		CryptoKey: "test-key-123",
 	}
 
-	mockStore := newMockStorage()
+	mockStore := storage.NewMemoryClient()
 	cipher := crypto.NewAESGCM("test-password")
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
 
@@ -72,7 +60,7 @@ func createTestFileManager(t *testing.T) (*FileManager, string, *mockStorage) {
 func TestNewFileManager(t *testing.T) {
 	tempDir := t.TempDir()
 	cfg := &config.Config{TargetDir: tempDir}
-	mockStore := newMockStorage()
+	mockStore := storage.NewMemoryClient()
 	cipher := crypto.NewAESGCM("test")
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 
@@ -105,8 +93,8 @@ func TestFileManager_EncryptAndUploadFile(t *testing.T) {
 	}
 
 	// Verify file was uploaded
-	uploadedData, exists := mockStore.files["test.txt"]
-	if !exists {
+	uploadedData, err := mockStore.Download("test.txt")
+	if err != nil {
 		t.Fatal("File was not uploaded to storage")
 	}
 
@@ -163,15 +151,15 @@ func TestFileManager_EncryptAndUploadDirectory(t *testing.T) {
 	}
 
 	// Verify all files were uploaded
-	if len(mockStore.files) != len(files) {
-		t.Errorf("Expected %d files uploaded, got %d", len(files), len(mockStore.files))
+	if remoteFileCount(t, mockStore) != len(files) {
+		t.Errorf("Expected %d files uploaded, got %d", len(files), remoteFileCount(t, mockStore))
 	}
 
 	// Verify each file
 	cipher := crypto.NewAESGCM("test-password")
 	for relPath, expectedContent := range files {
-		uploadedData, exists := mockStore.files[filepath.ToSlash(relPath)]
-		if !exists {
+		uploadedData, err := mockStore.Download(filepath.ToSlash(relPath))
+		if err != nil {
 			t.Errorf("File %s was not uploaded", relPath)
 			continue
 		}
@@ -188,6 +176,70 @@ func TestFileManager_EncryptAndUploadDirectory(t *testing.T) {
 	}
 }
 
+func TestFileManager_PreserveEmptyDirsRoundTrip(t *testing.T) {
+	fm, tempDir, mockStore := createTestFileManager(t)
+	fm.config.PreserveEmptyDirs = true
+
+	for _, dir := range []string{"tree/empty", "tree/full"} {
+		if err := os.MkdirAll(filepath.Join(tempDir, dir), 0755); err != nil {
+			t.Fatalf("Failed to create directory %s: %v", dir, err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "tree/full/file.txt"), []byte("data"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	if err := fm.EncryptAndUploadDirectory(context.Background(), filepath.Join(tempDir, "tree")); err != nil {
+		t.Fatalf("EncryptAndUploadDirectory failed: %v", err)
+	}
+
+	if _, err := mockStore.Stat("tree/empty/" + EmptyDirPlaceholder); err != nil {
+		t.Fatalf("Expected placeholder for empty directory: %v", err)
+	}
+	if _, err := mockStore.Stat("tree/full/" + EmptyDirPlaceholder); err == nil {
+		t.Error("Non-empty directory should not get a placeholder")
+	}
+
+	if err := os.RemoveAll(filepath.Join(tempDir, "tree")); err != nil {
+		t.Fatalf("Failed to remove local tree: %v", err)
+	}
+	if _, err := fm.SyncDownload(context.Background()); err != nil {
+		t.Fatalf("SyncDownload failed: %v", err)
+	}
+
+	info, err := os.Stat(filepath.Join(tempDir, "tree/empty"))
+	if err != nil || !info.IsDir() {
+		t.Fatalf("Empty directory should be recreated: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "tree/empty", EmptyDirPlaceholder)); !os.IsNotExist(err) {
+		t.Error("Placeholder should not be written locally")
+	}
+
+	// 目录已存在时，占位符不再出现在同步计划中
+	missing, err := fm.PlanSyncDownload(context.Background())
+	if err != nil {
+		t.Fatalf("PlanSyncDownload failed: %v", err)
+	}
+	if len(missing) != 0 {
+		t.Errorf("Expected nothing to download after round-trip, got %v", missing)
+	}
+}
+
+func TestFileManager_PreserveEmptyDirsDisabled(t *testing.T) {
+	fm, tempDir, mockStore := createTestFileManager(t)
+
+	if err := os.MkdirAll(filepath.Join(tempDir, "tree/empty"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := fm.EncryptAndUploadDirectory(context.Background(), filepath.Join(tempDir, "tree")); err != nil {
+		t.Fatalf("EncryptAndUploadDirectory failed: %v", err)
+	}
+
+	if count := remoteFileCount(t, mockStore); count != 0 {
+		t.Errorf("Expected no remote keys without PreserveEmptyDirs, got %d", count)
+	}
+}
+
 func TestFileManager_DownloadAndDecryptFile(t *testing.T) {
 	fm, tempDir, mockStore := createTestFileManager(t)
 
@@ -199,7 +251,7 @@ func TestFileManager_DownloadAndDecryptFile(t *testing.T) {
 		t.Fatalf("Failed to encrypt test data: %v", err)
 	}
 
-	mockStore.files["download/test.txt"] = encrypted
+	mustUpload(t, mockStore, "download/test.txt", encrypted)
 
 	// Download and decrypt
 	localPath := filepath.Join(tempDir, "downloaded.txt")
@@ -235,7 +287,7 @@ func TestFileManager_SyncDownload(t *testing.T) {
 		if err != nil {
 			t.Fatalf("Failed to encrypt %s: %v", remotePath, err)
 		}
-		mockStore.files[remotePath] = encrypted
+		mustUpload(t, mockStore, remotePath, encrypted)
 	}
 
 	// Create one file locally that already exists remotely
@@ -247,10 +299,13 @@ func TestFileManager_SyncDownload(t *testing.T) {
 
 	// Sync download
 	ctx := context.Background()
-	err = fm.SyncDownload(ctx)
+	result, err := fm.SyncDownload(ctx)
 	if err != nil {
 		t.Fatalf("SyncDownload failed: %v", err)
 	}
+	if len(result.Succeeded) != 2 || len(result.Failed) != 0 {
+		t.Errorf("Expected 2 succeeded and 0 failed files, got %+v", result)
+	}
 
 	// Verify missing files were downloaded
 	expectedDownloads := []string{"remote1.txt", "folder/remote2.txt"}
@@ -283,6 +338,175 @@ func TestFileManager_SyncDownload(t *testing.T) {
 	}
 }
 
+// failingUploadStorage fails uploads of the keys in failing
+type failingUploadStorage struct {
+	storage.Client
+	failing map[string]bool
+}
+
+func (f failingUploadStorage) Upload(key string, data []byte) error {
+	if f.failing[key] {
+		return fmt.Errorf("upload %s: simulated failure", key)
+	}
+	return f.Client.Upload(key, data)
+}
+
+func TestFileManager_SyncDownloadPartialFailure(t *testing.T) {
+	fm, _, mockStore := createTestFileManager(t)
+
+	cipher := crypto.NewAESGCM("test-password")
+	for _, key := range []string{"a.txt", "b.txt"} {
+		encrypted, err := cipher.Encrypt([]byte(key))
+		if err != nil {
+			t.Fatalf("Failed to encrypt %s: %v", key, err)
+		}
+		mustUpload(t, mockStore, key, encrypted)
+	}
+	// 不是有效的密文，解密失败
+	mustUpload(t, mockStore, "corrupt.txt", []byte("garbage"))
+
+	result, err := fm.SyncDownload(context.Background())
+	if err != nil {
+		t.Fatalf("SyncDownload failed: %v", err)
+	}
+	if len(result.Succeeded) != 2 || result.Total() != 3 {
+		t.Errorf("Expected 2 of 3 files to succeed, got %+v", result)
+	}
+	if !reflect.DeepEqual(result.FailedPaths(), []string{"corrupt.txt"}) || result.Failed[0].Err == nil {
+		t.Errorf("Expected corrupt.txt to fail with an error, got %+v", result.Failed)
+	}
+}
+
+// concurrentDownloadStorage records how many downloads run at the same time
+type concurrentDownloadStorage struct {
+	storage.Client
+	running atomic.Int32
+	peak    atomic.Int32
+}
+
+func (s *concurrentDownloadStorage) Download(key string) ([]byte, error) {
+	n := s.running.Add(1)
+	defer s.running.Add(-1)
+	for {
+		peak := s.peak.Load()
+		if n <= peak || s.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	time.Sleep(20 * time.Millisecond)
+	return s.Client.Download(key)
+}
+
+func TestFileManager_SyncDownloadConcurrent(t *testing.T) {
+	fm, tempDir, mockStore := createTestFileManager(t)
+	fm.downloadWorkers = 4
+	counting := &concurrentDownloadStorage{Client: mockStore}
+	fm.storage = counting
+
+	cipher := crypto.NewAESGCM("test-password")
+	var keys []string
+	for i := 0; i < 12; i++ {
+		key := fmt.Sprintf("dir%d/sub/file%02d.txt", i%3, i)
+		encrypted, err := cipher.Encrypt([]byte(key))
+		if err != nil {
+			t.Fatalf("Failed to encrypt %s: %v", key, err)
+		}
+		mustUpload(t, mockStore, key, encrypted)
+		keys = append(keys, key)
+	}
+	mustUpload(t, mockStore, "existing.txt", []byte("remote"))
+	if err := os.WriteFile(filepath.Join(tempDir, "existing.txt"), []byte("local"), 0644); err != nil {
+		t.Fatalf("Failed to create existing.txt: %v", err)
+	}
+
+	result, err := fm.SyncDownload(context.Background())
+	if err != nil {
+		t.Fatalf("SyncDownload failed: %v", err)
+	}
+	sort.Strings(keys)
+	if !reflect.DeepEqual(result.Succeeded, keys) || len(result.Failed) != 0 {
+		t.Errorf("Expected every missing file downloaded in planned order, got %+v", result)
+	}
+	if peak := counting.peak.Load(); peak < 2 || peak > 4 {
+		t.Errorf("Expected between 2 and 4 concurrent downloads, got %d", peak)
+	}
+
+	for _, key := range keys {
+		data, err := os.ReadFile(filepath.Join(tempDir, filepath.FromSlash(key)))
+		if err != nil || string(data) != key {
+			t.Errorf("Expected %s downloaded, got %q, %v", key, data, err)
+		}
+	}
+	if data, _ := os.ReadFile(filepath.Join(tempDir, "existing.txt")); string(data) != "local" {
+		t.Errorf("Existing local file should not be overwritten, got %q", data)
+	}
+}
+
+func TestFileManager_DownloadMissingSkipsFilesCreatedSincePlanning(t *testing.T) {
+	fm, tempDir, mockStore := createTestFileManager(t)
+	encrypted, err := crypto.NewAESGCM("test-password").Encrypt([]byte("remote"))
+	if err != nil {
+		t.Fatalf("Failed to encrypt: %v", err)
+	}
+	mustUpload(t, mockStore, "a.txt", encrypted)
+	mustUpload(t, mockStore, "b.txt", encrypted)
+
+	missing, err := fm.PlanSyncDownload(context.Background())
+	if err != nil {
+		t.Fatalf("PlanSyncDownload failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "b.txt"), []byte("local"), 0644); err != nil {
+		t.Fatalf("Failed to create b.txt: %v", err)
+	}
+
+	result, err := fm.downloadMissing(context.Background(), missing)
+	if err != nil {
+		t.Fatalf("downloadMissing failed: %v", err)
+	}
+	if !reflect.DeepEqual(result.Succeeded, []string{"a.txt"}) || !reflect.DeepEqual(result.Skipped, []string{"b.txt"}) {
+		t.Errorf("Expected a.txt downloaded and b.txt skipped, got %+v", result)
+	}
+	if data, _ := os.ReadFile(filepath.Join(tempDir, "b.txt")); string(data) != "local" {
+		t.Errorf("b.txt should not be overwritten, got %q", data)
+	}
+}
+
+func TestFileManager_DownloadMissingCancelled(t *testing.T) {
+	fm, _, _ := createTestFileManager(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result, err := fm.downloadMissing(ctx, []string{"a.txt", "b.txt"})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	if result.Total() != 0 {
+		t.Errorf("Expected nothing processed after cancellation, got %+v", result)
+	}
+}
+
+func TestFileManager_SyncUploadPartialFailure(t *testing.T) {
+	fm, tempDir, mockStore := createTestFileManager(t)
+	fm.storage = failingUploadStorage{Client: mockStore, failing: map[string]bool{"b.txt": true, "c.txt": true}}
+
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	result, err := fm.SyncUpload(context.Background())
+	if err != nil {
+		t.Fatalf("SyncUpload failed: %v", err)
+	}
+	if !reflect.DeepEqual(result.Succeeded, []string{"a.txt"}) {
+		t.Errorf("Expected only a.txt to succeed, got %v", result.Succeeded)
+	}
+	if !reflect.DeepEqual(result.FailedPaths(), []string{"b.txt", "c.txt"}) {
+		t.Errorf("Expected b.txt and c.txt to fail, got %v", result.FailedPaths())
+	}
+}
+
 func TestFileManager_SyncUpload(t *testing.T) {
 	fm, tempDir, mockStore := createTestFileManager(t)
 
@@ -304,26 +528,31 @@ func TestFileManager_SyncUpload(t *testing.T) {
 		}
 	}
 
-	// Add one file that already exists remotely
+	// Add one file that already exists remotely with the same content
 	cipher := crypto.NewAESGCM("test-password")
-	existingEncrypted, err := cipher.Encrypt([]byte("remote existing content"))
+	if err := fm.EncryptAndUploadFile(filepath.Join(tempDir, "existing.txt"), "existing.txt"); err != nil {
+		t.Fatalf("Failed to upload existing remote file: %v", err)
+	}
+	existingEncrypted, err := mockStore.Download("existing.txt")
 	if err != nil {
-		t.Fatalf("Failed to encrypt existing remote file: %v", err)
+		t.Fatalf("Failed to download existing remote file: %v", err)
 	}
-	mockStore.files["existing.txt"] = existingEncrypted
 
 	// Sync upload
 	ctx := context.Background()
-	err = fm.SyncUpload(ctx)
+	result, err := fm.SyncUpload(ctx)
 	if err != nil {
 		t.Fatalf("SyncUpload failed: %v", err)
 	}
+	if len(result.Succeeded) != 2 || len(result.Failed) != 0 {
+		t.Errorf("Expected 2 succeeded and 0 failed files, got %+v", result)
+	}
 
 	// Verify missing files were uploaded
 	expectedUploads := []string{"local1.txt", "folder/local2.txt"}
 	for _, relPath := range expectedUploads {
-		uploadedData, exists := mockStore.files[filepath.ToSlash(relPath)]
-		if !exists {
+		uploadedData, err := mockStore.Download(filepath.ToSlash(relPath))
+		if err != nil {
 			t.Errorf("File %s was not uploaded", relPath)
 			continue
 		}
@@ -340,164 +569,1069 @@ func TestFileManager_SyncUpload(t *testing.T) {
 		}
 	}
 
-	// Verify existing remote file was not overwritten
-	existingData := mockStore.files["existing.txt"]
-	decrypted, err := cipher.Decrypt(existingData)
+	// Verify the unchanged remote file was not uploaded again
+	existingData, err := mockStore.Download("existing.txt")
 	if err != nil {
-		t.Fatalf("Failed to decrypt existing remote file: %v", err)
+		t.Fatalf("Failed to download existing remote file: %v", err)
 	}
-	if string(decrypted) != "remote existing content" {
-		t.Error("Existing remote file was overwritten during sync upload")
+	if !bytes.Equal(existingData, existingEncrypted) {
+		t.Error("Unchanged remote file was uploaded again during sync upload")
 	}
 }
 
-func TestFileManager_ListRemoteFiles(t *testing.T) {
-	fm, _, mockStore := createTestFileManager(t)
+// writeFileContents writes each file below root with the given content
+func writeFileContents(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+}
 
-	// Add files to mock storage
-	mockStore.files["file1.txt"] = []byte("data1")
-	mockStore.files["folder/file2.txt"] = []byte("data2")
-	mockStore.files["folder/file3.txt"] = []byte("data3")
-	mockStore.files["other/file4.txt"] = []byte("data4")
+// uploadRecordingStorage records the keys uploaded through it, keeping metadata
+type uploadRecordingStorage struct {
+	storage.Client
+	uploaded []string
+}
 
-	// Test listing all files
-	files, err := fm.ListRemoteFiles("")
-	if err != nil {
-		t.Fatalf("ListRemoteFiles failed: %v", err)
-	}
+func (s *uploadRecordingStorage) Upload(key string, data []byte) error {
+	s.uploaded = append(s.uploaded, key)
+	return s.Client.Upload(key, data)
+}
 
-	if len(files) != 4 {
-		t.Errorf("Expected 4 files, got %d", len(files))
+func (s *uploadRecordingStorage) UploadWithMeta(key string, data []byte, meta map[string]string) error {
+	s.uploaded = append(s.uploaded, key)
+	return s.Client.(storage.MetadataUploader).UploadWithMeta(key, data, meta)
+}
+
+func TestFileManager_SyncUpload_SkipsUnchangedFiles(t *testing.T) {
+	fm, tempDir, _ := createTestFileManager(t)
+	writeFileContents(t, tempDir, map[string]string{
+		"a.txt":       "alpha",
+		"docs/b.txt":  "bravo",
+		"docs/c.txt":  "charlie",
+		"same-size.a": "12345",
+	})
+	recording := &uploadRecordingStorage{Client: fm.storage}
+	fm.storage = recording
+
+	if _, err := fm.SyncUpload(t.Context()); err != nil {
+		t.Fatalf("First SyncUpload failed: %v", err)
 	}
+	recording.uploaded = nil
 
-	// Test listing with prefix
-	folderFiles, err := fm.ListRemoteFiles("folder/")
+	result, err := fm.SyncUpload(t.Context())
 	if err != nil {
-		t.Fatalf("ListRemoteFiles with prefix failed: %v", err)
+		t.Fatalf("Second SyncUpload failed: %v", err)
 	}
-
-	if len(folderFiles) != 2 {
-		t.Errorf("Expected 2 files with folder/ prefix, got %d", len(folderFiles))
+	if len(result.Succeeded) != 0 || len(recording.uploaded) != 0 {
+		t.Errorf("Expected no uploads for an unchanged tree, got %v (uploaded %v)", result.Succeeded, recording.uploaded)
 	}
-}
-
-func TestFileManager_DownloadSpecificFile(t *testing.T) {
-	fm, tempDir, mockStore := createTestFileManager(t)
 
-	// Prepare encrypted data in mock storage
-	cipher := crypto.NewAESGCM("test-password")
-	originalContent := []byte("specific file content")
-	encrypted, err := cipher.Encrypt(originalContent)
+	// 修改内容（包括大小不变的修改）后重新上传；修改时间与上传时记录的不同
+	writeFileContents(t, tempDir, map[string]string{"docs/b.txt": "bravo, changed", "same-size.a": "54321"})
+	later := time.Now().Add(time.Minute)
+	for _, name := range []string{"docs/b.txt", "same-size.a"} {
+		if err := os.Chtimes(filepath.Join(tempDir, name), later, later); err != nil {
+			t.Fatalf("Chtimes failed: %v", err)
+		}
+	}
+	result, err = fm.SyncUpload(t.Context())
 	if err != nil {
-		t.Fatalf("Failed to encrypt test data: %v", err)
+		t.Fatalf("SyncUpload after change failed: %v", err)
+	}
+	if !reflect.DeepEqual(result.Succeeded, []string{filepath.Join("docs", "b.txt"), "same-size.a"}) {
+		t.Errorf("Expected only the changed files to upload, got %v", result.Succeeded)
+	}
+	restored := filepath.Join(t.TempDir(), "b.txt")
+	if err := fm.DownloadAndDecryptFile("docs/b.txt", restored); err != nil {
+		t.Fatalf("DownloadAndDecryptFile failed: %v", err)
+	}
+	if data, _ := os.ReadFile(restored); string(data) != "bravo, changed" {
+		t.Errorf("Expected the changed content remotely, got %q", data)
 	}
+}
 
-	mockStore.files["specific/file.txt"] = encrypted
+func TestFileManager_SyncUpload_UploadsWhenMetadataMissing(t *testing.T) {
+	fm, tempDir, store := createTestFileManager(t)
+	writeFileContents(t, tempDir, map[string]string{"legacy.txt": "legacy"})
 
-	// Download specific file
-	ctx := context.Background()
-	err = fm.DownloadSpecificFile(ctx, "specific/file.txt")
+	// 没有明文哈希元数据的旧对象无法判断是否相同
+	encrypted, err := fm.cipher.Encrypt([]byte("legacy"))
 	if err != nil {
-		t.Fatalf("DownloadSpecificFile failed: %v", err)
+		t.Fatalf("Encrypt failed: %v", err)
 	}
+	mustUpload(t, store, "legacy.txt", encrypted)
 
-	// Verify file was downloaded
-	localPath := filepath.Join(tempDir, "specific", "file.txt")
-	content, err := os.ReadFile(localPath)
+	result, err := fm.SyncUpload(t.Context())
 	if err != nil {
-		t.Fatalf("Failed to read downloaded file: %v", err)
+		t.Fatalf("SyncUpload failed: %v", err)
+	}
+	if !reflect.DeepEqual(result.Succeeded, []string{"legacy.txt"}) {
+		t.Errorf("Expected legacy.txt to be uploaded again, got %v", result.Succeeded)
 	}
+	info, err := store.Stat("legacy.txt")
+	if err != nil || info.Metadata[storage.MetaSHA256] == "" {
+		t.Errorf("Expected the re-upload to store the hash, got %v (%v)", info.Metadata, err)
+	}
+}
 
-	if string(content) != string(originalContent) {
-		t.Errorf("Downloaded content mismatch. Expected: %s, Got: %s", originalContent, content)
+// writeHiddenTree creates visible and hidden files below root for the hidden-file tests
+func writeHiddenTree(t *testing.T, root string) {
+	t.Helper()
+	files := []string{"visible.txt", ".bashrc", ".config/app.conf", "sub/.secret"}
+	for _, relPath := range files {
+		fullPath := filepath.Join(root, relPath)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", relPath, err)
+		}
+		if err := os.WriteFile(fullPath, []byte(relPath), 0644); err != nil {
+			t.Fatalf("Failed to create file %s: %v", relPath, err)
+		}
 	}
 }
 
-func TestFileManager_DeleteLocalFile(t *testing.T) {
+func TestFileManager_UploadExcludesHiddenByDefault(t *testing.T) {
 	fm, tempDir, _ := createTestFileManager(t)
+	writeHiddenTree(t, tempDir)
 
-	// Create a test file
-	testFile := filepath.Join(tempDir, "delete_me.txt")
-	err := os.WriteFile(testFile, []byte("delete this"), 0644)
-	if err != nil {
-		t.Fatalf("Failed to create test file: %v", err)
+	if _, err := fm.SyncUpload(context.Background()); err != nil {
+		t.Fatalf("SyncUpload failed: %v", err)
+	}
+	if err := fm.EncryptAndUploadDirectory(context.Background(), filepath.Join(tempDir, "sub")); err != nil {
+		t.Fatalf("EncryptAndUploadDirectory failed: %v", err)
 	}
 
-	// Delete the file
-	err = fm.DeleteLocalFile("delete_me.txt")
+	files, err := fm.ListRemoteFiles("")
 	if err != nil {
-		t.Fatalf("DeleteLocalFile failed: %v", err)
+		t.Fatalf("Failed to list remote files: %v", err)
 	}
-
-	// Verify file was deleted
-	if _, err := os.Stat(testFile); !os.IsNotExist(err) {
-		t.Error("File was not deleted")
+	if len(files) != 1 || files[0] != "visible.txt" {
+		t.Errorf("Expected only visible.txt to be uploaded, got %v", files)
 	}
 }
 
-func TestFileManager_DeleteLocalFile_SecurityCheck(t *testing.T) {
-	fm, _, _ := createTestFileManager(t)
+func TestFileManager_UploadIncludeHidden(t *testing.T) {
+	fm, tempDir, mockStore := createTestFileManager(t)
+	writeHiddenTree(t, tempDir)
+	fm.SetIncludeHidden(true)
 
-	// Try to delete a file outside working directory
-	err := fm.DeleteLocalFile("../outside.txt")
-	if err == nil {
-		t.Error("Should not allow deleting files outside working directory")
+	if _, err := fm.SyncUpload(context.Background()); err != nil {
+		t.Fatalf("SyncUpload failed: %v", err)
+	}
+	if err := fm.EncryptAndUploadDirectory(context.Background(), filepath.Join(tempDir, "sub")); err != nil {
+		t.Fatalf("EncryptAndUploadDirectory failed: %v", err)
 	}
 
-	// Try to delete with absolute path outside working directory
-	err = fm.DeleteLocalFile("/etc/passwd")
-	if err == nil {
-		t.Error("Should not allow deleting files with absolute paths outside working directory")
+	for _, key := range []string{"visible.txt", ".bashrc", ".config/app.conf", "sub/.secret"} {
+		if _, err := mockStore.Stat(key); err != nil {
+			t.Errorf("Expected %s to be uploaded with IncludeHidden: %v", key, err)
+		}
 	}
 }
 
-func TestFileManager_ContextCancellation(t *testing.T) {
-	fm, tempDir, _ := createTestFileManager(t)
+func TestFileManager_UploadFileSizeLimits(t *testing.T) {
+	uploads := map[string]func(fm *FileManager, root string) error{
+		"SyncUpload": func(fm *FileManager, root string) error {
+			_, err := fm.SyncUpload(context.Background())
+			return err
+		},
+		"EncryptAndUploadDirectory": func(fm *FileManager, root string) error {
+			return fm.EncryptAndUploadDirectory(context.Background(), root)
+		},
+	}
 
-	// Create a test file
-	testFile := filepath.Join(tempDir, "test.txt")
-	err := os.WriteFile(testFile, []byte("test"), 0644)
-	if err != nil {
-		t.Fatalf("Failed to create test file: %v", err)
+	for name, upload := range uploads {
+		t.Run(name, func(t *testing.T) {
+			fm, tempDir, _ := createTestFileManager(t)
+			fm.SetFileSizeLimits(10, 100)
+
+			sizes := map[string]int{
+				"too_small.bin": 9,
+				"min.bin":       10,
+				"max.bin":       100,
+				"too_large.bin": 101,
+			}
+			for fileName, size := range sizes {
+				if err := os.WriteFile(filepath.Join(tempDir, fileName), make([]byte, size), 0644); err != nil {
+					t.Fatalf("Failed to create %s: %v", fileName, err)
+				}
+			}
+
+			if err := upload(fm, tempDir); err != nil {
+				t.Fatalf("Upload failed: %v", err)
+			}
+
+			files, err := fm.ListRemoteFiles("")
+			if err != nil {
+				t.Fatalf("Failed to list remote files: %v", err)
+			}
+			sort.Strings(files)
+			if !reflect.DeepEqual(files, []string{"max.bin", "min.bin"}) {
+				t.Errorf("Expected only in-range files to be uploaded, got %v", files)
+			}
+		})
 	}
+}
 
-	// Create a context that will be cancelled
-	ctx, cancel := context.WithCancel(context.Background())
-	cancel() // Cancel immediately
+func TestFileManager_UploadExtensionFilter(t *testing.T) {
+	testCases := []struct {
+		name     string
+		include  []string
+		exclude  []string
+		expected []string
+	}{
+		{
+			name:     "include only",
+			include:  []string{".txt", "MD", ".pdf"},
+			expected: []string{"docs/guide.md", "notes.TXT", "report.pdf"},
+		},
+		{
+			name:     "exclude only",
+			exclude:  []string{".mp4", ".TMP"},
+			expected: []string{".bashrc", "Makefile", "docs/guide.md", "notes.TXT", "report.pdf"},
+		},
+		{
+			name:     "include and exclude",
+			include:  []string{".txt", ".md", ".mp4"},
+			exclude:  []string{".mp4"},
+			expected: []string{"docs/guide.md", "notes.TXT"},
+		},
+	}
 
-	// Try to upload directory with cancelled context
-	err = fm.EncryptAndUploadDirectory(ctx, tempDir)
-	if err == nil {
-		t.Error("Expected error for cancelled context, got nil")
-	} else if err != context.Canceled {
-		// On some systems, the error might be wrapped or different
-		t.Logf("Got error (expected context cancellation): %v", err)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fm, tempDir, mockStore := createTestFileManager(t)
+			fm.SetIncludeHidden(true)
+			fm.SetExtensionFilter(tc.include, tc.exclude)
+
+			for _, relPath := range []string{"notes.TXT", "report.pdf", "video.mp4", "scratch.tmp", ".bashrc", "Makefile", "docs/guide.md"} {
+				fullPath := filepath.Join(tempDir, relPath)
+				if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+					t.Fatalf("Failed to create directory for %s: %v", relPath, err)
+				}
+				if err := os.WriteFile(fullPath, []byte(relPath), 0644); err != nil {
+					t.Fatalf("Failed to create %s: %v", relPath, err)
+				}
+			}
+
+			if err := fm.EncryptAndUploadDirectory(context.Background(), tempDir); err != nil {
+				t.Fatalf("EncryptAndUploadDirectory failed: %v", err)
+			}
+			files, err := mockStore.List("")
+			if err != nil {
+				t.Fatalf("Failed to list remote files: %v", err)
+			}
+			sort.Strings(files)
+			if !reflect.DeepEqual(files, tc.expected) {
+				t.Errorf("EncryptAndUploadDirectory uploaded %v, expected %v", files, tc.expected)
+			}
+
+			plan, err := fm.PlanSyncUpload(context.Background())
+			if err != nil {
+				t.Fatalf("PlanSyncUpload failed: %v", err)
+			}
+			if len(plan.Files) != 0 {
+				t.Errorf("SyncUpload should plan nothing after the filtered upload, got %v", plan.Files)
+			}
+		})
 	}
+}
 
-	// // Try sync operations with cancelled context
-	// err = fm.SyncDownload(ctx)
-	// if err == nil {
-	// 	t.Error("Expected error for cancelled context in SyncDownload, got nil")
-	// } else if err != context.Canceled {
-	// 	t.Logf("Got error for SyncDownload (expected context cancellation): %v", err)
-	// }
+func TestFileManager_CountUploadableFiles(t *testing.T) {
+	fm, tempDir, _ := createTestFileManager(t)
+	writeLocalFiles(t, tempDir,
+		"photos/a.jpg", "photos/b.jpg", "photos/2021/c.jpg", "photos/2021/trip/d.jpg",
+		"photos/notes.txt", "photos/.thumbs/e.jpg", "photos/.DS_Store", "single.txt")
+
+	testCases := []struct {
+		name     string
+		path     string
+		setup    func()
+		expected int
+	}{
+		{name: "single file", path: "single.txt", expected: 1},
+		{name: "nested directory", path: "photos", expected: 5},
+		{name: "sub-directory", path: filepath.Join("photos", "2021"), expected: 2},
+		{name: "include hidden", path: "photos", setup: func() { fm.SetIncludeHidden(true) }, expected: 7},
+		{name: "extension filter", path: "photos", setup: func() { fm.SetExtensionFilter([]string{".jpg"}, nil) }, expected: 4},
+	}
 
-	err = fm.SyncUpload(ctx)
-	if err == nil {
-		t.Error("Expected error for cancelled context in SyncUpload, got nil")
-	} else if err != context.Canceled {
-		t.Logf("Got error for SyncUpload (expected context cancellation): %v", err)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fm.SetIncludeHidden(false)
+			fm.SetExtensionFilter(nil, nil)
+			if tc.setup != nil {
+				tc.setup()
+			}
+			count, err := fm.CountUploadableFiles(tc.path)
+			if err != nil {
+				t.Fatalf("CountUploadableFiles failed: %v", err)
+			}
+			if count != tc.expected {
+				t.Errorf("Expected %d files, got %d", tc.expected, count)
+			}
+		})
 	}
 
-	err = fm.DownloadSpecificFile(ctx, "test.txt")
-	if err == nil {
-		t.Error("Expected error for cancelled context in DownloadSpecificFile, got nil")
-	} else if err != context.Canceled {
-		t.Logf("Got error for DownloadSpecificFile (expected context cancellation): %v", err)
+	if _, err := fm.CountUploadableFiles("missing"); err == nil {
+		t.Error("Expected an error for a missing path")
+	}
+	if _, err := fm.CountUploadableFiles(filepath.Join("..", "outside")); err == nil {
+		t.Error("Expected an error for a path outside the working directory")
 	}
 }
 
-func TestFileManager_ContextTimeout(t *testing.T) {
+func TestFileManager_UploadStoresMetadata(t *testing.T) {
+	fm, tempDir, mockStore := createTestFileManager(t)
+
+	content := []byte("hello metadata")
+	localPath := filepath.Join(tempDir, "meta.txt")
+	if err := os.WriteFile(localPath, content, 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	mtime := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	if err := os.Chtimes(localPath, mtime, mtime); err != nil {
+		t.Fatalf("Failed to set mtime: %v", err)
+	}
+
+	if err := fm.EncryptAndUploadFile(localPath, "meta.txt"); err != nil {
+		t.Fatalf("EncryptAndUploadFile failed: %v", err)
+	}
+
+	info, err := mockStore.Stat("meta.txt")
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if info.Size != int64(len(content)) {
+		t.Errorf("Stat should report the plaintext size %d, got %d", len(content), info.Size)
+	}
+	sum := sha256.Sum256(content)
+	expected := map[string]string{
+		storage.MetaOrigSize:    strconv.Itoa(len(content)),
+		storage.MetaMtime:       "2024-05-06T07:08:09Z",
+		storage.MetaSHA256:      hex.EncodeToString(sum[:]),
+		storage.MetaContentType: detectContentType("meta.txt", content),
+	}
+	if !reflect.DeepEqual(info.Metadata, expected) {
+		t.Errorf("Expected metadata %v, got %v", expected, info.Metadata)
+	}
+}
+
+func TestFileManager_DeleteRemoteFile(t *testing.T) {
+	fm, _, mockStore := createTestFileManager(t)
+	mustUpload(t, mockStore, "docs/file.txt", []byte("data"))
+
+	if err := fm.DeleteRemoteFile(`docs\file.txt`); err != nil {
+		t.Fatalf("DeleteRemoteFile failed: %v", err)
+	}
+	if _, err := mockStore.Stat("docs/file.txt"); err == nil {
+		t.Error("Remote file should be deleted")
+	}
+	if err := fm.DeleteRemoteFile(""); err == nil {
+		t.Error("Expected error for an empty remote path")
+	}
+}
+
+func TestFileManager_PlanSync(t *testing.T) {
+	fm, tempDir, mockStore := createTestFileManager(t)
+
+	cipher := crypto.NewAESGCM("test-password")
+	encrypted, err := cipher.Encrypt([]byte("remote_only.txt"))
+	if err != nil {
+		t.Fatalf("Failed to encrypt remote_only.txt: %v", err)
+	}
+	mustUpload(t, mockStore, "remote_only.txt", encrypted)
+	for _, localPath := range []string{"shared.txt", "local_only.txt"} {
+		if err := os.WriteFile(filepath.Join(tempDir, localPath), []byte(localPath), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", localPath, err)
+		}
+	}
+	if err := fm.EncryptAndUploadFile(filepath.Join(tempDir, "shared.txt"), "shared.txt"); err != nil {
+		t.Fatalf("EncryptAndUploadFile failed: %v", err)
+	}
+
+	ctx := context.Background()
+	download, err := fm.PlanSyncDownload(ctx)
+	if err != nil {
+		t.Fatalf("PlanSyncDownload failed: %v", err)
+	}
+	if len(download) != 1 || download[0] != "remote_only.txt" {
+		t.Errorf("Expected plan to download remote_only.txt, got %v", download)
+	}
+
+	plan, err := fm.PlanSyncUpload(ctx)
+	if err != nil {
+		t.Fatalf("PlanSyncUpload failed: %v", err)
+	}
+	if len(plan.Files) != 1 || plan.Files[0] != "local_only.txt" {
+		t.Errorf("Expected plan to upload local_only.txt, got %v", plan.Files)
+	}
+
+	// Planning must not transfer anything
+	if remoteFileCount(t, mockStore) != 2 {
+		t.Errorf("Expected remote storage untouched, got %d files", remoteFileCount(t, mockStore))
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "remote_only.txt")); !os.IsNotExist(err) {
+		t.Error("Planning should not download files")
+	}
+}
+
+func TestFileManager_ListRemoteFiles(t *testing.T) {
+	fm, _, mockStore := createTestFileManager(t)
+
+	// Add files to mock storage
+	mustUpload(t, mockStore, "file1.txt", []byte("data1"))
+	mustUpload(t, mockStore, "folder/file2.txt", []byte("data2"))
+	mustUpload(t, mockStore, "folder/file3.txt", []byte("data3"))
+	mustUpload(t, mockStore, "other/file4.txt", []byte("data4"))
+
+	// Test listing all files
+	files, err := fm.ListRemoteFiles("")
+	if err != nil {
+		t.Fatalf("ListRemoteFiles failed: %v", err)
+	}
+
+	if len(files) != 4 {
+		t.Errorf("Expected 4 files, got %d", len(files))
+	}
+
+	// Test listing with prefix
+	folderFiles, err := fm.ListRemoteFiles("folder/")
+	if err != nil {
+		t.Fatalf("ListRemoteFiles with prefix failed: %v", err)
+	}
+
+	if len(folderFiles) != 2 {
+		t.Errorf("Expected 2 files with folder/ prefix, got %d", len(folderFiles))
+	}
+}
+
+// presignStorage is a storage client that can presign URLs
+type presignStorage struct {
+	storage.Client
+}
+
+func (p presignStorage) Presign(key string, expiry time.Duration) (string, error) {
+	return fmt.Sprintf("https://example.com/%s?expires=%d", key, int(expiry.Seconds())), nil
+}
+
+func TestFileManager_PresignRemoteFile(t *testing.T) {
+	fm, _, _ := createTestFileManager(t)
+
+	if _, err := fm.PresignRemoteFile("file.txt", time.Hour); !errors.Is(err, ErrPresignNotSupported) {
+		t.Errorf("Expected ErrPresignNotSupported, got %v", err)
+	}
+
+	fm.storage = presignStorage{Client: fm.storage}
+	url, err := fm.PresignRemoteFile("folder/file.txt", time.Minute)
+	if err != nil {
+		t.Fatalf("PresignRemoteFile failed: %v", err)
+	}
+	if url != "https://example.com/folder/file.txt?expires=60" {
+		t.Errorf("Unexpected presigned URL: %s", url)
+	}
+}
+
+func TestFileManager_RenameRemoteFile(t *testing.T) {
+	fm, _, mockStore := createTestFileManager(t)
+	mustUpload(t, mockStore, "2022/photo.jpg", []byte("photo"))
+	mustUpload(t, mockStore, "archive/existing.jpg", []byte("existing"))
+
+	if err := fm.RenameRemoteFile(context.Background(), "2022/photo.jpg", "archive/2022/photo.jpg"); err != nil {
+		t.Fatalf("RenameRemoteFile failed: %v", err)
+	}
+	data, err := mockStore.Download("archive/2022/photo.jpg")
+	if err != nil || string(data) != "photo" {
+		t.Errorf("Renamed object should keep its bytes, got %q (%v)", data, err)
+	}
+	if _, err := mockStore.Stat("2022/photo.jpg"); err == nil {
+		t.Error("Source object should be removed after rename")
+	}
+
+	if err := fm.RenameRemoteFile(context.Background(), "archive/2022/photo.jpg", "archive/existing.jpg"); err == nil {
+		t.Error("Expected error renaming onto an existing object")
+	}
+	if err := fm.RenameRemoteFile(context.Background(), "archive/existing.jpg", "archive/existing.jpg"); err == nil {
+		t.Error("Expected error renaming an object onto itself")
+	}
+}
+
+func TestFileManager_RenameRemoteFileNotSupported(t *testing.T) {
+	fm, _, mockStore := createTestFileManager(t)
+	fm.storage = presignStorage{Client: mockStore}
+
+	err := fm.RenameRemoteFile(context.Background(), "a.txt", "b.txt")
+	if !errors.Is(err, ErrMoveNotSupported) {
+		t.Errorf("Expected ErrMoveNotSupported, got %v", err)
+	}
+}
+
+func TestFileManager_ListRemoteDir(t *testing.T) {
+	fm, _, mockStore := createTestFileManager(t)
+
+	mustUpload(t, mockStore, "top.txt", []byte("top"))
+	mustUpload(t, mockStore, "folder/file.txt", []byte("file"))
+	mustUpload(t, mockStore, "folder/sub/deep.txt", []byte("deep"))
+
+	files, folders, err := fm.ListRemoteDir("folder/")
+	if err != nil {
+		t.Fatalf("ListRemoteDir failed: %v", err)
+	}
+	if len(files) != 1 || files[0] != "folder/file.txt" {
+		t.Errorf("Expected folder/file.txt, got %v", files)
+	}
+	if len(folders) != 1 || folders[0] != "folder/sub/" {
+		t.Errorf("Expected folder/sub/, got %v", folders)
+	}
+}
+
+func TestFileManager_DownloadSpecificFile(t *testing.T) {
+	fm, tempDir, mockStore := createTestFileManager(t)
+
+	// Prepare encrypted data in mock storage
+	cipher := crypto.NewAESGCM("test-password")
+	originalContent := []byte("specific file content")
+	encrypted, err := cipher.Encrypt(originalContent)
+	if err != nil {
+		t.Fatalf("Failed to encrypt test data: %v", err)
+	}
+
+	mustUpload(t, mockStore, "specific/file.txt", encrypted)
+
+	// Download specific file
+	ctx := context.Background()
+	err = fm.DownloadSpecificFile(ctx, "specific/file.txt", false)
+	if err != nil {
+		t.Fatalf("DownloadSpecificFile failed: %v", err)
+	}
+
+	// Verify file was downloaded
+	localPath := filepath.Join(tempDir, "specific", "file.txt")
+	content, err := os.ReadFile(localPath)
+	if err != nil {
+		t.Fatalf("Failed to read downloaded file: %v", err)
+	}
+
+	if string(content) != string(originalContent) {
+		t.Errorf("Downloaded content mismatch. Expected: %s, Got: %s", originalContent, content)
+	}
+}
+
+func TestFileManager_DownloadSpecificFileOverwrite(t *testing.T) {
+	fm, tempDir, mockStore := createTestFileManager(t)
+
+	encrypted, err := crypto.NewAESGCM("test-password").Encrypt([]byte("remote content"))
+	if err != nil {
+		t.Fatalf("Failed to encrypt test data: %v", err)
+	}
+	mustUpload(t, mockStore, "file.txt", encrypted)
+
+	localPath := filepath.Join(tempDir, "file.txt")
+	if err := os.WriteFile(localPath, []byte("local content"), 0644); err != nil {
+		t.Fatalf("Failed to create local file: %v", err)
+	}
+
+	ctx := context.Background()
+	err = fm.DownloadSpecificFile(ctx, "file.txt", false)
+	if !errors.Is(err, ErrLocalFileExists) {
+		t.Fatalf("Expected ErrLocalFileExists without overwrite, got %v", err)
+	}
+	if content, _ := os.ReadFile(localPath); string(content) != "local content" {
+		t.Errorf("Local file should be untouched without overwrite, got %q", content)
+	}
+
+	if err := fm.DownloadSpecificFile(ctx, "file.txt", true); err != nil {
+		t.Fatalf("DownloadSpecificFile with overwrite failed: %v", err)
+	}
+	if content, _ := os.ReadFile(localPath); string(content) != "remote content" {
+		t.Errorf("Local file should be replaced with overwrite, got %q", content)
+	}
+}
+
+func TestFileManager_DownloadRejectsKeysOutsideWorkingDir(t *testing.T) {
+	fm, tempDir, mockStore := createTestFileManager(t)
+
+	encrypted, err := fm.cipher.Encrypt([]byte("escaped"))
+	if err != nil {
+		t.Fatalf("Failed to encrypt test data: %v", err)
+	}
+	const key = "../escape.txt"
+	mustUpload(t, mockStore, key, encrypted)
+	escaped := filepath.Join(tempDir, "..", "escape.txt")
+
+	ctx := context.Background()
+	if err := fm.DownloadSpecificFile(ctx, key, true); !errors.Is(err, ErrOutsideWorkingDir) {
+		t.Errorf("Expected ErrOutsideWorkingDir from DownloadSpecificFile, got %v", err)
+	}
+
+	result, err := fm.DownloadFiles(ctx, []string{key}, true, nil)
+	if err != nil {
+		t.Fatalf("DownloadFiles failed: %v", err)
+	}
+	if len(result.Failed) != 1 || !errors.Is(result.Failed[0].Err, ErrOutsideWorkingDir) {
+		t.Errorf("Expected the key to fail with ErrOutsideWorkingDir, got %+v", result.Failed)
+	}
+
+	result, err = fm.SyncDownload(ctx)
+	if err != nil {
+		t.Fatalf("SyncDownload failed: %v", err)
+	}
+	if len(result.Failed) != 1 || !errors.Is(result.Failed[0].Err, ErrOutsideWorkingDir) {
+		t.Errorf("Expected the key to fail with ErrOutsideWorkingDir, got %+v", result.Failed)
+	}
+
+	if _, err := os.Stat(escaped); !os.IsNotExist(err) {
+		t.Errorf("Nothing should be written outside the working directory, got %v", err)
+	}
+}
+
+func TestFileManager_DownloadFilesSkipAndOverwrite(t *testing.T) {
+	cipher := crypto.NewAESGCM("test-password")
+
+	for _, overwrite := range []bool{false, true} {
+		t.Run(fmt.Sprintf("overwrite=%v", overwrite), func(t *testing.T) {
+			fm, tempDir, mockStore := createTestFileManager(t)
+			for _, key := range []string{"existing.txt", "new.txt"} {
+				encrypted, err := cipher.Encrypt([]byte("remote " + key))
+				if err != nil {
+					t.Fatalf("Failed to encrypt test data: %v", err)
+				}
+				mustUpload(t, mockStore, key, encrypted)
+			}
+			if err := os.WriteFile(filepath.Join(tempDir, "existing.txt"), []byte("local"), 0644); err != nil {
+				t.Fatalf("Failed to create local file: %v", err)
+			}
+
+			result, err := fm.DownloadFiles(context.Background(), []string{"existing.txt", "new.txt"}, overwrite, nil)
+			if err != nil {
+				t.Fatalf("DownloadFiles failed: %v", err)
+			}
+
+			if overwrite {
+				if len(result.Succeeded) != 2 || len(result.Skipped) != 0 {
+					t.Errorf("Expected both files downloaded, got %+v", result)
+				}
+				if !reflect.DeepEqual(result.Overwritten, []string{"existing.txt"}) {
+					t.Errorf("Expected existing.txt to be overwritten, got %v", result.Overwritten)
+				}
+			} else {
+				if !reflect.DeepEqual(result.Succeeded, []string{"new.txt"}) || len(result.Overwritten) != 0 {
+					t.Errorf("Expected only new.txt downloaded, got %+v", result)
+				}
+				if !reflect.DeepEqual(result.Skipped, []string{"existing.txt"}) {
+					t.Errorf("Expected existing.txt to be skipped, got %v", result.Skipped)
+				}
+			}
+			if len(result.Failed) != 0 {
+				t.Errorf("Skipped files should not count as failures, got %v", result.Failed)
+			}
+		})
+	}
+}
+
+func TestFileManager_DownloadFiles(t *testing.T) {
+	fm, tempDir, mockStore := createTestFileManager(t)
+
+	cipher := crypto.NewAESGCM("test-password")
+	for _, key := range []string{"a.txt", "dir/b.txt", "dir/c.txt"} {
+		encrypted, err := cipher.Encrypt([]byte("content of " + key))
+		if err != nil {
+			t.Fatalf("Failed to encrypt test data: %v", err)
+		}
+		mustUpload(t, mockStore, key, encrypted)
+	}
+	// Stored with a different key, so decryption fails
+	badCipher := crypto.NewAESGCM("wrong-password")
+	encrypted, err := badCipher.Encrypt([]byte("undecryptable"))
+	if err != nil {
+		t.Fatalf("Failed to encrypt test data: %v", err)
+	}
+	mustUpload(t, mockStore, "bad.txt", encrypted)
+
+	files := []string{"a.txt", "missing.txt", "dir/b.txt", "bad.txt", "dir/c.txt"}
+
+	var progressCalls []string
+	result, err := fm.DownloadFiles(context.Background(), files, false, func(done, total int, current string) {
+		if total != len(files) {
+			t.Errorf("Expected total %d, got %d", len(files), total)
+		}
+		progressCalls = append(progressCalls, current)
+	})
+	if err != nil {
+		t.Fatalf("DownloadFiles failed: %v", err)
+	}
+
+	if len(result.Succeeded) != 3 {
+		t.Errorf("Expected 3 succeeded files, got %d: %v", len(result.Succeeded), result.Succeeded)
+	}
+	if len(result.Failed) != 2 {
+		t.Fatalf("Expected 2 failed files, got %d: %v", len(result.Failed), result.Failed)
+	}
+
+	failed := result.FailedPaths()
+	if failed[0] != "missing.txt" || failed[1] != "bad.txt" {
+		t.Errorf("Unexpected failed files: %v", failed)
+	}
+
+	// One call per file plus the final one
+	if len(progressCalls) != len(files)+1 {
+		t.Errorf("Expected %d progress calls, got %d", len(files)+1, len(progressCalls))
+	}
+	if progressCalls[len(progressCalls)-1] != "" {
+		t.Errorf("Final progress call should have no current file, got %q", progressCalls[len(progressCalls)-1])
+	}
+
+	for _, key := range result.Succeeded {
+		if _, err := os.Stat(filepath.Join(tempDir, key)); err != nil {
+			t.Errorf("Downloaded file %s not found: %v", key, err)
+		}
+	}
+}
+
+func TestFileManager_DownloadFilesCancelled(t *testing.T) {
+	fm, _, _ := createTestFileManager(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result, err := fm.DownloadFiles(ctx, []string{"a.txt"}, false, nil)
+	if err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if len(result.Succeeded) != 0 || len(result.Failed) != 0 {
+		t.Errorf("No files should be processed after cancellation, got %+v", result)
+	}
+}
+
+func TestFileManager_EncryptAndUploadPaths(t *testing.T) {
+	fm, tempDir, mockStore := createTestFileManager(t)
+
+	files := map[string]string{
+		"one.txt":          "first",
+		"two.txt":          "second",
+		"folder/three.txt": "third",
+		"folder/four.txt":  "fourth",
+		"skipped.txt":      "not selected",
+	}
+	for name, content := range files {
+		path := filepath.Join(tempDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	selected := []string{
+		filepath.Join(tempDir, "one.txt"),
+		filepath.Join(tempDir, "folder"),
+		filepath.Join(tempDir, "missing.txt"),
+		filepath.Join(tempDir, "two.txt"),
+	}
+
+	result, err := fm.EncryptAndUploadPaths(context.Background(), selected, nil)
+	if err != nil {
+		t.Fatalf("EncryptAndUploadPaths failed: %v", err)
+	}
+
+	if len(result.Succeeded) != 3 {
+		t.Errorf("Expected 3 succeeded paths, got %v", result.Succeeded)
+	}
+	if len(result.Failed) != 1 || result.Failed[0].Path != selected[2] {
+		t.Errorf("Expected only missing.txt to fail, got %v", result.Failed)
+	}
+
+	cipher := crypto.NewAESGCM("test-password")
+	for _, key := range []string{"one.txt", "two.txt", "folder/three.txt", "folder/four.txt"} {
+		encrypted, err := mockStore.Download(key)
+		if err != nil {
+			t.Errorf("Expected %s to be uploaded", key)
+			continue
+		}
+		decrypted, err := cipher.Decrypt(encrypted)
+		if err != nil {
+			t.Errorf("Failed to decrypt %s: %v", key, err)
+			continue
+		}
+		if string(decrypted) != files[key] {
+			t.Errorf("Content mismatch for %s: got %s", key, decrypted)
+		}
+	}
+
+	if _, err := mockStore.Download("skipped.txt"); err == nil {
+		t.Error("Unselected file should not be uploaded")
+	}
+}
+
+func TestFileManager_DeleteLocalFile(t *testing.T) {
+	fm, tempDir, _ := createTestFileManager(t)
+
+	// Create a test file
+	testFile := filepath.Join(tempDir, "delete_me.txt")
+	err := os.WriteFile(testFile, []byte("delete this"), 0644)
+	if err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	// Delete the file
+	err = fm.DeleteLocalFile("delete_me.txt")
+	if err != nil {
+		t.Fatalf("DeleteLocalFile failed: %v", err)
+	}
+
+	// Verify file was deleted
+	if _, err := os.Stat(testFile); !os.IsNotExist(err) {
+		t.Error("File was not deleted")
+	}
+}
+
+func TestFileManager_DeleteLocalFile_SecurityCheck(t *testing.T) {
+	fm, _, _ := createTestFileManager(t)
+
+	// Try to delete a file outside working directory
+	err := fm.DeleteLocalFile("../outside.txt")
+	if err == nil {
+		t.Error("Should not allow deleting files outside working directory")
+	}
+
+	// Try to delete with absolute path outside working directory
+	err = fm.DeleteLocalFile("/etc/passwd")
+	if err == nil {
+		t.Error("Should not allow deleting files with absolute paths outside working directory")
+	}
+}
+
+func TestFileManager_DeleteLocalDirectory(t *testing.T) {
+	fm, tempDir, _ := createTestFileManager(t)
+
+	nested := filepath.Join(tempDir, "old", "nested")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatalf("Failed to create directories: %v", err)
+	}
+	for path, content := range map[string]string{
+		filepath.Join(tempDir, "old", "a.txt"): "aaaa",
+		filepath.Join(nested, "b.txt"):         "bb",
+		filepath.Join(tempDir, "keep.txt"):     "keep",
+	} {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", path, err)
+		}
+	}
+
+	files, size, err := fm.DirectoryUsage("old")
+	if err != nil {
+		t.Fatalf("DirectoryUsage failed: %v", err)
+	}
+	if files != 2 || size != 6 {
+		t.Errorf("Expected 2 files and 6 bytes, got %d files and %d bytes", files, size)
+	}
+
+	if err := fm.DeleteLocalDirectory("old"); err != nil {
+		t.Fatalf("DeleteLocalDirectory failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "old")); !os.IsNotExist(err) {
+		t.Error("Directory was not deleted")
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "keep.txt")); err != nil {
+		t.Errorf("Sibling file should be kept: %v", err)
+	}
+}
+
+func TestFileManager_DeleteLocalDirectory_RefusesWorkingDir(t *testing.T) {
+	fm, tempDir, _ := createTestFileManager(t)
+	if err := os.WriteFile(filepath.Join(tempDir, "keep.txt"), []byte("keep"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	for _, path := range []string{"", ".", "sub/..", "./"} {
+		if err := fm.DeleteLocalDirectory(path); !errors.Is(err, ErrDeleteWorkingDir) {
+			t.Errorf("DeleteLocalDirectory(%q): expected ErrDeleteWorkingDir, got %v", path, err)
+		}
+	}
+	if err := fm.DeleteLocalDirectory(".."); err == nil {
+		t.Error("Should not allow deleting directories outside working directory")
+	}
+	if err := fm.DeleteLocalDirectory("keep.txt"); err == nil {
+		t.Error("Should not delete a file as a directory")
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "keep.txt")); err != nil {
+		t.Errorf("Working directory contents should be kept: %v", err)
+	}
+}
+
+func TestFileManager_CreateLocalDirectory(t *testing.T) {
+	fm, tempDir, _ := createTestFileManager(t)
+
+	if err := os.Mkdir(filepath.Join(tempDir, "parent"), 0755); err != nil {
+		t.Fatalf("Failed to create parent directory: %v", err)
+	}
+
+	err := fm.CreateLocalDirectory(filepath.Join("parent", "new_dir"))
+	if err != nil {
+		t.Fatalf("CreateLocalDirectory failed: %v", err)
+	}
+
+	info, err := os.Stat(filepath.Join(tempDir, "parent", "new_dir"))
+	if err != nil {
+		t.Fatalf("Directory was not created: %v", err)
+	}
+	if !info.IsDir() {
+		t.Error("Created path is not a directory")
+	}
+
+	// Creating it again should fail
+	if err := fm.CreateLocalDirectory(filepath.Join("parent", "new_dir")); err == nil {
+		t.Error("Should fail when the directory already exists")
+	}
+}
+
+func TestFileManager_CreateLocalDirectory_SecurityCheck(t *testing.T) {
+	fm, tempDir, _ := createTestFileManager(t)
+
+	invalidPaths := []string{
+		"../outside",
+		"sub/../../outside",
+		"/tmp/outside",
+		"",
+		".",
+	}
+
+	for _, path := range invalidPaths {
+		if err := fm.CreateLocalDirectory(path); err == nil {
+			t.Errorf("Should not allow creating directory %q", path)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(filepath.Dir(tempDir), "outside")); !os.IsNotExist(err) {
+		t.Error("Directory was created outside working directory")
+	}
+}
+
+func TestFileManager_RenameLocalEntry(t *testing.T) {
+	fm, tempDir, _ := createTestFileManager(t)
+
+	if err := os.WriteFile(filepath.Join(tempDir, "old.txt"), []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := os.Mkdir(filepath.Join(tempDir, "old_dir"), 0755); err != nil {
+		t.Fatalf("Failed to create test directory: %v", err)
+	}
+
+	if err := fm.RenameLocalEntry("old.txt", "new.txt"); err != nil {
+		t.Fatalf("RenameLocalEntry failed for file: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(tempDir, "new.txt"))
+	if err != nil || string(data) != "content" {
+		t.Errorf("Renamed file content mismatch: %q, %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "old.txt")); !os.IsNotExist(err) {
+		t.Error("Old file should no longer exist")
+	}
+
+	if err := fm.RenameLocalEntry("old_dir", "new_dir"); err != nil {
+		t.Fatalf("RenameLocalEntry failed for directory: %v", err)
+	}
+	if info, err := os.Stat(filepath.Join(tempDir, "new_dir")); err != nil || !info.IsDir() {
+		t.Errorf("Renamed directory not found: %v", err)
+	}
+}
+
+func TestFileManager_RenameLocalEntry_NoOverwrite(t *testing.T) {
+	fm, tempDir, _ := createTestFileManager(t)
+
+	if err := os.WriteFile(filepath.Join(tempDir, "a.txt"), []byte("a"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "b.txt"), []byte("b"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	if err := fm.RenameLocalEntry("a.txt", "b.txt"); err == nil {
+		t.Error("Should not allow renaming over an existing entry")
+	}
+
+	data, err := os.ReadFile(filepath.Join(tempDir, "b.txt"))
+	if err != nil || string(data) != "b" {
+		t.Errorf("Existing file should be untouched, got %q, %v", data, err)
+	}
+}
+
+func TestFileManager_RenameLocalEntry_SecurityCheck(t *testing.T) {
+	fm, tempDir, _ := createTestFileManager(t)
+
+	if err := os.WriteFile(filepath.Join(tempDir, "inside.txt"), []byte("inside"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	if err := fm.RenameLocalEntry("inside.txt", "../outside.txt"); err == nil {
+		t.Error("Should not allow renaming to outside working directory")
+	}
+	if err := fm.RenameLocalEntry("inside.txt", "/tmp/outside.txt"); err == nil {
+		t.Error("Should not allow renaming to an absolute path")
+	}
+	if err := fm.RenameLocalEntry("../outside.txt", "inside2.txt"); err == nil {
+		t.Error("Should not allow renaming from outside working directory")
+	}
+
+	if _, err := os.Stat(filepath.Join(tempDir, "inside.txt")); err != nil {
+		t.Errorf("Original file should still exist: %v", err)
+	}
+}
+
+func TestFileManager_ContextCancellation(t *testing.T) {
+	fm, tempDir, _ := createTestFileManager(t)
+
+	// Create a test file
+	testFile := filepath.Join(tempDir, "test.txt")
+	err := os.WriteFile(testFile, []byte("test"), 0644)
+	if err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	// Create a context that will be cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel() // Cancel immediately
+
+	// Try to upload directory with cancelled context
+	err = fm.EncryptAndUploadDirectory(ctx, tempDir)
+	if err == nil {
+		t.Error("Expected error for cancelled context, got nil")
+	} else if err != context.Canceled {
+		// On some systems, the error might be wrapped or different
+		t.Logf("Got error (expected context cancellation): %v", err)
+	}
+
+	// // Try sync operations with cancelled context
+	// err = fm.SyncDownload(ctx)
+	// if err == nil {
+	// 	t.Error("Expected error for cancelled context in SyncDownload, got nil")
+	// } else if err != context.Canceled {
+	// 	t.Logf("Got error for SyncDownload (expected context cancellation): %v", err)
+	// }
+
+	_, err = fm.SyncUpload(ctx)
+	if err == nil {
+		t.Error("Expected error for cancelled context in SyncUpload, got nil")
+	} else if err != context.Canceled {
+		t.Logf("Got error for SyncUpload (expected context cancellation): %v", err)
+	}
+
+	err = fm.DownloadSpecificFile(ctx, "test.txt", true)
+	if err == nil {
+		t.Error("Expected error for cancelled context in DownloadSpecificFile, got nil")
+	} else if err != context.Canceled {
+		t.Logf("Got error for DownloadSpecificFile (expected context cancellation): %v", err)
+	}
+}
+
+func TestFileManager_ContextTimeout(t *testing.T) {
 	fm, tempDir, _ := createTestFileManager(t)
 
 	// Create a test file
@@ -545,3 +1679,151 @@ func TestFileManager_ErrorHandling(t *testing.T) {
 		t.Error("Should fail when deleting non-existent file")
 	}
 }
+
+func TestFileManager_ErrorCategories(t *testing.T) {
+	fm, tempDir, mockStore := createTestFileManager(t)
+	fm.storage = failingUploadStorage{Client: mockStore, failing: map[string]bool{"fail.txt": true}}
+
+	writeLocalFiles(t, tempDir, "fail.txt")
+	err := fm.EncryptAndUploadFile(filepath.Join(tempDir, "missing.txt"), "missing.txt")
+	if !errors.Is(err, ErrLocalIO) || !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected ErrLocalIO reading a missing file, got %v", err)
+	}
+	err = fm.EncryptAndUploadFile(filepath.Join(tempDir, "fail.txt"), "fail.txt")
+	if !errors.Is(err, ErrStorage) || errors.Is(err, ErrLocalIO) {
+		t.Errorf("Expected ErrStorage from a failed upload, got %v", err)
+	}
+
+	err = fm.DownloadAndDecryptFile("absent.txt", filepath.Join(tempDir, "absent.txt"))
+	if !errors.Is(err, ErrStorage) {
+		t.Errorf("Expected ErrStorage downloading a missing remote file, got %v", err)
+	}
+
+	encrypted, err := crypto.NewAESGCM("other-password").Encrypt([]byte("secret"))
+	if err != nil {
+		t.Fatalf("Failed to encrypt: %v", err)
+	}
+	mustUpload(t, mockStore, "other.txt", encrypted)
+	err = fm.DownloadAndDecryptFile("other.txt", filepath.Join(tempDir, "other.txt"))
+	if !errors.Is(err, crypto.ErrDecrypt) || errors.Is(err, ErrStorage) {
+		t.Errorf("Expected crypto.ErrDecrypt with the wrong key, got %v", err)
+	}
+
+	encrypted, err = fm.cipher.Encrypt([]byte("nested"))
+	if err != nil {
+		t.Fatalf("Failed to encrypt: %v", err)
+	}
+	mustUpload(t, mockStore, "fail.txt/nested.txt", encrypted)
+	err = fm.DownloadAndDecryptFile("fail.txt/nested.txt", filepath.Join(tempDir, "fail.txt", "nested.txt"))
+	if !errors.Is(err, ErrLocalIO) {
+		t.Errorf("Expected ErrLocalIO when the parent is a file, got %v", err)
+	}
+}
+
+func TestFileManager_EnsureWorkingDir(t *testing.T) {
+	fm, tempDir, _ := createTestFileManager(t)
+
+	// Already exists
+	if err := fm.EnsureWorkingDir(); err != nil {
+		t.Fatalf("EnsureWorkingDir failed for an existing directory: %v", err)
+	}
+
+	// Missing, including parents
+	fm.workingDir = filepath.Join(tempDir, "new", "nested")
+	if err := fm.EnsureWorkingDir(); err != nil {
+		t.Fatalf("EnsureWorkingDir failed: %v", err)
+	}
+	if info, err := os.Stat(fm.workingDir); err != nil || !info.IsDir() {
+		t.Errorf("Working directory should be created, stat err: %v", err)
+	}
+
+	// A file exists at the path
+	filePath := filepath.Join(tempDir, "file.txt")
+	if err := os.WriteFile(filePath, []byte("data"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	fm.workingDir = filePath
+	if err := fm.EnsureWorkingDir(); err == nil {
+		t.Error("Expected error when the working directory path is a file")
+	}
+}
+
+func TestFileManager_WorkingDirAvailable(t *testing.T) {
+	fm, tempDir, _ := createTestFileManager(t)
+	fm.workingDir = filepath.Join(tempDir, "drive")
+	if fm.WorkingDirAvailable() {
+		t.Error("A missing working directory should be unavailable")
+	}
+
+	if err := os.Mkdir(fm.workingDir, 0755); err != nil {
+		t.Fatalf("Failed to create working directory: %v", err)
+	}
+	if !fm.WorkingDirAvailable() {
+		t.Error("An existing working directory should be available")
+	}
+
+	// 例如外接硬盘被拔出
+	if err := os.Remove(fm.workingDir); err != nil {
+		t.Fatalf("Failed to remove working directory: %v", err)
+	}
+	if fm.WorkingDirAvailable() {
+		t.Error("A removed working directory should be unavailable")
+	}
+
+	if err := os.WriteFile(fm.workingDir, []byte("data"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	if fm.WorkingDirAvailable() {
+		t.Error("A file at the working directory path should be unavailable")
+	}
+}
+
+// slowStorage blocks downloads of the keys in slow until release is closed,
+// then fails them so nothing is written after the test ends
+type slowStorage struct {
+	storage.Client
+	slow    map[string]bool
+	release chan struct{}
+}
+
+func (s slowStorage) Download(key string) ([]byte, error) {
+	if s.slow[key] {
+		<-s.release
+		return nil, fmt.Errorf("download %s: released", key)
+	}
+	return s.Client.Download(key)
+}
+
+func TestFileManager_PerFileTimeout(t *testing.T) {
+	fm, tempDir, mockStore := createTestFileManager(t)
+
+	cipher := crypto.NewAESGCM("test-password")
+	for _, key := range []string{"a.txt", "slow.txt"} {
+		encrypted, err := cipher.Encrypt([]byte(key))
+		if err != nil {
+			t.Fatalf("Failed to encrypt %s: %v", key, err)
+		}
+		mustUpload(t, mockStore, key, encrypted)
+	}
+	release := make(chan struct{})
+	defer close(release)
+	fm.storage = slowStorage{Client: mockStore, slow: map[string]bool{"slow.txt": true}, release: release}
+	fm.SetTimeouts(0, 20*time.Millisecond)
+
+	result, err := fm.SyncDownload(context.Background())
+	if err != nil {
+		t.Fatalf("SyncDownload failed: %v", err)
+	}
+	if !reflect.DeepEqual(result.Succeeded, []string{"a.txt"}) {
+		t.Errorf("Expected a.txt to be downloaded, got %v", result.Succeeded)
+	}
+	if len(result.Failed) != 1 || result.Failed[0].Path != "slow.txt" {
+		t.Fatalf("Expected slow.txt to fail, got %v", result.Failed)
+	}
+	if !errors.Is(result.Failed[0].Err, ErrTimeout) {
+		t.Errorf("Expected a timeout error, got %v", result.Failed[0].Err)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "slow.txt")); !os.IsNotExist(err) {
+		t.Error("Timed out file should not be written")
+	}
+}
@@ -0,0 +1,110 @@
+package dir
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// resolveDownloadPath returns where key is written, relative to the download
+// directory. With flatten the directories of key are dropped and a base name
+// already in existing gets " (2)", " (3)"... before its extension; the
+// returned name is added to existing. Otherwise the structure of key is kept.
+func resolveDownloadPath(key string, flatten bool, existing map[string]bool) string {
+	if !flatten {
+		return filepath.FromSlash(key)
+	}
+	name := path.Base(key)
+	ext := path.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	if stem == "" {
+		// 以点开头的文件（如 .bashrc）整体作为主名
+		stem, ext = name, ""
+	}
+	candidate := name
+	for n := 2; existing[candidate]; n++ {
+		candidate = fmt.Sprintf("%s (%d)%s", stem, n, ext)
+	}
+	existing[candidate] = true
+	return candidate
+}
+
+// DownloadFilesFlat downloads the given remote files directly into destDir,
+// relative to the working directory ("" for the root), dropping the
+// directories of their keys. Files with the same base name are renamed with
+// resolveDownloadPath; a file that exists locally is skipped unless overwrite
+// is true. Failures and cancellation are reported like DownloadFiles.
+func (fm *FileManager) DownloadFilesFlat(ctx context.Context, remotePaths []string, destDir string, overwrite bool, progress ProgressFunc) (*BatchResult, error) {
+	dest := fm.workingDir
+	if destDir != "" && destDir != "." {
+		var err error
+		if dest, err = fm.resolveLocalPath(destDir); err != nil {
+			return &BatchResult{}, err
+		}
+	}
+
+	assigned := make(map[string]bool)
+	return fm.downloadFilesTo(ctx, remotePaths, overwrite, progress, func(remotePath string) (string, error) {
+		if isEmptyDirPlaceholder(remotePath) {
+			// 展开后没有目录可以恢复
+			return "", nil
+		}
+		return filepath.Join(dest, resolveDownloadPath(remotePath, true, assigned)), nil
+	})
+}
+
+// downloadFilesTo downloads each remote file to the local path returned by
+// localPath, skipping the files for which it returns "" and failing the ones
+// for which it returns an error
+func (fm *FileManager) downloadFilesTo(ctx context.Context, remotePaths []string, overwrite bool, progress ProgressFunc, localPath func(remotePath string) (string, error)) (*BatchResult, error) {
+	result := &BatchResult{LocalPaths: make(map[string]string)}
+	total := len(remotePaths)
+
+	for i, remotePath := range remotePaths {
+		select {
+		case <-ctx.Done():
+			return result, ctx.Err()
+		default:
+		}
+
+		if progress != nil {
+			progress(i, total, remotePath)
+		}
+
+		target, err := localPath(remotePath)
+		if err != nil {
+			fm.logger.Error("Refusing to download file", slog.String("file", remotePath), slog.String("error", err.Error()))
+			result.Failed = append(result.Failed, FileError{Path: remotePath, Err: err})
+			continue
+		}
+		if target == "" {
+			result.Skipped = append(result.Skipped, remotePath)
+			continue
+		}
+		existed := localFileExists(target)
+		if existed && !overwrite {
+			fm.logger.Info("Skipping download, local file exists", slog.String("file", remotePath))
+			result.Skipped = append(result.Skipped, remotePath)
+			result.LocalPaths[remotePath] = target
+			continue
+		}
+		if err := fm.downloadFile(ctx, remotePath, target); err != nil {
+			fm.logger.Error("Failed to download file", slog.String("file", remotePath), slog.String("error", err.Error()))
+			result.Failed = append(result.Failed, FileError{Path: remotePath, Err: err})
+			continue
+		}
+		result.Succeeded = append(result.Succeeded, remotePath)
+		result.LocalPaths[remotePath] = target
+		if existed {
+			result.Overwritten = append(result.Overwritten, remotePath)
+		}
+	}
+
+	if progress != nil {
+		progress(total, total, "")
+	}
+	return result, nil
+}
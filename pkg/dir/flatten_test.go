@@ -0,0 +1,86 @@
+package dir
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveDownloadPath(t *testing.T) {
+	existing := make(map[string]bool)
+	tests := []struct {
+		key     string
+		flatten bool
+		want    string
+	}{
+		{"docs/2023/report.pdf", false, filepath.Join("docs", "2023", "report.pdf")},
+		{"docs/2023/report.pdf", true, "report.pdf"},
+		{"docs/2024/report.pdf", true, "report (2).pdf"},
+		{"report.pdf", true, "report (3).pdf"},
+		{"a/archive.tar.gz", true, "archive.tar.gz"},
+		{"b/archive.tar.gz", true, "archive.tar (2).gz"},
+		{"a/.bashrc", true, ".bashrc"},
+		{"b/.bashrc", true, ".bashrc (2)"},
+		{"a/README", true, "README"},
+		{"b/README", true, "README (2)"},
+	}
+	for _, tt := range tests {
+		if got := resolveDownloadPath(tt.key, tt.flatten, existing); got != tt.want {
+			t.Errorf("resolveDownloadPath(%q, %v) = %q, want %q", tt.key, tt.flatten, got, tt.want)
+		}
+	}
+	// 保持结构时不做去重
+	if got := resolveDownloadPath("report.pdf", false, existing); got != "report.pdf" {
+		t.Errorf("Structured path should be the key, got %q", got)
+	}
+}
+
+func TestFileManager_DownloadFilesFlat(t *testing.T) {
+	fm, tempDir, _ := createTestFileManager(t)
+	files := map[string]string{
+		filepath.Join("docs", "2023", "report.pdf"): "2023",
+		filepath.Join("docs", "2024", "report.pdf"): "2024",
+		filepath.Join("docs", "notes.txt"):          "notes",
+	}
+	writeFileContents(t, tempDir, files)
+	for name := range files {
+		if err := fm.EncryptAndUploadFile(filepath.Join(tempDir, name), name); err != nil {
+			t.Fatalf("Failed to upload %s: %v", name, err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(tempDir, "inbox"), 0755); err != nil {
+		t.Fatalf("Failed to create inbox: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "inbox", "notes.txt"), []byte("local"), 0644); err != nil {
+		t.Fatalf("Failed to create local file: %v", err)
+	}
+
+	keys := []string{"docs/2023/report.pdf", "docs/2024/report.pdf", "docs/notes.txt"}
+	result, err := fm.DownloadFilesFlat(context.Background(), keys, "inbox", false, nil)
+	if err != nil {
+		t.Fatalf("DownloadFilesFlat failed: %v", err)
+	}
+	if len(result.Succeeded) != 2 || len(result.Skipped) != 1 || result.Skipped[0] != "docs/notes.txt" {
+		t.Errorf("Expected 2 downloads and notes.txt skipped, got %+v", result)
+	}
+	for name, want := range map[string]string{"report.pdf": "2023", "report (2).pdf": "2024", "notes.txt": "local"} {
+		data, err := os.ReadFile(filepath.Join(tempDir, "inbox", name))
+		if err != nil || string(data) != want {
+			t.Errorf("Expected inbox/%s to contain %q, got %q, %v", name, want, data, err)
+		}
+	}
+	if got := result.LocalPaths["docs/2024/report.pdf"]; got != filepath.Join(tempDir, "inbox", "report (2).pdf") {
+		t.Errorf("Expected the renamed local path of docs/2024/report.pdf, got %q", got)
+	}
+	if got := result.LocalPaths["docs/notes.txt"]; got != filepath.Join(tempDir, "inbox", "notes.txt") {
+		t.Errorf("Expected the existing local file of a skipped download, got %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "inbox", "docs")); !os.IsNotExist(err) {
+		t.Errorf("Flattened download should not create directories, got %v", err)
+	}
+
+	if _, err := fm.DownloadFilesFlat(context.Background(), keys, "../outside", false, nil); err == nil {
+		t.Error("Expected a destination outside the working directory to be rejected")
+	}
+}
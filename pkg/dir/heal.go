@@ -0,0 +1,119 @@
+package dir
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// ErrHashMismatch is returned when a remote copy does not have the hash recorded in the manifest
+var ErrHashMismatch = errors.New("content does not match the manifest hash")
+
+// PlanHeal returns the local files whose content no longer matches the
+// SHA-256 recorded for them in the manifest, i.e. the files Heal would restore
+func (fm *FileManager) PlanHeal(ctx context.Context) ([]string, error) {
+	damaged, _, err := fm.planHeal(ctx)
+	return damaged, err
+}
+
+// planHeal returns the damaged local files and the manifest entries they were checked against
+func (fm *FileManager) planHeal(ctx context.Context) ([]string, map[string]ManifestEntry, error) {
+	m, err := fm.LoadManifest()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load manifest: %w", err)
+	}
+
+	var damaged []string
+	for _, key := range m.Keys() {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+		entry := m.Files[key]
+		if entry.SHA256 == "" || isEmptyDirPlaceholder(key) {
+			continue
+		}
+		localPath, err := fm.resolveLocalPath(filepath.FromSlash(key))
+		if err != nil {
+			// 交给 healFile 报告失败，不读取工作目录之外的文件
+			fm.logger.Warn("Manifest entry is outside the working directory", slog.String("path", key))
+			damaged = append(damaged, key)
+			continue
+		}
+		info, err := os.Stat(localPath)
+		if err != nil || !info.Mode().IsRegular() {
+			// 本地不存在的文件由 SyncDownload 负责
+			continue
+		}
+		sum, err := fileSHA256(localPath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to hash %s: %w: %w", key, ErrLocalIO, err)
+		}
+		if sum != entry.SHA256 {
+			fm.logger.Warn("Local file does not match the manifest", slog.String("path", key))
+			damaged = append(damaged, key)
+		}
+	}
+	return damaged, m.Files, nil
+}
+
+// Heal restores every local file whose content no longer matches the SHA-256
+// in the manifest from its remote copy. The remote copy is only written, atomically,
+// when it has the recorded hash; files not in the manifest are left untouched.
+func (fm *FileManager) Heal(ctx context.Context) (*BatchResult, error) {
+	damaged, entries, err := fm.planHeal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BatchResult{}
+	for _, key := range damaged {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		err := fm.withFileTimeout(ctx, func(context.Context) error {
+			return fm.healFile(key, entries[key].SHA256)
+		})
+		if err != nil {
+			fm.logger.Error("Failed to heal file", slog.String("path", key), slog.String("error", err.Error()))
+			result.Failed = append(result.Failed, FileError{Path: key, Err: err})
+			continue
+		}
+		result.Succeeded = append(result.Succeeded, key)
+	}
+
+	fm.logger.Info("Heal completed",
+		slog.Int("healed", len(result.Succeeded)),
+		slog.Int("failed", len(result.Failed)))
+	return result, nil
+}
+
+// healFile replaces the local copy of key with the remote one if it has the hash want
+func (fm *FileManager) healFile(key, want string) error {
+	localPath, err := fm.resolveLocalPath(filepath.FromSlash(key))
+	if err != nil {
+		return err
+	}
+	encrypted, err := fm.storage.Download(key)
+	if err != nil {
+		return fmt.Errorf("failed to download file %s: %w: %w", key, ErrStorage, err)
+	}
+	plain, err := fm.currentCipher().Decrypt(encrypted)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt file %s: %w", key, err)
+	}
+	if sum := sha256.Sum256(plain); hex.EncodeToString(sum[:]) != want {
+		// 远程副本也已损坏时保留本地文件
+		return fmt.Errorf("remote copy of %s: %w", key, ErrHashMismatch)
+	}
+
+	if err := writeFileAtomic(localPath, plain, defaultFileMode); err != nil {
+		return fmt.Errorf("failed to write file %s: %w: %w", localPath, ErrLocalIO, err)
+	}
+	fm.logger.Info("File healed from remote copy", slog.String("path", key))
+	return nil
+}
@@ -0,0 +1,128 @@
+package dir
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFileManager_HealRestoresCorruptedFile(t *testing.T) {
+	fm, tempDir, _ := newManifestTestFileManager(t)
+	writeLocalFiles(t, tempDir, "a.txt", "docs/b.txt")
+	if _, err := fm.SyncUpload(context.Background()); err != nil {
+		t.Fatalf("SyncUpload failed: %v", err)
+	}
+
+	// 损坏一个已上传的文件，并新增一个不在清单中的文件
+	corrupted := filepath.Join(tempDir, "docs", "b.txt")
+	if err := os.WriteFile(corrupted, []byte("bit rot"), 0644); err != nil {
+		t.Fatalf("Failed to corrupt b.txt: %v", err)
+	}
+	localOnly := filepath.Join(tempDir, "c.txt")
+	if err := os.WriteFile(localOnly, []byte("local only"), 0644); err != nil {
+		t.Fatalf("Failed to create c.txt: %v", err)
+	}
+
+	planned, err := fm.PlanHeal(context.Background())
+	if err != nil {
+		t.Fatalf("PlanHeal failed: %v", err)
+	}
+	if !reflect.DeepEqual(planned, []string{"docs/b.txt"}) {
+		t.Errorf("Expected only docs/b.txt to be planned, got %v", planned)
+	}
+
+	result, err := fm.Heal(context.Background())
+	if err != nil {
+		t.Fatalf("Heal failed: %v", err)
+	}
+	if !reflect.DeepEqual(result.Succeeded, []string{"docs/b.txt"}) || len(result.Failed) != 0 {
+		t.Errorf("Expected docs/b.txt to be healed, got %+v", result)
+	}
+	if data, _ := os.ReadFile(corrupted); string(data) != "docs/b.txt" {
+		t.Errorf("Expected the remote content to be restored, got %q", data)
+	}
+	if data, _ := os.ReadFile(localOnly); string(data) != "local only" {
+		t.Errorf("Files not in the manifest should be untouched, got %q", data)
+	}
+
+	if planned, err := fm.PlanHeal(context.Background()); err != nil || len(planned) != 0 {
+		t.Errorf("Expected nothing left to heal, got %v, %v", planned, err)
+	}
+}
+
+func TestFileManager_HealKeepsFileWhenRemoteCopyIsBad(t *testing.T) {
+	fm, tempDir, store := newManifestTestFileManager(t)
+	writeLocalFiles(t, tempDir, "a.txt")
+	if _, err := fm.SyncUpload(context.Background()); err != nil {
+		t.Fatalf("SyncUpload failed: %v", err)
+	}
+
+	// 远程对象被其他内容替换，与清单中的哈希不符
+	encrypted, err := fm.cipher.Encrypt([]byte("replaced"))
+	if err != nil {
+		t.Fatalf("Failed to encrypt: %v", err)
+	}
+	mustUpload(t, store.Client, "a.txt", encrypted)
+	path := filepath.Join(tempDir, "a.txt")
+	if err := os.WriteFile(path, []byte("damaged"), 0644); err != nil {
+		t.Fatalf("Failed to corrupt a.txt: %v", err)
+	}
+
+	result, err := fm.Heal(context.Background())
+	if err != nil {
+		t.Fatalf("Heal failed: %v", err)
+	}
+	if len(result.Failed) != 1 || !errors.Is(result.Failed[0].Err, ErrHashMismatch) {
+		t.Errorf("Expected ErrHashMismatch for a.txt, got %+v", result.Failed)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "damaged" {
+		t.Errorf("Local file should be kept when the remote copy is bad, got %q", data)
+	}
+}
+
+func TestFileManager_HealRejectsKeysOutsideWorkingDir(t *testing.T) {
+	fm, tempDir, store := newManifestTestFileManager(t)
+	writeLocalFiles(t, tempDir, "a.txt")
+	if _, err := fm.SyncUpload(context.Background()); err != nil {
+		t.Fatalf("SyncUpload failed: %v", err)
+	}
+
+	// 清单中的键指向工作目录之外的文件
+	const key = "../escape.txt"
+	outside := filepath.Join(tempDir, "..", "escape.txt")
+	if err := os.WriteFile(outside, []byte("outside"), 0644); err != nil {
+		t.Fatalf("Failed to create the outside file: %v", err)
+	}
+	encrypted, err := fm.cipher.Encrypt([]byte("remote"))
+	if err != nil {
+		t.Fatalf("Failed to encrypt: %v", err)
+	}
+	mustUpload(t, store.Client, key, encrypted)
+	sum := sha256.Sum256([]byte("remote"))
+	fm.updateManifest(manifestPut(key, ManifestEntry{SHA256: hex.EncodeToString(sum[:])}))
+
+	result, err := fm.Heal(context.Background())
+	if err != nil {
+		t.Fatalf("Heal failed: %v", err)
+	}
+	if len(result.Failed) != 1 || !errors.Is(result.Failed[0].Err, ErrOutsideWorkingDir) {
+		t.Errorf("Expected ErrOutsideWorkingDir for %s, got %+v", key, result.Failed)
+	}
+	if data, _ := os.ReadFile(outside); string(data) != "outside" {
+		t.Errorf("Files outside the working directory should be untouched, got %q", data)
+	}
+}
+
+func TestFileManager_HealWithoutManifest(t *testing.T) {
+	fm, tempDir, _ := createTestFileManager(t)
+	writeLocalFiles(t, tempDir, "a.txt")
+
+	if _, err := fm.Heal(context.Background()); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected os.ErrNotExist without a manifest, got %v", err)
+	}
+}
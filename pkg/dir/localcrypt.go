@@ -0,0 +1,83 @@
+package dir
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// EncryptLocalFile encrypts the file at src into dst without using remote
+// storage; dst has the same format as an uploaded object
+func (fm *FileManager) EncryptLocalFile(src, dst string) error {
+	return fm.transformLocalFile(src, dst, "encrypt", fm.currentCipher().Encrypt)
+}
+
+// DecryptLocalFile decrypts the file at src, written by EncryptLocalFile or
+// downloaded as an encrypted object, into dst
+func (fm *FileManager) DecryptLocalFile(src, dst string) error {
+	return fm.transformLocalFile(src, dst, "decrypt", fm.currentCipher().Decrypt)
+}
+
+// transformLocalFile writes transform(src) to dst atomically, so a failure
+// never leaves a partial dst or replaces an existing one
+func (fm *FileManager) transformLocalFile(src, dst, action string, transform func([]byte) ([]byte, error)) error {
+	if err := fm.checkLocalDestination(src, dst); err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return fmt.Errorf("failed to read file %s: %w", src, err)
+	}
+	out, err := transform(data)
+	if err != nil {
+		return fmt.Errorf("failed to %s file %s: %w", action, src, err)
+	}
+	if err := writeFileAtomic(dst, out, defaultFileMode); err != nil {
+		return fmt.Errorf("failed to write file %s: %w", dst, err)
+	}
+
+	fm.logger.Info("Local file "+action+"ed", slog.String("from", src), slog.String("to", dst))
+	return nil
+}
+
+// checkLocalDestination rejects writing over src, and a dst under the working
+// directory whose real location is outside it, e.g. through a symlinked folder
+func (fm *FileManager) checkLocalDestination(src, dst string) error {
+	absSrc, err := filepath.Abs(src)
+	if err != nil {
+		return fmt.Errorf("invalid path %q: %w", src, err)
+	}
+	absDst, err := filepath.Abs(dst)
+	if err != nil {
+		return fmt.Errorf("invalid path %q: %w", dst, err)
+	}
+	if absSrc == absDst {
+		return fmt.Errorf("destination %s is the source file", dst)
+	}
+
+	workingDir, err := filepath.Abs(fm.workingDir)
+	if err != nil || !isWithin(workingDir, absDst) {
+		return nil
+	}
+	realWorkingDir, err := filepath.EvalSymlinks(workingDir)
+	if err != nil {
+		return fmt.Errorf("failed to resolve working directory: %w", err)
+	}
+	realParent, err := filepath.EvalSymlinks(filepath.Dir(absDst))
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", filepath.Dir(dst), err)
+	}
+	if !isWithin(realWorkingDir, filepath.Join(realParent, filepath.Base(absDst))) {
+		return fmt.Errorf("%s is outside working directory", dst)
+	}
+	return nil
+}
+
+// isWithin reports whether path is root or lies under it; both must be clean absolute paths
+func isWithin(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
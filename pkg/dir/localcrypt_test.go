@@ -0,0 +1,99 @@
+package dir
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mingregister/fers/pkg/crypto"
+)
+
+func TestFileManager_LocalEncryptDecryptRoundTrip(t *testing.T) {
+	fm, tempDir, mockStore := createTestFileManager(t)
+	outside := t.TempDir()
+
+	original := bytes.Repeat([]byte("local only \x00\xff"), 1000)
+	src := filepath.Join(outside, "report.bin")
+	if err := os.WriteFile(src, original, 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	encrypted := filepath.Join(outside, "report.bin.enc")
+	if err := fm.EncryptLocalFile(src, encrypted); err != nil {
+		t.Fatalf("EncryptLocalFile failed: %v", err)
+	}
+	data, err := os.ReadFile(encrypted)
+	if err != nil {
+		t.Fatalf("Encrypted file not written: %v", err)
+	}
+	if bytes.Contains(data, []byte("local only")) {
+		t.Error("The encrypted file should not contain the plaintext")
+	}
+
+	// 解密到工作目录内
+	decrypted := filepath.Join(tempDir, "restored.bin")
+	if err := fm.DecryptLocalFile(encrypted, decrypted); err != nil {
+		t.Fatalf("DecryptLocalFile failed: %v", err)
+	}
+	got, err := os.ReadFile(decrypted)
+	if err != nil {
+		t.Fatalf("Decrypted file not written: %v", err)
+	}
+	if !bytes.Equal(got, original) {
+		t.Error("Decrypted content does not match the original")
+	}
+
+	if count := remoteFileCount(t, mockStore); count != 0 {
+		t.Errorf("Local encryption should not touch remote storage, got %d objects", count)
+	}
+}
+
+func TestFileManager_DecryptLocalFileWrongKey(t *testing.T) {
+	fm, tempDir, _ := createTestFileManager(t)
+	src := filepath.Join(tempDir, "a.txt")
+	if err := os.WriteFile(src, []byte("secret"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	encrypted := filepath.Join(tempDir, "a.txt.enc")
+	if err := fm.EncryptLocalFile(src, encrypted); err != nil {
+		t.Fatalf("EncryptLocalFile failed: %v", err)
+	}
+
+	// 解密失败时不能替换已有的文件
+	dst := filepath.Join(tempDir, "existing.txt")
+	if err := os.WriteFile(dst, []byte("keep me"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	fm.cipher = crypto.NewAESGCM("another-password")
+	if err := fm.DecryptLocalFile(encrypted, dst); err == nil {
+		t.Fatal("Decrypting with another key should fail")
+	}
+	if data, _ := os.ReadFile(dst); string(data) != "keep me" {
+		t.Errorf("A failed decrypt replaced the destination: %q", data)
+	}
+}
+
+func TestFileManager_LocalCryptDestinationChecks(t *testing.T) {
+	fm, tempDir, _ := createTestFileManager(t)
+	src := filepath.Join(tempDir, "a.txt")
+	if err := os.WriteFile(src, []byte("data"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	if err := fm.EncryptLocalFile(src, src); err == nil {
+		t.Error("Encrypting a file onto itself should be rejected")
+	}
+
+	// 工作目录内指向外部的符号链接目录
+	outside := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(tempDir, "link")); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+	if err := fm.EncryptLocalFile(src, filepath.Join(tempDir, "link", "a.txt.enc")); err == nil {
+		t.Error("Writing through a symlink out of the working directory should be rejected")
+	}
+	if _, err := os.Stat(filepath.Join(outside, "a.txt.enc")); !os.IsNotExist(err) {
+		t.Error("Nothing should be written outside the working directory")
+	}
+}
@@ -0,0 +1,303 @@
+package dir
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mingregister/fers/pkg/storage"
+)
+
+// ManifestKey is the remote object holding the encrypted manifest
+const ManifestKey = ".fers-manifest.json.enc"
+
+const (
+	manifestVersion = 1
+	// manifestMaxAge is how long a manifest is trusted after the last full
+	// listing; other clients may have changed the remote since
+	manifestMaxAge = 24 * time.Hour
+)
+
+// Manifest describes every remote object so sync does not have to list the whole remote
+type Manifest struct {
+	Version int                      `json:"version"`
+	Listed  time.Time                `json:"listed"`  // 上次根据完整列表重建的时间
+	Updated time.Time                `json:"updated"` // 上次修改的时间
+	Files   map[string]ManifestEntry `json:"files"`
+}
+
+// ManifestEntry describes one remote object; fields are empty when unknown
+type ManifestEntry struct {
+	Size    int64     `json:"size"` // 明文大小，后端不保存元数据时为远程对象大小
+	SHA256  string    `json:"sha256,omitempty"`
+	ModTime time.Time `json:"mtime,omitzero"`
+	Link    string    `json:"link,omitempty"` // 符号链接的目标，以 SymlinkStore 上传时记录
+}
+
+// newManifest returns an empty manifest
+func newManifest() *Manifest {
+	return &Manifest{Version: manifestVersion, Files: map[string]ManifestEntry{}}
+}
+
+// Keys returns the remote keys in the manifest, sorted
+func (m *Manifest) Keys() []string {
+	keys := make([]string, 0, len(m.Files))
+	for key := range m.Files {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// stale reports whether the manifest should be rebuilt from a full listing
+func (m *Manifest) stale(now time.Time) bool {
+	return m.Version != manifestVersion || now.Sub(m.Listed) > manifestMaxAge
+}
+
+// manifestUsable reports whether m can replace a full listing of the remote.
+// With obfuscated keys a listing only returns the manifest again, so it never goes stale.
+func (fm *FileManager) manifestUsable(m *Manifest) bool {
+	return fm.obfuscateKeys || !m.stale(time.Now())
+}
+
+// manifestState serialises manifest updates and batches them during bulk operations
+type manifestState struct {
+	writeMu sync.Mutex // 串行化对远程清单的读-改-写
+
+	mu      sync.Mutex
+	depth   int // 进行中的批量操作数
+	pending []func(*Manifest)
+}
+
+// LoadManifest downloads and decrypts the manifest; the error wraps
+// os.ErrNotExist when the remote has no manifest yet
+func (fm *FileManager) LoadManifest() (*Manifest, error) {
+	encrypted, err := fm.storage.Download(ManifestKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download manifest: %w", err)
+	}
+	data, err := fm.currentCipher().Decrypt(encrypted)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt manifest: %w", err)
+	}
+
+	m := newManifest()
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	if m.Files == nil {
+		m.Files = map[string]ManifestEntry{}
+	}
+	return m, nil
+}
+
+// SaveManifest encrypts and uploads m, replacing the remote manifest
+func (fm *FileManager) SaveManifest(m *Manifest) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	encrypted, err := fm.currentCipher().Encrypt(data)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt manifest: %w", err)
+	}
+	if err := fm.storage.Upload(ManifestKey, encrypted); err != nil {
+		return fmt.Errorf("failed to upload manifest: %w", err)
+	}
+	return nil
+}
+
+// RebuildManifest lists the whole remote, stats every object and saves the result as the new manifest
+func (fm *FileManager) RebuildManifest(ctx context.Context) (*Manifest, error) {
+	keys, err := fm.storage.List("")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list remote files: %w", err)
+	}
+	return fm.buildManifest(ctx, withoutInternalKeys(keys))
+}
+
+// buildManifest stats keys, which must be every remote key, and saves them as the new manifest
+func (fm *FileManager) buildManifest(ctx context.Context, keys []string) (*Manifest, error) {
+	fm.manifest.writeMu.Lock()
+	defer fm.manifest.writeMu.Unlock()
+
+	m := newManifest()
+	m.Listed = time.Now()
+	m.Updated = m.Listed
+	for _, key := range keys {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		info, err := fm.storage.Stat(key)
+		if err != nil {
+			// 大小和哈希未知时仍记录键，同步只需要键
+			fm.logger.Warn("Failed to stat remote file for manifest", slog.String("key", key), slog.String("error", err.Error()))
+			m.Files[key] = ManifestEntry{}
+			continue
+		}
+		entry := manifestEntryFromInfo(info)
+		if info.Metadata[storage.MetaSymlink] != "" {
+			// 链接目标只保存在加密内容中
+			if entry.Link, err = fm.remoteLinkTarget(key); err != nil {
+				fm.logger.Warn("Failed to read remote link for manifest", slog.String("key", key), slog.String("error", err.Error()))
+			}
+		}
+		m.Files[key] = entry
+	}
+
+	if err := fm.SaveManifest(m); err != nil {
+		return nil, err
+	}
+	fm.logger.Info("Manifest rebuilt", slog.Int("files", len(m.Files)))
+	return m, nil
+}
+
+// saveListedManifest builds the manifest after a sync that had to list the
+// remote; failures only mean the next sync lists again
+func (fm *FileManager) saveListedManifest(ctx context.Context, keys []string) {
+	if _, err := fm.buildManifest(ctx, keys); err != nil {
+		fm.logger.Warn("Failed to save remote manifest", slog.String("error", err.Error()))
+	}
+}
+
+// manifestEntryFromInfo builds a manifest entry from the object metadata stored on upload
+func manifestEntryFromInfo(info storage.ObjectInfo) ManifestEntry {
+	entry := ManifestEntry{Size: info.Size, SHA256: info.Metadata[storage.MetaSHA256], ModTime: info.LastModified}
+	if mtime, err := time.Parse(time.RFC3339, info.Metadata[storage.MetaMtime]); err == nil {
+		entry.ModTime = mtime
+	}
+	return entry
+}
+
+// remoteKeys returns every remote key, from the manifest when it is fresh and
+// from a full listing otherwise; listed reports that the keys come from a
+// listing that may replace the manifest
+func (fm *FileManager) remoteKeys() (keys []string, listed bool, err error) {
+	keys, _, listed, err = fm.remoteState()
+	return keys, listed, err
+}
+
+// remoteState is remoteKeys that also returns the manifest the keys come
+// from, or nil when they come from a listing
+func (fm *FileManager) remoteState() (keys []string, fresh *Manifest, listed bool, err error) {
+	m, loadErr := fm.LoadManifest()
+	switch {
+	case loadErr == nil && fm.manifestUsable(m):
+		fm.logger.Debug("Using remote manifest", slog.Int("files", len(m.Files)))
+		return m.Keys(), m, false, nil
+	case loadErr == nil:
+		fm.logger.Info("Remote manifest is stale, listing remote")
+	case errors.Is(loadErr, os.ErrNotExist):
+		fm.logger.Debug("No remote manifest yet, listing remote")
+	default:
+		fm.logger.Warn("Failed to load remote manifest, listing remote", slog.String("error", loadErr.Error()))
+	}
+
+	keys, err = fm.storage.List("")
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("failed to list remote files: %w: %w", ErrStorage, err)
+	}
+	// 清单存在但无法读取时（例如密钥不对）不报告为已列出，避免覆盖它
+	listed = m != nil || errors.Is(loadErr, os.ErrNotExist)
+	return withoutInternalKeys(keys), nil, listed, nil
+}
+
+// beginManifestBatch defers manifest updates until the matching endManifestBatch,
+// so a bulk operation rewrites the manifest once instead of once per file
+func (fm *FileManager) beginManifestBatch() {
+	fm.manifest.mu.Lock()
+	fm.manifest.depth++
+	fm.manifest.mu.Unlock()
+}
+
+// endManifestBatch writes the changes collected since the outermost beginManifestBatch
+func (fm *FileManager) endManifestBatch() {
+	fm.manifest.mu.Lock()
+	fm.manifest.depth--
+	var changes []func(*Manifest)
+	if fm.manifest.depth == 0 {
+		changes, fm.manifest.pending = fm.manifest.pending, nil
+	}
+	fm.manifest.mu.Unlock()
+
+	if len(changes) > 0 {
+		fm.updateManifest(changes...)
+	}
+}
+
+// changeManifest applies change to the remote manifest now, or at the end of the current batch
+func (fm *FileManager) changeManifest(change func(*Manifest)) {
+	fm.manifest.mu.Lock()
+	if fm.manifest.depth > 0 {
+		fm.manifest.pending = append(fm.manifest.pending, change)
+		fm.manifest.mu.Unlock()
+		return
+	}
+	fm.manifest.mu.Unlock()
+
+	fm.updateManifest(change)
+}
+
+// updateManifest re-reads the remote manifest, applies changes and writes it
+// back. Without a manifest nothing is written and the next sync builds one,
+// unless keys are obfuscated: then the manifest is the only record of the
+// paths and is created here.
+func (fm *FileManager) updateManifest(changes ...func(*Manifest)) {
+	fm.manifest.writeMu.Lock()
+	defer fm.manifest.writeMu.Unlock()
+
+	// 写之前重新读取，保留其他客户端刚写入的修改
+	m, err := fm.LoadManifest()
+	if errors.Is(err, os.ErrNotExist) {
+		if !fm.obfuscateKeys {
+			return
+		}
+		m, err = newManifest(), nil
+		m.Listed = time.Now()
+	}
+	if err != nil {
+		fm.logger.Warn("Failed to load manifest for update", slog.String("error", err.Error()))
+		return
+	}
+
+	for _, change := range changes {
+		change(m)
+	}
+	m.Updated = time.Now()
+	if err := fm.SaveManifest(m); err != nil {
+		if fm.obfuscateKeys {
+			// 清单是路径的唯一记录，不能删除；这次修改的对象无法再列出
+			fm.logger.Error("Failed to update manifest", slog.String("error", err.Error()))
+			return
+		}
+		// 旧清单缺少这次的修改，删除它让下次同步重新列出远程
+		fm.logger.Warn("Failed to update manifest, removing it", slog.String("error", err.Error()))
+		if err := fm.storage.Delete(ManifestKey); err != nil {
+			fm.logger.Error("Failed to remove outdated manifest", slog.String("error", err.Error()))
+		}
+	}
+}
+
+// manifestPut returns a change that records key with entry
+func manifestPut(key string, entry ManifestEntry) func(*Manifest) {
+	return func(m *Manifest) { m.Files[key] = entry }
+}
+
+// manifestRemove returns a change that forgets key
+func manifestRemove(key string) func(*Manifest) {
+	return func(m *Manifest) { delete(m.Files, key) }
+}
+
+// manifestMove returns a change that records src as moved to dst
+func manifestMove(src, dst string) func(*Manifest) {
+	return func(m *Manifest) {
+		m.Files[dst] = m.Files[src]
+		delete(m.Files, src)
+	}
+}
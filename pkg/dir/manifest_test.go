@@ -0,0 +1,395 @@
+package dir
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mingregister/fers/pkg/crypto"
+	"github.com/mingregister/fers/pkg/storage"
+)
+
+// countingStorage counts full listings of the remote and object stats
+type countingStorage struct {
+	storage.Client
+	lists atomic.Int32
+	stats atomic.Int32
+}
+
+func (c *countingStorage) Stat(key string) (storage.ObjectInfo, error) {
+	c.stats.Add(1)
+	return c.Client.Stat(key)
+}
+
+func (c *countingStorage) List(prefix string) ([]string, error) {
+	c.lists.Add(1)
+	return c.Client.List(prefix)
+}
+
+func (c *countingStorage) UploadWithMeta(key string, data []byte, meta map[string]string) error {
+	return c.Client.(storage.MetadataUploader).UploadWithMeta(key, data, meta)
+}
+
+// newManifestTestFileManager returns a file manager whose remote listings are counted
+func newManifestTestFileManager(t *testing.T) (*FileManager, string, *countingStorage) {
+	t.Helper()
+	fm, tempDir, mockStore := createTestFileManager(t)
+	counting := &countingStorage{Client: mockStore}
+	fm.storage = counting
+	return fm, tempDir, counting
+}
+
+// mustLoadManifest loads the remote manifest or fails the test
+func mustLoadManifest(t *testing.T, fm *FileManager) *Manifest {
+	t.Helper()
+	m, err := fm.LoadManifest()
+	if err != nil {
+		t.Fatalf("LoadManifest failed: %v", err)
+	}
+	return m
+}
+
+// writeLocalFiles creates the named files under root with their name as content
+func writeLocalFiles(t *testing.T, root string, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+}
+
+func TestManifest_SaveLoadRoundTrip(t *testing.T) {
+	fm, _, mockStore := createTestFileManager(t)
+
+	if _, err := fm.LoadManifest(); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected os.ErrNotExist without a manifest, got %v", err)
+	}
+
+	m := newManifest()
+	m.Listed = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	m.Updated = m.Listed
+	m.Files["a.txt"] = ManifestEntry{Size: 5, SHA256: "abc", ModTime: m.Listed}
+	m.Files["docs/b.txt"] = ManifestEntry{Size: 7}
+	if err := fm.SaveManifest(m); err != nil {
+		t.Fatalf("SaveManifest failed: %v", err)
+	}
+
+	raw, err := mockStore.Download(ManifestKey)
+	if err != nil {
+		t.Fatalf("Manifest not stored: %v", err)
+	}
+	if bytes.Contains(raw, []byte("docs/b.txt")) {
+		t.Error("The stored manifest should be encrypted")
+	}
+
+	got := mustLoadManifest(t, fm)
+	if !reflect.DeepEqual(got, m) {
+		t.Errorf("Round trip mismatch:\n got %+v\nwant %+v", got, m)
+	}
+	if keys := got.Keys(); !reflect.DeepEqual(keys, []string{"a.txt", "docs/b.txt"}) {
+		t.Errorf("Unexpected keys %v", keys)
+	}
+
+	fm.cipher = crypto.NewAESGCM("another-password")
+	if _, err := fm.LoadManifest(); err == nil {
+		t.Error("Loading the manifest with another key should fail")
+	}
+}
+
+func TestManifest_SyncBuildsManifest(t *testing.T) {
+	fm, tempDir, store := newManifestTestFileManager(t)
+	writeLocalFiles(t, tempDir, "a.txt", "docs/b.txt")
+
+	if _, err := fm.SyncUpload(t.Context()); err != nil {
+		t.Fatalf("SyncUpload failed: %v", err)
+	}
+	m := mustLoadManifest(t, fm)
+	if keys := m.Keys(); !reflect.DeepEqual(keys, []string{"a.txt", "docs/b.txt"}) {
+		t.Fatalf("Unexpected manifest keys %v", keys)
+	}
+	if entry := m.Files["a.txt"]; entry.Size != int64(len("a.txt")) || entry.SHA256 == "" {
+		t.Errorf("Expected size and hash in the manifest, got %+v", entry)
+	}
+
+	// 清单是新的，再次规划不必列出远程
+	lists := store.lists.Load()
+	plan, err := fm.PlanSyncUpload(t.Context())
+	if err != nil {
+		t.Fatalf("PlanSyncUpload failed: %v", err)
+	}
+	if len(plan.Files) != 0 {
+		t.Errorf("Expected nothing to upload, got %v", plan.Files)
+	}
+	if store.lists.Load() != lists {
+		t.Error("A fresh manifest should avoid listing the remote")
+	}
+
+	// The manifest itself is never synced
+	files, err := fm.ListRemoteFiles("")
+	if err != nil {
+		t.Fatalf("ListRemoteFiles failed: %v", err)
+	}
+	for _, file := range files {
+		if file == ManifestKey {
+			t.Error("The manifest should be hidden from remote listings")
+		}
+	}
+}
+
+func TestManifest_DiffAgainstManifest(t *testing.T) {
+	fm, tempDir, store := newManifestTestFileManager(t)
+	writeLocalFiles(t, tempDir, "a.txt", "b.txt")
+	if _, err := fm.SyncUpload(t.Context()); err != nil {
+		t.Fatalf("SyncUpload failed: %v", err)
+	}
+
+	// 另一台机器上传了 c.txt 并更新了清单；本地新增 d.txt、删除 b.txt
+	mustUpload(t, store, "c.txt", []byte("encrypted"))
+	m := mustLoadManifest(t, fm)
+	m.Files["c.txt"] = ManifestEntry{Size: 5}
+	if err := fm.SaveManifest(m); err != nil {
+		t.Fatalf("SaveManifest failed: %v", err)
+	}
+	writeLocalFiles(t, tempDir, "d.txt")
+	if err := os.Remove(filepath.Join(tempDir, "b.txt")); err != nil {
+		t.Fatalf("Failed to remove b.txt: %v", err)
+	}
+
+	lists := store.lists.Load()
+	download, err := fm.PlanSyncDownload(t.Context())
+	if err != nil {
+		t.Fatalf("PlanSyncDownload failed: %v", err)
+	}
+	sort.Strings(download)
+	if !reflect.DeepEqual(download, []string{"b.txt", "c.txt"}) {
+		t.Errorf("Unexpected download plan %v", download)
+	}
+	plan, err := fm.PlanSyncUpload(t.Context())
+	if err != nil {
+		t.Fatalf("PlanSyncUpload failed: %v", err)
+	}
+	if !reflect.DeepEqual(plan.Files, []string{"d.txt"}) {
+		t.Errorf("Unexpected upload plan %v", plan.Files)
+	}
+	if store.lists.Load() != lists {
+		t.Error("Planning with a fresh manifest should not list the remote")
+	}
+}
+
+func TestManifest_UpdatedByUploadDeleteRename(t *testing.T) {
+	fm, tempDir, _ := createTestFileManager(t)
+	writeLocalFiles(t, tempDir, "a.txt")
+	if _, err := fm.SyncUpload(t.Context()); err != nil {
+		t.Fatalf("SyncUpload failed: %v", err)
+	}
+
+	writeLocalFiles(t, tempDir, "b.txt")
+	if err := fm.EncryptAndUploadFile(filepath.Join(tempDir, "b.txt"), "b.txt"); err != nil {
+		t.Fatalf("EncryptAndUploadFile failed: %v", err)
+	}
+	if err := fm.DeleteRemoteFile("a.txt"); err != nil {
+		t.Fatalf("DeleteRemoteFile failed: %v", err)
+	}
+	if err := fm.RenameRemoteFile(t.Context(), "b.txt", "c.txt"); err != nil {
+		t.Fatalf("RenameRemoteFile failed: %v", err)
+	}
+
+	m := mustLoadManifest(t, fm)
+	if keys := m.Keys(); !reflect.DeepEqual(keys, []string{"c.txt"}) {
+		t.Errorf("Expected the manifest to follow the changes, got %v", keys)
+	}
+	if entry := m.Files["c.txt"]; entry.Size != int64(len("b.txt")) {
+		t.Errorf("Renaming should keep the entry, got %+v", entry)
+	}
+}
+
+func TestManifest_BatchWritesOnce(t *testing.T) {
+	fm, tempDir, _ := createTestFileManager(t)
+	if _, err := fm.SyncUpload(t.Context()); err != nil {
+		t.Fatalf("SyncUpload failed: %v", err)
+	}
+	uploads := &manifestUploadCounter{Client: fm.storage}
+	fm.storage = uploads
+
+	writeLocalFiles(t, tempDir, "a.txt", "b.txt", "c.txt")
+	paths := []string{
+		filepath.Join(tempDir, "a.txt"),
+		filepath.Join(tempDir, "b.txt"),
+		filepath.Join(tempDir, "c.txt"),
+	}
+	if _, err := fm.EncryptAndUploadPaths(t.Context(), paths, nil); err != nil {
+		t.Fatalf("EncryptAndUploadPaths failed: %v", err)
+	}
+	if n := uploads.manifestWrites.Load(); n != 1 {
+		t.Errorf("Expected one manifest write for the batch, got %d", n)
+	}
+	if keys := mustLoadManifest(t, fm).Keys(); len(keys) != 3 {
+		t.Errorf("Expected 3 files in the manifest, got %v", keys)
+	}
+}
+
+// manifestUploadCounter counts writes of the manifest
+type manifestUploadCounter struct {
+	storage.Client
+	manifestWrites atomic.Int32
+}
+
+func (c *manifestUploadCounter) Upload(key string, data []byte) error {
+	if key == ManifestKey {
+		c.manifestWrites.Add(1)
+	}
+	return c.Client.Upload(key, data)
+}
+
+func TestManifest_StaleFallsBackToList(t *testing.T) {
+	fm, _, store := newManifestTestFileManager(t)
+	mustUpload(t, store, "a.txt", []byte("encrypted"))
+
+	// 过期的清单缺少 a.txt，必须重新列出远程
+	m := newManifest()
+	m.Listed = time.Now().Add(-2 * manifestMaxAge)
+	if err := fm.SaveManifest(m); err != nil {
+		t.Fatalf("SaveManifest failed: %v", err)
+	}
+
+	lists := store.lists.Load()
+	missing, err := fm.PlanSyncDownload(t.Context())
+	if err != nil {
+		t.Fatalf("PlanSyncDownload failed: %v", err)
+	}
+	if !reflect.DeepEqual(missing, []string{"a.txt"}) {
+		t.Errorf("Expected a.txt from the listing, got %v", missing)
+	}
+	if store.lists.Load() == lists {
+		t.Error("A stale manifest should fall back to listing the remote")
+	}
+}
+
+func TestManifest_UnreadableIsNotOverwritten(t *testing.T) {
+	fm, tempDir, store := newManifestTestFileManager(t)
+	writeLocalFiles(t, tempDir, "a.txt")
+	if _, err := fm.SyncUpload(t.Context()); err != nil {
+		t.Fatalf("SyncUpload failed: %v", err)
+	}
+	before, err := store.Download(ManifestKey)
+	if err != nil {
+		t.Fatalf("Manifest not stored: %v", err)
+	}
+
+	fm.cipher = crypto.NewAESGCM("another-password")
+	if _, err := fm.SyncDownload(t.Context()); err != nil {
+		t.Fatalf("SyncDownload failed: %v", err)
+	}
+	after, err := store.Download(ManifestKey)
+	if err != nil {
+		t.Fatalf("Manifest removed: %v", err)
+	}
+	if !bytes.Equal(before, after) {
+		t.Error("A manifest that cannot be decrypted should not be overwritten")
+	}
+}
+
+func TestManifest_UpdateRereadsBeforeWrite(t *testing.T) {
+	fm, tempDir, _ := createTestFileManager(t)
+	writeLocalFiles(t, tempDir, "a.txt")
+	if _, err := fm.SyncUpload(t.Context()); err != nil {
+		t.Fatalf("SyncUpload failed: %v", err)
+	}
+
+	// 另一个客户端在此期间写入了 remote.txt
+	other := NewFileManager(fm.config, fm.storage, fm.logger, fm.cipher)
+	m := mustLoadManifest(t, other)
+	m.Files["remote.txt"] = ManifestEntry{Size: 1}
+	if err := other.SaveManifest(m); err != nil {
+		t.Fatalf("SaveManifest failed: %v", err)
+	}
+
+	writeLocalFiles(t, tempDir, "b.txt")
+	if err := fm.EncryptAndUploadFile(filepath.Join(tempDir, "b.txt"), "b.txt"); err != nil {
+		t.Fatalf("EncryptAndUploadFile failed: %v", err)
+	}
+	keys := mustLoadManifest(t, fm).Keys()
+	if !reflect.DeepEqual(keys, []string{"a.txt", "b.txt", "remote.txt"}) {
+		t.Errorf("Expected the other client's change to be kept, got %v", keys)
+	}
+}
+
+func TestManifest_SyncUploadChecksManifestBeforeRemote(t *testing.T) {
+	fm, tempDir, store := newManifestTestFileManager(t)
+	writeLocalFiles(t, tempDir, "a.txt", "docs/b.txt", "docs/c.txt")
+	if _, err := fm.SyncUpload(t.Context()); err != nil {
+		t.Fatalf("SyncUpload failed: %v", err)
+	}
+	// 再上传一次，清单中记录上传时的精确修改时间
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(filepath.Join(tempDir, "a.txt"), later, later); err != nil {
+		t.Fatalf("Chtimes failed: %v", err)
+	}
+	if _, err := fm.SyncUpload(t.Context()); err != nil {
+		t.Fatalf("SyncUpload failed: %v", err)
+	}
+
+	// 只改修改时间的文件按清单中的哈希判断，都不需要查询远程
+	touched := time.Now().Add(2 * time.Minute)
+	if err := os.Chtimes(filepath.Join(tempDir, "docs", "b.txt"), touched, touched); err != nil {
+		t.Fatalf("Chtimes failed: %v", err)
+	}
+	stats := store.stats.Load()
+	plan, err := fm.PlanSyncUpload(t.Context())
+	if err != nil {
+		t.Fatalf("PlanSyncUpload failed: %v", err)
+	}
+	if len(plan.Files) != 0 {
+		t.Errorf("Expected nothing to upload, got %v", plan.Files)
+	}
+	if n := store.stats.Load() - stats; n != 0 {
+		t.Errorf("Expected the manifest to answer without stats, got %d", n)
+	}
+
+	// 清单中没有大小和哈希的文件才查询远程
+	m := mustLoadManifest(t, fm)
+	m.Files["docs/c.txt"] = ManifestEntry{}
+	if err := fm.SaveManifest(m); err != nil {
+		t.Fatalf("SaveManifest failed: %v", err)
+	}
+	stats = store.stats.Load()
+	if plan, err = fm.PlanSyncUpload(t.Context()); err != nil || len(plan.Files) != 0 {
+		t.Fatalf("Expected nothing to upload, got %v, %v", plan, err)
+	}
+	if n := store.stats.Load() - stats; n != 1 {
+		t.Errorf("Expected one stat for the file unknown to the manifest, got %d", n)
+	}
+}
+
+func TestFileManager_SyncUploadPlanned(t *testing.T) {
+	fm, tempDir, _ := newManifestTestFileManager(t)
+	writeLocalFiles(t, tempDir, "a.txt")
+	plan, err := fm.PlanSyncUpload(t.Context())
+	if err != nil {
+		t.Fatalf("PlanSyncUpload failed: %v", err)
+	}
+
+	// 确认之后新增的文件不在计划中，留给下一次同步
+	writeLocalFiles(t, tempDir, "late.txt")
+	result, err := fm.SyncUploadPlanned(t.Context(), plan)
+	if err != nil {
+		t.Fatalf("SyncUploadPlanned failed: %v", err)
+	}
+	if !reflect.DeepEqual(result.Succeeded, []string{"a.txt"}) {
+		t.Errorf("Expected only the planned file, got %v", result.Succeeded)
+	}
+	if keys := mustLoadManifest(t, fm).Keys(); !reflect.DeepEqual(keys, []string{"a.txt"}) {
+		t.Errorf("Expected the manifest built from the planned listing, got %v", keys)
+	}
+}
@@ -0,0 +1,63 @@
+package dir
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Stats is a snapshot of the encrypt-and-upload throughput
+type Stats struct {
+	Files   int64         // 已上传的文件数
+	Bytes   int64         // 已加密上传的明文字节数
+	Elapsed time.Duration // 加密和上传累计耗时
+}
+
+// MBPerSecond returns the average throughput in MB/s, or 0 when nothing was measured
+func (s Stats) MBPerSecond() float64 {
+	if s.Elapsed <= 0 {
+		return 0
+	}
+	return float64(s.Bytes) / (1 << 20) / s.Elapsed.Seconds()
+}
+
+// Sub returns the stats accumulated since the earlier snapshot
+func (s Stats) Sub(earlier Stats) Stats {
+	return Stats{
+		Files:   s.Files - earlier.Files,
+		Bytes:   s.Bytes - earlier.Bytes,
+		Elapsed: s.Elapsed - earlier.Elapsed,
+	}
+}
+
+// FormatRate formats the average throughput of stats for log lines
+func FormatRate(s Stats) string {
+	return fmt.Sprintf("%.2f MB/s", s.MBPerSecond())
+}
+
+// metrics accumulates per-file transfer measurements; safe for concurrent use
+type metrics struct {
+	mu    sync.Mutex
+	stats Stats
+}
+
+// record adds one finished file of the given size and duration
+func (m *metrics) record(bytes int64, elapsed time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stats.Files++
+	m.stats.Bytes += bytes
+	m.stats.Elapsed += elapsed
+}
+
+// snapshot returns the current totals
+func (m *metrics) snapshot() Stats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stats
+}
+
+// Stats returns a snapshot of the encrypt-and-upload throughput since the FileManager was created
+func (fm *FileManager) Stats() Stats {
+	return fm.metrics.snapshot()
+}
@@ -0,0 +1,86 @@
+package dir
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMetrics_Rate(t *testing.T) {
+	m := &metrics{}
+	m.record(6<<20, time.Second)
+	m.record(4<<20, time.Second)
+
+	stats := m.snapshot()
+	if stats.Files != 2 || stats.Bytes != 10<<20 || stats.Elapsed != 2*time.Second {
+		t.Fatalf("Unexpected stats: %+v", stats)
+	}
+	if rate := stats.MBPerSecond(); math.Abs(rate-5) > 1e-9 {
+		t.Errorf("Expected 5 MB/s, got %f", rate)
+	}
+	if got := FormatRate(stats); got != "5.00 MB/s" {
+		t.Errorf("Expected '5.00 MB/s', got %q", got)
+	}
+}
+
+func TestMetrics_ZeroElapsed(t *testing.T) {
+	if rate := (Stats{Bytes: 100}).MBPerSecond(); rate != 0 {
+		t.Errorf("Expected 0 MB/s without elapsed time, got %f", rate)
+	}
+}
+
+func TestMetrics_Sub(t *testing.T) {
+	m := &metrics{}
+	m.record(1<<20, time.Second)
+	before := m.snapshot()
+	m.record(3<<20, 500*time.Millisecond)
+
+	delta := m.snapshot().Sub(before)
+	if delta.Files != 1 || delta.Bytes != 3<<20 || delta.Elapsed != 500*time.Millisecond {
+		t.Fatalf("Unexpected delta: %+v", delta)
+	}
+	if rate := delta.MBPerSecond(); math.Abs(rate-6) > 1e-9 {
+		t.Errorf("Expected 6 MB/s, got %f", rate)
+	}
+}
+
+func TestMetrics_Concurrent(t *testing.T) {
+	m := &metrics{}
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.record(1024, time.Millisecond)
+		}()
+	}
+	wg.Wait()
+
+	stats := m.snapshot()
+	if stats.Files != 50 || stats.Bytes != 50*1024 || stats.Elapsed != 50*time.Millisecond {
+		t.Errorf("Unexpected stats after concurrent records: %+v", stats)
+	}
+}
+
+func TestFileManager_StatsCountsUploads(t *testing.T) {
+	fm, tempDir, _ := createTestFileManager(t)
+
+	path := filepath.Join(tempDir, "data.bin")
+	if err := os.WriteFile(path, make([]byte, 4096), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	if err := fm.EncryptAndUploadFile(path, "data.bin"); err != nil {
+		t.Fatalf("EncryptAndUploadFile failed: %v", err)
+	}
+
+	stats := fm.Stats()
+	if stats.Files != 1 || stats.Bytes != 4096 {
+		t.Errorf("Expected 1 file and 4096 bytes, got %+v", stats)
+	}
+	if stats.Elapsed <= 0 {
+		t.Error("Expected elapsed time to be recorded")
+	}
+}
@@ -0,0 +1,91 @@
+package dir
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+)
+
+// MirrorConfirmFunc is asked, before anything is transferred, whether the
+// remote keys that mirroring would delete may be deleted
+type MirrorConfirmFunc func(toDelete []string) bool
+
+// PlanMirrorDeletes returns the remote keys without a local file, which
+// SyncUploadMirror would delete. The remote is always listed, since a stale
+// manifest could miss keys.
+func (fm *FileManager) PlanMirrorDeletes(ctx context.Context) ([]string, error) {
+	keys, err := fm.storage.List("")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list remote files: %w: %w", ErrStorage, err)
+	}
+
+	var toDelete []string
+	for _, key := range withoutInternalKeys(keys) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		// 空目录占位对象在目录仍存在时保留
+		localKey := key
+		if isEmptyDirPlaceholder(key) {
+			localKey = path.Dir(key)
+			if localKey == "." {
+				continue
+			}
+		}
+		localPath, err := fm.resolveLocalPath(filepath.FromSlash(localKey))
+		if err != nil {
+			// 无法对应到工作目录内的路径时不删除
+			fm.logger.Warn("Keeping remote file outside the working directory", slog.String("path", key))
+			continue
+		}
+		if _, err := os.Lstat(localPath); errors.Is(err, os.ErrNotExist) {
+			toDelete = append(toDelete, key)
+		} else if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrLocalIO, err)
+		}
+	}
+	sort.Strings(toDelete)
+	return toDelete, nil
+}
+
+// SyncUploadMirror makes the remote an exact copy of the working directory:
+// it uploads like SyncUpload and then deletes the remote keys without a local
+// file. confirm is called with those keys before the upload; when it returns
+// false, or the upload is cancelled, nothing is deleted. Deleted keys are
+// reported in Deleted and failed deletions in Failed.
+func (fm *FileManager) SyncUploadMirror(ctx context.Context, confirm MirrorConfirmFunc) (*BatchResult, error) {
+	toDelete, err := fm.PlanMirrorDeletes(ctx)
+	if err != nil {
+		return &BatchResult{}, err
+	}
+	deleteConfirmed := len(toDelete) > 0 && confirm(toDelete)
+	if len(toDelete) > 0 && !deleteConfirmed {
+		fm.logger.Info("Mirror deletions declined, only uploading", slog.Int("keys", len(toDelete)))
+	}
+
+	result, err := fm.SyncUpload(ctx)
+	if err != nil || !deleteConfirmed {
+		return result, err
+	}
+
+	fm.beginManifestBatch()
+	defer fm.endManifestBatch()
+	for _, key := range toDelete {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		if err := fm.DeleteRemoteFile(key); err != nil {
+			fm.logger.Error("Failed to delete remote file", slog.String("path", key), slog.String("error", err.Error()))
+			result.Failed = append(result.Failed, FileError{Path: key, Err: err})
+			continue
+		}
+		result.Deleted = append(result.Deleted, key)
+	}
+	fm.logger.Info("Mirror deletions finished", slog.Int("deleted", len(result.Deleted)))
+	return result, nil
+}
@@ -0,0 +1,116 @@
+package dir
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// setupMirror uploads keep.txt, gone.txt and empty/, then removes gone.txt and
+// empty/ locally and adds new.txt
+func setupMirror(t *testing.T) (*FileManager, string) {
+	t.Helper()
+	fm, tempDir, _ := createTestFileManager(t)
+	fm.config.PreserveEmptyDirs = true
+	writeFileContents(t, tempDir, map[string]string{"keep.txt": "keep", "sub/gone.txt": "gone"})
+	if err := os.Mkdir(filepath.Join(tempDir, "empty"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := fm.EncryptAndUploadDirectory(context.Background(), tempDir); err != nil {
+		t.Fatalf("EncryptAndUploadDirectory failed: %v", err)
+	}
+
+	if err := os.RemoveAll(filepath.Join(tempDir, "sub")); err != nil {
+		t.Fatalf("Failed to remove local file: %v", err)
+	}
+	if err := os.Remove(filepath.Join(tempDir, "empty")); err != nil {
+		t.Fatalf("Failed to remove local directory: %v", err)
+	}
+	writeFileContents(t, tempDir, map[string]string{"new.txt": "new"})
+	return fm, tempDir
+}
+
+func TestFileManager_PlanMirrorDeletes(t *testing.T) {
+	fm, _ := setupMirror(t)
+
+	toDelete, err := fm.PlanMirrorDeletes(context.Background())
+	if err != nil {
+		t.Fatalf("PlanMirrorDeletes failed: %v", err)
+	}
+	want := []string{"empty/" + EmptyDirPlaceholder, "sub/gone.txt"}
+	if !slices.Equal(toDelete, want) {
+		t.Errorf("Expected %v, got %v", want, toDelete)
+	}
+}
+
+func TestFileManager_SyncUploadMirrorDeclined(t *testing.T) {
+	fm, _ := setupMirror(t)
+
+	var asked []string
+	result, err := fm.SyncUploadMirror(context.Background(), func(toDelete []string) bool {
+		asked = toDelete
+		return false
+	})
+	if err != nil {
+		t.Fatalf("SyncUploadMirror failed: %v", err)
+	}
+	if len(asked) != 2 {
+		t.Errorf("Expected to be asked about 2 keys, got %v", asked)
+	}
+	if !slices.Equal(result.Succeeded, []string{"new.txt"}) || len(result.Deleted) != 0 {
+		t.Errorf("Expected new.txt uploaded and nothing deleted, got %+v", result)
+	}
+
+	remote, _ := fm.ListRemoteFiles("")
+	for _, key := range []string{"keep.txt", "new.txt", "sub/gone.txt", "empty/" + EmptyDirPlaceholder} {
+		if !slices.Contains(remote, key) {
+			t.Errorf("Expected %s to remain remote, got %v", key, remote)
+		}
+	}
+}
+
+func TestFileManager_SyncUploadMirrorConfirmed(t *testing.T) {
+	fm, _ := setupMirror(t)
+
+	result, err := fm.SyncUploadMirror(context.Background(), func([]string) bool { return true })
+	if err != nil {
+		t.Fatalf("SyncUploadMirror failed: %v", err)
+	}
+	if len(result.Deleted) != 2 || len(result.Failed) != 0 {
+		t.Errorf("Expected 2 deletions, got %+v", result)
+	}
+
+	remote, _ := fm.ListRemoteFiles("")
+	slices.Sort(remote)
+	if !slices.Equal(remote, []string{"keep.txt", "new.txt"}) {
+		t.Errorf("Expected the remote to mirror the working directory, got %v", remote)
+	}
+
+	// 已经一致时不再询问
+	_, err = fm.SyncUploadMirror(context.Background(), func([]string) bool {
+		t.Error("Should not ask when nothing would be deleted")
+		return true
+	})
+	if err != nil {
+		t.Fatalf("SyncUploadMirror failed: %v", err)
+	}
+}
+
+func TestFileManager_SyncUploadMirrorCancelled(t *testing.T) {
+	fm, _ := setupMirror(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err := fm.SyncUploadMirror(ctx, func([]string) bool {
+		cancel()
+		return true
+	})
+	if err == nil {
+		t.Fatal("Expected the cancelled mirror to fail")
+	}
+	remote, _ := fm.ListRemoteFiles("")
+	if !slices.Contains(remote, "sub/gone.txt") {
+		t.Errorf("Nothing should be deleted after cancelling, got %v", remote)
+	}
+}
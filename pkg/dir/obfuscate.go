@@ -0,0 +1,39 @@
+package dir
+
+import (
+	"errors"
+	"os"
+
+	"github.com/mingregister/fers/pkg/crypto"
+)
+
+// ErrKeyHashingNotSupported is returned with obfuscate_keys when the cipher cannot hash keys
+var ErrKeyHashingNotSupported = errors.New("cipher does not support obfuscated keys")
+
+// ObfuscateKeys reports whether remote objects are stored under the HMAC of
+// their path, with the real paths only in the encrypted manifest
+func (fm *FileManager) ObfuscateKeys() bool {
+	return fm.obfuscateKeys
+}
+
+// hashKey returns the remote object key for the plain key
+func (fm *FileManager) hashKey(key string) (string, error) {
+	hasher, ok := fm.currentCipher().(crypto.KeyHasher)
+	if !ok {
+		return "", ErrKeyHashingNotSupported
+	}
+	return hasher.HashKey(key)
+}
+
+// manifestKeys returns the plain keys recorded in the manifest, which is the
+// only listing of the remote when keys are obfuscated
+func (fm *FileManager) manifestKeys() ([]string, error) {
+	m, err := fm.LoadManifest()
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return m.Keys(), nil
+}
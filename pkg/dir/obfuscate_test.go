@@ -0,0 +1,124 @@
+package dir
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/mingregister/fers/pkg/config"
+	"github.com/mingregister/fers/pkg/crypto"
+	"github.com/mingregister/fers/pkg/storage"
+)
+
+// newObfuscatedTestFileManager returns a file manager with obfuscate_keys over a memory store
+func newObfuscatedTestFileManager(t *testing.T, cipher crypto.Cipher) (*FileManager, string, storage.Client) {
+	t.Helper()
+	tempDir := t.TempDir()
+	cfg := &config.Config{TargetDir: tempDir, ObfuscateKeys: true}
+	mockStore := storage.NewMemoryClient()
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	return NewFileManager(cfg, mockStore, logger, cipher), tempDir, mockStore
+}
+
+var hashedKeyPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+func TestObfuscateKeys_RemoteKeysAreOpaque(t *testing.T) {
+	fm, tempDir, mockStore := newObfuscatedTestFileManager(t, crypto.NewAESGCM("test-password"))
+	writeLocalFiles(t, tempDir, "a.txt", "docs/salary-2024.xlsx", "docs/sub/notes.md")
+
+	if _, err := fm.SyncUpload(t.Context()); err != nil {
+		t.Fatalf("SyncUpload failed: %v", err)
+	}
+
+	stored, err := mockStore.List("")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	hashed := 0
+	for _, key := range stored {
+		switch {
+		case key == ManifestKey || key == CanaryKey:
+		case hashedKeyPattern.MatchString(key):
+			hashed++
+		default:
+			t.Errorf("Remote key %q is not opaque", key)
+		}
+	}
+	if hashed != 3 {
+		t.Errorf("Expected 3 hashed objects, got %v", stored)
+	}
+
+	files, err := fm.ListRemoteFiles("")
+	if err != nil {
+		t.Fatalf("ListRemoteFiles failed: %v", err)
+	}
+	if want := []string{"a.txt", "docs/salary-2024.xlsx", "docs/sub/notes.md"}; !reflect.DeepEqual(files, want) {
+		t.Errorf("Expected plain paths %v from the manifest, got %v", want, files)
+	}
+	files, folders, err := fm.ListRemoteDir("docs/")
+	if err != nil {
+		t.Fatalf("ListRemoteDir failed: %v", err)
+	}
+	if !reflect.DeepEqual(files, []string{"docs/salary-2024.xlsx"}) || !reflect.DeepEqual(folders, []string{"docs/sub/"}) {
+		t.Errorf("Unexpected folder listing: files %v, folders %v", files, folders)
+	}
+}
+
+func TestObfuscateKeys_RoundTrip(t *testing.T) {
+	fm, tempDir, _ := newObfuscatedTestFileManager(t, crypto.NewAESGCM("test-password"))
+	writeLocalFiles(t, tempDir, "a.txt", "docs/b.txt", "docs/sub/c.txt")
+
+	if _, err := fm.SyncUpload(t.Context()); err != nil {
+		t.Fatalf("SyncUpload failed: %v", err)
+	}
+	if err := fm.RenameRemoteFile(t.Context(), "a.txt", "renamed/a.txt"); err != nil {
+		t.Fatalf("RenameRemoteFile failed: %v", err)
+	}
+	if err := fm.DeleteRemoteFile("docs/b.txt"); err != nil {
+		t.Fatalf("DeleteRemoteFile failed: %v", err)
+	}
+
+	// 另一个工作目录只凭密钥和远程就能还原路径
+	restoreDir := t.TempDir()
+	fm.workingDir = restoreDir
+	result, err := fm.SyncDownload(t.Context())
+	if err != nil {
+		t.Fatalf("SyncDownload failed: %v", err)
+	}
+	if len(result.Failed) != 0 {
+		t.Fatalf("Unexpected failures: %v", result.Failed)
+	}
+
+	for path, want := range map[string]string{"renamed/a.txt": "a.txt", "docs/sub/c.txt": "docs/sub/c.txt"} {
+		data, err := os.ReadFile(filepath.Join(restoreDir, filepath.FromSlash(path)))
+		if err != nil || string(data) != want {
+			t.Errorf("Expected %s restored with %q, got %q, %v", path, want, data, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(restoreDir, "docs", "b.txt")); !os.IsNotExist(err) {
+		t.Errorf("Deleted file should not be restored, got %v", err)
+	}
+}
+
+// plainCipher is a cipher that cannot hash keys
+type plainCipher struct{}
+
+func (plainCipher) Encrypt(plain []byte) ([]byte, error) { return plain, nil }
+func (plainCipher) Decrypt(data []byte) ([]byte, error)  { return data, nil }
+
+func TestObfuscateKeys_RequiresKeyHasher(t *testing.T) {
+	fm, tempDir, mockStore := newObfuscatedTestFileManager(t, plainCipher{})
+	writeLocalFiles(t, tempDir, "a.txt")
+
+	err := fm.EncryptAndUploadFile(filepath.Join(tempDir, "a.txt"), "a.txt")
+	if !errors.Is(err, ErrKeyHashingNotSupported) {
+		t.Fatalf("Expected ErrKeyHashingNotSupported, got %v", err)
+	}
+	if stored, _ := mockStore.List(""); len(stored) != 0 {
+		t.Errorf("Nothing should be stored under a plain key, got %v", stored)
+	}
+}
@@ -0,0 +1,218 @@
+package dir
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/mingregister/fers/pkg/storage"
+)
+
+// QuarantineFile records, in the working directory, the files that keep failing in syncs
+const QuarantineFile = ".fers-quarantine"
+
+// DefaultQuarantineAfter is the number of failed syncs after which a file is
+// quarantined when quarantine_after is not set
+const DefaultQuarantineAfter = 3
+
+// QuarantineEntry is the sync failure record of one file
+type QuarantineEntry struct {
+	Path        string `json:"path"` // 远程键格式的相对路径
+	Failures    int    `json:"failures"`
+	LastError   string `json:"last_error"`
+	Quarantined bool   `json:"quarantined"` // 失败次数达到阈值，同步时跳过
+}
+
+// quarantineAfter returns how many failures quarantine a file, 0 when quarantining is disabled
+func (fm *FileManager) quarantineAfter() int {
+	switch n := fm.config.QuarantineAfter; {
+	case n < 0:
+		return 0
+	case n == 0:
+		return DefaultQuarantineAfter
+	default:
+		return n
+	}
+}
+
+// loadQuarantine reads the failure records keyed by path; a missing file means none
+func (fm *FileManager) loadQuarantine() (map[string]*QuarantineEntry, error) {
+	entries := make(map[string]*QuarantineEntry)
+	data, err := os.ReadFile(filepath.Join(fm.workingDir, QuarantineFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read quarantine: %w: %w", ErrLocalIO, err)
+	}
+	var list []QuarantineEntry
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse quarantine: %w", err)
+	}
+	for i := range list {
+		entries[list[i].Path] = &list[i]
+	}
+	return entries, nil
+}
+
+// saveQuarantine writes the failure records sorted by path, removing the file when there are none
+func (fm *FileManager) saveQuarantine(entries map[string]*QuarantineEntry) error {
+	path := filepath.Join(fm.workingDir, QuarantineFile)
+	if len(entries) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove quarantine: %w: %w", ErrLocalIO, err)
+		}
+		return nil
+	}
+
+	list := make([]QuarantineEntry, 0, len(entries))
+	for _, entry := range entries {
+		list = append(list, *entry)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Path < list[j].Path })
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode quarantine: %w", err)
+	}
+	if err := writeFileAtomic(path, data, defaultFileMode); err != nil {
+		return fmt.Errorf("failed to write quarantine: %w: %w", ErrLocalIO, err)
+	}
+	return nil
+}
+
+// Quarantine returns the failure records of the files that failed in recent
+// syncs, sorted by path. Entries with Quarantined set are skipped by syncs
+// until released with RetryQuarantined.
+func (fm *FileManager) Quarantine() ([]QuarantineEntry, error) {
+	fm.quarantineMu.Lock()
+	defer fm.quarantineMu.Unlock()
+
+	entries, err := fm.loadQuarantine()
+	if err != nil {
+		return nil, err
+	}
+	list := make([]QuarantineEntry, 0, len(entries))
+	for _, entry := range entries {
+		list = append(list, *entry)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Path < list[j].Path })
+	return list, nil
+}
+
+// quarantined returns the paths that syncs skip
+func (fm *FileManager) quarantined() map[string]bool {
+	fm.quarantineMu.Lock()
+	defer fm.quarantineMu.Unlock()
+
+	entries, err := fm.loadQuarantine()
+	if err != nil {
+		fm.logger.Warn("Failed to load quarantine", slog.String("error", err.Error()))
+		return nil
+	}
+	paths := make(map[string]bool)
+	for path, entry := range entries {
+		if entry.Quarantined {
+			paths[path] = true
+		}
+	}
+	return paths
+}
+
+// recordSyncResult counts the failures of a sync batch, quarantining files
+// that reached the threshold, and forgets the failures of files that succeeded
+func (fm *FileManager) recordSyncResult(result *BatchResult) {
+	threshold := fm.quarantineAfter()
+	if threshold == 0 || (len(result.Failed) == 0 && len(result.Succeeded) == 0) {
+		return
+	}
+
+	fm.quarantineMu.Lock()
+	defer fm.quarantineMu.Unlock()
+
+	entries, err := fm.loadQuarantine()
+	if err != nil {
+		fm.logger.Warn("Failed to load quarantine", slog.String("error", err.Error()))
+		return
+	}
+	changed := false
+	for _, path := range result.Succeeded {
+		key := storage.NormalizeKey(path)
+		if _, ok := entries[key]; ok {
+			delete(entries, key)
+			changed = true
+		}
+	}
+	for _, failed := range result.Failed {
+		if errors.Is(failed.Err, context.Canceled) || errors.Is(failed.Err, context.DeadlineExceeded) {
+			// 取消或整体超时不是文件本身的问题
+			continue
+		}
+		key := storage.NormalizeKey(failed.Path)
+		entry, ok := entries[key]
+		if !ok {
+			entry = &QuarantineEntry{Path: key}
+			entries[key] = entry
+		}
+		entry.Failures++
+		entry.LastError = failed.Err.Error()
+		if !entry.Quarantined && entry.Failures >= threshold {
+			entry.Quarantined = true
+			fm.logger.Warn("File quarantined after repeated failures, syncs will skip it until retried",
+				slog.String("path", key), slog.Int("failures", entry.Failures), slog.String("error", entry.LastError))
+		}
+		changed = true
+	}
+	if !changed {
+		return
+	}
+	if err := fm.saveQuarantine(entries); err != nil {
+		fm.logger.Warn("Failed to save quarantine", slog.String("error", err.Error()))
+	}
+}
+
+// RetryQuarantined releases the quarantined files and transfers them again:
+// files that exist locally are uploaded, the others downloaded. Files that
+// fail again start counting from zero.
+func (fm *FileManager) RetryQuarantined(ctx context.Context) (*BatchResult, error) {
+	fm.quarantineMu.Lock()
+	entries, err := fm.loadQuarantine()
+	if err != nil {
+		fm.quarantineMu.Unlock()
+		return &BatchResult{}, err
+	}
+	var uploads, downloads []string
+	for path, entry := range entries {
+		if !entry.Quarantined {
+			continue
+		}
+		delete(entries, path)
+		if localFileExists(filepath.Join(fm.workingDir, filepath.FromSlash(path))) {
+			uploads = append(uploads, filepath.FromSlash(path))
+		} else {
+			downloads = append(downloads, path)
+		}
+	}
+	err = fm.saveQuarantine(entries)
+	fm.quarantineMu.Unlock()
+	if err != nil {
+		return &BatchResult{}, err
+	}
+	sort.Strings(uploads)
+	sort.Strings(downloads)
+	fm.logger.Info("Retrying quarantined files", slog.Int("upload", len(uploads)), slog.Int("download", len(downloads)))
+
+	result, err := fm.uploadMissing(ctx, uploads)
+	if err != nil {
+		return result, err
+	}
+	downloaded, err := fm.downloadMissing(ctx, downloads)
+	result.Succeeded = append(result.Succeeded, downloaded.Succeeded...)
+	result.Failed = append(result.Failed, downloaded.Failed...)
+	result.Skipped = append(result.Skipped, downloaded.Skipped...)
+	return result, err
+}
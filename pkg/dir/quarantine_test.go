@@ -0,0 +1,162 @@
+package dir
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mingregister/fers/pkg/storage"
+)
+
+// quarantineEntry returns the failure record of path, if any
+func quarantineEntry(t *testing.T, fm *FileManager, path string) (QuarantineEntry, bool) {
+	t.Helper()
+	entries, err := fm.Quarantine()
+	if err != nil {
+		t.Fatalf("Quarantine failed: %v", err)
+	}
+	for _, entry := range entries {
+		if entry.Path == path {
+			return entry, true
+		}
+	}
+	return QuarantineEntry{}, false
+}
+
+func TestQuarantine_CountsFailuresUntilThreshold(t *testing.T) {
+	fm, tempDir, _ := createTestFileManager(t)
+	writeLocalFiles(t, tempDir, "ok.txt", "docs/locked.txt")
+	fm.storage = failingUploadStorage{Client: fm.storage, failing: map[string]bool{"docs/locked.txt": true}}
+
+	for run := 1; run <= DefaultQuarantineAfter; run++ {
+		if _, err := fm.SyncUpload(t.Context()); err != nil {
+			t.Fatalf("SyncUpload %d failed: %v", run, err)
+		}
+		entry, ok := quarantineEntry(t, fm, "docs/locked.txt")
+		if !ok || entry.Failures != run {
+			t.Fatalf("After run %d expected %d failures, got %+v", run, run, entry)
+		}
+		if entry.Quarantined != (run == DefaultQuarantineAfter) {
+			t.Errorf("After run %d expected quarantined=%v, got %+v", run, run == DefaultQuarantineAfter, entry)
+		}
+		if !strings.Contains(entry.LastError, "simulated failure") {
+			t.Errorf("Expected the last error to be recorded, got %q", entry.LastError)
+		}
+	}
+	if _, ok := quarantineEntry(t, fm, "ok.txt"); ok {
+		t.Error("Files that succeed should not be recorded")
+	}
+
+	// 隔离后的同步跳过该文件，失败次数不再增加
+	plan, err := fm.PlanSyncUpload(t.Context())
+	if err != nil {
+		t.Fatalf("PlanSyncUpload failed: %v", err)
+	}
+	if slices.Contains(plan.Files, filepath.Join("docs", "locked.txt")) {
+		t.Errorf("Expected the quarantined file to be skipped, got %v", plan.Files)
+	}
+	result, err := fm.SyncUpload(t.Context())
+	if err != nil {
+		t.Fatalf("SyncUpload failed: %v", err)
+	}
+	if len(result.Failed) != 0 {
+		t.Errorf("Expected no failures once quarantined, got %v", result.FailedPaths())
+	}
+	if entry, _ := quarantineEntry(t, fm, "docs/locked.txt"); entry.Failures != DefaultQuarantineAfter {
+		t.Errorf("Expected the failure count to stay at %d, got %d", DefaultQuarantineAfter, entry.Failures)
+	}
+}
+
+func TestQuarantine_SuccessClearsFailures(t *testing.T) {
+	fm, tempDir, _ := createTestFileManager(t)
+	writeLocalFiles(t, tempDir, "flaky.txt")
+	client := fm.storage
+	fm.storage = failingUploadStorage{Client: client, failing: map[string]bool{"flaky.txt": true}}
+
+	if _, err := fm.SyncUpload(t.Context()); err != nil {
+		t.Fatalf("SyncUpload failed: %v", err)
+	}
+	if entry, ok := quarantineEntry(t, fm, "flaky.txt"); !ok || entry.Failures != 1 {
+		t.Fatalf("Expected one failure, got %+v", entry)
+	}
+
+	fm.storage = client
+	if _, err := fm.SyncUpload(t.Context()); err != nil {
+		t.Fatalf("SyncUpload failed: %v", err)
+	}
+	if _, ok := quarantineEntry(t, fm, "flaky.txt"); ok {
+		t.Error("A successful upload should clear the failure record")
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, QuarantineFile)); !os.IsNotExist(err) {
+		t.Errorf("Empty quarantine file should be removed, got %v", err)
+	}
+}
+
+func TestQuarantine_Disabled(t *testing.T) {
+	fm, tempDir, _ := createTestFileManager(t)
+	fm.config.QuarantineAfter = -1
+	writeLocalFiles(t, tempDir, "locked.txt")
+	fm.storage = failingUploadStorage{Client: fm.storage, failing: map[string]bool{"locked.txt": true}}
+
+	for run := 0; run < DefaultQuarantineAfter+1; run++ {
+		result, err := fm.SyncUpload(t.Context())
+		if err != nil {
+			t.Fatalf("SyncUpload failed: %v", err)
+		}
+		if !reflect.DeepEqual(result.FailedPaths(), []string{"locked.txt"}) {
+			t.Fatalf("Expected locked.txt to be retried every run, got %v", result.FailedPaths())
+		}
+	}
+	if entries, _ := fm.Quarantine(); len(entries) != 0 {
+		t.Errorf("Expected no records with quarantine disabled, got %+v", entries)
+	}
+}
+
+func TestRetryQuarantined(t *testing.T) {
+	fm, tempDir, store := createTestFileManager(t)
+	fm.config.QuarantineAfter = 1
+	writeLocalFiles(t, tempDir, "locked.txt")
+	encrypted, err := fm.cipher.Encrypt([]byte("remote"))
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	mustUpload(t, store, "remote.txt", encrypted)
+
+	fm.storage = failingUploadStorage{Client: store, failing: map[string]bool{"locked.txt": true}}
+	if _, err := fm.SyncUpload(t.Context()); err != nil {
+		t.Fatalf("SyncUpload failed: %v", err)
+	}
+	fm.storage = &droppingStorage{Client: store, limit: 0, drops: downloadAttempts}
+	if _, err := fm.SyncDownload(t.Context()); err != nil {
+		t.Fatalf("SyncDownload failed: %v", err)
+	}
+	entries, err := fm.Quarantine()
+	if err != nil || len(entries) != 2 || !entries[0].Quarantined || !entries[1].Quarantined {
+		t.Fatalf("Expected both files quarantined, got %+v (%v)", entries, err)
+	}
+
+	// 问题解决后重试：本地存在的上传，其余下载
+	fm.storage = store
+	result, err := fm.RetryQuarantined(t.Context())
+	if err != nil {
+		t.Fatalf("RetryQuarantined failed: %v", err)
+	}
+	if !reflect.DeepEqual(result.Succeeded, []string{"locked.txt", "remote.txt"}) || len(result.Failed) != 0 {
+		t.Errorf("Expected both files to be transferred, got %+v", result)
+	}
+	if _, err := store.Stat("locked.txt"); err != nil {
+		t.Errorf("locked.txt should be uploaded: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(tempDir, "remote.txt")); string(data) != "remote" {
+		t.Errorf("remote.txt should be downloaded, got %q", data)
+	}
+	if entries, _ := fm.Quarantine(); len(entries) != 0 {
+		t.Errorf("Expected the quarantine to be empty, got %+v", entries)
+	}
+	if _, err := store.Stat(storage.NormalizeKey(QuarantineFile)); err == nil {
+		t.Error("The quarantine file must not be uploaded")
+	}
+}
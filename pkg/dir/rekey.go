@@ -0,0 +1,204 @@
+package dir
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/mingregister/fers/pkg/crypto"
+	"github.com/mingregister/fers/pkg/storage"
+)
+
+// ErrRekeyNotSupported is returned by Rekey with obfuscate_keys, whose object
+// keys are derived from the crypto key as well
+var ErrRekeyNotSupported = errors.New("re-keying is not supported with obfuscate_keys")
+
+// ErrRekeySharedKey is returned by Rekey when other sources use the same
+// crypto key, whose remotes would no longer decrypt once the key is changed
+var ErrRekeySharedKey = errors.New("the crypto key is shared with other sources, give this source its own crypto_key first")
+
+// ErrRekeyKeystore is returned by Rekey when the crypto key is the master key
+// of a keystore, which would still unlock the old key after the next start
+var ErrRekeyKeystore = errors.New("the crypto key comes from a keystore and cannot be changed here")
+
+// currentCipher returns the cipher in use, which Rekey may replace
+func (fm *FileManager) currentCipher() crypto.Cipher {
+	fm.cipherMu.RLock()
+	defer fm.cipherMu.RUnlock()
+	return fm.cipher
+}
+
+// SetKeySetting sets the config setting that holds the crypto key, e.g. the
+// crypto_key of a source; it is "crypto_key" by default
+func (fm *FileManager) SetKeySetting(setting string) {
+	fm.keySetting = setting
+}
+
+// KeySetting returns the config setting to update after Rekey
+func (fm *FileManager) KeySetting() string {
+	return fm.keySetting
+}
+
+// SetSharedCipher marks the cipher as shared with other sources, so Rekey refuses to run
+func (fm *FileManager) SetSharedCipher(shared bool) {
+	fm.sharedCipher = shared
+}
+
+// SetKeystoreKey marks the cipher as using the master key of a keystore, so Rekey refuses to run
+func (fm *FileManager) SetKeystoreKey(keystore bool) {
+	fm.keystoreKey = keystore
+}
+
+// CanRekey returns the reason Rekey cannot change the key, or nil
+func (fm *FileManager) CanRekey() error {
+	switch {
+	case fm.obfuscateKeys:
+		return ErrRekeyNotSupported
+	case fm.keystoreKey:
+		return ErrRekeyKeystore
+	case fm.sharedCipher:
+		return ErrRekeySharedKey
+	}
+	return nil
+}
+
+// Close clears the key of the cipher in use; the file manager cannot
+// encrypt or decrypt afterwards
+func (fm *FileManager) Close() error {
+	if closer, ok := fm.currentCipher().(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// Rekey re-encrypts every remote object with a cipher built from newKey: each
+// object is downloaded, decrypted with the current key and uploaded again
+// encrypted with the new one, keeping its metadata. Objects that already
+// decrypt with the new key are skipped, so an interrupted or cancelled Rekey is
+// resumed by running it again with the same key. The manifest and the key
+// canary are re-encrypted last, once every file succeeded, and the file manager
+// then uses the new key and clears the old one; the setting returned by
+// KeySetting must be updated before the next start.
+func (fm *FileManager) Rekey(ctx context.Context, newKey string, progress func(done, total int)) (*BatchResult, error) {
+	if err := fm.CanRekey(); err != nil {
+		return nil, err
+	}
+	if newKey == "" {
+		return nil, fmt.Errorf("new crypto key is empty")
+	}
+
+	keys, err := fm.storage.List("")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list remote files: %w: %w", ErrStorage, err)
+	}
+	keys = withoutInternalKeys(keys)
+
+	cipherOptions, err := fm.config.CipherOptions()
+	if err != nil {
+		return nil, err
+	}
+	newCipher := crypto.NewAESGCM(newKey, cipherOptions...)
+	switched := false
+	defer func() {
+		if closer, ok := newCipher.(io.Closer); ok && !switched {
+			closer.Close()
+		}
+	}()
+
+	result := &BatchResult{}
+	for i, key := range keys {
+		if progress != nil {
+			progress(i, len(keys))
+		}
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		if isEmptyDirPlaceholder(key) {
+			// 空目录占位符没有加密内容
+			result.Skipped = append(result.Skipped, key)
+			continue
+		}
+
+		var rekeyed bool
+		err := fm.withFileTimeout(ctx, func(context.Context) (err error) {
+			rekeyed, err = fm.rekeyObject(key, newCipher)
+			return err
+		})
+		switch {
+		case err != nil:
+			fm.logger.Error("Failed to re-key file", slog.String("path", key), slog.String("error", err.Error()))
+			result.Failed = append(result.Failed, FileError{Path: key, Err: err})
+		case rekeyed:
+			result.Succeeded = append(result.Succeeded, key)
+		default:
+			result.Skipped = append(result.Skipped, key)
+		}
+	}
+	if progress != nil {
+		progress(len(keys), len(keys))
+	}
+
+	if len(result.Failed) > 0 {
+		// 清单和校验对象保留旧密钥，修复后用同一个新密钥再次执行即可继续
+		fm.logger.Warn("Re-key incomplete, run it again with the same key to resume",
+			slog.Int("failed", len(result.Failed)))
+		return result, nil
+	}
+	for _, key := range []string{ManifestKey, CanaryKey} {
+		if _, err := fm.rekeyObject(key, newCipher); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return result, fmt.Errorf("failed to re-key %s: %w", key, err)
+		}
+	}
+
+	fm.cipherMu.Lock()
+	oldCipher := fm.cipher
+	fm.cipher = newCipher
+	fm.cipherMu.Unlock()
+	switched = true
+	if closer, ok := oldCipher.(io.Closer); ok {
+		closer.Close()
+	}
+	fm.logger.Info("Re-key completed",
+		slog.Int("rekeyed", len(result.Succeeded)),
+		slog.Int("skipped", len(result.Skipped)))
+	return result, nil
+}
+
+// rekeyObject re-encrypts key with newCipher and reports whether it was
+// rewritten; an object that already decrypts with newCipher is left alone
+func (fm *FileManager) rekeyObject(key string, newCipher crypto.Cipher) (bool, error) {
+	encrypted, err := fm.storage.Download(key)
+	if err != nil {
+		return false, fmt.Errorf("failed to download file %s: %w: %w", key, ErrStorage, err)
+	}
+	plain, err := fm.currentCipher().Decrypt(encrypted)
+	if err != nil {
+		if _, newErr := newCipher.Decrypt(encrypted); newErr == nil {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to decrypt file %s: %w", key, err)
+	}
+	reencrypted, err := newCipher.Encrypt(plain)
+	if err != nil {
+		return false, fmt.Errorf("failed to encrypt file %s: %w", key, err)
+	}
+
+	// 保留上传时记录的元数据，清单中的大小和哈希因明文不变而无需更新
+	var meta map[string]string
+	if info, err := fm.storage.Stat(key); err == nil {
+		meta = info.Metadata
+	}
+	if uploader, ok := fm.storage.(storage.MetadataUploader); ok && len(meta) > 0 {
+		err = uploader.UploadWithMeta(key, reencrypted, meta)
+	} else {
+		err = fm.storage.Upload(key, reencrypted)
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to upload file %s: %w: %w", key, ErrStorage, err)
+	}
+	fm.logger.Debug("File re-keyed", slog.String("path", key))
+	return true, nil
+}
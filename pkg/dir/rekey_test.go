@@ -0,0 +1,154 @@
+package dir
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/mingregister/fers/pkg/crypto"
+	"github.com/mingregister/fers/pkg/storage"
+)
+
+// assertEncryptedWith checks that the remote object key decrypts with password and not with the others
+func assertEncryptedWith(t *testing.T, store storage.Client, key, password string, others ...string) {
+	t.Helper()
+	encrypted, err := store.Download(key)
+	if err != nil {
+		t.Fatalf("Failed to download %s: %v", key, err)
+	}
+	if _, err := crypto.NewAESGCM(password).Decrypt(encrypted); err != nil {
+		t.Errorf("%s should decrypt with %q: %v", key, password, err)
+	}
+	for _, other := range others {
+		if _, err := crypto.NewAESGCM(other).Decrypt(encrypted); !errors.Is(err, crypto.ErrDecrypt) {
+			t.Errorf("%s should not decrypt with %q, got %v", key, other, err)
+		}
+	}
+}
+
+func TestFileManager_Rekey(t *testing.T) {
+	fm, tempDir, mockStore := createTestFileManager(t)
+	writeLocalFiles(t, tempDir, "a.txt", "docs/b.txt")
+	if _, err := fm.SyncUpload(context.Background()); err != nil {
+		t.Fatalf("SyncUpload failed: %v", err)
+	}
+	if err := fm.CheckKey(); err != nil {
+		t.Fatalf("CheckKey failed: %v", err)
+	}
+	before, err := mockStore.Stat("a.txt")
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+
+	oldCipher := fm.cipher
+	var reports [][2]int
+	result, err := fm.Rekey(context.Background(), "new-password", func(done, total int) {
+		reports = append(reports, [2]int{done, total})
+	})
+	if err != nil {
+		t.Fatalf("Rekey failed: %v", err)
+	}
+	if !reflect.DeepEqual(result.Succeeded, []string{"a.txt", "docs/b.txt"}) || len(result.Failed) != 0 {
+		t.Errorf("Expected both files to be re-keyed, got %+v", result)
+	}
+	if last := reports[len(reports)-1]; last != [2]int{2, 2} {
+		t.Errorf("Expected final progress 2/2, got %v", last)
+	}
+
+	for _, key := range []string{"a.txt", "docs/b.txt", ManifestKey, CanaryKey} {
+		assertEncryptedWith(t, mockStore, key, "new-password", "test-password")
+	}
+	after, err := mockStore.Stat("a.txt")
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if after.Metadata[storage.MetaSHA256] != before.Metadata[storage.MetaSHA256] {
+		t.Errorf("Expected metadata to be kept, got %v", after.Metadata)
+	}
+
+	// 之后使用新密钥，旧密钥已清除
+	if err := fm.CheckKey(); err != nil {
+		t.Errorf("CheckKey should pass with the new key: %v", err)
+	}
+	if _, err := fm.LoadManifest(); err != nil {
+		t.Errorf("Manifest should load with the new key: %v", err)
+	}
+	if _, err := oldCipher.Encrypt([]byte("data")); !errors.Is(err, crypto.ErrCipherClosed) {
+		t.Errorf("Expected the old cipher to be closed, got %v", err)
+	}
+
+	if err := fm.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if _, err := fm.cipher.Encrypt([]byte("data")); !errors.Is(err, crypto.ErrCipherClosed) {
+		t.Errorf("Expected Close to clear the new key, got %v", err)
+	}
+}
+
+func TestFileManager_RekeyResumes(t *testing.T) {
+	fm, tempDir, mockStore := createTestFileManager(t)
+	writeLocalFiles(t, tempDir, "a.txt", "b.txt", "c.txt")
+	if _, err := fm.SyncUpload(context.Background()); err != nil {
+		t.Fatalf("SyncUpload failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	fm.storage = &interruptingStorage{Client: mockStore, limit: 1, cancel: cancel}
+	result, err := fm.Rekey(ctx, "new-password", nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected the re-key to be cancelled, got %v", err)
+	}
+	if !reflect.DeepEqual(result.Succeeded, []string{"a.txt"}) {
+		t.Errorf("Expected only a.txt to be re-keyed before the interruption, got %+v", result)
+	}
+	assertEncryptedWith(t, mockStore, ManifestKey, "test-password", "new-password")
+
+	fm.storage = mockStore
+	result, err = fm.Rekey(context.Background(), "new-password", nil)
+	if err != nil {
+		t.Fatalf("Resumed Rekey failed: %v", err)
+	}
+	if !reflect.DeepEqual(result.Skipped, []string{"a.txt"}) || !reflect.DeepEqual(result.Succeeded, []string{"b.txt", "c.txt"}) {
+		t.Errorf("Expected a.txt to be skipped and the rest re-keyed, got %+v", result)
+	}
+	for _, key := range []string{"a.txt", "b.txt", "c.txt", ManifestKey} {
+		assertEncryptedWith(t, mockStore, key, "new-password", "test-password")
+	}
+}
+
+func TestFileManager_RekeyObfuscatedKeys(t *testing.T) {
+	fm, _, _ := createTestFileManager(t)
+	fm.obfuscateKeys = true
+
+	if _, err := fm.Rekey(context.Background(), "new-password", nil); !errors.Is(err, ErrRekeyNotSupported) {
+		t.Errorf("Expected ErrRekeyNotSupported, got %v", err)
+	}
+}
+
+func TestFileManager_RekeySharedKey(t *testing.T) {
+	fm, tempDir, mockStore := createTestFileManager(t)
+	writeLocalFiles(t, tempDir, "a.txt")
+	if _, err := fm.SyncUpload(context.Background()); err != nil {
+		t.Fatalf("SyncUpload failed: %v", err)
+	}
+	fm.SetSharedCipher(true)
+
+	if _, err := fm.Rekey(context.Background(), "new-password", nil); !errors.Is(err, ErrRekeySharedKey) {
+		t.Errorf("Expected ErrRekeySharedKey, got %v", err)
+	}
+	assertEncryptedWith(t, mockStore, "a.txt", "test-password", "new-password")
+}
+
+func TestFileManager_RekeyKeystore(t *testing.T) {
+	fm, _, _ := createTestFileManager(t)
+	fm.SetKeystoreKey(true)
+
+	if err := fm.CanRekey(); !errors.Is(err, ErrRekeyKeystore) {
+		t.Errorf("Expected CanRekey to report ErrRekeyKeystore, got %v", err)
+	}
+	if _, err := fm.Rekey(context.Background(), "new-password", nil); !errors.Is(err, ErrRekeyKeystore) {
+		t.Errorf("Expected ErrRekeyKeystore, got %v", err)
+	}
+}
@@ -0,0 +1,145 @@
+package dir
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/mingregister/fers/pkg/storage"
+)
+
+// partSuffix marks the partial downloads kept to resume interrupted transfers
+const partSuffix = ".fers-part"
+
+// downloadAttempts is how often DownloadResumable opens the stream before giving up
+const downloadAttempts = 3
+
+// partPath returns the hidden partial download file next to localPath
+func partPath(localPath string) string {
+	return filepath.Join(filepath.Dir(localPath), "."+filepath.Base(localPath)+partSuffix)
+}
+
+// DownloadResumable downloads and decrypts remotePath into localPath. The
+// encrypted object is streamed into a partial file next to localPath; when
+// the stream drops, or a previous call was interrupted, the download resumes
+// from the bytes already written. The complete file is decrypted, checked
+// against the SHA-256 stored with the object and written atomically.
+// progress, if not nil, is called with the encrypted bytes received so far.
+func (fm *FileManager) DownloadResumable(ctx context.Context, remotePath, localPath string, progress func(written int64)) error {
+	if isEmptyDirPlaceholder(remotePath) {
+		return fm.DownloadAndDecryptFile(remotePath, localPath)
+	}
+
+	dir := filepath.Dir(localPath)
+	if err := os.MkdirAll(dir, defaultDirMode); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w: %w", dir, ErrLocalIO, err)
+	}
+	part := partPath(localPath)
+	if err := fm.downloadPart(ctx, remotePath, part, progress); err != nil {
+		return err
+	}
+
+	encrypted, err := os.ReadFile(part)
+	if err != nil {
+		return fmt.Errorf("failed to read partial download %s: %w: %w", part, ErrLocalIO, err)
+	}
+	plain, err := fm.currentCipher().Decrypt(encrypted)
+	if err != nil {
+		// 对象在两次下载之间被替换时拼出的数据无法解密，丢弃后下次重新下载
+		os.Remove(part)
+		return fmt.Errorf("failed to decrypt file %s: %w", remotePath, err)
+	}
+	if info, err := fm.storage.Stat(remotePath); err == nil {
+		sum := sha256.Sum256(plain)
+		if want := info.Metadata[storage.MetaSHA256]; want != "" && want != hex.EncodeToString(sum[:]) {
+			os.Remove(part)
+			return fmt.Errorf("downloaded file %s: %w", remotePath, ErrHashMismatch)
+		}
+	}
+
+	if err := writeFileAtomic(localPath, plain, defaultFileMode); err != nil {
+		return fmt.Errorf("failed to write file %s: %w: %w", localPath, ErrLocalIO, err)
+	}
+	os.Remove(part)
+	fm.logger.Info("File downloaded and decrypted successfully", slog.String("path", localPath))
+	return nil
+}
+
+// downloadPart appends the encrypted object to the partial file from its
+// current size, reopening the stream where it stopped when it drops
+func (fm *FileManager) downloadPart(ctx context.Context, remotePath, part string, progress func(written int64)) error {
+	f, err := os.OpenFile(part, os.O_CREATE|os.O_WRONLY, defaultFileMode)
+	if err != nil {
+		return fmt.Errorf("failed to open partial download %s: %w: %w", part, ErrLocalIO, err)
+	}
+	defer f.Close()
+	offset, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return fmt.Errorf("failed to open partial download %s: %w: %w", part, ErrLocalIO, err)
+	}
+	if offset > 0 {
+		fm.logger.Info("Resuming download", slog.String("path", remotePath), slog.Int64("offset", offset))
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < downloadAttempts; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		stream, err := storage.DownloadStreamRange(ctx, fm.storage, remotePath, offset)
+		if errors.Is(err, storage.ErrInvalidRange) && offset > 0 {
+			// 远程对象比已下载的部分还短，说明已被替换，从头开始
+			fm.logger.Warn("Partial download is longer than the remote file, restarting", slog.String("path", remotePath))
+			if offset, err = 0, f.Truncate(0); err == nil {
+				_, err = f.Seek(0, io.SeekStart)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to reset partial download %s: %w: %w", part, ErrLocalIO, err)
+			}
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to download file %s: %w: %w", remotePath, ErrStorage, err)
+		}
+
+		written, err := io.Copy(f, &progressReader{ctx: ctx, r: stream, read: offset, progress: progress})
+		stream.Close()
+		offset += written
+		if err == nil {
+			return f.Sync()
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		lastErr = err
+		fm.logger.Warn("Download interrupted, resuming",
+			slog.String("path", remotePath), slog.Int64("offset", offset), slog.String("error", err.Error()))
+	}
+	return fmt.Errorf("failed to download file %s: %w: %w", remotePath, ErrStorage, lastErr)
+}
+
+// progressReader reports the bytes read from r and stops once ctx is done
+type progressReader struct {
+	ctx      context.Context
+	r        io.Reader
+	read     int64
+	progress func(read int64)
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	if err := p.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := p.r.Read(b)
+	p.read += int64(n)
+	if n > 0 && p.progress != nil {
+		p.progress(p.read)
+	}
+	return n, err
+}
@@ -0,0 +1,199 @@
+package dir
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mingregister/fers/pkg/storage"
+)
+
+// droppingStorage streams at most limit bytes of each download before failing,
+// like a connection that drops midway; offsets records where each stream started
+type droppingStorage struct {
+	storage.Client
+	limit   int64
+	drops   int
+	offsets []int64
+}
+
+var errConnectionReset = errors.New("connection reset by peer")
+
+func (s *droppingStorage) DownloadStreamRange(ctx context.Context, key string, offset int64) (io.ReadCloser, error) {
+	s.offsets = append(s.offsets, offset)
+	stream, err := storage.DownloadStreamRange(ctx, s.Client, key, offset)
+	if err != nil || s.drops == 0 {
+		return stream, err
+	}
+	s.drops--
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(io.LimitReader(stream, s.limit), failingReader{}), stream}, nil
+}
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) { return 0, errConnectionReset }
+
+// uploadForResume uploads content as name and returns the encrypted object
+func uploadForResume(t *testing.T, fm *FileManager, tempDir, name, content string) []byte {
+	t.Helper()
+	src := filepath.Join(tempDir, name)
+	if err := os.WriteFile(src, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+	if err := fm.EncryptAndUploadFile(src, name); err != nil {
+		t.Fatalf("EncryptAndUploadFile failed: %v", err)
+	}
+	encrypted, err := fm.storage.Download(name)
+	if err != nil {
+		t.Fatalf("Download failed: %v", err)
+	}
+	return encrypted
+}
+
+func TestDownloadResumable_ResumesFromPartialFile(t *testing.T) {
+	fm, tempDir, _ := createTestFileManager(t)
+	content := strings.Repeat("resumable content ", 100)
+	encrypted := uploadForResume(t, fm, tempDir, "big.txt", content)
+
+	// 模拟上次中断时已写入的一半密文
+	localPath := filepath.Join(tempDir, "restore", "big.txt")
+	if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	half := int64(len(encrypted) / 2)
+	if err := os.WriteFile(partPath(localPath), encrypted[:half], 0644); err != nil {
+		t.Fatalf("Failed to write partial file: %v", err)
+	}
+
+	dropping := &droppingStorage{Client: fm.storage}
+	fm.storage = dropping
+	var reported int64
+	err := fm.DownloadResumable(context.Background(), "big.txt", localPath, func(written int64) { reported = written })
+	if err != nil {
+		t.Fatalf("DownloadResumable failed: %v", err)
+	}
+
+	if data, _ := os.ReadFile(localPath); string(data) != content {
+		t.Errorf("Expected the full file to be reconstructed, got %d bytes", len(data))
+	}
+	if len(dropping.offsets) != 1 || dropping.offsets[0] != half {
+		t.Errorf("Expected a single stream from offset %d, got %v", half, dropping.offsets)
+	}
+	if reported != int64(len(encrypted)) {
+		t.Errorf("Expected progress up to %d bytes, got %d", len(encrypted), reported)
+	}
+	if _, err := os.Stat(partPath(localPath)); !os.IsNotExist(err) {
+		t.Errorf("Partial file should be removed, got %v", err)
+	}
+}
+
+func TestDownloadResumable_RetriesDroppedStream(t *testing.T) {
+	fm, tempDir, _ := createTestFileManager(t)
+	content := strings.Repeat("flaky network ", 200)
+	uploadForResume(t, fm, tempDir, "flaky.txt", content)
+
+	dropping := &droppingStorage{Client: fm.storage, limit: 1000, drops: 2}
+	fm.storage = dropping
+	localPath := filepath.Join(tempDir, "restore", "flaky.txt")
+	if err := fm.DownloadResumable(context.Background(), "flaky.txt", localPath, nil); err != nil {
+		t.Fatalf("DownloadResumable failed: %v", err)
+	}
+
+	if data, _ := os.ReadFile(localPath); string(data) != content {
+		t.Errorf("Expected the full file after retries, got %d bytes", len(data))
+	}
+	want := []int64{0, 1000, 2000}
+	if len(dropping.offsets) != len(want) {
+		t.Fatalf("Expected streams from %v, got %v", want, dropping.offsets)
+	}
+	for i := range want {
+		if dropping.offsets[i] != want[i] {
+			t.Errorf("Expected streams from %v, got %v", want, dropping.offsets)
+			break
+		}
+	}
+}
+
+func TestDownloadResumable_KeepsPartialFileWhenGivingUp(t *testing.T) {
+	fm, tempDir, _ := createTestFileManager(t)
+	content := strings.Repeat("x", 10000)
+	uploadForResume(t, fm, tempDir, "gone.txt", content)
+
+	fm.storage = &droppingStorage{Client: fm.storage, limit: 100, drops: downloadAttempts}
+	localPath := filepath.Join(tempDir, "restore", "gone.txt")
+	err := fm.DownloadResumable(context.Background(), "gone.txt", localPath, nil)
+	if !errors.Is(err, ErrStorage) || !errors.Is(err, errConnectionReset) {
+		t.Fatalf("Expected a storage error, got %v", err)
+	}
+	info, err := os.Stat(partPath(localPath))
+	if err != nil || info.Size() != 100*downloadAttempts {
+		t.Errorf("Expected the partial file to keep %d bytes, got %v (%v)", 100*downloadAttempts, info, err)
+	}
+	if _, err := os.Stat(localPath); !os.IsNotExist(err) {
+		t.Errorf("Local file should not be written, got %v", err)
+	}
+
+	// 网络恢复后从已下载的位置继续
+	fm.storage = fm.storage.(*droppingStorage).Client
+	if err := fm.DownloadResumable(context.Background(), "gone.txt", localPath, nil); err != nil {
+		t.Fatalf("DownloadResumable failed: %v", err)
+	}
+	if data, _ := os.ReadFile(localPath); string(data) != content {
+		t.Errorf("Expected the full file after resuming, got %d bytes", len(data))
+	}
+}
+
+func TestDownloadResumable_RestartsWhenPartialFileIsTooLong(t *testing.T) {
+	fm, tempDir, _ := createTestFileManager(t)
+	encrypted := uploadForResume(t, fm, tempDir, "short.txt", "short")
+
+	localPath := filepath.Join(tempDir, "short-copy.txt")
+	stale := append(append([]byte{}, encrypted...), []byte("from a longer, replaced object")...)
+	if err := os.WriteFile(partPath(localPath), stale, 0644); err != nil {
+		t.Fatalf("Failed to write partial file: %v", err)
+	}
+
+	if err := fm.DownloadResumable(context.Background(), "short.txt", localPath, nil); err != nil {
+		t.Fatalf("DownloadResumable failed: %v", err)
+	}
+	if data, _ := os.ReadFile(localPath); string(data) != "short" {
+		t.Errorf("Expected %q, got %q", "short", data)
+	}
+}
+
+func TestDownloadResumable_HashMismatch(t *testing.T) {
+	fm, tempDir, store := createTestFileManager(t)
+	uploadForResume(t, fm, tempDir, "doc.txt", "original")
+
+	// 替换内容但保留旧的哈希元数据
+	info, err := store.Stat("doc.txt")
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	tampered, err := fm.cipher.Encrypt([]byte("tampered"))
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	if err := store.(storage.MetadataUploader).UploadWithMeta("doc.txt", tampered, info.Metadata); err != nil {
+		t.Fatalf("UploadWithMeta failed: %v", err)
+	}
+
+	localPath := filepath.Join(tempDir, "restore", "doc.txt")
+	err = fm.DownloadResumable(context.Background(), "doc.txt", localPath, nil)
+	if !errors.Is(err, ErrHashMismatch) {
+		t.Fatalf("Expected ErrHashMismatch, got %v", err)
+	}
+	if _, err := os.Stat(localPath); !os.IsNotExist(err) {
+		t.Errorf("Local file should not be written, got %v", err)
+	}
+	if _, err := os.Stat(partPath(localPath)); !os.IsNotExist(err) {
+		t.Errorf("Partial file should be removed after a mismatch, got %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+package dir
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultSyncInterval is used when no scheduled sync interval is configured
+const DefaultSyncInterval = 15 * time.Minute
+
+// SyncMode selects what a scheduled sync run does
+type SyncMode string
+
+const (
+	SyncModeUpload   SyncMode = "upload"   // 只上传远程缺失的本地文件
+	SyncModeDownload SyncMode = "download" // 只下载本地缺失的远程文件
+	SyncModeBoth     SyncMode = "both"     // 先下载再上传
+)
+
+// ParseSyncMode parses a configured sync mode, defaulting to SyncModeUpload
+func ParseSyncMode(s string) (SyncMode, error) {
+	switch mode := SyncMode(s); mode {
+	case "":
+		return SyncModeUpload, nil
+	case SyncModeUpload, SyncModeDownload, SyncModeBoth:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown sync mode %q", s)
+	}
+}
+
+// Ticker delivers the ticks that trigger scheduled syncs
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// timeTicker is the time.Ticker based Ticker
+type timeTicker struct {
+	t *time.Ticker
+}
+
+func newTimeTicker(d time.Duration) Ticker {
+	return &timeTicker{t: time.NewTicker(d)}
+}
+
+func (t *timeTicker) C() <-chan time.Time { return t.t.C }
+func (t *timeTicker) Stop()               { t.t.Stop() }
+
+// StartScheduledSync runs a sync in the given mode every interval until ctx
+// is cancelled. A tick is skipped while the previous run is still in progress.
+func (fm *FileManager) StartScheduledSync(ctx context.Context, interval time.Duration, mode SyncMode) error {
+	if interval <= 0 {
+		return fmt.Errorf("invalid sync interval %s", interval)
+	}
+	if _, err := ParseSyncMode(string(mode)); err != nil {
+		return err
+	}
+
+	newTicker := fm.newTicker
+	if newTicker == nil {
+		newTicker = newTimeTicker
+	}
+	ticker := newTicker(interval)
+
+	fm.logger.Info("Scheduled sync started", slog.String("interval", interval.String()), slog.String("mode", string(mode)))
+	go fm.runScheduledSync(ctx, ticker, mode)
+	return nil
+}
+
+// runScheduledSync starts a sync run on each tick unless one is already running
+func (fm *FileManager) runScheduledSync(ctx context.Context, ticker Ticker, mode SyncMode) {
+	defer ticker.Stop()
+
+	var running atomic.Bool
+	for {
+		select {
+		case <-ctx.Done():
+			fm.logger.Info("Scheduled sync stopped")
+			return
+		case <-ticker.C():
+			if !running.CompareAndSwap(false, true) {
+				fm.logger.Warn("Previous scheduled sync still running, skipping this run")
+				continue
+			}
+			go func() {
+				defer running.Store(false)
+				fm.scheduledSyncOnce(ctx, mode)
+			}()
+		}
+	}
+}
+
+// scheduledSyncOnce performs one sync run and logs its summary
+func (fm *FileManager) scheduledSyncOnce(ctx context.Context, mode SyncMode) {
+	start := time.Now()
+	before := fm.Stats()
+	var downloaded, uploaded, failed int
+
+	if mode == SyncModeDownload || mode == SyncModeBoth {
+		missing, err := fm.PlanSyncDownload(ctx)
+		if err != nil {
+			fm.logger.Error("Scheduled sync download failed", slog.String("error", err.Error()))
+			return
+		}
+		result, err := fm.downloadMissing(ctx, missing)
+		downloaded, failed = len(result.Succeeded), failed+len(result.Failed)
+		if err != nil {
+			fm.logger.Error("Scheduled sync download failed", slog.String("error", err.Error()))
+			return
+		}
+	}
+
+	if mode == SyncModeUpload || mode == SyncModeBoth {
+		plan, err := fm.PlanSyncUpload(ctx)
+		if err != nil {
+			fm.logger.Error("Scheduled sync upload failed", slog.String("error", err.Error()))
+			return
+		}
+		result, err := fm.uploadMissing(ctx, plan.Files)
+		uploaded, failed = len(result.Succeeded), failed+len(result.Failed)
+		if err != nil {
+			fm.logger.Error("Scheduled sync upload failed", slog.String("error", err.Error()))
+			return
+		}
+	}
+
+	fm.logger.Info("Scheduled sync finished",
+		slog.String("mode", string(mode)),
+		slog.Int("downloaded", downloaded),
+		slog.Int("uploaded", uploaded),
+		slog.Int("failed", failed),
+		slog.String("upload_rate", FormatRate(fm.Stats().Sub(before))),
+		slog.String("duration", time.Since(start).Round(time.Millisecond).String()))
+}
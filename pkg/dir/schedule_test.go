@@ -0,0 +1,192 @@
+package dir
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mingregister/fers/pkg/storage"
+)
+
+// fakeTicker is a Ticker whose ticks are sent by the test
+type fakeTicker struct {
+	ch      chan time.Time
+	stopped atomic.Bool
+}
+
+func (f *fakeTicker) C() <-chan time.Time { return f.ch }
+func (f *fakeTicker) Stop()               { f.stopped.Store(true) }
+
+// blockingStorage blocks every List call until the test releases it
+type blockingStorage struct {
+	storage.Client
+	lists   atomic.Int32
+	release chan struct{}
+}
+
+func (b *blockingStorage) List(prefix string) ([]string, error) {
+	b.lists.Add(1)
+	<-b.release
+	return b.Client.List(prefix)
+}
+
+// Upload rejects the manifest so every run lists the remote and is counted
+func (b *blockingStorage) Upload(key string, data []byte) error {
+	if key == ManifestKey {
+		return errors.New("manifest disabled in test")
+	}
+	return b.Client.Upload(key, data)
+}
+
+func startFakeScheduledSync(t *testing.T, fm *FileManager, mode SyncMode) *fakeTicker {
+	t.Helper()
+	ticker := &fakeTicker{ch: make(chan time.Time)}
+	fm.newTicker = func(time.Duration) Ticker { return ticker }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	if err := fm.StartScheduledSync(ctx, time.Minute, mode); err != nil {
+		t.Fatalf("StartScheduledSync failed: %v", err)
+	}
+	return ticker
+}
+
+func TestParseSyncMode(t *testing.T) {
+	tests := map[string]SyncMode{
+		"":         SyncModeUpload,
+		"upload":   SyncModeUpload,
+		"download": SyncModeDownload,
+		"both":     SyncModeBoth,
+	}
+	for input, want := range tests {
+		got, err := ParseSyncMode(input)
+		if err != nil || got != want {
+			t.Errorf("ParseSyncMode(%q) = %q, %v; want %q", input, got, err, want)
+		}
+	}
+
+	if _, err := ParseSyncMode("mirror"); err == nil {
+		t.Error("Expected error for unknown sync mode")
+	}
+}
+
+func TestFileManager_StartScheduledSync_InvalidArgs(t *testing.T) {
+	fm, _, _ := createTestFileManager(t)
+
+	if err := fm.StartScheduledSync(context.Background(), 0, SyncModeUpload); err == nil {
+		t.Error("Expected error for zero interval")
+	}
+	if err := fm.StartScheduledSync(context.Background(), time.Minute, "mirror"); err == nil {
+		t.Error("Expected error for unknown mode")
+	}
+}
+
+func TestFileManager_ScheduledSyncRunsOnTick(t *testing.T) {
+	fm, tempDir, store := createTestFileManager(t)
+	if err := os.WriteFile(filepath.Join(tempDir, "local.txt"), []byte("local"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	encrypted, err := fm.cipher.Encrypt([]byte("remote"))
+	if err != nil {
+		t.Fatalf("Failed to encrypt: %v", err)
+	}
+	mustUpload(t, store, "remote.txt", encrypted)
+
+	ticker := startFakeScheduledSync(t, fm, SyncModeBoth)
+	ticker.ch <- time.Now()
+
+	waitForRemote(t, store, "local.txt")
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, err := os.Stat(filepath.Join(tempDir, "remote.txt")); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected remote.txt to be downloaded")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestFileManager_ScheduledSyncSkipsOverlap(t *testing.T) {
+	fm, _, store := createTestFileManager(t)
+	blocking := &blockingStorage{Client: store, release: make(chan struct{})}
+	fm.storage = blocking
+
+	ticker := startFakeScheduledSync(t, fm, SyncModeUpload)
+
+	ticker.ch <- time.Now()
+	waitForLists := func(want int32) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for blocking.lists.Load() != want {
+			if time.Now().After(deadline) {
+				t.Fatalf("Expected %d sync runs, got %d", want, blocking.lists.Load())
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+	waitForLists(1)
+
+	// 上一次同步仍在运行，这些触发应被跳过
+	ticker.ch <- time.Now()
+	ticker.ch <- time.Now()
+	if got := blocking.lists.Load(); got != 1 {
+		t.Fatalf("Overlapping ticks should be skipped, got %d runs", got)
+	}
+
+	blocking.release <- struct{}{}
+
+	// 上一次结束后，下一次触发重新运行
+	deadline := time.Now().Add(2 * time.Second)
+	for blocking.lists.Load() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected a new run after the previous one finished")
+		}
+		select {
+		case ticker.ch <- time.Now():
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+	close(blocking.release)
+}
+
+func TestFileManager_ScheduledSyncStopsOnCancel(t *testing.T) {
+	fm, _, _ := createTestFileManager(t)
+	ticker := &fakeTicker{ch: make(chan time.Time)}
+	fm.newTicker = func(time.Duration) Ticker { return ticker }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := fm.StartScheduledSync(ctx, time.Minute, SyncModeUpload); err != nil {
+		t.Fatalf("StartScheduledSync failed: %v", err)
+	}
+	cancel()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !ticker.stopped.Load() {
+		if time.Now().After(deadline) {
+			t.Fatal("Ticker should be stopped after cancellation")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestFileManager_SyncSchedule(t *testing.T) {
+	fm, _, _ := createTestFileManager(t)
+
+	interval, mode, err := fm.SyncSchedule()
+	if err != nil || interval != DefaultSyncInterval || mode != SyncModeUpload {
+		t.Errorf("Expected defaults, got %s %q %v", interval, mode, err)
+	}
+
+	fm.config.Sync.Interval = 5 * time.Minute
+	fm.config.Sync.Mode = "both"
+	interval, mode, err = fm.SyncSchedule()
+	if err != nil || interval != 5*time.Minute || mode != SyncModeBoth {
+		t.Errorf("Expected configured schedule, got %s %q %v", interval, mode, err)
+	}
+}
@@ -0,0 +1,69 @@
+package dir
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// RemoteFileKeys returns every remote file key, from the manifest when it is
+// fresh so that repeated searches do not list the whole remote
+func (fm *FileManager) RemoteFileKeys(ctx context.Context) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	keys, _, err := fm.remoteKeys()
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if !isEmptyDirPlaceholder(key) {
+			files = append(files, key)
+		}
+	}
+	return files, nil
+}
+
+// SearchRemote returns the remote file keys matching query, see MatchKeys
+func (fm *FileManager) SearchRemote(ctx context.Context, query string) ([]string, error) {
+	keys, err := fm.RemoteFileKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return MatchKeys(keys, query)
+}
+
+// MatchKeys returns the keys matching query, ignoring case. A query with glob
+// characters (*, ? or [) is a path.Match pattern, matched against the file
+// name unless it contains "/"; any other query matches keys containing it.
+func MatchKeys(keys []string, query string) ([]string, error) {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return nil, nil
+	}
+	glob := strings.ContainsAny(query, "*?[")
+	if glob {
+		if _, err := path.Match(query, ""); err != nil {
+			return nil, fmt.Errorf("invalid search pattern %q: %w", query, err)
+		}
+	}
+
+	var matches []string
+	for _, key := range keys {
+		lower := strings.ToLower(key)
+		matched := strings.Contains(lower, query)
+		if glob {
+			target := lower
+			if !strings.Contains(query, "/") {
+				target = path.Base(lower)
+			}
+			matched, _ = path.Match(query, target)
+		}
+		if matched {
+			matches = append(matches, key)
+		}
+	}
+	return matches, nil
+}
@@ -0,0 +1,68 @@
+package dir
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+var searchTestKeys = []string{
+	"invoice-2021.pdf",
+	"finance/Invoice-2022.PDF",
+	"finance/2021/receipts.zip",
+	"photos/2021/beach.jpg",
+	"notes.txt",
+}
+
+func TestMatchKeys(t *testing.T) {
+	testCases := []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{name: "substring ignores case", query: "INVOICE", expected: []string{"invoice-2021.pdf", "finance/Invoice-2022.PDF"}},
+		{name: "substring matches folders", query: "2021", expected: []string{"invoice-2021.pdf", "finance/2021/receipts.zip", "photos/2021/beach.jpg"}},
+		{name: "glob on file name", query: "*.pdf", expected: []string{"invoice-2021.pdf", "finance/Invoice-2022.PDF"}},
+		{name: "glob with single character", query: "invoice-202?.pdf", expected: []string{"invoice-2021.pdf", "finance/Invoice-2022.PDF"}},
+		{name: "glob on path", query: "finance/*/*", expected: []string{"finance/2021/receipts.zip"}},
+		{name: "glob character class", query: "[bn]*", expected: []string{"photos/2021/beach.jpg", "notes.txt"}},
+		{name: "no match", query: "missing", expected: nil},
+		{name: "empty query", query: "  ", expected: nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			matches, err := MatchKeys(searchTestKeys, tc.query)
+			if err != nil {
+				t.Fatalf("MatchKeys failed: %v", err)
+			}
+			if !reflect.DeepEqual(matches, tc.expected) {
+				t.Errorf("MatchKeys(%q) = %v, expected %v", tc.query, matches, tc.expected)
+			}
+		})
+	}
+
+	if _, err := MatchKeys(searchTestKeys, "[invalid"); err == nil {
+		t.Error("Expected an error for an invalid pattern")
+	}
+}
+
+func TestFileManager_SearchRemoteUsesManifest(t *testing.T) {
+	fm, tempDir, store := newManifestTestFileManager(t)
+	writeLocalFiles(t, tempDir, "invoice-2021.pdf", "docs/invoice-2022.pdf", "docs/notes.txt")
+	if _, err := fm.SyncUpload(context.Background()); err != nil {
+		t.Fatalf("SyncUpload failed: %v", err)
+	}
+
+	lists := store.lists.Load()
+	matches, err := fm.SearchRemote(context.Background(), "invoice")
+	if err != nil {
+		t.Fatalf("SearchRemote failed: %v", err)
+	}
+	if expected := []string{"docs/invoice-2022.pdf", "invoice-2021.pdf"}; !reflect.DeepEqual(matches, expected) {
+		t.Errorf("Expected %v, got %v", expected, matches)
+	}
+	if store.lists.Load() != lists {
+		t.Error("Searching with a fresh manifest should not list the remote")
+	}
+}
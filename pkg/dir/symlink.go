@@ -0,0 +1,206 @@
+package dir
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+
+	"github.com/mingregister/fers/pkg/storage"
+)
+
+// SymlinkMode selects how uploads and syncs treat symbolic links in the working directory
+type SymlinkMode string
+
+const (
+	SymlinkSkip   SymlinkMode = "skip"   // 忽略符号链接
+	SymlinkFollow SymlinkMode = "follow" // 上传链接目标的内容，指向目录时递归进入
+	SymlinkStore  SymlinkMode = "store"  // 只上传链接目标路径，下载时重新创建链接
+)
+
+// ParseSymlinkMode parses a configured symlink mode, defaulting to SymlinkSkip
+func ParseSymlinkMode(s string) (SymlinkMode, error) {
+	switch mode := SymlinkMode(s); mode {
+	case "":
+		return SymlinkSkip, nil
+	case SymlinkSkip, SymlinkFollow, SymlinkStore:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown symlink mode %q", s)
+	}
+}
+
+// SymlinkMode returns how symbolic links are treated
+func (fm *FileManager) SymlinkMode() SymlinkMode {
+	return fm.symlinkMode
+}
+
+// SetSymlinkMode changes how symbolic links are treated
+func (fm *FileManager) SetSymlinkMode(mode SymlinkMode) {
+	fm.symlinkMode = mode
+}
+
+// isSymlink reports whether info describes a symbolic link
+func isSymlink(info os.FileInfo) bool {
+	return info.Mode()&os.ModeSymlink != 0
+}
+
+// walk is filepath.Walk over root, which is followed if it is a link, with the
+// links below it treated according to the symlink mode: skipped, followed
+// under their own path, or passed to fn as links to be stored. Following
+// never enters a directory that is already being walked, so link loops end.
+func (fm *FileManager) walk(root string, fn filepath.WalkFunc) error {
+	info, err := os.Stat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		err = fm.walkPath(root, info, fn, nil)
+	}
+	if err == filepath.SkipDir || err == filepath.SkipAll {
+		return nil
+	}
+	return err
+}
+
+// walkPath walks path, described by its Lstat info; ancestors are the real
+// paths of the directories being walked
+func (fm *FileManager) walkPath(path string, info os.FileInfo, fn filepath.WalkFunc, ancestors []string) error {
+	if isSymlink(info) {
+		switch fm.symlinkMode {
+		case SymlinkStore:
+			return fn(path, info, nil)
+		case SymlinkFollow:
+			target, err := os.Stat(path)
+			if err != nil {
+				fm.logger.Warn("Skipping broken symlink", slog.String("path", path), slog.String("error", err.Error()))
+				return nil
+			}
+			info = target
+		default:
+			fm.logger.Debug("Skipping symlink", slog.String("path", path))
+			return nil
+		}
+	}
+	if !info.IsDir() {
+		return fn(path, info, nil)
+	}
+
+	realPath, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return fn(path, info, err)
+	}
+	if slices.Contains(ancestors, realPath) {
+		fm.logger.Warn("Skipping symlink loop", slog.String("path", path), slog.String("target", realPath))
+		return nil
+	}
+
+	if err := fn(path, info, nil); err != nil {
+		if err == filepath.SkipDir {
+			return nil
+		}
+		return err
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		if err := fn(path, info, err); err != nil && err != filepath.SkipDir {
+			return err
+		}
+		return nil
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+
+	ancestors = append(ancestors, realPath)
+	for _, name := range names {
+		childPath := filepath.Join(path, name)
+		childInfo, err := os.Lstat(childPath)
+		if err != nil {
+			err = fn(childPath, nil, err)
+		} else {
+			err = fm.walkPath(childPath, childInfo, fn, ancestors)
+		}
+		if err == filepath.SkipDir {
+			// 文件返回 SkipDir 时跳过所在目录的其余项目
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// uploadSymlink uploads the encrypted target of the link at linkPath and
+// records it in the manifest, so downloads can recreate the link
+func (fm *FileManager) uploadSymlink(linkPath, relativePath string) error {
+	target, err := os.Readlink(linkPath)
+	if err != nil {
+		return fmt.Errorf("failed to read link %s: %w: %w", linkPath, ErrLocalIO, err)
+	}
+	encrypted, err := fm.currentCipher().Encrypt([]byte(target))
+	if err != nil {
+		return fmt.Errorf("failed to encrypt link %s: %w", linkPath, err)
+	}
+
+	key := storage.NormalizeKey(relativePath)
+	if uploader, ok := fm.storage.(storage.MetadataUploader); ok {
+		// 只标记为链接，目标路径只保存在加密的内容和清单中
+		err = uploader.UploadWithMeta(key, encrypted, map[string]string{storage.MetaSymlink: "true"})
+	} else {
+		err = fm.storage.Upload(key, encrypted)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to upload link %s: %w: %w", relativePath, ErrStorage, err)
+	}
+	fm.changeManifest(manifestPut(key, ManifestEntry{Size: int64(len(target)), Link: target}))
+
+	fm.logger.Info("Symlink uploaded successfully", slog.String("path", relativePath), slog.String("target", target))
+	return nil
+}
+
+// remoteLinkTarget downloads and decrypts the target of a link uploaded by uploadSymlink
+func (fm *FileManager) remoteLinkTarget(key string) (string, error) {
+	encrypted, err := fm.storage.Download(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to download link %s: %w: %w", key, ErrStorage, err)
+	}
+	target, err := fm.currentCipher().Decrypt(encrypted)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt link %s: %w", key, err)
+	}
+	return string(target), nil
+}
+
+// manifestLinks returns the link targets recorded in the manifest by key;
+// without a readable manifest no links are known
+func (fm *FileManager) manifestLinks() map[string]string {
+	m, err := fm.LoadManifest()
+	if err != nil {
+		return nil
+	}
+	links := make(map[string]string)
+	for key, entry := range m.Files {
+		if entry.Link != "" {
+			links[key] = entry.Link
+		}
+	}
+	return links
+}
+
+// restoreSymlink creates a link to target at localPath
+func (fm *FileManager) restoreSymlink(target, localPath string) error {
+	dir := filepath.Dir(localPath)
+	if err := os.MkdirAll(dir, defaultDirMode); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w: %w", dir, ErrLocalIO, err)
+	}
+	if err := os.Symlink(target, localPath); err != nil {
+		return fmt.Errorf("failed to create link %s: %w: %w", localPath, ErrLocalIO, err)
+	}
+	fm.logger.Info("Symlink restored", slog.String("path", localPath), slog.String("target", target))
+	return nil
+}
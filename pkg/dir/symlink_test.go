@@ -0,0 +1,158 @@
+package dir
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// mustSymlink creates a link to target at link, skipping the test where symlinks are not supported
+func mustSymlink(t *testing.T, target, link string) {
+	t.Helper()
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+}
+
+// newSymlinkTree creates a.txt, a link to it and a link to the real/ directory under root
+func newSymlinkTree(t *testing.T, root string) {
+	t.Helper()
+	writeLocalFiles(t, root, "a.txt", "real/b.txt")
+	mustSymlink(t, "a.txt", filepath.Join(root, "link.txt"))
+	mustSymlink(t, "real", filepath.Join(root, "linked"))
+}
+
+// sortedRemoteFiles returns the sorted remote files, without internal objects
+func sortedRemoteFiles(t *testing.T, fm *FileManager) []string {
+	t.Helper()
+	files, err := fm.ListRemoteFiles("")
+	if err != nil {
+		t.Fatalf("ListRemoteFiles failed: %v", err)
+	}
+	sort.Strings(files)
+	return files
+}
+
+func TestParseSymlinkMode(t *testing.T) {
+	for input, expected := range map[string]SymlinkMode{"": SymlinkSkip, "skip": SymlinkSkip, "follow": SymlinkFollow, "store": SymlinkStore} {
+		if mode, err := ParseSymlinkMode(input); err != nil || mode != expected {
+			t.Errorf("ParseSymlinkMode(%q) = %q, %v, expected %q", input, mode, err, expected)
+		}
+	}
+	if _, err := ParseSymlinkMode("copy"); err == nil {
+		t.Error("Expected an error for an unknown mode")
+	}
+}
+
+func TestFileManager_SymlinkSkip(t *testing.T) {
+	fm, tempDir, _ := createTestFileManager(t)
+	newSymlinkTree(t, tempDir)
+
+	if err := fm.EncryptAndUploadDirectory(context.Background(), tempDir); err != nil {
+		t.Fatalf("EncryptAndUploadDirectory failed: %v", err)
+	}
+	if files := sortedRemoteFiles(t, fm); !reflect.DeepEqual(files, []string{"a.txt", "real/b.txt"}) {
+		t.Errorf("Expected links to be skipped, got %v", files)
+	}
+}
+
+func TestFileManager_SymlinkFollow(t *testing.T) {
+	fm, tempDir, mockStore := createTestFileManager(t)
+	fm.SetSymlinkMode(SymlinkFollow)
+	newSymlinkTree(t, tempDir)
+
+	if _, err := fm.SyncUpload(context.Background()); err != nil {
+		t.Fatalf("SyncUpload failed: %v", err)
+	}
+	expected := []string{"a.txt", "link.txt", "linked/b.txt", "real/b.txt"}
+	if files := sortedRemoteFiles(t, fm); !reflect.DeepEqual(files, expected) {
+		t.Errorf("Expected link targets to be uploaded under the link path, got %v", files)
+	}
+	encrypted, err := mockStore.Download("linked/b.txt")
+	if err != nil {
+		t.Fatalf("Download failed: %v", err)
+	}
+	if plain, _ := fm.cipher.Decrypt(encrypted); string(plain) != "real/b.txt" {
+		t.Errorf("Expected the content of the target, got %q", plain)
+	}
+}
+
+func TestFileManager_SymlinkFollowLoop(t *testing.T) {
+	fm, tempDir, _ := createTestFileManager(t)
+	fm.SetSymlinkMode(SymlinkFollow)
+	writeLocalFiles(t, tempDir, "dir/a.txt")
+	// dir/up 指回工作目录，dir/self 指向自身所在目录
+	mustSymlink(t, "..", filepath.Join(tempDir, "dir", "up"))
+	mustSymlink(t, ".", filepath.Join(tempDir, "dir", "self"))
+
+	count, err := fm.CountUploadableFiles("dir")
+	if err != nil {
+		t.Fatalf("CountUploadableFiles failed: %v", err)
+	}
+	// 经 dir/up 回到工作目录后，其中的 dir 已在遍历中而被跳过
+	if count != 1 {
+		t.Errorf("Expected the loop to be skipped and 1 file counted, got %d", count)
+	}
+	if err := fm.EncryptAndUploadDirectory(context.Background(), tempDir); err != nil {
+		t.Fatalf("EncryptAndUploadDirectory failed: %v", err)
+	}
+	if files := sortedRemoteFiles(t, fm); !reflect.DeepEqual(files, []string{"dir/a.txt"}) {
+		t.Errorf("Expected only dir/a.txt to be uploaded, got %v", files)
+	}
+}
+
+func TestFileManager_SymlinkStoreRoundTrip(t *testing.T) {
+	fm, tempDir, _ := newManifestTestFileManager(t)
+	fm.SetSymlinkMode(SymlinkStore)
+	newSymlinkTree(t, tempDir)
+
+	if _, err := fm.SyncUpload(context.Background()); err != nil {
+		t.Fatalf("SyncUpload failed: %v", err)
+	}
+	m := mustLoadManifest(t, fm)
+	if m.Files["link.txt"].Link != "a.txt" || m.Files["linked"].Link != "real" {
+		t.Errorf("Expected the link targets in the manifest, got %+v", m.Files)
+	}
+	if report, err := fm.Diff(context.Background()); err != nil || len(report.Modified) != 0 {
+		t.Errorf("Stored links should compare as identical, got %+v, %v", report, err)
+	}
+
+	for _, name := range []string{"link.txt", "linked"} {
+		if err := os.Remove(filepath.Join(tempDir, name)); err != nil {
+			t.Fatalf("Failed to remove %s: %v", name, err)
+		}
+	}
+	result, err := fm.SyncDownload(context.Background())
+	if err != nil {
+		t.Fatalf("SyncDownload failed: %v", err)
+	}
+	if !reflect.DeepEqual(result.Succeeded, []string{"link.txt", "linked"}) {
+		t.Errorf("Expected both links to be restored, got %+v", result)
+	}
+	for name, target := range map[string]string{"link.txt": "a.txt", "linked": "real"} {
+		if got, err := os.Readlink(filepath.Join(tempDir, name)); err != nil || got != target {
+			t.Errorf("Expected %s to link to %s, got %q, %v", name, target, got, err)
+		}
+	}
+}
+
+func TestFileManager_SymlinkStoreManifestRebuild(t *testing.T) {
+	fm, tempDir, _ := createTestFileManager(t)
+	fm.SetSymlinkMode(SymlinkStore)
+	newSymlinkTree(t, tempDir)
+	if err := fm.EncryptAndUploadFile(filepath.Join(tempDir, "link.txt"), "link.txt"); err != nil {
+		t.Fatalf("EncryptAndUploadFile failed: %v", err)
+	}
+
+	// 重建清单时从加密内容中恢复链接目标
+	m, err := fm.RebuildManifest(context.Background())
+	if err != nil {
+		t.Fatalf("RebuildManifest failed: %v", err)
+	}
+	if m.Files["link.txt"].Link != "a.txt" {
+		t.Errorf("Expected the rebuilt manifest to record the link, got %+v", m.Files["link.txt"])
+	}
+}
@@ -0,0 +1,50 @@
+package dir
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrTimeout is returned when an operation or a single file takes longer than
+// its configured timeout
+var ErrTimeout = errors.New("operation timed out")
+
+// OperationTimeout returns the timeout for a whole UI operation, 0 means no limit
+func (fm *FileManager) OperationTimeout() time.Duration {
+	return fm.operationTimeout
+}
+
+// SetTimeouts sets the timeouts for a whole operation and for a single file
+// upload or download, 0 means no limit
+func (fm *FileManager) SetTimeouts(operation, perFile time.Duration) {
+	fm.operationTimeout = operation
+	fm.perFileTimeout = perFile
+}
+
+// withFileTimeout runs fn for a single file and gives up once the per-file
+// timeout passes or ctx is done; fn gets the context limited by the timeout.
+// 存储接口大多不支持 context，超时后 fn 仍在后台运行直到返回，其结果被丢弃。
+func (fm *FileManager) withFileTimeout(ctx context.Context, fn func(ctx context.Context) error) error {
+	if fm.perFileTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, fm.perFileTimeout)
+		defer cancel()
+	}
+	if ctx.Done() == nil {
+		return fn(ctx)
+	}
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- fn(ctx) }()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return ErrTimeout
+		}
+		return ctx.Err()
+	}
+}
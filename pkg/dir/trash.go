@@ -0,0 +1,84 @@
+package dir
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// TrashDirName is the directory under the working directory that deleted
+// files are moved to when use_trash is enabled
+const TrashDirName = ".fers-trash"
+
+// trashDir returns the absolute path of the trash directory
+func (fm *FileManager) trashDir() string {
+	return filepath.Join(fm.workingDir, TrashDirName)
+}
+
+// moveToTrash moves localPath into the trash, keeping its path relative to the working directory
+func (fm *FileManager) moveToTrash(localPath, relativePath string) error {
+	trashPath := filepath.Join(fm.trashDir(), filepath.Clean(relativePath))
+	if err := os.MkdirAll(filepath.Dir(trashPath), defaultDirMode); err != nil {
+		return fmt.Errorf("failed to create trash directory: %w", err)
+	}
+	// 同一路径再次删除时，回收站中只保留最新的一份
+	if err := os.RemoveAll(trashPath); err != nil {
+		return fmt.Errorf("failed to replace %s in trash: %w", relativePath, err)
+	}
+	if err := os.Rename(localPath, trashPath); err != nil {
+		return fmt.Errorf("failed to move %s to trash: %w", relativePath, err)
+	}
+
+	fm.logger.Info("File moved to trash", slog.String("path", relativePath))
+	return nil
+}
+
+// inTrash reports whether localPath is the trash directory or inside it
+func (fm *FileManager) inTrash(localPath string) bool {
+	rel, err := filepath.Rel(fm.trashDir(), localPath)
+	return err == nil && !strings.HasPrefix(rel, "..")
+}
+
+// RestoreFromTrash moves a trashed entry back to its original path relative to the working directory
+func (fm *FileManager) RestoreFromTrash(relativePath string) error {
+	localPath, err := fm.resolveLocalPath(relativePath)
+	if err != nil {
+		return err
+	}
+	if fm.inTrash(localPath) {
+		return fmt.Errorf("invalid path: %q", relativePath)
+	}
+	trashPath := filepath.Join(fm.trashDir(), filepath.Clean(relativePath))
+
+	if _, err := os.Lstat(trashPath); err != nil {
+		return fmt.Errorf("failed to restore %s: %w", relativePath, err)
+	}
+	// 原位置已有同名文件时不覆盖
+	if _, err := os.Lstat(localPath); err == nil {
+		return fmt.Errorf("failed to restore %s: file already exists", relativePath)
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to restore %s: %w", relativePath, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(localPath), defaultDirMode); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", relativePath, err)
+	}
+	if err := os.Rename(trashPath, localPath); err != nil {
+		return fmt.Errorf("failed to restore %s: %w", relativePath, err)
+	}
+
+	fm.logger.Info("File restored from trash", slog.String("path", relativePath))
+	return nil
+}
+
+// EmptyTrash permanently deletes everything in the trash
+func (fm *FileManager) EmptyTrash() error {
+	if err := os.RemoveAll(fm.trashDir()); err != nil {
+		return fmt.Errorf("failed to empty trash: %w", err)
+	}
+
+	fm.logger.Info("Trash emptied")
+	return nil
+}
@@ -0,0 +1,128 @@
+package dir
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func createTrashFileManager(t *testing.T) (*FileManager, string) {
+	t.Helper()
+	fm, tempDir, _ := createTestFileManager(t)
+	fm.config.UseTrash = true
+
+	if err := os.MkdirAll(filepath.Join(tempDir, "docs"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "docs", "a.txt"), []byte("a"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	return fm, tempDir
+}
+
+func TestFileManager_DeleteToTrash(t *testing.T) {
+	fm, tempDir := createTrashFileManager(t)
+
+	if err := fm.DeleteLocalFile("docs/a.txt"); err != nil {
+		t.Fatalf("DeleteLocalFile failed: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(tempDir, "docs", "a.txt")); !os.IsNotExist(err) {
+		t.Error("File should be removed from its original location")
+	}
+	data, err := os.ReadFile(filepath.Join(tempDir, TrashDirName, "docs", "a.txt"))
+	if err != nil || string(data) != "a" {
+		t.Errorf("File should be in the trash with its relative path: %v", err)
+	}
+
+	// 删除回收站中的文件是永久删除
+	if err := fm.DeleteLocalFile(filepath.Join(TrashDirName, "docs", "a.txt")); err != nil {
+		t.Fatalf("DeleteLocalFile in trash failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, TrashDirName, "docs", "a.txt")); !os.IsNotExist(err) {
+		t.Error("Deleting from the trash should remove the file permanently")
+	}
+}
+
+func TestFileManager_RestoreFromTrash(t *testing.T) {
+	fm, tempDir := createTrashFileManager(t)
+
+	if err := fm.DeleteLocalFile("docs/a.txt"); err != nil {
+		t.Fatalf("DeleteLocalFile failed: %v", err)
+	}
+	if err := os.RemoveAll(filepath.Join(tempDir, "docs")); err != nil {
+		t.Fatalf("Failed to remove directory: %v", err)
+	}
+
+	if err := fm.RestoreFromTrash("docs/a.txt"); err != nil {
+		t.Fatalf("RestoreFromTrash failed: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(tempDir, "docs", "a.txt"))
+	if err != nil || string(data) != "a" {
+		t.Errorf("File should be restored to its original path: %v", err)
+	}
+
+	if err := fm.RestoreFromTrash("docs/a.txt"); err == nil {
+		t.Error("Expected error restoring a file that is not in the trash")
+	}
+	if err := fm.RestoreFromTrash("../outside.txt"); err == nil {
+		t.Error("Expected error restoring a path outside the working directory")
+	}
+}
+
+func TestFileManager_RestoreFromTrashNoOverwrite(t *testing.T) {
+	fm, tempDir := createTrashFileManager(t)
+
+	if err := fm.DeleteLocalFile("docs/a.txt"); err != nil {
+		t.Fatalf("DeleteLocalFile failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "docs", "a.txt"), []byte("new"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	if err := fm.RestoreFromTrash("docs/a.txt"); err == nil {
+		t.Error("Expected error restoring over an existing file")
+	}
+	data, _ := os.ReadFile(filepath.Join(tempDir, "docs", "a.txt"))
+	if string(data) != "new" {
+		t.Error("Existing file should not be overwritten")
+	}
+}
+
+func TestFileManager_EmptyTrash(t *testing.T) {
+	fm, tempDir := createTrashFileManager(t)
+
+	if err := fm.DeleteLocalFile("docs/a.txt"); err != nil {
+		t.Fatalf("DeleteLocalFile failed: %v", err)
+	}
+	if err := fm.EmptyTrash(); err != nil {
+		t.Fatalf("EmptyTrash failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, TrashDirName)); !os.IsNotExist(err) {
+		t.Error("Trash directory should be removed")
+	}
+}
+
+func TestFileManager_TrashSkippedBySyncAndList(t *testing.T) {
+	fm, tempDir := createTrashFileManager(t)
+	fm.SetIncludeHidden(true)
+
+	if err := fm.DeleteLocalFile("docs/a.txt"); err != nil {
+		t.Fatalf("DeleteLocalFile failed: %v", err)
+	}
+
+	plan, err := fm.PlanSyncUpload(context.Background())
+	if err != nil {
+		t.Fatalf("PlanSyncUpload failed: %v", err)
+	}
+	if len(plan.Files) != 0 {
+		t.Errorf("Trash should be skipped by SyncUpload, got %v", plan.Files)
+	}
+
+	for _, name := range ListWith(tempDir, ListOptions{IncludeHidden: true}) {
+		if name == TrashDirName {
+			t.Error("Trash should not be listed even with hidden files shown")
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package dir
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/mingregister/fers/pkg/storage"
+)
+
+// ErrSizeMismatch is returned by VerifyRemote when an object decrypts to a
+// different size than the one stored with it
+var ErrSizeMismatch = errors.New("decrypted size does not match the stored size")
+
+// VerifyRemote streams every remote object and checks that it decrypts
+// with the current key. Objects are verified one at a time so only one
+// ciphertext is held in memory; report, if not nil, is called with each key's result.
+// It returns the number of good and bad objects.
+func (fm *FileManager) VerifyRemote(ctx context.Context, report func(key string, ok bool, err error)) (int, int, error) {
+	keys, err := fm.storage.List("")
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to list remote files: %w", err)
+	}
+	keys = withoutInternalKeys(keys)
+
+	good, bad := 0, 0
+	for _, key := range keys {
+		select {
+		case <-ctx.Done():
+			return good, bad, ctx.Err()
+		default:
+		}
+
+		err := fm.verifyRemoteObject(ctx, key)
+		if err != nil {
+			bad++
+			fm.logger.Error("Remote object failed verification", slog.String("key", key), slog.String("error", err.Error()))
+		} else {
+			good++
+		}
+		if report != nil {
+			report(key, err == nil, err)
+		}
+	}
+
+	fm.logger.Info("Remote verification finished", slog.Int("good", good), slog.Int("bad", bad))
+	return good, bad, nil
+}
+
+// verifyRemoteObject streams a single object and checks that it decrypts to
+// the size recorded with it. AES-GCM authenticates the object as a whole, so
+// the ciphertext is buffered while it downloads; the plaintext is cleared as
+// soon as its length has been checked.
+func (fm *FileManager) verifyRemoteObject(ctx context.Context, key string) error {
+	// 空目录占位符没有加密内容
+	if isEmptyDirPlaceholder(key) {
+		return nil
+	}
+
+	stream, err := storage.DownloadStreamRange(ctx, fm.storage, key, 0)
+	if err != nil {
+		return fmt.Errorf("failed to download: %w", err)
+	}
+	defer stream.Close()
+	var encrypted bytes.Buffer
+	if _, err := encrypted.ReadFrom(&progressReader{ctx: ctx, r: stream}); err != nil {
+		return fmt.Errorf("failed to download: %w", err)
+	}
+
+	plain, err := fm.currentCipher().Decrypt(encrypted.Bytes())
+	if err != nil {
+		return fmt.Errorf("failed to decrypt: %w", err)
+	}
+	size := len(plain)
+	clear(plain)
+
+	// 对象带有明文大小时，确认解密结果没有被截断
+	if info, err := fm.storage.Stat(key); err == nil {
+		if want := info.Metadata[storage.MetaOrigSize]; want != "" && want != strconv.Itoa(size) {
+			return fmt.Errorf("decrypted %d bytes, expected %s: %w", size, want, ErrSizeMismatch)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package dir
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mingregister/fers/pkg/storage"
+)
+
+func TestFileManager_VerifyRemote(t *testing.T) {
+	fm, _, mockStore := createTestFileManager(t)
+
+	for _, key := range []string{"a.txt", "docs/b.txt"} {
+		encrypted, err := fm.cipher.Encrypt([]byte(key))
+		if err != nil {
+			t.Fatalf("Failed to encrypt: %v", err)
+		}
+		mustUpload(t, mockStore, key, encrypted)
+	}
+	corrupted, err := fm.cipher.Encrypt([]byte("corrupted"))
+	if err != nil {
+		t.Fatalf("Failed to encrypt: %v", err)
+	}
+	corrupted[len(corrupted)-1] ^= 0xff
+	mustUpload(t, mockStore, "docs/corrupted.txt", corrupted)
+	mustUpload(t, mockStore, "empty/"+EmptyDirPlaceholder, []byte{})
+
+	results := make(map[string]bool)
+	good, bad, err := fm.VerifyRemote(context.Background(), func(key string, ok bool, err error) {
+		results[key] = ok
+		if ok != (err == nil) {
+			t.Errorf("Inconsistent result for %s: ok=%v err=%v", key, ok, err)
+		}
+	})
+	if err != nil {
+		t.Fatalf("VerifyRemote failed: %v", err)
+	}
+
+	if good != 3 || bad != 1 {
+		t.Errorf("Expected 3 good and 1 bad, got %d good and %d bad", good, bad)
+	}
+	if len(results) != 4 {
+		t.Errorf("Expected a result for every key, got %v", results)
+	}
+	if results["docs/corrupted.txt"] {
+		t.Error("Corrupted object should be flagged")
+	}
+	if !results["a.txt"] || !results["docs/b.txt"] {
+		t.Error("Valid objects should pass verification")
+	}
+}
+
+func TestFileManager_VerifyRemoteCancelled(t *testing.T) {
+	fm, _, mockStore := createTestFileManager(t)
+	mustUpload(t, mockStore, "a.txt", []byte("data"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, _, err := fm.VerifyRemote(ctx, nil); err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestFileManager_VerifyRemoteSizeMismatch(t *testing.T) {
+	fm, _, mockStore := createTestFileManager(t)
+
+	encrypted, err := fm.cipher.Encrypt([]byte("short"))
+	if err != nil {
+		t.Fatalf("Failed to encrypt: %v", err)
+	}
+	meta := map[string]string{storage.MetaOrigSize: "100"}
+	if err := mockStore.(storage.MetadataUploader).UploadWithMeta("a.txt", encrypted, meta); err != nil {
+		t.Fatalf("Failed to upload: %v", err)
+	}
+
+	var got error
+	good, bad, err := fm.VerifyRemote(context.Background(), func(key string, ok bool, err error) {
+		got = err
+	})
+	if err != nil {
+		t.Fatalf("VerifyRemote failed: %v", err)
+	}
+	if good != 0 || bad != 1 {
+		t.Errorf("Expected 0 good and 1 bad, got %d good and %d bad", good, bad)
+	}
+	if !errors.Is(got, ErrSizeMismatch) {
+		t.Errorf("Expected ErrSizeMismatch, got %v", got)
+	}
+}
@@ -0,0 +1,68 @@
+package menu
+
+import "fyne.io/fyne/v2"
+
+// MenuActions 是主菜单项触发的操作，由界面层实现
+type MenuActions interface {
+	// OpenInFileManager 在系统文件管理器中打开选中项或当前目录
+	OpenInFileManager()
+	// SaveLogs 将界面日志保存到文件
+	SaveLogs()
+	// EncryptToFile 将本地文件加密到另一个本地文件，不上传
+	EncryptToFile()
+	// DecryptFromFile 将本地的加密文件解密到另一个本地文件
+	DecryptFromFile()
+	// ChangeKey 用新密钥重新加密全部远程文件
+	ChangeKey()
+	// CanChangeKey 报告当前密钥能否更换，不能时禁用对应菜单项
+	CanChangeKey() bool
+	// Refresh 刷新文件列表
+	Refresh()
+	// SyncUpload 上传远程缺失的本地文件
+	SyncUpload()
+	// SyncUploadMirror 上传本地文件并在确认后删除本地已不存在的远程文件
+	SyncUploadMirror()
+	// SyncDownload 下载本地缺失的远程文件
+	SyncDownload()
+	// CompareWithRemote 比较本地与远程文件并显示差异
+	CompareWithRemote()
+	// Heal 用远程副本恢复与清单哈希不符的本地文件
+	Heal()
+	// RetryQuarantined 重新传输因多次同步失败而被隔离的文件
+	RetryQuarantined()
+	// ShowAbout 显示版本、构建信息和当前使用的存储
+	ShowAbout()
+}
+
+// CreateMainMenu 创建应用主菜单，菜单项的动作委托给 actions
+func CreateMainMenu(actions MenuActions) *fyne.MainMenu {
+	changeKey := fyne.NewMenuItem("Change Crypto Key...", actions.ChangeKey)
+	changeKey.Disabled = !actions.CanChangeKey()
+
+	fileMenu := fyne.NewMenu("File",
+		fyne.NewMenuItem("Open in Files", actions.OpenInFileManager),
+		fyne.NewMenuItem("Save Logs", actions.SaveLogs),
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Encrypt to File...", actions.EncryptToFile),
+		fyne.NewMenuItem("Decrypt from File...", actions.DecryptFromFile),
+		fyne.NewMenuItemSeparator(),
+		changeKey,
+	)
+
+	syncMenu := fyne.NewMenu("Sync",
+		fyne.NewMenuItem("Sync Upload", actions.SyncUpload),
+		fyne.NewMenuItem("Sync Upload Mirror...", actions.SyncUploadMirror),
+		fyne.NewMenuItem("Sync Download", actions.SyncDownload),
+		fyne.NewMenuItem("Compare Local and Remote", actions.CompareWithRemote),
+		fyne.NewMenuItem("Heal Corrupted Files", actions.Heal),
+		fyne.NewMenuItem("Retry Quarantined Files", actions.RetryQuarantined),
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Refresh", actions.Refresh),
+	)
+
+	helpMenu := fyne.NewMenu("Help",
+		fyne.NewMenuItem("About", actions.ShowAbout),
+	)
+
+	return fyne.NewMainMenu(fileMenu, syncMenu, helpMenu)
+}
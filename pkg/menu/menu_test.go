@@ -0,0 +1,119 @@
+package menu
+
+import (
+	"testing"
+
+	"fyne.io/fyne/v2"
+)
+
+// recordingActions records which MenuActions callbacks were invoked
+type recordingActions struct {
+	calls     []string
+	keyLocked bool
+}
+
+func (r *recordingActions) OpenInFileManager() { r.calls = append(r.calls, "open") }
+func (r *recordingActions) SaveLogs()          { r.calls = append(r.calls, "save logs") }
+func (r *recordingActions) EncryptToFile()     { r.calls = append(r.calls, "encrypt to file") }
+func (r *recordingActions) DecryptFromFile()   { r.calls = append(r.calls, "decrypt from file") }
+func (r *recordingActions) ChangeKey()         { r.calls = append(r.calls, "change key") }
+func (r *recordingActions) CanChangeKey() bool { return !r.keyLocked }
+func (r *recordingActions) Refresh()           { r.calls = append(r.calls, "refresh") }
+func (r *recordingActions) SyncUpload()        { r.calls = append(r.calls, "sync upload") }
+func (r *recordingActions) SyncUploadMirror()  { r.calls = append(r.calls, "sync upload mirror") }
+func (r *recordingActions) SyncDownload()      { r.calls = append(r.calls, "sync download") }
+func (r *recordingActions) CompareWithRemote() { r.calls = append(r.calls, "compare") }
+func (r *recordingActions) Heal()              { r.calls = append(r.calls, "heal") }
+func (r *recordingActions) RetryQuarantined()  { r.calls = append(r.calls, "retry quarantined") }
+func (r *recordingActions) ShowAbout()         { r.calls = append(r.calls, "about") }
+
+// findItem returns the menu item with the given label
+func findItem(t *testing.T, mainMenu *fyne.MainMenu, menuLabel, itemLabel string) *fyne.MenuItem {
+	t.Helper()
+	for _, m := range mainMenu.Items {
+		if m.Label != menuLabel {
+			continue
+		}
+		for _, item := range m.Items {
+			if item.Label == itemLabel {
+				return item
+			}
+		}
+	}
+	t.Fatalf("Menu item %s > %s not found", menuLabel, itemLabel)
+	return nil
+}
+
+func TestCreateMainMenu(t *testing.T) {
+	mainMenu := CreateMainMenu(&recordingActions{})
+
+	if mainMenu == nil {
+		t.Fatal("CreateMainMenu returned nil")
+	}
+
+	if len(mainMenu.Items) != 3 {
+		t.Fatalf("Expected 3 menus, got %d", len(mainMenu.Items))
+	}
+
+	if mainMenu.Items[0].Label != "File" {
+		t.Errorf("Expected first menu 'File', got '%s'", mainMenu.Items[0].Label)
+	}
+
+	if mainMenu.Items[1].Label != "Sync" {
+		t.Errorf("Expected second menu 'Sync', got '%s'", mainMenu.Items[1].Label)
+	}
+
+	if mainMenu.Items[2].Label != "Help" {
+		t.Errorf("Expected third menu 'Help', got '%s'", mainMenu.Items[2].Label)
+	}
+}
+
+func TestCreateMainMenu_Actions(t *testing.T) {
+	tests := []struct {
+		menu     string
+		item     string
+		expected string
+	}{
+		{"File", "Open in Files", "open"},
+		{"File", "Save Logs", "save logs"},
+		{"File", "Encrypt to File...", "encrypt to file"},
+		{"File", "Decrypt from File...", "decrypt from file"},
+		{"File", "Change Crypto Key...", "change key"},
+		{"Sync", "Sync Upload", "sync upload"},
+		{"Sync", "Sync Upload Mirror...", "sync upload mirror"},
+		{"Sync", "Sync Download", "sync download"},
+		{"Sync", "Compare Local and Remote", "compare"},
+		{"Sync", "Heal Corrupted Files", "heal"},
+		{"Sync", "Retry Quarantined Files", "retry quarantined"},
+		{"Sync", "Refresh", "refresh"},
+		{"Help", "About", "about"},
+	}
+
+	for _, test := range tests {
+		actions := &recordingActions{}
+		mainMenu := CreateMainMenu(actions)
+
+		item := findItem(t, mainMenu, test.menu, test.item)
+		if item.Action == nil {
+			t.Errorf("%s > %s has no action", test.menu, test.item)
+			continue
+		}
+		item.Action()
+
+		if len(actions.calls) != 1 || actions.calls[0] != test.expected {
+			t.Errorf("%s > %s: expected call %q, got %v", test.menu, test.item, test.expected, actions.calls)
+		}
+	}
+}
+
+func TestCreateMainMenu_ChangeKeyDisabled(t *testing.T) {
+	item := findItem(t, CreateMainMenu(&recordingActions{}), "File", "Change Crypto Key...")
+	if item.Disabled {
+		t.Error("Change Crypto Key should be enabled when the key can be changed")
+	}
+
+	item = findItem(t, CreateMainMenu(&recordingActions{keyLocked: true}), "File", "Change Crypto Key...")
+	if !item.Disabled {
+		t.Error("Change Crypto Key should be disabled when the key cannot be changed")
+	}
+}
@@ -1,13 +1,204 @@
 package storage
 
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultPageSize is the page size used when ListPage is called with max <= 0
+const DefaultPageSize = 1000
+
+// ObjectInfo describes a stored object
+type ObjectInfo struct {
+	Key          string
+	Size         int64 // 有 MetaOrigSize 元数据时为明文大小，否则为存储的大小
+	LastModified time.Time
+	Metadata     map[string]string
+}
+
+// 上传时附加的用户元数据键，OSS 上保存为 x-oss-meta-<key>
+const (
+	MetaOrigSize = "orig-size" // 明文大小（字节）
+	MetaMtime    = "mtime"     // 本地文件修改时间，RFC 3339
+	MetaSHA256   = "sha256"    // 明文的 SHA-256，十六进制
+	MetaSymlink  = "symlink"   // 对象是符号链接，加密内容为链接目标
+
+	MetaContentType = "content-type" // 明文的 MIME 类型，OSS 上同时设为对象的 Content-Type
+)
+
 type Client interface {
 	// List all object keys (relative paths) under given prefix (empty => list all)
 	List(prefix string) ([]string, error)
+	// ListPage lists at most max keys under prefix starting at continuationToken
+	// (empty => first page). nextToken is empty once there are no more keys.
+	ListPage(prefix, continuationToken string, max int) (keys []string, nextToken string, err error)
+	// ListWithDelimiter lists the keys directly under prefix and the common
+	// prefixes ("sub-folders") ending in delimiter one level below it
+	ListWithDelimiter(prefix, delimiter string) (keys []string, commonPrefixes []string, err error)
 	// Upload object with given key and content
 	Upload(key string, data []byte) error
 	// Download object by key
 	Download(key string) ([]byte, error)
+	// Stat returns the size and modification time of an object without downloading it
+	Stat(key string) (ObjectInfo, error)
 	// Delete removes the value for a key.
 	// Returns nil if successful or key doesn't exist.
 	Delete(key string) error
 }
+
+// Presigner is implemented by clients that can generate time-limited download URLs
+type Presigner interface {
+	// Presign returns a URL that downloads the object for the given duration
+	Presign(key string, expiry time.Duration) (string, error)
+}
+
+// MetadataUploader is implemented by clients that can store user metadata
+// with an object; Stat returns it in ObjectInfo.Metadata
+type MetadataUploader interface {
+	// UploadWithMeta uploads the object like Upload and attaches meta
+	UploadWithMeta(key string, data []byte, meta map[string]string) error
+}
+
+// ErrInvalidRange is returned by DownloadStreamRange when offset is beyond the end of the object
+var ErrInvalidRange = errors.New("range not satisfiable")
+
+// RangeDownloader is implemented by clients that can stream an object from an
+// offset, so an interrupted download can resume where it stopped
+type RangeDownloader interface {
+	// DownloadStreamRange streams the object from offset to its end
+	DownloadStreamRange(ctx context.Context, key string, offset int64) (io.ReadCloser, error)
+}
+
+// DownloadStreamRange streams key from offset with client, downloading the
+// whole object and skipping offset bytes when client is not a RangeDownloader
+func DownloadStreamRange(ctx context.Context, client Client, key string, offset int64) (io.ReadCloser, error) {
+	if ranger, ok := client.(RangeDownloader); ok {
+		return ranger.DownloadStreamRange(ctx, key, offset)
+	}
+	data, err := client.Download(key)
+	if err != nil {
+		return nil, err
+	}
+	return rangeReader(data, key, offset)
+}
+
+// rangeReader returns a reader of data from offset
+func rangeReader(data []byte, key string, offset int64) (io.ReadCloser, error) {
+	if offset < 0 || offset > int64(len(data)) {
+		return nil, fmt.Errorf("offset %d of %s (%d bytes): %w", offset, key, len(data), ErrInvalidRange)
+	}
+	return io.NopCloser(bytes.NewReader(data[offset:])), nil
+}
+
+// withMetadata returns info with meta attached, reporting the plaintext size when it is known
+func withMetadata(info ObjectInfo, meta map[string]string) ObjectInfo {
+	if len(meta) == 0 {
+		return info
+	}
+	info.Metadata = meta
+	if size, err := strconv.ParseInt(meta[MetaOrigSize], 10, 64); err == nil {
+		info.Size = size
+	}
+	return info
+}
+
+// Mover is implemented by clients that can copy and move objects without
+// downloading them
+type Mover interface {
+	// Copy copies the object at srcKey to dstKey, overwriting dstKey
+	Copy(srcKey, dstKey string) error
+	// Move copies the object at srcKey to dstKey and then removes srcKey
+	Move(srcKey, dstKey string) error
+}
+
+// Pinger is implemented by clients that can cheaply check that the backend is
+// reachable and accepts the configured credentials
+type Pinger interface {
+	// Ping returns an error when the backend cannot be reached or rejects the request
+	Ping(ctx context.Context) error
+}
+
+// Ping checks that client's backend is reachable; clients that are not a
+// Pinger are assumed to be
+func Ping(ctx context.Context, client Client) error {
+	if pinger, ok := client.(Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+// NormalizeKey converts a relative OS path into the canonical storage key:
+// backslashes become slashes on every platform, the path is cleaned and any
+// leading "/" or "./" is dropped. "." and "" both normalize to "".
+func NormalizeKey(p string) string {
+	key := path.Clean(strings.ReplaceAll(p, "\\", "/"))
+	key = strings.TrimLeft(key, "/")
+	if key == "." {
+		return ""
+	}
+	return key
+}
+
+// pageKeys returns one page of keys for backends that list everything at once.
+// The keys are sorted for a stable order and the token is the offset of the next page.
+func pageKeys(keys []string, continuationToken string, max int) ([]string, string, error) {
+	if max <= 0 {
+		max = DefaultPageSize
+	}
+	offset := 0
+	if continuationToken != "" {
+		var err error
+		offset, err = strconv.Atoi(continuationToken)
+		if err != nil || offset < 0 {
+			return nil, "", fmt.Errorf("invalid continuation token %q", continuationToken)
+		}
+	}
+
+	sort.Strings(keys)
+	if offset >= len(keys) {
+		return nil, "", nil
+	}
+	end := min(offset+max, len(keys))
+	nextToken := ""
+	if end < len(keys) {
+		nextToken = strconv.Itoa(end)
+	}
+	return keys[offset:end], nextToken, nil
+}
+
+// groupByDelimiter emulates a delimiter listing for backends that only list flat keys.
+// Keys containing delimiter after prefix are grouped into their first segment.
+func groupByDelimiter(keys []string, prefix, delimiter string) ([]string, []string) {
+	var direct, commonPrefixes []string
+	seen := make(map[string]bool)
+	for _, key := range keys {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		rest := key[len(prefix):]
+		idx := -1
+		if delimiter != "" {
+			idx = strings.Index(rest, delimiter)
+		}
+		if idx < 0 {
+			direct = append(direct, key)
+			continue
+		}
+		commonPrefix := prefix + rest[:idx+len(delimiter)]
+		if !seen[commonPrefix] {
+			seen[commonPrefix] = true
+			commonPrefixes = append(commonPrefixes, commonPrefix)
+		}
+	}
+	sort.Strings(direct)
+	sort.Strings(commonPrefixes)
+	return direct, commonPrefixes
+}
@@ -0,0 +1,338 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"testing"
+)
+
+// collectPages pages through client with the given page size and returns every key
+func collectPages(t *testing.T, client Client, prefix string, max int) []string {
+	t.Helper()
+	var all []string
+	token := ""
+	for pages := 0; ; pages++ {
+		if pages > 1000 {
+			t.Fatal("ListPage did not terminate")
+		}
+		keys, next, err := client.ListPage(prefix, token, max)
+		if err != nil {
+			t.Fatalf("ListPage failed: %v", err)
+		}
+		if max > 0 && len(keys) > max {
+			t.Fatalf("ListPage returned %d keys, more than max %d", len(keys), max)
+		}
+		all = append(all, keys...)
+		if next == "" {
+			return all
+		}
+		token = next
+	}
+}
+
+// testListPage checks that paging through client yields every key exactly once
+func testListPage(t *testing.T, client Client) {
+	t.Helper()
+	var expected []string
+	for i := 0; i < 25; i++ {
+		key := fmt.Sprintf("folder/file%02d.txt", i)
+		expected = append(expected, key)
+		if err := client.Upload(key, []byte(key)); err != nil {
+			t.Fatalf("Upload failed: %v", err)
+		}
+	}
+	if err := client.Upload("other.txt", []byte("other")); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+
+	for _, max := range []int{1, 7, 25, 100} {
+		got := collectPages(t, client, "folder/", max)
+		if !slices.Equal(got, expected) {
+			t.Errorf("Paging with max %d returned %v, want %v", max, got, expected)
+		}
+	}
+
+	all := collectPages(t, client, "", 0)
+	if len(all) != 26 {
+		t.Errorf("Expected 26 keys with the default page size, got %d", len(all))
+	}
+}
+
+func TestPageKeys(t *testing.T) {
+	keys := []string{"c", "a", "b"}
+
+	page, next, err := pageKeys(keys, "", 2)
+	if err != nil {
+		t.Fatalf("pageKeys failed: %v", err)
+	}
+	if !slices.Equal(page, []string{"a", "b"}) || next != "2" {
+		t.Errorf("Unexpected first page %v, next %q", page, next)
+	}
+
+	page, next, err = pageKeys(keys, next, 2)
+	if err != nil {
+		t.Fatalf("pageKeys failed: %v", err)
+	}
+	if !slices.Equal(page, []string{"c"}) || next != "" {
+		t.Errorf("Unexpected last page %v, next %q", page, next)
+	}
+
+	page, next, err = pageKeys(keys, "10", 2)
+	if err != nil || len(page) != 0 || next != "" {
+		t.Errorf("Expected empty page past the end, got %v, %q, %v", page, next, err)
+	}
+
+	for _, token := range []string{"abc", "-1"} {
+		if _, _, err := pageKeys(keys, token, 2); err == nil {
+			t.Errorf("Expected error for invalid token %q", token)
+		}
+	}
+}
+
+func TestGroupByDelimiter(t *testing.T) {
+	keys := []string{
+		"root.txt",
+		"docs/a.txt",
+		"docs/b.txt",
+		"docs/2021/report.pdf",
+		"docs/2021/q1/summary.pdf",
+		"photos/cat.jpg",
+		"docsfile.txt",
+	}
+
+	testCases := []struct {
+		name             string
+		prefix           string
+		delimiter        string
+		expectedKeys     []string
+		expectedPrefixes []string
+	}{
+		{"root", "", "/", []string{"docsfile.txt", "root.txt"}, []string{"docs/", "photos/"}},
+		{"one level down", "docs/", "/", []string{"docs/a.txt", "docs/b.txt"}, []string{"docs/2021/"}},
+		{"nested", "docs/2021/", "/", []string{"docs/2021/report.pdf"}, []string{"docs/2021/q1/"}},
+		{"leaf folder", "docs/2021/q1/", "/", []string{"docs/2021/q1/summary.pdf"}, nil},
+		{"missing prefix", "music/", "/", nil, nil},
+		{"no delimiter", "docs/", "", []string{"docs/2021/q1/summary.pdf", "docs/2021/report.pdf", "docs/a.txt", "docs/b.txt"}, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			gotKeys, gotPrefixes := groupByDelimiter(keys, tc.prefix, tc.delimiter)
+			if !slices.Equal(gotKeys, tc.expectedKeys) {
+				t.Errorf("Expected keys %v, got %v", tc.expectedKeys, gotKeys)
+			}
+			if !slices.Equal(gotPrefixes, tc.expectedPrefixes) {
+				t.Errorf("Expected prefixes %v, got %v", tc.expectedPrefixes, gotPrefixes)
+			}
+		})
+	}
+}
+
+// testListWithDelimiter checks the folder-style listing of client for nested keys
+func testListWithDelimiter(t *testing.T, client Client) {
+	t.Helper()
+	for _, key := range []string{"root.txt", "docs/a.txt", "docs/2021/report.pdf", "docs/2021/q1/summary.pdf"} {
+		if err := client.Upload(key, []byte(key)); err != nil {
+			t.Fatalf("Upload failed: %v", err)
+		}
+	}
+
+	keys, prefixes, err := client.ListWithDelimiter("", "/")
+	if err != nil {
+		t.Fatalf("ListWithDelimiter failed: %v", err)
+	}
+	if !slices.Equal(keys, []string{"root.txt"}) || !slices.Equal(prefixes, []string{"docs/"}) {
+		t.Errorf("Unexpected root listing: keys %v, prefixes %v", keys, prefixes)
+	}
+
+	keys, prefixes, err = client.ListWithDelimiter("docs/2021/", "/")
+	if err != nil {
+		t.Fatalf("ListWithDelimiter failed: %v", err)
+	}
+	if !slices.Equal(keys, []string{"docs/2021/report.pdf"}) || !slices.Equal(prefixes, []string{"docs/2021/q1/"}) {
+		t.Errorf("Unexpected nested listing: keys %v, prefixes %v", keys, prefixes)
+	}
+}
+
+func TestNormalizeKey(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		// POSIX
+		{"file.txt", "file.txt"},
+		{"folder/sub/file.txt", "folder/sub/file.txt"},
+		{"/folder/file.txt", "folder/file.txt"},
+		{"./folder//file.txt", "folder/file.txt"},
+		{"folder/../other/file.txt", "other/file.txt"},
+		{"notes:2024.txt", "notes:2024.txt"},
+		// Windows
+		{`folder\sub\file.txt`, "folder/sub/file.txt"},
+		{`.\folder\file.txt`, "folder/file.txt"},
+		{`\folder\file.txt`, "folder/file.txt"},
+		// 空路径
+		{"", ""},
+		{".", ""},
+		{"/", ""},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeKey(tt.input); got != tt.want {
+			t.Errorf("NormalizeKey(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+// testMover checks that client copies objects with their bytes intact and
+// that moving removes the source
+func testMover(t *testing.T, client Client) {
+	t.Helper()
+	mover, ok := client.(Mover)
+	if !ok {
+		t.Fatalf("%T does not implement Mover", client)
+	}
+	data := []byte("photo bytes")
+	if err := client.Upload("2022/photo.jpg", data); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+
+	if err := mover.Copy("2022/photo.jpg", "backup/photo.jpg"); err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	copied, err := client.Download("backup/photo.jpg")
+	if err != nil || string(copied) != string(data) {
+		t.Errorf("Copy should preserve bytes, got %q (%v)", copied, err)
+	}
+	if _, err := client.Download("2022/photo.jpg"); err != nil {
+		t.Errorf("Copy should keep the source: %v", err)
+	}
+
+	if err := mover.Move("2022/photo.jpg", "archive/2022/photo.jpg"); err != nil {
+		t.Fatalf("Move failed: %v", err)
+	}
+	moved, err := client.Download("archive/2022/photo.jpg")
+	if err != nil || string(moved) != string(data) {
+		t.Errorf("Move should preserve bytes, got %q (%v)", moved, err)
+	}
+	if _, err := client.Stat("2022/photo.jpg"); err == nil {
+		t.Error("Move should remove the source")
+	}
+
+	if err := mover.Move("missing.jpg", "other.jpg"); err == nil {
+		t.Error("Expected error moving a missing object")
+	}
+}
+
+// testMetadataUploader checks that metadata uploaded with an object round-trips
+// through Stat, follows copies and moves, and that plain uploads carry none
+func testMetadataUploader(t *testing.T, client Client) {
+	t.Helper()
+	uploader, ok := client.(MetadataUploader)
+	if !ok {
+		t.Fatalf("%T does not implement MetadataUploader", client)
+	}
+	meta := map[string]string{
+		MetaOrigSize: "5",
+		MetaMtime:    "2024-01-02T03:04:05Z",
+		MetaSHA256:   "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+	}
+	if err := uploader.UploadWithMeta("docs/hello.txt", []byte("encrypted hello"), meta); err != nil {
+		t.Fatalf("UploadWithMeta failed: %v", err)
+	}
+
+	info, err := client.Stat("docs/hello.txt")
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if !maps.Equal(info.Metadata, meta) {
+		t.Errorf("Expected metadata %v, got %v", meta, info.Metadata)
+	}
+	if info.Size != 5 {
+		t.Errorf("Stat should report the original size 5, got %d", info.Size)
+	}
+
+	keys, err := client.List("")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if !slices.Equal(keys, []string{"docs/hello.txt"}) {
+		t.Errorf("Metadata should not show up as objects, got %v", keys)
+	}
+
+	if mover, ok := client.(Mover); ok {
+		if err := mover.Move("docs/hello.txt", "archive/hello.txt"); err != nil {
+			t.Fatalf("Move failed: %v", err)
+		}
+		if info, err := client.Stat("archive/hello.txt"); err != nil || !maps.Equal(info.Metadata, meta) {
+			t.Errorf("Move should keep the metadata, got %v (%v)", info.Metadata, err)
+		}
+	}
+
+	if err := client.Upload("plain.txt", []byte("no metadata")); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	info, err = client.Stat("plain.txt")
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if len(info.Metadata) != 0 || info.Size != int64(len("no metadata")) {
+		t.Errorf("Plain upload should have no metadata and the stored size, got %+v", info)
+	}
+}
+
+// testRangeDownloader checks that client streams an object from any offset up
+// to its size and rejects offsets beyond it
+func testRangeDownloader(t *testing.T, client Client) {
+	t.Helper()
+	if _, ok := client.(RangeDownloader); !ok {
+		t.Fatalf("%T does not implement RangeDownloader", client)
+	}
+	data := []byte("0123456789abcdef")
+	if err := client.Upload("video/clip.bin", data); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+
+	for _, offset := range []int64{0, 1, 10, int64(len(data))} {
+		stream, err := DownloadStreamRange(context.Background(), client, "video/clip.bin", offset)
+		if err != nil {
+			t.Fatalf("DownloadStreamRange from %d failed: %v", offset, err)
+		}
+		got, err := io.ReadAll(stream)
+		stream.Close()
+		if err != nil || string(got) != string(data[offset:]) {
+			t.Errorf("From offset %d expected %q, got %q (%v)", offset, data[offset:], got, err)
+		}
+	}
+
+	if _, err := DownloadStreamRange(context.Background(), client, "video/clip.bin", int64(len(data))+1); !errors.Is(err, ErrInvalidRange) {
+		t.Errorf("Expected ErrInvalidRange past the end, got %v", err)
+	}
+	if _, err := DownloadStreamRange(context.Background(), client, "missing.bin", 0); err == nil {
+		t.Error("Expected error streaming a missing object")
+	}
+}
+
+// plainClient hides the optional interfaces of the wrapped Client
+type plainClient struct{ Client }
+
+func TestDownloadStreamRange_Fallback(t *testing.T) {
+	client := plainClient{NewMemoryClient()}
+	if err := client.Upload("notes.txt", []byte("hello world")); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	stream, err := DownloadStreamRange(context.Background(), client, "notes.txt", 6)
+	if err != nil {
+		t.Fatalf("DownloadStreamRange failed: %v", err)
+	}
+	defer stream.Close()
+	if got, err := io.ReadAll(stream); err != nil || string(got) != "world" {
+		t.Errorf("Expected %q, got %q (%v)", "world", got, err)
+	}
+	if _, err := DownloadStreamRange(context.Background(), client, "notes.txt", 12); !errors.Is(err, ErrInvalidRange) {
+		t.Errorf("Expected ErrInvalidRange past the end, got %v", err)
+	}
+}
@@ -0,0 +1,157 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	_ Client           = (*memoryClient)(nil)
+	_ Mover            = (*memoryClient)(nil)
+	_ MetadataUploader = (*memoryClient)(nil)
+	_ RangeDownloader  = (*memoryClient)(nil)
+)
+
+type memoryObject struct {
+	data    []byte
+	modTime time.Time
+	meta    map[string]string
+}
+
+// memoryClient keeps objects in memory, for tests and demos
+type memoryClient struct {
+	mu      sync.RWMutex
+	objects map[string]memoryObject
+}
+
+// NewMemoryClient creates an empty in-memory storage client
+func NewMemoryClient() Client {
+	return &memoryClient{objects: make(map[string]memoryObject)}
+}
+
+// List returns the keys under prefix in sorted order
+func (m *memoryClient) List(prefix string) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var out []string
+	for key := range m.objects {
+		if prefix == "" || strings.HasPrefix(key, prefix) {
+			out = append(out, key)
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// ListPage returns one page of the sorted keys under prefix
+func (m *memoryClient) ListPage(prefix, continuationToken string, max int) ([]string, string, error) {
+	keys, err := m.List(prefix)
+	if err != nil {
+		return nil, "", err
+	}
+	return pageKeys(keys, continuationToken, max)
+}
+
+// ListWithDelimiter groups the keys under prefix on their first segment after it
+func (m *memoryClient) ListWithDelimiter(prefix, delimiter string) ([]string, []string, error) {
+	keys, err := m.List(prefix)
+	if err != nil {
+		return nil, nil, err
+	}
+	direct, commonPrefixes := groupByDelimiter(keys, prefix, delimiter)
+	return direct, commonPrefixes, nil
+}
+
+func (m *memoryClient) Upload(key string, data []byte) error {
+	return m.UploadWithMeta(key, data, nil)
+}
+
+// UploadWithMeta stores the object together with a copy of meta
+func (m *memoryClient) UploadWithMeta(key string, data []byte, meta map[string]string) error {
+	if key == "" {
+		return fmt.Errorf("empty key")
+	}
+	// 复制数据，避免调用方之后修改
+	stored := make([]byte, len(data))
+	copy(stored, data)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[key] = memoryObject{data: stored, modTime: time.Now(), meta: maps.Clone(meta)}
+	return nil
+}
+
+func (m *memoryClient) Download(key string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	obj, ok := m.objects[key]
+	if !ok {
+		return nil, fmt.Errorf("object %s: %w", key, os.ErrNotExist)
+	}
+	data := make([]byte, len(obj.data))
+	copy(data, obj.data)
+	return data, nil
+}
+
+func (m *memoryClient) DownloadStreamRange(ctx context.Context, key string, offset int64) (io.ReadCloser, error) {
+	data, err := m.Download(key)
+	if err != nil {
+		return nil, err
+	}
+	return rangeReader(data, key, offset)
+}
+
+func (m *memoryClient) Stat(key string) (ObjectInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	obj, ok := m.objects[key]
+	if !ok {
+		return ObjectInfo{}, fmt.Errorf("object %s: %w", key, os.ErrNotExist)
+	}
+	info := ObjectInfo{Key: key, Size: int64(len(obj.data)), LastModified: obj.modTime}
+	return withMetadata(info, maps.Clone(obj.meta)), nil
+}
+
+func (m *memoryClient) Delete(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.objects, key)
+	return nil
+}
+
+func (m *memoryClient) Copy(srcKey, dstKey string) error {
+	if dstKey == "" {
+		return fmt.Errorf("empty key")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	obj, ok := m.objects[srcKey]
+	if !ok {
+		return fmt.Errorf("object %s: %w", srcKey, os.ErrNotExist)
+	}
+	// 存储的数据不会被原地修改，可以共享
+	m.objects[dstKey] = memoryObject{data: obj.data, modTime: time.Now(), meta: obj.meta}
+	return nil
+}
+
+func (m *memoryClient) Move(srcKey, dstKey string) error {
+	if dstKey == "" {
+		return fmt.Errorf("empty key")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	obj, ok := m.objects[srcKey]
+	if !ok {
+		return fmt.Errorf("object %s: %w", srcKey, os.ErrNotExist)
+	}
+	delete(m.objects, srcKey)
+	m.objects[dstKey] = obj
+	return nil
+}
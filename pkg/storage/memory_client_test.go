@@ -0,0 +1,244 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestNewMemoryClient(t *testing.T) {
+	client := NewMemoryClient()
+	if client == nil {
+		t.Fatal("NewMemoryClient returned nil")
+	}
+
+	files, err := client.List("")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(files) != 0 {
+		t.Errorf("Expected new client to be empty, got %v", files)
+	}
+}
+
+func TestMemoryClient_UploadDownload(t *testing.T) {
+	client := NewMemoryClient()
+
+	testCases := []struct {
+		name string
+		key  string
+		data []byte
+	}{
+		{"simple file", "test.txt", []byte("hello world")},
+		{"nested path", "folder/subfolder/file.txt", []byte("nested content")},
+		{"binary data", "binary.bin", []byte{0x00, 0x01, 0x02, 0xFF, 0xFE, 0xFD}},
+		{"empty file", "empty.txt", []byte{}},
+		{"unicode filename", "测试文件.txt", []byte("unicode content")},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := client.Upload(tc.key, tc.data); err != nil {
+				t.Fatalf("Upload failed: %v", err)
+			}
+
+			downloaded, err := client.Download(tc.key)
+			if err != nil {
+				t.Fatalf("Download failed: %v", err)
+			}
+			if !bytes.Equal(downloaded, tc.data) {
+				t.Errorf("Data mismatch. Expected: %v, Got: %v", tc.data, downloaded)
+			}
+		})
+	}
+}
+
+func TestMemoryClient_UploadEmptyKey(t *testing.T) {
+	client := NewMemoryClient()
+
+	if err := client.Upload("", []byte("data")); err == nil {
+		t.Error("Expected error for empty key")
+	}
+}
+
+func TestMemoryClient_DownloadNonExistent(t *testing.T) {
+	client := NewMemoryClient()
+
+	_, err := client.Download("nonexistent.txt")
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected not-exist error, got %v", err)
+	}
+}
+
+func TestMemoryClient_DataIsCopied(t *testing.T) {
+	client := NewMemoryClient()
+
+	data := []byte("original")
+	if err := client.Upload("copy.txt", data); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	data[0] = 'X'
+
+	downloaded, err := client.Download("copy.txt")
+	if err != nil {
+		t.Fatalf("Download failed: %v", err)
+	}
+	if string(downloaded) != "original" {
+		t.Errorf("Stored data changed with the caller's slice: %s", downloaded)
+	}
+
+	downloaded[0] = 'Y'
+	again, _ := client.Download("copy.txt")
+	if string(again) != "original" {
+		t.Errorf("Stored data changed with a downloaded slice: %s", again)
+	}
+}
+
+func TestMemoryClient_List(t *testing.T) {
+	client := NewMemoryClient()
+
+	for _, key := range []string{"file1.txt", "folder/file2.txt", "folder/file3.txt", "other/file4.txt", "folder/sub/file5.txt"} {
+		if err := client.Upload(key, []byte(key)); err != nil {
+			t.Fatalf("Upload failed for %s: %v", key, err)
+		}
+	}
+
+	testCases := []struct {
+		name     string
+		prefix   string
+		expected []string
+	}{
+		{"list all", "", []string{"file1.txt", "folder/file2.txt", "folder/file3.txt", "folder/sub/file5.txt", "other/file4.txt"}},
+		{"list folder prefix", "folder/", []string{"folder/file2.txt", "folder/file3.txt", "folder/sub/file5.txt"}},
+		{"list other prefix", "other/", []string{"other/file4.txt"}},
+		{"list non-existent prefix", "nonexistent/", nil},
+		{"list specific file prefix", "file1", []string{"file1.txt"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			files, err := client.List(tc.prefix)
+			if err != nil {
+				t.Fatalf("List failed: %v", err)
+			}
+			// List returns keys in sorted order
+			if !slices.Equal(files, tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, files)
+			}
+		})
+	}
+}
+
+func TestMemoryClient_ListPage(t *testing.T) {
+	testListPage(t, NewMemoryClient())
+}
+
+func TestMemoryClient_ListWithDelimiter(t *testing.T) {
+	testListWithDelimiter(t, NewMemoryClient())
+}
+
+func TestMemoryClient_CopyAndMove(t *testing.T) {
+	testMover(t, NewMemoryClient())
+}
+
+func TestMemoryClient_UploadWithMeta(t *testing.T) {
+	testMetadataUploader(t, NewMemoryClient())
+}
+
+func TestMemoryClient_DownloadStreamRange(t *testing.T) {
+	testRangeDownloader(t, NewMemoryClient())
+}
+
+func TestMemoryClient_Stat(t *testing.T) {
+	client := NewMemoryClient()
+
+	data := []byte("stat content")
+	if err := client.Upload("folder/stat.txt", data); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+
+	info, err := client.Stat("folder/stat.txt")
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if info.Key != "folder/stat.txt" || info.Size != int64(len(data)) {
+		t.Errorf("Unexpected object info: %+v", info)
+	}
+	if info.LastModified.IsZero() {
+		t.Error("LastModified should be set")
+	}
+
+	if _, err := client.Stat("missing.txt"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected not-exist error, got %v", err)
+	}
+}
+
+func TestMemoryClient_Delete(t *testing.T) {
+	client := NewMemoryClient()
+
+	if err := client.Upload("delete_me.txt", []byte("data")); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+
+	if err := client.Delete("delete_me.txt"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := client.Download("delete_me.txt"); err == nil {
+		t.Error("Deleted object should not be downloadable")
+	}
+
+	// Deleting a missing key is not an error
+	if err := client.Delete("delete_me.txt"); err != nil {
+		t.Errorf("Delete of missing key should not return error, got: %v", err)
+	}
+}
+
+func TestMemoryClient_ConcurrentOperations(t *testing.T) {
+	client := NewMemoryClient()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+
+			key := fmt.Sprintf("concurrent/file%d.txt", id)
+			data := []byte(fmt.Sprintf("data%d", id))
+
+			if err := client.Upload(key, data); err != nil {
+				t.Errorf("Concurrent upload failed for %s: %v", key, err)
+				return
+			}
+
+			downloaded, err := client.Download(key)
+			if err != nil {
+				t.Errorf("Concurrent download failed for %s: %v", key, err)
+				return
+			}
+			if !bytes.Equal(downloaded, data) {
+				t.Errorf("Concurrent data mismatch for %s", key)
+			}
+
+			if _, err := client.List("concurrent/"); err != nil {
+				t.Errorf("Concurrent list failed: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	files, err := client.List("concurrent/")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(files) != 10 {
+		t.Errorf("Expected 10 files, got %d", len(files))
+	}
+}
+
+func TestMemoryClient_InterfaceCompliance(t *testing.T) {
+	var _ Client = NewMemoryClient()
+}
@@ -0,0 +1,33 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Namespacer is implemented by clients that can keep their keys in a
+// namespace: a folder of the bucket, below its work dir, that is prepended to
+// every key and stripped when listing. Machines sharing one bucket use
+// different namespaces so their keys never collide, and can still reach each
+// other's keys explicitly.
+type Namespacer interface {
+	// Namespace returns the namespace of the client, "" when it has none
+	Namespace() string
+	// WithNamespace returns a client for namespace of the same bucket and
+	// work dir, e.g. to list the keys of another machine; "" is the work dir itself
+	WithNamespace(namespace string) (Client, error)
+	// CopyFromNamespace copies srcKey of namespace to dstKey of this client
+	CopyFromNamespace(namespace, srcKey, dstKey string) error
+}
+
+// normalizeNamespace returns namespace as a key prefix, rejecting namespaces
+// that would leave the work dir
+func normalizeNamespace(namespace string) (string, error) {
+	ns := NormalizeKey(namespace)
+	for _, part := range strings.Split(ns, "/") {
+		if part == ".." {
+			return "", fmt.Errorf("invalid namespace %q", namespace)
+		}
+	}
+	return ns, nil
+}
@@ -0,0 +1,202 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+// obfuscatedClient stores every key under its keyed hash in another client,
+// so the backend never sees paths or file names
+type obfuscatedClient struct {
+	client      Client
+	hash        func(key string) (string, error)
+	index       func() ([]string, error)
+	passthrough map[string]bool
+}
+
+// NewObfuscatedClient returns a client that stores key as hash(key) in client.
+// The hashes cannot be listed back, so listings come from index, which must
+// return every plain key stored through the client. Keys in passthrough, such
+// as the object index itself, are stored unchanged. The optional Mover and
+// Presigner interfaces are implemented when client implements them;
+// MetadataUploader and RangeDownloader always are and fall back to Upload
+// without metadata and a full Download.
+func NewObfuscatedClient(client Client, hash func(key string) (string, error), index func() ([]string, error), passthrough ...string) Client {
+	o := &obfuscatedClient{client: client, hash: hash, index: index, passthrough: map[string]bool{}}
+	for _, key := range passthrough {
+		o.passthrough[key] = true
+	}
+
+	mover, canMove := client.(Mover)
+	presigner, canPresign := client.(Presigner)
+	switch {
+	case canMove && canPresign:
+		return &struct {
+			*obfuscatedClient
+			*obfuscatedMover
+			*obfuscatedPresigner
+		}{o, &obfuscatedMover{o, mover}, &obfuscatedPresigner{o, presigner}}
+	case canMove:
+		return &struct {
+			*obfuscatedClient
+			*obfuscatedMover
+		}{o, &obfuscatedMover{o, mover}}
+	case canPresign:
+		return &struct {
+			*obfuscatedClient
+			*obfuscatedPresigner
+		}{o, &obfuscatedPresigner{o, presigner}}
+	default:
+		return o
+	}
+}
+
+// key returns the key in the wrapped client
+func (o *obfuscatedClient) key(key string) (string, error) {
+	key = NormalizeKey(key)
+	if o.passthrough[key] {
+		return key, nil
+	}
+	return o.hash(key)
+}
+
+func (o *obfuscatedClient) List(prefix string) ([]string, error) {
+	keys, err := o.index()
+	if err != nil {
+		return nil, err
+	}
+	var matched []string
+	for _, key := range keys {
+		if strings.HasPrefix(key, prefix) {
+			matched = append(matched, key)
+		}
+	}
+	slices.Sort(matched)
+	return matched, nil
+}
+
+func (o *obfuscatedClient) ListPage(prefix, continuationToken string, max int) ([]string, string, error) {
+	keys, err := o.List(prefix)
+	if err != nil {
+		return nil, "", err
+	}
+	return pageKeys(keys, continuationToken, max)
+}
+
+func (o *obfuscatedClient) ListWithDelimiter(prefix, delimiter string) ([]string, []string, error) {
+	keys, err := o.List(prefix)
+	if err != nil {
+		return nil, nil, err
+	}
+	direct, commonPrefixes := groupByDelimiter(keys, prefix, delimiter)
+	return direct, commonPrefixes, nil
+}
+
+func (o *obfuscatedClient) Upload(key string, data []byte) error {
+	hashed, err := o.key(key)
+	if err != nil {
+		return err
+	}
+	return o.client.Upload(hashed, data)
+}
+
+// UploadWithMeta uploads with metadata when the wrapped client supports it
+func (o *obfuscatedClient) UploadWithMeta(key string, data []byte, meta map[string]string) error {
+	hashed, err := o.key(key)
+	if err != nil {
+		return err
+	}
+	if uploader, ok := o.client.(MetadataUploader); ok {
+		return uploader.UploadWithMeta(hashed, data, meta)
+	}
+	return o.client.Upload(hashed, data)
+}
+
+func (o *obfuscatedClient) Download(key string) ([]byte, error) {
+	hashed, err := o.key(key)
+	if err != nil {
+		return nil, err
+	}
+	return o.client.Download(hashed)
+}
+
+// Ping checks the wrapped client's backend when it supports it
+func (o *obfuscatedClient) Ping(ctx context.Context) error {
+	return Ping(ctx, o.client)
+}
+
+// DownloadStreamRange streams with a range GET when the wrapped client supports it
+func (o *obfuscatedClient) DownloadStreamRange(ctx context.Context, key string, offset int64) (io.ReadCloser, error) {
+	hashed, err := o.key(key)
+	if err != nil {
+		return nil, err
+	}
+	return DownloadStreamRange(ctx, o.client, hashed, offset)
+}
+
+func (o *obfuscatedClient) Stat(key string) (ObjectInfo, error) {
+	hashed, err := o.key(key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	info, err := o.client.Stat(hashed)
+	info.Key = NormalizeKey(key)
+	return info, err
+}
+
+func (o *obfuscatedClient) Delete(key string) error {
+	hashed, err := o.key(key)
+	if err != nil {
+		return err
+	}
+	return o.client.Delete(hashed)
+}
+
+// obfuscatedMover moves objects between hashed keys
+type obfuscatedMover struct {
+	o     *obfuscatedClient
+	mover Mover
+}
+
+// keys returns the wrapped keys of srcKey and dstKey
+func (m *obfuscatedMover) keys(srcKey, dstKey string) (string, string, error) {
+	src, err := m.o.key(srcKey)
+	if err != nil {
+		return "", "", err
+	}
+	dst, err := m.o.key(dstKey)
+	return src, dst, err
+}
+
+func (m *obfuscatedMover) Copy(srcKey, dstKey string) error {
+	src, dst, err := m.keys(srcKey, dstKey)
+	if err != nil {
+		return err
+	}
+	return m.mover.Copy(src, dst)
+}
+
+func (m *obfuscatedMover) Move(srcKey, dstKey string) error {
+	src, dst, err := m.keys(srcKey, dstKey)
+	if err != nil {
+		return err
+	}
+	return m.mover.Move(src, dst)
+}
+
+// obfuscatedPresigner presigns objects under their hashed keys
+type obfuscatedPresigner struct {
+	o         *obfuscatedClient
+	presigner Presigner
+}
+
+func (p *obfuscatedPresigner) Presign(key string, expiry time.Duration) (string, error) {
+	hashed, err := p.o.key(key)
+	if err != nil {
+		return "", err
+	}
+	return p.presigner.Presign(hashed, expiry)
+}
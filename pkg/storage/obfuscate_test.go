@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// newTestObfuscatedClient returns an obfuscated client over inner whose index
+// maps the hashed keys in inner back to the keys that produced them
+func newTestObfuscatedClient(inner Client, passthrough ...string) Client {
+	var mu sync.Mutex
+	plain := map[string]string{}
+	hash := func(key string) (string, error) {
+		sum := sha256.Sum256([]byte(key))
+		hashed := hex.EncodeToString(sum[:])
+		mu.Lock()
+		plain[hashed] = key
+		mu.Unlock()
+		return hashed, nil
+	}
+	index := func() ([]string, error) {
+		stored, err := inner.List("")
+		if err != nil {
+			return nil, err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		var keys []string
+		for _, key := range stored {
+			if p, ok := plain[key]; ok {
+				keys = append(keys, p)
+			}
+		}
+		return keys, nil
+	}
+	return NewObfuscatedClient(inner, hash, index, passthrough...)
+}
+
+func TestObfuscatedClient_Contract(t *testing.T) {
+	t.Run("ListPage", func(t *testing.T) { testListPage(t, newTestObfuscatedClient(NewMemoryClient())) })
+	t.Run("ListWithDelimiter", func(t *testing.T) { testListWithDelimiter(t, newTestObfuscatedClient(NewMemoryClient())) })
+	t.Run("Mover", func(t *testing.T) { testMover(t, newTestObfuscatedClient(NewMemoryClient())) })
+	t.Run("MetadataUploader", func(t *testing.T) { testMetadataUploader(t, newTestObfuscatedClient(NewMemoryClient())) })
+	t.Run("RangeDownloader", func(t *testing.T) { testRangeDownloader(t, newTestObfuscatedClient(NewMemoryClient())) })
+}
+
+func TestObfuscatedClient_KeysAreOpaque(t *testing.T) {
+	inner := NewMemoryClient()
+	client := newTestObfuscatedClient(inner, "index")
+
+	for _, key := range []string{"docs/secret-plan.txt", "index"} {
+		if err := client.Upload(key, []byte(key)); err != nil {
+			t.Fatalf("Upload failed: %v", err)
+		}
+	}
+
+	stored, err := inner.List("")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	slices.Sort(stored)
+	if len(stored) != 2 || stored[1] != "index" {
+		t.Fatalf("Expected a hashed key and the passthrough key, got %v", stored)
+	}
+	if strings.Contains(stored[0], "secret") || strings.Contains(stored[0], "/") {
+		t.Errorf("Stored key %q reveals the path", stored[0])
+	}
+
+	info, err := client.Stat("docs/secret-plan.txt")
+	if err != nil || info.Key != "docs/secret-plan.txt" {
+		t.Errorf("Stat should report the plain key, got %+v, %v", info, err)
+	}
+	if err := client.Delete("docs/secret-plan.txt"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := client.Download("docs/secret-plan.txt"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected os.ErrNotExist after delete, got %v", err)
+	}
+}
+
+func TestObfuscatedClient_HashError(t *testing.T) {
+	errHash := errors.New("no key")
+	client := NewObfuscatedClient(NewMemoryClient(),
+		func(string) (string, error) { return "", errHash },
+		func() ([]string, error) { return nil, nil })
+
+	if err := client.Upload("a.txt", []byte("a")); !errors.Is(err, errHash) {
+		t.Errorf("Expected hash error from Upload, got %v", err)
+	}
+	if _, err := client.Download("a.txt"); !errors.Is(err, errHash) {
+		t.Errorf("Expected hash error from Download, got %v", err)
+	}
+}
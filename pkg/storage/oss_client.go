@@ -2,43 +2,128 @@ package storage
 
 import (
 	"bytes"
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
+	"net/url"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/aliyun/alibabacloud-oss-go-sdk-v2/oss"
 	"github.com/aliyun/alibabacloud-oss-go-sdk-v2/oss/credentials"
+	"github.com/aliyun/alibabacloud-oss-go-sdk-v2/oss/transport"
 )
 
-var _ Client = (*ossClient)(nil)
+var (
+	_ Client           = (*ossClient)(nil)
+	_ Presigner        = (*ossClient)(nil)
+	_ Mover            = (*ossClient)(nil)
+	_ MetadataUploader = (*ossClient)(nil)
+	_ RangeDownloader  = (*ossClient)(nil)
+	_ Pinger           = (*ossClient)(nil)
+	_ Namespacer       = (*ossClient)(nil)
+)
 
 type ossClient struct {
 	client     *oss.Client
 	bucketName string
-	workDir    string
+	workDir    string // 所有键的前缀：baseDir 加上命名空间
+	baseDir    string // 配置的 workDir
+	namespace  string
+
+	// 上传和下载的带宽限制，nil 表示不限制
+	uploadLimiter   *RateLimiter
+	downloadLimiter *RateLimiter
+
+	// 超过 multipartThreshold 字节的对象分片并行上传
+	multipartThreshold int64
+	partSize           int64
+	partConcurrency    int
+}
+
+// OSSOptions tunes the HTTP client used by the OSS client; zero values keep the SDK defaults
+type OSSOptions struct {
+	ConnectTimeout time.Duration // 建立连接的超时时间
+	ReadTimeout    time.Duration // 单次读取响应的超时时间
+	WriteTimeout   time.Duration // 单次写入请求的超时时间
+	RequestTimeout time.Duration // 整个请求的超时时间，0 表示不限制，大文件上传时应保持为 0 或足够大
+	Proxy          string        // HTTP 代理地址，如 http://127.0.0.1:8080
+	MaxUploadBps   int64         // 上传带宽上限（字节/秒），0 表示不限制
+	MaxDownloadBps int64         // 下载带宽上限（字节/秒），0 表示不限制
+
+	MultipartThreshold int64 // 超过该大小（字节）的对象分片上传，0 使用 DefaultMultipartThreshold，负数表示不分片
+	PartSize           int64 // 分片大小（字节），0 使用 DefaultPartSize
+	PartConcurrency    int   // 同时上传的分片数，0 使用 DefaultPartConcurrency
+
+	Namespace string // 加在 workDir 之后的命名空间，多台机器共用一个 bucket 时各用一个
+}
+
+// newOSSHTTPClient builds the HTTP client for the OSS SDK from opts
+func newOSSHTTPClient(opts OSSOptions) (*http.Client, error) {
+	tcfg := &transport.Config{}
+	if opts.ConnectTimeout > 0 {
+		tcfg.ConnectTimeout = oss.Ptr(opts.ConnectTimeout)
+	}
+	// SDK 对读和写使用同一个超时，取两者中较大的一个
+	if readWrite := max(opts.ReadTimeout, opts.WriteTimeout); readWrite > 0 {
+		tcfg.ReadWriteTimeout = oss.Ptr(readWrite)
+	}
+
+	var custom []func(*http.Transport)
+	if opts.Proxy != "" {
+		proxyURL, err := url.Parse(opts.Proxy)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid proxy %q", opts.Proxy)
+		}
+		custom = append(custom, transport.HttpProxy(proxyURL))
+	}
+
+	client := transport.NewHttpClient(tcfg, custom...)
+	client.Timeout = opts.RequestTimeout
+	return client, nil
 }
 
 // NewOSSClient creates a new OSS client using SDK v2
-func NewOSSClient(endpoint, accessKeyID, accessKeySecret, bucketName, region, workDir string) (Client, error) {
+func NewOSSClient(endpoint, accessKeyID, accessKeySecret, bucketName, region, workDir string, opts OSSOptions) (Client, error) {
 	// Create credentials provider
 	credentialsProvider := credentials.NewStaticCredentialsProvider(accessKeyID, accessKeySecret)
 
+	httpClient, err := newOSSHTTPClient(opts)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create OSS client configuration
 	cfg := oss.LoadDefaultConfig().
 		WithCredentialsProvider(credentialsProvider).
 		WithRegion(region).
-		WithEndpoint(endpoint)
+		WithEndpoint(endpoint).
+		WithHttpClient(httpClient)
 
 	// Create OSS client
 	client := oss.NewClient(cfg)
 
 	workDir = strings.Replace(workDir, "//", "/", -1)
 	workDir = strings.TrimPrefix(workDir, "/")
+	namespace, err := normalizeNamespace(opts.Namespace)
+	if err != nil {
+		return nil, err
+	}
 	return &ossClient{
-		client:     client,
-		bucketName: bucketName,
-		workDir:    workDir,
+		client:             client,
+		bucketName:         bucketName,
+		workDir:            namespacedWorkDir(workDir, namespace),
+		baseDir:            workDir,
+		namespace:          namespace,
+		uploadLimiter:      NewRateLimiter(opts.MaxUploadBps),
+		downloadLimiter:    NewRateLimiter(opts.MaxDownloadBps),
+		multipartThreshold: cmp.Or(opts.MultipartThreshold, DefaultMultipartThreshold),
+		partSize:           cmp.Or(opts.PartSize, DefaultPartSize),
+		partConcurrency:    cmp.Or(opts.PartConcurrency, DefaultPartConcurrency),
 	}, nil
 }
 
@@ -49,7 +134,7 @@ func (o *ossClient) List(prefix string) ([]string, error) {
 	// Create list objects request
 	request := &oss.ListObjectsV2Request{
 		Bucket:  oss.Ptr(o.bucketName),
-		Prefix:  oss.Ptr(o.getFullPath(prefix)),
+		Prefix:  oss.Ptr(o.listPrefix(prefix)),
 		MaxKeys: int32(1000),
 	}
 
@@ -64,14 +149,7 @@ func (o *ossClient) List(prefix string) ([]string, error) {
 
 		// Collect object keys and remove workDir prefix
 		for _, object := range result.Contents {
-			if object.Key != nil {
-				key := *object.Key
-				// Remove workDir prefix from returned keys
-				if o.workDir != "" && strings.HasPrefix(key, o.workDir+"/") {
-					key = strings.TrimPrefix(key, o.workDir+"/")
-				} else if o.workDir != "" && key == o.workDir {
-					key = ""
-				}
+			if key, ok := o.ownKey(object.Key); ok {
 				objects = append(objects, key)
 			}
 		}
@@ -92,17 +170,141 @@ func (o *ossClient) List(prefix string) ([]string, error) {
 	return objects, nil
 }
 
+// ListPage lists one page of object keys under prefix, passing the OSS continuation token through
+func (o *ossClient) ListPage(prefix, continuationToken string, max int) ([]string, string, error) {
+	if max <= 0 {
+		max = DefaultPageSize
+	}
+	request := &oss.ListObjectsV2Request{
+		Bucket:  oss.Ptr(o.bucketName),
+		Prefix:  oss.Ptr(o.listPrefix(prefix)),
+		MaxKeys: int32(max),
+	}
+	if continuationToken != "" {
+		request.ContinuationToken = oss.Ptr(continuationToken)
+	}
+
+	ctx := context.Background()
+	result, err := o.client.ListObjectsV2(ctx, request)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list objects: %w", err)
+	}
+
+	var objects []string
+	for _, object := range result.Contents {
+		if key, ok := o.ownKey(object.Key); ok {
+			objects = append(objects, key)
+		}
+	}
+
+	nextToken := ""
+	if result.IsTruncated && result.NextContinuationToken != nil {
+		nextToken = *result.NextContinuationToken
+	}
+	return objects, nextToken, nil
+}
+
+// Ping lists at most one object under the work dir, which fails when the
+// endpoint is unreachable, the bucket does not exist or the credentials are rejected
+func (o *ossClient) Ping(ctx context.Context) error {
+	request := &oss.ListObjectsV2Request{
+		Bucket:  oss.Ptr(o.bucketName),
+		Prefix:  oss.Ptr(o.listPrefix("")),
+		MaxKeys: 1,
+	}
+	if _, err := o.client.ListObjectsV2(ctx, request); err != nil {
+		return fmt.Errorf("failed to reach bucket %s: %w", o.bucketName, err)
+	}
+	return nil
+}
+
+// ListWithDelimiter lists the objects directly under prefix and its sub-folders
+// using the native OSS delimiter support
+func (o *ossClient) ListWithDelimiter(prefix, delimiter string) ([]string, []string, error) {
+	request := &oss.ListObjectsV2Request{
+		Bucket:    oss.Ptr(o.bucketName),
+		Prefix:    oss.Ptr(o.listPrefix(prefix)),
+		Delimiter: oss.Ptr(delimiter),
+		MaxKeys:   int32(DefaultPageSize),
+	}
+
+	ctx := context.Background()
+
+	var keys, commonPrefixes []string
+	for {
+		result, err := o.client.ListObjectsV2(ctx, request)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to list objects: %w", err)
+		}
+
+		for _, object := range result.Contents {
+			if key, ok := o.ownKey(object.Key); ok {
+				keys = append(keys, key)
+			}
+		}
+		for _, commonPrefix := range result.CommonPrefixes {
+			if key, ok := o.ownKey(commonPrefix.Prefix); ok {
+				commonPrefixes = append(commonPrefixes, key)
+			}
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == nil {
+			break
+		}
+		request.ContinuationToken = result.NextContinuationToken
+	}
+
+	return keys, commonPrefixes, nil
+}
+
+// listPrefix returns the OSS prefix listing prefix; the root of the work dir
+// ends with "/" so that a sibling such as fers/pc2 is not listed for fers/pc
+func (o *ossClient) listPrefix(prefix string) string {
+	fullPrefix := o.getFullPath(prefix)
+	if prefix == "" && o.workDir != "" {
+		// 列出 workDir 下的内容，而不是 workDir 本身
+		fullPrefix += "/"
+	}
+	return fullPrefix
+}
+
+// ownKey returns a listed key without the workDir prefix, and false for keys
+// that are not below the work dir
+func (o *ossClient) ownKey(key *string) (string, bool) {
+	if key == nil {
+		return "", false
+	}
+	workDir := strings.TrimSuffix(o.workDir, "/")
+	if workDir == "" {
+		return *key, true
+	}
+	return strings.CutPrefix(*key, workDir+"/")
+}
+
 // Upload object with given key and content
 func (o *ossClient) Upload(key string, data []byte) error {
-	reader := bytes.NewReader(data)
+	return o.UploadWithMeta(key, data, nil)
+}
+
+// UploadWithMeta uploads an object with user metadata, sent as x-oss-meta-* headers
+func (o *ossClient) UploadWithMeta(key string, data []byte, meta map[string]string) error {
+	ctx := context.Background()
+	if o.multipartThreshold > 0 && int64(len(data)) > o.multipartThreshold {
+		return o.uploadMultipart(ctx, key, data, meta)
+	}
+	reader := NewThrottledReader(ctx, bytes.NewReader(data), o.uploadLimiter)
 
 	request := &oss.PutObjectRequest{
-		Bucket: oss.Ptr(o.bucketName),
-		Key:    oss.Ptr(o.getFullPath(key)),
-		Body:   reader,
+		Bucket:   oss.Ptr(o.bucketName),
+		Key:      oss.Ptr(o.getFullPath(key)),
+		Body:     reader,
+		Metadata: meta,
+	}
+	if contentType := meta[MetaContentType]; contentType != "" {
+		// 便于用其他工具浏览存储桶时按明文类型识别
+		request.ContentType = oss.Ptr(contentType)
 	}
 
-	ctx := context.Background()
 	_, err := o.client.PutObject(ctx, request)
 	if err != nil {
 		return fmt.Errorf("failed to upload object %s: %w", key, err)
@@ -121,12 +323,12 @@ func (o *ossClient) Download(key string) ([]byte, error) {
 	ctx := context.Background()
 	result, err := o.client.GetObject(ctx, request)
 	if err != nil {
-		return nil, fmt.Errorf("failed to download object %s: %w", key, err)
+		return nil, fmt.Errorf("failed to download object %s: %w", key, notFoundError(err))
 	}
 	defer result.Body.Close()
 
 	// Read all data
-	data, err := io.ReadAll(result.Body)
+	data, err := io.ReadAll(NewThrottledReader(ctx, result.Body, o.downloadLimiter))
 	if err != nil {
 		return nil, fmt.Errorf("failed to read object data %s: %w", key, err)
 	}
@@ -134,6 +336,159 @@ func (o *ossClient) Download(key string) ([]byte, error) {
 	return data, nil
 }
 
+// DownloadStreamRange streams the object from offset with a range GET
+func (o *ossClient) DownloadStreamRange(ctx context.Context, key string, offset int64) (io.ReadCloser, error) {
+	request := &oss.GetObjectRequest{
+		Bucket: oss.Ptr(o.bucketName),
+		Key:    oss.Ptr(o.getFullPath(key)),
+	}
+	if offset > 0 {
+		request.Range = oss.Ptr(fmt.Sprintf("bytes=%d-", offset))
+		// 超出对象大小时返回 416 而不是整个对象
+		request.RangeBehavior = oss.Ptr("standard")
+	}
+
+	result, err := o.client.GetObject(ctx, request)
+	if err != nil {
+		var serviceErr *oss.ServiceError
+		if errors.As(err, &serviceErr) && serviceErr.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+			return nil, fmt.Errorf("failed to download object %s from %d: %w: %w", key, offset, ErrInvalidRange, err)
+		}
+		return nil, fmt.Errorf("failed to download object %s: %w", key, notFoundError(err))
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{NewThrottledReader(ctx, result.Body, o.downloadLimiter), result.Body}, nil
+}
+
+// notFoundError also wraps os.ErrNotExist when OSS reports that the object
+// does not exist, matching the other clients
+func notFoundError(err error) error {
+	var serviceErr *oss.ServiceError
+	if errors.As(err, &serviceErr) && serviceErr.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %w", os.ErrNotExist, err)
+	}
+	return err
+}
+
+// Stat returns the size and modification time of an object
+func (o *ossClient) Stat(key string) (ObjectInfo, error) {
+	request := &oss.HeadObjectRequest{
+		Bucket: oss.Ptr(o.bucketName),
+		Key:    oss.Ptr(o.getFullPath(key)),
+	}
+
+	ctx := context.Background()
+	result, err := o.client.HeadObject(ctx, request)
+	if err != nil {
+		return ObjectInfo{}, fmt.Errorf("failed to stat object %s: %w", key, notFoundError(err))
+	}
+
+	info := ObjectInfo{Key: key, Size: result.ContentLength}
+	if result.LastModified != nil {
+		info.LastModified = *result.LastModified
+	}
+	return withMetadata(info, result.Metadata), nil
+}
+
+// Presign returns a presigned GET URL for the object valid for expiry
+func (o *ossClient) Presign(key string, expiry time.Duration) (string, error) {
+	request := &oss.GetObjectRequest{
+		Bucket: oss.Ptr(o.bucketName),
+		Key:    oss.Ptr(o.getFullPath(key)),
+	}
+
+	ctx := context.Background()
+	result, err := o.client.Presign(ctx, request, oss.PresignExpires(expiry))
+	if err != nil {
+		return "", fmt.Errorf("failed to presign object %s: %w", key, err)
+	}
+
+	return result.URL, nil
+}
+
+// Copy copies an object on the server side; the copier switches to a
+// multipart copy for objects too large for a single CopyObject
+func (o *ossClient) Copy(srcKey, dstKey string) error {
+	request := &oss.CopyObjectRequest{
+		Bucket:    oss.Ptr(o.bucketName),
+		Key:       oss.Ptr(o.getFullPath(dstKey)),
+		SourceKey: oss.Ptr(o.getFullPath(srcKey)),
+	}
+
+	ctx := context.Background()
+	if _, err := o.client.NewCopier().Copy(ctx, request); err != nil {
+		return fmt.Errorf("failed to copy object %s to %s: %w", srcKey, dstKey, err)
+	}
+	return nil
+}
+
+// Move copies an object on the server side and then deletes the source
+func (o *ossClient) Move(srcKey, dstKey string) error {
+	if err := o.Copy(srcKey, dstKey); err != nil {
+		return err
+	}
+
+	request := &oss.DeleteObjectRequest{
+		Bucket: oss.Ptr(o.bucketName),
+		Key:    oss.Ptr(o.getFullPath(srcKey)),
+	}
+	ctx := context.Background()
+	if _, err := o.client.DeleteObject(ctx, request); err != nil {
+		return fmt.Errorf("failed to delete object %s after copy: %w", srcKey, err)
+	}
+	return nil
+}
+
+// namespacedWorkDir returns the key prefix of namespace below workDir
+func namespacedWorkDir(workDir, namespace string) string {
+	if namespace == "" {
+		return workDir
+	}
+	if workDir = strings.TrimSuffix(workDir, "/"); workDir == "" {
+		return namespace
+	}
+	return workDir + "/" + namespace
+}
+
+// Namespace returns the namespace below the configured work dir
+func (o *ossClient) Namespace() string {
+	return o.namespace
+}
+
+// WithNamespace returns a client for namespace sharing the connection,
+// bandwidth limits and settings of o
+func (o *ossClient) WithNamespace(namespace string) (Client, error) {
+	ns, err := normalizeNamespace(namespace)
+	if err != nil {
+		return nil, err
+	}
+	other := *o
+	other.namespace = ns
+	other.workDir = namespacedWorkDir(o.baseDir, ns)
+	return &other, nil
+}
+
+// CopyFromNamespace copies srcKey of namespace to dstKey of o on the server side
+func (o *ossClient) CopyFromNamespace(namespace, srcKey, dstKey string) error {
+	src, err := o.WithNamespace(namespace)
+	if err != nil {
+		return err
+	}
+	request := &oss.CopyObjectRequest{
+		Bucket:    oss.Ptr(o.bucketName),
+		Key:       oss.Ptr(o.getFullPath(dstKey)),
+		SourceKey: oss.Ptr(src.(*ossClient).getFullPath(srcKey)),
+	}
+
+	ctx := context.Background()
+	if _, err := o.client.NewCopier().Copy(ctx, request); err != nil {
+		return fmt.Errorf("failed to copy object %s of namespace %q to %s: %w", srcKey, namespace, dstKey, err)
+	}
+	return nil
+}
+
 func (o *ossClient) getFullPath(key string) string {
 	// 如果 workDir 为空，直接返回 key
 	if o.workDir == "" {
@@ -154,13 +509,15 @@ func (o *ossClient) getFullPath(key string) string {
 	return strings.Replace(fullPath, "//", "/", -1)
 }
 
+// Delete removes an object; OSS reports success for keys that don't exist
 func (o *ossClient) Delete(key string) error {
+	request := &oss.DeleteObjectRequest{
+		Bucket: oss.Ptr(o.bucketName),
+		Key:    oss.Ptr(o.getFullPath(key)),
+	}
+	ctx := context.Background()
+	if _, err := o.client.DeleteObject(ctx, request); err != nil {
+		return fmt.Errorf("failed to delete object %s: %w", key, err)
+	}
 	return nil
 }
-
-// IsOSSKey checks if the given key looks like an OSS object key
-func IsOSSKey(key string) bool {
-	return strings.HasPrefix(key, "oss://") ||
-		strings.Contains(key, ".oss-") ||
-		(!strings.Contains(key, "\\") && !strings.HasPrefix(key, "/") && !strings.Contains(key, ":"))
-}
@@ -0,0 +1,321 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aliyun/alibabacloud-oss-go-sdk-v2/oss"
+	"github.com/aliyun/alibabacloud-oss-go-sdk-v2/oss/credentials"
+)
+
+func TestOSSClient_PresignUsesFullKey(t *testing.T) {
+	// Presigning only signs locally, no request is sent
+	client, err := NewOSSClient("oss-cn-hangzhou.aliyuncs.com", "test-id", "test-secret", "test-bucket", "cn-hangzhou", "/backup//fers", OSSOptions{})
+	if err != nil {
+		t.Fatalf("NewOSSClient failed: %v", err)
+	}
+
+	presigner, ok := client.(Presigner)
+	if !ok {
+		t.Fatal("ossClient should implement Presigner")
+	}
+
+	signed, err := presigner.Presign("folder/file.txt", 10*time.Minute)
+	if err != nil {
+		t.Fatalf("Presign failed: %v", err)
+	}
+
+	u, err := url.Parse(signed)
+	if err != nil {
+		t.Fatalf("Presign returned an invalid URL %q: %v", signed, err)
+	}
+	if u.Host != "test-bucket.oss-cn-hangzhou.aliyuncs.com" {
+		t.Errorf("Expected bucket host, got %s", u.Host)
+	}
+	if u.Path != "/backup/fers/folder/file.txt" {
+		t.Errorf("Expected path with work dir prefix, got %s", u.Path)
+	}
+	if u.Query().Get("x-oss-expires") != "600" {
+		t.Errorf("Expected 600s expiry, got query %s", u.RawQuery)
+	}
+}
+
+func TestNewOSSHTTPClient_Options(t *testing.T) {
+	client, err := newOSSHTTPClient(OSSOptions{
+		ConnectTimeout: 3 * time.Second,
+		ReadTimeout:    30 * time.Second,
+		RequestTimeout: 10 * time.Minute,
+		Proxy:          "http://127.0.0.1:8080",
+	})
+	if err != nil {
+		t.Fatalf("newOSSHTTPClient failed: %v", err)
+	}
+
+	if client.Timeout != 10*time.Minute {
+		t.Errorf("Expected http.Client.Timeout 10m, got %s", client.Timeout)
+	}
+
+	tr, ok := client.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("Expected *http.Transport, got %T", client.Transport)
+	}
+	if tr.TLSHandshakeTimeout != 3*time.Second {
+		t.Errorf("Expected connect timeout 3s, got %s", tr.TLSHandshakeTimeout)
+	}
+	req, _ := http.NewRequest(http.MethodGet, "https://test-bucket.oss-cn-hangzhou.aliyuncs.com/", nil)
+	proxyURL, err := tr.Proxy(req)
+	if err != nil || proxyURL == nil || proxyURL.Host != "127.0.0.1:8080" {
+		t.Errorf("Expected proxy 127.0.0.1:8080, got %v (%v)", proxyURL, err)
+	}
+}
+
+func TestNewOSSHTTPClient_Defaults(t *testing.T) {
+	client, err := newOSSHTTPClient(OSSOptions{})
+	if err != nil {
+		t.Fatalf("newOSSHTTPClient failed: %v", err)
+	}
+	if client.Timeout != 0 {
+		t.Errorf("Expected no overall request timeout by default, got %s", client.Timeout)
+	}
+}
+
+func TestNewOSSClient_InvalidProxy(t *testing.T) {
+	_, err := NewOSSClient("oss-cn-hangzhou.aliyuncs.com", "test-id", "test-secret", "test-bucket", "cn-hangzhou", "", OSSOptions{Proxy: "://bad"})
+	if err == nil {
+		t.Error("Expected error for invalid proxy URL")
+	}
+}
+
+func TestNotFoundError(t *testing.T) {
+	missing := fmt.Errorf("operation error GetObject: %w", &oss.ServiceError{StatusCode: http.StatusNotFound, Code: "NoSuchKey"})
+	if err := notFoundError(missing); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("A 404 should wrap os.ErrNotExist, got %v", err)
+	}
+
+	denied := &oss.ServiceError{StatusCode: http.StatusForbidden, Code: "AccessDenied"}
+	if err := notFoundError(denied); errors.Is(err, os.ErrNotExist) {
+		t.Errorf("A 403 should not be reported as missing, got %v", err)
+	}
+}
+
+// newTestOSSClient returns an ossClient whose SDK client sends requests to handler
+func newTestOSSClient(t *testing.T, handler http.HandlerFunc) *ossClient {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	cfg := oss.LoadDefaultConfig().
+		WithCredentialsProvider(credentials.NewStaticCredentialsProvider("test-id", "test-secret")).
+		WithRegion("cn-hangzhou").
+		WithEndpoint(server.URL).
+		WithUsePathStyle(true).
+		WithRetryMaxAttempts(1)
+	return &ossClient{client: oss.NewClient(cfg), bucketName: "test-bucket", workDir: "fers", baseDir: "fers"}
+}
+
+func TestOSSClient_Ping(t *testing.T) {
+	var query url.Values
+	client := newTestOSSClient(t, func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		w.Header().Set("Content-Type", "application/xml")
+		fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?>
+<ListBucketResult><Name>test-bucket</Name><Prefix>fers</Prefix><MaxKeys>1</MaxKeys><KeyCount>0</KeyCount><IsTruncated>false</IsTruncated></ListBucketResult>`)
+	})
+
+	if err := Ping(context.Background(), client); err != nil {
+		t.Fatalf("Ping failed: %v", err)
+	}
+	if query.Get("max-keys") != "1" || query.Get("prefix") != "fers/" {
+		t.Errorf("Expected a one-key listing of the work dir, got query %v", query)
+	}
+}
+
+func TestOSSClient_PingFailure(t *testing.T) {
+	client := newTestOSSClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		w.Header().Set("x-oss-request-id", "test-request")
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?>
+<Error><Code>InvalidAccessKeyId</Code><Message>The OSS Access Key Id you provided does not exist in our records.</Message><RequestId>test-request</RequestId></Error>`)
+	})
+
+	err := client.Ping(context.Background())
+	var serviceErr *oss.ServiceError
+	if !errors.As(err, &serviceErr) || serviceErr.Code != "InvalidAccessKeyId" {
+		t.Fatalf("Expected the access key error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "test-bucket") {
+		t.Errorf("Expected the bucket in the error, got %v", err)
+	}
+}
+
+func TestOSSClient_PingUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	endpoint := server.URL
+	server.Close()
+
+	cfg := oss.LoadDefaultConfig().
+		WithCredentialsProvider(credentials.NewStaticCredentialsProvider("test-id", "test-secret")).
+		WithRegion("cn-hangzhou").
+		WithEndpoint(endpoint).
+		WithUsePathStyle(true).
+		WithRetryMaxAttempts(1)
+	client := &ossClient{client: oss.NewClient(cfg), bucketName: "test-bucket"}
+	if err := client.Ping(context.Background()); err == nil {
+		t.Fatal("Expected Ping to fail when the endpoint is unreachable")
+	}
+}
+
+func TestNewOSSClient_Namespace(t *testing.T) {
+	tests := []struct {
+		namespace string
+		want      string
+	}{
+		{"", "fers/a.txt"},
+		{"laptop-a", "fers/laptop-a/a.txt"},
+		{"/machines//laptop-a/", "fers/machines/laptop-a/a.txt"},
+	}
+	for _, tt := range tests {
+		client, err := NewOSSClient("oss-cn-hangzhou.aliyuncs.com", "test-id", "test-secret", "test-bucket", "cn-hangzhou", "fers", OSSOptions{Namespace: tt.namespace})
+		if err != nil {
+			t.Fatalf("NewOSSClient(%q) failed: %v", tt.namespace, err)
+		}
+		if got := client.(*ossClient).getFullPath("a.txt"); got != tt.want {
+			t.Errorf("Namespace %q: expected %s, got %s", tt.namespace, tt.want, got)
+		}
+	}
+
+	if _, err := NewOSSClient("oss-cn-hangzhou.aliyuncs.com", "test-id", "test-secret", "test-bucket", "cn-hangzhou", "fers", OSSOptions{Namespace: "../other"}); err == nil {
+		t.Error("Expected a namespace leaving the work dir to be rejected")
+	}
+}
+
+func TestOSSClient_NamespaceUploadAndList(t *testing.T) {
+	var putPath, listPrefix string
+	base := newTestOSSClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPut:
+			putPath = r.URL.Path
+		case http.MethodGet:
+			listPrefix = r.URL.Query().Get("prefix")
+			w.Header().Set("Content-Type", "application/xml")
+			fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?>
+<ListBucketResult><Name>test-bucket</Name><KeyCount>2</KeyCount><IsTruncated>false</IsTruncated>
+<Contents><Key>fers/laptop-a/a.txt</Key><Size>4</Size></Contents>
+<Contents><Key>fers/laptop-a/docs/b.txt</Key><Size>4</Size></Contents>
+</ListBucketResult>`)
+		}
+	})
+	client, err := base.WithNamespace("laptop-a")
+	if err != nil {
+		t.Fatalf("WithNamespace failed: %v", err)
+	}
+	if client.(Namespacer).Namespace() != "laptop-a" || base.Namespace() != "" {
+		t.Errorf("Expected namespaces laptop-a and empty, got %q and %q", client.(Namespacer).Namespace(), base.Namespace())
+	}
+
+	if err := client.Upload("a.txt", []byte("data")); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	if putPath != "/test-bucket/fers/laptop-a/a.txt" {
+		t.Errorf("Expected the upload under the namespace, got %s", putPath)
+	}
+
+	keys, err := client.List("")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if listPrefix != "fers/laptop-a/" {
+		t.Errorf("Expected the listing of the namespace, got prefix %q", listPrefix)
+	}
+	if len(keys) != 2 || keys[0] != "a.txt" || keys[1] != "docs/b.txt" {
+		t.Errorf("Expected the namespace stripped from the keys, got %v", keys)
+	}
+}
+
+func TestOSSClient_CopyFromNamespace(t *testing.T) {
+	var headPath, copyPath, copySource string
+	client := newTestOSSClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodHead:
+			headPath = r.URL.Path
+			w.Header().Set("Content-Length", "4")
+		case http.MethodPut:
+			copyPath = r.URL.Path
+			copySource, _ = url.PathUnescape(r.Header.Get("x-oss-copy-source"))
+			w.Header().Set("Content-Type", "application/xml")
+			fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?><CopyObjectResult><ETag>"etag"</ETag></CopyObjectResult>`)
+		}
+	})
+	client.namespace = "laptop-a"
+	client.workDir = "fers/laptop-a"
+
+	if err := client.CopyFromNamespace("laptop-b", "notes.txt", "from-b/notes.txt"); err != nil {
+		t.Fatalf("CopyFromNamespace failed: %v", err)
+	}
+	if headPath != "" && headPath != "/test-bucket/fers/laptop-b/notes.txt" {
+		t.Errorf("Expected the source to be read from laptop-b, got %s", headPath)
+	}
+	if copyPath != "/test-bucket/fers/laptop-a/from-b/notes.txt" {
+		t.Errorf("Expected the copy in the own namespace, got %s", copyPath)
+	}
+	if copySource != "/test-bucket/fers/laptop-b/notes.txt" {
+		t.Errorf("Expected the source key of laptop-b, got %s", copySource)
+	}
+}
+
+func TestOSSClient_NamespaceIgnoresSiblingNamespace(t *testing.T) {
+	var prefixes []string
+	base := newTestOSSClient(t, func(w http.ResponseWriter, r *http.Request) {
+		prefixes = append(prefixes, r.URL.Query().Get("prefix"))
+		// 模拟只按字符串前缀匹配的服务端：fers/pc 同时匹配 fers/pc2 的对象
+		w.Header().Set("Content-Type", "application/xml")
+		fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?>
+<ListBucketResult><Name>test-bucket</Name><KeyCount>3</KeyCount><IsTruncated>false</IsTruncated>
+<Contents><Key>fers/pc/a.txt</Key><Size>4</Size></Contents>
+<Contents><Key>fers/pc2/x.txt</Key><Size>4</Size></Contents>
+<Contents><Key>fers/pc2/pc/y.txt</Key><Size>4</Size></Contents>
+<CommonPrefixes><Prefix>fers/pc2/</Prefix></CommonPrefixes>
+</ListBucketResult>`)
+	})
+	client, err := base.WithNamespace("pc")
+	if err != nil {
+		t.Fatalf("WithNamespace failed: %v", err)
+	}
+	pc := client.(*ossClient)
+
+	keys, err := pc.List("")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(keys) != 1 || keys[0] != "a.txt" {
+		t.Errorf("Expected only the keys of pc, got %v", keys)
+	}
+	page, _, err := pc.ListPage("", "", 10)
+	if err != nil {
+		t.Fatalf("ListPage failed: %v", err)
+	}
+	if len(page) != 1 || page[0] != "a.txt" {
+		t.Errorf("Expected only the keys of pc in the page, got %v", page)
+	}
+	keys, dirs, err := pc.ListWithDelimiter("", "/")
+	if err != nil {
+		t.Fatalf("ListWithDelimiter failed: %v", err)
+	}
+	if len(keys) != 1 || len(dirs) != 0 {
+		t.Errorf("Expected no entries of pc2, got %v and %v", keys, dirs)
+	}
+	for _, prefix := range prefixes {
+		if prefix != "fers/pc/" {
+			t.Errorf("Expected the namespace root to be listed as fers/pc/, got %q", prefix)
+		}
+	}
+}
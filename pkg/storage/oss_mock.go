@@ -1,24 +1,95 @@
 package storage
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
 )
 
-var _ Client = (*ossMock)(nil)
+var (
+	_ Client           = (*ossMock)(nil)
+	_ Mover            = (*ossMock)(nil)
+	_ MetadataUploader = (*ossMock)(nil)
+	_ RangeDownloader  = (*ossMock)(nil)
+	_ Pinger           = (*ossMock)(nil)
+	_ Namespacer       = (*ossMock)(nil)
+)
+
+// mockMetaDir is the directory under the mock base holding the metadata sidecar files
+const mockMetaDir = ".fers-meta"
 
 type ossMock struct {
-	base string
-	mu   sync.Mutex
+	base      string // 命名空间对应的目录
+	root      string // 配置的目录
+	namespace string
+	mu        sync.Mutex
 }
 
 func NewOSSMock(base string) Client {
-	if err := os.MkdirAll(base, 0o755); err != nil {
+	client, err := NewOSSMockWithNamespace(base, "")
+	if err != nil {
 		panic(err)
 	}
-	return &ossMock{base: base}
+	return client
+}
+
+// NewOSSMockWithNamespace returns a mock storing its objects in the namespace
+// folder of base
+func NewOSSMockWithNamespace(base, namespace string) (Client, error) {
+	ns, err := normalizeNamespace(namespace)
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(base, filepath.FromSlash(ns))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &ossMock{base: dir, root: base, namespace: ns}, nil
+}
+
+// Namespace returns the namespace folder of the mock
+func (o *ossMock) Namespace() string {
+	return o.namespace
+}
+
+// WithNamespace returns a mock for namespace of the same base directory
+func (o *ossMock) WithNamespace(namespace string) (Client, error) {
+	return NewOSSMockWithNamespace(o.root, namespace)
+}
+
+// CopyFromNamespace copies srcKey of namespace, with its metadata, to dstKey
+func (o *ossMock) CopyFromNamespace(namespace, srcKey, dstKey string) error {
+	other, err := o.WithNamespace(namespace)
+	if err != nil {
+		return err
+	}
+	data, err := other.Download(srcKey)
+	if err != nil {
+		return err
+	}
+	info, err := other.Stat(srcKey)
+	if err != nil {
+		return err
+	}
+	return o.UploadWithMeta(dstKey, data, info.Metadata)
+}
+
+// Ping checks that the base directory still exists
+func (o *ossMock) Ping(ctx context.Context) error {
+	info, err := os.Stat(o.base)
+	if err != nil {
+		return fmt.Errorf("failed to reach mock storage: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("mock storage %s is not a directory", o.base)
+	}
+	return nil
 }
 
 func (o *ossMock) List(prefix string) ([]string, error) {
@@ -30,13 +101,17 @@ func (o *ossMock) List(prefix string) ([]string, error) {
 			return err
 		}
 		if info.IsDir() {
+			// 也跳过其他命名空间的元数据目录
+			if info.Name() == mockMetaDir {
+				return filepath.SkipDir
+			}
 			return nil
 		}
 		rel, err := filepath.Rel(o.base, p)
 		if err != nil {
 			return err
 		}
-		rel = filepath.ToSlash(rel)
+		rel = NormalizeKey(rel)
 		if prefix == "" || strings.HasPrefix(rel, prefix) {
 			out = append(out, rel)
 		}
@@ -45,11 +120,77 @@ func (o *ossMock) List(prefix string) ([]string, error) {
 	return out, err
 }
 
+func (o *ossMock) ListPage(prefix, continuationToken string, max int) ([]string, string, error) {
+	keys, err := o.List(prefix)
+	if err != nil {
+		return nil, "", err
+	}
+	return pageKeys(keys, continuationToken, max)
+}
+
 func (o *ossMock) keyPath(key string) string {
 	return filepath.Join(o.base, filepath.FromSlash(key))
 }
 
+// metaPath returns the sidecar file holding the metadata of key
+func (o *ossMock) metaPath(key string) string {
+	if key == "" {
+		return filepath.Join(o.base, mockMetaDir)
+	}
+	return filepath.Join(o.base, mockMetaDir, filepath.FromSlash(key)+".json")
+}
+
+// readMeta returns the metadata of key, or nil when it has none
+func (o *ossMock) readMeta(key string) (map[string]string, error) {
+	data, err := os.ReadFile(o.metaPath(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var meta map[string]string
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, err
+	}
+	return meta, nil
+}
+
+// writeMeta replaces the metadata of key; nil meta removes the sidecar file
+func (o *ossMock) writeMeta(key string, meta map[string]string) error {
+	p := o.metaPath(key)
+	if meta == nil {
+		if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(p, data, 0o644)
+}
+
+// ListWithDelimiter groups the keys under prefix on their first segment after it
+func (o *ossMock) ListWithDelimiter(prefix, delimiter string) ([]string, []string, error) {
+	keys, err := o.List(prefix)
+	if err != nil {
+		return nil, nil, err
+	}
+	direct, commonPrefixes := groupByDelimiter(keys, prefix, delimiter)
+	return direct, commonPrefixes, nil
+}
+
 func (o *ossMock) Upload(key string, data []byte) error {
+	return o.UploadWithMeta(key, data, nil)
+}
+
+// UploadWithMeta writes the object and stores meta in a sidecar file
+func (o *ossMock) UploadWithMeta(key string, data []byte, meta map[string]string) error {
 	o.mu.Lock()
 	defer o.mu.Unlock()
 	p := o.keyPath(key)
@@ -57,7 +198,10 @@ func (o *ossMock) Upload(key string, data []byte) error {
 	if err := os.MkdirAll(dir, 0o755); err != nil {
 		return err
 	}
-	return os.WriteFile(p, data, 0o644)
+	if err := os.WriteFile(p, data, 0o644); err != nil {
+		return err
+	}
+	return o.writeMeta(key, meta)
 }
 
 func (o *ossMock) Download(key string) ([]byte, error) {
@@ -67,6 +211,93 @@ func (o *ossMock) Download(key string) ([]byte, error) {
 	return os.ReadFile(p)
 }
 
+// DownloadStreamRange streams the object file from offset, like a range GET
+func (o *ossMock) DownloadStreamRange(ctx context.Context, key string, offset int64) (io.ReadCloser, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	f, err := os.Open(o.keyPath(key))
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err == nil && (offset < 0 || offset > info.Size()) {
+		err = fmt.Errorf("offset %d of %s (%d bytes): %w", offset, key, info.Size(), ErrInvalidRange)
+	}
+	if err == nil {
+		_, err = f.Seek(offset, io.SeekStart)
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+func (o *ossMock) Stat(key string) (ObjectInfo, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	fi, err := os.Stat(o.keyPath(key))
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	meta, err := o.readMeta(key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	return withMetadata(ObjectInfo{Key: key, Size: fi.Size(), LastModified: fi.ModTime()}, meta), nil
+}
+
+func (o *ossMock) Copy(srcKey, dstKey string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	data, err := os.ReadFile(o.keyPath(srcKey))
+	if err != nil {
+		return err
+	}
+	meta, err := o.readMeta(srcKey)
+	if err != nil {
+		return err
+	}
+	dst := o.keyPath(dstKey)
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(dst, data, 0o644); err != nil {
+		return err
+	}
+	return o.writeMeta(dstKey, meta)
+}
+
+func (o *ossMock) Move(srcKey, dstKey string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	src := o.keyPath(srcKey)
+	if _, err := os.Stat(src); err != nil {
+		return err
+	}
+	meta, err := o.readMeta(srcKey)
+	if err != nil {
+		return err
+	}
+	dst := o.keyPath(dstKey)
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	if err := os.Rename(src, dst); err != nil {
+		return err
+	}
+	if err := o.writeMeta(dstKey, meta); err != nil {
+		return err
+	}
+	return o.writeMeta(srcKey, nil)
+}
+
+// Delete removes the object and its metadata; missing keys are not an error
 func (o *ossMock) Delete(key string) error {
-	return nil
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if err := os.Remove(o.keyPath(key)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return o.writeMeta(key, nil)
 }
@@ -2,9 +2,12 @@ package storage
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 )
 
@@ -221,16 +224,73 @@ func TestOSSMock_ListEmptyDirectory(t *testing.T) {
 	}
 }
 
+func TestOSSMock_ListPage(t *testing.T) {
+	testListPage(t, NewOSSMock(t.TempDir()))
+}
+
+func TestOSSMock_ListWithDelimiter(t *testing.T) {
+	testListWithDelimiter(t, NewOSSMock(t.TempDir()))
+}
+
+func TestOSSMock_CopyAndMove(t *testing.T) {
+	testMover(t, NewOSSMock(t.TempDir()))
+}
+
+func TestOSSMock_UploadWithMeta(t *testing.T) {
+	testMetadataUploader(t, NewOSSMock(t.TempDir()))
+}
+
+func TestOSSMock_DownloadStreamRange(t *testing.T) {
+	testRangeDownloader(t, NewOSSMock(t.TempDir()))
+}
+
+func TestOSSMock_Stat(t *testing.T) {
+	tempDir := t.TempDir()
+	client := NewOSSMock(tempDir)
+
+	data := []byte("stat content")
+	if err := client.Upload("folder/stat.txt", data); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+
+	info, err := client.Stat("folder/stat.txt")
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if info.Key != "folder/stat.txt" {
+		t.Errorf("Expected key folder/stat.txt, got %s", info.Key)
+	}
+	if info.Size != int64(len(data)) {
+		t.Errorf("Expected size %d, got %d", len(data), info.Size)
+	}
+	if info.LastModified.IsZero() {
+		t.Error("LastModified should be set")
+	}
+
+	if _, err := client.Stat("missing.txt"); err == nil {
+		t.Error("Expected error for non-existent key")
+	}
+}
+
 func TestOSSMock_Delete(t *testing.T) {
 	tempDir := t.TempDir()
 	client := NewOSSMock(tempDir)
 
-	// Note: The current implementation of Delete is a no-op
-	// This test verifies that it doesn't return an error
+	// Deleting a missing key is not an error
 	err := client.Delete("any-key")
 	if err != nil {
 		t.Errorf("Delete should not return error, got: %v", err)
 	}
+
+	if err := client.Upload("docs/file.txt", []byte("data")); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	if err := client.Delete("docs/file.txt"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := client.Stat("docs/file.txt"); err == nil {
+		t.Error("Deleted object should be gone")
+	}
 }
 
 func TestOSSMock_ConcurrentOperations(t *testing.T) {
@@ -348,3 +408,78 @@ func TestOSSMock_InterfaceCompliance(t *testing.T) {
 	// Test that it implements the Client interface
 	var _ Client = client
 }
+
+func TestOSSMock_Ping(t *testing.T) {
+	base := filepath.Join(t.TempDir(), "remote")
+	client := NewOSSMock(base)
+
+	if err := Ping(context.Background(), client); err != nil {
+		t.Fatalf("Ping failed: %v", err)
+	}
+	if err := os.RemoveAll(base); err != nil {
+		t.Fatalf("Failed to remove base: %v", err)
+	}
+	if err := Ping(context.Background(), client); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected Ping to fail once the base is gone, got %v", err)
+	}
+	// 包装的客户端同样转发 Ping
+	if err := Ping(context.Background(), NewPrefixedClient(client, "team")); err == nil {
+		t.Error("Expected the prefixed client to forward Ping")
+	}
+}
+
+func TestOSSMock_Namespace(t *testing.T) {
+	base := t.TempDir()
+	laptopA, err := NewOSSMockWithNamespace(base, "laptop-a")
+	if err != nil {
+		t.Fatalf("NewOSSMockWithNamespace failed: %v", err)
+	}
+	laptopB, err := laptopA.(Namespacer).WithNamespace("laptop-b")
+	if err != nil {
+		t.Fatalf("WithNamespace failed: %v", err)
+	}
+	if err := laptopA.Upload("notes.txt", []byte("from a")); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	if err := laptopB.Upload("notes.txt", []byte("from b")); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+
+	// 对象落在命名空间目录下，列出时不带命名空间
+	if _, err := os.Stat(filepath.Join(base, "laptop-a", "notes.txt")); err != nil {
+		t.Errorf("Expected the object under the namespace: %v", err)
+	}
+	keys, err := laptopA.List("")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(keys) != 1 || keys[0] != "notes.txt" {
+		t.Errorf("Expected only the own key without namespace, got %v", keys)
+	}
+	data, _ := laptopB.Download("notes.txt")
+	if string(data) != "from b" {
+		t.Errorf("Expected namespaces not to collide, got %q", data)
+	}
+
+	// 空命名空间就是整个目录，能看到所有命名空间
+	root := NewOSSMock(base)
+	if root.(Namespacer).Namespace() != "" {
+		t.Errorf("Expected the default namespace to be empty, got %q", root.(Namespacer).Namespace())
+	}
+	all, _ := root.List("")
+	if len(all) != 2 || !slices.Contains(all, "laptop-a/notes.txt") || !slices.Contains(all, "laptop-b/notes.txt") {
+		t.Errorf("Expected the keys of both namespaces, got %v", all)
+	}
+
+	if err := laptopA.(Namespacer).CopyFromNamespace("laptop-b", "notes.txt", "from-b/notes.txt"); err != nil {
+		t.Fatalf("CopyFromNamespace failed: %v", err)
+	}
+	data, _ = laptopA.Download("from-b/notes.txt")
+	if string(data) != "from b" {
+		t.Errorf("Expected the object of laptop-b to be copied, got %q", data)
+	}
+
+	if _, err := NewOSSMockWithNamespace(base, "../escape"); err == nil {
+		t.Error("Expected a namespace leaving the base to be rejected")
+	}
+}
@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/aliyun/alibabacloud-oss-go-sdk-v2/oss"
+)
+
+// 分片上传的默认设置
+const (
+	DefaultMultipartThreshold int64 = 64 << 20 // 超过 64 MiB 的对象分片上传
+	DefaultPartSize           int64 = 16 << 20
+	DefaultPartConcurrency          = 4
+
+	// OSS 限制：除最后一片外每片至少 100 KiB，最多 10000 片
+	minPartSize  int64 = 100 << 10
+	maxPartCount int64 = 10000
+)
+
+// partRange is the byte range [Offset, Offset+Size) of a part, numbered from 1
+type partRange struct {
+	Number int32
+	Offset int64
+	Size   int64
+}
+
+// splitParts divides size bytes into parts of partSize, growing the parts when
+// needed to stay within the OSS minimum part size and maximum part count
+func splitParts(size, partSize int64) []partRange {
+	partSize = max(partSize, minPartSize, (size+maxPartCount-1)/maxPartCount)
+	var parts []partRange
+	for offset := int64(0); offset < size; offset += partSize {
+		parts = append(parts, partRange{
+			Number: int32(len(parts) + 1),
+			Offset: offset,
+			Size:   min(partSize, size-offset),
+		})
+	}
+	return parts
+}
+
+// uploadMultipart uploads data as a multipart upload with up to partConcurrency
+// parts in flight. The upload is aborted on any error so that no parts are
+// left behind (and billed) in the bucket.
+func (o *ossClient) uploadMultipart(ctx context.Context, key string, data []byte, meta map[string]string) (err error) {
+	fullKey := o.getFullPath(key)
+	initRequest := &oss.InitiateMultipartUploadRequest{
+		Bucket:   oss.Ptr(o.bucketName),
+		Key:      oss.Ptr(fullKey),
+		Metadata: meta,
+	}
+	if contentType := meta[MetaContentType]; contentType != "" {
+		initRequest.ContentType = oss.Ptr(contentType)
+	}
+	initResult, err := o.client.InitiateMultipartUpload(ctx, initRequest)
+	if err != nil {
+		return fmt.Errorf("failed to start multipart upload of %s: %w", key, err)
+	}
+	uploadID := initResult.UploadId
+
+	defer func() {
+		if err == nil {
+			return
+		}
+		// 用新的 context 清理，原 context 可能已被取消
+		_, abortErr := o.client.AbortMultipartUpload(context.Background(), &oss.AbortMultipartUploadRequest{
+			Bucket:   oss.Ptr(o.bucketName),
+			Key:      oss.Ptr(fullKey),
+			UploadId: uploadID,
+		})
+		if abortErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to abort multipart upload of %s: %w", key, abortErr))
+		}
+	}()
+
+	parts := splitParts(int64(len(data)), o.partSize)
+	uploaded, err := o.uploadParts(ctx, fullKey, uploadID, data, parts)
+	if err != nil {
+		return fmt.Errorf("failed to upload object %s: %w", key, err)
+	}
+
+	_, err = o.client.CompleteMultipartUpload(ctx, &oss.CompleteMultipartUploadRequest{
+		Bucket:                  oss.Ptr(o.bucketName),
+		Key:                     oss.Ptr(fullKey),
+		UploadId:                uploadID,
+		CompleteMultipartUpload: &oss.CompleteMultipartUpload{Parts: uploaded},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to complete multipart upload of %s: %w", key, err)
+	}
+	return nil
+}
+
+// uploadParts uploads parts of data concurrently and returns them in part
+// number order. The first failure cancels the parts still waiting to start.
+func (o *ossClient) uploadParts(ctx context.Context, fullKey string, uploadID *string, data []byte, parts []partRange) ([]oss.UploadPart, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	uploaded := make([]oss.UploadPart, len(parts))
+	errs := make([]error, len(parts))
+	sem := make(chan struct{}, max(o.partConcurrency, 1))
+	var wg sync.WaitGroup
+	for i, part := range parts {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			errs[i] = ctx.Err()
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			body := data[part.Offset : part.Offset+part.Size]
+			result, err := o.client.UploadPart(ctx, &oss.UploadPartRequest{
+				Bucket:        oss.Ptr(o.bucketName),
+				Key:           oss.Ptr(fullKey),
+				UploadId:      uploadID,
+				PartNumber:    part.Number,
+				Body:          NewThrottledReader(ctx, bytes.NewReader(body), o.uploadLimiter),
+				ContentLength: oss.Ptr(part.Size),
+			})
+			if err != nil {
+				errs[i] = fmt.Errorf("part %d: %w", part.Number, err)
+				cancel()
+				return
+			}
+			uploaded[i] = oss.UploadPart{PartNumber: part.Number, ETag: result.ETag}
+		}()
+	}
+	wg.Wait()
+
+	// 只报告第一个真正的错误，其余分片多半是因它被取消
+	for _, err := range errs {
+		if err != nil && !errors.Is(err, context.Canceled) {
+			return nil, err
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return uploaded, nil
+}
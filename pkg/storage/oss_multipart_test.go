@@ -0,0 +1,176 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestSplitParts(t *testing.T) {
+	tests := []struct {
+		name      string
+		size      int64
+		partSize  int64
+		wantParts int
+		wantLast  int64
+	}{
+		{"exact", 4 * minPartSize, minPartSize, 4, minPartSize},
+		{"remainder", 4*minPartSize + 10, minPartSize, 5, 10},
+		{"small part size raised to minimum", 3 * minPartSize, 1, 3, minPartSize},
+		{"part count capped", maxPartCount*minPartSize + 1, minPartSize, int(maxPartCount), maxPartCount*minPartSize + 1 - (maxPartCount-1)*(minPartSize+1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts := splitParts(tt.size, tt.partSize)
+			if len(parts) != tt.wantParts {
+				t.Fatalf("Expected %d parts, got %d", tt.wantParts, len(parts))
+			}
+			var next int64
+			for i, part := range parts {
+				if part.Number != int32(i+1) || part.Offset != next {
+					t.Fatalf("Part %d is not contiguous: %+v", i, part)
+				}
+				next += part.Size
+			}
+			if next != tt.size {
+				t.Errorf("Parts cover %d bytes, want %d", next, tt.size)
+			}
+			if last := parts[len(parts)-1].Size; last != tt.wantLast {
+				t.Errorf("Expected a last part of %d bytes, got %d", tt.wantLast, last)
+			}
+		})
+	}
+}
+
+// fakeMultipartServer stores the parts of multipart uploads like OSS does
+type fakeMultipartServer struct {
+	mu        sync.Mutex
+	parts     map[int][]byte
+	meta      string
+	object    []byte
+	completed bool
+	aborted   bool
+	failPart  int // 上传该分片时返回错误
+}
+
+func (f *fakeMultipartServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	query := r.URL.Query()
+	w.Header().Set("Content-Type", "application/xml")
+	switch {
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		f.meta = r.Header.Get("x-oss-meta-sha256")
+		fmt.Fprint(w, `<InitiateMultipartUploadResult><Bucket>test-bucket</Bucket><Key>fers/big.bin</Key><UploadId>test-upload</UploadId></InitiateMultipartUploadResult>`)
+	case r.Method == http.MethodPut && query.Has("partNumber"):
+		number, _ := strconv.Atoi(query.Get("partNumber"))
+		if number == f.failPart {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `<Error><Code>AccessDenied</Code><Message>simulated part failure</Message></Error>`)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		f.parts[number] = body
+		w.Header().Set("ETag", fmt.Sprintf(`"etag-%d"`, number))
+	case r.Method == http.MethodPost && query.Get("uploadId") == "test-upload":
+		var complete struct {
+			Parts []struct {
+				PartNumber int
+				ETag       string
+			} `xml:"Part"`
+		}
+		if err := xml.NewDecoder(r.Body).Decode(&complete); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		sort.Slice(complete.Parts, func(i, j int) bool { return complete.Parts[i].PartNumber < complete.Parts[j].PartNumber })
+		var object bytes.Buffer
+		for _, part := range complete.Parts {
+			if part.ETag != fmt.Sprintf(`"etag-%d"`, part.PartNumber) {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			object.Write(f.parts[part.PartNumber])
+		}
+		f.object = object.Bytes()
+		f.completed = true
+		fmt.Fprint(w, `<CompleteMultipartUploadResult><Bucket>test-bucket</Bucket><Key>fers/big.bin</Key><ETag>"done"</ETag></CompleteMultipartUploadResult>`)
+	case r.Method == http.MethodDelete && query.Get("uploadId") == "test-upload":
+		f.aborted = true
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+	}
+}
+
+func TestOSSClient_UploadMultipart(t *testing.T) {
+	server := &fakeMultipartServer{parts: make(map[int][]byte)}
+	client := newTestOSSClient(t, server.ServeHTTP)
+	client.multipartThreshold = 2 * minPartSize
+	client.partSize = minPartSize
+	client.partConcurrency = 3
+
+	data := make([]byte, 5*minPartSize+123)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	if err := client.UploadWithMeta("big.bin", data, map[string]string{MetaSHA256: "abc"}); err != nil {
+		t.Fatalf("UploadWithMeta failed: %v", err)
+	}
+
+	if len(server.parts) != 6 {
+		t.Errorf("Expected 6 parts, got %d", len(server.parts))
+	}
+	if !server.completed || server.aborted {
+		t.Fatalf("Expected a completed upload, completed=%v aborted=%v", server.completed, server.aborted)
+	}
+	if !bytes.Equal(server.object, data) {
+		t.Error("Reassembled object differs from the uploaded data")
+	}
+	if server.meta != "abc" {
+		t.Errorf("Expected the metadata on the initiate request, got %q", server.meta)
+	}
+}
+
+func TestOSSClient_UploadMultipartAbortsOnError(t *testing.T) {
+	server := &fakeMultipartServer{parts: make(map[int][]byte), failPart: 2}
+	client := newTestOSSClient(t, server.ServeHTTP)
+	client.multipartThreshold = minPartSize
+	client.partSize = minPartSize
+	client.partConcurrency = 1
+
+	err := client.Upload("big.bin", make([]byte, 4*minPartSize))
+	if err == nil {
+		t.Fatal("Expected the upload to fail")
+	}
+	if !server.aborted || server.completed {
+		t.Errorf("Expected the upload to be aborted, completed=%v aborted=%v", server.completed, server.aborted)
+	}
+	if len(server.parts) > 1 {
+		t.Errorf("Expected the parts after the failure to be skipped, got %d parts", len(server.parts))
+	}
+}
+
+func TestOSSClient_UploadBelowThresholdUsesPutObject(t *testing.T) {
+	var puts int
+	client := newTestOSSClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.URL.Query().Has("partNumber") {
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL)
+		}
+		puts++
+	})
+	client.multipartThreshold = minPartSize
+
+	if err := client.Upload("small.txt", make([]byte, minPartSize)); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	if puts != 1 {
+		t.Errorf("Expected a single PutObject, got %d requests", puts)
+	}
+}
@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"strings"
+	"time"
+)
+
+// prefixedClient stores every key under a fixed prefix of another client, so
+// several key spaces can share one bucket or directory without colliding
+type prefixedClient struct {
+	client Client
+	prefix string // 以 "/" 结尾
+}
+
+// NewPrefixedClient returns a client that stores key as prefix/key in client
+// and lists only the keys under prefix, with the prefix removed. The optional
+// Mover and Presigner interfaces are implemented when client implements them;
+// MetadataUploader and RangeDownloader always are and fall back to Upload
+// without metadata and a full Download.
+// An empty prefix returns client unchanged.
+func NewPrefixedClient(client Client, prefix string) Client {
+	prefix = NormalizeKey(prefix)
+	if prefix == "" {
+		return client
+	}
+	p := &prefixedClient{client: client, prefix: prefix + "/"}
+
+	mover, canMove := client.(Mover)
+	presigner, canPresign := client.(Presigner)
+	switch {
+	case canMove && canPresign:
+		return &struct {
+			*prefixedClient
+			*prefixedMover
+			*prefixedPresigner
+		}{p, &prefixedMover{p, mover}, &prefixedPresigner{p, presigner}}
+	case canMove:
+		return &struct {
+			*prefixedClient
+			*prefixedMover
+		}{p, &prefixedMover{p, mover}}
+	case canPresign:
+		return &struct {
+			*prefixedClient
+			*prefixedPresigner
+		}{p, &prefixedPresigner{p, presigner}}
+	default:
+		return p
+	}
+}
+
+// key returns the key in the wrapped client
+func (p *prefixedClient) key(key string) string {
+	return p.prefix + NormalizeKey(key)
+}
+
+// trim removes the prefix from keys of the wrapped client
+func (p *prefixedClient) trim(keys []string) []string {
+	trimmed := make([]string, 0, len(keys))
+	for _, key := range keys {
+		trimmed = append(trimmed, strings.TrimPrefix(key, p.prefix))
+	}
+	return trimmed
+}
+
+func (p *prefixedClient) List(prefix string) ([]string, error) {
+	keys, err := p.client.List(p.prefix + prefix)
+	return p.trim(keys), err
+}
+
+func (p *prefixedClient) ListPage(prefix, continuationToken string, max int) ([]string, string, error) {
+	keys, nextToken, err := p.client.ListPage(p.prefix+prefix, continuationToken, max)
+	return p.trim(keys), nextToken, err
+}
+
+func (p *prefixedClient) ListWithDelimiter(prefix, delimiter string) ([]string, []string, error) {
+	keys, commonPrefixes, err := p.client.ListWithDelimiter(p.prefix+prefix, delimiter)
+	return p.trim(keys), p.trim(commonPrefixes), err
+}
+
+func (p *prefixedClient) Upload(key string, data []byte) error {
+	return p.client.Upload(p.key(key), data)
+}
+
+// UploadWithMeta uploads with metadata when the wrapped client supports it
+func (p *prefixedClient) UploadWithMeta(key string, data []byte, meta map[string]string) error {
+	if uploader, ok := p.client.(MetadataUploader); ok {
+		return uploader.UploadWithMeta(p.key(key), data, meta)
+	}
+	return p.client.Upload(p.key(key), data)
+}
+
+func (p *prefixedClient) Download(key string) ([]byte, error) {
+	return p.client.Download(p.key(key))
+}
+
+// Ping checks the wrapped client's backend when it supports it
+func (p *prefixedClient) Ping(ctx context.Context) error {
+	return Ping(ctx, p.client)
+}
+
+// DownloadStreamRange streams with a range GET when the wrapped client supports it
+func (p *prefixedClient) DownloadStreamRange(ctx context.Context, key string, offset int64) (io.ReadCloser, error) {
+	return DownloadStreamRange(ctx, p.client, p.key(key), offset)
+}
+
+func (p *prefixedClient) Stat(key string) (ObjectInfo, error) {
+	info, err := p.client.Stat(p.key(key))
+	info.Key = strings.TrimPrefix(info.Key, p.prefix)
+	return info, err
+}
+
+func (p *prefixedClient) Delete(key string) error {
+	return p.client.Delete(p.key(key))
+}
+
+// prefixedMover moves objects within the prefix
+type prefixedMover struct {
+	p     *prefixedClient
+	mover Mover
+}
+
+func (m *prefixedMover) Copy(srcKey, dstKey string) error {
+	return m.mover.Copy(m.p.key(srcKey), m.p.key(dstKey))
+}
+
+func (m *prefixedMover) Move(srcKey, dstKey string) error {
+	return m.mover.Move(m.p.key(srcKey), m.p.key(dstKey))
+}
+
+// prefixedPresigner presigns objects within the prefix
+type prefixedPresigner struct {
+	p         *prefixedClient
+	presigner Presigner
+}
+
+func (s *prefixedPresigner) Presign(key string, expiry time.Duration) (string, error) {
+	return s.presigner.Presign(s.p.key(key), expiry)
+}
@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPrefixedClient_Contract(t *testing.T) {
+	t.Run("ListPage", func(t *testing.T) { testListPage(t, NewPrefixedClient(NewMemoryClient(), "docs")) })
+	t.Run("ListWithDelimiter", func(t *testing.T) { testListWithDelimiter(t, NewPrefixedClient(NewMemoryClient(), "docs")) })
+	t.Run("Mover", func(t *testing.T) { testMover(t, NewPrefixedClient(NewMemoryClient(), "docs")) })
+	t.Run("MetadataUploader", func(t *testing.T) { testMetadataUploader(t, NewPrefixedClient(NewMemoryClient(), "docs")) })
+	t.Run("RangeDownloader", func(t *testing.T) { testRangeDownloader(t, NewPrefixedClient(NewMemoryClient(), "docs")) })
+}
+
+func TestPrefixedClient_Isolation(t *testing.T) {
+	shared := NewMemoryClient()
+	docs := NewPrefixedClient(shared, "docs")
+	photos := NewPrefixedClient(shared, "photos/")
+
+	if err := docs.Upload("a.txt", []byte("docs")); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	if err := photos.Upload("a.txt", []byte("photos")); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+
+	for client, want := range map[Client]string{docs: "docs", photos: "photos"} {
+		data, err := client.Download("a.txt")
+		if err != nil || string(data) != want {
+			t.Errorf("Expected %q, got %q, %v", want, data, err)
+		}
+		keys, err := client.List("")
+		if err != nil || !slices.Equal(keys, []string{"a.txt"}) {
+			t.Errorf("Expected only a.txt under the prefix, got %v, %v", keys, err)
+		}
+	}
+
+	keys, err := shared.List("")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	slices.Sort(keys)
+	if !slices.Equal(keys, []string{"docs/a.txt", "photos/a.txt"}) {
+		t.Errorf("Unexpected keys in the shared client %v", keys)
+	}
+
+	info, err := docs.Stat("a.txt")
+	if err != nil || info.Key != "a.txt" {
+		t.Errorf("Stat should report the key without the prefix, got %+v, %v", info, err)
+	}
+	if err := docs.Delete("a.txt"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := photos.Download("a.txt"); err != nil {
+		t.Errorf("Deleting in one prefix removed the other: %v", err)
+	}
+	if _, err := docs.Download("a.txt"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected os.ErrNotExist after delete, got %v", err)
+	}
+}
+
+// uploadOnlyClient hides the optional interfaces of a client
+type uploadOnlyClient struct {
+	Client
+}
+
+// presignClient adds presigning to a client
+type presignClient struct {
+	Client
+}
+
+func (presignClient) Presign(key string, expiry time.Duration) (string, error) {
+	return "https://example.com/" + key, nil
+}
+
+func TestPrefixedClient_OptionalInterfaces(t *testing.T) {
+	if NewPrefixedClient(NewMemoryClient(), "") == nil {
+		t.Fatal("An empty prefix should return the client")
+	}
+
+	plain := NewPrefixedClient(uploadOnlyClient{NewMemoryClient()}, "docs")
+	if _, ok := plain.(Mover); ok {
+		t.Error("A client that cannot move should not become a Mover")
+	}
+	if _, ok := plain.(Presigner); ok {
+		t.Error("A client that cannot presign should not become a Presigner")
+	}
+	if err := plain.(MetadataUploader).UploadWithMeta("a.txt", []byte("x"), map[string]string{MetaSHA256: "abc"}); err != nil {
+		t.Errorf("UploadWithMeta should fall back to Upload: %v", err)
+	}
+
+	presigner, ok := NewPrefixedClient(presignClient{NewMemoryClient()}, "docs").(Presigner)
+	if !ok {
+		t.Fatal("Expected a Presigner")
+	}
+	url, err := presigner.Presign("a.txt", time.Minute)
+	if err != nil || url != "https://example.com/docs/a.txt" {
+		t.Errorf("Expected the prefixed key to be presigned, got %q, %v", url, err)
+	}
+}
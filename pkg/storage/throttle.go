@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token bucket that caps throughput in bytes per second.
+// A nil *RateLimiter means unlimited. One limiter may be shared by several
+// concurrent transfers, which then share the bandwidth.
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64 // 每秒补充的字节数
+	burst  float64 // 桶容量，同时也是单次读写的最大字节数
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter returns a limiter allowing bps bytes per second, or nil when bps <= 0
+func NewRateLimiter(bps int64) *RateLimiter {
+	if bps <= 0 {
+		return nil
+	}
+	// 桶容量为一秒的流量，极低的限速下至少为 1 字节，保证总能前进
+	burst := float64(bps)
+	return &RateLimiter{rate: float64(bps), burst: burst, tokens: burst, last: time.Now()}
+}
+
+// chunk returns how many of n bytes may be transferred in one step
+func (l *RateLimiter) chunk(n int) int {
+	if l == nil || float64(n) <= l.burst {
+		return n
+	}
+	return int(l.burst)
+}
+
+// WaitN blocks until n bytes may be transferred or ctx is done; n must not exceed the burst
+func (l *RateLimiter) WaitN(ctx context.Context, n int) error {
+	if l == nil || n <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	// 先预支令牌，再等待欠下的部分补齐，多个传输按到达顺序排队
+	l.tokens -= float64(n)
+	var wait time.Duration
+	if l.tokens < 0 {
+		wait = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// 未传输的字节归还令牌
+		l.mu.Lock()
+		l.tokens += float64(n)
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// throttledReader limits the rate at which r is read
+type throttledReader struct {
+	ctx     context.Context
+	r       io.Reader
+	limiter *RateLimiter
+}
+
+// throttledReadSeeker keeps the reader seekable so SDK retries can rewind the body
+type throttledReadSeeker struct {
+	*throttledReader
+	seeker io.Seeker
+}
+
+// NewThrottledReader returns a reader that reads from r no faster than limiter
+// allows; reads fail with ctx.Err() once ctx is done. A nil limiter returns r.
+func NewThrottledReader(ctx context.Context, r io.Reader, limiter *RateLimiter) io.Reader {
+	if limiter == nil {
+		return r
+	}
+	tr := &throttledReader{ctx: ctx, r: r, limiter: limiter}
+	if seeker, ok := r.(io.Seeker); ok {
+		return &throttledReadSeeker{throttledReader: tr, seeker: seeker}
+	}
+	return tr
+}
+
+func (t *throttledReader) Read(p []byte) (int, error) {
+	if err := t.ctx.Err(); err != nil {
+		return 0, err
+	}
+	p = p[:t.limiter.chunk(len(p))]
+	n, err := t.r.Read(p)
+	if waitErr := t.limiter.WaitN(t.ctx, n); waitErr != nil {
+		return n, waitErr
+	}
+	return n, err
+}
+
+func (t *throttledReadSeeker) Seek(offset int64, whence int) (int64, error) {
+	return t.seeker.Seek(offset, whence)
+}
+
+// throttledWriter limits the rate at which w is written
+type throttledWriter struct {
+	ctx     context.Context
+	w       io.Writer
+	limiter *RateLimiter
+}
+
+// NewThrottledWriter returns a writer that writes to w no faster than limiter
+// allows; writes fail with ctx.Err() once ctx is done. A nil limiter returns w.
+func NewThrottledWriter(ctx context.Context, w io.Writer, limiter *RateLimiter) io.Writer {
+	if limiter == nil {
+		return w
+	}
+	return &throttledWriter{ctx: ctx, w: w, limiter: limiter}
+}
+
+func (t *throttledWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		chunk := p[:t.limiter.chunk(len(p))]
+		if err := t.limiter.WaitN(t.ctx, len(chunk)); err != nil {
+			return written, err
+		}
+		n, err := t.w.Write(chunk)
+		written += n
+		if err != nil {
+			return written, err
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewRateLimiter_Unlimited(t *testing.T) {
+	if NewRateLimiter(0) != nil || NewRateLimiter(-1) != nil {
+		t.Error("A zero or negative rate should mean unlimited")
+	}
+
+	r := strings.NewReader("data")
+	if NewThrottledReader(context.Background(), r, nil) != io.Reader(r) {
+		t.Error("An unlimited reader should be returned unchanged")
+	}
+	var buf bytes.Buffer
+	if NewThrottledWriter(context.Background(), &buf, nil) != io.Writer(&buf) {
+		t.Error("An unlimited writer should be returned unchanged")
+	}
+}
+
+func TestThrottledReader_MinimumDuration(t *testing.T) {
+	// 桶内先有一秒的流量，剩余 500 字节需要 0.5 秒
+	data := bytes.Repeat([]byte("x"), 1500)
+	limiter := NewRateLimiter(1000)
+
+	start := time.Now()
+	got, err := io.ReadAll(NewThrottledReader(context.Background(), bytes.NewReader(data), limiter))
+	elapsed := time.Since(start)
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Error("Throttled reader changed the data")
+	}
+	if elapsed < 450*time.Millisecond {
+		t.Errorf("Expected the capped read to take at least ~500ms, took %s", elapsed)
+	}
+	if elapsed > 2*time.Second {
+		t.Errorf("Capped read took too long: %s", elapsed)
+	}
+}
+
+func TestThrottledWriter_MinimumDuration(t *testing.T) {
+	data := bytes.Repeat([]byte("y"), 1500)
+	var buf bytes.Buffer
+	w := NewThrottledWriter(context.Background(), &buf, NewRateLimiter(1000))
+
+	start := time.Now()
+	n, err := w.Write(data)
+	elapsed := time.Since(start)
+	if err != nil || n != len(data) {
+		t.Fatalf("Write returned %d, %v", n, err)
+	}
+	if !bytes.Equal(buf.Bytes(), data) {
+		t.Error("Throttled writer changed the data")
+	}
+	if elapsed < 450*time.Millisecond {
+		t.Errorf("Expected the capped write to take at least ~500ms, took %s", elapsed)
+	}
+}
+
+func TestThrottledReader_KeepsSeeker(t *testing.T) {
+	r := NewThrottledReader(context.Background(), bytes.NewReader([]byte("data")), NewRateLimiter(1000))
+	if _, ok := r.(io.Seeker); !ok {
+		t.Error("Throttling a seekable reader should keep it seekable")
+	}
+	r = NewThrottledReader(context.Background(), io.MultiReader(strings.NewReader("data")), NewRateLimiter(1000))
+	if _, ok := r.(io.Seeker); ok {
+		t.Error("Throttling a plain reader should not make it seekable")
+	}
+}
+
+func TestThrottledWriter_LowLimitCancel(t *testing.T) {
+	// 1 字节/秒时也要能被取消，而不是卡住
+	ctx, cancel := context.WithCancel(context.Background())
+	var buf bytes.Buffer
+	w := NewThrottledWriter(ctx, &buf, NewRateLimiter(1))
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := w.Write([]byte("slow data"))
+		done <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Throttled write did not stop after cancellation")
+	}
+	if buf.String() != "s" {
+		t.Errorf("Expected only the first byte to be written, got %q", buf.String())
+	}
+}
+
+func TestThrottledReader_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r := NewThrottledReader(ctx, strings.NewReader("data"), NewRateLimiter(1))
+	if _, err := r.Read(make([]byte, 4)); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/mingregister/fers/pkg/config"
+	"github.com/mingregister/fers/pkg/crypto"
+	"github.com/mingregister/fers/pkg/dir"
+	"github.com/mingregister/fers/pkg/storage"
+)
+
+// source is one backup set and the file manager working on it
+type source struct {
+	name        string
+	fileManager *dir.FileManager
+}
+
+// newSources creates a file manager for every source in cfg, or a single
+// unnamed one for target_dir when no sources are configured. Sources without
+// their own crypto key use cipher and those without their own storage share
+// one client; each source keeps its remote keys under its name. Sources
+// sharing crypto_key, or using the master key of a keystore, cannot change it
+// with Rekey. The returned close function
+// clears the keys of the ciphers created here.
+func newSources(cfg *config.Config, logger *slog.Logger, cipher crypto.Cipher) ([]source, func(), error) {
+	sharedStorage, err := NewStorageClient(&cfg.Storage)
+	if err != nil {
+		return nil, nil, err
+	}
+	// 密钥库中的主密钥无法通过 Rekey 更换
+	_, useKeystore := keystorePath(cfg.CryptoKeySource)
+	if len(cfg.Sources) == 0 {
+		fileManager := dir.NewFileManager(cfg, sharedStorage, logger, cipher)
+		fileManager.SetKeystoreKey(useKeystore)
+		return []source{{fileManager: fileManager}}, func() {}, nil
+	}
+
+	cipherOptions, err := cfg.CipherOptions()
+	if err != nil {
+		return nil, nil, err
+	}
+	var ciphers []crypto.Cipher
+	closeCiphers := func() {
+		for _, c := range ciphers {
+			if closer, ok := c.(io.Closer); ok {
+				closer.Close()
+			}
+		}
+	}
+
+	// 共用顶层 crypto_key 的备份集不能单独更换密钥
+	sharing := 0
+	for _, s := range cfg.Sources {
+		if s.CryptoKey == "" {
+			sharing++
+		}
+	}
+
+	sources := make([]source, 0, len(cfg.Sources))
+	for _, s := range cfg.Sources {
+		client := sharedStorage
+		if s.Storage != nil {
+			if client, err = NewStorageClient(s.Storage); err != nil {
+				closeCiphers()
+				return nil, nil, err
+			}
+		}
+		sourceCipher := cipher
+		if s.CryptoKey != "" {
+			sourceCipher = crypto.NewAESGCM(s.CryptoKey, cipherOptions...)
+			ciphers = append(ciphers, sourceCipher)
+		}
+
+		// 每个备份集的远程键放在以名称命名的目录下，同名文件互不覆盖
+		fileManager := dir.NewFileManager(cfg.ForSource(s), storage.NewPrefixedClient(client, s.Name), logger, sourceCipher)
+		if s.CryptoKey != "" {
+			fileManager.SetKeySetting(fmt.Sprintf("crypto_key of source %q", s.Name))
+		} else {
+			fileManager.SetSharedCipher(sharing > 1)
+			fileManager.SetKeystoreKey(useKeystore)
+		}
+		sources = append(sources, source{name: s.Name, fileManager: fileManager})
+	}
+	return sources, closeCiphers, nil
+}
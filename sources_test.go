@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mingregister/fers/pkg/config"
+	"github.com/mingregister/fers/pkg/crypto"
+	"github.com/mingregister/fers/pkg/dir"
+)
+
+func TestNewSources_SameNamesDoNotCollide(t *testing.T) {
+	remoteDir := t.TempDir()
+	cfg := newHeadlessConfig("", remoteDir)
+	cfg.Sources = []config.Source{
+		{Name: "documents", TargetDir: t.TempDir()},
+		{Name: "photos", TargetDir: t.TempDir(), CryptoKey: "photos-key"},
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	sources, closeSources, err := newSources(cfg, logger, crypto.NewAESGCM(cfg.CryptoKey))
+	if err != nil {
+		t.Fatalf("newSources failed: %v", err)
+	}
+	defer closeSources()
+	if len(sources) != 2 || sources[0].name != "documents" || sources[1].name != "photos" {
+		t.Fatalf("Unexpected sources %+v", sources)
+	}
+
+	// 两个备份集中有同名文件
+	for _, src := range sources {
+		path := filepath.Join(src.fileManager.GetWorkingDir(), "notes.txt")
+		if err := os.WriteFile(path, []byte("notes of "+src.name), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+		if _, err := src.fileManager.SyncUpload(t.Context()); err != nil {
+			t.Fatalf("SyncUpload of %s failed: %v", src.name, err)
+		}
+	}
+
+	for _, src := range sources {
+		if _, err := os.Stat(filepath.Join(remoteDir, src.name, "notes.txt")); err != nil {
+			t.Errorf("Expected the %s file under its own remote folder: %v", src.name, err)
+		}
+		files, err := src.fileManager.ListRemoteFiles("")
+		if err != nil {
+			t.Fatalf("ListRemoteFiles failed: %v", err)
+		}
+		if len(files) != 1 || files[0] != "notes.txt" {
+			t.Errorf("%s should only see its own files, got %v", src.name, files)
+		}
+
+		restored := filepath.Join(t.TempDir(), "notes.txt")
+		if err := src.fileManager.DownloadAndDecryptFile("notes.txt", restored); err != nil {
+			t.Fatalf("Download of %s failed: %v", src.name, err)
+		}
+		if data, _ := os.ReadFile(restored); !bytes.Equal(data, []byte("notes of "+src.name)) {
+			t.Errorf("%s got another source's content: %q", src.name, data)
+		}
+	}
+}
+
+func TestNewSources_RekeyOnlyWithOwnKey(t *testing.T) {
+	cfg := newHeadlessConfig("", t.TempDir())
+	cfg.Sources = []config.Source{
+		{Name: "documents", TargetDir: t.TempDir()},
+		{Name: "music", TargetDir: t.TempDir()},
+		{Name: "photos", TargetDir: t.TempDir(), CryptoKey: "photos-key"},
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	sources, closeSources, err := newSources(cfg, logger, crypto.NewAESGCM(cfg.CryptoKey))
+	if err != nil {
+		t.Fatalf("newSources failed: %v", err)
+	}
+	defer closeSources()
+
+	// documents 和 music 共用顶层 crypto_key
+	if _, err := sources[0].fileManager.Rekey(t.Context(), "new-key", nil); !errors.Is(err, dir.ErrRekeySharedKey) {
+		t.Errorf("Expected ErrRekeySharedKey for a shared key, got %v", err)
+	}
+	if _, err := sources[2].fileManager.Rekey(t.Context(), "new-key", nil); err != nil {
+		t.Errorf("Rekey of a source with its own key failed: %v", err)
+	}
+	if setting := sources[2].fileManager.KeySetting(); setting != `crypto_key of source "photos"` {
+		t.Errorf("Unexpected key setting %q", setting)
+	}
+}
+
+func TestNewSources_KeystoreKeyCannotBeChanged(t *testing.T) {
+	cfg := newHeadlessConfig("", t.TempDir())
+	cfg.CryptoKeySource = "keystore:" + filepath.Join(t.TempDir(), "keys.json")
+	cfg.Sources = []config.Source{
+		{Name: "documents", TargetDir: t.TempDir()},
+		{Name: "photos", TargetDir: t.TempDir(), CryptoKey: "photos-key"},
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	sources, closeSources, err := newSources(cfg, logger, crypto.NewAESGCM(cfg.CryptoKey))
+	if err != nil {
+		t.Fatalf("newSources failed: %v", err)
+	}
+	defer closeSources()
+
+	if err := sources[0].fileManager.CanRekey(); !errors.Is(err, dir.ErrRekeyKeystore) {
+		t.Errorf("Expected ErrRekeyKeystore for the keystore key, got %v", err)
+	}
+	if err := sources[1].fileManager.CanRekey(); err != nil {
+		t.Errorf("A source with its own key should be re-keyable, got %v", err)
+	}
+}
+
+func TestNewSources_NoSources(t *testing.T) {
+	cfg := newHeadlessConfig(t.TempDir(), t.TempDir())
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	sources, closeSources, err := newSources(cfg, logger, crypto.NewAESGCM(cfg.CryptoKey))
+	if err != nil {
+		t.Fatalf("newSources failed: %v", err)
+	}
+	defer closeSources()
+	if len(sources) != 1 || sources[0].name != "" || sources[0].fileManager.GetWorkingDir() != cfg.TargetDir {
+		t.Errorf("Expected a single unnamed source for target_dir, got %+v", sources)
+	}
+}
+
+func TestRunHeadless_Sources(t *testing.T) {
+	remoteDir := t.TempDir()
+	cfg := newHeadlessConfig("", remoteDir)
+	docsDir, photosDir := t.TempDir(), t.TempDir()
+	cfg.Sources = []config.Source{{Name: "docs", TargetDir: docsDir}, {Name: "photos", TargetDir: photosDir}}
+	for _, d := range []string{docsDir, photosDir} {
+		if err := os.WriteFile(filepath.Join(d, "a.txt"), []byte(d), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+
+	var out bytes.Buffer
+	if code := runHeadless(cfg, headlessOptions{Op: "upload"}, &out); code != exitOK {
+		t.Fatalf("Upload exited with %d:\n%s", code, out.String())
+	}
+	if fields := parseSummaryLine(t, out.String()); fields["uploaded"] != "2" {
+		t.Errorf("Expected both sources to be uploaded, got %v", fields)
+	}
+}